@@ -410,7 +410,10 @@ func (w *AgreementWorker) CommandHandler(command worker.Command) bool {
 		deleteMessage := true
 		proposalAccepted := false
 
-		if msgProtocol, err := abstractprotocol.ExtractProtocol(protocolMsg); err != nil {
+		if exchange.ExtractServiceLogRequest(protocolMsg) != nil {
+			// The governance worker handles the service log requests.
+			deleteMessage = false
+		} else if msgProtocol, err := abstractprotocol.ExtractProtocol(protocolMsg); err != nil {
 			glog.Errorf(logString(fmt.Sprintf("unable to extract agreement protocol name from message %v", protocolMsg)))
 		} else if _, ok := w.producerPH[msgProtocol]; !ok {
 			glog.Infof(logString(fmt.Sprintf("unable to direct exchange message %v to a protocol handler, deleting it.", protocolMsg)))
@@ -755,6 +755,15 @@ func (w *AgreementBotWorker) processProtocolMessage(limit int) {
 				glog.Errorf(fmt.Sprintf("AgreementBotWorker unable to marshal the key from the encrypted message %v, error %v", receivedPubKey, err))
 			} else if bytes.Compare(msg.DevicePubKey, serializedPubKey) != 0 {
 				glog.Errorf(fmt.Sprintf("AgreementBotWorker sender public key from exchange %x is not the same as the sender public key in the encrypted message %x", msg.DevicePubKey, serializedPubKey))
+			} else if resp := exchange.ExtractServiceLogResponse(string(protocolMessage)); resp != nil {
+				// A node answered a service log request of the secure API. Another agbot instance sharing the message queue
+				// might be waiting for it, so the message is left in the exchange until a request takes it or it expires.
+				deleteMessage = false
+				if deliverServiceLogResponse(msg.DeviceId, resp) {
+					DeleteMessage(msg.MsgId, w.GetExchangeId(), w.GetExchangeToken(), w.GetExchangeURL(), w.httpClient)
+				} else {
+					glog.V(5).Infof(fmt.Sprintf("AgreementBotWorker no request waiting for service log response %v", resp))
+				}
 			} else if msgProtocol, err := abstractprotocol.ExtractProtocol(string(protocolMessage)); err != nil {
 				glog.Errorf(fmt.Sprintf("AgreementBotWorker unable to extract agreement protocol name from message %v", protocolMessage))
 			} else if !w.consumerPH.Has(msgProtocol) {
@@ -872,6 +881,7 @@ func (w *AgreementBotWorker) reportWorkQueues() {
 	rep := ""
 	for _, cph := range w.consumerPH.GetAll() {
 		rep += fmt.Sprintf("%v High: %6d, Low: %6d\n", cph, w.consumerPH.Get(cph).WorkQueue().HighPriorityBufferLen(), w.consumerPH.Get(cph).WorkQueue().LowPriorityBufferLen())
+		recordWorkQueueDepth(cph, w.consumerPH.Get(cph).WorkQueue())
 		rep += fmt.Sprintf(w.consumerPH.Get(cph).WorkQueue().queueHistory.report())
 	}
 	glog.V(3).Infof(AWlogString(fmt.Sprintf("Prioritized Work Queues: %v", rep)))
@@ -1337,15 +1347,22 @@ func (w *AgreementBotWorker) generatePolicyFromBusinessPols(msg *events.Exchange
 	for _, org := range businessPolManager.GetAllPolicyOrgs() {
 
 		var exchPolsMetadata map[string]exchange.ExchangeBusinessPolicy
+		var exOrg *exchange.Organization
+		var schema *externalpolicy.PropertySchema
 		var err error
 
 		// check if the org exists on the exchange or not
 		getOrganization := exchange.GetHTTPExchangeOrgHandler(w)
-		if _, err = getOrganization(org); err != nil {
+		if exOrg, err = getOrganization(org); err != nil {
 			// org does not exist is returned as an error
 			glog.V(5).Infof(AWlogString(fmt.Sprintf("unable to get organization %v: %v", org, err)))
 			exchPolsMetadata = make(map[string]exchange.ExchangeBusinessPolicy)
 		} else {
+			// the business policies are checked against the property schema of the org
+			if schema, err = exOrg.GetPropertySchema(); err != nil {
+				glog.Warningf(AWlogString(fmt.Sprintf("unable to get the property schema of organization %v, the business policy properties are not checked against it: %v", org, err)))
+			}
+
 			// Query exchange for all business policies in the org
 			getBusinessPolicies := exchange.GetHTTPBusinessPoliciesHandler(w)
 			if exchPolsMetadata, err = getBusinessPolicies(org, ""); err != nil {
@@ -1354,7 +1371,7 @@ func (w *AgreementBotWorker) generatePolicyFromBusinessPols(msg *events.Exchange
 		}
 
 		// Check for business policy metadata changes and update policies accordingly
-		if err := businessPolManager.UpdatePolicies(org, exchPolsMetadata, w.pm); err != nil {
+		if err := businessPolManager.UpdatePolicies(org, exchPolsMetadata, schema, w.pm); err != nil {
 			return errors.New(fmt.Sprintf("unable to update business policies for org %v, error %v", org, err))
 		}
 
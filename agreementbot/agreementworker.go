@@ -486,6 +486,17 @@ func (b *BaseAgreementWorker) InitiateNewAgreement(cph ConsumerProtocolHandler,
 
 		}
 
+		// If a staged rollout of a new service version is in progress, the node might have to wait for its turn.
+		if wi.ConsumerPolicy.Rollout != nil {
+			if rolloutWL, err := rolloutWorkload(b.db, &wi.ConsumerPolicy, wi.Device.Id, workload); err != nil {
+				glog.Errorf(BAWlogstring(workerId, fmt.Sprintf("error checking the workload rollout of policy %v for device %v, error: %v", wi.ConsumerPolicy.Header.Name, wi.Device.Id, err)))
+				return
+			} else if rolloutWL != workload {
+				glog.V(3).Infof(BAWlogstring(workerId, fmt.Sprintf("device %v gets version %v of policy %v until the workload rollout reaches it", wi.Device.Id, rolloutWL.Version, wi.ConsumerPolicy.Header.Name)))
+				workload = rolloutWL
+			}
+		}
+
 		// If the service is suspended, then do not make an agreement.
 		if found, suspended := exchange.ServiceSuspended(exchangeDev.RegisteredServices, workload.WorkloadURL, workload.Org, workload.Version); found && suspended {
 			glog.Infof(BAWlogstring(workerId, fmt.Sprintf("cannot make agreement with %v for policy %v because service %v version %v is suspended by the user.", wi.Device.Id, wi.ConsumerPolicy.Header.Name, cutil.FormOrgSpecUrl(workload.WorkloadURL, workload.Org), workload.Version)))
@@ -12,6 +12,7 @@ import (
 	"github.com/open-horizon/anax/config"
 	"github.com/open-horizon/anax/events"
 	"github.com/open-horizon/anax/exchange"
+	"github.com/open-horizon/anax/metrics"
 	"github.com/open-horizon/anax/policy"
 	"github.com/open-horizon/anax/worker"
 	"io/ioutil"
@@ -345,6 +346,7 @@ func (a *API) listen(apiListen string) {
 		router.HandleFunc("/status", a.status).Methods("GET", "OPTIONS")
 		router.HandleFunc("/health", a.health).Methods("GET", "OPTIONS")
 		router.HandleFunc("/status/workers", a.workerstatus).Methods("GET", "OPTIONS")
+		router.HandleFunc("/metrics", a.metrics).Methods("GET", "OPTIONS")
 		router.HandleFunc("/node", a.node).Methods("GET", "DELETE", "OPTIONS")
 		router.HandleFunc("/config", a.config).Methods("GET", "OPTIONS")
 		router.HandleFunc("/cache/servedorg", a.ListServedOrgs).Methods("GET", "OPTIONS")
@@ -359,6 +361,8 @@ func (a *API) listen(apiListen string) {
 		router.HandleFunc("/ha/upgradingwlu/{org}/{group_name}/{policy_name}", a.ha_upgrading_wlu).Methods("GET", "DELETE", "OPTIONS")
 		router.HandleFunc("/ha/upgradingnode", a.ha_upgrading_node).Methods("GET", "DELETE", "OPTIONS")
 		router.HandleFunc("/ha/upgradingnode/{org}/{group_name}", a.ha_upgrading_node).Methods("GET", "DELETE", "OPTIONS")
+		router.HandleFunc("/rollout", a.rollout).Methods("GET", "OPTIONS")
+		router.HandleFunc("/rollout/{org}/{name}", a.rollout).Methods("GET", "DELETE", "OPTIONS")
 
 		if err := http.ListenAndServe(apiListen, nocache(router)); err != nil {
 			glog.Fatalf(APIlogString(fmt.Sprintf("failed to start listener on %v, error %v", apiListen, err)))
@@ -685,6 +689,23 @@ func (a *API) health(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (a *API) metrics(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		a.collectMetrics()
+		w.Header().Set("Content-Type", metrics.CONTENT_TYPE)
+		w.WriteHeader(http.StatusOK)
+		if err := metrics.DefaultRegistry.Write(w); err != nil {
+			glog.Error(APIlogString(fmt.Sprintf("error writing metrics, error: %v", err)))
+		}
+	case "OPTIONS":
+		w.Header().Set("Allow", "GET, OPTIONS")
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
 func (a *API) workerstatus(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case "GET":
@@ -924,6 +945,61 @@ func (a *API) ha_upgrading_wlu(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// The staged rollouts of new service versions. Deleting a rollout stops it, the nodes then get the highest priority
+// service version of the policy the next time they make an agreement.
+func (a *API) rollout(w http.ResponseWriter, r *http.Request) {
+
+	glog.V(5).Infof(APIlogString(fmt.Sprintf("Handling %v on workload rollouts.", r.Method)))
+
+	pathVars := mux.Vars(r)
+	orgID := pathVars["org"]
+	name := pathVars["name"]
+
+	switch r.Method {
+	case "GET":
+		var err error
+		var rollouts []persistence.WorkloadRollout
+		if orgID == "" {
+			rollouts, err = a.db.ListAllWorkloadRollouts()
+		} else {
+			var rollout *persistence.WorkloadRollout
+			rollout, err = a.db.GetWorkloadRollout(fmt.Sprintf("%v/%v", orgID, name))
+			if err == nil {
+				if rollout == nil {
+					rollouts = []persistence.WorkloadRollout{}
+				} else {
+					rollouts = []persistence.WorkloadRollout{*rollout}
+				}
+			}
+		}
+
+		if err != nil {
+			glog.Error(APIlogString(fmt.Sprintf("error finding workload rollouts, error: %v", err)))
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		} else {
+			// write output
+			writeResponse(w, rollouts, http.StatusOK)
+		}
+	case "DELETE":
+		if err := a.db.DeleteWorkloadRollout(fmt.Sprintf("%v/%v", orgID, name)); err != nil {
+			glog.Error(APIlogString(fmt.Sprintf("error deleting workload rollout of %v/%v, error: %v", orgID, name, err)))
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		} else {
+			w.WriteHeader(http.StatusOK)
+		}
+
+	case "OPTIONS":
+		if orgID == "" {
+			w.Header().Set("Allow", "GET, OPTIONS")
+		} else {
+			w.Header().Set("Allow", "GET, DELETE, OPTIONS")
+		}
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
 // List all the entries in the ha_group_updates table. They are
 // the nodes that are being upgraded when the node is in a HA group.
 func (a *API) ha_upgrading_node(w http.ResponseWriter, r *http.Request) {
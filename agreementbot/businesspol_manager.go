@@ -535,7 +535,8 @@ func (pm *BusinessPolicyManager) SetCurrentBusinessPolicies(servedPols map[strin
 // For each org that the agbot is supporting, take the set of business policies defined within the org and save them into
 // the BusinessPolicyManager. When new or updated policies are discovered, clear ServicePolicies for that BusinessPolicyEntry so that
 // new businees polices can be filled later.
-func (pm *BusinessPolicyManager) UpdatePolicies(org string, definedPolicies map[string]exchange.ExchangeBusinessPolicy, polManager *policy.PolicyManager) error {
+// The properties of the new and changed business policies are checked against the property schema of the org, if it has one.
+func (pm *BusinessPolicyManager) UpdatePolicies(org string, definedPolicies map[string]exchange.ExchangeBusinessPolicy, schema *externalpolicy.PropertySchema, polManager *policy.PolicyManager) error {
 	pm.polMapLock.Lock()
 	defer pm.polMapLock.Unlock()
 
@@ -582,7 +583,7 @@ func (pm *BusinessPolicyManager) UpdatePolicies(org string, definedPolicies map[
 		if !pm.serveBusinessPolicy(org, exchange.GetId(polId)) {
 			continue
 		}
-		if err := pm.updateBusinessPolicy(org, polId, &pol, polManager); err != nil {
+		if err := pm.updateBusinessPolicy(org, polId, &pol, schema, polManager); err != nil {
 			glog.Errorf("Error updating business policy %v from the org %v in the policy manager. Error: %v", polId, org, err)
 			continue
 		}
@@ -592,7 +593,7 @@ func (pm *BusinessPolicyManager) UpdatePolicies(org string, definedPolicies map[
 }
 
 // Add or update the given service policy. Send an event message if it is updating so that the catcher can re-evaluate the agreements.
-func (pm *BusinessPolicyManager) updateBusinessPolicy(org string, polId string, pol *businesspolicy.BusinessPolicy, polManager *policy.PolicyManager) error {
+func (pm *BusinessPolicyManager) updateBusinessPolicy(org string, polId string, pol *businesspolicy.BusinessPolicy, schema *externalpolicy.PropertySchema, polManager *policy.PolicyManager) error {
 	need_new_entry := true
 	if pm.hasBusinessPolicy(org, exchange.GetId(polId)) {
 		if pe := pm.OrgPolicies[org][exchange.GetId(polId)]; pe != nil {
@@ -607,6 +608,11 @@ func (pm *BusinessPolicyManager) updateBusinessPolicy(org string, polId string,
 			}
 
 			if !bytes.Equal(pe.Hash, newHash) {
+				// a changed policy that does not match the property schema is not served, the previous version is kept
+				if err := checkPropertySchema(polId, pol, schema); err != nil {
+					return err
+				}
+
 				oldPolicy := pe.Policy
 				// update the cache
 				glog.V(5).Infof("Updating policy entry for %v of org %v because it is changed. ", polId, org)
@@ -631,7 +637,9 @@ func (pm *BusinessPolicyManager) updateBusinessPolicy(org string, polId string,
 
 	//If there's no BusinessPolicyEntry yet, create one
 	if need_new_entry {
-		if newPE, err := NewBusinessPolicyEntry(pol, polId); err != nil {
+		if err := checkPropertySchema(polId, pol, schema); err != nil {
+			return err
+		} else if newPE, err := NewBusinessPolicyEntry(pol, polId); err != nil {
 			return errors.New(fmt.Sprintf("unable to create business policy entry for %v, error %v", pol, err))
 		} else {
 			pm.OrgPolicies[org][exchange.GetId(polId)] = newPE
@@ -653,6 +661,23 @@ func (pm *BusinessPolicyManager) updateBusinessPolicy(org string, polId string,
 	return nil
 }
 
+// Check the properties of the business policy against the property schema of its org. The problems are logged as
+// warnings unless the schema is enforced as errors.
+func checkPropertySchema(polId string, pol *businesspolicy.BusinessPolicy, schema *externalpolicy.PropertySchema) error {
+	if schema == nil {
+		return nil
+	}
+
+	warnings, err := schema.CheckProperties(pol.Properties)
+	if err != nil {
+		return errors.New(fmt.Sprintf("business policy %v does not match the property schema of the org: %v", polId, err))
+	}
+	for _, warning := range warnings {
+		glog.Warningf(fmt.Sprintf("Business policy %v property schema check: %v", polId, warning))
+	}
+	return nil
+}
+
 // When an org is removed from the list of supported orgs and business policies, remove the org
 // from the BusinessPolicyManager.
 func (pm *BusinessPolicyManager) deleteOrg(org_in string, polManager *policy.PolicyManager) error {
@@ -279,12 +279,21 @@ func (b *BaseConsumerProtocolHandler) HandlePolicyChanged(cmd *PolicyChangedComm
 		glog.Errorf(BCPHlogstring(b.Name(), fmt.Sprintf("error demarshalling change policy event %v, error: %v", cmd.Msg.PolicyString(), err)))
 	} else {
 
+		// Start a staged rollout if the policy asks for one and a new service version was added.
+		rollout, err := startWorkloadRollout(b.db, eventPol, cmd.Msg.OldPolicy())
+		if err != nil {
+			glog.Errorf(BCPHlogstring(b.Name(), fmt.Sprintf("unable to start the workload rollout for policy %v, error: %v", eventPol.Header.Name, err)))
+		} else if rollout != nil {
+			glog.V(3).Infof(BCPHlogstring(b.Name(), fmt.Sprintf("workload rollout for policy %v: %v", eventPol.Header.Name, rollout.ShortString())))
+		}
+
 		// Cancel related agreements
 		InProgress := func() persistence.AFilter {
 			return func(e persistence.Agreement) bool { return e.AgreementCreationTime != 0 && e.AgreementTimedout == 0 }
 		}
 
 		stillValidAgs := []string{}
+		nodePolHandler := cachedNodePolicyHandler(exchange.GetHTTPNodePolicyHandler(b))
 
 		if agreements, err := b.db.FindAgreements([]persistence.AFilter{persistence.UnarchivedAFilter(), InProgress()}, cph.Name()); err == nil {
 			for _, ag := range agreements {
@@ -302,10 +311,42 @@ func (b *BaseConsumerProtocolHandler) HandlePolicyChanged(cmd *PolicyChangedComm
 					noNewPriority := false
 
 					if ag.Pattern == "" {
-						policyMatches, noNewPriority = b.HandlePolicyChangeForAgreement(ag, cmd.Msg.OldPolicy(), cph)
+						policyMatches, noNewPriority = b.HandlePolicyChangeForAgreement(ag, cmd.Msg.OldPolicy(), nodePolHandler, cph)
 						agStillValid = policyMatches && noNewPriority
 					}
 
+					// Outside of the maintenance windows, a higher priority service version waits for governance to upgrade the node,
+					// either as part of the staged rollout or as a pending upgrade.
+					if policyMatches && !noNewPriority {
+						allowed, err := maintenanceAllows(nodePolHandler, eventPol, ag.DeviceId)
+						if err != nil {
+							glog.Errorf(BCPHlogstring(b.Name(), fmt.Sprintf("unable to check the maintenance schedule for agreement %v, error: %v", ag.CurrentAgreementId, err)))
+						} else if !allowed {
+							glog.V(3).Infof(BCPHlogstring(b.Name(), fmt.Sprintf("agreement %v keeps its service version until the next maintenance window of node %v", ag.CurrentAgreementId, ag.DeviceId)))
+						}
+
+						if err != nil || !allowed {
+							agStillValid = true
+							if rollout != nil && rolloutPreviousWorkload(eventPol, rollout) != nil {
+								if _, err := rolloutAddPendingNode(b.db, eventPol.Header.Name, ag.DeviceId); err != nil {
+									glog.Errorf(BCPHlogstring(b.Name(), fmt.Sprintf("unable to add node %v to the workload rollout of policy %v, error: %v", ag.DeviceId, eventPol.Header.Name, err)))
+								}
+							} else if _, err := b.db.UpdatePendingUpgrade(ag.DeviceId, ag.PolicyName); err != nil {
+								glog.Warningf(BCPHlogstring(b.Name(), fmt.Sprintf("unable to set workloadusage pending for %v using policy %v, error: %v", ag.DeviceId, ag.PolicyName, err)))
+							}
+						}
+					}
+
+					// A higher priority service version waits for its turn in the staged rollout.
+					if !agStillValid && rollout != nil && policyMatches && !noNewPriority && rolloutPreviousWorkload(eventPol, rollout) != nil {
+						if allowed, err := rolloutAllowsUpgrade(b.db, eventPol.Header.Name, ag.DeviceId); err != nil {
+							glog.Errorf(BCPHlogstring(b.Name(), fmt.Sprintf("unable to check the workload rollout of policy %v for agreement %v, error: %v", eventPol.Header.Name, ag.CurrentAgreementId, err)))
+						} else if !allowed {
+							glog.V(3).Infof(BCPHlogstring(b.Name(), fmt.Sprintf("agreement %v keeps its service version until the workload rollout of policy %v reaches node %v", ag.CurrentAgreementId, eventPol.Header.Name, ag.DeviceId)))
+							agStillValid = true
+						}
+					}
+
 					if !agStillValid {
 						glog.Warningf(BCPHlogstring(b.Name(), fmt.Sprintf("agreement %v has a policy %v that has changed incompatibly. Cancelling agreement: %v", ag.CurrentAgreementId, pol.Header.Name, err)))
 						b.CancelAgreement(ag, TERM_REASON_POLICY_CHANGED, cph, policyMatches)
@@ -345,7 +386,7 @@ func (b *BaseConsumerProtocolHandler) HandlePolicyChanged(cmd *PolicyChangedComm
 // first bool is true if the policy still matches, false otherwise
 // second bool is true unless a higher priority workload than the current one has been added or changed
 // if an error occurs, both will be false
-func (b *BaseConsumerProtocolHandler) HandlePolicyChangeForAgreement(ag persistence.Agreement, oldPolicy *policy.Policy, cph ConsumerProtocolHandler) (bool, bool) {
+func (b *BaseConsumerProtocolHandler) HandlePolicyChangeForAgreement(ag persistence.Agreement, oldPolicy *policy.Policy, nodePolHandler exchange.NodePolicyHandler, cph ConsumerProtocolHandler) (bool, bool) {
 	glog.V(5).Infof("attempting to update agreement %v due to change in policy", ag.CurrentAgreementId)
 	svcAllPol := externalpolicy.ExternalPolicy{}
 
@@ -367,7 +408,6 @@ func (b *BaseConsumerProtocolHandler) HandlePolicyChangeForAgreement(ag persiste
 		return false, false
 	}
 
-	nodePolHandler := exchange.GetHTTPNodePolicyHandler(b)
 	_, nodePol, err := compcheck.GetNodePolicy(nodePolHandler, ag.DeviceId, msgPrinter)
 	if err != nil {
 		glog.Errorf(BCPHlogstring(b.Name(), fmt.Sprintf("failed to get node policy for %v from the exchange.", ag.DeviceId)))
@@ -449,6 +489,24 @@ func (b *BaseConsumerProtocolHandler) HandlePolicyChangeForAgreement(ag persiste
 		return false, false
 	}
 
+	// A new service version waits for the next maintenance window of the node, governance upgrades the node then.
+	if deferred, err := workloadChangeDeferred(nodePolHandler, busPol, &ag, wl); err != nil || deferred {
+		if err != nil {
+			glog.Errorf(BCPHlogstring(b.Name(), fmt.Sprintf("unable to check the maintenance schedule for agreement %v, error: %v", ag.CurrentAgreementId, err)))
+		} else {
+			glog.V(3).Infof(BCPHlogstring(b.Name(), fmt.Sprintf("agreement %v keeps its service version until the next maintenance window of node %v", ag.CurrentAgreementId, ag.DeviceId)))
+		}
+		if wlUsage == nil {
+			if err := b.db.NewWorkloadUsage(ag.DeviceId, ag.Policy, ag.PolicyName, 0, 0, 0, false, ag.CurrentAgreementId); err != nil {
+				glog.Warningf(BCPHlogstring(b.Name(), fmt.Sprintf("unable to create workloadusage for %v using policy %v, error: %v", ag.DeviceId, ag.PolicyName, err)))
+			}
+		}
+		if _, err := b.db.UpdatePendingUpgrade(ag.DeviceId, ag.PolicyName); err != nil {
+			glog.Warningf(BCPHlogstring(b.Name(), fmt.Sprintf("unable to set workloadusage pending for %v using policy %v, error: %v", ag.DeviceId, ag.PolicyName, err)))
+		}
+		return true, true
+	}
+
 	ag.LastPolicyUpdateTime = uint64(time.Now().Unix())
 
 	b.UpdateAgreement(&ag, basicprotocol.MsgUpdateTypePolicyChange, newTsCs, cph)
@@ -464,6 +522,10 @@ func (b *BaseConsumerProtocolHandler) HandlePolicyDeleted(cmd *PolicyDeletedComm
 	if eventPol, err := policy.DemarshalPolicy(cmd.Msg.PolicyString()); err != nil {
 		glog.Errorf(BCPHlogstring(b.Name(), fmt.Sprintf("error demarshalling change policy event %v, error: %v", cmd.Msg.PolicyString(), err)))
 	} else {
+		if err := b.db.DeleteWorkloadRollout(eventPol.Header.Name); err != nil {
+			glog.Errorf(BCPHlogstring(b.Name(), fmt.Sprintf("Failed to delete the workload rollout of policy %v, %v", eventPol.Header.Name, err)))
+		}
+
 		if wlu_array, err := b.db.FindWorkloadUsages([]persistence.WUFilter{persistence.PNoAWUFilter(eventPol.Header.Name)}); err != nil {
 			glog.Errorf(BCPHlogstring(b.Name(), fmt.Sprintf("Failed to get the workload usages with policy name: %v, %v", eventPol.Header.Name, err)))
 		} else {
@@ -516,9 +578,10 @@ func (b *BaseConsumerProtocolHandler) HandleServicePolicyChanged(cmd *ServicePol
 	}
 
 	if agreements, err := b.db.FindAgreements([]persistence.AFilter{persistence.UnarchivedAFilter(), InProgress()}, cph.Name()); err == nil {
+		nodePolHandler := cachedNodePolicyHandler(exchange.GetHTTPNodePolicyHandler(b))
 		for _, ag := range agreements {
 			if ag.Pattern == "" && ag.PolicyName == fmt.Sprintf("%v/%v", cmd.Msg.BusinessPolOrg, cmd.Msg.BusinessPolName) && ag.ServiceId[0] == cmd.Msg.ServiceId {
-				policyMatches, noNewPriority := b.HandlePolicyChangeForAgreement(ag, nil, cph)
+				policyMatches, noNewPriority := b.HandlePolicyChangeForAgreement(ag, nil, nodePolHandler, cph)
 				agStillValid := policyMatches && noNewPriority
 				if !agStillValid {
 					glog.Warningf(BCPHlogstring(b.Name(), fmt.Sprintf("agreement %v has a service policy %v that has changed.", ag.CurrentAgreementId, ag.ServiceId)))
@@ -539,9 +602,10 @@ func (b *BaseConsumerProtocolHandler) HandleNodePolicyChanged(cmd *NodePolicyCha
 	}
 
 	if agreements, err := b.db.FindAgreements([]persistence.AFilter{persistence.UnarchivedAFilter(), InProgress()}, cph.Name()); err == nil {
+		nodePolHandler := cachedNodePolicyHandler(exchange.GetHTTPNodePolicyHandler(b))
 		for _, ag := range agreements {
 			if ag.Pattern == "" && ag.DeviceId == cutil.FormOrgSpecUrl(cmd.Msg.NodeId, cmd.Msg.NodePolOrg) {
-				policyMatches, noNewPriority := b.HandlePolicyChangeForAgreement(ag, nil, cph)
+				policyMatches, noNewPriority := b.HandlePolicyChangeForAgreement(ag, nil, nodePolHandler, cph)
 				agStillValid := policyMatches && noNewPriority
 				if !agStillValid {
 					glog.Warningf(BCPHlogstring(b.Name(), fmt.Sprintf("agreement %v has a node policy %v that has changed.", ag.CurrentAgreementId, ag.ServiceId)))
@@ -249,8 +249,14 @@ func (w *AgreementBotWorker) GovernAgreements() int {
 		}
 	}
 
+	// The maintenance schedules of the nodes are checked with the node policies read once in this cycle.
+	nodePolicyHandler := cachedNodePolicyHandler(exchange.GetHTTPNodePolicyHandler(w))
+
 	// Govern the HA partners by examining workload usage records.
-	w.governHAPartners()
+	w.governHAPartners(nodePolicyHandler)
+
+	// Move the staged rollouts of new service versions forward.
+	w.governRollouts(nodePolicyHandler)
 
 	// Dynamically adjust skips to account for long NH check rates.
 	if w.GovTiming.nhSkip == 0 {
@@ -297,7 +303,7 @@ func calculateSkipTime(nhCheckrate uint64, pgi uint64) uint64 {
 //
 //	Table workloadusage is partitioned. So one agbot could only see the workloadusage in
 //	its own partition. Table ha_workload_upgrade is not partitioned.
-func (w *AgreementBotWorker) governHAPartners() {
+func (w *AgreementBotWorker) governHAPartners(nodePolicyHandler exchange.NodePolicyHandler) {
 	// Part A: remove all entries from the ha_workload_upgrade table if the upgrade is done.
 	// Part B: handle workloaduages that has pendingUpdateTime != 0
 	// 1. get all the workload with pendingUpdateTime != 0
@@ -362,6 +368,19 @@ func (w *AgreementBotWorker) governHAPartners() {
 			if glog.V(5) {
 				glog.Infof(logString(fmt.Sprintf("checking for workload usage %v that are waiting for upgrading", wlu.String())))
 			}
+			// The upgrade waits for the next maintenance window of the node and the policy.
+			if pol, err := w.latestPolicy(wlu.PolicyName, wlu.Policy); err != nil {
+				glog.Errorf(logString(fmt.Sprintf("unable to get the policy of workload usage %v, error: %v", wlu.String(), err)))
+				continue
+			} else if allowed, err := maintenanceAllows(nodePolicyHandler, pol, wlu.DeviceId); err != nil {
+				glog.Errorf(logString(fmt.Sprintf("unable to check the maintenance schedule of node %v, error: %v", wlu.DeviceId, err)))
+				continue
+			} else if !allowed {
+				if glog.V(5) {
+					glog.Infof(logString(fmt.Sprintf("workload usage %v waits for the next maintenance window", wlu.String())))
+				}
+				continue
+			}
 			// Setup variables to track the state of the HA group that the current workload usage record belongs to.
 			device, err := GetDevice(w.GetHTTPFactory().NewHTTPClient(nil), wlu.DeviceId, w.GetExchangeURL(), w.GetExchangeId(), w.GetExchangeToken())
 			if err != nil {
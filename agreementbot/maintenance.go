@@ -0,0 +1,78 @@
+package agreementbot
+
+import (
+	"fmt"
+	"github.com/open-horizon/anax/agreementbot/persistence"
+	"github.com/open-horizon/anax/compcheck"
+	"github.com/open-horizon/anax/exchange"
+	"github.com/open-horizon/anax/policy"
+	"time"
+)
+
+// Return true if the service version running on the node can be changed now. Both the maintenance schedule of the
+// deployment policy and the maintenance schedule of the node policy have to allow it.
+func maintenanceAllows(nodePolicyHandler exchange.NodePolicyHandler, pol *policy.Policy, deviceId string) (bool, error) {
+	now := time.Now()
+
+	if allowed, err := pol.Maintenance.IsAllowed(now); err != nil {
+		return false, fmt.Errorf("unable to check the maintenance schedule of policy %v, error: %v", pol.Header.Name, err)
+	} else if !allowed {
+		return false, nil
+	}
+
+	nodePolicy, _, err := compcheck.GetNodePolicy(nodePolicyHandler, deviceId, nil)
+	if err != nil {
+		return false, err
+	} else if nodePolicy == nil {
+		return true, nil
+	}
+
+	if allowed, err := nodePolicy.Maintenance.IsAllowed(now); err != nil {
+		return false, fmt.Errorf("unable to check the maintenance schedule of node %v, error: %v", deviceId, err)
+	} else {
+		return allowed, nil
+	}
+}
+
+// Return a node policy handler that keeps the node policies it reads, so that the agreements of a node that are examined
+// together only read the node policy from the exchange once. A new handler is created for each governance cycle or
+// policy change, so the node policies are not kept longer than that. The handler is not safe for concurrent use.
+func cachedNodePolicyHandler(nodePolicyHandler exchange.NodePolicyHandler) exchange.NodePolicyHandler {
+	nodePolicies := make(map[string]*exchange.ExchangeNodePolicy)
+	return func(deviceId string) (*exchange.ExchangeNodePolicy, error) {
+		if nodePolicy, ok := nodePolicies[deviceId]; ok {
+			return nodePolicy, nil
+		}
+		nodePolicy, err := nodePolicyHandler(deviceId)
+		if err == nil {
+			nodePolicies[deviceId] = nodePolicy
+		}
+		return nodePolicy, err
+	}
+}
+
+// Return true if changing the service of an agreement to the workload has to wait for the next maintenance window. The
+// policy of the agreement has the workload that the node is running, the agreement can be updated right away when the
+// service version does not change.
+func workloadChangeDeferred(nodePolicyHandler exchange.NodePolicyHandler, pol *policy.Policy, ag *persistence.Agreement, wl *policy.Workload) (bool, error) {
+	if agPol, err := policy.DemarshalPolicy(ag.Policy); err != nil {
+		return false, fmt.Errorf("unable to demarshal policy for agreement %v, error: %v", ag.CurrentAgreementId, err)
+	} else if len(agPol.Workloads) != 0 {
+		current := agPol.Workloads[0]
+		if current.WorkloadURL == wl.WorkloadURL && current.Org == wl.Org && current.Version == wl.Version && (current.Arch == wl.Arch || current.Arch == "" || current.Arch == "*") {
+			return false, nil
+		}
+	}
+
+	allowed, err := maintenanceAllows(nodePolicyHandler, pol, ag.DeviceId)
+	return !allowed, err
+}
+
+// Return the latest version of a policy. The policy manager has the latest changes to the policy, the input policy
+// string is the policy as it was when an agreement or a workload usage record last used it.
+func (w *AgreementBotWorker) latestPolicy(policyName string, policyString string) (*policy.Policy, error) {
+	if pol := w.pm.GetPolicy(exchange.GetOrg(policyName), policyName); pol != nil {
+		return pol, nil
+	}
+	return policy.DemarshalPolicy(policyString)
+}
@@ -0,0 +1,130 @@
+//go:build unit
+// +build unit
+
+package agreementbot
+
+import (
+	"errors"
+	"github.com/open-horizon/anax/agreementbot/persistence"
+	"github.com/open-horizon/anax/exchange"
+	"github.com/open-horizon/anax/exchangecommon"
+	"github.com/open-horizon/anax/policy"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_maintenanceAllows(t *testing.T) {
+
+	now := time.Now()
+	current := exchangecommon.TimeWindow{Start: now.Add(-time.Hour).Format(time.RFC3339), End: now.Add(time.Hour).Format(time.RFC3339)}
+	later := exchangecommon.TimeWindow{Start: now.Add(time.Hour).Format(time.RFC3339), End: now.Add(2 * time.Hour).Format(time.RFC3339)}
+
+	nodeMaintenance := map[string]*exchangecommon.MaintenanceSchedule{
+		"myorg/open":     {Windows: []exchangecommon.TimeWindow{current}},
+		"myorg/closed":   {Windows: []exchangecommon.TimeWindow{later}},
+		"myorg/blackout": {Blackouts: []exchangecommon.TimeWindow{current}},
+	}
+	nodePolicyHandler := func(deviceId string) (*exchange.ExchangeNodePolicy, error) {
+		if deviceId == "myorg/nopolicy" {
+			return nil, nil
+		}
+		return &exchange.ExchangeNodePolicy{NodePolicy: exchangecommon.NodePolicy{Maintenance: nodeMaintenance[deviceId]}}, nil
+	}
+
+	pol := policy.Policy_Factory("myorg/mybp")
+
+	// without a policy schedule, the node schedule decides
+	for deviceId, expected := range map[string]bool{"myorg/open": true, "myorg/closed": false, "myorg/blackout": false, "myorg/none": true, "myorg/nopolicy": true} {
+		allowed, err := maintenanceAllows(nodePolicyHandler, pol, deviceId)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, allowed, deviceId)
+	}
+
+	// a policy blackout applies to all the nodes
+	pol.Maintenance = &exchangecommon.MaintenanceSchedule{Blackouts: []exchangecommon.TimeWindow{current}}
+	allowed, err := maintenanceAllows(nodePolicyHandler, pol, "myorg/open")
+	assert.Nil(t, err)
+	assert.False(t, allowed)
+
+	// both schedules have to allow it
+	pol.Maintenance = &exchangecommon.MaintenanceSchedule{Windows: []exchangecommon.TimeWindow{current}}
+	allowed, err = maintenanceAllows(nodePolicyHandler, pol, "myorg/open")
+	assert.Nil(t, err)
+	assert.True(t, allowed)
+	allowed, err = maintenanceAllows(nodePolicyHandler, pol, "myorg/closed")
+	assert.Nil(t, err)
+	assert.False(t, allowed)
+
+	// an invalid schedule does not allow it
+	pol.Maintenance = &exchangecommon.MaintenanceSchedule{Windows: []exchangecommon.TimeWindow{{Schedule: "bad", Duration: 60}}}
+	allowed, err = maintenanceAllows(nodePolicyHandler, pol, "myorg/open")
+	assert.NotNil(t, err)
+	assert.False(t, allowed)
+}
+
+func Test_workloadChangeDeferred(t *testing.T) {
+
+	now := time.Now()
+	later := exchangecommon.TimeWindow{Start: now.Add(time.Hour).Format(time.RFC3339), End: now.Add(2 * time.Hour).Format(time.RFC3339)}
+	nodePolicyHandler := func(deviceId string) (*exchange.ExchangeNodePolicy, error) {
+		if deviceId == "myorg/closed" {
+			return &exchange.ExchangeNodePolicy{NodePolicy: exchangecommon.NodePolicy{Maintenance: &exchangecommon.MaintenanceSchedule{Windows: []exchangecommon.TimeWindow{later}}}}, nil
+		}
+		return nil, nil
+	}
+
+	// the agreement runs version 1.0.0 of a policy without priorities
+	agPol := policy.Policy_Factory("myorg/mybp")
+	agPol.Workloads = append(agPol.Workloads, *policy.Workload_Factory("http://mysvc", "myorg", "1.0.0", "amd64"))
+	agPolString, err := policy.MarshalPolicy(agPol)
+	assert.Nil(t, err)
+
+	pol := policy.Policy_Factory("myorg/mybp")
+	bump := policy.Workload_Factory("http://mysvc", "myorg", "1.1.0", "amd64")
+	same := policy.Workload_Factory("http://mysvc", "myorg", "1.0.0", "amd64")
+
+	// a version bump waits for the maintenance window of the node
+	deferred, err := workloadChangeDeferred(nodePolicyHandler, pol, &persistence.Agreement{CurrentAgreementId: "ag1", DeviceId: "myorg/closed", Policy: agPolString}, bump)
+	assert.Nil(t, err)
+	assert.True(t, deferred)
+
+	deferred, err = workloadChangeDeferred(nodePolicyHandler, pol, &persistence.Agreement{CurrentAgreementId: "ag2", DeviceId: "myorg/open", Policy: agPolString}, bump)
+	assert.Nil(t, err)
+	assert.False(t, deferred)
+
+	// other policy changes update the agreement right away
+	deferred, err = workloadChangeDeferred(nodePolicyHandler, pol, &persistence.Agreement{CurrentAgreementId: "ag1", DeviceId: "myorg/closed", Policy: agPolString}, same)
+	assert.Nil(t, err)
+	assert.False(t, deferred)
+
+	// a policy blackout applies to the version bump too
+	pol.Maintenance = &exchangecommon.MaintenanceSchedule{Blackouts: []exchangecommon.TimeWindow{{Start: now.Add(-time.Hour).Format(time.RFC3339), End: now.Add(time.Hour).Format(time.RFC3339)}}}
+	deferred, err = workloadChangeDeferred(nodePolicyHandler, pol, &persistence.Agreement{CurrentAgreementId: "ag2", DeviceId: "myorg/open", Policy: agPolString}, bump)
+	assert.Nil(t, err)
+	assert.True(t, deferred)
+}
+
+func Test_cachedNodePolicyHandler(t *testing.T) {
+
+	reads := map[string]int{}
+	nodePolicyHandler := cachedNodePolicyHandler(func(deviceId string) (*exchange.ExchangeNodePolicy, error) {
+		reads[deviceId]++
+		if deviceId == "myorg/error" {
+			return nil, errors.New("exchange unavailable")
+		} else if deviceId == "myorg/nopolicy" {
+			return nil, nil
+		}
+		return &exchange.ExchangeNodePolicy{}, nil
+	})
+
+	// each node policy is read once, errors are not kept
+	for i := 0; i < 3; i++ {
+		for _, deviceId := range []string{"myorg/node1", "myorg/nopolicy", "myorg/error"} {
+			nodePolicy, err := nodePolicyHandler(deviceId)
+			assert.Equal(t, deviceId == "myorg/error", err != nil, deviceId)
+			assert.Equal(t, deviceId == "myorg/node1", nodePolicy != nil, deviceId)
+		}
+	}
+	assert.Equal(t, map[string]int{"myorg/node1": 1, "myorg/nopolicy": 1, "myorg/error": 3}, reads)
+}
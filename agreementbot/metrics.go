@@ -0,0 +1,95 @@
+package agreementbot
+
+import (
+	"fmt"
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/metrics"
+)
+
+// The metrics exported by the agbot on its /metrics API. The work queue metrics are updated as work flows through
+// the queues. The database and secrets provider metrics are gauges which are recomputed each time the endpoint
+// is scraped, so they always reflect the current state of the database shared by all agbot instances.
+const AGBOT_WORKQUEUE_DEPTH = "agbot_workqueue_depth"
+const AGBOT_WORKQUEUE_ITEMS = "agbot_workqueue_items_total"
+const AGBOT_AGREEMENTS = "agbot_agreements"
+const AGBOT_WORKLOAD_USAGES = "agbot_workload_usages"
+const AGBOT_PARTITION_OWNER = "agbot_partition_owner"
+const AGBOT_DB_HEARTBEAT = "agbot_db_last_heartbeat_timestamp_seconds"
+const AGBOT_SECRETS_READY = "agbot_secrets_provider_ready"
+const AGBOT_VAULT_INTERACTION = "agbot_secrets_last_vault_interaction_timestamp_seconds"
+
+func init() {
+	r := metrics.DefaultRegistry
+	r.NewGauge(AGBOT_WORKQUEUE_DEPTH, "Number of buffered agreement work items waiting for a worker, by agreement protocol and priority.")
+	r.NewCounter(AGBOT_WORKQUEUE_ITEMS, "Number of agreement work items that moved through the prioritized work queue, by priority and direction.")
+	r.NewGauge(AGBOT_AGREEMENTS, "Number of agreements in the agbot database, by partition and state.")
+	r.NewGauge(AGBOT_WORKLOAD_USAGES, "Number of workload usage records in the agbot database, by partition.")
+	r.NewGauge(AGBOT_PARTITION_OWNER, "Set to 1 for each database partition and the agbot instance that owns it.")
+	r.NewGauge(AGBOT_DB_HEARTBEAT, "Unix time of the last database heartbeat of this agbot instance.")
+	r.NewGauge(AGBOT_SECRETS_READY, "Set to 1 when the secrets provider is ready to use, 0 otherwise.")
+	r.NewGauge(AGBOT_VAULT_INTERACTION, "Unix time of the last successful interaction with the secrets provider.")
+}
+
+// Update the work queue depth gauges for an agreement protocol.
+func recordWorkQueueDepth(protocol string, q *PrioritizedWorkQueue) {
+	metrics.SetGauge(AGBOT_WORKQUEUE_DEPTH, metrics.Labels{"protocol": protocol, "priority": HIGH_PRIORITY}, float64(q.HighPriorityBufferLen()))
+	metrics.SetGauge(AGBOT_WORKQUEUE_DEPTH, metrics.Labels{"protocol": protocol, "priority": LOW_PRIORITY}, float64(q.LowPriorityBufferLen()))
+}
+
+// Accumulate a work queue statistics record into the work queue counters.
+func recordWorkQueueStats(s *PrioritizedWorkQueueStats) {
+	metrics.AddCounter(AGBOT_WORKQUEUE_ITEMS, metrics.Labels{"priority": HIGH_PRIORITY, "direction": "inbound"}, float64(s.numInboundHigh))
+	metrics.AddCounter(AGBOT_WORKQUEUE_ITEMS, metrics.Labels{"priority": HIGH_PRIORITY, "direction": "dispatched"}, float64(s.numHighBuffered))
+	metrics.AddCounter(AGBOT_WORKQUEUE_ITEMS, metrics.Labels{"priority": LOW_PRIORITY, "direction": "inbound"}, float64(s.numInboundLow))
+	metrics.AddCounter(AGBOT_WORKQUEUE_ITEMS, metrics.Labels{"priority": LOW_PRIORITY, "direction": "dispatched"}, float64(s.numLowBuffered))
+}
+
+// Recompute the gauges that are derived from the database and the secrets provider. Errors are logged and the
+// affected gauges are left out of the output rather than failing the whole scrape.
+func (a *API) collectMetrics() {
+
+	r := metrics.DefaultRegistry
+	r.ResetFamily(AGBOT_AGREEMENTS)
+	r.ResetFamily(AGBOT_WORKLOAD_USAGES)
+	r.ResetFamily(AGBOT_PARTITION_OWNER)
+
+	if partitions, err := a.db.FindPartitions(); err != nil {
+		glog.Errorf(APIlogString(fmt.Sprintf("unable to collect partition metrics, error: %v", err)))
+	} else {
+		for _, p := range partitions {
+			if owner, err := a.db.GetPartitionOwner(p); err != nil {
+				glog.Errorf(APIlogString(fmt.Sprintf("unable to collect owner metric for partition %v, error: %v", p, err)))
+			} else {
+				r.SetGauge(AGBOT_PARTITION_OWNER, metrics.Labels{"partition": p, "owner": owner}, 1)
+			}
+
+			if active, archived, err := a.db.GetAgreementCount(p); err != nil {
+				glog.Errorf(APIlogString(fmt.Sprintf("unable to collect agreement metrics for partition %v, error: %v", p, err)))
+			} else {
+				r.SetGauge(AGBOT_AGREEMENTS, metrics.Labels{"partition": p, "state": "active"}, float64(active))
+				r.SetGauge(AGBOT_AGREEMENTS, metrics.Labels{"partition": p, "state": "archived"}, float64(archived))
+			}
+
+			if num, err := a.db.GetWorkloadUsagesCount(p); err != nil {
+				glog.Errorf(APIlogString(fmt.Sprintf("unable to collect workload usage metrics for partition %v, error: %v", p, err)))
+			} else {
+				r.SetGauge(AGBOT_WORKLOAD_USAGES, metrics.Labels{"partition": p}, float64(num))
+			}
+		}
+	}
+
+	if hb, err := a.db.GetHeartbeat(); err != nil {
+		glog.Errorf(APIlogString(fmt.Sprintf("unable to collect DB heartbeat metric, error: %v", err)))
+	} else {
+		r.SetGauge(AGBOT_DB_HEARTBEAT, nil, float64(hb))
+	}
+
+	if a.secretProvider != nil {
+		ready := 0.0
+		if a.secretProvider.IsReady() {
+			ready = 1
+		}
+		r.SetGauge(AGBOT_SECRETS_READY, nil, ready)
+		r.SetGauge(AGBOT_VAULT_INTERACTION, nil, float64(a.secretProvider.GetLastVaultStatus()))
+	}
+}
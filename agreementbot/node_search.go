@@ -31,14 +31,24 @@ type NodeSearch struct {
 	lastSearchComplete   bool
 	lastSearchTime       uint64
 	searchThread         chan bool
-	rescanLock           sync.Mutex      // The lock that protects the rescanNeeded flag. The rescanNeeded flag can be checked/changed on different threads.
-	rescanNeeded         bool            // A broad indicator that something policy or pattern related changed, and therefore the agbot needs to rescan all nodes.
-	batchSize            uint64          // The max number of nodes that this object will process in a deployment policy search result.
-	activeDeviceTimeoutS int             // The amount of time a device can go without heartbeating and still be considered active for the purposes of search.
-	retryLookBack        uint64          // The amount of time to look backward for node changes when node retries are happening.
-	policyOrder          bool            // When true, order policies most recently changed to least recently changed.
-	clearExchangeCache   bool            // When true, the exchange cache will be deleted after a seach is made with devices returned.
-	completedSearches    map[string]bool //Keeps track of the patterns/policies that have been searched to eliminate rescans until all are searched
+	rescanLock           sync.Mutex                  // The lock that protects the rescanNeeded flag. The rescanNeeded flag can be checked/changed on different threads.
+	rescanNeeded         bool                        // A broad indicator that something policy or pattern related changed, and therefore the agbot needs to rescan all nodes.
+	batchSize            uint64                      // The max number of nodes that this object will process in a deployment policy search result.
+	activeDeviceTimeoutS int                         // The amount of time a device can go without heartbeating and still be considered active for the purposes of search.
+	retryLookBack        uint64                      // The amount of time to look backward for node changes when node retries are happening.
+	policyOrder          bool                        // When true, order policies most recently changed to least recently changed.
+	clearExchangeCache   bool                        // When true, the exchange cache will be deleted after a seach is made with devices returned.
+	completedSearches    map[string]bool             //Keeps track of the patterns/policies that have been searched to eliminate rescans until all are searched
+	placementSkipped     map[string]bool             // The deployment policies that left compatible nodes out of the last search because of their maxNodes limit
+	placementSearches    map[string]*placementSearch // The nodes returned so far by the search sessions of the deployment policies with a weighted placement
+}
+
+// The pages of a deployment policy search session are gathered before the nodes are ranked, so that the policy is placed
+// on the highest scoring nodes of the whole search result, not on the nodes of the first page.
+type placementSearch struct {
+	devices           []exchange.SearchResultDevice
+	ids               map[string]bool
+	polLastUpdateTime uint64 // the policy update time used for every page of the search session
 }
 
 func NewNodeSearch() *NodeSearch {
@@ -51,6 +61,8 @@ func NewNodeSearch() *NodeSearch {
 		rescanNeeded:        false,
 		clearExchangeCache:  false,
 		completedSearches:   make(map[string]bool),
+		placementSkipped:    make(map[string]bool),
+		placementSearches:   make(map[string]*placementSearch),
 	}
 	return ns
 }
@@ -278,6 +290,21 @@ func (n *NodeSearch) searchNodesAndMakeAgreements(consumerPolicy *policy.Policy,
 
 	endOfResults := true
 
+	// The nodes that were left out of a previous search because of the maxNodes limit are not returned by the exchange
+	// again unless they change. When the policy has room for more nodes, search all the nodes again so that they are ranked again.
+	// The nodes that were never left out either have an agreement or are not compatible, so ranking the nodes that changed
+	// since the last search is enough otherwise.
+	ps := n.placementSearches[consumerPolicy.Header.Name]
+	if ps != nil {
+		polLastUpdateTime = ps.polLastUpdateTime
+	} else if n.placementSkipped[consumerPolicy.Header.Name] {
+		if _, agreementNodes := n.findPolicyAgreements(consumerPolicy); consumerPolicy.Placement.IsEmpty() || len(agreementNodes) < consumerPolicy.Placement.MaxNodes {
+			glog.V(3).Infof(AWlogString(fmt.Sprintf("searching all nodes for %v, it has room for more nodes", consumerPolicy.Header.Name)))
+			polLastUpdateTime = uint64(time.Now().Unix())
+			delete(n.placementSkipped, consumerPolicy.Header.Name)
+		}
+	}
+
 	if devices, err := n.searchExchange(consumerPolicy, org, polName, polLastUpdateTime); err != nil {
 		glog.Errorf(AWlogString(fmt.Sprintf("received error searching for %v, error: %v", consumerPolicy, err)))
 		return endOfResults, err
@@ -289,33 +316,44 @@ func (n *NodeSearch) searchNodesAndMakeAgreements(consumerPolicy *policy.Policy,
 			endOfResults = false
 		}
 
-		// Get all the agreements for this policy that are still active.
-		pendingAgreementFilter := func() persistence.AFilter {
-			return func(a persistence.Agreement) bool {
-				return a.PolicyName == consumerPolicy.Header.Name && a.AgreementTimedout == 0
+		// A deployment policy with a weighted placement only makes agreements with its highest scoring nodes, so the
+		// nodes are not ranked until the last page of the search session has been returned.
+		if !consumerPolicy.Placement.IsEmpty() && consumerPolicy.PatternId == "" {
+			if ps == nil {
+				ps = &placementSearch{polLastUpdateTime: polLastUpdateTime}
 			}
+			ps.add(*devices)
+			if !endOfResults {
+				glog.V(3).Infof(AWlogString(fmt.Sprintf("gathered %v nodes for %v, waiting for the rest of the search session", len(ps.devices), consumerPolicy.Header.Name)))
+				n.placementSearches[consumerPolicy.Header.Name] = ps
+				return endOfResults, nil
+			}
+			delete(n.placementSearches, consumerPolicy.Header.Name)
+			devices = &ps.devices
 		}
 
-		ags := make(map[string][]persistence.Agreement)
-
-		// The agreements with this policy could be part of any supported agreement protocol.
-		for _, agp := range policy.AllAgreementProtocols() {
-			// Find all agreements that are in progress. They might be waiting for a reply or not yet finalized.
-			// TODO: To support more than 1 agreement (maxagreements > 1) with this device for this policy, we need to adjust this logic.
-			if agreements, err := n.db.FindAgreements([]persistence.AFilter{persistence.UnarchivedAFilter(), pendingAgreementFilter()}, agp); err != nil {
-				glog.Errorf(AWlogString(fmt.Sprintf("received error trying to find pending agreements for protocol %v: %v", agp, err)))
-			} else {
-				ags[agp] = agreements
-			}
+		if len(*devices) == 0 {
+			return endOfResults, nil
 		}
 
 		// For each Scan(), clear the cache only once when there are devices returned from the search api.
-		if n.clearExchangeCache && len(*devices) != 0 {
+		if n.clearExchangeCache {
 			glog.V(5).Infof("Clearing cache for all resources.")
 			exchange.ClearAllResourceCache()
 			n.clearExchangeCache = false
 		}
 
+		// Get all the agreements for this policy that are still active.
+		ags, agreementNodes := n.findPolicyAgreements(consumerPolicy)
+
+		if !consumerPolicy.Placement.IsEmpty() {
+			ranked, skipped := rankByPlacement(*devices, consumerPolicy, agreementNodes, exchange.GetHTTPNodePolicyHandler(n.ec), exchange.GetHTTPServicePolicyWithIdHandler(n.ec))
+			if skipped {
+				n.placementSkipped[consumerPolicy.Header.Name] = true
+			}
+			devices = &ranked
+		}
+
 		for _, dev := range *devices {
 
 			glog.V(3).Infof(AWlogString(fmt.Sprintf("picked up %v for policy %v.", dev.ShortString(), consumerPolicy.Header.Name)))
@@ -374,6 +412,48 @@ func (n *NodeSearch) searchNodesAndMakeAgreements(consumerPolicy *policy.Policy,
 
 }
 
+// Return the agreements with the policy that are still active, keyed by agreement protocol, and the nodes they are with.
+func (n *NodeSearch) findPolicyAgreements(consumerPolicy *policy.Policy) (map[string][]persistence.Agreement, map[string]bool) {
+
+	pendingAgreementFilter := func() persistence.AFilter {
+		return func(a persistence.Agreement) bool {
+			return a.PolicyName == consumerPolicy.Header.Name && a.AgreementTimedout == 0
+		}
+	}
+
+	ags := make(map[string][]persistence.Agreement)
+	agreementNodes := make(map[string]bool)
+
+	// The agreements with this policy could be part of any supported agreement protocol.
+	for _, agp := range policy.AllAgreementProtocols() {
+		// Find all agreements that are in progress. They might be waiting for a reply or not yet finalized.
+		// TODO: To support more than 1 agreement (maxagreements > 1) with this device for this policy, we need to adjust this logic.
+		if agreements, err := n.db.FindAgreements([]persistence.AFilter{persistence.UnarchivedAFilter(), pendingAgreementFilter()}, agp); err != nil {
+			glog.Errorf(AWlogString(fmt.Sprintf("received error trying to find pending agreements for protocol %v: %v", agp, err)))
+		} else {
+			ags[agp] = agreements
+			for _, ag := range agreements {
+				agreementNodes[ag.DeviceId] = true
+			}
+		}
+	}
+	return ags, agreementNodes
+}
+
+// Add a page of search results. A node is only added once, the exchange can return it again when the search session
+// gets out of sync.
+func (p *placementSearch) add(devices []exchange.SearchResultDevice) {
+	if p.ids == nil {
+		p.ids = make(map[string]bool)
+	}
+	for _, dev := range devices {
+		if !p.ids[dev.Id] {
+			p.ids[dev.Id] = true
+			p.devices = append(p.devices, dev)
+		}
+	}
+}
+
 // Check all agreement protocol buckets to see if there are any agreements with this device.
 // Return true if there is already an agreement for this node and policy. The input list of agreements has already been filtered to
 // include only agreements using the input policy.
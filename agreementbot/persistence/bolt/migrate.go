@@ -0,0 +1,84 @@
+package bolt
+
+import (
+	"errors"
+	"fmt"
+	"github.com/boltdb/bolt"
+	"github.com/open-horizon/anax/config"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// Functions used when migrating the contents of a bolt database to another database implementation. They are not part
+// of the agbot database interface because they only make sense for an existing bolt database that is no longer in use
+// by a running agbot.
+
+// Open an existing bolt database without initializing anything in it. The Initialize method resets the search session, which
+// would lose the state that a migration needs to carry over. Bolt holds an exclusive lock on the database file while it is
+// open, so this will fail (after a timeout) if an agbot is still using the database.
+func (db *AgbotBoltDB) OpenExisting(cfg *config.HorizonConfig) error {
+
+	dbname := path.Join(cfg.AgreementBot.DBPath, BOLTDB_DATABASE_NAME)
+
+	if _, err := os.Stat(dbname); err != nil {
+		return errors.New(fmt.Sprintf("unable to find bolt database %v, error: %v", dbname, err))
+	} else if agdb, err := bolt.Open(dbname, 0600, &bolt.Options{Timeout: 10 * time.Second}); err != nil {
+		return errors.New(fmt.Sprintf("unable to open bolt database %v, error: %v", dbname, err))
+	} else {
+		db.db = agdb
+	}
+
+	return nil
+}
+
+// Return the names of all the top level buckets in the database.
+func (db *AgbotBoltDB) ListBuckets() ([]string, error) {
+
+	buckets := make([]string, 0, 10)
+
+	readErr := db.db.View(func(tx *bolt.Tx) error {
+		return tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+			buckets = append(buckets, string(name))
+			return nil
+		})
+	})
+
+	if readErr != nil {
+		return nil, readErr
+	}
+	return buckets, nil
+}
+
+// Return the agreement protocols that have an agreement bucket in the database.
+func (db *AgbotBoltDB) ListAgreementProtocols() ([]string, error) {
+
+	protocols := make([]string, 0, 2)
+
+	if buckets, err := db.ListBuckets(); err != nil {
+		return nil, err
+	} else {
+		for _, b := range buckets {
+			if strings.HasPrefix(b, AGREEMENTS+"-") {
+				protocols = append(protocols, strings.TrimPrefix(b, AGREEMENTS+"-"))
+			}
+		}
+	}
+	return protocols, nil
+}
+
+// Return the one and only search session object.
+func (db *AgbotBoltDB) GetSearchSession() (*SearchSession, error) {
+	return db.findSearchSession()
+}
+
+// Return all the secrets used by deployment policies.
+func (db *AgbotBoltDB) ListManagedPolicySecrets() ([]ManagedSecret, error) {
+	return db.findManagedSecrets(SECRETS_POLICY_BUCKET, func(s ManagedSecret) bool { return true })
+}
+
+// Return all the secrets used by patterns.
+func (db *AgbotBoltDB) ListManagedPatternSecrets() ([]ManagedSecret, error) {
+	return db.findManagedSecrets(SECRETS_PATTERN_BUCKET, func(s ManagedSecret) bool { return true })
+}
@@ -1,53 +1,240 @@
 package bolt
 
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/boltdb/bolt"
+	"github.com/golang/glog"
+)
+
+const SECRETS_POLICY_BUCKET = "secrets_policy"   // The bolt DB bucket name for the secrets used by deployment policies.
+const SECRETS_PATTERN_BUCKET = "secrets_pattern" // The bolt DB bucket name for the secrets used by patterns.
+
+// A secret that is used by a deployment policy or a pattern, and the last time the agbot checked it for updates.
+type ManagedSecret struct {
+	SecretOrg       string `json:"secret_org"`
+	SecretName      string `json:"secret_name"`
+	Org             string `json:"org"`  // The org of the deployment policy or pattern.
+	Name            string `json:"name"` // The name of the deployment policy or pattern.
+	LastUpdateCheck int64  `json:"last_update_check"`
+}
+
+func (s ManagedSecret) String() string {
+	return fmt.Sprintf("SecretOrg: %v, SecretName: %v, Org: %v, Name: %v, LastUpdateCheck: %v", s.SecretOrg, s.SecretName, s.Org, s.Name, s.LastUpdateCheck)
+}
+
 func (db *AgbotBoltDB) AddManagedPolicySecret(secretOrg, secretName, policyOrg, policyName string, updateTime int64) error {
-	return nil
+	return db.addManagedSecret(SECRETS_POLICY_BUCKET, ManagedSecret{SecretOrg: secretOrg, SecretName: secretName, Org: policyOrg, Name: policyName, LastUpdateCheck: updateTime})
 }
 
 func (db *AgbotBoltDB) GetManagedPolicySecretNames(policyOrg, policyName string) ([]string, error) {
-	return []string{}, nil
+	return db.getManagedSecretNames(SECRETS_POLICY_BUCKET, policyOrg, policyName)
 }
 
 func (db *AgbotBoltDB) GetPoliciesWithUpdatedSecrets(secretOrg, secretName string, lastUpdate int64) ([]string, error) {
-	return []string{}, nil
+	return db.getUpdatedSecrets(SECRETS_POLICY_BUCKET, secretOrg, secretName, lastUpdate)
 }
 
 func (db *AgbotBoltDB) SetSecretUpdate(secretOrg, secretName string, secretUpdateTime int64) error {
+	for _, bucket := range []string{SECRETS_POLICY_BUCKET, SECRETS_PATTERN_BUCKET} {
+		if err := db.setSecretUpdate(bucket, secretOrg, secretName, secretUpdateTime); err != nil {
+			return fmt.Errorf("error updating secret %s/%s in %v: %v", secretOrg, secretName, bucket, err)
+		}
+	}
 	return nil
 }
 
 func (db *AgbotBoltDB) GetPoliciesInOrg(org string) ([]string, error) {
-	return []string{}, nil
+	return db.getDeploymentInOrg(SECRETS_POLICY_BUCKET, org)
 }
 
 func (db *AgbotBoltDB) DeleteSecretsForPolicy(polOrg, polName string) error {
-	return nil
+	return db.deleteManagedSecrets(SECRETS_POLICY_BUCKET, func(s ManagedSecret) bool { return s.Org == polOrg && s.Name == polName })
 }
 
 func (db *AgbotBoltDB) DeletePolicySecret(secretOrg, secretName, policyOrg, policyName string) error {
-	return nil
+	return db.deleteManagedSecrets(SECRETS_POLICY_BUCKET, func(s ManagedSecret) bool {
+		return s.SecretOrg == secretOrg && s.SecretName == secretName && s.Org == policyOrg && s.Name == policyName
+	})
 }
 
 func (db *AgbotBoltDB) AddManagedPatternSecret(secretOrg, secretName, policyOrg, policyName string, updateTime int64) error {
-	return nil
+	return db.addManagedSecret(SECRETS_PATTERN_BUCKET, ManagedSecret{SecretOrg: secretOrg, SecretName: secretName, Org: policyOrg, Name: policyName, LastUpdateCheck: updateTime})
 }
 
 func (db *AgbotBoltDB) GetManagedPatternSecretNames(policyOrg, policyName string) ([]string, error) {
-	return []string{}, nil
+	return db.getManagedSecretNames(SECRETS_PATTERN_BUCKET, policyOrg, policyName)
 }
 
 func (db *AgbotBoltDB) GetPatternsWithUpdatedSecrets(secretOrg, secretName string, lastUpdate int64) ([]string, error) {
-	return []string{}, nil
+	return db.getUpdatedSecrets(SECRETS_PATTERN_BUCKET, secretOrg, secretName, lastUpdate)
 }
 
 func (db *AgbotBoltDB) GetPatternsInOrg(org string) ([]string, error) {
-	return []string{}, nil
+	return db.getDeploymentInOrg(SECRETS_PATTERN_BUCKET, org)
 }
 
 func (db *AgbotBoltDB) DeleteSecretsForPattern(polOrg, polName string) error {
-	return nil
+	return db.deleteManagedSecrets(SECRETS_PATTERN_BUCKET, func(s ManagedSecret) bool { return s.Org == polOrg && s.Name == polName })
 }
 
 func (db *AgbotBoltDB) DeletePatternSecret(secretOrg, secretName, policyOrg, policyName string) error {
-	return nil
+	return db.deleteManagedSecrets(SECRETS_PATTERN_BUCKET, func(s ManagedSecret) bool {
+		return s.SecretOrg == secretOrg && s.SecretName == secretName && s.Org == policyOrg && s.Name == policyName
+	})
+}
+
+// The key of a managed secret record. The names can contain slashes, so the key is the JSON encoding of the names.
+func managedSecretKey(s ManagedSecret) []byte {
+	key, _ := json.Marshal([]string{s.SecretOrg, s.SecretName, s.Org, s.Name})
+	return key
+}
+
+// Add the managed secret record unless it is already there, in which case its last update check is not changed.
+func (db *AgbotBoltDB) addManagedSecret(bucket string, secret ManagedSecret) error {
+	return db.db.Update(func(tx *bolt.Tx) error {
+		if b, err := tx.CreateBucketIfNotExists([]byte(bucket)); err != nil {
+			return err
+		} else if key := managedSecretKey(secret); b.Get(key) != nil {
+			return nil
+		} else if serialized, err := json.Marshal(secret); err != nil {
+			return fmt.Errorf("Failed to serialize managed secret record: %v. Error: %v", secret, err)
+		} else if err := b.Put(key, serialized); err != nil {
+			return fmt.Errorf("Failed to write managed secret record %v. Error: %v", secret, err)
+		} else {
+			glog.V(2).Infof("Succeeded creating managed secret record %v in %v", secret, bucket)
+			return nil
+		}
+	})
+}
+
+// Return the managed secret records in the bucket that pass the filter.
+func (db *AgbotBoltDB) findManagedSecrets(bucket string, filter func(ManagedSecret) bool) ([]ManagedSecret, error) {
+	secrets := make([]ManagedSecret, 0)
+
+	readErr := db.db.View(func(tx *bolt.Tx) error {
+		if b := tx.Bucket([]byte(bucket)); b != nil {
+			return b.ForEach(func(k, v []byte) error {
+				var s ManagedSecret
+				if err := json.Unmarshal(v, &s); err != nil {
+					return fmt.Errorf("Unable to deserialize managed secret record %v: %v", string(v), err)
+				} else if filter(s) {
+					secrets = append(secrets, s)
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+
+	if readErr != nil {
+		return nil, readErr
+	}
+	return secrets, nil
+}
+
+// Return the unique org qualified names of the secrets used by the deployment policy or pattern, or by all of them when
+// the org is empty.
+func (db *AgbotBoltDB) getManagedSecretNames(bucket string, org string, name string) ([]string, error) {
+	secrets, err := db.findManagedSecrets(bucket, func(s ManagedSecret) bool { return org == "" || (s.Org == org && s.Name == name) })
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(secrets))
+	found := make(map[string]bool)
+	for _, s := range secrets {
+		if fullName := fmt.Sprintf("%s/%s", s.SecretOrg, s.SecretName); !found[fullName] {
+			names = append(names, fullName)
+			found[fullName] = true
+		}
+	}
+	return names, nil
+}
+
+// Return the unique org qualified names of the deployment policies or patterns that use the secret, when the secret has been
+// updated since they last checked it.
+func (db *AgbotBoltDB) getUpdatedSecrets(bucket string, secretOrg string, secretName string, lastUpdate int64) ([]string, error) {
+	secrets, err := db.findManagedSecrets(bucket, func(s ManagedSecret) bool {
+		return s.SecretOrg == secretOrg && s.SecretName == secretName && s.LastUpdateCheck < lastUpdate
+	})
+	if err != nil {
+		return nil, err
+	}
+	return uniqueDeploymentNames(secrets), nil
+}
+
+// Return the unique org qualified names of the deployment policies or patterns in the org that use a secret.
+func (db *AgbotBoltDB) getDeploymentInOrg(bucket string, org string) ([]string, error) {
+	secrets, err := db.findManagedSecrets(bucket, func(s ManagedSecret) bool { return s.Org == org })
+	if err != nil {
+		return nil, err
+	}
+	return uniqueDeploymentNames(secrets), nil
+}
+
+func uniqueDeploymentNames(secrets []ManagedSecret) []string {
+	names := make([]string, 0, len(secrets))
+	found := make(map[string]bool)
+	for _, s := range secrets {
+		if fullName := fmt.Sprintf("%s/%s", s.Org, s.Name); !found[fullName] {
+			names = append(names, fullName)
+			found[fullName] = true
+		}
+	}
+	return names
+}
+
+func (db *AgbotBoltDB) setSecretUpdate(bucket string, secretOrg string, secretName string, secretUpdateTime int64) error {
+	return db.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucket))
+		if b == nil {
+			return nil
+		}
+
+		updated := make([]ManagedSecret, 0)
+		if err := b.ForEach(func(k, v []byte) error {
+			var s ManagedSecret
+			if err := json.Unmarshal(v, &s); err != nil {
+				return fmt.Errorf("Unable to deserialize managed secret record %v: %v", string(v), err)
+			} else if s.SecretOrg == secretOrg && s.SecretName == secretName {
+				s.LastUpdateCheck = secretUpdateTime
+				updated = append(updated, s)
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		// The bucket is not modified while it is iterated.
+		for _, s := range updated {
+			if serialized, err := json.Marshal(s); err != nil {
+				return fmt.Errorf("Failed to serialize managed secret record: %v. Error: %v", s, err)
+			} else if err := b.Put(managedSecretKey(s), serialized); err != nil {
+				return fmt.Errorf("Failed to write managed secret record %v. Error: %v", s, err)
+			}
+		}
+		glog.V(2).Infof("Succeeded setting update time in %v records for %s/%s", len(updated), secretOrg, secretName)
+		return nil
+	})
+}
+
+func (db *AgbotBoltDB) deleteManagedSecrets(bucket string, filter func(ManagedSecret) bool) error {
+	secrets, err := db.findManagedSecrets(bucket, filter)
+	if err != nil {
+		return err
+	} else if len(secrets) == 0 {
+		return nil
+	}
+
+	return db.db.Update(func(tx *bolt.Tx) error {
+		if b := tx.Bucket([]byte(bucket)); b != nil {
+			for _, s := range secrets {
+				if err := b.Delete(managedSecretKey(s)); err != nil {
+					return fmt.Errorf("Unable to delete managed secret record %v: %v", s, err)
+				}
+			}
+		}
+		return nil
+	})
 }
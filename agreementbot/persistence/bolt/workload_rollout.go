@@ -0,0 +1,132 @@
+package bolt
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/boltdb/bolt"
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/agreementbot/persistence"
+)
+
+const WORKLOAD_ROLLOUT_BUCKET = "workload_rollouts"
+
+func (db *AgbotBoltDB) GetWorkloadRollout(policyName string) (*persistence.WorkloadRollout, error) {
+	var pr *persistence.WorkloadRollout
+
+	readErr := db.db.View(func(tx *bolt.Tx) error {
+		if b := tx.Bucket([]byte(WORKLOAD_ROLLOUT_BUCKET)); b != nil {
+			if v := b.Get([]byte(policyName)); v != nil {
+				var r persistence.WorkloadRollout
+				if err := json.Unmarshal(v, &r); err != nil {
+					return fmt.Errorf("Failed to deserialize workload rollout record: %v. Error: %v", string(v), err)
+				}
+				pr = &r
+			}
+		}
+		return nil // end the transaction
+	})
+
+	if readErr != nil {
+		return nil, readErr
+	}
+	return pr, nil
+}
+
+func (db *AgbotBoltDB) ListAllWorkloadRollouts() ([]persistence.WorkloadRollout, error) {
+	rollouts := make([]persistence.WorkloadRollout, 0)
+
+	readErr := db.db.View(func(tx *bolt.Tx) error {
+		if b := tx.Bucket([]byte(WORKLOAD_ROLLOUT_BUCKET)); b != nil {
+			return b.ForEach(func(k, v []byte) error {
+				var r persistence.WorkloadRollout
+				if err := json.Unmarshal(v, &r); err != nil {
+					return fmt.Errorf("Failed to deserialize workload rollout record: %v. Error: %v", string(v), err)
+				}
+				rollouts = append(rollouts, r)
+				return nil
+			})
+		}
+		return nil
+	})
+
+	return rollouts, readErr
+}
+
+// Insert the rollout if there is none for the policy yet, or replace the existing one if it rolls out a different version.
+// The rollout in the database is returned, which is the existing one when it rolls out the same version.
+func (db *AgbotBoltDB) InsertWorkloadRollout(rollout *persistence.WorkloadRollout) (*persistence.WorkloadRollout, error) {
+	result := rollout
+	dbErr := db.db.Update(func(tx *bolt.Tx) error {
+		if b, err := tx.CreateBucketIfNotExists([]byte(WORKLOAD_ROLLOUT_BUCKET)); err != nil {
+			return err
+		} else {
+			if current := b.Get([]byte(rollout.PolicyName)); current != nil {
+				var mod persistence.WorkloadRollout
+				if err := json.Unmarshal(current, &mod); err != nil {
+					return fmt.Errorf("Failed to unmarshal workload rollout DB data: %v. Error: %v", string(current), err)
+				} else if mod.Version == rollout.Version {
+					result = &mod
+					return nil
+				}
+			}
+
+			if serialized, err := json.Marshal(rollout); err != nil {
+				return fmt.Errorf("Failed to serialize workload rollout record: %v. Error: %v", rollout, err)
+			} else if err := b.Put([]byte(rollout.PolicyName), serialized); err != nil {
+				return fmt.Errorf("Failed to write workload rollout for %v. Error: %v", rollout.PolicyName, err)
+			}
+			glog.V(2).Infof("Succeeded inserting workload rollout %v", rollout.ShortString())
+			return nil
+		}
+	})
+
+	if dbErr != nil {
+		return nil, dbErr
+	}
+	return result, nil
+}
+
+// Update the rollout of a policy. If the update function returns nil, the rollout is not changed.
+func (db *AgbotBoltDB) SingleWorkloadRolloutUpdate(policyName string, fn func(persistence.WorkloadRollout) *persistence.WorkloadRollout) (*persistence.WorkloadRollout, error) {
+	var result *persistence.WorkloadRollout
+	dbErr := db.db.Update(func(tx *bolt.Tx) error {
+		if b, err := tx.CreateBucketIfNotExists([]byte(WORKLOAD_ROLLOUT_BUCKET)); err != nil {
+			return err
+		} else {
+			current := b.Get([]byte(policyName))
+			var mod persistence.WorkloadRollout
+			if current == nil {
+				return fmt.Errorf("No workload rollout with key available to update: %v", policyName)
+			} else if err := json.Unmarshal(current, &mod); err != nil {
+				return fmt.Errorf("Failed to unmarshal workload rollout DB data: %v. Error: %v", string(current), err)
+			}
+
+			result = fn(mod)
+			if result == nil {
+				result = &mod
+				return nil
+			}
+
+			if serialized, err := json.Marshal(result); err != nil {
+				return fmt.Errorf("Failed to serialize workload rollout record: %v. Error: %v", result, err)
+			} else if err := b.Put([]byte(policyName), serialized); err != nil {
+				return fmt.Errorf("Failed to write workload rollout with key: %v. Error: %v", policyName, err)
+			}
+			return nil
+		}
+	})
+
+	if dbErr != nil {
+		return nil, dbErr
+	}
+	return result, nil
+}
+
+func (db *AgbotBoltDB) DeleteWorkloadRollout(policyName string) error {
+	return db.db.Update(func(tx *bolt.Tx) error {
+		if b := tx.Bucket([]byte(WORKLOAD_ROLLOUT_BUCKET)); b != nil {
+			return b.Delete([]byte(policyName))
+		}
+		return nil
+	})
+}
@@ -111,4 +111,11 @@ type AgbotDatabase interface {
 	GetHAUpgradingWorkload(org string, haGroupName string, policyName string) (*UpgradingHAGroupWorkload, error)
 	UpdateHAUpgradingWorkloadForGroupAndPolicy(org string, haGroupName string, policyName string, deviceId string) error
 	InsertHAUpgradingWorkloadForGroupAndPolicy(org string, haGroupName string, policyName string, deviceId string) (string, error)
+
+	// Functions related to persistence of the staged rollouts of new service versions.
+	GetWorkloadRollout(policyName string) (*WorkloadRollout, error)
+	ListAllWorkloadRollouts() ([]WorkloadRollout, error)
+	InsertWorkloadRollout(rollout *WorkloadRollout) (*WorkloadRollout, error)
+	SingleWorkloadRolloutUpdate(policyName string, fn func(WorkloadRollout) *WorkloadRollout) (*WorkloadRollout, error)
+	DeleteWorkloadRollout(policyName string) error
 }
@@ -0,0 +1,563 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/agreementbot/persistence"
+	"github.com/open-horizon/anax/agreementbot/persistence/bolt"
+	"github.com/open-horizon/anax/agreementbot/persistence/postgresql"
+	"github.com/open-horizon/anax/agreementbot/persistence/sqlite"
+	"github.com/open-horizon/anax/config"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// This package copies the contents of an agbot bolt database into a postgresql database. The bolt database is read from
+// AgreementBot.DBPath and the records are written through the postgresql provider configured in AgreementBot.Postgresql.
+// The bolt database holds an exclusive file lock while it is open, so the agbot that owns it must be stopped before the
+// migration is run. Agbots that are already using the postgresql database can keep running; the migrated records are
+// written into a partition owned by the migration, which is quiesced at the end so that a running agbot adopts it (via
+// the normal partition move) as soon as it notices. A bolt database can be copied into a sqlite database in the same way.
+
+const AGREEMENTS = "agreements"
+const WORKLOAD_USAGES = "workload usages"
+const SEARCH_SESSIONS = "search sessions"
+const SECRETS = "managed secrets"
+const HA_NODES = "ha upgrading nodes"
+const HA_WORKLOADS = "ha upgrading workloads"
+const ROLLOUTS = "workload rollouts"
+
+// The result of migrating (or counting, in dry run mode) one kind of record.
+type Count struct {
+	Source   int // The number of records found in the bolt database.
+	Migrated int // The number of records written into the target database.
+	Skipped  int // The number of records that were already present in the target database.
+	Target   int // The number of records found in the target database by the verification pass.
+}
+
+func (c Count) String() string {
+	return fmt.Sprintf("source: %v, migrated: %v, skipped: %v, target: %v", c.Source, c.Migrated, c.Skipped, c.Target)
+}
+
+type Report struct {
+	DryRun         bool
+	Partition      string
+	Counts         map[string]*Count
+	UnknownBuckets []string // Bolt buckets that the migration does not know how to handle.
+	Errors         []string // Verification failures.
+}
+
+func (r Report) String() string {
+	res := fmt.Sprintf("DryRun: %v, Partition: %v", r.DryRun, r.Partition)
+	for _, k := range []string{AGREEMENTS, WORKLOAD_USAGES, SEARCH_SESSIONS, SECRETS, HA_NODES, HA_WORKLOADS, ROLLOUTS} {
+		if c, ok := r.Counts[k]; ok {
+			res += fmt.Sprintf("\n  %v: %v", k, c)
+		}
+	}
+	if len(r.UnknownBuckets) != 0 {
+		res += fmt.Sprintf("\n  unknown bolt buckets not migrated: %v", strings.Join(r.UnknownBuckets, ", "))
+	}
+	for _, e := range r.Errors {
+		res += fmt.Sprintf("\n  verification error: %v", e)
+	}
+	return res
+}
+
+func (r *Report) Verified() bool {
+	return len(r.Errors) == 0
+}
+
+func newReport(dryRun bool) *Report {
+	r := &Report{
+		DryRun: dryRun,
+		Counts: make(map[string]*Count),
+		Errors: make([]string, 0),
+	}
+	for _, k := range []string{AGREEMENTS, WORKLOAD_USAGES, SEARCH_SESSIONS, SECRETS, HA_NODES, HA_WORKLOADS, ROLLOUTS} {
+		r.Counts[k] = new(Count)
+	}
+	return r
+}
+
+// The database that the bolt records are copied into. The import functions are not part of the agbot database interface
+// because they are only used by the migration.
+type Target interface {
+	persistence.AgbotDatabase
+	PrimaryPartition() string
+	HeartbeatMigrationPartition() error
+	ImportAgreement(ag *persistence.Agreement, protocol string) (bool, error)
+	ImportWorkloadUsage(wu *persistence.WorkloadUsage) (bool, error)
+	SearchSessionExists(policyName string) (bool, error)
+}
+
+// The records read from the bolt database.
+type boltRecords struct {
+	protocols      []string
+	agreements     map[string][]persistence.Agreement
+	policies       map[string]bool
+	wus            []persistence.WorkloadUsage
+	session        *bolt.SearchSession
+	policySecrets  []bolt.ManagedSecret
+	patternSecrets []bolt.ManagedSecret
+	haNodes        []persistence.UpgradingHAGroupNode
+	haWorkloads    []persistence.UpgradingHAGroupWorkload
+	rollouts       []persistence.WorkloadRollout
+}
+
+// Copy all the records in the bolt database into the input postgresql partition. If the partition is empty, a new (or
+// stale) partition is used. In dry run mode, the bolt database is read and the records are counted, but the postgresql
+// database is not touched.
+func BoltToPostgresql(cfg *config.HorizonConfig, partition string, dryRun bool) (*Report, error) {
+
+	if !cfg.IsBoltDBConfigured() {
+		return nil, errors.New(fmt.Sprintf("AgreementBot.DBPath must be configured to locate the bolt database"))
+	} else if !dryRun && !cfg.IsPostgresqlConfigured() {
+		return nil, errors.New(fmt.Sprintf("AgreementBot.Postgresql must be configured to migrate the bolt database"))
+	}
+
+	return boltToTarget(cfg, dryRun, func() (Target, error) {
+		target := new(postgresql.AgbotPostgresqlDB)
+		if err := target.InitializeWithPartition(cfg, partition); err != nil {
+			return nil, err
+		}
+		return target, nil
+	})
+}
+
+// Copy all the records in the bolt database into a partition of the sqlite database configured in AgreementBot.Sqlite,
+// in the same way as BoltToPostgresql.
+func BoltToSqlite(cfg *config.HorizonConfig, dryRun bool) (*Report, error) {
+
+	if !cfg.IsBoltDBConfigured() {
+		return nil, errors.New(fmt.Sprintf("AgreementBot.DBPath must be configured to locate the bolt database"))
+	} else if !dryRun && !cfg.IsSqliteConfigured() {
+		return nil, errors.New(fmt.Sprintf("AgreementBot.Sqlite must be configured to migrate the bolt database"))
+	}
+
+	return boltToTarget(cfg, dryRun, func() (Target, error) {
+		target := new(sqlite.AgbotSqliteDB)
+		if err := target.Initialize(cfg); err != nil {
+			return nil, err
+		}
+		return target, nil
+	})
+}
+
+// Read the bolt database and copy its records into the database returned by openTarget, which is not called in dry run
+// mode.
+func boltToTarget(cfg *config.HorizonConfig, dryRun bool, openTarget func() (Target, error)) (*Report, error) {
+
+	report := newReport(dryRun)
+
+	source := new(bolt.AgbotBoltDB)
+	if err := source.OpenExisting(cfg); err != nil {
+		return nil, err
+	}
+	defer source.Close()
+
+	// Read everything from bolt first, so that a problem with the source is found before anything is written.
+	records, err := readBolt(source, report)
+	if err != nil {
+		return nil, err
+	} else if dryRun {
+		return report, nil
+	}
+
+	target, err := openTarget()
+	if err != nil {
+		return nil, err
+	}
+	defer target.Close()
+	report.Partition = target.PrimaryPartition()
+
+	glog.V(3).Infof("Migrating bolt database into partition %v", report.Partition)
+
+	// Keep the partition heartbeat fresh while the migration is running, so that no other agbot takes it over. If it is
+	// taken over anyway, the migration stops writing into it.
+	hb := startHeartbeat(target, cfg.GetPartitionStale())
+	defer hb.stop()
+
+	if err := copyRecords(target, records, report, hb); err != nil {
+		return report, err
+	} else if err := verify(target, report, records); err != nil {
+		return report, err
+	} else if err := hb.failed(); err != nil {
+		return report, err
+	}
+
+	// Give up the partition so that a running agbot moves the migrated records into its own partition.
+	if err := target.QuiescePartition(); err != nil {
+		return report, errors.New(fmt.Sprintf("unable to quiesce partition %v, error: %v", report.Partition, err))
+	}
+
+	return report, nil
+}
+
+// Read all the records in the bolt database and count them in the report.
+func readBolt(source *bolt.AgbotBoltDB, report *Report) (*boltRecords, error) {
+
+	var err error
+	records := &boltRecords{
+		agreements: make(map[string][]persistence.Agreement),
+		policies:   make(map[string]bool),
+	}
+
+	records.protocols, err = source.ListAgreementProtocols()
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to list agreement protocols, error: %v", err))
+	}
+
+	for _, protocol := range records.protocols {
+		if ags, err := source.FindAgreements([]persistence.AFilter{}, protocol); err != nil {
+			return nil, errors.New(fmt.Sprintf("unable to read %v agreements, error: %v", protocol, err))
+		} else {
+			records.agreements[protocol] = ags
+			report.Counts[AGREEMENTS].Source += len(ags)
+			for _, ag := range ags {
+				if ag.PolicyName != "" {
+					records.policies[ag.PolicyName] = true
+				}
+			}
+		}
+	}
+
+	records.wus, err = source.FindWorkloadUsages([]persistence.WUFilter{})
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to read workload usages, error: %v", err))
+	}
+	report.Counts[WORKLOAD_USAGES].Source = len(records.wus)
+
+	// The bolt implementation keeps a single search session for all policies, so it is applied to the search session of
+	// each policy that has an agreement.
+	records.session, err = source.GetSearchSession()
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to read search session, error: %v", err))
+	}
+	if records.session != nil {
+		report.Counts[SEARCH_SESSIONS].Source = len(records.policies)
+	}
+
+	records.policySecrets, err = source.ListManagedPolicySecrets()
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to read managed policy secrets, error: %v", err))
+	}
+	records.patternSecrets, err = source.ListManagedPatternSecrets()
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to read managed pattern secrets, error: %v", err))
+	}
+	report.Counts[SECRETS].Source = len(records.policySecrets) + len(records.patternSecrets)
+
+	records.haNodes, err = source.ListAllUpgradingHANode()
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to read ha upgrading nodes, error: %v", err))
+	}
+	report.Counts[HA_NODES].Source = len(records.haNodes)
+
+	records.haWorkloads, err = source.ListAllHAUpgradingWorkloads()
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to read ha upgrading workloads, error: %v", err))
+	}
+	report.Counts[HA_WORKLOADS].Source = len(records.haWorkloads)
+
+	records.rollouts, err = source.ListAllWorkloadRollouts()
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to read workload rollouts, error: %v", err))
+	}
+	report.Counts[ROLLOUTS].Source = len(records.rollouts)
+
+	if buckets, err := source.ListBuckets(); err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to list buckets, error: %v", err))
+	} else {
+		report.UnknownBuckets = unknownBuckets(buckets)
+	}
+
+	return records, nil
+}
+
+// Write the bolt records into the target partition. The copy stops as soon as the partition heartbeat fails, so that
+// nothing is written into a partition that another agbot has taken over.
+func copyRecords(target Target, records *boltRecords, report *Report, hb *heartbeat) error {
+
+	for _, protocol := range records.protocols {
+		for _, ag := range records.agreements[protocol] {
+			if err := hb.failed(); err != nil {
+				return err
+			} else if inserted, err := target.ImportAgreement(&ag, protocol); err != nil {
+				return errors.New(fmt.Sprintf("unable to migrate agreement %v, error: %v", ag.CurrentAgreementId, err))
+			} else if inserted {
+				report.Counts[AGREEMENTS].Migrated += 1
+			} else {
+				report.Counts[AGREEMENTS].Skipped += 1
+			}
+		}
+	}
+
+	for _, wu := range records.wus {
+		if err := hb.failed(); err != nil {
+			return err
+		} else if inserted, err := target.ImportWorkloadUsage(&wu); err != nil {
+			return errors.New(fmt.Sprintf("unable to migrate workload usage %v, error: %v", wu.ShortString(), err))
+		} else if inserted {
+			report.Counts[WORKLOAD_USAGES].Migrated += 1
+		} else {
+			report.Counts[WORKLOAD_USAGES].Skipped += 1
+		}
+	}
+
+	if records.session != nil {
+		for policyName := range records.policies {
+			if err := hb.failed(); err != nil {
+				return err
+			} else if _, _, err := target.ObtainSearchSession(policyName); err != nil {
+				return errors.New(fmt.Sprintf("unable to create search session for %v, error: %v", policyName, err))
+			} else if err := target.ResetPolicyChangedSince(policyName, records.session.ChangedSince); err != nil {
+				return errors.New(fmt.Sprintf("unable to migrate search session for %v, error: %v", policyName, err))
+			}
+			report.Counts[SEARCH_SESSIONS].Migrated += 1
+		}
+	}
+
+	for _, sec := range records.policySecrets {
+		if err := hb.failed(); err != nil {
+			return err
+		} else if found, err := secretInTarget(target.GetPoliciesWithUpdatedSecrets, sec); err != nil {
+			return errors.New(fmt.Sprintf("unable to read managed policy secret %v, error: %v", sec, err))
+		} else if found {
+			report.Counts[SECRETS].Skipped += 1
+		} else if err := target.AddManagedPolicySecret(sec.SecretOrg, sec.SecretName, sec.Org, sec.Name, sec.LastUpdateCheck); err != nil {
+			return errors.New(fmt.Sprintf("unable to migrate managed policy secret %v, error: %v", sec, err))
+		} else {
+			report.Counts[SECRETS].Migrated += 1
+		}
+	}
+
+	for _, sec := range records.patternSecrets {
+		if err := hb.failed(); err != nil {
+			return err
+		} else if found, err := secretInTarget(target.GetPatternsWithUpdatedSecrets, sec); err != nil {
+			return errors.New(fmt.Sprintf("unable to read managed pattern secret %v, error: %v", sec, err))
+		} else if found {
+			report.Counts[SECRETS].Skipped += 1
+		} else if err := target.AddManagedPatternSecret(sec.SecretOrg, sec.SecretName, sec.Org, sec.Name, sec.LastUpdateCheck); err != nil {
+			return errors.New(fmt.Sprintf("unable to migrate managed pattern secret %v, error: %v", sec, err))
+		} else {
+			report.Counts[SECRETS].Migrated += 1
+		}
+	}
+
+	for _, n := range records.haNodes {
+		if err := hb.failed(); err != nil {
+			return err
+		} else if current, err := target.CheckIfGroupPresentAndUpdateHATable(n); err != nil {
+			return errors.New(fmt.Sprintf("unable to migrate ha upgrading node %v, error: %v", n, err))
+		} else if current != nil && *current == n {
+			report.Counts[HA_NODES].Migrated += 1
+		} else {
+			report.Counts[HA_NODES].Skipped += 1
+		}
+	}
+
+	for _, w := range records.haWorkloads {
+		if err := hb.failed(); err != nil {
+			return err
+		} else if current, err := target.InsertHAUpgradingWorkloadForGroupAndPolicy(w.OrgId, w.GroupName, w.PolicyName, w.NodeId); err != nil {
+			return errors.New(fmt.Sprintf("unable to migrate ha upgrading workload %v, error: %v", w, err))
+		} else if current == w.NodeId {
+			report.Counts[HA_WORKLOADS].Migrated += 1
+		} else {
+			report.Counts[HA_WORKLOADS].Skipped += 1
+		}
+	}
+
+	for _, r := range records.rollouts {
+		if err := hb.failed(); err != nil {
+			return err
+		} else if current, err := target.GetWorkloadRollout(r.PolicyName); err != nil {
+			return errors.New(fmt.Sprintf("unable to read workload rollout for %v, error: %v", r.PolicyName, err))
+		} else if current != nil && current.Version == r.Version {
+			report.Counts[ROLLOUTS].Skipped += 1
+		} else if _, err := target.InsertWorkloadRollout(&r); err != nil {
+			return errors.New(fmt.Sprintf("unable to migrate workload rollout %v, error: %v", r.ShortString(), err))
+		} else {
+			report.Counts[ROLLOUTS].Migrated += 1
+		}
+	}
+
+	return nil
+}
+
+// Return true if the managed secret is already recorded for its deployment policy or pattern in the target partition. The
+// time of the last update check is ignored by asking for the deployments that have not checked the secret until the end
+// of time.
+func secretInTarget(getDeployments func(secretOrg, secretName string, lastUpdate int64) ([]string, error), sec bolt.ManagedSecret) (bool, error) {
+	if names, err := getDeployments(sec.SecretOrg, sec.SecretName, math.MaxInt64); err != nil {
+		return false, err
+	} else {
+		for _, name := range names {
+			if name == fmt.Sprintf("%s/%s", sec.Org, sec.Name) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// Compare the number of records in the target database with the number of records read from bolt. Agreements, workload
+// usages and managed secrets are counted in the migration partition. Search sessions, HA upgrade and rollout rows are not
+// partitioned, so each migrated row has to be found in the target.
+func verify(target Target, report *Report, records *boltRecords) error {
+
+	if active, archived, err := target.GetAgreementCount(report.Partition); err != nil {
+		return errors.New(fmt.Sprintf("unable to verify agreements, error: %v", err))
+	} else {
+		report.Counts[AGREEMENTS].Target = int(active + archived)
+	}
+
+	if num, err := target.GetWorkloadUsagesCount(report.Partition); err != nil {
+		return errors.New(fmt.Sprintf("unable to verify workload usages, error: %v", err))
+	} else {
+		report.Counts[WORKLOAD_USAGES].Target = int(num)
+	}
+
+	for policyName := range records.policies {
+		if found, err := target.SearchSessionExists(policyName); err != nil {
+			return errors.New(fmt.Sprintf("unable to verify search sessions, error: %v", err))
+		} else if found {
+			report.Counts[SEARCH_SESSIONS].Target += 1
+		}
+	}
+
+	for _, sec := range records.policySecrets {
+		if found, err := secretInTarget(target.GetPoliciesWithUpdatedSecrets, sec); err != nil {
+			return errors.New(fmt.Sprintf("unable to verify managed policy secrets, error: %v", err))
+		} else if found {
+			report.Counts[SECRETS].Target += 1
+		}
+	}
+
+	for _, sec := range records.patternSecrets {
+		if found, err := secretInTarget(target.GetPatternsWithUpdatedSecrets, sec); err != nil {
+			return errors.New(fmt.Sprintf("unable to verify managed pattern secrets, error: %v", err))
+		} else if found {
+			report.Counts[SECRETS].Target += 1
+		}
+	}
+
+	if nodes, err := target.ListAllUpgradingHANode(); err != nil {
+		return errors.New(fmt.Sprintf("unable to verify ha upgrading nodes, error: %v", err))
+	} else {
+		for _, n := range records.haNodes {
+			for _, tn := range nodes {
+				if n == tn {
+					report.Counts[HA_NODES].Target += 1
+					break
+				}
+			}
+		}
+	}
+
+	if workloads, err := target.ListAllHAUpgradingWorkloads(); err != nil {
+		return errors.New(fmt.Sprintf("unable to verify ha upgrading workloads, error: %v", err))
+	} else {
+		for _, w := range records.haWorkloads {
+			for _, tw := range workloads {
+				if w == tw {
+					report.Counts[HA_WORKLOADS].Target += 1
+					break
+				}
+			}
+		}
+	}
+
+	if targetRollouts, err := target.ListAllWorkloadRollouts(); err != nil {
+		return errors.New(fmt.Sprintf("unable to verify workload rollouts, error: %v", err))
+	} else {
+		for _, r := range records.rollouts {
+			for _, tr := range targetRollouts {
+				if r.PolicyName == tr.PolicyName && r.Version == tr.Version {
+					report.Counts[ROLLOUTS].Target += 1
+					break
+				}
+			}
+		}
+	}
+
+	for k, c := range report.Counts {
+		if c.Target < c.Migrated+c.Skipped {
+			report.Errors = append(report.Errors, fmt.Sprintf("%v: expected at least %v records in the target, found %v", k, c.Migrated+c.Skipped, c.Target))
+		} else if (k == AGREEMENTS || k == WORKLOAD_USAGES || k == SECRETS) && c.Target < c.Source {
+			report.Errors = append(report.Errors, fmt.Sprintf("%v: expected %v records in the target, found %v", k, c.Source, c.Target))
+		}
+	}
+
+	return nil
+}
+
+// Return the bolt buckets that are not read by the migration.
+func unknownBuckets(buckets []string) []string {
+	known := map[string]bool{
+		bolt.WORKLOAD_USAGE:           true,
+		bolt.SEARCH_SESSION_BUCKET:    true,
+		bolt.HABUCKET:                 true,
+		bolt.HA_WORKLOAD_USAGE_BUCKET: true,
+		bolt.WORKLOAD_ROLLOUT_BUCKET:  true,
+		bolt.SECRETS_POLICY_BUCKET:    true,
+		bolt.SECRETS_PATTERN_BUCKET:   true,
+	}
+
+	res := make([]string, 0)
+	for _, b := range buckets {
+		if !known[b] && !strings.HasPrefix(b, bolt.AGREEMENTS+"-") {
+			res = append(res, b)
+		}
+	}
+	return res
+}
+
+// Heartbeats the migration partition in the background and remembers why it failed, if it does.
+type heartbeat struct {
+	done chan bool
+	lock sync.Mutex
+	err  error
+}
+
+func startHeartbeat(target Target, stale uint64) *heartbeat {
+	hb := &heartbeat{done: make(chan bool)}
+	interval := time.Duration(stale/3+1) * time.Second
+
+	go func() {
+		for {
+			select {
+			case <-hb.done:
+				return
+			case <-time.After(interval):
+				if err := target.HeartbeatMigrationPartition(); err != nil {
+					glog.Errorf(fmt.Sprintf("unable to heartbeat migration partition, error: %v", err))
+					hb.lock.Lock()
+					hb.err = err
+					hb.lock.Unlock()
+					return
+				}
+			}
+		}
+	}()
+
+	return hb
+}
+
+func (hb *heartbeat) stop() {
+	close(hb.done)
+}
+
+// Return the reason why the migration has to stop, if the partition heartbeat has failed.
+func (hb *heartbeat) failed() error {
+	hb.lock.Lock()
+	defer hb.lock.Unlock()
+	if hb.err != nil {
+		return errors.New(fmt.Sprintf("migration aborted, the partition heartbeat failed, error: %v", hb.err))
+	}
+	return nil
+}
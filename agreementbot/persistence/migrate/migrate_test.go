@@ -0,0 +1,186 @@
+//go:build unit
+// +build unit
+
+package migrate
+
+import (
+	"github.com/open-horizon/anax/agreementbot/persistence"
+	"github.com/open-horizon/anax/agreementbot/persistence/bolt"
+	"github.com/open-horizon/anax/agreementbot/persistence/sqlite"
+	"github.com/open-horizon/anax/config"
+	"github.com/open-horizon/anax/policy"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"testing"
+	"time"
+)
+
+func Test_BoltToSqlite(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "utmigrate-")
+	if err != nil {
+		t.Fatalf("Error creating the database directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	cfg := &config.HorizonConfig{
+		AgreementBot: config.AGConfig{
+			DBPath: path.Join(dir, "bolt"),
+			Sqlite: config.SqliteConfig{DBPath: path.Join(dir, "sqlite")},
+		},
+	}
+	populateBolt(t, cfg)
+
+	// A dry run counts the bolt records without creating the target database.
+	if report, err := BoltToSqlite(cfg, true); err != nil {
+		t.Fatalf("Error in dry run: %v", err)
+	} else {
+		checkCounts(t, report, func(c *Count) int { return c.Source })
+		if _, err := os.Stat(cfg.AgreementBot.Sqlite.DBPath); err == nil {
+			t.Errorf("The dry run should not have created the sqlite database")
+		}
+	}
+
+	report, err := BoltToSqlite(cfg, false)
+	if err != nil {
+		t.Fatalf("Error migrating the bolt database: %v", err)
+	} else if !report.Verified() {
+		t.Errorf("The migration should have been verified: %v", report)
+	} else if len(report.UnknownBuckets) != 0 {
+		t.Errorf("Expected no unknown buckets but got %v", report.UnknownBuckets)
+	}
+	checkCounts(t, report, func(c *Count) int { return c.Migrated })
+	checkCounts(t, report, func(c *Count) int { return c.Target })
+
+	// Running the migration again skips the records that are already in the target.
+	if report, err := BoltToSqlite(cfg, false); err != nil {
+		t.Fatalf("Error migrating the bolt database again: %v", err)
+	} else if !report.Verified() {
+		t.Errorf("The second migration should have been verified: %v", report)
+	} else {
+		for _, k := range []string{AGREEMENTS, WORKLOAD_USAGES, SECRETS, ROLLOUTS} {
+			if c := report.Counts[k]; c.Migrated != 0 || c.Skipped != c.Source {
+				t.Errorf("Expected all the %v to be skipped but got %v", k, c)
+			}
+		}
+	}
+
+	// An agbot using the sqlite database adopts the quiesced migration partition.
+	target := new(sqlite.AgbotSqliteDB)
+	if err := target.Initialize(cfg); err != nil {
+		t.Fatalf("Error opening the sqlite database: %v", err)
+	}
+	defer target.Close()
+
+	if target.PrimaryPartition() != report.Partition {
+		t.Errorf("Expected the agbot to claim partition %v but got %v", report.Partition, target.PrimaryPartition())
+	} else if ag, err := target.FindSingleAgreementByAgreementId("ag2", policy.BasicProtocol, []persistence.AFilter{}); err != nil {
+		t.Errorf("Error finding agreement ag2: %v", err)
+	} else if ag == nil || ag.DeviceId != "myorg/dev2" || ag.AgreementFinalizedTime == 0 {
+		t.Errorf("Agreement ag2 was not migrated: %v", ag)
+	} else if names, err := target.GetManagedPolicySecretNames("", ""); err != nil {
+		t.Errorf("Error getting policy secrets: %v", err)
+	} else if sort.Strings(names); len(names) != 2 || names[0] != "myorg/sec1" || names[1] != "myorg/sec2" {
+		t.Errorf("Expected policy secrets myorg/sec1 and myorg/sec2 but got %v", names)
+	} else if names, err := target.GetPatternsWithUpdatedSecrets("myorg", "sec1", 20); err != nil {
+		t.Errorf("Error getting updated patterns: %v", err)
+	} else if len(names) != 1 || names[0] != "otherorg/pat1" {
+		t.Errorf("Expected pattern otherorg/pat1 to check its secret but got %v", names)
+	}
+}
+
+func Test_heartbeat_abort(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "utmigrate-")
+	if err != nil {
+		t.Fatalf("Error creating the database directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	cfg := &config.HorizonConfig{AgreementBot: config.AGConfig{Sqlite: config.SqliteConfig{DBPath: dir}}}
+	target := new(sqlite.AgbotSqliteDB)
+	if err := target.Initialize(cfg); err != nil {
+		t.Fatalf("Error opening the sqlite database: %v", err)
+	}
+	defer target.Close()
+
+	// The heartbeat fails once the partition is no longer owned by the migration.
+	hb := startHeartbeat(target, 1)
+	defer hb.stop()
+	if err := target.QuiescePartition(); err != nil {
+		t.Fatalf("Error quiescing partition: %v", err)
+	}
+
+	for i := 0; i < 30 && hb.failed() == nil; i++ {
+		time.Sleep(100 * time.Millisecond)
+	}
+	if hb.failed() == nil {
+		t.Fatalf("The heartbeat should have failed for a partition that is not owned")
+	}
+
+	// Nothing is written after the heartbeat has failed.
+	records := &boltRecords{
+		protocols:  []string{policy.BasicProtocol},
+		agreements: map[string][]persistence.Agreement{policy.BasicProtocol: {{CurrentAgreementId: "ag1", AgreementProtocol: policy.BasicProtocol}}},
+	}
+	report := newReport(false)
+	if err := copyRecords(target, records, report, hb); err == nil {
+		t.Errorf("The copy should have been aborted")
+	} else if report.Counts[AGREEMENTS].Migrated != 0 {
+		t.Errorf("Expected no migrated agreements but got %v", report.Counts[AGREEMENTS])
+	}
+}
+
+// Check that the count of each kind of record in the report matches the records created by populateBolt.
+func checkCounts(t *testing.T, report *Report, count func(c *Count) int) {
+	expected := map[string]int{AGREEMENTS: 2, WORKLOAD_USAGES: 1, SEARCH_SESSIONS: 2, SECRETS: 3, HA_NODES: 1, HA_WORKLOADS: 1, ROLLOUTS: 1}
+	for k, num := range expected {
+		if c := report.Counts[k]; count(c) != num {
+			t.Errorf("Expected %v %v but got %v", num, k, c)
+		}
+	}
+}
+
+// Create a bolt database with every kind of record that is migrated.
+func populateBolt(t *testing.T, cfg *config.HorizonConfig) {
+	source := new(bolt.AgbotBoltDB)
+	if err := source.Initialize(cfg); err != nil {
+		t.Fatalf("Error initializing the bolt database: %v", err)
+	}
+	defer source.Close()
+
+	if err := source.AgreementAttempt("ag1", "myorg", "myorg/dev1", persistence.DEVICE_TYPE_DEVICE, "myorg/pol1", "", "", "", policy.BasicProtocol, "", []string{"svc1"}, policy.NodeHealth{}, 0, 0); err != nil {
+		t.Fatalf("Error creating agreement ag1: %v", err)
+	} else if err := source.AgreementAttempt("ag2", "myorg", "myorg/dev2", persistence.DEVICE_TYPE_DEVICE, "myorg/pol2", "", "", "", policy.BasicProtocol, "", []string{"svc1"}, policy.NodeHealth{}, 0, 0); err != nil {
+		t.Fatalf("Error creating agreement ag2: %v", err)
+	} else if _, err := source.AgreementFinalized("ag2", policy.BasicProtocol); err != nil {
+		t.Fatalf("Error finalizing agreement ag2: %v", err)
+	} else if err := source.NewWorkloadUsage("myorg/dev1", "", "myorg/pol1", 1, 600, 60, false, "ag1"); err != nil {
+		t.Fatalf("Error creating workload usage: %v", err)
+	} else if err := source.AddManagedPolicySecret("myorg", "sec1", "myorg", "pol1", 10); err != nil {
+		t.Fatalf("Error creating policy secret: %v", err)
+	} else if err := source.AddManagedPolicySecret("myorg", "sec2", "myorg", "pol2", 10); err != nil {
+		t.Fatalf("Error creating policy secret: %v", err)
+	} else if err := source.AddManagedPatternSecret("myorg", "sec1", "otherorg", "pat1", 10); err != nil {
+		t.Fatalf("Error creating pattern secret: %v", err)
+	} else if _, err := source.CheckIfGroupPresentAndUpdateHATable(persistence.UpgradingHAGroupNode{GroupName: "group1", OrgId: "myorg", NodeId: "dev1", NMPName: "nmp1"}); err != nil {
+		t.Fatalf("Error creating ha upgrading node: %v", err)
+	} else if _, err := source.InsertHAUpgradingWorkloadForGroupAndPolicy("myorg", "group1", "myorg/pol1", "myorg/dev1"); err != nil {
+		t.Fatalf("Error creating ha upgrading workload: %v", err)
+	} else if _, err := source.InsertWorkloadRollout(&persistence.WorkloadRollout{PolicyName: "myorg/pol1", Version: "2.0.0", PreviousVersion: "1.0.0", State: persistence.ROLLOUT_STATE_UPGRADING, Step: 1}); err != nil {
+		t.Fatalf("Error creating workload rollout: %v", err)
+	}
+
+	// The secrets are tracked by the bolt database.
+	if names, err := source.GetPoliciesWithUpdatedSecrets("myorg", "sec1", 20); err != nil {
+		t.Fatalf("Error getting updated policies: %v", err)
+	} else if len(names) != 1 || names[0] != "myorg/pol1" {
+		t.Fatalf("Expected policy myorg/pol1 but got %v", names)
+	} else if err := source.SetSecretUpdate("myorg", "sec2", 20); err != nil {
+		t.Fatalf("Error setting secret update time: %v", err)
+	} else if names, err := source.GetPoliciesWithUpdatedSecrets("myorg", "sec2", 20); err != nil || len(names) != 0 {
+		t.Fatalf("Expected no policies to update but got %v, error: %v", names, err)
+	}
+}
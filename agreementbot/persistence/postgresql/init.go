@@ -16,6 +16,12 @@ import (
 // - The database contains structures with schema that are not at the latest version
 // - The database is completely up to date WRT the schemas
 func (db *AgbotPostgresqlDB) Initialize(cfg *config.HorizonConfig) error {
+	return db.initialize(cfg, "")
+}
+
+// Initialize the database and claim a partition for this agbot instance. When the input partition is empty, an unowned
+// or stale partition is claimed (or a new one is created). Otherwise, the agbot claims that specific partition.
+func (db *AgbotPostgresqlDB) initialize(cfg *config.HorizonConfig, partition string) error {
 
 	connectInfo, trace := cfg.AgreementBot.Postgresql.MakeConnectionString()
 
@@ -62,7 +68,13 @@ func (db *AgbotPostgresqlDB) Initialize(cfg *config.HorizonConfig) error {
 		}
 
 		// Claim a partition for ourselves.
-		if partition, err := db.ClaimPartition(cfg.GetPartitionStale()); err != nil {
+		if partition != "" {
+			if err := db.claimSpecificPartition(partition, cfg.GetPartitionStale()); err != nil {
+				return errors.New(fmt.Sprintf("unable to claim partition %v, error: %v", partition, err))
+			}
+			db.primaryPartition = partition
+			db.partitions = append(db.partitions, partition)
+		} else if partition, err := db.ClaimPartition(cfg.GetPartitionStale()); err != nil {
 			return errors.New(fmt.Sprintf("unable to claim a partition, error: %v", err))
 		} else {
 			db.primaryPartition = partition
@@ -116,6 +128,11 @@ func (db *AgbotPostgresqlDB) Initialize(cfg *config.HorizonConfig) error {
 			return fmt.Errorf("unable to create ha workload add if not present function, error: %v", err)
 		}
 
+		// Create the workload rollout table. Do not partition it.
+		if _, err := db.db.Exec(WORKLOAD_ROLLOUT_CREATE_MAIN_TABLE); err != nil {
+			return fmt.Errorf("unable to create workload rollout table, error: %v", err)
+		}
+
 		glog.V(3).Infof("Postgresql primary partition database tables exist.")
 
 		// Migrate the database tables if necessary. Extract the current schema version from the version table,
@@ -0,0 +1,98 @@
+package postgresql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/agreementbot/persistence"
+	"github.com/open-horizon/anax/config"
+)
+
+// Functions used to import records from another database implementation (e.g. bolt) into a postgresql partition. They are
+// not part of the agbot database interface because they are only used by the database migration tool.
+
+// Claim a specific partition. The partition must already exist and must be either quiesced or stale, which ensures that
+// the migration never writes into a partition that a running agbot is using.
+const PARTITION_CLAIM_SPECIFIC = `UPDATE partitions SET owner = $1, heartbeat = current_timestamp
+	WHERE id = $2 AND (
+		(owner IS NULL AND heartbeat IS NULL)
+		OR
+		(owner IS NOT NULL AND (SELECT EXTRACT ('epoch' FROM (SELECT AGE(current_timestamp, heartbeat)))) > $3)
+	)
+	RETURNING id;`
+
+const SEARCH_SESSIONS_EXISTS = `SELECT COUNT(*) FROM search_sessions WHERE policyName = $1;`
+
+// Initialize the database for a migration. When the input partition is empty, the migration gets a partition in the same
+// way as any other agbot instance.
+func (db *AgbotPostgresqlDB) InitializeWithPartition(cfg *config.HorizonConfig, partition string) error {
+	return db.initialize(cfg, partition)
+}
+
+func (db *AgbotPostgresqlDB) claimSpecificPartition(partition string, timeout uint64) error {
+
+	var id string
+	if err := db.db.QueryRow(PARTITION_CLAIM_SPECIFIC, db.identity, partition, timeout).Scan(&id); err == sql.ErrNoRows {
+		var owner sql.NullString
+		if err := db.db.QueryRow(PARTITION_OWNER, partition).Scan(&owner); err == sql.ErrNoRows {
+			return errors.New(fmt.Sprintf("partition %v does not exist", partition))
+		}
+		return errors.New(fmt.Sprintf("partition %v is in use by another agbot", partition))
+	} else if err != nil {
+		return err
+	}
+
+	glog.Infof("AgreementBot %v claimed partition %v", db.identity, id)
+	return nil
+}
+
+// Insert an agreement into the primary partition unless an agreement with the same id is already there. The return value
+// indicates whether or not the agreement was inserted, so that a migration can be safely re-run.
+func (db *AgbotPostgresqlDB) ImportAgreement(ag *persistence.Agreement, protocol string) (bool, error) {
+
+	if existing, err := db.FindSingleAgreementByAgreementId(ag.CurrentAgreementId, protocol, []persistence.AFilter{}); err != nil {
+		return false, err
+	} else if existing != nil {
+		return false, nil
+	} else if err := db.insertAgreement(ag, protocol); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Insert a workload usage into the primary partition unless one already exists for the same device and policy. The return
+// value indicates whether or not the workload usage was inserted.
+func (db *AgbotPostgresqlDB) ImportWorkloadUsage(wu *persistence.WorkloadUsage) (bool, error) {
+
+	if existing, err := db.FindSingleWorkloadUsageByDeviceAndPolicyName(wu.DeviceId, wu.PolicyName); err != nil {
+		return false, err
+	} else if existing != nil {
+		return false, nil
+	} else if err := db.insertWorkloadUsage(nil, wu); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Return true if there is a search session for the input policy.
+func (db *AgbotPostgresqlDB) SearchSessionExists(policyName string) (bool, error) {
+	var num int64
+	if err := db.db.QueryRow(SEARCH_SESSIONS_EXISTS, policyName).Scan(&num); err != nil {
+		return false, errors.New(fmt.Sprintf("error checking for %v search session, error: %v", policyName, err))
+	}
+	return num != 0, nil
+}
+
+// Heartbeat the partition of a migration. Unlike HeartbeatPartition, an error is returned when the partition has been taken
+// over by another agbot, so that the migration stops writing into it instead of crashing.
+func (db *AgbotPostgresqlDB) HeartbeatMigrationPartition() error {
+	if res, err := db.db.Exec(PARTITION_HEARTBEAT, db.PrimaryPartition(), db.identity); err != nil {
+		return errors.New(fmt.Sprintf("unable to heartbeat partition %v, error: %v", db.PrimaryPartition(), err))
+	} else if num, err := res.RowsAffected(); err != nil {
+		return errors.New(fmt.Sprintf("error getting rows affected by the heartbeat of partition %v, error: %v", db.PrimaryPartition(), err))
+	} else if num != 1 {
+		return errors.New(fmt.Sprintf("partition %v is no longer owned by %v, it has been taken over by another agbot", db.PrimaryPartition(), db.identity))
+	}
+	return nil
+}
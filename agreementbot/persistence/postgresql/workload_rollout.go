@@ -0,0 +1,148 @@
+package postgresql
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/agreementbot/persistence"
+)
+
+// Constants for the sql table operations required to manage the staged rollouts of new service versions.
+
+// Create the workload rollout table. This table will not be partitioned as it is shared between agbots, so that any
+// agbot can continue a rollout.
+const WORKLOAD_ROLLOUT_CREATE_MAIN_TABLE = `CREATE TABLE IF NOT EXISTS workload_rollouts (
+	policy_name text PRIMARY KEY,
+	rollout jsonb NOT NULL,
+	updated timestamp with time zone DEFAULT current_timestamp
+);`
+
+const WORKLOAD_ROLLOUT_INSERT = `INSERT INTO workload_rollouts (policy_name, rollout) VALUES ($1, $2) ON CONFLICT DO NOTHING;`
+
+const WORKLOAD_ROLLOUT_QUERY = `SELECT rollout FROM workload_rollouts WHERE policy_name = $1;`
+
+const WORKLOAD_ROLLOUT_QUERY_FOR_UPDATE = `SELECT rollout FROM workload_rollouts WHERE policy_name = $1 FOR UPDATE;`
+
+const WORKLOAD_ROLLOUT_QUERY_ALL = `SELECT rollout FROM workload_rollouts;`
+
+const WORKLOAD_ROLLOUT_UPDATE = `UPDATE workload_rollouts SET rollout = $2, updated = current_timestamp WHERE policy_name = $1;`
+
+const WORKLOAD_ROLLOUT_DELETE = `DELETE FROM workload_rollouts WHERE policy_name = $1;`
+
+func (db *AgbotPostgresqlDB) GetWorkloadRollout(policyName string) (*persistence.WorkloadRollout, error) {
+	return db.findWorkloadRollout(nil, policyName)
+}
+
+func (db *AgbotPostgresqlDB) ListAllWorkloadRollouts() ([]persistence.WorkloadRollout, error) {
+	rollouts := []persistence.WorkloadRollout{}
+	rows, err := db.db.Query(WORKLOAD_ROLLOUT_QUERY_ALL)
+	if err != nil {
+		return nil, fmt.Errorf("error querying database for all workload rollouts. Error was: %v", err)
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var rBytes []byte
+		if err = rows.Scan(&rBytes); err != nil {
+			return nil, fmt.Errorf("error scanning row for workload rollouts, error was: %v", err)
+		}
+
+		r := new(persistence.WorkloadRollout)
+		if err := json.Unmarshal(rBytes, r); err != nil {
+			return nil, errors.New(fmt.Sprintf("error demarshalling workload rollout %v, error: %v", string(rBytes), err))
+		}
+		rollouts = append(rollouts, *r)
+	}
+
+	return rollouts, nil
+}
+
+// Insert the rollout if there is none for the policy yet, or replace the existing one if it rolls out a different version.
+// The rollout in the database is returned, which is the existing one when it rolls out the same version.
+func (db *AgbotPostgresqlDB) InsertWorkloadRollout(rollout *persistence.WorkloadRollout) (*persistence.WorkloadRollout, error) {
+	rBytes, err := json.Marshal(rollout)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("error marshalling workload rollout %v, error: %v", rollout, err))
+	}
+
+	tx, err := db.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(WORKLOAD_ROLLOUT_INSERT, rollout.PolicyName, rBytes); err != nil {
+		return nil, errors.New(fmt.Sprintf("error inserting workload rollout %v, error: %v", rollout, err))
+	} else if current, err := db.findWorkloadRollout(tx, rollout.PolicyName); err != nil {
+		return nil, err
+	} else if current != nil && current.Version == rollout.Version {
+		glog.V(5).Infof("Workload rollout of %v for %v already exists: %v", rollout.Version, rollout.PolicyName, current.ShortString())
+		return current, tx.Commit()
+	} else if _, err := tx.Exec(WORKLOAD_ROLLOUT_UPDATE, rollout.PolicyName, rBytes); err != nil {
+		return nil, errors.New(fmt.Sprintf("error replacing workload rollout with %v, error: %v", rollout, err))
+	}
+
+	glog.V(2).Infof("Succeeded inserting workload rollout %v", rollout.ShortString())
+	return rollout, tx.Commit()
+}
+
+// Update the rollout of a policy in a single transaction, so that the agbots do not overwrite each other's changes.
+// If the update function returns nil, the rollout is not changed.
+func (db *AgbotPostgresqlDB) SingleWorkloadRolloutUpdate(policyName string, fn func(persistence.WorkloadRollout) *persistence.WorkloadRollout) (*persistence.WorkloadRollout, error) {
+	tx, err := db.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	current, err := db.findWorkloadRollout(tx, policyName)
+	if err != nil {
+		return nil, err
+	} else if current == nil {
+		return nil, fmt.Errorf("Unable to locate workload rollout for policy: %v", policyName)
+	}
+
+	updated := fn(*current)
+	if updated == nil {
+		return current, nil
+	}
+
+	if rBytes, err := json.Marshal(updated); err != nil {
+		return nil, errors.New(fmt.Sprintf("error marshalling workload rollout %v, error: %v", updated, err))
+	} else if _, err := tx.Exec(WORKLOAD_ROLLOUT_UPDATE, policyName, rBytes); err != nil {
+		return nil, errors.New(fmt.Sprintf("error updating workload rollout %v, error: %v", updated, err))
+	}
+	return updated, tx.Commit()
+}
+
+func (db *AgbotPostgresqlDB) DeleteWorkloadRollout(policyName string) error {
+	if _, err := db.db.Exec(WORKLOAD_ROLLOUT_DELETE, policyName); err != nil {
+		return errors.New(fmt.Sprintf("error deleting workload rollout for %v, error: %v", policyName, err))
+	}
+	return nil
+}
+
+// Read the rollout of a policy. Within a transaction, the row is locked until the transaction ends.
+func (db *AgbotPostgresqlDB) findWorkloadRollout(tx *sql.Tx, policyName string) (*persistence.WorkloadRollout, error) {
+	var rBytes []byte
+	var qerr error
+	if tx == nil {
+		qerr = db.db.QueryRow(WORKLOAD_ROLLOUT_QUERY, policyName).Scan(&rBytes)
+	} else {
+		qerr = tx.QueryRow(WORKLOAD_ROLLOUT_QUERY_FOR_UPDATE, policyName).Scan(&rBytes)
+	}
+
+	if qerr == sql.ErrNoRows {
+		return nil, nil
+	} else if qerr != nil {
+		return nil, errors.New(fmt.Sprintf("error scanning row for workload rollout of %v, error: %v", policyName, qerr))
+	}
+
+	r := new(persistence.WorkloadRollout)
+	if err := json.Unmarshal(rBytes, r); err != nil {
+		return nil, errors.New(fmt.Sprintf("error demarshalling workload rollout %v, error: %v", string(rBytes), err))
+	}
+	return r, nil
+}
@@ -21,7 +21,8 @@ func Register(name string, db AgbotDatabase) {
 }
 
 // Initialize the underlying Agbot database depending on what is configured. If the bolt DB is configured, it is used. Next,
-// the postgresql config is checked and used if configured. If nothing is configured, an error is returned.
+// the postgresql config is checked and used if configured, followed by the SQLite config. If nothing is configured, an error
+// is returned.
 func InitDatabase(cfg *config.HorizonConfig) (AgbotDatabase, error) {
 
 	if cfg.IsBoltDBConfigured() {
@@ -32,7 +33,11 @@ func InitDatabase(cfg *config.HorizonConfig) (AgbotDatabase, error) {
 		dbObj := DatabaseProviders["postgresql"]
 		return dbObj, dbObj.Initialize(cfg)
 
+	} else if cfg.IsSqliteConfigured() {
+		dbObj := DatabaseProviders["sqlite"]
+		return dbObj, dbObj.Initialize(cfg)
+
 	}
-	return nil, errors.New(fmt.Sprintf("neither bolt DB nor Postgresql DB is configured correctly."))
+	return nil, errors.New(fmt.Sprintf("none of bolt DB, Postgresql DB or SQLite DB is configured correctly."))
 
 }
@@ -0,0 +1,443 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/agreementbot/persistence"
+	"github.com/open-horizon/anax/policy"
+)
+
+// This function registers an uninitialized agbot DB instance with the DB plugin registry. The plugin's Initialize
+// method is used to configure the object.
+func init() {
+	persistence.Register("sqlite", new(AgbotSqliteDB))
+}
+
+// Constants for the SQL statements that are used to work with agreements. Agreements are partitioned by agbot instances in
+// the same way as they are in the Postgresql implementation, except that all partitions live in the same table. Every query
+// and update is constrained to a partition by the partition column, which is indexed.
+//
+// See the IMPORTANT NOTE in the Postgresql implementation of this file regarding the lifecycle of workload usage records. It
+// applies equally to this implementation.
+
+// agreements schema:
+// agreement_id: The stringified agreement id for the agreement object in the record.
+// protocol:     The agreement protocol in use. It is a way of partitioning the database so that an agbot can focus on handling
+//               all agreements for a given protocol on at a time.
+// partition:    The agbot partition that this agreement lives in.
+// agreement:    The agreement object which is a JSON blob. The blob schema is defined by the Agreement struct in the
+//               persistence package.
+// updated:      A timestamp to record last updated time.
+//
+
+const AGREEMENT_CREATE_MAIN_TABLE = `CREATE TABLE IF NOT EXISTS agreements (
+	agreement_id text NOT NULL,
+	protocol text NOT NULL,
+	partition text NOT NULL,
+	agreement text NOT NULL,
+	updated timestamp DEFAULT current_timestamp
+);`
+const AGREEMENT_CREATE_INDEX = `CREATE INDEX IF NOT EXISTS agreement_id_index_on_agreements ON agreements (agreement_id);`
+const AGREEMENT_CREATE_PARTITION_INDEX = `CREATE INDEX IF NOT EXISTS partition_index_on_agreements ON agreements (partition);`
+
+const AGREEMENT_QUERY = `SELECT agreement FROM agreements WHERE agreement_id = ?1 AND protocol = ?2 AND partition = ?3;`
+const ALL_AGREEMENTS_QUERY = `SELECT agreement FROM agreements WHERE protocol = ?1 AND partition = ?2;`
+
+const AGREEMENT_COUNT = `SELECT agreement FROM agreements WHERE partition = ?1;`
+
+const AGREEMENT_INSERT = `INSERT INTO agreements (agreement_id, protocol, partition, agreement) VALUES (?1, ?2, ?3, ?4);`
+const AGREEMENT_UPDATE = `UPDATE agreements SET agreement = ?3, updated = current_timestamp WHERE agreement_id = ?1 AND protocol = ?2 AND partition = ?4;`
+const AGREEMENT_DELETE = `DELETE FROM agreements WHERE agreement_id = ?1 AND partition = ?2;`
+
+const AGREEMENT_MOVE = `UPDATE agreements SET partition = ?2 WHERE partition = ?1;`
+
+const AGREEMENT_PARTITIONS = `SELECT DISTINCT partition FROM agreements;`
+
+// The fields in this object are initialized in the Initialize method in this package.
+type AgbotSqliteDB struct {
+	identity         string   // The identity of this agbot in the partitions table.
+	db               *sql.DB  // A handle to the underlying database.
+	primaryPartition string   // The partition to use when creating new agreements.
+	partitions       []string // The list of partitions this agbot is responsible to maintain.
+}
+
+func (db *AgbotSqliteDB) String() string {
+	return fmt.Sprintf("Instance: %v, PrimaryPartition: %v, All Partitions: %v, DB Handle: %v", db.identity, db.primaryPartition, db.partitions, db.db)
+}
+
+func (db *AgbotSqliteDB) PrimaryPartition() string {
+	return db.primaryPartition
+}
+
+func (db *AgbotSqliteDB) AllPartitions() []string {
+	return db.partitions
+}
+
+func (db *AgbotSqliteDB) FindAgreementPartitions() ([]string, error) {
+
+	// Find all the agreement partitions.
+	partitions := make([]string, 0, 10)
+	foundPrimary := false
+
+	rows, err := db.db.Query(AGREEMENT_PARTITIONS)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("error querying for agreement partitions: %v", err))
+	}
+
+	// If the rows object doesnt get closed, memory and connections will grow and/or leak.
+	defer rows.Close()
+	for rows.Next() {
+		var partition string
+		if err := rows.Scan(&partition); err != nil {
+			return nil, errors.New(fmt.Sprintf("error scanning row: %v", err))
+		} else {
+			partitions = append(partitions, partition)
+			if partition == db.PrimaryPartition() {
+				foundPrimary = true
+			}
+		}
+	}
+
+	// The rows.Next() function will exit with false when done or an error occurred. Get any error encountered during iteration.
+	if err = rows.Err(); err != nil {
+		return nil, errors.New(fmt.Sprintf("error iterating: %v", err))
+	}
+
+	// Make sure the primary partition appears (even if it doesnt have any agreements yet), if it has not already been added
+	if !foundPrimary {
+		partitions = append(partitions, db.PrimaryPartition())
+	}
+
+	return partitions, nil
+}
+
+func (db *AgbotSqliteDB) GetAgreementCount(partition string) (int64, int64, error) {
+
+	var activeNum, archivedNum int64
+
+	rows, err := db.db.Query(AGREEMENT_COUNT, partition)
+	if err != nil {
+		return 0, 0, errors.New(fmt.Sprintf("error getting rows for agreement counts, error: %v", err))
+	}
+
+	// If the rows object doesnt get closed, memory and connections will grow and/or leak.
+	defer rows.Close()
+	for rows.Next() {
+		agBytes := make([]byte, 0, 2048)
+		ag := new(persistence.Agreement)
+		if err := rows.Scan(&agBytes); err != nil {
+			return 0, 0, errors.New(fmt.Sprintf("error scanning row for agreement counts: %v", err))
+		} else if err := json.Unmarshal(agBytes, ag); err != nil {
+			return 0, 0, errors.New(fmt.Sprintf("error demarshalling row for agreement count: %v, error: %v", string(agBytes), err))
+		} else if ag.Archived {
+			archivedNum += 1
+		} else {
+			activeNum += 1
+		}
+	}
+
+	// The rows.Next() function will exit with false when done or an error occurred. Get any error encountered during iteration.
+	if err = rows.Err(); err != nil {
+		return 0, 0, errors.New(fmt.Sprintf("error iterating rows for agreement counts: %v", err))
+	}
+
+	return activeNum, archivedNum, nil
+}
+
+// Retrieve all agreements from the database and filter them out based on the input filters.
+func (db *AgbotSqliteDB) FindAgreements(filters []persistence.AFilter, protocol string) ([]persistence.Agreement, error) {
+
+	ags := make([]persistence.Agreement, 0, 100)
+
+	for _, currentPartition := range db.AllPartitions() {
+		// Find all the agreement objects, read them in and run them through the filters (after unmarshalling the blob into an
+		// in memory agreement object).
+		if err := db.findAgreementsInPartition(currentPartition, filters, protocol, &ags); err != nil {
+			return nil, err
+		}
+	}
+
+	return ags, nil
+
+}
+
+func (db *AgbotSqliteDB) findAgreementsInPartition(partition string, filters []persistence.AFilter, protocol string, ags *[]persistence.Agreement) error {
+
+	rows, err := db.db.Query(ALL_AGREEMENTS_QUERY, protocol, partition)
+	if err != nil {
+		return errors.New(fmt.Sprintf("error querying for agreements error: %v", err))
+	}
+
+	// If the rows object doesnt get closed, memory and connections will grow and/or leak.
+	defer rows.Close()
+	for rows.Next() {
+		agBytes := make([]byte, 0, 2048)
+		ag := new(persistence.Agreement)
+		if err := rows.Scan(&agBytes); err != nil {
+			return errors.New(fmt.Sprintf("error scanning row: %v", err))
+		} else if err := json.Unmarshal(agBytes, ag); err != nil {
+			return errors.New(fmt.Sprintf("error demarshalling row: %v, error: %v", string(agBytes), err))
+		} else {
+			if !ag.Archived {
+				if glog.V(5) {
+					glog.Infof("Demarshalled agreement in partition %v from DB: %v", partition, ag)
+				}
+			}
+			if agPassed := persistence.RunFilters(ag, filters); agPassed != nil {
+				*ags = append(*ags, *ag)
+			}
+		}
+	}
+
+	// The rows.Next() function will exit with false when done or an error occurred. Get any error encountered during iteration.
+	if err = rows.Err(); err != nil {
+		return errors.New(fmt.Sprintf("error iterating: %v", err))
+	}
+	return nil
+}
+
+// Find a specific agreement in the database. The input filters are applied to the agreement once it is found.
+func (db *AgbotSqliteDB) internalFindSingleAgreementByAgreementId(tx *sql.Tx, agreementId string, protocol string, filters []persistence.AFilter) (*persistence.Agreement, string, error) {
+
+	agBytes := make([]byte, 0, 2048)
+	ag := new(persistence.Agreement)
+
+	for _, currentPartition := range db.AllPartitions() {
+
+		// Find the agreement row and read in the agreement object column, run the returned agreement through the filters, then unmarshal
+		// the blob into an in memory agreement object which gets returned to the caller.
+		var qerr error
+		if tx == nil {
+			qerr = db.db.QueryRow(AGREEMENT_QUERY, agreementId, protocol, currentPartition).Scan(&agBytes)
+		} else {
+			qerr = tx.QueryRow(AGREEMENT_QUERY, agreementId, protocol, currentPartition).Scan(&agBytes)
+		}
+
+		if qerr != nil && qerr != sql.ErrNoRows {
+			return nil, "", errors.New(fmt.Sprintf("error scanning row for agreement %v error: %v", agreementId, qerr))
+		} else if qerr == sql.ErrNoRows {
+			continue
+		}
+
+		if err := json.Unmarshal(agBytes, ag); err != nil {
+			return nil, "", errors.New(fmt.Sprintf("error demarshalling row: %v, error: %v", string(agBytes), err))
+		} else if agPassed := persistence.RunFilters(ag, filters); agPassed == nil {
+			return nil, "", nil // Agreement ids are unique. If we found the one we want but the filters rejected it, then we're done. No need to look at more partitions.
+		} else {
+			return ag, currentPartition, nil
+		}
+	}
+	return nil, "", nil
+
+}
+
+func (db *AgbotSqliteDB) FindSingleAgreementByAgreementId(agreementId string, protocol string, filters []persistence.AFilter) (*persistence.Agreement, error) {
+	ag, _, err := db.internalFindSingleAgreementByAgreementId(nil, agreementId, protocol, filters)
+	return ag, err
+}
+
+func (db *AgbotSqliteDB) FindSingleAgreementByAgreementIdAllProtocols(agreementid string, protocols []string, filters []persistence.AFilter) (*persistence.Agreement, error) {
+	filters = append(filters, persistence.IdAFilter(agreementid))
+
+	for _, protocol := range protocols {
+		if agreements, err := db.FindAgreements(filters, protocol); err != nil {
+			return nil, err
+		} else if len(agreements) > 1 {
+			return nil, fmt.Errorf("Expected only one record for agreementid: %v, but retrieved: %v", agreementid, agreements)
+		} else if len(agreements) == 0 {
+			continue
+		} else {
+			return &agreements[0], nil
+		}
+	}
+	return nil, nil
+}
+
+func (db *AgbotSqliteDB) AgreementAttempt(agreementid string, org string, deviceid string, deviceType string, policyName string, bcType string, bcName string, bcOrg string, agreementProto string, pattern string, serviceId []string, nhPolicy policy.NodeHealth, protocolTimeout uint64, agreementTimeout uint64) error {
+	if agreement, err := persistence.NewAgreement(agreementid, org, deviceid, deviceType, policyName, bcType, bcName, bcOrg, agreementProto, pattern, serviceId, nhPolicy, protocolTimeout, agreementTimeout); err != nil {
+		return err
+	} else if err := db.insertAgreement(agreement, agreementProto); err != nil {
+		return err
+	} else {
+		return nil
+	}
+}
+
+func (db *AgbotSqliteDB) AgreementFinalized(agreementId string, protocol string) (*persistence.Agreement, error) {
+	return persistence.AgreementFinalized(db, agreementId, protocol)
+}
+
+func (db *AgbotSqliteDB) AgreementUpdate(agreementid string, proposal string, policy string, dvPolicy policy.DataVerification, defaultCheckRate uint64, hash string, sig string, protocol string, agreementProtoVersion int) (*persistence.Agreement, error) {
+	return persistence.AgreementUpdate(db, agreementid, proposal, policy, dvPolicy, defaultCheckRate, hash, sig, protocol, agreementProtoVersion)
+}
+
+func (db *AgbotSqliteDB) AgreementMade(agreementId string, counterParty string, signature string, protocol string, bcType string, bcName string, bcOrg string) (*persistence.Agreement, error) {
+	return persistence.AgreementMade(db, agreementId, counterParty, signature, protocol, bcType, bcName, bcOrg)
+}
+
+func (db *AgbotSqliteDB) AgreementTimedout(agreementid string, protocol string) (*persistence.Agreement, error) {
+	return persistence.AgreementTimedout(db, agreementid, protocol)
+}
+
+func (db *AgbotSqliteDB) AgreementBlockchainUpdate(agreementId string, consumerSig string, hash string, counterParty string, signature string, protocol string) (*persistence.Agreement, error) {
+	return persistence.AgreementBlockchainUpdate(db, agreementId, consumerSig, hash, counterParty, signature, protocol)
+}
+
+func (db *AgbotSqliteDB) AgreementBlockchainUpdateAck(agreementId string, protocol string) (*persistence.Agreement, error) {
+	return persistence.AgreementBlockchainUpdateAck(db, agreementId, protocol)
+}
+
+func (db *AgbotSqliteDB) DataVerified(agreementid string, protocol string) (*persistence.Agreement, error) {
+	return persistence.DataVerified(db, agreementid, protocol)
+}
+
+func (db *AgbotSqliteDB) DataNotVerified(agreementid string, protocol string) (*persistence.Agreement, error) {
+	return persistence.DataNotVerified(db, agreementid, protocol)
+}
+
+func (db *AgbotSqliteDB) DataNotification(agreementid string, protocol string) (*persistence.Agreement, error) {
+	return persistence.DataNotification(db, agreementid, protocol)
+}
+
+func (db *AgbotSqliteDB) MeteringNotification(agreementid string, protocol string, mn string) (*persistence.Agreement, error) {
+	return persistence.MeteringNotification(db, agreementid, protocol, mn)
+}
+
+func (db *AgbotSqliteDB) ArchiveAgreement(agreementid string, protocol string, reason uint, desc string) (*persistence.Agreement, error) {
+	return persistence.ArchiveAgreement(db, agreementid, protocol, reason, desc)
+}
+
+func (db *AgbotSqliteDB) AgreementSecretUpdateTime(agreementid string, protocol string, secretUpdateTime uint64) (*persistence.Agreement, error) {
+	return persistence.AgreementSecretUpdateTime(db, agreementid, protocol, secretUpdateTime)
+}
+
+func (db *AgbotSqliteDB) AgreementSecretUpdateAckTime(agreementid string, protocol string, secretUpdateAckTime uint64) (*persistence.Agreement, error) {
+	return persistence.AgreementSecretUpdateAckTime(db, agreementid, protocol, secretUpdateAckTime)
+}
+
+func (db *AgbotSqliteDB) AgreementPolicyUpdateTime(agreementid string, protocol string, policyUpdateTime uint64) (*persistence.Agreement, error) {
+	return persistence.AgreementPolicyUpdateTime(db, agreementid, protocol, policyUpdateTime)
+}
+
+func (db *AgbotSqliteDB) AgreementPolicyUpdateAckTime(agreementid string, protocol string, policyUpdateAckTime uint64) (*persistence.Agreement, error) {
+	return persistence.AgreementPolicyUpdateAckTime(db, agreementid, protocol, policyUpdateAckTime)
+}
+
+func (db *AgbotSqliteDB) DeleteAgreement(agreementid string, protocol string) error {
+	tx, err := db.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := db.deleteAgreement(tx, agreementid, protocol); err != nil {
+		return err
+	} else {
+		return tx.Commit()
+	}
+}
+
+func (db *AgbotSqliteDB) Close() {
+	glog.V(2).Infof("Closing SQLite database")
+	db.db.Close()
+	glog.V(2).Infof("Closed SQLite database")
+}
+
+// Utility functions used by the public functions in this package.
+
+// This function is used by all functions that want to change something in the database. It first locates the agreement
+// to be updated, then calls the input function to update the agreement in memory, and finally calls wrapTransaction to
+// start a transaction that will actually perform the update. The agreement that was written to the database is returned.
+func (db *AgbotSqliteDB) SingleAgreementUpdate(agreementid string, protocol string, fn func(persistence.Agreement) *persistence.Agreement) (*persistence.Agreement, error) {
+	if agreement, err := db.FindSingleAgreementByAgreementId(agreementid, protocol, []persistence.AFilter{}); err != nil {
+		return nil, err
+	} else if agreement == nil {
+		return nil, errors.New(fmt.Sprintf("unable to locate agreement id: %v", agreementid))
+	} else {
+		return db.wrapTransaction(agreementid, protocol, fn(*agreement))
+	}
+}
+
+// This function is used to wrap a database transaction around an update to an agreement object.
+func (db *AgbotSqliteDB) wrapTransaction(agreementid string, protocol string, updated *persistence.Agreement) (*persistence.Agreement, error) {
+
+	if tx, err := db.db.Begin(); err != nil {
+		return nil, err
+	} else if persisted, err := db.persistUpdatedAgreement(tx, agreementid, protocol, updated); err != nil {
+		tx.Rollback()
+		return nil, err
+	} else if err := tx.Commit(); err != nil {
+		return nil, err
+	} else {
+		return persisted, nil
+	}
+
+}
+
+// This function runs inside a transaction. It will atomicly read the agreement from the DB, verify that the updated
+// agreement object contains valid state transitions, and then write the updated agreement back to the database. The
+// agreement as written is returned.
+func (db *AgbotSqliteDB) persistUpdatedAgreement(tx *sql.Tx, agreementid string, protocol string, update *persistence.Agreement) (*persistence.Agreement, error) {
+
+	if mod, partition, err := db.internalFindSingleAgreementByAgreementId(tx, agreementid, protocol, []persistence.AFilter{}); err != nil {
+		return nil, err
+	} else if mod == nil {
+		return nil, errors.New(fmt.Sprintf("No agreement with given id available to update: %v", agreementid))
+	} else {
+		// This code is running in a database transaction. Within the tx, the current record (mod) is
+		// read and then updated according to the updates within the input update record. It is critical
+		// to check for correct data transitions within the tx.
+		persistence.ValidateStateTransition(mod, update)
+		return mod, db.updateAgreement(tx, mod, protocol, partition)
+	}
+}
+
+func (db *AgbotSqliteDB) insertAgreement(ag *persistence.Agreement, protocol string) error {
+
+	if agm, err := json.Marshal(ag); err != nil {
+		return err
+	} else if _, err = db.db.Exec(AGREEMENT_INSERT, ag.CurrentAgreementId, protocol, db.PrimaryPartition(), agm); err != nil {
+		return err
+	} else {
+		glog.V(2).Infof("Succeeded creating agreement record %v", *ag)
+	}
+
+	return nil
+}
+
+func (db *AgbotSqliteDB) updateAgreement(tx *sql.Tx, ag *persistence.Agreement, protocol string, partition string) error {
+
+	if agm, err := json.Marshal(ag); err != nil {
+		return err
+	} else if _, err = tx.Exec(AGREEMENT_UPDATE, ag.CurrentAgreementId, protocol, agm, partition); err != nil {
+		return err
+	} else {
+		glog.V(2).Infof("Succeeded writing agreement record %v", *ag)
+	}
+
+	return nil
+}
+
+func (db *AgbotSqliteDB) deleteAgreement(tx *sql.Tx, agreementId string, protocol string) error {
+
+	// Query the agreement id to retrieve the partition for this agreement. We dont need the agreement object in this case.
+	_, partition, err := db.internalFindSingleAgreementByAgreementId(tx, agreementId, protocol, []persistence.AFilter{})
+	if err != nil {
+		return err
+	}
+
+	// Delete the agreement. Partitions are not tables in this implementation, so there is nothing else to clean up.
+	if _, err := tx.Exec(AGREEMENT_DELETE, agreementId, partition); err != nil {
+		return err
+	}
+
+	if glog.V(5) {
+		glog.Infof("Agreement %v deleted from database.", agreementId)
+	}
+
+	return nil
+
+}
@@ -0,0 +1,186 @@
+//go:build unit
+// +build unit
+
+package sqlite
+
+import (
+	"github.com/open-horizon/anax/agreementbot/persistence"
+	"github.com/open-horizon/anax/config"
+	"github.com/open-horizon/anax/policy"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+const TEST_PROTOCOL = policy.BasicProtocol
+
+func Test_Agreement_lifecycle(t *testing.T) {
+
+	dir := utsetup(t)
+	defer os.RemoveAll(dir)
+	db := utopen(t, dir)
+	defer db.Close()
+
+	if err := db.AgreementAttempt("ag1", "myorg", "myorg/dev1", persistence.DEVICE_TYPE_DEVICE, "myorg/pol1", "", "", "", TEST_PROTOCOL, "", []string{"svc1"}, policy.NodeHealth{}, 0, 0); err != nil {
+		t.Fatalf("Error creating agreement ag1: %v", err)
+	} else if err := db.AgreementAttempt("ag2", "myorg", "myorg/dev2", persistence.DEVICE_TYPE_DEVICE, "myorg/pol1", "", "", "", TEST_PROTOCOL, "", []string{"svc1"}, policy.NodeHealth{}, 0, 0); err != nil {
+		t.Fatalf("Error creating agreement ag2: %v", err)
+	}
+
+	// The agreements are created in the primary partition.
+	if partitions, err := db.FindAgreementPartitions(); err != nil {
+		t.Errorf("Error finding agreement partitions: %v", err)
+	} else if len(partitions) != 1 || partitions[0] != db.PrimaryPartition() {
+		t.Errorf("Expected only the primary partition %v but got %v", db.PrimaryPartition(), partitions)
+	}
+
+	if ags, err := db.FindAgreements([]persistence.AFilter{persistence.UnarchivedAFilter()}, TEST_PROTOCOL); err != nil {
+		t.Errorf("Error finding agreements: %v", err)
+	} else if len(ags) != 2 {
+		t.Errorf("Expected 2 agreements but got %v", ags)
+	}
+
+	if ags, err := db.FindAgreements([]persistence.AFilter{persistence.DevPolAFilter("myorg/dev2", "myorg/pol1")}, TEST_PROTOCOL); err != nil {
+		t.Errorf("Error finding agreements: %v", err)
+	} else if len(ags) != 1 || ags[0].CurrentAgreementId != "ag2" {
+		t.Errorf("Expected agreement ag2 but got %v", ags)
+	}
+
+	// Agreements are found by protocol.
+	if ags, err := db.FindAgreements([]persistence.AFilter{}, "other"); err != nil {
+		t.Errorf("Error finding agreements: %v", err)
+	} else if len(ags) != 0 {
+		t.Errorf("Expected no agreements for another protocol but got %v", ags)
+	}
+
+	if ag, err := db.AgreementMade("ag1", "0x1234", "sig", TEST_PROTOCOL, "", "", ""); err != nil {
+		t.Errorf("Error updating agreement ag1: %v", err)
+	} else if ag.CounterPartyAddress != "0x1234" || ag.ProposalSig != "sig" {
+		t.Errorf("Wrong updated agreement %v", ag)
+	} else if _, err := db.AgreementFinalized("ag1", TEST_PROTOCOL); err != nil {
+		t.Errorf("Error finalizing agreement ag1: %v", err)
+	}
+
+	// The counter party cannot be replaced, the agreement that is returned is the one that was written.
+	if ag, err := db.AgreementMade("ag1", "0x5678", "sig2", TEST_PROTOCOL, "", "", ""); err != nil {
+		t.Errorf("Error updating agreement ag1: %v", err)
+	} else if ag.CounterPartyAddress != "0x1234" || ag.ProposalSig != "sig" {
+		t.Errorf("Expected the agreement as written but got %v", ag)
+	}
+
+	if ag, err := db.FindSingleAgreementByAgreementId("ag1", TEST_PROTOCOL, []persistence.AFilter{}); err != nil {
+		t.Errorf("Error finding agreement ag1: %v", err)
+	} else if ag == nil || ag.CounterPartyAddress != "0x1234" || ag.AgreementFinalizedTime == 0 {
+		t.Errorf("Agreement ag1 was not updated in the database: %v", ag)
+	}
+
+	if ag, err := db.FindSingleAgreementByAgreementIdAllProtocols("ag1", policy.AllAgreementProtocols(), []persistence.AFilter{}); err != nil {
+		t.Errorf("Error finding agreement ag1: %v", err)
+	} else if ag == nil || ag.CurrentAgreementId != "ag1" {
+		t.Errorf("Expected agreement ag1 but got %v", ag)
+	}
+
+	// An unknown agreement cannot be updated.
+	if _, err := db.AgreementFinalized("ag3", TEST_PROTOCOL); err == nil {
+		t.Errorf("Expected an error updating an unknown agreement")
+	}
+
+	if _, err := db.ArchiveAgreement("ag2", TEST_PROTOCOL, 1, "test"); err != nil {
+		t.Errorf("Error archiving agreement ag2: %v", err)
+	}
+
+	if active, archived, err := db.GetAgreementCount(db.PrimaryPartition()); err != nil {
+		t.Errorf("Error counting agreements: %v", err)
+	} else if active != 1 || archived != 1 {
+		t.Errorf("Expected 1 active and 1 archived agreement but got %v and %v", active, archived)
+	}
+
+	// The filters are applied to the agreement that is found.
+	if ag, err := db.FindSingleAgreementByAgreementId("ag2", TEST_PROTOCOL, []persistence.AFilter{persistence.UnarchivedAFilter()}); err != nil {
+		t.Errorf("Error finding agreement ag2: %v", err)
+	} else if ag != nil {
+		t.Errorf("Archived agreement ag2 should be filtered out but got %v", ag)
+	}
+
+	if err := db.DeleteAgreement("ag2", TEST_PROTOCOL); err != nil {
+		t.Errorf("Error deleting agreement ag2: %v", err)
+	} else if ag, err := db.FindSingleAgreementByAgreementId("ag2", TEST_PROTOCOL, []persistence.AFilter{}); err != nil {
+		t.Errorf("Error finding agreement ag2: %v", err)
+	} else if ag != nil {
+		t.Errorf("Agreement ag2 should be deleted but got %v", ag)
+	}
+}
+
+func Test_WorkloadUsage_lifecycle(t *testing.T) {
+
+	dir := utsetup(t)
+	defer os.RemoveAll(dir)
+	db := utopen(t, dir)
+	defer db.Close()
+
+	if err := db.NewWorkloadUsage("myorg/dev1", "", "myorg/pol1", 1, 600, 60, false, "ag1"); err != nil {
+		t.Fatalf("Error creating workload usage: %v", err)
+	} else if err := db.NewWorkloadUsage("myorg/dev1", "", "myorg/pol1", 1, 600, 60, false, "ag1"); err == nil {
+		t.Errorf("Expected an error creating a duplicate workload usage")
+	} else if err := db.NewWorkloadUsage("myorg/dev2", "", "myorg/pol1", 1, 600, 60, false, "ag2"); err != nil {
+		t.Fatalf("Error creating workload usage: %v", err)
+	}
+
+	if num, err := db.GetWorkloadUsagesCount(db.PrimaryPartition()); err != nil {
+		t.Errorf("Error counting workload usages: %v", err)
+	} else if num != 2 {
+		t.Errorf("Expected 2 workload usages but got %v", num)
+	}
+
+	// Only the valid state transitions are written, the agreement id is not replaced by another agreement id.
+	// The workload usage that is returned is the one that was written.
+	if wu, err := db.UpdatePriority("myorg/dev1", "myorg/pol1", 2, 300, 30, "ag3"); err != nil {
+		t.Errorf("Error updating workload usage priority: %v", err)
+	} else if wu.Priority != 2 || wu.RetryDurationS != 300 || wu.CurrentAgreementId != "ag1" {
+		t.Errorf("Wrong updated workload usage %v", wu)
+	}
+
+	if wu, err := db.FindSingleWorkloadUsageByDeviceAndPolicyName("myorg/dev1", "myorg/pol1"); err != nil {
+		t.Errorf("Error finding workload usage: %v", err)
+	} else if wu == nil || wu.Priority != 2 || wu.RetryDurationS != 300 || wu.CurrentAgreementId != "ag1" {
+		t.Errorf("Workload usage was not updated in the database: %v", wu)
+	}
+
+	if wus, err := db.FindWorkloadUsages([]persistence.WUFilter{persistence.DWUFilter("myorg/dev2")}); err != nil {
+		t.Errorf("Error finding workload usages: %v", err)
+	} else if len(wus) != 1 || wus[0].CurrentAgreementId != "ag2" {
+		t.Errorf("Expected the workload usage of myorg/dev2 but got %v", wus)
+	}
+
+	if err := db.DeleteWorkloadUsage("myorg/dev2", "myorg/pol1"); err != nil {
+		t.Errorf("Error deleting workload usage: %v", err)
+	} else if wu, err := db.FindSingleWorkloadUsageByDeviceAndPolicyName("myorg/dev2", "myorg/pol1"); err != nil {
+		t.Errorf("Error finding workload usage: %v", err)
+	} else if wu != nil {
+		t.Errorf("Workload usage should be deleted but got %v", wu)
+	}
+}
+
+// Create a directory for the database file.
+func utsetup(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "utsqlite-")
+	if err != nil {
+		t.Fatalf("Error creating the database directory: %v", err)
+	}
+	return dir
+}
+
+// Open the database in the directory, as a new agbot instance.
+func utopen(t *testing.T, dir string) *AgbotSqliteDB {
+	cfg := &config.HorizonConfig{
+		AgreementBot: config.AGConfig{
+			Sqlite: config.SqliteConfig{DBPath: dir},
+		},
+	}
+
+	db := new(AgbotSqliteDB)
+	if err := db.Initialize(cfg); err != nil {
+		t.Fatalf("Error initializing the database: %v", err)
+	}
+	return db
+}
@@ -0,0 +1,120 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/open-horizon/anax/agreementbot/persistence"
+)
+
+// Constants for the sql table operations required to manage node upgrades for nodes in HA groups
+
+// Create the ha group table. This table is not partitioned.
+const CREATE_HA_GROUP_UPGRADE_MAIN_TABLE = `CREATE TABLE IF NOT EXISTS ha_group_updates (
+	group_name text NOT NULL,
+	org_id text NOT NULL,
+	node_id text NOT NULL,
+	nmp_id text NOT NULL,
+	updated timestamp DEFAULT current_timestamp
+);`
+
+// Add the group, node, and nmp that it is upgrading with if the ha group is not already in the table. This statement and the
+// query that follows it run in the same write transaction, which is the SQLite equivalent of the Postgresql function.
+const HA_GROUP_ADD_IF_NOT_PRESENT = `INSERT INTO ha_group_updates (group_name, org_id, node_id, nmp_id)
+	SELECT ?1, ?2, ?3, ?4
+	WHERE NOT EXISTS (SELECT node_id FROM ha_group_updates WHERE group_name = ?1 AND org_id = ?2);`
+
+const HA_GROUP_DELETE_NODE_ALL = `DELETE FROM ha_group_updates `
+
+const HA_GROUP_DELETE_NODE = `DELETE FROM ha_group_updates WHERE group_name = ?1 AND org_id = ?2 AND node_id = ?3 AND nmp_id = ?4 `
+
+const HA_GROUP_DELETE_NODE_BY_GROUP = `DELETE FROM ha_group_updates WHERE group_name = ?1 AND org_id = ?2 `
+
+const HA_GROUP_GET_IN_ORG_GROUP = `SELECT node_id, nmp_id FROM ha_group_updates WHERE org_id = ?1 AND group_name = ?2`
+
+const HA_GROUP_GET_ALL_NODES = `SELECT group_name, org_id, node_id, nmp_id FROM ha_group_updates`
+
+func (db *AgbotSqliteDB) CheckIfGroupPresentAndUpdateHATable(requestingNode persistence.UpgradingHAGroupNode) (*persistence.UpgradingHAGroupNode, error) {
+	var dbNodeId sql.NullString
+	var dbNmpId sql.NullString
+
+	tx, err := db.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("unable to start transaction for ha group %v update, error: %v", requestingNode.GroupName, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(HA_GROUP_ADD_IF_NOT_PRESENT, requestingNode.GroupName, requestingNode.OrgId, requestingNode.NodeId, requestingNode.NMPName); err != nil {
+		return nil, fmt.Errorf("error adding ha node %v in group %v to the updates table, error: %v", requestingNode.NodeId, requestingNode.GroupName, err)
+	}
+
+	qerr := tx.QueryRow(HA_GROUP_GET_IN_ORG_GROUP, requestingNode.OrgId, requestingNode.GroupName).Scan(&dbNodeId, &dbNmpId)
+
+	if qerr != nil && qerr != sql.ErrNoRows {
+		return nil, fmt.Errorf("error scanning row for ha nodes in group %v currently updating error: %v", requestingNode.GroupName, qerr)
+	}
+
+	if !dbNodeId.Valid {
+		return nil, fmt.Errorf("node id returned from ha group updates table search is not valid")
+	} else if !dbNmpId.Valid {
+		return nil, fmt.Errorf("nmp id returned from ha group updates table search is not valid")
+	} else if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("unable to commit ha group %v update, error: %v", requestingNode.GroupName, err)
+	} else {
+		return &persistence.UpgradingHAGroupNode{GroupName: requestingNode.GroupName, OrgId: requestingNode.OrgId, NodeId: dbNodeId.String, NMPName: dbNmpId.String}, nil
+	}
+}
+
+func (db *AgbotSqliteDB) DeleteAllUpgradingHANode() error {
+	_, qerr := db.db.Exec(HA_GROUP_DELETE_NODE_ALL)
+	return qerr
+}
+
+func (db *AgbotSqliteDB) DeleteHAUpgradeNode(nodeToDelete persistence.UpgradingHAGroupNode) error {
+	_, qerr := db.db.Exec(HA_GROUP_DELETE_NODE, nodeToDelete.GroupName, nodeToDelete.OrgId, nodeToDelete.NodeId, nodeToDelete.NMPName)
+	return qerr
+}
+
+func (db *AgbotSqliteDB) DeleteHAUpgradeNodeByGroup(orgId string, groupName string) error {
+	_, qerr := db.db.Exec(HA_GROUP_DELETE_NODE_BY_GROUP, groupName, orgId)
+	return qerr
+}
+
+func (db *AgbotSqliteDB) ListUpgradingNodeInGroup(orgId string, groupName string) (*persistence.UpgradingHAGroupNode, error) {
+	var dbNodeId sql.NullString
+	var dbNmpId sql.NullString
+	qerr := db.db.QueryRow(HA_GROUP_GET_IN_ORG_GROUP, orgId, groupName).Scan(&dbNodeId, &dbNmpId)
+	if qerr != nil && qerr != sql.ErrNoRows {
+		return nil, fmt.Errorf("error querying database for upgrading node in group %v/%v. Error was: %v", orgId, groupName, qerr)
+	}
+
+	if dbNodeId.Valid {
+		return &persistence.UpgradingHAGroupNode{GroupName: groupName, OrgId: orgId, NodeId: dbNodeId.String, NMPName: dbNmpId.String}, nil
+	}
+
+	return nil, nil
+}
+
+func (db *AgbotSqliteDB) ListAllUpgradingHANode() ([]persistence.UpgradingHAGroupNode, error) {
+	upgradingNodes := []persistence.UpgradingHAGroupNode{}
+	rows, err := db.db.Query(HA_GROUP_GET_ALL_NODES)
+
+	if err != nil {
+		return nil, fmt.Errorf("error querying database for all upgrading HA nodes. Error was: %v", err)
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var dbHAGroup sql.NullString
+		var dbOrg sql.NullString
+		var dbNodeId sql.NullString
+		var dbNmpId sql.NullString
+
+		if err = rows.Scan(&dbHAGroup, &dbOrg, &dbNodeId, &dbNmpId); err != nil {
+			return nil, fmt.Errorf("error scanning row for ha nodes, error was: %v", err)
+		}
+
+		upgradingNodes = append(upgradingNodes, persistence.UpgradingHAGroupNode{GroupName: dbHAGroup.String, OrgId: dbOrg.String, NodeId: dbNodeId.String, NMPName: dbNmpId.String})
+	}
+
+	return upgradingNodes, nil
+}
@@ -0,0 +1,128 @@
+//go:build unit
+// +build unit
+
+package sqlite
+
+import (
+	"github.com/open-horizon/anax/agreementbot/persistence"
+	"os"
+	"testing"
+)
+
+func Test_HAGroupUpgrades(t *testing.T) {
+
+	dir := utsetup(t)
+	defer os.RemoveAll(dir)
+	db := utopen(t, dir)
+	defer db.Close()
+
+	// Only one node of an HA group is upgraded at a time.
+	if n, err := db.CheckIfGroupPresentAndUpdateHATable(persistence.UpgradingHAGroupNode{GroupName: "group1", OrgId: "myorg", NodeId: "dev1", NMPName: "nmp1"}); err != nil {
+		t.Errorf("Error adding upgrading node: %v", err)
+	} else if n.NodeId != "dev1" {
+		t.Errorf("Expected node dev1 to be upgrading but got %v", n)
+	}
+
+	if n, err := db.CheckIfGroupPresentAndUpdateHATable(persistence.UpgradingHAGroupNode{GroupName: "group1", OrgId: "myorg", NodeId: "dev2", NMPName: "nmp1"}); err != nil {
+		t.Errorf("Error adding upgrading node: %v", err)
+	} else if n.NodeId != "dev1" {
+		t.Errorf("Expected node dev1 to be upgrading but got %v", n)
+	}
+
+	if err := db.DeleteHAUpgradeNode(persistence.UpgradingHAGroupNode{GroupName: "group1", OrgId: "myorg", NodeId: "dev1", NMPName: "nmp1"}); err != nil {
+		t.Errorf("Error deleting upgrading node: %v", err)
+	} else if n, err := db.ListUpgradingNodeInGroup("myorg", "group1"); err != nil {
+		t.Errorf("Error listing upgrading node: %v", err)
+	} else if n != nil {
+		t.Errorf("Expected no upgrading node but got %v", n)
+	}
+
+	// Only one node of an HA group is upgraded at a time for each policy.
+	if nodeId, err := db.InsertHAUpgradingWorkloadForGroupAndPolicy("myorg", "group1", "myorg/pol1", "myorg/dev1"); err != nil {
+		t.Errorf("Error inserting upgrading workload: %v", err)
+	} else if nodeId != "myorg/dev1" {
+		t.Errorf("Expected node myorg/dev1 to be upgrading but got %v", nodeId)
+	}
+
+	if nodeId, err := db.InsertHAUpgradingWorkloadForGroupAndPolicy("myorg", "group1", "myorg/pol1", "myorg/dev2"); err != nil {
+		t.Errorf("Error inserting upgrading workload: %v", err)
+	} else if nodeId != "myorg/dev1" {
+		t.Errorf("Expected node myorg/dev1 to be upgrading but got %v", nodeId)
+	} else if _, err := db.InsertHAUpgradingWorkloadForGroupAndPolicy("myorg", "group1", "myorg/pol2", "myorg/dev2"); err != nil {
+		t.Errorf("Error inserting upgrading workload: %v", err)
+	}
+
+	if err := db.UpdateHAUpgradingWorkloadForGroupAndPolicy("myorg", "group1", "myorg/pol1", "myorg/dev3"); err != nil {
+		t.Errorf("Error updating upgrading workload: %v", err)
+	} else if w, err := db.GetHAUpgradingWorkload("myorg", "group1", "myorg/pol1"); err != nil {
+		t.Errorf("Error getting upgrading workload: %v", err)
+	} else if w == nil || w.NodeId != "myorg/dev3" {
+		t.Errorf("Expected node myorg/dev3 to be upgrading but got %v", w)
+	}
+
+	if ws, err := db.ListHAUpgradingWorkloadsByGroupName("myorg", "group1"); err != nil {
+		t.Errorf("Error listing upgrading workloads: %v", err)
+	} else if len(ws) != 2 {
+		t.Errorf("Expected 2 upgrading workloads but got %v", ws)
+	}
+
+	if err := db.DeleteHAUpgradingWorkloadsByGroupName("myorg", "group1"); err != nil {
+		t.Errorf("Error deleting upgrading workloads: %v", err)
+	} else if ws, err := db.ListAllHAUpgradingWorkloads(); err != nil {
+		t.Errorf("Error listing upgrading workloads: %v", err)
+	} else if len(ws) != 0 {
+		t.Errorf("Expected no upgrading workloads but got %v", ws)
+	}
+}
+
+func Test_WorkloadRollout(t *testing.T) {
+
+	dir := utsetup(t)
+	defer os.RemoveAll(dir)
+	db := utopen(t, dir)
+	defer db.Close()
+
+	rollout := &persistence.WorkloadRollout{PolicyName: "myorg/pol1", Version: "2.0.0", PreviousVersion: "1.0.0", State: persistence.ROLLOUT_STATE_UPGRADING, Step: 1}
+	if r, err := db.InsertWorkloadRollout(rollout); err != nil {
+		t.Fatalf("Error inserting workload rollout: %v", err)
+	} else if r.Version != "2.0.0" {
+		t.Errorf("Wrong workload rollout %v", r)
+	}
+
+	if _, err := db.SingleWorkloadRolloutUpdate("myorg/pol1", func(r persistence.WorkloadRollout) *persistence.WorkloadRollout {
+		r.Step = 2
+		return &r
+	}); err != nil {
+		t.Errorf("Error updating workload rollout: %v", err)
+	}
+
+	// The rollout of the same version is not replaced.
+	if r, err := db.InsertWorkloadRollout(rollout); err != nil {
+		t.Errorf("Error inserting workload rollout: %v", err)
+	} else if r.Step != 2 {
+		t.Errorf("Expected the existing rollout at step 2 but got %v", r)
+	}
+
+	// The rollout of another version replaces it.
+	if r, err := db.InsertWorkloadRollout(&persistence.WorkloadRollout{PolicyName: "myorg/pol1", Version: "3.0.0", State: persistence.ROLLOUT_STATE_UPGRADING, Step: 1}); err != nil {
+		t.Errorf("Error inserting workload rollout: %v", err)
+	} else if r.Version != "3.0.0" {
+		t.Errorf("Expected the rollout of 3.0.0 but got %v", r)
+	} else if r, err := db.GetWorkloadRollout("myorg/pol1"); err != nil {
+		t.Errorf("Error getting workload rollout: %v", err)
+	} else if r == nil || r.Version != "3.0.0" || r.Step != 1 {
+		t.Errorf("Expected the rollout of 3.0.0 but got %v", r)
+	}
+
+	if _, err := db.SingleWorkloadRolloutUpdate("myorg/pol2", func(r persistence.WorkloadRollout) *persistence.WorkloadRollout { return &r }); err == nil {
+		t.Errorf("Expected an error updating an unknown workload rollout")
+	}
+
+	if err := db.DeleteWorkloadRollout("myorg/pol1"); err != nil {
+		t.Errorf("Error deleting workload rollout: %v", err)
+	} else if rs, err := db.ListAllWorkloadRollouts(); err != nil {
+		t.Errorf("Error listing workload rollouts: %v", err)
+	} else if len(rs) != 0 {
+		t.Errorf("Expected no workload rollouts but got %v", rs)
+	}
+}
@@ -0,0 +1,150 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/config"
+	uuid "github.com/satori/go.uuid"
+	_ "modernc.org/sqlite"
+	"os"
+)
+
+// This function is called by the anax main to allow the configured database a chance to initialize itself.
+// This function is called every time the agbot starts, so it has to handle the following cases:
+// - The database file does not exist yet
+// - The database contains structures with schema that are not at the latest version
+// - The database is completely up to date WRT the schemas
+func (db *AgbotSqliteDB) Initialize(cfg *config.HorizonConfig) error {
+
+	if err := os.MkdirAll(cfg.AgreementBot.Sqlite.DBPath, 0700); err != nil {
+		return errors.New(fmt.Sprintf("unable to create directory %v for SQLite DB configuration, error: %v", cfg.AgreementBot.Sqlite.DBPath, err))
+	}
+
+	dbFile, connectInfo := cfg.AgreementBot.Sqlite.MakeConnectionString()
+
+	glog.V(1).Infof("Opening SQLite database: %v", dbFile)
+
+	if sqdb, err := sql.Open("sqlite", connectInfo); err != nil {
+		return errors.New(fmt.Sprintf("unable to open SQLite database %v, error: %v", dbFile, err))
+	} else if err := sqdb.Ping(); err != nil {
+		return errors.New(fmt.Sprintf("unable to ping SQLite database %v, error: %v", dbFile, err))
+	} else {
+		db.db = sqdb
+
+		// Initialize the DB instance fields.
+		db.identity = uuid.NewV4().String()
+		glog.V(1).Infof("Agreementbot %v initializing partitions", db.identity)
+
+		// Now create the tables and initialize them as necessary.
+		glog.V(3).Infof("SQLite database tables initializing.")
+
+		// Create the version table if necessary, and insert the current version row if necessary.
+		if _, err := db.db.Exec(VERSION_CREATE_TABLE); err != nil {
+			return errors.New(fmt.Sprintf("unable to create version table, error: %v", err))
+		} else if _, err := db.db.Exec(VERSION_INSERT); err != nil {
+			return errors.New(fmt.Sprintf("unable to insert singleton version row, error: %v", err))
+		}
+
+		// Create the search session table if necessary.
+		if _, err := db.db.Exec(SEARCH_SESSIONS_CREATE_MAIN_TABLE); err != nil {
+			return errors.New(fmt.Sprintf("unable to create search session table, error: %v", err))
+		}
+
+		// Create the partition table.
+		if _, err := db.db.Exec(PARTITION_CREATE_MAIN_TABLE); err != nil {
+			return errors.New(fmt.Sprintf("unable to create partition table, error: %v", err))
+		}
+
+		// Claim a partition for ourselves.
+		if partition, err := db.ClaimPartition(cfg.GetPartitionStale()); err != nil {
+			return errors.New(fmt.Sprintf("unable to claim a partition, error: %v", err))
+		} else {
+			db.primaryPartition = partition
+			db.partitions = append(db.partitions, partition)
+		}
+
+		// Create the workload usage table and index if necessary.
+		if _, err := db.db.Exec(WORKLOAD_USAGE_CREATE_MAIN_TABLE); err != nil {
+			return errors.New(fmt.Sprintf("unable to create workload usage table, error: %v", err))
+		} else if _, err := db.db.Exec(WORKLOAD_USAGE_CREATE_INDEX); err != nil {
+			return errors.New(fmt.Sprintf("unable to create workload usage table index, error: %v", err))
+		}
+
+		// Create the agreement table and indexes if necessary.
+		if _, err := db.db.Exec(AGREEMENT_CREATE_MAIN_TABLE); err != nil {
+			return errors.New(fmt.Sprintf("unable to create agreements table, error: %v", err))
+		} else if _, err := db.db.Exec(AGREEMENT_CREATE_INDEX); err != nil {
+			return errors.New(fmt.Sprintf("unable to create agreements table index, error: %v", err))
+		} else if _, err := db.db.Exec(AGREEMENT_CREATE_PARTITION_INDEX); err != nil {
+			return errors.New(fmt.Sprintf("unable to create agreements partition index, error: %v", err))
+		}
+
+		// Create the secrets tables and indexes if necessary.
+		if _, err := db.db.Exec(SECRET_CREATE_MAIN_TABLE_POLICY); err != nil {
+			return errors.New(fmt.Sprintf("unable to create policy secrets table, error: %v", err))
+		} else if _, err := db.db.Exec(SECRET_CREATE_INDEX_POLICY); err != nil {
+			return errors.New(fmt.Sprintf("unable to create policy secrets table index, error: %v", err))
+		} else if _, err := db.db.Exec(SECRET_CREATE_MAIN_TABLE_PATTERN); err != nil {
+			return errors.New(fmt.Sprintf("unable to create pattern secrets table, error: %v", err))
+		} else if _, err := db.db.Exec(SECRET_CREATE_INDEX_PATTERN); err != nil {
+			return errors.New(fmt.Sprintf("unable to create pattern secrets table index, error: %v", err))
+		}
+
+		// Create the ha group upgrade table.
+		if _, err := db.db.Exec(CREATE_HA_GROUP_UPGRADE_MAIN_TABLE); err != nil {
+			return fmt.Errorf("unable to create ha group update table, error: %v", err)
+		}
+
+		// Create the ha group service upgrade table.
+		if _, err := db.db.Exec(CREATE_HA_WORKLOAD_UPGRADE_MAIN_TABLE); err != nil {
+			return fmt.Errorf("unable to create ha workload upgrade table, error: %v", err)
+		}
+
+		// Create the workload rollout table.
+		if _, err := db.db.Exec(WORKLOAD_ROLLOUT_CREATE_MAIN_TABLE); err != nil {
+			return fmt.Errorf("unable to create workload rollout table, error: %v", err)
+		}
+
+		glog.V(3).Infof("SQLite database tables exist.")
+
+		// Migrate the database tables if necessary. Extract the current schema version from the version table,
+		// and then run each version's migration SQL to bring the database up to the current version supported
+		// by this code.
+		var dbVersion int
+		var description string
+		var timestamp string
+		if err := db.db.QueryRow(VERSION_QUERY).Scan(&dbVersion, &description, &timestamp); err != nil {
+			return errors.New(fmt.Sprintf("error scanning row for current version, error: %v", err))
+		} else {
+			glog.V(3).Infof("SQLite database tables are at version %v, %v, as of %v.", dbVersion, description, timestamp)
+		}
+
+		if dbVersion < HIGHEST_DATABASE_VERSION {
+			glog.V(3).Infof("SQLite database tables upgrading from version %v to %v.", dbVersion, HIGHEST_DATABASE_VERSION)
+
+			// Each new database version has it's own key in the migration SQL map.
+			for v := dbVersion + 1; v <= HIGHEST_DATABASE_VERSION; v++ {
+
+				// Run each SQL statement in the array of SQL statements for the current version.
+				for si := 0; si < len(migrationSQL[v].sql); si++ {
+					if _, err := db.db.Exec(migrationSQL[v].sql[si]); err != nil {
+						return errors.New(fmt.Sprintf("unable to run SQL migration statement version %v, index %v, statement %v, error: %v", v, si, migrationSQL[v].sql[si], err))
+					}
+				}
+				if _, err := db.db.Exec(VERSION_UPDATE, v, migrationSQL[v].description); err != nil {
+					return errors.New(fmt.Sprintf("unable to update version table, error: %v", err))
+				}
+				glog.V(3).Infof("SQLite database tables upgraded to version %v, %v", v, migrationSQL[v].description)
+			}
+
+			glog.V(3).Infof("SQLite database tables upgraded to version %v", HIGHEST_DATABASE_VERSION)
+		}
+
+		glog.V(3).Infof("SQLite database tables initialized.")
+
+	}
+	return nil
+
+}
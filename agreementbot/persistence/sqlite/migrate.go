@@ -0,0 +1,62 @@
+package sqlite
+
+import (
+	"errors"
+	"fmt"
+	"github.com/open-horizon/anax/agreementbot/persistence"
+)
+
+// Functions used to import records from another database implementation (e.g. bolt) into the primary partition. They are
+// not part of the agbot database interface because they are only used by the database migration tool.
+
+const SEARCH_SESSIONS_EXISTS = `SELECT COUNT(*) FROM search_sessions WHERE policyName = ?1;`
+
+// Insert an agreement into the primary partition unless an agreement with the same id is already there. The return value
+// indicates whether or not the agreement was inserted, so that a migration can be safely re-run.
+func (db *AgbotSqliteDB) ImportAgreement(ag *persistence.Agreement, protocol string) (bool, error) {
+
+	if existing, err := db.FindSingleAgreementByAgreementId(ag.CurrentAgreementId, protocol, []persistence.AFilter{}); err != nil {
+		return false, err
+	} else if existing != nil {
+		return false, nil
+	} else if err := db.insertAgreement(ag, protocol); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Insert a workload usage into the primary partition unless one already exists for the same device and policy. The return
+// value indicates whether or not the workload usage was inserted.
+func (db *AgbotSqliteDB) ImportWorkloadUsage(wu *persistence.WorkloadUsage) (bool, error) {
+
+	if existing, err := db.FindSingleWorkloadUsageByDeviceAndPolicyName(wu.DeviceId, wu.PolicyName); err != nil {
+		return false, err
+	} else if existing != nil {
+		return false, nil
+	} else if err := db.insertWorkloadUsage(wu); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Return true if there is a search session for the input policy.
+func (db *AgbotSqliteDB) SearchSessionExists(policyName string) (bool, error) {
+	var num int64
+	if err := db.db.QueryRow(SEARCH_SESSIONS_EXISTS, policyName).Scan(&num); err != nil {
+		return false, errors.New(fmt.Sprintf("error checking for %v search session, error: %v", policyName, err))
+	}
+	return num != 0, nil
+}
+
+// Heartbeat the partition of a migration. Unlike HeartbeatPartition, an error is returned when the partition has been taken
+// over by another agbot, so that the migration stops writing into it instead of crashing.
+func (db *AgbotSqliteDB) HeartbeatMigrationPartition() error {
+	if res, err := db.db.Exec(PARTITION_HEARTBEAT, db.PrimaryPartition(), db.identity); err != nil {
+		return errors.New(fmt.Sprintf("unable to heartbeat partition %v, error: %v", db.PrimaryPartition(), err))
+	} else if num, err := res.RowsAffected(); err != nil {
+		return errors.New(fmt.Sprintf("error getting rows affected by the heartbeat of partition %v, error: %v", db.PrimaryPartition(), err))
+	} else if num != 1 {
+		return errors.New(fmt.Sprintf("partition %v is no longer owned by %v, it has been taken over by another agbot", db.PrimaryPartition(), db.identity))
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/golang/glog"
+)
+
+// Constants for the SQL statements that are used to work with partitions. The SQLite database is a single file that is used by
+// a single agbot process, but the partition scheme is the same one used by the Postgresql implementation so that the rest of the
+// agbot behaves the same way regardless of the database in use. Each time the agbot starts, it creates a new identity for itself
+// and claims a partition. If the previous agbot process quiesced, its partition is claimed and reused immediately. If the previous
+// process terminated unexpectedly, its partition will not be heartbeated and becomes stale after the configured "stale" timeout.
+// The agbot periodically looks for stale partitions and moves the records in them into its own partition.
+//
+// SQLite does not support table inheritance, so the agreement related tables are not split into per partition tables. Instead,
+// each record carries the partition it lives in and all queries are constrained by partition. Moving a partition is therefore a
+// simple update of the partition column, and there are never any partition tables to drop.
+//
+// partitions schema:
+// id:        The partition id, serially incremented by the database when a new partition is created.
+// owner:     The UUID of the agbot that owns this partition. NULL means that the previous owner quiesced so the partition is
+//            available to be taken over immediately.
+// heartbeat: The unix time of the last heartbeat. If the owning agbot stops heartbeating, the partition becomes eligible to
+//            be taken over.
+//
+
+const PARTITION_CREATE_MAIN_TABLE = `CREATE TABLE IF NOT EXISTS partitions (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	owner text,
+	heartbeat integer
+);`
+
+const PARTITION_OWNER = `SELECT owner FROM partitions WHERE id = ?1;`
+
+const PARTITION_INSERT = `INSERT INTO partitions (owner, heartbeat) VALUES (?1, strftime('%s','now')) RETURNING id, owner;`
+
+const PARTITION_HEARTBEAT = `UPDATE partitions SET heartbeat = strftime('%s','now') WHERE id = ?1 AND owner = ?2;`
+
+const PARTITION_GET_HEARTBEAT = `SELECT heartbeat FROM partitions WHERE id = ?1;`
+
+const PARTITION_QUIESCE = `UPDATE partitions SET owner = NULL, heartbeat = NULL WHERE owner = ?1;`
+
+const PARTITION_DELETE = `DELETE FROM partitions WHERE id = ?1;`
+
+// There are no stored procedures in SQLite. This statement runs in a write transaction (the database is opened with immediate
+// transaction locking), so no other connection can claim the same partition while it is running.
+const PARTITION_CLAIM_UNOWNED = `UPDATE partitions SET owner = ?1, heartbeat = strftime('%s','now')
+	WHERE id = (
+		SELECT id FROM partitions
+			WHERE
+				(owner IS NULL AND heartbeat IS NULL)
+				OR
+				(owner IS NOT NULL AND (strftime('%s','now') - heartbeat) > ?2)
+			LIMIT 1
+		)
+	RETURNING id, owner;`
+
+// Functions related to partitions in the SQLite database. The workload usages should always be using the same partitions
+// as the agreements, or fewer partitions if an agreement partition contains only archived records.
+
+// Look for an ownerless or stale partition. If none exist, create a new partition.
+func (db *AgbotSqliteDB) ClaimPartition(timeout uint64) (string, error) {
+
+	if unownedPartition, err := db.findUnownedPartition(timeout); err != nil {
+		return "", errors.New(fmt.Sprintf("unable to claim an unowned partition, error: %v", err))
+	} else if unownedPartition == "" {
+		// There were no claimable partitions, so create a new partition.
+		var id string
+		var rowowner sql.NullString
+		if err := db.db.QueryRow(PARTITION_INSERT, db.identity).Scan(&id, &rowowner); err != nil {
+			return "", errors.New(fmt.Sprintf("AgreementBot %v unable to insert new partition, error: %v", db.identity, err))
+		} else {
+			glog.V(5).Infof("AgreementBot %v creating new partition %v", rowowner.String, id)
+			return id, nil
+		}
+	} else {
+		return unownedPartition, nil
+	}
+}
+
+func (db *AgbotSqliteDB) findUnownedPartition(timeout uint64) (string, error) {
+	var id string
+	var rowowner sql.NullString
+
+	tx, err := db.db.Begin()
+	if err != nil {
+		return "", errors.New(fmt.Sprintf("unable to start transaction, error: %v", err))
+	}
+	defer tx.Rollback()
+
+	if err := tx.QueryRow(PARTITION_CLAIM_UNOWNED, db.identity, timeout).Scan(&id, &rowowner); err != nil && err != sql.ErrNoRows {
+		return "", errors.New(fmt.Sprintf("unable to claim stale, error: %v", err))
+	} else if err == nil {
+		// We claimed a previously unowned row.
+		if err := tx.Commit(); err != nil {
+			return "", errors.New(fmt.Sprintf("unable to commit claim on unowned row, error: %v", err))
+		}
+		glog.Infof("AgreementBot %v claimed partition %v", rowowner.String, id)
+		return id, nil
+	} else {
+		// The no rows error was returned, so there were no partitions to be claimed.
+		return "", tx.Commit()
+	}
+}
+
+// Locate all the partitions currently found in the database.
+func (db *AgbotSqliteDB) FindPartitions() ([]string, error) {
+
+	if allPartitions, err := db.FindAgreementPartitions(); err != nil {
+		return nil, err
+	} else {
+		return allPartitions, nil
+	}
+
+}
+
+// Retrieve the partition owner for a given partition.
+func (db *AgbotSqliteDB) GetPartitionOwner(id string) (string, error) {
+
+	var owner sql.NullString
+	if err := db.db.QueryRow(PARTITION_OWNER, id).Scan(&owner); err != nil {
+		return "", errors.New(fmt.Sprintf("error scanning partition %v owner result, error: %v", id, err))
+	} else if !owner.Valid {
+		return "NO OWNER", nil
+	} else {
+		return owner.String, nil
+	}
+
+}
+
+// Update the hearbeat for our partition.
+func (db *AgbotSqliteDB) HeartbeatPartition() error {
+
+	if res, err := db.db.Exec(PARTITION_HEARTBEAT, db.PrimaryPartition(), db.identity); err != nil {
+		return errors.New(fmt.Sprintf("AgreementBot %v unable to heartbeat, error: %v", db.identity, err))
+	} else if num, err := res.RowsAffected(); err != nil {
+		return errors.New(fmt.Sprintf("AgreementBot %v error getting rows affected, error: %v", db.identity, err))
+	} else if num == 0 {
+		msg := fmt.Sprintf("AgreementBot %v heartbeat to partition %v failed to update any rows, assuming the partition has been stolen due to previously missing heartbeats.", db.identity, db.PrimaryPartition())
+		glog.Errorf(msg)
+		panic(msg)
+	} else if num != 1 {
+		return errors.New(fmt.Sprintf("AgreementBot %v, heartbeat update should have changed 1 row, but changed %v", db.identity, num))
+	} else {
+		glog.V(3).Infof("AgreementBot %v heartbeat", db.identity)
+	}
+	return nil
+}
+
+// Retrieve the heartbeat timestamp for our partition.
+func (db *AgbotSqliteDB) GetHeartbeat() (uint64, error) {
+
+	var hb sql.NullInt64
+	if err := db.db.QueryRow(PARTITION_GET_HEARTBEAT, db.PrimaryPartition()).Scan(&hb); err != nil {
+		return 0, errors.New(fmt.Sprintf("error scanning partition %v heartbeat result, error: %v", db.PrimaryPartition(), err))
+	} else {
+		return uint64(hb.Int64), nil
+	}
+}
+
+// Quiesce our partition.
+func (db *AgbotSqliteDB) QuiescePartition() error {
+
+	if _, err := db.db.Exec(PARTITION_QUIESCE, db.identity); err != nil {
+		return errors.New(fmt.Sprintf("Agbot %v unable to quiesce partition, error: %v", db.identity, err))
+	} else {
+		glog.V(3).Infof("AgreementBot %v quiesced partition", db.identity)
+	}
+	return nil
+}
+
+// Move all records from one partition to another if there is a stale or unowned partition in the database.
+func (db *AgbotSqliteDB) MovePartition(timeout uint64) (bool, error) {
+
+	if fromPartition, err := db.findUnownedPartition(timeout); err != nil {
+		return false, err
+	} else if fromPartition == "" {
+		glog.V(3).Infof("AgreementBot %v did not find an unowned database partition.", db.identity)
+		return false, nil
+	} else {
+		// We have found a partition and we have claimed it. Move all the agreement related records in the partition into our
+		// primary partition, and remove the partition row from the partitions table. This is all done under a single transaction
+		// so that if the agbot were to terminate during this time, the partition will eventually be claimed again and this same
+		// cleanup will be attempted again.
+		tx, err := db.db.Begin()
+		if err != nil {
+			return false, errors.New(fmt.Sprintf("unable to start transaction for moving agreements, error: %v", err))
+		}
+		defer tx.Rollback()
+
+		if _, err := tx.Exec(AGREEMENT_MOVE, fromPartition, db.PrimaryPartition()); err != nil {
+			return false, err
+		} else if _, err := tx.Exec(WORKLOAD_USAGE_MOVE, fromPartition, db.PrimaryPartition()); err != nil {
+			return false, err
+		} else if _, err := tx.Exec(SECRET_MOVE_PATTERN, fromPartition, db.PrimaryPartition()); err != nil {
+			return false, err
+		} else if _, err := tx.Exec(SECRET_DELETE_PARTITION_PATTERN, fromPartition); err != nil {
+			return false, err
+		} else if _, err := tx.Exec(SECRET_DELETE_PARTITION_POLICY, fromPartition); err != nil {
+			return false, err
+		} else if _, err := tx.Exec(PARTITION_DELETE, fromPartition); err != nil {
+			return false, err
+		} else {
+			if err := tx.Commit(); err != nil {
+				return false, errors.New(fmt.Sprintf("unable to commit transaction for moving agreements, error: %v", err))
+			}
+			glog.V(3).Infof("AgreementBot %v moved agreements, workload usage and secrets from partition %v to %v", db.identity, fromPartition, db.PrimaryPartition())
+		}
+	}
+	// We found a partition and moved all the records.
+	return true, nil
+}
@@ -0,0 +1,121 @@
+//go:build unit
+// +build unit
+
+package sqlite
+
+import (
+	"github.com/open-horizon/anax/agreementbot/persistence"
+	"github.com/open-horizon/anax/policy"
+	"os"
+	"testing"
+)
+
+func Test_Partition_move(t *testing.T) {
+
+	dir := utsetup(t)
+	defer os.RemoveAll(dir)
+
+	db1 := utopen(t, dir)
+	defer db1.Close()
+
+	if err := db1.AgreementAttempt("ag1", "myorg", "myorg/dev1", persistence.DEVICE_TYPE_DEVICE, "myorg/pol1", "", "", "", TEST_PROTOCOL, "", []string{"svc1"}, policy.NodeHealth{}, 0, 0); err != nil {
+		t.Fatalf("Error creating agreement ag1: %v", err)
+	} else if err := db1.NewWorkloadUsage("myorg/dev1", "", "myorg/pol1", 1, 600, 60, false, "ag1"); err != nil {
+		t.Fatalf("Error creating workload usage: %v", err)
+	} else if err := db1.AddManagedPolicySecret("myorg", "sec1", "myorg", "pol1", 10); err != nil {
+		t.Fatalf("Error creating policy secret: %v", err)
+	} else if err := db1.AddManagedPatternSecret("myorg", "sec1", "otherorg", "pat1", 10); err != nil {
+		t.Fatalf("Error creating pattern secret: %v", err)
+	} else if err := db1.AddManagedPatternSecret("otherorg", "sec2", "otherorg", "pat1", 10); err != nil {
+		t.Fatalf("Error creating pattern secret: %v", err)
+	}
+
+	// A second agbot gets its own partition while the first one is heartbeating.
+	db2 := utopen(t, dir)
+	defer db2.Close()
+
+	if db2.PrimaryPartition() == db1.PrimaryPartition() {
+		t.Errorf("Expected a new partition but got partition %v of the first agbot", db1.PrimaryPartition())
+	} else if owner, err := db2.GetPartitionOwner(db1.PrimaryPartition()); err != nil {
+		t.Errorf("Error getting partition owner: %v", err)
+	} else if owner != db1.identity {
+		t.Errorf("Expected partition %v to be owned by %v but got %v", db1.PrimaryPartition(), db1.identity, owner)
+	}
+
+	if err := db2.HeartbeatPartition(); err != nil {
+		t.Errorf("Error heartbeating partition: %v", err)
+	} else if hb, err := db2.GetHeartbeat(); err != nil {
+		t.Errorf("Error getting heartbeat: %v", err)
+	} else if hb == 0 {
+		t.Errorf("Expected a heartbeat for partition %v", db2.PrimaryPartition())
+	}
+
+	// There is nothing to move while the first agbot owns its partition.
+	if moved, err := db2.MovePartition(60); err != nil {
+		t.Errorf("Error moving partition: %v", err)
+	} else if moved {
+		t.Errorf("Partition %v of a live agbot should not be moved", db1.PrimaryPartition())
+	}
+
+	// The first agbot quiesces, the second one takes over its records.
+	if err := db1.QuiescePartition(); err != nil {
+		t.Errorf("Error quiescing partition: %v", err)
+	} else if owner, err := db2.GetPartitionOwner(db1.PrimaryPartition()); err != nil {
+		t.Errorf("Error getting partition owner: %v", err)
+	} else if owner != "NO OWNER" {
+		t.Errorf("Expected partition %v to have no owner but got %v", db1.PrimaryPartition(), owner)
+	}
+
+	if moved, err := db2.MovePartition(60); err != nil {
+		t.Errorf("Error moving partition: %v", err)
+	} else if !moved {
+		t.Errorf("Expected partition %v to be moved", db1.PrimaryPartition())
+	}
+
+	if partitions, err := db2.FindPartitions(); err != nil {
+		t.Errorf("Error finding partitions: %v", err)
+	} else if len(partitions) != 1 || partitions[0] != db2.PrimaryPartition() {
+		t.Errorf("Expected only partition %v but got %v", db2.PrimaryPartition(), partitions)
+	}
+
+	if ag, err := db2.FindSingleAgreementByAgreementId("ag1", TEST_PROTOCOL, []persistence.AFilter{}); err != nil {
+		t.Errorf("Error finding agreement ag1: %v", err)
+	} else if ag == nil {
+		t.Errorf("Agreement ag1 was not moved to partition %v", db2.PrimaryPartition())
+	}
+
+	if wu, err := db2.FindSingleWorkloadUsageByDeviceAndPolicyName("myorg/dev1", "myorg/pol1"); err != nil {
+		t.Errorf("Error finding workload usage: %v", err)
+	} else if wu == nil {
+		t.Errorf("Workload usage was not moved to partition %v", db2.PrimaryPartition())
+	}
+
+	// The pattern secrets from another org are moved, the policy secrets are recreated with new agreements.
+	if names, err := db2.GetManagedPatternSecretNames("", ""); err != nil {
+		t.Errorf("Error getting pattern secrets: %v", err)
+	} else if len(names) != 1 || names[0] != "myorg/sec1" {
+		t.Errorf("Expected pattern secret myorg/sec1 but got %v", names)
+	}
+
+	if names, err := db2.GetManagedPolicySecretNames("", ""); err != nil {
+		t.Errorf("Error getting policy secrets: %v", err)
+	} else if len(names) != 0 {
+		t.Errorf("Expected no policy secrets but got %v", names)
+	}
+
+	// A new agbot reuses the partition of a quiesced agbot.
+	if err := db2.QuiescePartition(); err != nil {
+		t.Errorf("Error quiescing partition: %v", err)
+	}
+
+	db3 := utopen(t, dir)
+	defer db3.Close()
+
+	if db3.PrimaryPartition() != db2.PrimaryPartition() {
+		t.Errorf("Expected partition %v to be claimed but got %v", db2.PrimaryPartition(), db3.PrimaryPartition())
+	} else if ag, err := db3.FindSingleAgreementByAgreementId("ag1", TEST_PROTOCOL, []persistence.AFilter{}); err != nil {
+		t.Errorf("Error finding agreement ag1: %v", err)
+	} else if ag == nil {
+		t.Errorf("Agreement ag1 should be in claimed partition %v", db3.PrimaryPartition())
+	}
+}
@@ -0,0 +1,212 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/cutil"
+	"strconv"
+	"time"
+)
+
+// Constants for the SQL statements that are used to manage search sessions. A search session is just a number. The Exchange
+// uses it like a key to indicate that a given policy search should return a single page of results. See the Postgresql
+// implementation of this file for a complete description of search sessions. The schema is the same as the Postgresql schema.
+// SQLite does not have stored procedures, so the multi-statement operations are run in a write transaction instead.
+//
+// schema:
+// policyName:          The fully qualified (org/policy-name) policy being searched
+// changedSince:        This is a linux epoch time stamp indicating that the exchange should return nodes that have changed since this time.
+// sessionToken:        This is a search session token. It is a number converted to a string.
+// sessionEnded:        Indicates that the current session is ended, so a new session can be allocated.
+// restartChangedSince: Indicates that an agbot was restarted, so this changedSince should be used when the next session is created.
+// updatingAgbot:       The UUID of the agbot that last updated this table/row.
+// updated:             The time when the agbot updated this table/row/
+//
+
+const SEARCH_SESSIONS_CREATE_MAIN_TABLE = `CREATE TABLE IF NOT EXISTS search_sessions (
+	policyName          text    PRIMARY KEY,
+	changedSince        integer NOT NULL,
+	sessionToken        integer NOT NULL,
+	sessionEnded        boolean NOT NULL,
+	restartChangedSince integer NOT NULL,
+	updatingAgbot       text    NOT NULL,
+	updated timestamp DEFAULT current_timestamp
+);`
+
+const SEARCH_SESSIONS_DUMP = `SELECT * FROM search_sessions;`
+
+const SEARCH_SESSIONS_ENDED = `SELECT sessionEnded FROM search_sessions WHERE policyName = ?1;`
+
+// Update changedSince based on an agbot restart.
+const SEARCH_SESSIONS_APPLY_RESTART = `UPDATE search_sessions
+	SET changedSince = restartChangedSince, restartChangedSince = 0
+	WHERE policyName = ?1 AND sessionEnded = 1 AND restartChangedSince != 0;`
+
+// Get a new session token.
+const SEARCH_SESSIONS_NEW_SESSION = `UPDATE search_sessions
+	SET sessionToken = sessionToken + 1, sessionEnded = 0, updatingAgbot = ?2, updated = current_timestamp
+	WHERE policyName = ?1 AND sessionEnded = 1;`
+
+// Handle session token roll over, note that the session was ended in the previous update.
+const SEARCH_SESSIONS_ROLL_OVER = `UPDATE search_sessions
+	SET sessionToken = 1
+	WHERE policyName = ?1 AND sessionEnded = 0 AND sessionToken > 2000000000;`
+
+const SEARCH_SESSIONS_INSERT = `INSERT OR IGNORE INTO search_sessions (policyName, changedSince, sessionToken, sessionEnded, restartChangedSince, updatingAgbot, updated)
+	VALUES (?1, 0, 1999999998, 0, 0, ?2, current_timestamp);`
+
+const SEARCH_SESSIONS_GET = `SELECT sessionToken, changedSince FROM search_sessions WHERE policyName = ?1;`
+
+const SEARCH_SESSIONS_UPDATE_CHANGED_SINCE = `UPDATE search_sessions
+	SET changedSince = ?2, sessionEnded = 1, updatingAgbot = ?3, updated = current_timestamp
+	WHERE changedSince = ?1 AND sessionEnded = 0 AND policyName = ?4;`
+
+const SEARCH_SESSIONS_RESET_RESTART_CHANGED_SINCE = `UPDATE search_sessions
+	SET restartChangedSince = ?1, updatingAgbot = ?2, updated = current_timestamp
+	WHERE sessionEnded = 0;`
+
+const SEARCH_SESSIONS_RESET_ENDED_CHANGED_SINCE = `UPDATE search_sessions
+	SET changedSince = ?1, updatingAgbot = ?2, updated = current_timestamp
+	WHERE sessionEnded = 1;`
+
+const SEARCH_SESSIONS_RESET_CHANGED_SINCE_FOR_POLICY = `UPDATE search_sessions
+	SET restartChangedSince = ?1, updatingAgbot = ?3, updated = current_timestamp
+	WHERE policyName = ?2 AND (restartChangedSince = 0 OR restartChangedSince > ?1);
+`
+
+// Functions related to the search session table.
+
+// Get the current search session from the DB. If the current session is ended, then a new session token will
+// be allocated and stored in the DB.
+func (db *AgbotSqliteDB) ObtainSearchSession(policyName string) (string, uint64, error) {
+
+	tx, err := db.db.Begin()
+	if err != nil {
+		return "", 0, errors.New(fmt.Sprintf("unable to start transaction for %v search session, error: %v", policyName, err))
+	}
+	defer tx.Rollback()
+
+	var ended sql.NullBool
+	if err := tx.QueryRow(SEARCH_SESSIONS_ENDED, policyName).Scan(&ended); err != nil && err != sql.ErrNoRows {
+		return "", 0, errors.New(fmt.Sprintf("error reading %v search session state, error: %v", policyName, err))
+	}
+
+	if ended.Valid && ended.Bool {
+		if _, err := tx.Exec(SEARCH_SESSIONS_APPLY_RESTART, policyName); err != nil {
+			return "", 0, errors.New(fmt.Sprintf("error applying restart changedSince to %v search session, error: %v", policyName, err))
+		} else if _, err := tx.Exec(SEARCH_SESSIONS_NEW_SESSION, policyName, db.identity); err != nil {
+			return "", 0, errors.New(fmt.Sprintf("error allocating new %v search session, error: %v", policyName, err))
+		} else if _, err := tx.Exec(SEARCH_SESSIONS_ROLL_OVER, policyName); err != nil {
+			return "", 0, errors.New(fmt.Sprintf("error rolling over %v search session, error: %v", policyName, err))
+		}
+	} else if !ended.Valid {
+		// The row doesnt exist at all.
+		if _, err := tx.Exec(SEARCH_SESSIONS_INSERT, policyName, db.identity); err != nil {
+			return "", 0, errors.New(fmt.Sprintf("error creating %v search session, error: %v", policyName, err))
+		}
+	}
+
+	var ss sql.NullInt64
+	var cs sql.NullInt64
+	if err := tx.QueryRow(SEARCH_SESSIONS_GET, policyName).Scan(&ss, &cs); err != nil {
+		return "", 0, errors.New(fmt.Sprintf("error obtaining %v search session, error: %v", policyName, err))
+	} else if !ss.Valid {
+		return "", 0, errors.New(fmt.Sprintf("returned search session for %v is not a valid integer", policyName))
+	} else if !cs.Valid {
+		return "", 0, errors.New(fmt.Sprintf("returned changedSince for %v is not a valid integer", policyName))
+	} else if err := tx.Commit(); err != nil {
+		return "", 0, errors.New(fmt.Sprintf("unable to commit %v search session, error: %v", policyName, err))
+	} else {
+		return strconv.FormatInt(ss.Int64, 10), uint64(cs.Int64), nil
+	}
+}
+
+// Update the changed since time in the DB and mark the current session as ended. This is done when a node scan has completed
+// successfully and all pages of nodes have been processed. The returned boolean indicates whether or not the session was
+// already ended. The semantics are the same as the Postgresql implementation, where an update which doesnt change any rows
+// is returned as an error.
+func (db *AgbotSqliteDB) UpdateSearchSessionChangedSince(currentChangedSince uint64, newChangedSince uint64, policyName string) (bool, error) {
+	glog.V(3).Infof("AgreementBot updating changedSince from %v to %v for %v search session", time.Unix(int64(currentChangedSince), 0).Format(cutil.ExchangeTimeFormat), time.Unix(int64(newChangedSince), 0).Format(cutil.ExchangeTimeFormat), policyName)
+
+	tx, err := db.db.Begin()
+	if err != nil {
+		return false, errors.New(fmt.Sprintf("unable to start transaction for %v search session, error: %v", policyName, err))
+	}
+	defer tx.Rollback()
+
+	var se sql.NullBool
+	if err := tx.QueryRow(SEARCH_SESSIONS_ENDED, policyName).Scan(&se); err != nil {
+		return false, errors.New(fmt.Sprintf("error updating %v search session changedSince, error: %v", policyName, err))
+	} else if res, err := tx.Exec(SEARCH_SESSIONS_UPDATE_CHANGED_SINCE, currentChangedSince, newChangedSince, db.identity, policyName); err != nil {
+		return false, errors.New(fmt.Sprintf("error updating %v search session changedSince, error: %v", policyName, err))
+	} else if num, err := res.RowsAffected(); err != nil {
+		return false, errors.New(fmt.Sprintf("error getting rows affected for %v search session changedSince, error: %v", policyName, err))
+	} else if num == 0 {
+		return false, errors.New(fmt.Sprintf("error updating %v search session changedSince, error: %v", policyName, sql.ErrNoRows))
+	} else if !se.Valid {
+		return false, errors.New(fmt.Sprintf("returned search session state for %v is not a valid boolean", policyName))
+	} else if err := tx.Commit(); err != nil {
+		return false, errors.New(fmt.Sprintf("unable to commit %v search session changedSince, error: %v", policyName, err))
+	} else {
+		return se.Bool, nil
+	}
+}
+
+// Update all search session with a new changed Since to account for possible lost search results when an agbot restarts.
+func (db *AgbotSqliteDB) ResetAllChangedSince(newChangedSince uint64) error {
+
+	tx, err := db.db.Begin()
+	if err != nil {
+		return errors.New(fmt.Sprintf("unable to start transaction to reset changed since, error: %v", err))
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(SEARCH_SESSIONS_RESET_RESTART_CHANGED_SINCE, newChangedSince, db.identity); err != nil {
+		return errors.New(fmt.Sprintf("error resetting changed since in all search sessions, error: %v", err))
+	} else if _, err := tx.Exec(SEARCH_SESSIONS_RESET_ENDED_CHANGED_SINCE, newChangedSince, db.identity); err != nil {
+		return errors.New(fmt.Sprintf("error resetting changed since in all search sessions, error: %v", err))
+	}
+	return tx.Commit()
+}
+
+// Update search session for a specific policy with a new changed Since to account for possible lost search results.
+func (db *AgbotSqliteDB) ResetPolicyChangedSince(policy string, newChangedSince uint64) error {
+	if _, err := db.db.Exec(SEARCH_SESSIONS_RESET_CHANGED_SINCE_FOR_POLICY, newChangedSince, policy, db.identity); err != nil {
+		return errors.New(fmt.Sprintf("error resetting changed since in %v search sessions, error: %v", policy, err))
+	}
+	return nil
+}
+
+type ssRecord struct {
+	pn string
+	cs int64
+	st int64
+	se bool
+	r  int64
+	ua string
+	up string
+}
+
+func (r ssRecord) String() string {
+	return fmt.Sprintf("Policy: %v, ChangedSince: %v, SessionToken: %v, SessionEnded: %v, RestartCS: %v, Agbot: %v, Updated: %v", r.pn, r.cs, r.st, r.se, r.r, r.ua, r.up)
+}
+
+// Log the contents of the search session table.
+func (db *AgbotSqliteDB) DumpSearchSessions() error {
+	if rows, err := db.db.Query(SEARCH_SESSIONS_DUMP); err != nil {
+		return errors.New(fmt.Sprintf("error dumping search sessions, error: %v", err))
+	} else {
+		defer rows.Close()
+		for rows.Next() {
+			out := ssRecord{}
+			if err := rows.Scan(&out.pn, &out.cs, &out.st, &out.se, &out.r, &out.ua, &out.up); err != nil {
+				glog.Errorf("AgbotDB: error dumping search sessions table, error: %v", err)
+			} else {
+				glog.V(4).Infof("Search Session: %v", out)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+//go:build unit
+// +build unit
+
+package sqlite
+
+import (
+	"os"
+	"testing"
+)
+
+func Test_SearchSession(t *testing.T) {
+
+	dir := utsetup(t)
+	defer os.RemoveAll(dir)
+	db := utopen(t, dir)
+	defer db.Close()
+
+	// The first session of a policy searches all the nodes.
+	session, changedSince, err := db.ObtainSearchSession("myorg/pol1")
+	if err != nil {
+		t.Fatalf("Error obtaining search session: %v", err)
+	} else if changedSince != 0 {
+		t.Errorf("Expected changedSince 0 but got %v", changedSince)
+	}
+
+	// The session is the same until it is ended.
+	if s, _, err := db.ObtainSearchSession("myorg/pol1"); err != nil {
+		t.Errorf("Error obtaining search session: %v", err)
+	} else if s != session {
+		t.Errorf("Expected session %v but got %v", session, s)
+	}
+
+	if ended, err := db.UpdateSearchSessionChangedSince(0, 100, "myorg/pol1"); err != nil {
+		t.Errorf("Error ending search session: %v", err)
+	} else if ended {
+		t.Errorf("Search session %v should not have been ended already", session)
+	}
+
+	// The session can only be ended once.
+	if _, err := db.UpdateSearchSessionChangedSince(0, 100, "myorg/pol1"); err == nil {
+		t.Errorf("Expected an error ending search session %v again", session)
+	}
+
+	// A new session starts from the changedSince of the previous one.
+	newSession, changedSince, err := db.ObtainSearchSession("myorg/pol1")
+	if err != nil {
+		t.Errorf("Error obtaining search session: %v", err)
+	} else if newSession == session {
+		t.Errorf("Expected a new session but got %v", newSession)
+	} else if changedSince != 100 {
+		t.Errorf("Expected changedSince 100 but got %v", changedSince)
+	}
+
+	// The changedSince of a policy is reset when its session ends.
+	if err := db.ResetPolicyChangedSince("myorg/pol1", 50); err != nil {
+		t.Errorf("Error resetting changedSince: %v", err)
+	} else if _, err := db.UpdateSearchSessionChangedSince(100, 200, "myorg/pol1"); err != nil {
+		t.Errorf("Error ending search session: %v", err)
+	} else if _, changedSince, err := db.ObtainSearchSession("myorg/pol1"); err != nil {
+		t.Errorf("Error obtaining search session: %v", err)
+	} else if changedSince != 50 {
+		t.Errorf("Expected changedSince 50 after reset but got %v", changedSince)
+	}
+
+	// The sessions in progress start again from the time of an agbot restart.
+	if err := db.ResetAllChangedSince(10); err != nil {
+		t.Errorf("Error resetting changedSince: %v", err)
+	} else if _, err := db.UpdateSearchSessionChangedSince(50, 300, "myorg/pol1"); err != nil {
+		t.Errorf("Error ending search session: %v", err)
+	} else if _, changedSince, err := db.ObtainSearchSession("myorg/pol1"); err != nil {
+		t.Errorf("Error obtaining search session: %v", err)
+	} else if changedSince != 10 {
+		t.Errorf("Expected changedSince 10 after restart but got %v", changedSince)
+	}
+
+	if err := db.DumpSearchSessions(); err != nil {
+		t.Errorf("Error dumping search sessions: %v", err)
+	}
+}
@@ -0,0 +1,286 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/golang/glog"
+)
+
+// Constants for the SQL statements that are used to manage secret updates. Secrets are partitioned in the same way as
+// agreements, using the partition column. The primary key includes the partition so that the same secret can be tracked
+// for the same policy or pattern in more than one partition, which is what the Postgresql partition tables allow.
+
+const SECRET_CREATE_MAIN_TABLE_POLICY = `CREATE TABLE IF NOT EXISTS secrets_policy (
+	secret_org text NOT NULL,
+	secret_name text NOT NULL,
+	policy_org text NOT NULL,
+	policy_name text NOT NULL,
+	last_update_check integer NOT NULL,
+	partition text NOT NULL,
+	updated timestamp DEFAULT current_timestamp,
+	PRIMARY KEY (secret_org, secret_name, policy_org, policy_name, partition)
+);`
+
+const SECRET_CREATE_MAIN_TABLE_PATTERN = `CREATE TABLE IF NOT EXISTS secrets_pattern (
+	secret_org text NOT NULL,
+	secret_name text NOT NULL,
+	pattern_org text NOT NULL,
+	pattern_name text NOT NULL,
+	last_update_check integer NOT NULL,
+	partition text NOT NULL,
+	updated timestamp DEFAULT current_timestamp,
+	PRIMARY KEY (secret_org, secret_name, pattern_org, pattern_name, partition)
+);`
+
+const SECRET_CREATE_INDEX_POLICY = `CREATE INDEX IF NOT EXISTS secret_index_on_secrets_policy ON secrets_policy (secret_name);`
+const SECRET_CREATE_INDEX_PATTERN = `CREATE INDEX IF NOT EXISTS secret_index_on_secrets_pattern ON secrets_pattern (secret_name);`
+
+const SECRET_INSERT_POLICY = `INSERT OR IGNORE INTO secrets_policy (secret_org, secret_name, policy_org, policy_name, last_update_check, partition) VALUES (?1, ?2, ?3, ?4, ?5, ?6);`
+const SECRET_UPDATE_TIME_POLICY = `UPDATE secrets_policy SET last_update_check = ?1, updated = current_timestamp WHERE secret_org = ?2 AND secret_name = ?3 AND partition = ?4;`
+const SECRET_DELETE_POLICY = `DELETE FROM secrets_policy WHERE secret_org = ?1 AND secret_name = ?2 AND policy_org = ?3 AND policy_name = ?4 AND partition = ?5;`
+
+const SECRET_INSERT_PATTERN = `INSERT OR IGNORE INTO secrets_pattern (secret_org, secret_name, pattern_org, pattern_name, last_update_check, partition) VALUES (?1, ?2, ?3, ?4, ?5, ?6);`
+const SECRET_UPDATE_TIME_PATTERN = `UPDATE secrets_pattern SET last_update_check = ?1, updated = current_timestamp WHERE secret_org = ?2 AND secret_name = ?3 AND partition = ?4;`
+const SECRET_DELETE_PATTERN = `DELETE FROM secrets_pattern WHERE secret_org = ?1 AND secret_name = ?2 AND pattern_org = ?3 AND pattern_name = ?4 AND partition = ?5;`
+
+// When a partition is moved, the pattern secrets are copied into the new partition (except those in the same org as the
+// pattern) and then the old partition's secrets are removed. Policy secrets are not moved, they are recreated by the agbot
+// as it makes new agreements. This is the same behavior as the Postgresql implementation.
+const SECRET_MOVE_PATTERN = `INSERT OR IGNORE INTO secrets_pattern (secret_org, secret_name, pattern_org, pattern_name, last_update_check, partition)
+	SELECT secret_org, secret_name, pattern_org, pattern_name, last_update_check, ?2 FROM secrets_pattern WHERE partition = ?1 AND secret_org <> pattern_org;`
+
+const SECRET_DELETE_PARTITION_POLICY = `DELETE FROM secrets_policy WHERE partition = ?1;`
+const SECRET_DELETE_PARTITION_PATTERN = `DELETE FROM secrets_pattern WHERE partition = ?1;`
+
+const SECRET_DISTINCT_NAMES_POLICY = `SELECT DISTINCT secret_org, secret_name FROM secrets_policy WHERE partition = ?1;`
+const SECRET_DISTINCT_NAMES_PATTERN = `SELECT DISTINCT secret_org, secret_name FROM secrets_pattern WHERE partition = ?1;`
+
+const SECRET_DISTINCT_NAMES_BY_POLICY = `SELECT DISTINCT secret_org, secret_name FROM secrets_policy WHERE partition = ?1 AND policy_org = ?2 AND policy_name = ?3;`
+const SECRET_DISTINCT_NAMES_BY_PATTERN = `SELECT DISTINCT secret_org, secret_name FROM secrets_pattern WHERE partition = ?1 AND pattern_org = ?2 AND pattern_name = ?3;`
+
+const SECRET_POLICIES_TO_UPDATE = `SELECT DISTINCT policy_org, policy_name FROM secrets_policy WHERE partition = ?1 AND secret_org = ?2 AND secret_name = ?3 AND last_update_check < ?4;`
+const SECRET_PATTERNS_TO_UPDATE = `SELECT DISTINCT pattern_org, pattern_name FROM secrets_pattern WHERE partition = ?1 AND secret_org = ?2 AND secret_name = ?3 AND last_update_check < ?4;`
+
+const SECRET_DISTINCT_POLICIES = `SELECT DISTINCT policy_name FROM secrets_policy WHERE partition = ?1 AND policy_org = ?2;`
+const SECRET_DISTINCT_PATTERNS = `SELECT DISTINCT pattern_name FROM secrets_pattern WHERE partition = ?1 AND pattern_org = ?2;`
+
+const SECRET_DELETE_BY_POLICY = `DELETE FROM secrets_policy WHERE partition = ?1 AND policy_org = ?2 AND policy_name = ?3;`
+const SECRET_DELETE_BY_PATTERN = `DELETE FROM secrets_pattern WHERE partition = ?1 AND pattern_org = ?2 AND pattern_name = ?3;`
+
+func (db *AgbotSqliteDB) GetManagedPolicySecretNames(policyOrg, policyName string) ([]string, error) {
+	if policyOrg == "" {
+		return db.getManagedSecretNames(SECRET_DISTINCT_NAMES_POLICY, policyOrg, policyName)
+	}
+	return db.getManagedSecretNames(SECRET_DISTINCT_NAMES_BY_POLICY, policyOrg, policyName)
+}
+
+func (db *AgbotSqliteDB) GetManagedPatternSecretNames(patternOrg, patternName string) ([]string, error) {
+	if patternOrg == "" {
+		return db.getManagedSecretNames(SECRET_DISTINCT_NAMES_PATTERN, patternOrg, patternName)
+	}
+	return db.getManagedSecretNames(SECRET_DISTINCT_NAMES_BY_PATTERN, patternOrg, patternName)
+}
+
+func (db *AgbotSqliteDB) getManagedSecretNames(sqlString, org, name string) ([]string, error) {
+
+	// Find all the unique org/secretname combinations in the secrets table.
+	secretNames := make([]string, 0, 10)
+
+	var rows *sql.Rows
+	var err error
+
+	if org == "" {
+		rows, err = db.db.Query(sqlString, db.PrimaryPartition())
+	} else {
+		rows, err = db.db.Query(sqlString, db.PrimaryPartition(), org, name)
+	}
+
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("error querying for unique org/secret names: %v", err))
+	}
+
+	// If the rows object doesnt get closed, memory and connections will grow and/or leak.
+	defer rows.Close()
+	for rows.Next() {
+		var secretOrg, secretName string
+		if err := rows.Scan(&secretOrg, &secretName); err != nil {
+			return nil, errors.New(fmt.Sprintf("error scanning unique secret name result set row: %v", err))
+		} else {
+			secretNames = append(secretNames, fmt.Sprintf("%s/%s", secretOrg, secretName))
+		}
+	}
+
+	// The rows.Next() function will exit with false when done or an error occurred. Get any error encountered during iteration.
+	if err = rows.Err(); err != nil {
+		return nil, errors.New(fmt.Sprintf("error iterating unique secret name result set: %v", err))
+	}
+
+	return secretNames, nil
+}
+
+// Returns a list of unique org qualified policy names that use a secret which has been updated since it was last checked.
+func (db *AgbotSqliteDB) GetPoliciesWithUpdatedSecrets(secretOrg, secretName string, lastUpdate int64) ([]string, error) {
+	return db.getUpdatedSecrets(SECRET_POLICIES_TO_UPDATE, secretOrg, secretName, lastUpdate)
+}
+
+func (db *AgbotSqliteDB) GetPatternsWithUpdatedSecrets(secretOrg, secretName string, lastUpdate int64) ([]string, error) {
+	return db.getUpdatedSecrets(SECRET_PATTERNS_TO_UPDATE, secretOrg, secretName, lastUpdate)
+}
+
+func (db *AgbotSqliteDB) getUpdatedSecrets(sqlString, org, name string, lastUpdate int64) ([]string, error) {
+
+	// Find all the unique org/name combinations in the secrets table.
+	names := make([]string, 0, 10)
+
+	rows, err := db.db.Query(sqlString, db.PrimaryPartition(), org, name, lastUpdate)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("error querying for unique org/secret names: %v", err))
+	}
+
+	// If the rows object doesnt get closed, memory and connections will grow and/or leak.
+	defer rows.Close()
+	for rows.Next() {
+		var retOrg, retName string
+		if err := rows.Scan(&retOrg, &retName); err != nil {
+			return nil, errors.New(fmt.Sprintf("error scanning for updated secrets: %v", err))
+		} else {
+			names = append(names, fmt.Sprintf("%s/%s", retOrg, retName))
+		}
+	}
+
+	// The rows.Next() function will exit with false when done or an error occurred. Get any error encountered during iteration.
+	if err = rows.Err(); err != nil {
+		return nil, errors.New(fmt.Sprintf("error iterating rows with updated secrets: %v", err))
+	}
+
+	return names, nil
+}
+
+func (db *AgbotSqliteDB) SetSecretUpdate(secretOrg, secretName string, secretUpdateTime int64) error {
+
+	err := db.setInternalSecretUpdate(SECRET_UPDATE_TIME_POLICY, secretOrg, secretName, secretUpdateTime)
+	if err != nil {
+		return errors.New(fmt.Sprintf("error updating policy secret %s/%s: %v", secretOrg, secretName, err))
+	}
+
+	err = db.setInternalSecretUpdate(SECRET_UPDATE_TIME_PATTERN, secretOrg, secretName, secretUpdateTime)
+	if err != nil {
+		return errors.New(fmt.Sprintf("error updating pattern secret %s/%s: %v", secretOrg, secretName, err))
+	}
+
+	return nil
+}
+
+func (db *AgbotSqliteDB) setInternalSecretUpdate(sqlString, secretOrg, secretName string, secretUpdateTime int64) error {
+
+	updated, err := db.db.Exec(sqlString, secretUpdateTime, secretOrg, secretName, db.PrimaryPartition())
+	if err != nil {
+		return errors.New(fmt.Sprintf("error setting update time for %s/%s: %v", secretOrg, secretName, err))
+	}
+
+	if rowsAffected, err := updated.RowsAffected(); err == nil {
+		glog.V(2).Infof("Succeeded setting update time in %v rows for %s/%s", rowsAffected, secretOrg, secretName)
+	} else {
+		glog.V(2).Infof("Succeeded setting update time for %s/%s", secretOrg, secretName)
+	}
+
+	return nil
+
+}
+
+func (db *AgbotSqliteDB) GetPoliciesInOrg(org string) ([]string, error) {
+	return db.getDeploymentInOrg(SECRET_DISTINCT_POLICIES, org)
+}
+
+func (db *AgbotSqliteDB) GetPatternsInOrg(org string) ([]string, error) {
+	return db.getDeploymentInOrg(SECRET_DISTINCT_PATTERNS, org)
+}
+
+func (db *AgbotSqliteDB) getDeploymentInOrg(sqlString, org string) ([]string, error) {
+
+	// Find all the unique policy/pattern names for a given org in the secrets table.
+	names := make([]string, 0, 10)
+
+	rows, err := db.db.Query(sqlString, db.PrimaryPartition(), org)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("error querying for unique names: %v", err))
+	}
+
+	// If the rows object doesnt get closed, memory and connections will grow and/or leak.
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, errors.New(fmt.Sprintf("error scanning unique name result set row: %v", err))
+		} else {
+			names = append(names, fmt.Sprintf("%s/%s", org, name))
+		}
+	}
+
+	// The rows.Next() function will exit with false when done or an error occurred. Get any error encountered during iteration.
+	if err = rows.Err(); err != nil {
+		return nil, errors.New(fmt.Sprintf("error iterating unique name result set: %v", err))
+	}
+
+	return names, nil
+
+}
+
+func (db *AgbotSqliteDB) DeleteSecretsForPolicy(polOrg, polName string) error {
+
+	if _, err := db.db.Exec(SECRET_DELETE_BY_POLICY, db.PrimaryPartition(), polOrg, polName); err != nil {
+		return errors.New(fmt.Sprintf("error deleting secrets for %s/%s: %v", polOrg, polName, err))
+	}
+
+	return nil
+}
+
+func (db *AgbotSqliteDB) DeleteSecretsForPattern(patternOrg, patternName string) error {
+
+	if _, err := db.db.Exec(SECRET_DELETE_BY_PATTERN, db.PrimaryPartition(), patternOrg, patternName); err != nil {
+		return errors.New(fmt.Sprintf("error deleting secrets for %s/%s: %v", patternOrg, patternName, err))
+	}
+
+	return nil
+}
+
+func (db *AgbotSqliteDB) DeletePolicySecret(secretOrg, secretName, policyOrg, policyName string) error {
+
+	if _, err := db.db.Exec(SECRET_DELETE_POLICY, secretOrg, secretName, policyOrg, policyName, db.PrimaryPartition()); err != nil {
+		return errors.New(fmt.Sprintf("error deleting secret %s/%s from policy %s/%s: %v", secretOrg, secretName, policyOrg, policyName, err))
+	}
+
+	return nil
+}
+
+func (db *AgbotSqliteDB) DeletePatternSecret(secretOrg, secretName, patternOrg, patternName string) error {
+
+	if _, err := db.db.Exec(SECRET_DELETE_PATTERN, secretOrg, secretName, patternOrg, patternName, db.PrimaryPartition()); err != nil {
+		return errors.New(fmt.Sprintf("error deleting secret %s/%s from pattern %s/%s: %v", secretOrg, secretName, patternOrg, patternName, err))
+	}
+
+	return nil
+}
+
+func (db *AgbotSqliteDB) AddManagedPolicySecret(secretOrg, secretName, policyOrg, policyName string, updateTime int64) error {
+
+	if _, err := db.db.Exec(SECRET_INSERT_POLICY, secretOrg, secretName, policyOrg, policyName, updateTime, db.PrimaryPartition()); err != nil {
+		return err
+	} else {
+		glog.V(2).Infof("Succeeded creating managed policy secret record")
+	}
+
+	return nil
+}
+
+func (db *AgbotSqliteDB) AddManagedPatternSecret(secretOrg, secretName, patternOrg, patternName string, updateTime int64) error {
+
+	if _, err := db.db.Exec(SECRET_INSERT_PATTERN, secretOrg, secretName, patternOrg, patternName, updateTime, db.PrimaryPartition()); err != nil {
+		return err
+	} else {
+		glog.V(2).Infof("Succeeded creating managed pattern secret record")
+	}
+
+	return nil
+}
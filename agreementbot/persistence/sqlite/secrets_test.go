@@ -0,0 +1,87 @@
+//go:build unit
+// +build unit
+
+package sqlite
+
+import (
+	"os"
+	"sort"
+	"testing"
+)
+
+func Test_ManagedSecrets(t *testing.T) {
+
+	dir := utsetup(t)
+	defer os.RemoveAll(dir)
+	db := utopen(t, dir)
+	defer db.Close()
+
+	if err := db.AddManagedPolicySecret("myorg", "sec1", "myorg", "pol1", 10); err != nil {
+		t.Fatalf("Error adding policy secret: %v", err)
+	} else if err := db.AddManagedPolicySecret("myorg", "sec1", "myorg", "pol1", 10); err != nil {
+		t.Fatalf("Adding a policy secret again should be ignored: %v", err)
+	} else if err := db.AddManagedPolicySecret("myorg", "sec2", "myorg", "pol2", 10); err != nil {
+		t.Fatalf("Error adding policy secret: %v", err)
+	} else if err := db.AddManagedPatternSecret("myorg", "sec1", "myorg", "pat1", 10); err != nil {
+		t.Fatalf("Error adding pattern secret: %v", err)
+	}
+
+	if names, err := db.GetManagedPolicySecretNames("", ""); err != nil {
+		t.Errorf("Error getting policy secrets: %v", err)
+	} else if sort.Strings(names); len(names) != 2 || names[0] != "myorg/sec1" || names[1] != "myorg/sec2" {
+		t.Errorf("Expected policy secrets myorg/sec1 and myorg/sec2 but got %v", names)
+	}
+
+	if names, err := db.GetManagedPolicySecretNames("myorg", "pol2"); err != nil {
+		t.Errorf("Error getting policy secrets: %v", err)
+	} else if len(names) != 1 || names[0] != "myorg/sec2" {
+		t.Errorf("Expected policy secret myorg/sec2 but got %v", names)
+	}
+
+	if names, err := db.GetPoliciesInOrg("myorg"); err != nil {
+		t.Errorf("Error getting policies: %v", err)
+	} else if len(names) != 2 {
+		t.Errorf("Expected 2 policies but got %v", names)
+	}
+
+	// The deployments that use a secret are updated when the secret changes after the last check.
+	if names, err := db.GetPoliciesWithUpdatedSecrets("myorg", "sec1", 20); err != nil {
+		t.Errorf("Error getting updated policies: %v", err)
+	} else if len(names) != 1 || names[0] != "myorg/pol1" {
+		t.Errorf("Expected policy myorg/pol1 but got %v", names)
+	} else if names, err := db.GetPatternsWithUpdatedSecrets("myorg", "sec1", 20); err != nil {
+		t.Errorf("Error getting updated patterns: %v", err)
+	} else if len(names) != 1 || names[0] != "myorg/pat1" {
+		t.Errorf("Expected pattern myorg/pat1 but got %v", names)
+	}
+
+	if err := db.SetSecretUpdate("myorg", "sec1", 20); err != nil {
+		t.Errorf("Error setting secret update time: %v", err)
+	} else if names, err := db.GetPoliciesWithUpdatedSecrets("myorg", "sec1", 20); err != nil {
+		t.Errorf("Error getting updated policies: %v", err)
+	} else if len(names) != 0 {
+		t.Errorf("Expected no policies to update but got %v", names)
+	} else if names, err := db.GetPatternsWithUpdatedSecrets("myorg", "sec1", 20); err != nil {
+		t.Errorf("Error getting updated patterns: %v", err)
+	} else if len(names) != 0 {
+		t.Errorf("Expected no patterns to update but got %v", names)
+	}
+
+	if err := db.DeletePolicySecret("myorg", "sec2", "myorg", "pol2"); err != nil {
+		t.Errorf("Error deleting policy secret: %v", err)
+	} else if err := db.DeleteSecretsForPolicy("myorg", "pol1"); err != nil {
+		t.Errorf("Error deleting policy secrets: %v", err)
+	} else if names, err := db.GetManagedPolicySecretNames("", ""); err != nil {
+		t.Errorf("Error getting policy secrets: %v", err)
+	} else if len(names) != 0 {
+		t.Errorf("Expected no policy secrets but got %v", names)
+	}
+
+	if err := db.DeleteSecretsForPattern("myorg", "pat1"); err != nil {
+		t.Errorf("Error deleting pattern secrets: %v", err)
+	} else if names, err := db.GetPatternsInOrg("myorg"); err != nil {
+		t.Errorf("Error getting patterns: %v", err)
+	} else if len(names) != 0 {
+		t.Errorf("Expected no patterns but got %v", names)
+	}
+}
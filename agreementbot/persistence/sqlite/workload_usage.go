@@ -0,0 +1,299 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/agreementbot/persistence"
+)
+
+// Constants for the SQL statements that are used to work with workload usages. These records are used to track what workload
+// is running on each device so that we can do proper management of HA devices. Workload usages are partitioned in the same way
+// as agreements, using the partition column of the single workload_usages table.
+//
+
+// workload_usages schema:
+// device_id:      The device's exchange id.
+// policy_name:    The name of the policy that is placing this workload on the device.
+// partition:      The agbot partition that this workload usage lives in.
+// workload_usage: The worload_usage object which is a JSON blob. The blob schema is defined by the WorkloadUsage struct in the persistence package.
+// updated:        A timestamp to record last updated time.
+//
+
+const WORKLOAD_USAGE_CREATE_MAIN_TABLE = `CREATE TABLE IF NOT EXISTS workload_usages (
+	device_id text NOT NULL,
+	policy_name text NOT NULL,
+	partition text NOT NULL,
+	workload_usage text NOT NULL,
+	updated timestamp DEFAULT current_timestamp
+);`
+const WORKLOAD_USAGE_CREATE_INDEX = `CREATE INDEX IF NOT EXISTS device_index_on_workload_usages ON workload_usages (device_id, policy_name);`
+
+const WORKLOAD_USAGE_QUERY = `SELECT workload_usage FROM workload_usages WHERE device_id = ?1 AND policy_name = ?2 AND partition = ?3;`
+const ALL_WORKLOAD_USAGE_QUERY = `SELECT workload_usage FROM workload_usages WHERE partition = ?1;`
+
+const WORKLOAD_USAGE_COUNT = `SELECT COUNT(*) FROM workload_usages WHERE partition = ?1;`
+
+const WORKLOAD_USAGE_INSERT = `INSERT INTO workload_usages (device_id, policy_name, partition, workload_usage) VALUES (?1, ?2, ?3, ?4);`
+const WORKLOAD_USAGE_UPDATE = `UPDATE workload_usages SET workload_usage = ?3, updated = current_timestamp WHERE device_id = ?1 AND policy_name = ?2 AND partition = ?4;`
+const WORKLOAD_USAGE_DELETE = `DELETE FROM workload_usages WHERE device_id = ?1 AND policy_name = ?2 AND partition = ?3;`
+
+const WORKLOAD_USAGE_MOVE = `UPDATE workload_usages SET partition = ?2 WHERE partition = ?1;`
+const WORKLOAD_USAGE_MOVE_ONE = `UPDATE workload_usages SET partition = ?4, updated = current_timestamp WHERE device_id = ?1 AND policy_name = ?2 AND partition = ?3;`
+
+func (db *AgbotSqliteDB) GetWorkloadUsagesCount(partition string) (int64, error) {
+	var num int64
+	if err := db.db.QueryRow(WORKLOAD_USAGE_COUNT, partition).Scan(&num); err != nil && err != sql.ErrNoRows {
+		return 0, errors.New(fmt.Sprintf("error scanning result for workload usage count in partition %v, error: %v", partition, err))
+	} else {
+		return num, nil
+	}
+}
+
+// Find the workload usage record, but constrain the search to partitions owned by this agbot.
+func (db *AgbotSqliteDB) internalFindSingleWorkloadUsageByDeviceAndPolicyName(tx *sql.Tx, deviceid string, policyName string) (*persistence.WorkloadUsage, string, error) {
+
+	wuBytes := make([]byte, 0, 2048)
+	wu := new(persistence.WorkloadUsage)
+
+	for _, currentPartition := range db.AllPartitions() {
+
+		// Find the workload usage row and read in the workload usage object column, then unmarshal the blob into an
+		// in memory workload usage object which gets returned to the caller.
+		var qerr error
+		if tx == nil {
+			qerr = db.db.QueryRow(WORKLOAD_USAGE_QUERY, deviceid, policyName, currentPartition).Scan(&wuBytes)
+		} else {
+			qerr = tx.QueryRow(WORKLOAD_USAGE_QUERY, deviceid, policyName, currentPartition).Scan(&wuBytes)
+		}
+
+		if qerr != nil && qerr != sql.ErrNoRows {
+			return nil, "", errors.New(fmt.Sprintf("error scanning row for workload usage for device id %v and policy name %v, error: %v", deviceid, policyName, qerr))
+		} else if qerr == sql.ErrNoRows {
+			continue
+		}
+
+		if err := json.Unmarshal(wuBytes, wu); err != nil {
+			return nil, "", errors.New(fmt.Sprintf("error demarshalling row: %v, error: %v", string(wuBytes), err))
+		} else {
+			return wu, currentPartition, nil
+		}
+	}
+	// No records found.
+	return nil, "", nil
+
+}
+
+func (db *AgbotSqliteDB) FindSingleWorkloadUsageByDeviceAndPolicyName(deviceid string, policyName string) (*persistence.WorkloadUsage, error) {
+	wu, _, err := db.internalFindSingleWorkloadUsageByDeviceAndPolicyName(nil, deviceid, policyName)
+	return wu, err
+}
+
+func (db *AgbotSqliteDB) FindWorkloadUsages(filters []persistence.WUFilter) ([]persistence.WorkloadUsage, error) {
+	wus := make([]persistence.WorkloadUsage, 0, 100)
+
+	for _, currentPartition := range db.AllPartitions() {
+
+		// Find all the workload usage objects, read them in and run them through the filters (after unmarshalling the blob into an
+		// in memory workload usage object).
+		if err := db.findWorkloadUsagesInPartition(currentPartition, filters, &wus); err != nil {
+			return nil, err
+		}
+	}
+
+	return wus, nil
+}
+
+func (db *AgbotSqliteDB) findWorkloadUsagesInPartition(partition string, filters []persistence.WUFilter, wus *[]persistence.WorkloadUsage) error {
+
+	rows, err := db.db.Query(ALL_WORKLOAD_USAGE_QUERY, partition)
+	if err != nil {
+		return errors.New(fmt.Sprintf("error querying for workload usages, error: %v", err))
+	}
+
+	// If the rows object doesnt get closed, memory and connections will grow and/or leak.
+	defer rows.Close()
+	for rows.Next() {
+		wuBytes := make([]byte, 0, 2048)
+		wu := new(persistence.WorkloadUsage)
+		if err := rows.Scan(&wuBytes); err != nil {
+			return errors.New(fmt.Sprintf("error scanning row: %v", err))
+		} else if err := json.Unmarshal(wuBytes, wu); err != nil {
+			return errors.New(fmt.Sprintf("error demarshalling row: %v, error: %v", string(wuBytes), err))
+		} else {
+			exclude := false
+			for _, filterFn := range filters {
+				if !filterFn(*wu) {
+					exclude = true
+				}
+			}
+			if !exclude {
+				*wus = append(*wus, *wu)
+			}
+		}
+	}
+
+	// The rows.Next() function will exit with false when done or an error occurred. Get any error encountered during iteration.
+	if err = rows.Err(); err != nil {
+		return errors.New(fmt.Sprintf("error iterating: %v", err))
+	}
+	return nil
+}
+
+func (db *AgbotSqliteDB) NewWorkloadUsage(deviceId string, policy string, policyName string, priority int, retryDurationS int, verifiedDurationS int, reqsNotMet bool, agid string) error {
+	if wlUsage, err := persistence.NewWorkloadUsage(deviceId, policy, policyName, priority, retryDurationS, verifiedDurationS, reqsNotMet, agid); err != nil {
+		return err
+	} else if existing, partition, err := db.internalFindSingleWorkloadUsageByDeviceAndPolicyName(nil, deviceId, policyName); err != nil {
+		return err
+	} else if existing != nil {
+		return fmt.Errorf("Workload usage record for device %v and policy name %v already exists in partition %v.", deviceId, policyName, partition)
+	} else if err := db.insertWorkloadUsage(wlUsage); err != nil {
+		return err
+	} else {
+		return nil
+	}
+}
+
+func (db *AgbotSqliteDB) UpdatePendingUpgrade(deviceid string, policyName string) (*persistence.WorkloadUsage, error) {
+	return persistence.UpdatePendingUpgrade(db, deviceid, policyName)
+}
+
+func (db *AgbotSqliteDB) UpdateRetryCount(deviceid string, policyName string, retryCount int, agid string) (*persistence.WorkloadUsage, error) {
+	return persistence.UpdateRetryCount(db, deviceid, policyName, retryCount, agid)
+}
+
+func (db *AgbotSqliteDB) UpdatePriority(deviceid string, policyName string, priority int, retryDurationS int, verifiedDurationS int, agid string) (*persistence.WorkloadUsage, error) {
+	return persistence.UpdatePriority(db, deviceid, policyName, priority, retryDurationS, verifiedDurationS, agid)
+}
+
+func (db *AgbotSqliteDB) UpdatePolicy(deviceid string, policyName string, pol string) (*persistence.WorkloadUsage, error) {
+	return persistence.UpdatePolicy(db, deviceid, policyName, pol)
+}
+
+// Updating the agreement id in the existing record is easy. However, the record might be in the wrong partition. It is possible that
+// the agbot was restarted with a new primary partition, and then the agreement that was using this record was cancelled and moved to
+// the new primary partition. If that's the case, we need to make sure the workload usage record gets moved to the agreement's
+// partition also, which is simply an update of the record's partition column.
+func (db *AgbotSqliteDB) UpdateWUAgreementId(deviceid string, policyName string, agid string, protocol string) (*persistence.WorkloadUsage, error) {
+
+	// Get the partition of the workload usage record and the partition of the agreement. If they are different then we need to
+	// move the workload usage record.
+	if _, wlPartition, err := db.internalFindSingleWorkloadUsageByDeviceAndPolicyName(nil, deviceid, policyName); err != nil {
+		return nil, err
+	} else if _, agPartition, err := db.internalFindSingleAgreementByAgreementId(nil, agid, protocol, []persistence.AFilter{}); err != nil {
+		return nil, err
+	} else if wlPartition != "" && wlPartition != agPartition {
+		// Move the existing record to the new partition. Inserts are always done in the primary partition.
+		if _, err := db.db.Exec(WORKLOAD_USAGE_MOVE_ONE, deviceid, policyName, wlPartition, db.PrimaryPartition()); err != nil {
+			return nil, errors.New(fmt.Sprintf("Unable to move workload usage record to new partition, error %v", err))
+		}
+	}
+
+	// Finally, update the agreement id in the workload usage object.
+	return persistence.UpdateWUAgreementId(db, deviceid, policyName, agid)
+}
+
+func (db *AgbotSqliteDB) DisableRollbackChecking(deviceid string, policyName string) (*persistence.WorkloadUsage, error) {
+	return persistence.DisableRollbackChecking(db, deviceid, policyName)
+}
+
+func (db *AgbotSqliteDB) DeleteWorkloadUsage(deviceid string, policyName string) error {
+	tx, err := db.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := db.deleteWU(tx, deviceid, policyName); err != nil {
+		return err
+	} else {
+		return tx.Commit()
+	}
+}
+
+func (db *AgbotSqliteDB) SingleWorkloadUsageUpdate(deviceid string, policyName string, fn func(persistence.WorkloadUsage) *persistence.WorkloadUsage) (*persistence.WorkloadUsage, error) {
+	if wlUsage, err := db.FindSingleWorkloadUsageByDeviceAndPolicyName(deviceid, policyName); err != nil {
+		return nil, err
+	} else if wlUsage == nil {
+		return nil, fmt.Errorf("Unable to locate workload usage for device: %v, and policy: %v", deviceid, policyName)
+	} else {
+		return db.wrapWUTransaction(deviceid, policyName, fn(*wlUsage))
+	}
+}
+
+func (db *AgbotSqliteDB) wrapWUTransaction(deviceid string, policyName string, updated *persistence.WorkloadUsage) (*persistence.WorkloadUsage, error) {
+
+	if tx, err := db.db.Begin(); err != nil {
+		return nil, err
+	} else if persisted, err := db.persistUpdatedWorkloadUsage(tx, deviceid, policyName, updated); err != nil {
+		tx.Rollback()
+		return nil, err
+	} else if err := tx.Commit(); err != nil {
+		return nil, err
+	} else {
+		return persisted, nil
+	}
+
+}
+
+// This function runs inside a transaction. It will atomicly read the workload usage from the DB, verify that the updated
+// workload usage object contains valid state transitions, and then write the updated workload usage back to the database.
+// The workload usage as written is returned.
+func (db *AgbotSqliteDB) persistUpdatedWorkloadUsage(tx *sql.Tx, deviceid string, policyName string, update *persistence.WorkloadUsage) (*persistence.WorkloadUsage, error) {
+
+	if mod, partition, err := db.internalFindSingleWorkloadUsageByDeviceAndPolicyName(tx, deviceid, policyName); err != nil {
+		return nil, err
+	} else if mod == nil {
+		return nil, errors.New(fmt.Sprintf("No workload usage with device id %v and policy name %v available to update.", deviceid, policyName))
+	} else {
+		// This code is running in a database transaction. Within the tx, the current record (mod) is
+		// read and then updated according to the updates within the input update record. It is critical
+		// to check for correct data transitions within the tx.
+		persistence.ValidateWUStateTransition(mod, update)
+		return mod, db.updateWorkloadUsage(tx, mod, partition)
+	}
+}
+
+func (db *AgbotSqliteDB) insertWorkloadUsage(wu *persistence.WorkloadUsage) error {
+
+	if wum, err := json.Marshal(wu); err != nil {
+		return err
+	} else if _, err = db.db.Exec(WORKLOAD_USAGE_INSERT, wu.DeviceId, wu.PolicyName, db.PrimaryPartition(), wum); err != nil {
+		return err
+	}
+	glog.V(2).Infof("Succeeded creating workload usage record %v", wu.ShortString())
+
+	return nil
+}
+
+func (db *AgbotSqliteDB) updateWorkloadUsage(tx *sql.Tx, wu *persistence.WorkloadUsage, partition string) error {
+
+	if wum, err := json.Marshal(wu); err != nil {
+		return err
+	} else if _, err = tx.Exec(WORKLOAD_USAGE_UPDATE, wu.DeviceId, wu.PolicyName, wum, partition); err != nil {
+		return err
+	} else {
+		glog.V(2).Infof("Succeeded writing workload usage record %v", wu.ShortString())
+	}
+
+	return nil
+}
+
+func (db *AgbotSqliteDB) deleteWU(tx *sql.Tx, deviceid string, policyName string) error {
+
+	// Query the device id and policy name to retrieve the partition for this workload usage, then delete it if it's there.
+	wu, partition, err := db.internalFindSingleWorkloadUsageByDeviceAndPolicyName(tx, deviceid, policyName)
+	if err != nil {
+		return err
+	} else if wu != nil {
+		if _, err := tx.Exec(WORKLOAD_USAGE_DELETE, deviceid, policyName, partition); err != nil {
+			return err
+		}
+		glog.V(5).Infof("Succeeded deleting workload usage for device %v and policy %v from database.", deviceid, policyName)
+	}
+
+	return nil
+
+}
@@ -0,0 +1,162 @@
+package persistence
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// The states of a staged rollout of a new service version.
+const (
+	ROLLOUT_STATE_UPGRADING   = "upgrading"   // nodes are being upgraded up to the quota of the current step
+	ROLLOUT_STATE_VERIFYING   = "verifying"   // the upgraded nodes are verified before the next step starts
+	ROLLOUT_STATE_COMPLETED   = "completed"   // all the nodes are allowed to run the new version
+	ROLLOUT_STATE_ROLLED_BACK = "rolled_back" // the new version failed, the upgraded nodes run the previous version again
+)
+
+// The state of the staged rollout of a new service version to the nodes that have an agreement with a deployment policy.
+// The rollout is shared by all the agbot partitions, so that any of them can continue it.
+type WorkloadRollout struct {
+	PolicyName            string   `json:"policyName"`            // format: org/policyName
+	Version               string   `json:"version"`               // the service version being rolled out
+	PreviousVersion       string   `json:"previousVersion"`       // the service version the nodes are upgraded from
+	Percentage            int      `json:"percentage"`            // the percentage of the nodes upgraded in each step
+	VerificationS         int      `json:"verificationDuration"`  // the number of seconds the upgraded nodes are verified after each step
+	State                 string   `json:"state"`                 // one of the ROLLOUT_STATE_* constants
+	Step                  int      `json:"step"`                  // the current step, starting at 1
+	PendingNodes          []string `json:"pendingNodes"`          // nodes waiting to be upgraded, format: org/deviceId
+	UpgradedNodes         []string `json:"upgradedNodes"`         // nodes allowed to run the new version, format: org/deviceId
+	FailedNodes           []string `json:"failedNodes"`           // upgraded nodes on which the new version failed, format: org/deviceId
+	StartTime             uint64   `json:"startTime"`             // when the rollout started
+	VerificationStartTime uint64   `json:"verificationStartTime"` // when the verification of the current step started
+	EndTime               uint64   `json:"endTime"`               // when the rollout completed or was rolled back
+}
+
+func (r WorkloadRollout) String() string {
+	return fmt.Sprintf("PolicyName: %v, "+
+		"Version: %v, "+
+		"PreviousVersion: %v, "+
+		"Percentage: %v, "+
+		"VerificationS: %v, "+
+		"State: %v, "+
+		"Step: %v, "+
+		"PendingNodes: %v, "+
+		"UpgradedNodes: %v, "+
+		"FailedNodes: %v, "+
+		"StartTime: %v, "+
+		"VerificationStartTime: %v, "+
+		"EndTime: %v",
+		r.PolicyName, r.Version, r.PreviousVersion, r.Percentage, r.VerificationS, r.State, r.Step,
+		r.PendingNodes, r.UpgradedNodes, r.FailedNodes, r.StartTime, r.VerificationStartTime, r.EndTime)
+}
+
+func (r WorkloadRollout) ShortString() string {
+	return fmt.Sprintf("PolicyName: %v, Version: %v, PreviousVersion: %v, State: %v, Step: %v, Pending: %v, Upgraded: %v, Failed: %v",
+		r.PolicyName, r.Version, r.PreviousVersion, r.State, r.Step, len(r.PendingNodes), len(r.UpgradedNodes), len(r.FailedNodes))
+}
+
+func NewWorkloadRollout(policyName string, version string, previousVersion string, percentage int, verificationS int) (*WorkloadRollout, error) {
+	if policyName == "" || version == "" || previousVersion == "" {
+		return nil, errors.New("Illegal input: one of policyName, version or previousVersion is empty")
+	} else if percentage < 1 || percentage > 100 {
+		return nil, errors.New(fmt.Sprintf("Illegal input: percentage %v is not between 1 and 100", percentage))
+	}
+
+	return &WorkloadRollout{
+		PolicyName:      policyName,
+		Version:         version,
+		PreviousVersion: previousVersion,
+		Percentage:      percentage,
+		VerificationS:   verificationS,
+		State:           ROLLOUT_STATE_UPGRADING,
+		Step:            1,
+		PendingNodes:    []string{},
+		UpgradedNodes:   []string{},
+		FailedNodes:     []string{},
+		StartTime:       uint64(time.Now().Unix()),
+	}, nil
+}
+
+// Return true while nodes are still being upgraded or verified.
+func (r *WorkloadRollout) IsActive() bool {
+	return r.State == ROLLOUT_STATE_UPGRADING || r.State == ROLLOUT_STATE_VERIFYING
+}
+
+// The number of nodes that are part of the rollout.
+func (r *WorkloadRollout) TotalNodes() int {
+	return len(r.PendingNodes) + len(r.UpgradedNodes)
+}
+
+// The number of nodes that are allowed to be upgraded by the end of the current step. The nodes that have an agreement
+// for the policy are enrolled when the rollout starts, nodes that make an agreement later are added to the total.
+func (r *WorkloadRollout) StepQuota() int {
+	total := r.TotalNodes()
+	if total == 0 {
+		return 0
+	}
+
+	quota := (total*r.Percentage*r.Step + 99) / 100
+	if quota < 1 {
+		quota = 1
+	} else if quota > total {
+		quota = total
+	}
+	return quota
+}
+
+// Return true when the nodes of the current step have all been upgraded.
+func (r *WorkloadRollout) StepComplete() bool {
+	return len(r.UpgradedNodes) >= r.StepQuota()
+}
+
+func (r *WorkloadRollout) IsUpgraded(nodeId string) bool {
+	return containsNode(r.UpgradedNodes, nodeId)
+}
+
+func (r *WorkloadRollout) IsPending(nodeId string) bool {
+	return containsNode(r.PendingNodes, nodeId)
+}
+
+// Add a node to the nodes waiting to be upgraded, unless it is already part of the rollout.
+func (r *WorkloadRollout) AddPendingNode(nodeId string) {
+	if !r.IsPending(nodeId) && !r.IsUpgraded(nodeId) {
+		r.PendingNodes = append(r.PendingNodes, nodeId)
+	}
+}
+
+// Move a pending node to the upgraded nodes if the quota of the current step allows it. Return true if the node is
+// allowed to run the new version.
+func (r *WorkloadRollout) ReleaseNode(nodeId string) bool {
+	if r.IsActive() && r.IsUpgraded(nodeId) {
+		return true
+	} else if r.State != ROLLOUT_STATE_UPGRADING || !r.IsPending(nodeId) || r.StepComplete() {
+		return false
+	}
+
+	pending := make([]string, 0, len(r.PendingNodes))
+	for _, n := range r.PendingNodes {
+		if n != nodeId {
+			pending = append(pending, n)
+		}
+	}
+	r.PendingNodes = pending
+	r.UpgradedNodes = append(r.UpgradedNodes, nodeId)
+	return true
+}
+
+// Return true if the node is allowed to run the given service version.
+func (r *WorkloadRollout) AllowsVersion(nodeId string, version string) bool {
+	if version != r.Version || r.State == ROLLOUT_STATE_COMPLETED {
+		return true
+	}
+	return r.IsActive() && r.IsUpgraded(nodeId)
+}
+
+func containsNode(nodes []string, nodeId string) bool {
+	for _, n := range nodes {
+		if n == nodeId {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+package agreementbot
+
+import (
+	"errors"
+	"fmt"
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/compcheck"
+	"github.com/open-horizon/anax/cutil"
+	"github.com/open-horizon/anax/exchange"
+	"github.com/open-horizon/anax/externalpolicy"
+	"github.com/open-horizon/anax/policy"
+	"sort"
+)
+
+// A node that is a candidate for the weighted placement of a deployment policy.
+type placementCandidate struct {
+	device exchange.SearchResultDevice
+	score  int
+}
+
+// Rank the nodes returned by a deployment policy search by their placement score, highest score first, and keep only
+// as many of them as the maxNodes limit of the policy still has room for. The nodes that already have an agreement with
+// the policy are kept so that their agreements are verified, and they use up room. The nodes that are not compatible
+// with the policy cannot get the workload, so they are dropped before they use up room.
+// The returned bool is true when compatible nodes were left out because of the maxNodes limit.
+func rankByPlacement(devices []exchange.SearchResultDevice, consumerPolicy *policy.Policy, agreementNodes map[string]bool, nodePolicyHandler exchange.NodePolicyHandler, servicePolicyHandler exchange.ServicePolicyWithIdHandler) ([]exchange.SearchResultDevice, bool) {
+
+	placement := consumerPolicy.Placement
+
+	// the service policies are the same for all the nodes
+	servicePolicies := map[string]*externalpolicy.ExternalPolicy{}
+	getServicePolicy := func(svcId string) (*externalpolicy.ExternalPolicy, error) {
+		if pol, ok := servicePolicies[svcId]; ok {
+			return pol, nil
+		}
+		pol, err := compcheck.GetServicePolicyWithId(servicePolicyHandler, svcId, nil)
+		if err == nil {
+			servicePolicies[svcId] = pol
+		}
+		return pol, err
+	}
+
+	ranked := make([]exchange.SearchResultDevice, 0, len(devices))
+	candidates := make([]placementCandidate, 0, len(devices))
+	for _, dev := range devices {
+		if agreementNodes[dev.Id] {
+			ranked = append(ranked, dev)
+			continue
+		} else if dev.PublicKey == "" {
+			continue
+		}
+
+		_, nodePolicy, err := compcheck.GetNodePolicy(nodePolicyHandler, dev.Id, nil)
+		if err != nil {
+			glog.Errorf(AWlogString(fmt.Sprintf("unable to get the node policy of %v to compute its placement score, error: %v", dev.Id, err)))
+			continue
+		}
+
+		if nodePolicy == nil {
+			glog.V(5).Infof(AWlogString(fmt.Sprintf("skipping device id %v, it does not have a node policy", dev.Id)))
+			continue
+		} else if err := placementCompatible(nodePolicy, consumerPolicy, getServicePolicy); err != nil {
+			glog.V(5).Infof(AWlogString(fmt.Sprintf("skipping device id %v, it is not compatible with %v: %v", dev.Id, consumerPolicy.Header.Name, err)))
+			continue
+		}
+
+		candidates = append(candidates, placementCandidate{device: dev, score: placement.Score(nodePolicy.Properties)})
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return candidates[i].device.Id < candidates[j].device.Id
+	})
+
+	room := len(candidates)
+	if placement.MaxNodes != 0 {
+		room = placement.MaxNodes - len(agreementNodes)
+		if room < 0 {
+			room = 0
+		}
+	}
+
+	for i, c := range candidates {
+		if i >= room {
+			glog.V(5).Infof(AWlogString(fmt.Sprintf("skipping device id %v with placement score %v, the maxNodes limit %v of %v is reached", c.device.Id, c.score, placement.MaxNodes, consumerPolicy.Header.Name)))
+			continue
+		}
+		glog.V(5).Infof(AWlogString(fmt.Sprintf("placing %v on device id %v with placement score %v", consumerPolicy.Header.Name, c.device.Id, c.score)))
+		ranked = append(ranked, c.device)
+	}
+
+	return ranked, len(candidates) > room
+}
+
+// Return nil if the node is compatible with one of the service versions of the deployment policy. It is the same two way
+// check as the one done before an agreement is made: the constraints of the deployment and service policies against the
+// node properties, and the node constraints against the properties of the deployment and service policies.
+func placementCompatible(nodePolicy *policy.Policy, consumerPolicy *policy.Policy, getServicePolicy func(svcId string) (*externalpolicy.ExternalPolicy, error)) error {
+
+	// the arch of a service version that can run on any arch is the arch of the node
+	nodeArch := ""
+	for _, prop := range nodePolicy.Properties {
+		if prop.Name == externalpolicy.PROP_NODE_ARCH {
+			nodeArch = fmt.Sprintf("%v", prop.Value)
+		}
+	}
+
+	reason := "the deployment policy has no service versions"
+	for _, workload := range consumerPolicy.Workloads {
+		arch := workload.Arch
+		if arch == "" || arch == "*" {
+			arch = nodeArch
+		}
+
+		svcId := fmt.Sprintf("%v/%v", workload.Org, cutil.FormExchangeIdForService(workload.WorkloadURL, workload.Version, arch))
+		servicePol, err := getServicePolicy(svcId)
+		if err != nil {
+			return err
+		}
+
+		builtInSvcPol := externalpolicy.CreateServiceBuiltInPolicy(workload.WorkloadURL, workload.Org, workload.Version, arch)
+		mergedServicePol := compcheck.AddDefaultPropertiesToServicePolicy(servicePol, builtInSvcPol, nil)
+		if compatible, shortReason, _, _, err := compcheck.CheckPolicyCompatiblility(nodePolicy, consumerPolicy, mergedServicePol, "", nil); err != nil {
+			return err
+		} else if compatible {
+			return nil
+		} else {
+			reason = shortReason
+		}
+	}
+	return errors.New(reason)
+}
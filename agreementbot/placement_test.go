@@ -0,0 +1,83 @@
+//go:build unit
+// +build unit
+
+package agreementbot
+
+import (
+	"github.com/open-horizon/anax/exchange"
+	"github.com/open-horizon/anax/exchangecommon"
+	"github.com/open-horizon/anax/externalpolicy"
+	_ "github.com/open-horizon/anax/externalpolicy/text_language"
+	"github.com/open-horizon/anax/policy"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_rankByPlacement(t *testing.T) {
+
+	nodeProps := map[string]externalpolicy.PropertyList{
+		"myorg/gpu1":    {*externalpolicy.Property_Factory("gpu", true), *externalpolicy.Property_Factory("purpose", "test")},
+		"myorg/gpu2":    {*externalpolicy.Property_Factory("gpu", true), *externalpolicy.Property_Factory("purpose", "test")},
+		"myorg/cpu1":    {*externalpolicy.Property_Factory("gpu", false), *externalpolicy.Property_Factory("purpose", "test")},
+		"myorg/prod1":   {*externalpolicy.Property_Factory("gpu", true), *externalpolicy.Property_Factory("purpose", "prod")},
+		"myorg/placed1": {*externalpolicy.Property_Factory("gpu", false), *externalpolicy.Property_Factory("purpose", "test")},
+	}
+	// gpu1 needs a property of the service policy, gpu2 needs a property that neither the deployment nor the service has
+	nodeConstraints := map[string]externalpolicy.ConstraintExpression{
+		"myorg/gpu1": {"svctype == vision"},
+		"myorg/gpu2": {"tier == gold"},
+	}
+	nodePolicyHandler := func(deviceId string) (*exchange.ExchangeNodePolicy, error) {
+		return &exchange.ExchangeNodePolicy{NodePolicy: exchangecommon.NodePolicy{ExternalPolicy: externalpolicy.ExternalPolicy{Properties: nodeProps[deviceId], Constraints: nodeConstraints[deviceId]}}}, nil
+	}
+	servicePolicyCalls := 0
+	servicePolicyHandler := func(svcId string) (*exchange.ExchangeServicePolicy, error) {
+		servicePolicyCalls++
+		assert.Equal(t, "myorg/mysvc_1.0.0_amd64", svcId)
+		return &exchange.ExchangeServicePolicy{ServicePolicy: exchangecommon.ServicePolicy{ExternalPolicy: externalpolicy.ExternalPolicy{Properties: externalpolicy.PropertyList{*externalpolicy.Property_Factory("svctype", "vision")}}}}, nil
+	}
+
+	devices := []exchange.SearchResultDevice{
+		{Id: "myorg/cpu1", PublicKey: "key"},
+		{Id: "myorg/gpu2", PublicKey: "key"},
+		{Id: "myorg/prod1", PublicKey: "key"},
+		{Id: "myorg/placed1", PublicKey: "key"},
+		{Id: "myorg/gpu1", PublicKey: "key"},
+		{Id: "myorg/nokey", PublicKey: ""},
+	}
+
+	pol := policy.Policy_Factory("myorg/mybp")
+	pol.Constraints = externalpolicy.ConstraintExpression{"purpose == test"}
+	pol.Placement = policy.Placement_Factory(0, []policy.Preference{{Constraint: "gpu == true", Weight: 5}})
+	pol.Workloads = []policy.Workload{{WorkloadURL: "mysvc", Org: "myorg", Version: "1.0.0", Arch: "amd64"}}
+
+	// without a limit, all the compatible nodes are kept, the nodes with a GPU first
+	ranked, skipped := rankByPlacement(devices, pol, map[string]bool{"myorg/placed1": true}, nodePolicyHandler, servicePolicyHandler)
+	assert.False(t, skipped)
+	assert.Equal(t, []string{"myorg/placed1", "myorg/gpu1", "myorg/cpu1"}, deviceIds(ranked))
+	assert.Equal(t, 1, servicePolicyCalls)
+
+	// the node that already has an agreement uses up room, the node whose constraints are not satisfied does not
+	pol.Placement.MaxNodes = 3
+	ranked, skipped = rankByPlacement(devices, pol, map[string]bool{"myorg/placed1": true}, nodePolicyHandler, servicePolicyHandler)
+	assert.False(t, skipped)
+	assert.Equal(t, []string{"myorg/placed1", "myorg/gpu1", "myorg/cpu1"}, deviceIds(ranked))
+
+	pol.Placement.MaxNodes = 2
+	ranked, skipped = rankByPlacement(devices, pol, map[string]bool{"myorg/placed1": true}, nodePolicyHandler, servicePolicyHandler)
+	assert.True(t, skipped)
+	assert.Equal(t, []string{"myorg/placed1", "myorg/gpu1"}, deviceIds(ranked))
+
+	// no room left
+	ranked, skipped = rankByPlacement(devices, pol, map[string]bool{"myorg/placed1": true, "myorg/other": true}, nodePolicyHandler, servicePolicyHandler)
+	assert.True(t, skipped)
+	assert.Equal(t, []string{"myorg/placed1"}, deviceIds(ranked))
+}
+
+func deviceIds(devices []exchange.SearchResultDevice) []string {
+	ids := []string{}
+	for _, dev := range devices {
+		ids = append(ids, dev.Id)
+	}
+	return ids
+}
@@ -74,6 +74,7 @@ func (p *PrioritizedWorkQueueHistory) Collect(s *PrioritizedWorkQueueStats, forc
 func (p *PrioritizedWorkQueueHistory) addRecord(s *PrioritizedWorkQueueStats) {
 	glog.V(5).Infof(pwqString(fmt.Sprintf("Recent stats: %v", s.report())))
 	if !s.empty() {
+		recordWorkQueueStats(s)
 		p.history = append(p.history, *s)
 		// If the max number of records is reached, remove the oldest history record.
 		if len(p.history) > p.maxRecords {
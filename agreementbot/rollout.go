@@ -0,0 +1,346 @@
+package agreementbot
+
+import (
+	"fmt"
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/agreementbot/persistence"
+	"github.com/open-horizon/anax/basicprotocol"
+	"github.com/open-horizon/anax/exchange"
+	"github.com/open-horizon/anax/policy"
+	"time"
+)
+
+// The agreement termination reasons which indicate that the new service version failed on an upgraded node.
+var rolloutFailureReasons = map[uint]bool{
+	basicprotocol.CANCEL_CONTAINER_FAILURE:        true,
+	basicprotocol.CANCEL_NOT_EXECUTED_TIMEOUT:     true,
+	basicprotocol.CANCEL_MICROSERVICE_FAILURE:     true,
+	basicprotocol.CANCEL_WL_IMAGE_LOAD_FAILURE:    true,
+	basicprotocol.CANCEL_MS_IMAGE_LOAD_FAILURE:    true,
+	basicprotocol.CANCEL_IMAGE_DATA_ERROR:         true,
+	basicprotocol.CANCEL_IMAGE_FETCH_FAILURE:      true,
+	basicprotocol.CANCEL_IMAGE_FETCH_AUTH_FAILURE: true,
+	basicprotocol.CANCEL_IMAGE_SIG_VERIF_FAILURE:  true,
+	basicprotocol.CANCEL_MS_IMAGE_FETCH_FAILURE:   true,
+	basicprotocol.AB_CANCEL_NO_REPLY:              true,
+	basicprotocol.AB_CANCEL_NEGATIVE_REPLY:        true,
+	basicprotocol.AB_CANCEL_NO_DATA_RECEIVED:      true,
+	basicprotocol.AB_CANCEL_NODE_HEARTBEAT:        true,
+}
+
+// Return the workload of the policy that runs the version the rollout upgrades the nodes from, nil if the policy
+// no longer has it.
+func rolloutPreviousWorkload(pol *policy.Policy, rollout *persistence.WorkloadRollout) *policy.Workload {
+	for ix, wl := range pol.Workloads {
+		if wl.Version == rollout.PreviousVersion {
+			return &pol.Workloads[ix]
+		}
+	}
+	return nil
+}
+
+// Start a staged rollout when the policy change replaces the highest priority service version and the policy asks for
+// a rollout. The rollout of the policy is removed when the policy no longer asks for one. Nil is returned when there is
+// no rollout to start. The previous version has to stay in the policy, otherwise the nodes have nothing to wait with.
+// All the nodes that have an agreement for the policy in this partition are enrolled in the rollout when it starts, so
+// that the percentage of each step is taken of all of them.
+func startWorkloadRollout(db persistence.AgbotDatabase, newPol *policy.Policy, oldPol *policy.Policy) (*persistence.WorkloadRollout, error) {
+	if newPol.Rollout == nil {
+		return nil, db.DeleteWorkloadRollout(newPol.Header.Name)
+	} else if oldPol == nil {
+		return nil, nil
+	}
+
+	newTop := policy.GetNextWorkloadChoice(newPol.Workloads, -1)
+	oldTop := policy.GetNextWorkloadChoice(oldPol.Workloads, -1)
+	if newTop == nil || oldTop == nil || newTop.Version == oldTop.Version {
+		return nil, nil
+	}
+
+	rollout, err := persistence.NewWorkloadRollout(newPol.Header.Name, newTop.Version, oldTop.Version, newPol.Rollout.Percentage, newPol.Rollout.GetVerificationS())
+	if err != nil {
+		return nil, err
+	} else if rolloutPreviousWorkload(newPol, rollout) == nil {
+		glog.Warningf(logString(fmt.Sprintf("policy %v asks for a staged rollout of service version %v, but the previous version %v was removed from the policy. The nodes are upgraded without a rollout.", newPol.Header.Name, rollout.Version, rollout.PreviousVersion)))
+		return nil, nil
+	}
+
+	nodes, err := rolloutPolicyNodes(db, newPol.Header.Name)
+	if err != nil {
+		return nil, err
+	}
+	for _, nodeId := range nodes {
+		rollout.AddPendingNode(nodeId)
+	}
+
+	inserted, err := db.InsertWorkloadRollout(rollout)
+	if err != nil || inserted.Version != rollout.Version {
+		return inserted, err
+	}
+
+	// The rollout can already have been started by another agbot partition, which did not see the nodes of this one.
+	return db.SingleWorkloadRolloutUpdate(rollout.PolicyName, func(r persistence.WorkloadRollout) *persistence.WorkloadRollout {
+		if !r.IsActive() || r.Version != rollout.Version {
+			return nil
+		}
+		total := r.TotalNodes()
+		for _, nodeId := range nodes {
+			r.AddPendingNode(nodeId)
+		}
+		if r.TotalNodes() == total {
+			return nil
+		}
+		return &r
+	})
+}
+
+// Return the nodes that have an agreement for the policy in this partition.
+func rolloutPolicyNodes(db persistence.AgbotDatabase, policyName string) ([]string, error) {
+	policyFilter := func(a persistence.Agreement) bool {
+		return a.PolicyName == policyName && a.AgreementTimedout == 0
+	}
+
+	nodes := []string{}
+	for _, agp := range policy.AllAgreementProtocols() {
+		agreements, err := db.FindAgreements([]persistence.AFilter{persistence.UnarchivedAFilter(), policyFilter}, agp)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read agreements for policy %v, error: %v", policyName, err)
+		}
+		for _, ag := range agreements {
+			nodes = append(nodes, ag.DeviceId)
+		}
+	}
+	return nodes, nil
+}
+
+// Return true if the node is allowed to run the version that is rolled out for the policy. While the rollout is active,
+// the node is added to the rollout and it is allowed once the current step has room for it. True is returned when
+// there is no rollout for the policy.
+func rolloutAllowsUpgrade(db persistence.AgbotDatabase, policyName string, nodeId string) (bool, error) {
+	rollout, err := db.GetWorkloadRollout(policyName)
+	if err != nil || rollout == nil {
+		return true, err
+	} else if !rollout.IsActive() {
+		return rollout.AllowsVersion(nodeId, rollout.Version), nil
+	}
+
+	allowed := false
+	_, err = db.SingleWorkloadRolloutUpdate(policyName, func(r persistence.WorkloadRollout) *persistence.WorkloadRollout {
+		if !r.IsActive() {
+			allowed = r.AllowsVersion(nodeId, r.Version)
+			return nil
+		}
+		r.AddPendingNode(nodeId)
+		allowed = r.ReleaseNode(nodeId)
+		return &r
+	})
+	return allowed, err
+}
+
+// Add the node to the nodes waiting to be upgraded by the active rollout of the policy, without using up room in the
+// current step. Return true if the node was added.
+func rolloutAddPendingNode(db persistence.AgbotDatabase, policyName string, nodeId string) (bool, error) {
+	added := false
+	_, err := db.SingleWorkloadRolloutUpdate(policyName, func(r persistence.WorkloadRollout) *persistence.WorkloadRollout {
+		if !r.IsActive() {
+			return nil
+		}
+		r.AddPendingNode(nodeId)
+		added = true
+		return &r
+	})
+	return added, err
+}
+
+// Return the workload that the node should get when the input workload is chosen for it. If the input workload runs the
+// version that is rolled out for the policy and the node is not allowed to run it yet, the workload of the previous
+// version is returned instead.
+func rolloutWorkload(db persistence.AgbotDatabase, pol *policy.Policy, nodeId string, workload *policy.Workload) (*policy.Workload, error) {
+	rollout, err := db.GetWorkloadRollout(pol.Header.Name)
+	if err != nil || rollout == nil || workload.Version != rollout.Version {
+		return workload, err
+	}
+
+	if allowed, err := rolloutAllowsUpgrade(db, pol.Header.Name, nodeId); err != nil || allowed {
+		return workload, err
+	} else if previous := rolloutPreviousWorkload(pol, rollout); previous != nil {
+		return previous, nil
+	}
+	return workload, nil
+}
+
+// Move the staged rollouts forward. Each agbot works on the agreements in its own partition, the rollout state is shared
+// by all of them.
+func (w *AgreementBotWorker) governRollouts(nodePolicyHandler exchange.NodePolicyHandler) {
+
+	rollouts, err := w.db.ListAllWorkloadRollouts()
+	if err != nil {
+		glog.Errorf(logString(fmt.Sprintf("unable to read the workload rollouts, error: %v", err)))
+		return
+	}
+
+	for _, rollout := range rollouts {
+		if glog.V(5) {
+			glog.Infof(logString(fmt.Sprintf("governing workload rollout %v", rollout.ShortString())))
+		}
+
+		if rollout.IsActive() {
+			if failed := w.rolloutFailures(&rollout); len(failed) != 0 {
+				w.failRollout(&rollout, failed)
+			} else if rollout.State == persistence.ROLLOUT_STATE_UPGRADING {
+				w.upgradeRolloutNodes(&rollout, nodePolicyHandler)
+			} else {
+				w.verifyRollout(&rollout)
+			}
+		} else if rollout.State == persistence.ROLLOUT_STATE_ROLLED_BACK {
+			w.rollBackRolloutNodes(&rollout)
+		}
+	}
+}
+
+// Return the upgraded nodes on which the new version failed since the rollout started.
+func (w *AgreementBotWorker) rolloutFailures(rollout *persistence.WorkloadRollout) []string {
+	failedFilter := func(a persistence.Agreement) bool {
+		return a.PolicyName == rollout.PolicyName && a.AgreementInceptionTime >= rollout.StartTime &&
+			rolloutFailureReasons[a.TerminatedReason] && rollout.IsUpgraded(a.DeviceId)
+	}
+
+	failed := []string{}
+	for _, agp := range policy.AllAgreementProtocols() {
+		if agreements, err := w.db.FindAgreements([]persistence.AFilter{persistence.ArchivedAFilter(), failedFilter}, agp); err != nil {
+			glog.Errorf(logString(fmt.Sprintf("unable to read archived agreements for policy %v, error: %v", rollout.PolicyName, err)))
+		} else {
+			for _, ag := range agreements {
+				failed = append(failed, ag.DeviceId)
+			}
+		}
+	}
+	return failed
+}
+
+// Stop the rollout because the new version failed on some of the upgraded nodes.
+func (w *AgreementBotWorker) failRollout(rollout *persistence.WorkloadRollout, failed []string) {
+	glog.Warningf(logString(fmt.Sprintf("service version %v failed on nodes %v, rolling back the workload rollout of %v", rollout.Version, failed, rollout.PolicyName)))
+
+	if updated, err := w.db.SingleWorkloadRolloutUpdate(rollout.PolicyName, func(r persistence.WorkloadRollout) *persistence.WorkloadRollout {
+		if !r.IsActive() || r.Version != rollout.Version {
+			return nil
+		}
+		r.State = persistence.ROLLOUT_STATE_ROLLED_BACK
+		r.FailedNodes = failed
+		r.EndTime = uint64(time.Now().Unix())
+		return &r
+	}); err != nil {
+		glog.Errorf(logString(fmt.Sprintf("unable to roll back the workload rollout of %v, error: %v", rollout.PolicyName, err)))
+	} else if updated.State == persistence.ROLLOUT_STATE_ROLLED_BACK {
+		w.rollBackRolloutNodes(updated)
+	}
+}
+
+// Upgrade the pending nodes that have an agreement in this partition, as long as the current step has room for them. When
+// the nodes of the step are all upgraded, the verification of the step starts.
+func (w *AgreementBotWorker) upgradeRolloutNodes(rollout *persistence.WorkloadRollout, nodePolicyHandler exchange.NodePolicyHandler) {
+	if !rollout.StepComplete() {
+		pendingFilter := func(a persistence.Agreement) bool {
+			return a.PolicyName == rollout.PolicyName && a.AgreementFinalizedTime != 0 && a.AgreementTimedout == 0 &&
+				rollout.IsPending(a.DeviceId)
+		}
+
+		for _, agp := range policy.AllAgreementProtocols() {
+			agreements, err := w.db.FindAgreements([]persistence.AFilter{persistence.UnarchivedAFilter(), pendingFilter}, agp)
+			if err != nil {
+				glog.Errorf(logString(fmt.Sprintf("unable to read agreements for policy %v, error: %v", rollout.PolicyName, err)))
+				return
+			}
+
+			for _, ag := range agreements {
+				if pol, err := w.latestPolicy(ag.PolicyName, ag.Policy); err != nil {
+					glog.Errorf(logString(fmt.Sprintf("unable to get the policy of agreement %v, error: %v", ag.CurrentAgreementId, err)))
+					continue
+				} else if allowed, err := maintenanceAllows(nodePolicyHandler, pol, ag.DeviceId); err != nil {
+					glog.Errorf(logString(fmt.Sprintf("unable to check the maintenance schedule of node %v, error: %v", ag.DeviceId, err)))
+					continue
+				} else if !allowed {
+					// the node waits for its next maintenance window
+					continue
+				}
+
+				if allowed, err := rolloutAllowsUpgrade(w.db, rollout.PolicyName, ag.DeviceId); err != nil {
+					glog.Errorf(logString(fmt.Sprintf("unable to upgrade node %v in the workload rollout of %v, error: %v", ag.DeviceId, rollout.PolicyName, err)))
+					return
+				} else if !allowed {
+					// the current step has no room left
+					return
+				}
+
+				glog.Infof(logString(fmt.Sprintf("upgrading node %v to service version %v in the workload rollout of %v", ag.DeviceId, rollout.Version, rollout.PolicyName)))
+				w.cancelForRollout(&ag)
+			}
+		}
+		return
+	}
+
+	if _, err := w.db.SingleWorkloadRolloutUpdate(rollout.PolicyName, func(r persistence.WorkloadRollout) *persistence.WorkloadRollout {
+		if r.State != persistence.ROLLOUT_STATE_UPGRADING || r.Step != rollout.Step || !r.StepComplete() {
+			return nil
+		}
+		r.State = persistence.ROLLOUT_STATE_VERIFYING
+		r.VerificationStartTime = uint64(time.Now().Unix())
+		return &r
+	}); err != nil {
+		glog.Errorf(logString(fmt.Sprintf("unable to start the verification of the workload rollout of %v, error: %v", rollout.PolicyName, err)))
+	}
+}
+
+// When the verification period of the current step is over, start the next step or complete the rollout.
+func (w *AgreementBotWorker) verifyRollout(rollout *persistence.WorkloadRollout) {
+	if uint64(time.Now().Unix()) < rollout.VerificationStartTime+uint64(rollout.VerificationS) {
+		return
+	}
+
+	if updated, err := w.db.SingleWorkloadRolloutUpdate(rollout.PolicyName, func(r persistence.WorkloadRollout) *persistence.WorkloadRollout {
+		if r.State != persistence.ROLLOUT_STATE_VERIFYING || r.Step != rollout.Step {
+			return nil
+		} else if len(r.PendingNodes) == 0 {
+			r.State = persistence.ROLLOUT_STATE_COMPLETED
+			r.EndTime = uint64(time.Now().Unix())
+		} else {
+			r.State = persistence.ROLLOUT_STATE_UPGRADING
+			r.Step += 1
+		}
+		return &r
+	}); err != nil {
+		glog.Errorf(logString(fmt.Sprintf("unable to continue the workload rollout of %v, error: %v", rollout.PolicyName, err)))
+	} else if glog.V(3) {
+		glog.Infof(logString(fmt.Sprintf("workload rollout of %v is %v at step %v", updated.PolicyName, updated.State, updated.Step)))
+	}
+}
+
+// Cancel the agreements of the upgraded nodes in this partition that were made during the rollout, so that the nodes
+// make a new agreement with the previous version.
+func (w *AgreementBotWorker) rollBackRolloutNodes(rollout *persistence.WorkloadRollout) {
+	upgradedFilter := func(a persistence.Agreement) bool {
+		return a.PolicyName == rollout.PolicyName && a.AgreementTimedout == 0 && a.AgreementInceptionTime >= rollout.StartTime &&
+			a.AgreementInceptionTime <= rollout.EndTime && rollout.IsUpgraded(a.DeviceId)
+	}
+
+	for _, agp := range policy.AllAgreementProtocols() {
+		if agreements, err := w.db.FindAgreements([]persistence.AFilter{persistence.UnarchivedAFilter(), upgradedFilter}, agp); err != nil {
+			glog.Errorf(logString(fmt.Sprintf("unable to read agreements for policy %v, error: %v", rollout.PolicyName, err)))
+		} else {
+			for _, ag := range agreements {
+				glog.Infof(logString(fmt.Sprintf("rolling back node %v to service version %v in the workload rollout of %v", ag.DeviceId, rollout.PreviousVersion, rollout.PolicyName)))
+				w.cancelForRollout(&ag)
+			}
+		}
+	}
+}
+
+// Cancel the agreement so that the node makes a new one with the version the rollout allows it to run.
+func (w *AgreementBotWorker) cancelForRollout(ag *persistence.Agreement) {
+	// Make sure the workload usage record is gone, this will allow the node to pick up the highest priority workload.
+	if err := w.db.DeleteWorkloadUsage(ag.DeviceId, ag.PolicyName); err != nil {
+		glog.Errorf(logString(fmt.Sprintf("error deleting workload usage for %v using policy %v, error: %v", ag.DeviceId, ag.PolicyName, err)))
+	}
+	w.TerminateAgreement(ag, w.consumerPH.Get(ag.AgreementProtocol).GetTerminationCode(TERM_REASON_POLICY_CHANGED))
+}
@@ -0,0 +1,149 @@
+//go:build unit
+// +build unit
+
+package agreementbot
+
+import (
+	"fmt"
+	"github.com/open-horizon/anax/agreementbot/persistence"
+	"github.com/open-horizon/anax/agreementbot/persistence/sqlite"
+	"github.com/open-horizon/anax/config"
+	"github.com/open-horizon/anax/policy"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func Test_WorkloadRollout_steps(t *testing.T) {
+
+	r, err := persistence.NewWorkloadRollout("myorg/mypol", "2.0.0", "1.0.0", 25, 60)
+	assert.Nil(t, err)
+
+	nodes := []string{"myorg/n1", "myorg/n2", "myorg/n3", "myorg/n4", "myorg/n5", "myorg/n6", "myorg/n7", "myorg/n8"}
+	for _, n := range nodes {
+		r.AddPendingNode(n)
+	}
+	r.AddPendingNode("myorg/n1")
+	assert.Equal(t, 8, r.TotalNodes(), "a node is only added once")
+
+	// step 1 allows 25% of the nodes
+	assert.Equal(t, 2, r.StepQuota())
+	assert.True(t, r.ReleaseNode("myorg/n1"))
+	assert.True(t, r.ReleaseNode("myorg/n2"))
+	assert.False(t, r.ReleaseNode("myorg/n3"), "the step quota is used up")
+	assert.True(t, r.StepComplete())
+	assert.True(t, r.AllowsVersion("myorg/n1", "2.0.0"))
+	assert.False(t, r.AllowsVersion("myorg/n3", "2.0.0"))
+	assert.True(t, r.AllowsVersion("myorg/n3", "1.0.0"))
+
+	// no node is released while the step is verified
+	r.State = persistence.ROLLOUT_STATE_VERIFYING
+	r.Step = 2
+	assert.False(t, r.ReleaseNode("myorg/n3"))
+
+	// step 2 allows 50% of the nodes
+	r.State = persistence.ROLLOUT_STATE_UPGRADING
+	assert.Equal(t, 4, r.StepQuota())
+	assert.True(t, r.ReleaseNode("myorg/n3"))
+	assert.Equal(t, 5, len(r.PendingNodes))
+
+	// a rolled back rollout does not allow the new version on any node
+	r.State = persistence.ROLLOUT_STATE_ROLLED_BACK
+	assert.False(t, r.AllowsVersion("myorg/n1", "2.0.0"))
+	assert.False(t, r.ReleaseNode("myorg/n1"))
+
+	// a completed rollout allows the new version on every node
+	r.State = persistence.ROLLOUT_STATE_COMPLETED
+	assert.True(t, r.AllowsVersion("myorg/n8", "2.0.0"))
+
+	// a small percentage still upgrades one node
+	r, _ = persistence.NewWorkloadRollout("myorg/mypol", "2.0.0", "1.0.0", 1, 60)
+	r.AddPendingNode("myorg/n1")
+	r.AddPendingNode("myorg/n2")
+	assert.Equal(t, 1, r.StepQuota())
+
+	_, err = persistence.NewWorkloadRollout("myorg/mypol", "2.0.0", "", 10, 60)
+	assert.NotNil(t, err)
+}
+
+func Test_rolloutPreviousWorkload(t *testing.T) {
+
+	pol := policy.Policy_Factory("myorg/mypol")
+	pol.Workloads = []policy.Workload{
+		{WorkloadURL: "mysvc", Org: "myorg", Version: "2.0.0", Priority: policy.WorkloadPriority{PriorityValue: 1}},
+		{WorkloadURL: "mysvc", Org: "myorg", Version: "1.0.0", Priority: policy.WorkloadPriority{PriorityValue: 2}},
+	}
+
+	r, _ := persistence.NewWorkloadRollout("myorg/mypol", "2.0.0", "1.0.0", 10, 60)
+	wl := rolloutPreviousWorkload(pol, r)
+	assert.NotNil(t, wl)
+	assert.Equal(t, "1.0.0", wl.Version)
+	assert.Equal(t, 2, wl.Priority.PriorityValue)
+
+	r.PreviousVersion = "0.9.0"
+	assert.Nil(t, rolloutPreviousWorkload(pol, r))
+}
+
+func Test_startWorkloadRollout(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "utrollout-")
+	if err != nil {
+		t.Fatalf("Error creating the database directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	db := new(sqlite.AgbotSqliteDB)
+	if err := db.Initialize(&config.HorizonConfig{AgreementBot: config.AGConfig{Sqlite: config.SqliteConfig{DBPath: dir}}}); err != nil {
+		t.Fatalf("Error initializing the database: %v", err)
+	}
+	defer db.Close()
+
+	// 4 nodes have an agreement for the policy, another node for another policy
+	for ix, nodeId := range []string{"myorg/n1", "myorg/n2", "myorg/n3", "myorg/n4", "myorg/n5"} {
+		policyName := "myorg/mypol"
+		if nodeId == "myorg/n5" {
+			policyName = "myorg/otherpol"
+		}
+		err := db.AgreementAttempt(fmt.Sprintf("ag%v", ix), "myorg", nodeId, "device", policyName, "", "", "", policy.BasicProtocol, "", []string{"myorg/svc"}, policy.NodeHealth{}, 0, 0)
+		assert.Nil(t, err)
+	}
+
+	workload := func(version string, priority int) policy.Workload {
+		return policy.Workload{WorkloadURL: "svc", Org: "myorg", Version: version, Priority: policy.WorkloadPriority{PriorityValue: priority}}
+	}
+	oldPol := policy.Policy_Factory("myorg/mypol")
+	oldPol.Workloads = []policy.Workload{workload("1.0.0", 1)}
+	newPol := policy.Policy_Factory("myorg/mypol")
+	newPol.Workloads = []policy.Workload{workload("2.0.0", 1), workload("1.0.0", 2)}
+	newPol.Rollout = policy.Rollout_Factory(25, 60)
+
+	// all the nodes of the policy are enrolled, so the first step only releases one of the 4 nodes
+	rollout, err := startWorkloadRollout(db, newPol, oldPol)
+	assert.Nil(t, err)
+	if assert.NotNil(t, rollout) {
+		assert.Equal(t, 4, rollout.TotalNodes())
+		assert.Equal(t, 1, rollout.StepQuota())
+	}
+	allowed, err := rolloutAllowsUpgrade(db, "myorg/mypol", "myorg/n1")
+	assert.Nil(t, err)
+	assert.True(t, allowed)
+	allowed, err = rolloutAllowsUpgrade(db, "myorg/mypol", "myorg/n2")
+	assert.Nil(t, err)
+	assert.False(t, allowed, "the first step has no room left")
+
+	// starting the rollout again, e.g. for another protocol, keeps its state
+	rollout, err = startWorkloadRollout(db, newPol, oldPol)
+	assert.Nil(t, err)
+	if assert.NotNil(t, rollout) {
+		assert.Equal(t, 4, rollout.TotalNodes())
+		assert.True(t, rollout.IsUpgraded("myorg/n1"))
+	}
+
+	// without the previous version in the policy there is no rollout
+	newPol.Workloads = []policy.Workload{workload("3.0.0", 1)}
+	oldPol.Workloads = []policy.Workload{workload("2.0.0", 1)}
+	rollout, err = startWorkloadRollout(db, newPol, oldPol)
+	assert.Nil(t, err)
+	assert.Nil(t, rollout)
+}
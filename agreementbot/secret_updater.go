@@ -78,58 +78,63 @@ func (sm *SecretUpdateManager) CheckForUpdates(secretProvider secrets.AgbotSecre
 
 	// For each secret, check to see if it has changed since it was last checked.
 	for _, fullSecretName := range secretNames {
+		checkSecretForUpdate(secretProvider, db, fullSecretName, secretUpdates)
+	}
 
-		secretOrg := exchange.GetOrg(fullSecretName)
-		secretUser, secretName, err := compcheck.ParseVaultSecretName(exchange.GetId(fullSecretName), nil)
-		if err != nil {
-			glog.Errorf(smlogString(fmt.Sprintf("Error parsing secret %s, error: %v", fullSecretName, err)))
-			continue
-		}
+	return secretUpdates, nil
+}
 
-		glog.V(5).Infof(smlogString(fmt.Sprintf("Checking for changes to secret %s", fullSecretName)))
+// Check a single secret, named org/secret, to see if it has changed since it was last checked. Updates are added to
+// the input secret updates object.
+func checkSecretForUpdate(secretProvider secrets.AgbotSecrets, db persistence.AgbotDatabase, fullSecretName string, secretUpdates *events.SecretUpdates) {
 
-		// All secrets that are referenced by a policy or pattern are in the secret update tables, but some of these secrets
-		// might not exist yet.
-		secretMetadata, err := secretProvider.GetSecretMetadata(secretOrg, secretUser, secretName)
-		if err != nil {
-			// For secrets that dont exist yet, just ignore them.
-			glog.Warningf(smlogString(fmt.Sprintf("Error retrieving metadata for secret %s for user %s in org %s metadata, error: %v", secretName, secretUser, secretOrg, err)))
-			continue
-		}
+	secretOrg := exchange.GetOrg(fullSecretName)
+	secretUser, secretName, err := compcheck.ParseVaultSecretName(exchange.GetId(fullSecretName), nil)
+	if err != nil {
+		glog.Errorf(smlogString(fmt.Sprintf("Error parsing secret %s, error: %v", fullSecretName, err)))
+		return
+	}
 
-		glog.V(5).Infof(smlogString(fmt.Sprintf("Secret %s metadata: %v", fullSecretName, secretMetadata)))
+	glog.V(5).Infof(smlogString(fmt.Sprintf("Checking for changes to secret %s", fullSecretName)))
 
-		// Get a list of policies that have a secret which has been updated.
-		policyNames, err := db.GetPoliciesWithUpdatedSecrets(secretOrg, exchange.GetId(fullSecretName), secretMetadata.UpdateTime)
-		if err != nil {
-			glog.Errorf(smlogString(fmt.Sprintf("Error checking policies for updated secret %s", fullSecretName)))
-			continue
-		}
+	// All secrets that are referenced by a policy or pattern are in the secret update tables, but some of these secrets
+	// might not exist yet.
+	secretMetadata, err := secretProvider.GetSecretMetadata(secretOrg, secretUser, secretName)
+	if err != nil {
+		// For secrets that dont exist yet, just ignore them.
+		glog.Warningf(smlogString(fmt.Sprintf("Error retrieving metadata for secret %s for user %s in org %s metadata, error: %v", secretName, secretUser, secretOrg, err)))
+		return
+	}
 
-		// If there are policies returned, then it means that the policy references the secret and the secret has been updated.
-		if len(policyNames) != 0 {
-			su := events.NewSecretUpdate(secretOrg, exchange.GetId(fullSecretName), secretMetadata.UpdateTime, policyNames, []string{})
-			secretUpdates.AddSecretUpdate(su)
-			glog.V(5).Infof(smlogString(fmt.Sprintf("Policies affected by %s, %v", fullSecretName, policyNames)))
-		}
+	glog.V(5).Infof(smlogString(fmt.Sprintf("Secret %s metadata: %v", fullSecretName, secretMetadata)))
 
-		// Get a list of patterns that have a secret which has been updated.
-		patternNames, err := db.GetPatternsWithUpdatedSecrets(secretOrg, exchange.GetId(fullSecretName), secretMetadata.UpdateTime)
-		if err != nil {
-			glog.Errorf(smlogString(fmt.Sprintf("Error checking patterns for updated secret %s", fullSecretName)))
-			continue
-		}
+	// Get a list of policies that have a secret which has been updated.
+	policyNames, err := db.GetPoliciesWithUpdatedSecrets(secretOrg, exchange.GetId(fullSecretName), secretMetadata.UpdateTime)
+	if err != nil {
+		glog.Errorf(smlogString(fmt.Sprintf("Error checking policies for updated secret %s", fullSecretName)))
+		return
+	}
 
-		// If there are patterns returned, then it means that the secret has been updated.
-		if len(patternNames) != 0 {
-			su := events.NewSecretUpdate(secretOrg, exchange.GetId(fullSecretName), secretMetadata.UpdateTime, []string{}, patternNames)
-			secretUpdates.AddSecretUpdate(su)
-			glog.V(5).Infof(smlogString(fmt.Sprintf("Patterns affected by %s, %v", fullSecretName, patternNames)))
-		}
+	// If there are policies returned, then it means that the policy references the secret and the secret has been updated.
+	if len(policyNames) != 0 {
+		su := events.NewSecretUpdate(secretOrg, exchange.GetId(fullSecretName), secretMetadata.UpdateTime, policyNames, []string{})
+		secretUpdates.AddSecretUpdate(su)
+		glog.V(5).Infof(smlogString(fmt.Sprintf("Policies affected by %s, %v", fullSecretName, policyNames)))
+	}
 
+	// Get a list of patterns that have a secret which has been updated.
+	patternNames, err := db.GetPatternsWithUpdatedSecrets(secretOrg, exchange.GetId(fullSecretName), secretMetadata.UpdateTime)
+	if err != nil {
+		glog.Errorf(smlogString(fmt.Sprintf("Error checking patterns for updated secret %s", fullSecretName)))
+		return
 	}
 
-	return secretUpdates, nil
+	// If there are patterns returned, then it means that the secret has been updated.
+	if len(patternNames) != 0 {
+		su := events.NewSecretUpdate(secretOrg, exchange.GetId(fullSecretName), secretMetadata.UpdateTime, []string{}, patternNames)
+		secretUpdates.AddSecretUpdate(su)
+		glog.V(5).Infof(smlogString(fmt.Sprintf("Patterns affected by %s, %v", fullSecretName, patternNames)))
+	}
 }
 
 // When policies are added, changed or deleted, the list of managed secrets in the DB might need to be updated.
@@ -0,0 +1,129 @@
+package file
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/agreementbot/secrets"
+	"github.com/open-horizon/anax/cutil"
+	"github.com/open-horizon/anax/exchange"
+	"strings"
+	"time"
+)
+
+// The vault enforces access to secrets with ACLs that are derived from the exchange user's identity. The file provider
+// has no such service in front of it, so it applies the same rules itself:
+// - The agbot can read every secret.
+// - Users in the root org with admin authority can do anything, in any org.
+// - Users can read and list the org level secrets of their own org. Org admins can also create and delete them.
+// - Users can manage their own user level secrets. Org admins can manage the user level secrets of all users in their org.
+
+const READ = "GET"
+const WRITE = "PUT"
+
+// The number of seconds that a successful exchange login is reused for, so that every secret request does not have to
+// call the exchange. A change to the user's authority in the exchange takes effect when the cached login expires.
+const AUTH_CACHE_TTL = 60
+
+type exchangeUser struct {
+	org   string
+	id    string
+	admin bool
+}
+
+type cachedLogin struct {
+	user    *exchangeUser
+	expires int64
+}
+
+// The key of a cached login. The token is hashed so that it is not kept in memory in the clear.
+func loginCacheKey(user, token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return user + ":" + hex.EncodeToString(hash[:])
+}
+
+// Authenticate the input user with the exchange and return the user's authority. The agbot's own credentials are
+// returned as a nil user.
+func (fs *AgbotFileSecrets) loginUser(user, token string) (*exchangeUser, error) {
+
+	if user == fs.cfg.AgreementBot.ExchangeId && token == fs.cfg.AgreementBot.ExchangeToken {
+		return nil, nil
+	}
+
+	key := loginCacheKey(user, token)
+	now := time.Now().Unix()
+
+	fs.authLock.Lock()
+	cached, ok := fs.authCache[key]
+	fs.authLock.Unlock()
+	if ok && cached.expires > now {
+		return cached.user, nil
+	}
+
+	userOrg, userId := cutil.SplitOrgSpecUrl(user)
+	if userOrg == "" || userId == "" {
+		return nil, errors.New(fmt.Sprintf("user %v must be org qualified", user))
+	}
+
+	var resp interface{}
+	resp = new(exchange.GetUsersResponse)
+	targetURL := fmt.Sprintf("%vorgs/%v/users/%v", fs.cfg.AgreementBot.ExchangeURL, userOrg, userId)
+	if err := exchange.InvokeExchangeRetryOnTransportError(fs.cfg.Collaborators.HTTPClientFactory, "GET", targetURL, user, token, nil, &resp); err != nil {
+		return nil, err
+	}
+
+	exUser := &exchangeUser{org: userOrg, id: userId}
+	users, _ := resp.(*exchange.GetUsersResponse)
+	if u, ok := users.Users[fmt.Sprintf("%v/%v", userOrg, userId)]; ok {
+		exUser.admin = u.Admin
+	} else {
+		return nil, errors.New(fmt.Sprintf("user %v not found in the exchange", user))
+	}
+
+	// Only successful logins are cached, and the expired ones are dropped whenever a new one is added.
+	fs.authLock.Lock()
+	for k, c := range fs.authCache {
+		if c.expires <= now {
+			delete(fs.authCache, k)
+		}
+	}
+	fs.authCache[key] = cachedLogin{user: exUser, expires: now + AUTH_CACHE_TTL}
+	fs.authLock.Unlock()
+
+	glog.V(5).Infof(filePluginLogString(fmt.Sprintf("authenticated user %v, admin: %v", user, exUser.admin)))
+	return exUser, nil
+}
+
+// Verify that the user is allowed to perform the action on the secret in the org. The secret name is the full name, so
+// user level secrets start with user/<user>. An empty name refers to all the org level secrets.
+func (fs *AgbotFileSecrets) authorize(user, token, org, name, action string) error {
+
+	exUser, err := fs.loginUser(user, token)
+	if err != nil {
+		return &secrets.Unauthenticated{LoginError: err, ExchangeUser: user}
+	} else if exUser == nil {
+		if action == READ {
+			return nil
+		}
+	} else if exUser.org == "root" && exUser.admin {
+		return nil
+	} else if exUser.org == org {
+		if strings.HasPrefix(name, "user/") {
+			owner := strings.SplitN(strings.TrimPrefix(name, "user/"), "/", 2)[0]
+			if owner == exUser.id || exUser.admin {
+				return nil
+			}
+		} else if action == READ || exUser.admin {
+			return nil
+		}
+	}
+
+	return &secrets.PermissionDenied{
+		Response:     map[string][]string{"errors": {"permission denied"}},
+		HttpMethod:   action,
+		SecretPath:   fmt.Sprintf("%v/%v", org, name),
+		ExchangeUser: user,
+	}
+}
@@ -0,0 +1,300 @@
+package file
+
+import (
+	"fmt"
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/agreementbot/secrets"
+	"github.com/open-horizon/anax/config"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// This function registers an uninitialized agbot secrets implementation with the secrets plugin registry. The plugin's Initialize
+// method is used to configure the object.
+func init() {
+	secrets.Register("file", new(AgbotFileSecrets))
+}
+
+// The fields in this object are initialized in the Initialize method in this package.
+type AgbotFileSecrets struct {
+	cfg             *config.HorizonConfig
+	storeFile       string       // The encrypted file holding the secrets.
+	key             []byte       // The key used to encrypt the secrets file.
+	lock            sync.RWMutex // Protects the in memory copy of the secrets.
+	store           *secretStore // The in memory copy of the secrets, nil until Login is called.
+	authLock        sync.Mutex   // Protects the cached user logins.
+	authCache       map[string]cachedLogin
+	lastInteraction uint64
+}
+
+func (fs *AgbotFileSecrets) String() string {
+	return fmt.Sprintf("StoreFile: %v", fs.storeFile)
+}
+
+func (fs *AgbotFileSecrets) touch() {
+	atomic.StoreUint64(&fs.lastInteraction, uint64(time.Now().Unix()))
+}
+
+// Check existence of an org level secret.
+func (fs *AgbotFileSecrets) ListOrgSecret(user, token, org, path string) error {
+	glog.V(3).Infof(filePluginLogString(fmt.Sprintf("list secret %v in org %v", path, org)))
+	return fs.listSecret(user, token, org, path)
+}
+
+// Check existence of a user level secret.
+func (fs *AgbotFileSecrets) ListOrgUserSecret(user, token, org, path string) error {
+	glog.V(3).Infof(filePluginLogString(fmt.Sprintf("list secret %v in org %v as user %v", path, org, user)))
+	return fs.listSecret(user, token, org, path)
+}
+
+func (fs *AgbotFileSecrets) listSecret(user, token, org, name string) error {
+
+	if err := fs.authorize(user, token, org, name, READ); err != nil {
+		return err
+	}
+
+	fs.lock.RLock()
+	defer fs.lock.RUnlock()
+
+	store, err := fs.getStore()
+	if err != nil {
+		return err
+	}
+
+	if store.get(org, name) == nil {
+		return &secrets.NoSecretFound{Response: map[string][]string{"errors": {}}, SecretPath: fmt.Sprintf("%v/%v", org, name)}
+	}
+	fs.touch()
+	return nil
+}
+
+// List all org level secrets at a specified path.
+func (fs *AgbotFileSecrets) ListOrgSecrets(user, token, org, path string) ([]string, error) {
+	glog.V(3).Infof(filePluginLogString(fmt.Sprintf("list secrets in %v", org)))
+	return fs.listSecrets(user, token, org, path)
+}
+
+// List all user level secrets at a specified path. The returned names do not include the user/<user> prefix.
+func (fs *AgbotFileSecrets) ListOrgUserSecrets(user, token, org, path string) ([]string, error) {
+	glog.V(3).Infof(filePluginLogString(fmt.Sprintf("listing secrets for user %v in %v", user, org)))
+
+	names, err := fs.listSecrets(user, token, org, path)
+	if err != nil {
+		return nil, err
+	}
+
+	secretList := make([]string, 0, len(names))
+	for _, name := range names {
+		secretList = append(secretList, strings.TrimPrefix(name, path+"/"))
+	}
+	return secretList, nil
+}
+
+func (fs *AgbotFileSecrets) listSecrets(user, token, org, path string) ([]string, error) {
+
+	if err := fs.authorize(user, token, org, path, READ); err != nil {
+		return nil, err
+	}
+
+	fs.lock.RLock()
+	defer fs.lock.RUnlock()
+
+	store, err := fs.getStore()
+	if err != nil {
+		return nil, err
+	}
+
+	names := store.list(org, path)
+	if len(names) == 0 {
+		return nil, &secrets.NoSecretFound{Response: map[string][]string{"errors": {}}, SecretPath: fmt.Sprintf("%v/%v", org, path)}
+	}
+	sort.Strings(names)
+	fs.touch()
+	return names, nil
+}
+
+// Create or update an org level secret. Only org admins can do this.
+func (fs *AgbotFileSecrets) CreateOrgSecret(user, token, org, path string, data secrets.SecretDetails) error {
+	glog.V(3).Infof(filePluginLogString(fmt.Sprintf("creating secret %s in org %s", path, org)))
+	return fs.createSecret(user, token, org, path, data)
+}
+
+// Create or update a user level secret.
+func (fs *AgbotFileSecrets) CreateOrgUserSecret(user, token, org, path string, data secrets.SecretDetails) error {
+	glog.V(3).Infof(filePluginLogString(fmt.Sprintf("creating secret %s in org %s", path, org)))
+	return fs.createSecret(user, token, org, path, data)
+}
+
+func (fs *AgbotFileSecrets) createSecret(user, token, org, name string, data secrets.SecretDetails) error {
+
+	if name == "" || strings.HasSuffix(name, "/") {
+		return &secrets.BadRequest{ResponseCode: http.StatusBadRequest, Response: map[string][]string{"errors": {"Secret name must not be empty or end with /"}},
+			HttpMethod: http.MethodPut, SecretPath: fmt.Sprintf("%v/%v", org, name), RequestBody: &data}
+	} else if err := fs.authorize(user, token, org, name, WRITE); err != nil {
+		return err
+	}
+
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	store, err := fs.getStore()
+	if err != nil {
+		return err
+	}
+
+	if err := fs.saveSecret(store, org, name, data); err != nil {
+		return err
+	}
+
+	glog.V(3).Infof(filePluginLogString(fmt.Sprintf("done creating %s.", name)))
+	return nil
+}
+
+// Write a new version of a secret and save the file. The caller must hold the lock.
+func (fs *AgbotFileSecrets) saveSecret(store *secretStore, org, name string, data secrets.SecretDetails) error {
+
+	// Keep the existing secret so that the in memory copy can be restored if the file cannot be written.
+	var previous *storedSecret
+	if current := store.get(org, name); current != nil {
+		previous = current.copy()
+	}
+
+	store.put(org, name, data, time.Now().Unix())
+	if err := writeStore(fs.storeFile, fs.key, store); err != nil {
+		if previous != nil {
+			store.restore(org, name, previous)
+		} else {
+			store.remove(org, name)
+		}
+		return &secrets.SecretsProviderUnavailable{ProviderError: err}
+	}
+
+	fs.touch()
+	return nil
+}
+
+// Delete an org level secret. Only org admins can do this.
+func (fs *AgbotFileSecrets) DeleteOrgSecret(user, token, org, path string) error {
+	glog.V(3).Infof(filePluginLogString(fmt.Sprintf("delete secret %s in org %s", path, org)))
+	return fs.deleteSecret(user, token, org, path)
+}
+
+// Delete a user level secret.
+func (fs *AgbotFileSecrets) DeleteOrgUserSecret(user, token, org, path string) error {
+	glog.V(3).Infof(filePluginLogString(fmt.Sprintf("delete secret %s in org %s", path, org)))
+	return fs.deleteSecret(user, token, org, path)
+}
+
+func (fs *AgbotFileSecrets) deleteSecret(user, token, org, name string) error {
+
+	if err := fs.authorize(user, token, org, name, WRITE); err != nil {
+		return err
+	}
+
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	store, err := fs.getStore()
+	if err != nil {
+		return err
+	}
+
+	current := store.get(org, name)
+	if current == nil {
+		return &secrets.NoSecretFound{Response: map[string][]string{"errors": {}}, SecretPath: fmt.Sprintf("%v/%v", org, name)}
+	}
+
+	store.remove(org, name)
+	if err := writeStore(fs.storeFile, fs.key, store); err != nil {
+		store.restore(org, name, current)
+		return &secrets.SecretsProviderUnavailable{ProviderError: err}
+	}
+
+	fs.touch()
+	glog.V(3).Infof(filePluginLogString(fmt.Sprintf("done deleting %s.", name)))
+	return nil
+}
+
+func (fs *AgbotFileSecrets) GetSecretDetails(user, token, org, secretUser, secretName string) (secrets.SecretDetails, error) {
+
+	glog.V(3).Infof(filePluginLogString(fmt.Sprintf("extract secret details for %s in org %s as user %s", secretName, org, secretUser)))
+
+	if err := fs.authorize(user, token, org, fullSecretName(secretUser, secretName), READ); err != nil {
+		return secrets.SecretDetails{}, err
+	}
+
+	stored, err := fs.findSecret(org, secretUser, secretName, http.MethodGet)
+	if err != nil {
+		return secrets.SecretDetails{}, err
+	}
+
+	glog.V(3).Infof(filePluginLogString("done extracting secret details"))
+	return stored.Details, nil
+}
+
+// Retrieve the metadata for a secret. This is called by the agbot itself, so there is no user to authorize.
+func (fs *AgbotFileSecrets) GetSecretMetadata(secretOrg, secretUser, secretName string) (secrets.SecretMetadata, error) {
+
+	glog.V(3).Infof(filePluginLogString(fmt.Sprintf("extract secret metadata for %s in org %s as user %s", secretName, secretOrg, secretUser)))
+
+	stored, err := fs.findSecret(secretOrg, secretUser, secretName, http.MethodGet)
+	if err != nil {
+		return secrets.SecretMetadata{}, err
+	}
+
+	res := secrets.SecretMetadata{CreationTime: stored.CreationTime, UpdateTime: stored.UpdateTime}
+	glog.V(5).Infof(filePluginLogString(fmt.Sprintf("Metadata: %v", res)))
+	return res, nil
+}
+
+// Return a copy of a secret, or an error if the input is malformed or the secret does not exist.
+func (fs *AgbotFileSecrets) findSecret(org, secretUser, secretName, method string) (*storedSecret, error) {
+
+	if org == "" {
+		return nil, &secrets.BadRequest{Response: map[string][]string{"errors": {"Organization name must not be an empty string"}}, HttpMethod: method}
+	} else if secretName == "" {
+		return nil, &secrets.BadRequest{Response: map[string][]string{"errors": {"Secret name must not be an empty string"}}, HttpMethod: method}
+	}
+
+	name := fullSecretName(secretUser, secretName)
+
+	fs.lock.RLock()
+	defer fs.lock.RUnlock()
+
+	store, err := fs.getStore()
+	if err != nil {
+		return nil, err
+	}
+
+	stored := store.get(org, name)
+	if stored == nil {
+		return nil, &secrets.NoSecretFound{Response: map[string][]string{"errors": {}}, SecretPath: fmt.Sprintf("%v/%v", org, name)}
+	}
+
+	fs.touch()
+	return stored.copy(), nil
+}
+
+// Return the in memory copy of the secrets, or an error if Login has not loaded them yet. The caller must hold the lock.
+func (fs *AgbotFileSecrets) getStore() (*secretStore, error) {
+	if fs.store == nil {
+		return nil, &secrets.SecretsProviderUnavailable{ProviderError: fmt.Errorf("secrets have not been loaded")}
+	}
+	return fs.store, nil
+}
+
+func fullSecretName(secretUser, secretName string) string {
+	if secretUser != "" {
+		return fmt.Sprintf("user/%s/%s", secretUser, secretName)
+	}
+	return secretName
+}
+
+// Log string prefix api
+var filePluginLogString = func(v interface{}) string {
+	return fmt.Sprintf("File Secrets Plugin: %v", v)
+}
@@ -0,0 +1,275 @@
+//go:build unit
+// +build unit
+
+package file
+
+import (
+	"encoding/json"
+	"github.com/open-horizon/anax/agreementbot/secrets"
+	"github.com/open-horizon/anax/config"
+	"github.com/open-horizon/anax/exchange"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func Test_store_encryption(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "utsecretsfile-")
+	if err != nil {
+		t.Fatalf("Error creating the secrets directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	keyFile := path.Join(dir, "key")
+	storeFile := path.Join(dir, "store")
+
+	// The key is generated once and then read back from the key file.
+	key, err := loadKey(keyFile)
+	if err != nil {
+		t.Fatalf("Error creating key: %v", err)
+	} else if len(key) != KEY_SIZE {
+		t.Errorf("Expected a %v byte key but got %v bytes", KEY_SIZE, len(key))
+	} else if key2, err := loadKey(keyFile); err != nil {
+		t.Errorf("Error reading key: %v", err)
+	} else if string(key2) != string(key) {
+		t.Errorf("The key read from %v is not the key that was created", keyFile)
+	}
+
+	// A missing store file is an empty store.
+	if store, err := readStore(storeFile, key); err != nil {
+		t.Errorf("Error reading missing store: %v", err)
+	} else if len(store.Orgs) != 0 {
+		t.Errorf("Expected an empty store but got %v", store.Orgs)
+	}
+
+	store := newSecretStore()
+	store.put("myorg", "sec1", secrets.SecretDetails{Key: "user", Value: "password1"}, 10)
+	store.put("myorg", "user/user1/sec2", secrets.SecretDetails{Key: "user", Value: "password2"}, 20)
+	if err := writeStore(storeFile, key, store); err != nil {
+		t.Fatalf("Error writing store: %v", err)
+	}
+
+	// The secret values are not written in the clear.
+	if data, err := ioutil.ReadFile(storeFile); err != nil {
+		t.Errorf("Error reading store file: %v", err)
+	} else if strings.Contains(string(data), "password1") || strings.Contains(string(data), "sec1") {
+		t.Errorf("The store file is not encrypted")
+	}
+
+	if read, err := readStore(storeFile, key); err != nil {
+		t.Errorf("Error reading store: %v", err)
+	} else if s := read.get("myorg", "sec1"); s == nil || s.Details.Value != "password1" || s.CreationTime != 10 || s.CurrentVersion != 1 {
+		t.Errorf("Expected sec1 to be read back but got %v", s)
+	} else if s := read.get("myorg", "user/user1/sec2"); s == nil || s.Details.Value != "password2" {
+		t.Errorf("Expected user/user1/sec2 to be read back but got %v", s)
+	}
+
+	// The store cannot be read with another key, or when the file is damaged.
+	otherKey := make([]byte, KEY_SIZE)
+	if _, err := readStore(storeFile, otherKey); err == nil {
+		t.Errorf("Expected an error reading the store with the wrong key")
+	} else if err := ioutil.WriteFile(storeFile, []byte("short"), 0600); err != nil {
+		t.Errorf("Error writing store file: %v", err)
+	} else if _, err := readStore(storeFile, key); err == nil {
+		t.Errorf("Expected an error reading a damaged store")
+	}
+
+	// A key file that is not a 256 bit key is rejected.
+	if err := ioutil.WriteFile(keyFile, []byte("c2hvcnQ="), 0600); err != nil {
+		t.Errorf("Error writing key file: %v", err)
+	} else if _, err := loadKey(keyFile); err == nil {
+		t.Errorf("Expected an error loading a short key")
+	}
+}
+
+func Test_FileSecrets(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "utsecretsfile-")
+	if err != nil {
+		t.Fatalf("Error creating the secrets directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	var logins int32
+	ex := newExchange(t, &logins)
+	defer ex.Close()
+
+	fs := newFileSecrets(t, dir, ex)
+
+	admin, user1, user2 := "myorg/admin", "myorg/user1", "myorg/user2"
+	details := secrets.SecretDetails{Key: "user", Value: "password"}
+
+	// Org admins create org level secrets, users can only read them.
+	if err := fs.CreateOrgSecret(admin, "pw", "myorg", "sec1", details); err != nil {
+		t.Errorf("Error creating org secret: %v", err)
+	} else if err := fs.CreateOrgSecret(user1, "pw", "myorg", "sec2", details); err == nil {
+		t.Errorf("Expected an error creating an org secret as a user")
+	} else if _, ok := err.(*secrets.PermissionDenied); !ok {
+		t.Errorf("Expected a PermissionDenied error but got %T: %v", err, err)
+	} else if d, err := fs.GetSecretDetails(user1, "pw", "myorg", "", "sec1"); err != nil {
+		t.Errorf("Error reading org secret: %v", err)
+	} else if d.Value != "password" {
+		t.Errorf("Expected password but got %v", d)
+	} else if _, err := fs.GetSecretDetails("otherorg/user1", "pw", "myorg", "", "sec1"); err == nil {
+		t.Errorf("Expected an error reading a secret of another org")
+	}
+
+	// Users manage their own user level secrets.
+	if err := fs.CreateOrgUserSecret(user1, "pw", "myorg", "user/user1/sec3", details); err != nil {
+		t.Errorf("Error creating user secret: %v", err)
+	} else if _, err := fs.GetSecretDetails(user2, "pw", "myorg", "user1", "sec3"); err == nil {
+		t.Errorf("Expected an error reading the secret of another user")
+	} else if names, err := fs.ListOrgUserSecrets(user1, "pw", "myorg", "user/user1"); err != nil {
+		t.Errorf("Error listing user secrets: %v", err)
+	} else if len(names) != 1 || names[0] != "sec3" {
+		t.Errorf("Expected user secret sec3 but got %v", names)
+	}
+
+	// The agbot reads every secret but cannot change them.
+	if _, err := fs.GetSecretDetails("myorg/agbot", "agbotpw", "myorg", "user1", "sec3"); err != nil {
+		t.Errorf("Error reading user secret as the agbot: %v", err)
+	} else if err := fs.DeleteOrgSecret("myorg/agbot", "agbotpw", "myorg", "sec1"); err == nil {
+		t.Errorf("Expected an error deleting a secret as the agbot")
+	}
+
+	// A wrong token is not authenticated.
+	if _, err := fs.GetSecretDetails(user1, "wrong", "myorg", "", "sec1"); err == nil {
+		t.Errorf("Expected an error reading a secret with the wrong token")
+	} else if _, ok := err.(*secrets.Unauthenticated); !ok {
+		t.Errorf("Expected an Unauthenticated error but got %T: %v", err, err)
+	}
+
+	// Each user logged in to the exchange once, the failed logins are not cached.
+	if n := atomic.LoadInt32(&logins); n != 5 {
+		t.Errorf("Expected 5 exchange logins but got %v", n)
+	} else if _, err := fs.GetSecretDetails(user1, "wrong", "myorg", "", "sec1"); err == nil {
+		t.Errorf("Expected an error reading a secret with the wrong token")
+	} else if n := atomic.LoadInt32(&logins); n != 6 {
+		t.Errorf("Expected the failed login to be retried but got %v exchange logins", n)
+	}
+
+	// An expired login is checked with the exchange again.
+	fs.authLock.Lock()
+	for k, c := range fs.authCache {
+		c.expires = 0
+		fs.authCache[k] = c
+	}
+	fs.authLock.Unlock()
+	if _, err := fs.GetSecretDetails(user1, "pw", "myorg", "", "sec1"); err != nil {
+		t.Errorf("Error reading org secret: %v", err)
+	} else if n := atomic.LoadInt32(&logins); n != 7 {
+		t.Errorf("Expected an exchange login for the expired login but got %v exchange logins", n)
+	}
+
+	// The secrets are read back from the encrypted file by a new instance.
+	fs2 := newFileSecrets(t, dir, ex)
+	if d, err := fs2.GetSecretDetails(user1, "pw", "myorg", "user1", "sec3"); err != nil {
+		t.Errorf("Error reading user secret from the file: %v", err)
+	} else if d.Key != "user" || d.Value != "password" {
+		t.Errorf("Expected the secret details to be read back but got %v", d)
+	} else if m, err := fs2.GetSecretMetadata("myorg", "", "sec1"); err != nil {
+		t.Errorf("Error reading metadata: %v", err)
+	} else if m.CreationTime == 0 || m.UpdateTime < m.CreationTime {
+		t.Errorf("Wrong metadata %v", m)
+	}
+
+	if err := fs2.DeleteOrgSecret(admin, "pw", "myorg", "sec1"); err != nil {
+		t.Errorf("Error deleting org secret: %v", err)
+	} else if _, err := fs2.GetSecretMetadata("myorg", "", "sec1"); err == nil {
+		t.Errorf("Expected an error reading a deleted secret")
+	} else if _, ok := err.(*secrets.NoSecretFound); !ok {
+		t.Errorf("Expected a NoSecretFound error but got %T: %v", err, err)
+	}
+}
+
+func Test_FileSecrets_key_location(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "utsecretsfile-")
+	if err != nil {
+		t.Fatalf("Error creating the secrets directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	storeDir := path.Join(dir, "secrets")
+	for _, keyFile := range []string{"", path.Join(storeDir, "agbot_secrets.key"), path.Join(storeDir, "keys", "agbot_secrets.key"), path.Join(dir, "other", "..", "secrets", "key")} {
+		cfg := &config.HorizonConfig{AgreementBot: config.AGConfig{SecretsFile: config.SecretFileConfig{Path: storeDir, KeyPath: keyFile}}}
+		if err := new(AgbotFileSecrets).Initialize(cfg); err == nil {
+			t.Errorf("Expected an error initializing the plugin with key file %v in %v", keyFile, storeDir)
+		}
+	}
+
+	// A key file next to the secrets directory is allowed, and the secrets are not available until they are loaded.
+	cfg := &config.HorizonConfig{AgreementBot: config.AGConfig{SecretsFile: config.SecretFileConfig{Path: storeDir, KeyPath: path.Join(dir, "secrets.key")}}}
+	fs := new(AgbotFileSecrets)
+	if err := fs.Initialize(cfg); err != nil {
+		t.Fatalf("Error initializing the plugin: %v", err)
+	} else if fs.IsReady() {
+		t.Errorf("The plugin should not be ready before Login")
+	}
+
+	fs.lock.RLock()
+	_, err = fs.getStore()
+	fs.lock.RUnlock()
+	if _, ok := err.(*secrets.SecretsProviderUnavailable); !ok {
+		t.Errorf("Expected a SecretsProviderUnavailable error before Login but got %T: %v", err, err)
+	} else if _, err := fs.GetSecretMetadata("myorg", "", "sec1"); err == nil {
+		t.Errorf("Expected an error reading a secret before Login")
+	}
+}
+
+// Create a file secrets plugin in the directory that authenticates users with the mock exchange.
+func newFileSecrets(t *testing.T, dir string, ex *httptest.Server) *AgbotFileSecrets {
+	cfg := &config.HorizonConfig{
+		AgreementBot: config.AGConfig{
+			ExchangeURL:   ex.URL + "/",
+			ExchangeId:    "myorg/agbot",
+			ExchangeToken: "agbotpw",
+			SecretsFile:   config.SecretFileConfig{Path: path.Join(dir, "secrets"), KeyPath: path.Join(dir, "keys", "agbot_secrets.key")},
+		},
+		Collaborators: config.Collaborators{
+			HTTPClientFactory: &config.HTTPClientFactory{
+				NewHTTPClient: func(overrideTimeoutS *uint) *http.Client { return ex.Client() },
+				RetryCount:    1,
+				RetryInterval: 1,
+			},
+		},
+	}
+
+	fs := new(AgbotFileSecrets)
+	if err := fs.Initialize(cfg); err != nil {
+		t.Fatalf("Error initializing the plugin: %v", err)
+	} else if err := fs.Login(); err != nil {
+		t.Fatalf("Error loading the secrets: %v", err)
+	} else if !fs.IsReady() {
+		t.Fatalf("The plugin should be ready")
+	}
+	return fs
+}
+
+// A mock exchange with an org admin and two users in myorg, all with the token pw. The number of user lookups is counted.
+func newExchange(t *testing.T, logins *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(logins, 1)
+		user, token, _ := r.BasicAuth()
+		id := strings.TrimPrefix(r.URL.Path, "/orgs/myorg/users/")
+		if token != "pw" || user != "myorg/"+id {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"code":"access denied","msg":"invalid credentials"}`))
+			return
+		}
+
+		resp := exchange.GetUsersResponse{Users: map[string]exchange.UserDefinition{user: exchange.UserDefinition{Admin: id == "admin"}}}
+		if body, err := json.Marshal(resp); err != nil {
+			t.Errorf("Error marshaling exchange response: %v", err)
+		} else {
+			w.WriteHeader(http.StatusOK)
+			w.Write(body)
+		}
+	}))
+}
@@ -0,0 +1,104 @@
+package file
+
+import (
+	"errors"
+	"fmt"
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/config"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+)
+
+// This function is called by the anax main to allow the plugin a chance to initialize itself. The encryption key is
+// loaded (or created) here, the secrets themselves are read by the Login function.
+func (fs *AgbotFileSecrets) Initialize(cfg *config.HorizonConfig) error {
+
+	glog.V(1).Infof(filePluginLogString("Initializing the secrets file as the secrets plugin."))
+
+	fs.cfg = cfg
+	fs.storeFile = cfg.AgreementBot.SecretsFile.GetStoreFile()
+	fs.authCache = make(map[string]cachedLogin)
+
+	if err := checkKeyFile(cfg.AgreementBot.SecretsFile.Path, cfg.AgreementBot.SecretsFile.GetKeyFile()); err != nil {
+		return err
+	} else if err := os.MkdirAll(cfg.AgreementBot.SecretsFile.Path, 0700); err != nil {
+		return errors.New(fmt.Sprintf("unable to create secrets directory %v, error: %v", cfg.AgreementBot.SecretsFile.Path, err))
+	} else if key, err := loadKey(cfg.AgreementBot.SecretsFile.GetKeyFile()); err != nil {
+		return err
+	} else {
+		fs.key = key
+	}
+
+	glog.V(1).Infof(filePluginLogString(fmt.Sprintf("Initialized the secrets file %v as the secrets plugin", fs.storeFile)))
+
+	return nil
+}
+
+// The key has to be kept apart from the encrypted secrets, otherwise anyone who can read the secrets file can also
+// read the key that decrypts it.
+func checkKeyFile(storeDir string, keyFile string) error {
+	if keyFile == "" {
+		return errors.New("the secrets file KeyPath must be set to a key file outside of the secrets directory")
+	}
+
+	dir, err := filepath.Abs(storeDir)
+	if err != nil {
+		return errors.New(fmt.Sprintf("unable to resolve secrets directory %v, error: %v", storeDir, err))
+	}
+	key, err := filepath.Abs(keyFile)
+	if err != nil {
+		return errors.New(fmt.Sprintf("unable to resolve secrets key file %v, error: %v", keyFile, err))
+	}
+
+	if rel, err := filepath.Rel(dir, key); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return errors.New(fmt.Sprintf("the secrets key file %v must not be within the secrets directory %v", keyFile, storeDir))
+	}
+	return nil
+}
+
+// Read the secrets file into memory. The file is only read once, after that it is only written by this plugin.
+func (fs *AgbotFileSecrets) Login() error {
+
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	if fs.store != nil {
+		return nil
+	}
+
+	if store, err := readStore(fs.storeFile, fs.key); err != nil {
+		return errors.New(fmt.Sprintf("agbot unable to load secrets, error: %v", err))
+	} else {
+		fs.store = store
+		fs.touch()
+	}
+
+	glog.V(3).Infof(filePluginLogString(fmt.Sprintf("loaded secrets from %v", fs.storeFile)))
+
+	return nil
+}
+
+// There are no credentials to renew, but the file is checked to make sure it is still usable.
+func (fs *AgbotFileSecrets) Renew() error {
+
+	if _, err := os.Stat(fs.storeFile); err != nil && !os.IsNotExist(err) {
+		return errors.New(fmt.Sprintf("unable to access secrets file %v, error: %v", fs.storeFile, err))
+	}
+	return nil
+}
+
+func (fs *AgbotFileSecrets) IsReady() bool {
+	fs.lock.RLock()
+	defer fs.lock.RUnlock()
+	return fs.store != nil
+}
+
+func (fs *AgbotFileSecrets) Close() {
+	glog.V(2).Infof("Closed file secrets implementation")
+}
+
+func (fs *AgbotFileSecrets) GetLastVaultStatus() uint64 {
+	return atomic.LoadUint64(&fs.lastInteraction)
+}
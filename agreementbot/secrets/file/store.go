@@ -0,0 +1,227 @@
+package file
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/open-horizon/anax/agreementbot/secrets"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+)
+
+// The secrets are kept in memory and written to a single file whenever they change. The file holds the JSON serialization
+// of the store, encrypted with AES-256-GCM. The first bytes of the file are the nonce used to encrypt it. A new nonce is
+// generated every time the file is written.
+
+const KEY_SIZE = 32
+
+// The number of versions of each secret that are retained, including the current version.
+const MAX_SECRET_VERSIONS = 10
+
+// A single secret and the metadata that the agbot needs to detect that it has changed.
+type storedSecret struct {
+	Details        secrets.SecretDetails `json:"details"`
+	CreationTime   int64                 `json:"created_time"`
+	UpdateTime     int64                 `json:"updated_time"`
+	CurrentVersion int                   `json:"current_version"`
+	Versions       []storedVersion       `json:"versions"` // The retained versions, oldest first. The last one is the current version.
+}
+
+type storedVersion struct {
+	Version      int                   `json:"version"`
+	Details      secrets.SecretDetails `json:"details"`
+	CreationTime int64                 `json:"created_time"`
+}
+
+// Return a copy of the secret that does not share any storage with the original.
+func (s *storedSecret) copy() *storedSecret {
+	copied := *s
+	copied.Versions = make([]storedVersion, len(s.Versions))
+	copy(copied.Versions, s.Versions)
+	return &copied
+}
+
+// Return the retained version of the secret with the input version number, or nil if it is not retained.
+func (s *storedSecret) version(version int) *storedVersion {
+	for ix := range s.Versions {
+		if s.Versions[ix].Version == version {
+			return &s.Versions[ix]
+		}
+	}
+	return nil
+}
+
+// The secrets in each org, keyed by the full secret name. User level secrets are named user/<user>/<secret>, the same
+// way as they are named in the vault.
+type secretStore struct {
+	Orgs map[string]map[string]*storedSecret `json:"orgs"`
+}
+
+func newSecretStore() *secretStore {
+	return &secretStore{
+		Orgs: make(map[string]map[string]*storedSecret),
+	}
+}
+
+func (s *secretStore) get(org string, name string) *storedSecret {
+	if orgSecrets, ok := s.Orgs[org]; ok {
+		return orgSecrets[name]
+	}
+	return nil
+}
+
+func (s *secretStore) put(org string, name string, details secrets.SecretDetails, now int64) {
+	if _, ok := s.Orgs[org]; !ok {
+		s.Orgs[org] = make(map[string]*storedSecret)
+	}
+	current, ok := s.Orgs[org][name]
+	if !ok {
+		current = &storedSecret{CreationTime: now}
+		s.Orgs[org][name] = current
+	}
+
+	current.Details = details
+	current.UpdateTime = now
+	current.CurrentVersion += 1
+	current.Versions = append(current.Versions, storedVersion{Version: current.CurrentVersion, Details: details, CreationTime: now})
+	if len(current.Versions) > MAX_SECRET_VERSIONS {
+		current.Versions = current.Versions[len(current.Versions)-MAX_SECRET_VERSIONS:]
+	}
+}
+
+// Put back a secret exactly as it was, used when a change could not be written to the file.
+func (s *secretStore) restore(org string, name string, secret *storedSecret) {
+	if _, ok := s.Orgs[org]; !ok {
+		s.Orgs[org] = make(map[string]*storedSecret)
+	}
+	s.Orgs[org][name] = secret
+}
+
+func (s *secretStore) remove(org string, name string) bool {
+	if _, ok := s.Orgs[org][name]; !ok {
+		return false
+	}
+	delete(s.Orgs[org], name)
+	if len(s.Orgs[org]) == 0 {
+		delete(s.Orgs, org)
+	}
+	return true
+}
+
+// Return the names of the secrets in the org that are within the input directory. An empty directory means all the org
+// level secrets, user level secrets are only returned when the directory is within user/.
+func (s *secretStore) list(org string, dir string) []string {
+	names := make([]string, 0)
+	prefix := ""
+	if dir != "" {
+		prefix = strings.TrimSuffix(dir, "/") + "/"
+	}
+	for name := range s.Orgs[org] {
+		if prefix == "" && strings.HasPrefix(name, "user/") {
+			continue
+		} else if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Read the encryption key from the key file. If the key file does not exist, a new key is generated and saved.
+func loadKey(keyFile string) ([]byte, error) {
+
+	if encoded, err := ioutil.ReadFile(keyFile); err != nil && !os.IsNotExist(err) {
+		return nil, errors.New(fmt.Sprintf("unable to read key file %v, error: %v", keyFile, err))
+	} else if err == nil {
+		if key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded))); err != nil {
+			return nil, errors.New(fmt.Sprintf("unable to decode key file %v, error: %v", keyFile, err))
+		} else if len(key) != KEY_SIZE {
+			return nil, errors.New(fmt.Sprintf("key in %v must be %v bytes, is %v bytes", keyFile, KEY_SIZE, len(key)))
+		} else {
+			return key, nil
+		}
+	}
+
+	key := make([]byte, KEY_SIZE)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to generate key, error: %v", err))
+	} else if err := os.MkdirAll(path.Dir(keyFile), 0700); err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to create key directory for %v, error: %v", keyFile, err))
+	} else if err := ioutil.WriteFile(keyFile, []byte(base64.StdEncoding.EncodeToString(key)), 0600); err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to write key file %v, error: %v", keyFile, err))
+	}
+	return key, nil
+}
+
+func newCipher(key []byte) (cipher.AEAD, error) {
+	if block, err := aes.NewCipher(key); err != nil {
+		return nil, err
+	} else {
+		return cipher.NewGCM(block)
+	}
+}
+
+// Read and decrypt the store file. A missing file is an empty store.
+func readStore(storeFile string, key []byte) (*secretStore, error) {
+
+	store := newSecretStore()
+
+	data, err := ioutil.ReadFile(storeFile)
+	if os.IsNotExist(err) {
+		return store, nil
+	} else if err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to read %v, error: %v", storeFile, err))
+	}
+
+	aead, err := newCipher(key)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to create cipher, error: %v", err))
+	} else if len(data) < aead.NonceSize() {
+		return nil, errors.New(fmt.Sprintf("%v is too short to be a secrets file", storeFile))
+	}
+
+	if plain, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil); err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to decrypt %v, error: %v", storeFile, err))
+	} else if err := json.Unmarshal(plain, store); err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to demarshal %v, error: %v", storeFile, err))
+	}
+
+	if store.Orgs == nil {
+		store.Orgs = make(map[string]map[string]*storedSecret)
+	}
+	return store, nil
+}
+
+// Encrypt and write the store file. The file is written to a temporary file and then renamed, so that a failure part way
+// through never leaves a corrupted store behind.
+func writeStore(storeFile string, key []byte, store *secretStore) error {
+
+	aead, err := newCipher(key)
+	if err != nil {
+		return errors.New(fmt.Sprintf("unable to create cipher, error: %v", err))
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return errors.New(fmt.Sprintf("unable to generate nonce, error: %v", err))
+	}
+
+	plain, err := json.Marshal(store)
+	if err != nil {
+		return errors.New(fmt.Sprintf("unable to marshal secrets, error: %v", err))
+	}
+
+	tmpFile := storeFile + ".tmp"
+	if err := ioutil.WriteFile(tmpFile, aead.Seal(nonce, nonce, plain, nil), 0600); err != nil {
+		return errors.New(fmt.Sprintf("unable to write %v, error: %v", tmpFile, err))
+	} else if err := os.Rename(tmpFile, storeFile); err != nil {
+		return errors.New(fmt.Sprintf("unable to replace %v, error: %v", storeFile, err))
+	}
+	return nil
+}
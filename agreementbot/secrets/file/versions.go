@@ -0,0 +1,71 @@
+package file
+
+import (
+	"fmt"
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/agreementbot/secrets"
+	"net/http"
+)
+
+// Each secret retains its last MAX_SECRET_VERSIONS versions. Deleting a secret removes all of its versions.
+
+// Return the retained versions of a secret, oldest first.
+func (fs *AgbotFileSecrets) ListSecretVersions(user, token, org, secretUser, secretName string) ([]secrets.SecretVersion, error) {
+
+	glog.V(3).Infof(filePluginLogString(fmt.Sprintf("list versions of secret %s in org %s as user %s", secretName, org, secretUser)))
+
+	if err := fs.authorize(user, token, org, fullSecretName(secretUser, secretName), READ); err != nil {
+		return nil, err
+	}
+
+	stored, err := fs.findSecret(org, secretUser, secretName, http.MethodGet)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]secrets.SecretVersion, 0, len(stored.Versions))
+	for _, v := range stored.Versions {
+		versions = append(versions, secrets.SecretVersion{
+			Version:      v.Version,
+			CreationTime: v.CreationTime,
+			Current:      v.Version == stored.CurrentVersion,
+		})
+	}
+	return versions, nil
+}
+
+// Make a prior version of a secret the current version, by saving its details as a new version.
+func (fs *AgbotFileSecrets) RollbackSecret(user, token, org, secretUser, secretName string, version int) error {
+
+	glog.V(3).Infof(filePluginLogString(fmt.Sprintf("rollback secret %s in org %s as user %s to version %v", secretName, org, secretUser, version)))
+
+	name := fullSecretName(secretUser, secretName)
+	if err := fs.authorize(user, token, org, name, WRITE); err != nil {
+		return err
+	}
+
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	store, err := fs.getStore()
+	if err != nil {
+		return err
+	}
+
+	stored := store.get(org, name)
+	if stored == nil {
+		return &secrets.NoSecretFound{Response: map[string][]string{"errors": {}}, SecretPath: fmt.Sprintf("%v/%v", org, name)}
+	}
+
+	v := stored.version(version)
+	if v == nil {
+		return &secrets.NoSecretFound{Response: map[string][]string{"errors": {fmt.Sprintf("version %v is not retained", version)}}, SecretPath: fmt.Sprintf("%v/%v", org, name)}
+	}
+
+	if err := fs.saveSecret(store, org, name, v.Details); err != nil {
+		return err
+	}
+
+	glog.V(3).Infof(filePluginLogString(fmt.Sprintf("done rolling back %s to version %v.", name, version)))
+	return nil
+}
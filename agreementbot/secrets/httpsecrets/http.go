@@ -0,0 +1,149 @@
+package httpsecrets
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/agreementbot/secrets"
+	"github.com/open-horizon/anax/config"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Retry intervals when connecting to the secrets service
+const EX_MAX_RETRY = 10
+const EX_RETRY_INTERVAL = 2
+
+type ListSecretsResponse struct {
+	Secrets []string `json:"secrets"`
+}
+
+type ListVersionsResponse struct {
+	Versions []secrets.SecretVersion `json:"versions"`
+}
+
+// Create an https connection, using a supplied SSL CA certificate.
+func newHTTPClient(cfg *config.HorizonConfig) (*http.Client, error) {
+
+	var tlsConf tls.Config
+
+	if _, err := os.Stat(cfg.GetSecretsHTTPCertPath()); err == nil {
+
+		caBytes, err := ioutil.ReadFile(cfg.GetSecretsHTTPCertPath())
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("unable to read %v, error %v", cfg.GetSecretsHTTPCertPath(), err))
+		}
+
+		// Do not allow negotiation to previous versions of TLS.
+		tlsConf.MinVersion = tls.VersionTLS12
+
+		certPool := x509.NewCertPool()
+		certPool.AppendCertsFromPEM(caBytes)
+		tlsConf.RootCAs = certPool
+	}
+
+	return &http.Client{
+		Timeout: time.Second * time.Duration(20),
+		Transport: &http.Transport{
+			Dial: (&net.Dialer{
+				Timeout:   60 * time.Second,
+				KeepAlive: 120 * time.Second,
+			}).Dial,
+			ResponseHeaderTimeout: 20 * time.Second,
+			ExpectContinueTimeout: 8 * time.Second,
+			MaxIdleConns:          20,
+			MaxConnsPerHost:       20,
+			MaxIdleConnsPerHost:   20,
+			IdleConnTimeout:       120 * time.Second,
+			TLSClientConfig:       &tlsConf,
+		},
+	}, nil
+
+}
+
+// Common function to invoke the secrets service with builtin retry logic for transport errors.
+func (hs *AgbotHTTPSecrets) invokeWithRetry(user string, token string, url string, method string, body interface{}) (*http.Response, error) {
+	var resp *http.Response
+	var err error
+	for currRetry := EX_MAX_RETRY; currRetry > 0; currRetry-- {
+		resp, err = hs.invoke(user, token, url, method, body)
+
+		if !isTransportError(resp, err) {
+			return resp, err
+		}
+
+		if resp != nil && resp.Body != nil {
+			resp.Body.Close()
+		}
+		glog.Warningf(httpPluginLogString(fmt.Sprintf("received transport error calling %v %v, retry...", method, url)))
+		time.Sleep(time.Duration(EX_RETRY_INTERVAL) * time.Second)
+	}
+
+	return nil, errors.New(fmt.Sprintf("unable to invoke %v %v in the secrets service, exceeded %v retries, last error: %v", method, url, EX_MAX_RETRY, err))
+}
+
+// Common function to invoke the secrets service. The caller's exchange credentials are passed through using basic
+// authentication, so that the secrets service can authorize the request.
+func (hs *AgbotHTTPSecrets) invoke(user string, token string, url string, method string, body interface{}) (*http.Response, error) {
+
+	apiMsg := fmt.Sprintf("%v %v", method, url)
+
+	var requestBody io.Reader
+	if body != nil {
+		jsonBytes, err := json.Marshal(body)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("failed to marshal body for %s, error: %v", apiMsg, err))
+		}
+		requestBody = bytes.NewBuffer(jsonBytes)
+	}
+
+	req, err := http.NewRequest(method, url, requestBody)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to create HTTP request for %v, error %v", apiMsg, err))
+	}
+
+	req.SetBasicAuth(user, token)
+	if body != nil {
+		req.Header.Add("Content-Type", "application/json")
+	}
+
+	resp, err := hs.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to send HTTP request for %v, error %v", apiMsg, err))
+	} else if resp.StatusCode < 500 {
+		hs.touch()
+	}
+	return resp, nil
+}
+
+// Return true if an invocation resulted in an error that is retryable.
+func isTransportError(pResp *http.Response, err error) bool {
+	if err != nil {
+		if strings.Contains(err.Error(), ": EOF") {
+			return true
+		}
+
+		l_error_string := strings.ToLower(err.Error())
+		if strings.Contains(l_error_string, "time") && strings.Contains(l_error_string, "out") {
+			return true
+		} else if strings.Contains(l_error_string, "connection") && (strings.Contains(l_error_string, "refused") || strings.Contains(l_error_string, "reset")) {
+			return true
+		}
+	}
+
+	if pResp != nil {
+		if pResp.StatusCode == http.StatusBadGateway || pResp.StatusCode == http.StatusGatewayTimeout || pResp.StatusCode == http.StatusServiceUnavailable {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,242 @@
+package httpsecrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/agreementbot/secrets"
+	"github.com/open-horizon/anax/config"
+	"io/ioutil"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// This function registers an uninitialized agbot secrets implementation with the secrets plugin registry. The plugin's Initialize
+// method is used to configure the object.
+func init() {
+	secrets.Register("http", new(AgbotHTTPSecrets))
+}
+
+// This plugin delegates secret storage and access control to a remote service that implements the contract described
+// in docs/agbot_secrets_providers.md. Secret names are passed to the service as they are used by the agbot, so user
+// level secrets are named user/<user>/<secret>.
+type AgbotHTTPSecrets struct {
+	httpClient      *http.Client // A cached http client to use for invoking the secrets service
+	cfg             *config.HorizonConfig
+	ready           int32
+	lastInteraction uint64
+}
+
+func (hs *AgbotHTTPSecrets) String() string {
+	return fmt.Sprintf("URL: %v", hs.cfg.GetSecretsHTTPURL())
+}
+
+func (hs *AgbotHTTPSecrets) touch() {
+	atomic.StoreUint64(&hs.lastInteraction, uint64(time.Now().Unix()))
+}
+
+func (hs *AgbotHTTPSecrets) secretURL(org, name string) string {
+	return fmt.Sprintf("%s/orgs/%s/secrets/%s", hs.cfg.GetSecretsHTTPURL(), org, name)
+}
+
+func (hs *AgbotHTTPSecrets) metadataURL(org, name string) string {
+	return fmt.Sprintf("%s/orgs/%s/metadata/%s", hs.cfg.GetSecretsHTTPURL(), org, name)
+}
+
+// Check existence of an org level secret.
+func (hs *AgbotHTTPSecrets) ListOrgSecret(user, token, org, path string) error {
+	glog.V(3).Infof(httpPluginLogString(fmt.Sprintf("list secret %v in org %v", path, org)))
+	_, err := hs.call(user, token, hs.metadataURL(org, path), http.MethodGet, nil)
+	return err
+}
+
+// Check existence of a user level secret.
+func (hs *AgbotHTTPSecrets) ListOrgUserSecret(user, token, org, path string) error {
+	glog.V(3).Infof(httpPluginLogString(fmt.Sprintf("list secret %v in org %v as user %v", path, org, user)))
+	_, err := hs.call(user, token, hs.metadataURL(org, path), http.MethodGet, nil)
+	return err
+}
+
+// List all org level secrets at a specified path.
+func (hs *AgbotHTTPSecrets) ListOrgSecrets(user, token, org, path string) ([]string, error) {
+	glog.V(3).Infof(httpPluginLogString(fmt.Sprintf("list secrets in %v", org)))
+	return hs.listSecrets(user, token, org, path)
+}
+
+// List all user level secrets at a specified path. The returned names do not include the user/<user> prefix.
+func (hs *AgbotHTTPSecrets) ListOrgUserSecrets(user, token, org, path string) ([]string, error) {
+	glog.V(3).Infof(httpPluginLogString(fmt.Sprintf("listing secrets for user %v in %v", user, org)))
+
+	names, err := hs.listSecrets(user, token, org, path)
+	if err != nil {
+		return nil, err
+	}
+
+	secretList := make([]string, 0, len(names))
+	for _, name := range names {
+		secretList = append(secretList, strings.TrimPrefix(name, path+"/"))
+	}
+	return secretList, nil
+}
+
+func (hs *AgbotHTTPSecrets) listSecrets(user, token, org, path string) ([]string, error) {
+
+	url := fmt.Sprintf("%s/orgs/%s/secrets?path=%s", hs.cfg.GetSecretsHTTPURL(), org, neturl.QueryEscape(path))
+	respBytes, err := hs.call(user, token, url, http.MethodGet, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	respMsg := ListSecretsResponse{}
+	if err := json.Unmarshal(respBytes, &respMsg); err != nil {
+		return nil, &secrets.InvalidResponse{ParseError: err, Response: respBytes, HttpMethod: http.MethodGet, SecretPath: url}
+	} else if len(respMsg.Secrets) == 0 {
+		return nil, &secrets.NoSecretFound{Response: map[string][]string{}, SecretPath: url}
+	}
+	return respMsg.Secrets, nil
+}
+
+// Create or update an org level secret.
+func (hs *AgbotHTTPSecrets) CreateOrgSecret(user, token, org, path string, data secrets.SecretDetails) error {
+	glog.V(3).Infof(httpPluginLogString(fmt.Sprintf("creating secret %s in org %s", path, org)))
+	_, err := hs.call(user, token, hs.secretURL(org, path), http.MethodPut, &data)
+	return err
+}
+
+// Create or update a user level secret.
+func (hs *AgbotHTTPSecrets) CreateOrgUserSecret(user, token, org, path string, data secrets.SecretDetails) error {
+	glog.V(3).Infof(httpPluginLogString(fmt.Sprintf("creating secret %s in org %s", path, org)))
+	_, err := hs.call(user, token, hs.secretURL(org, path), http.MethodPut, &data)
+	return err
+}
+
+// Delete an org level secret.
+func (hs *AgbotHTTPSecrets) DeleteOrgSecret(user, token, org, path string) error {
+	glog.V(3).Infof(httpPluginLogString(fmt.Sprintf("delete secret %s in org %s", path, org)))
+	_, err := hs.call(user, token, hs.secretURL(org, path), http.MethodDelete, nil)
+	return err
+}
+
+// Delete a user level secret.
+func (hs *AgbotHTTPSecrets) DeleteOrgUserSecret(user, token, org, path string) error {
+	glog.V(3).Infof(httpPluginLogString(fmt.Sprintf("delete secret %s in org %s", path, org)))
+	_, err := hs.call(user, token, hs.secretURL(org, path), http.MethodDelete, nil)
+	return err
+}
+
+func (hs *AgbotHTTPSecrets) GetSecretDetails(user, token, org, secretUser, secretName string) (secrets.SecretDetails, error) {
+
+	glog.V(3).Infof(httpPluginLogString(fmt.Sprintf("extract secret details for %s in org %s as user %s", secretName, org, secretUser)))
+
+	res := secrets.SecretDetails{}
+	if err := checkNames(org, secretName); err != nil {
+		return res, err
+	}
+
+	url := hs.secretURL(org, fullSecretName(secretUser, secretName))
+	respBytes, err := hs.call(user, token, url, http.MethodGet, nil)
+	if err != nil {
+		return res, err
+	} else if err := json.Unmarshal(respBytes, &res); err != nil {
+		return res, &secrets.InvalidResponse{ParseError: err, Response: []byte("********"), HttpMethod: http.MethodGet, SecretPath: url}
+	}
+
+	glog.V(3).Infof(httpPluginLogString("done extracting secret details"))
+	return res, nil
+}
+
+// Retrieve the metadata for a secret, using the agbot's credentials.
+func (hs *AgbotHTTPSecrets) GetSecretMetadata(secretOrg, secretUser, secretName string) (secrets.SecretMetadata, error) {
+
+	glog.V(3).Infof(httpPluginLogString(fmt.Sprintf("extract secret metadata for %s in org %s as user %s", secretName, secretOrg, secretUser)))
+
+	res := secrets.SecretMetadata{}
+	if err := checkNames(secretOrg, secretName); err != nil {
+		return res, err
+	}
+
+	url := hs.metadataURL(secretOrg, fullSecretName(secretUser, secretName))
+	respBytes, err := hs.call(hs.cfg.AgreementBot.ExchangeId, hs.cfg.AgreementBot.ExchangeToken, url, http.MethodGet, nil)
+	if err != nil {
+		return res, err
+	} else if err := json.Unmarshal(respBytes, &res); err != nil {
+		return res, &secrets.InvalidResponse{ParseError: err, Response: respBytes, HttpMethod: http.MethodGet, SecretPath: url}
+	}
+
+	glog.V(5).Infof(httpPluginLogString(fmt.Sprintf("Metadata: %v", res)))
+	return res, nil
+}
+
+// Invoke the secrets service and convert error responses into the errors defined by the secrets package. The response
+// body of a successful call is returned.
+func (hs *AgbotHTTPSecrets) call(user, token, url, method string, data *secrets.SecretDetails) ([]byte, error) {
+
+	var body interface{}
+	if data != nil {
+		body = data
+	}
+
+	resp, err := hs.invokeWithRetry(user, token, url, method, body)
+	if resp != nil && resp.Body != nil {
+		defer resp.Body.Close()
+	}
+	if err != nil {
+		return nil, &secrets.SecretsProviderUnavailable{ProviderError: err}
+	}
+
+	respBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &secrets.InvalidResponse{ReadError: err, HttpMethod: method, SecretPath: url}
+	}
+
+	httpCode := resp.StatusCode
+	glog.V(5).Infof(httpPluginLogString(fmt.Sprintf("HTTP: %v, %v %v", httpCode, method, url)))
+	if httpCode == http.StatusOK || httpCode == http.StatusCreated || httpCode == http.StatusNoContent {
+		return respBytes, nil
+	}
+
+	// The error response body is optional. If it is present, it should be {"errors": ["..."]}.
+	errResponse := map[string][]string{}
+	if len(respBytes) != 0 {
+		if perr := json.Unmarshal(respBytes, &errResponse); perr != nil {
+			return nil, &secrets.InvalidResponse{ParseError: perr, Response: respBytes, HttpMethod: method, SecretPath: url}
+		}
+	}
+
+	switch httpCode {
+	case http.StatusNotFound:
+		return nil, &secrets.NoSecretFound{Response: errResponse, SecretPath: url}
+	case http.StatusUnauthorized:
+		return nil, &secrets.Unauthenticated{LoginError: fmt.Errorf("%v", secrets.RespToString(errResponse)), ExchangeUser: user}
+	case http.StatusForbidden:
+		return nil, &secrets.PermissionDenied{Response: errResponse, HttpMethod: method, SecretPath: url, ExchangeUser: user}
+	case http.StatusBadRequest, http.StatusMethodNotAllowed:
+		return nil, &secrets.BadRequest{ResponseCode: httpCode, Response: errResponse, HttpMethod: method, SecretPath: url, RequestBody: data}
+	default:
+		return nil, &secrets.Unknown{Response: errResponse, ResponseCode: httpCode, HttpMethod: method, SecretPath: url}
+	}
+}
+
+func checkNames(org, secretName string) error {
+	if org == "" {
+		return &secrets.BadRequest{Response: map[string][]string{"errors": {"Organization name must not be an empty string"}}, HttpMethod: http.MethodGet}
+	} else if secretName == "" {
+		return &secrets.BadRequest{Response: map[string][]string{"errors": {"Secret name must not be an empty string"}}, HttpMethod: http.MethodGet}
+	}
+	return nil
+}
+
+func fullSecretName(secretUser, secretName string) string {
+	if secretUser != "" {
+		return fmt.Sprintf("user/%s/%s", secretUser, secretName)
+	}
+	return secretName
+}
+
+// Log string prefix api
+var httpPluginLogString = func(v interface{}) string {
+	return fmt.Sprintf("HTTP Secrets Plugin: %v", v)
+}
@@ -0,0 +1,177 @@
+//go:build unit
+// +build unit
+
+package httpsecrets
+
+import (
+	"github.com/open-horizon/anax/agreementbot/secrets"
+	"github.com/open-horizon/anax/config"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_call_errors(t *testing.T) {
+
+	// The secrets service returns the status code and body for each secret name.
+	responses := map[string]struct {
+		code int
+		body string
+	}{
+		"found":      {http.StatusOK, `{"key":"user","value":"password"}`},
+		"missing":    {http.StatusNotFound, `{"errors":["secret not found"]}`},
+		"badlogin":   {http.StatusUnauthorized, `{"errors":["invalid credentials"]}`},
+		"denied":     {http.StatusForbidden, `{"errors":["permission denied"]}`},
+		"badrequest": {http.StatusBadRequest, ""},
+		"broken":     {http.StatusInternalServerError, `{"errors":["internal error"]}`},
+		"badjson":    {http.StatusOK, `{"key":`},
+		"baderror":   {http.StatusNotFound, "not json"},
+	}
+
+	hs := newHTTPSecrets(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, token, ok := r.BasicAuth(); !ok || user != "myorg/user1" || token != "pw" {
+			t.Errorf("Expected the user credentials to be passed to the secrets service, got %v %v", user, token)
+		}
+		resp, ok := responses[r.URL.Path[len("/orgs/myorg/secrets/"):]]
+		if !ok {
+			t.Errorf("Unexpected request %v", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(resp.code)
+		w.Write([]byte(resp.body))
+	}))
+
+	if d, err := hs.GetSecretDetails("myorg/user1", "pw", "myorg", "", "found"); err != nil {
+		t.Errorf("Error getting secret: %v", err)
+	} else if d.Key != "user" || d.Value != "password" {
+		t.Errorf("Wrong secret details %v", d)
+	}
+
+	for name, check := range map[string]func(error) bool{
+		"missing":    func(err error) bool { _, ok := err.(*secrets.NoSecretFound); return ok },
+		"badlogin":   func(err error) bool { _, ok := err.(*secrets.Unauthenticated); return ok },
+		"denied":     func(err error) bool { _, ok := err.(*secrets.PermissionDenied); return ok },
+		"badrequest": func(err error) bool { _, ok := err.(*secrets.BadRequest); return ok },
+		"broken": func(err error) bool {
+			e, ok := err.(*secrets.Unknown)
+			return ok && e.ResponseCode == http.StatusInternalServerError
+		},
+		"badjson":  func(err error) bool { e, ok := err.(*secrets.InvalidResponse); return ok && e.ParseError != nil },
+		"baderror": func(err error) bool { e, ok := err.(*secrets.InvalidResponse); return ok && e.ParseError != nil },
+	} {
+		if _, err := hs.GetSecretDetails("myorg/user1", "pw", "myorg", "", name); err == nil {
+			t.Errorf("Expected an error getting secret %v", name)
+		} else if !check(err) {
+			t.Errorf("Wrong error type %T getting secret %v: %v", err, name, err)
+		}
+	}
+
+	// The secret values are not included in a parse error.
+	if _, err := hs.GetSecretDetails("myorg/user1", "pw", "myorg", "", "badjson"); err == nil {
+		t.Errorf("Expected an error getting secret badjson")
+	} else if e, ok := err.(*secrets.InvalidResponse); ok && string(e.Response) != "********" {
+		t.Errorf("The secret details should be obscured in the error but got %v", string(e.Response))
+	}
+
+	// The names are checked before the service is called.
+	if _, err := hs.GetSecretDetails("myorg/user1", "pw", "", "", "found"); err == nil {
+		t.Errorf("Expected an error for an empty org")
+	} else if _, ok := err.(*secrets.BadRequest); !ok {
+		t.Errorf("Expected a BadRequest error but got %T: %v", err, err)
+	} else if _, err := hs.GetSecretMetadata("myorg", "", ""); err == nil {
+		t.Errorf("Expected an error for an empty secret name")
+	} else if _, ok := err.(*secrets.BadRequest); !ok {
+		t.Errorf("Expected a BadRequest error but got %T: %v", err, err)
+	}
+}
+
+func Test_listSecrets_errors(t *testing.T) {
+
+	hs := newHTTPSecrets(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("path") {
+		case "user/user1":
+			w.Write([]byte(`{"secrets":["user/user1/sec1","user/user1/dir/sec2"]}`))
+		case "empty":
+			w.Write([]byte(`{"secrets":[]}`))
+		default:
+			w.Write([]byte(`["sec1"]`))
+		}
+	}))
+
+	if names, err := hs.ListOrgUserSecrets("myorg/user1", "pw", "myorg", "user/user1"); err != nil {
+		t.Errorf("Error listing secrets: %v", err)
+	} else if len(names) != 2 || names[0] != "sec1" || names[1] != "dir/sec2" {
+		t.Errorf("Expected the names without the user prefix but got %v", names)
+	}
+
+	if _, err := hs.ListOrgSecrets("myorg/user1", "pw", "myorg", "empty"); err == nil {
+		t.Errorf("Expected an error listing an empty directory")
+	} else if _, ok := err.(*secrets.NoSecretFound); !ok {
+		t.Errorf("Expected a NoSecretFound error but got %T: %v", err, err)
+	}
+
+	if _, err := hs.ListOrgSecrets("myorg/user1", "pw", "myorg", ""); err == nil {
+		t.Errorf("Expected an error for a malformed list response")
+	} else if _, ok := err.(*secrets.InvalidResponse); !ok {
+		t.Errorf("Expected an InvalidResponse error but got %T: %v", err, err)
+	}
+}
+
+func Test_Login_errors(t *testing.T) {
+
+	healthy := true
+	hs := newHTTPSecrets(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" {
+			t.Errorf("Unexpected request %v", r.URL.Path)
+		} else if user, token, _ := r.BasicAuth(); user != "myorg/agbot" || token != "agbotpw" {
+			w.WriteHeader(http.StatusUnauthorized)
+		} else if !healthy {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+
+	if err := hs.Login(); err != nil {
+		t.Errorf("Error logging in: %v", err)
+	} else if !hs.IsReady() {
+		t.Errorf("The plugin should be ready after login")
+	} else if hs.GetLastVaultStatus() == 0 {
+		t.Errorf("The last interaction with the secrets service should have been recorded")
+	}
+
+	// The plugin is not ready once the service stops responding.
+	healthy = false
+	if err := hs.Renew(); err == nil {
+		t.Errorf("Expected an error renewing with an unhealthy secrets service")
+	} else if hs.IsReady() {
+		t.Errorf("The plugin should not be ready")
+	}
+
+	healthy = true
+	hs.cfg.AgreementBot.ExchangeToken = "wrong"
+	if err := hs.Login(); err == nil {
+		t.Errorf("Expected an error logging in with the wrong credentials")
+	} else if hs.IsReady() {
+		t.Errorf("The plugin should not be ready")
+	}
+}
+
+// Create an http secrets plugin that calls the handler as the secrets service.
+func newHTTPSecrets(t *testing.T, handler http.Handler) *AgbotHTTPSecrets {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	cfg := &config.HorizonConfig{
+		AgreementBot: config.AGConfig{
+			ExchangeId:    "myorg/agbot",
+			ExchangeToken: "agbotpw",
+			SecretsHTTP:   config.SecretHTTPConfig{URL: server.URL + "/"},
+		},
+	}
+
+	hs := new(AgbotHTTPSecrets)
+	if err := hs.Initialize(cfg); err != nil {
+		t.Fatalf("Error initializing the plugin: %v", err)
+	}
+	return hs
+}
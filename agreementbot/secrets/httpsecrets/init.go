@@ -0,0 +1,65 @@
+package httpsecrets
+
+import (
+	"errors"
+	"fmt"
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/config"
+	"net/http"
+	"sync/atomic"
+)
+
+// This function is called by the anax main to allow the plugin a chance to initialize itself. It does not contact the
+// secrets service, that is done by the Login function.
+func (hs *AgbotHTTPSecrets) Initialize(cfg *config.HorizonConfig) (err error) {
+
+	glog.V(1).Infof(httpPluginLogString(fmt.Sprintf("Initializing %v as the secrets plugin.", cfg.GetSecretsHTTPURL())))
+
+	hs.cfg = cfg
+	hs.httpClient, err = newHTTPClient(cfg)
+
+	return err
+}
+
+// Verify that the secrets service is up and accepts the agbot's credentials.
+func (hs *AgbotHTTPSecrets) Login() error {
+
+	url := fmt.Sprintf("%s/health", hs.cfg.GetSecretsHTTPURL())
+
+	resp, err := hs.invokeWithRetry(hs.cfg.AgreementBot.ExchangeId, hs.cfg.AgreementBot.ExchangeToken, url, http.MethodGet, nil)
+	if resp != nil && resp.Body != nil {
+		defer resp.Body.Close()
+	}
+	if err != nil {
+		return errors.New(fmt.Sprintf("agbot unable to login, error: %v", err))
+	} else if resp.StatusCode != http.StatusOK {
+		return errors.New(fmt.Sprintf("agbot unable to login, HTTP status code: %v", resp.StatusCode))
+	}
+
+	atomic.StoreInt32(&hs.ready, 1)
+	glog.V(3).Infof(httpPluginLogString("logged in to the secrets service."))
+
+	return nil
+}
+
+// The agbot's credentials are sent on every request, so there is nothing to renew. The health check is repeated so
+// that the plugin reports itself as not ready when the service is down.
+func (hs *AgbotHTTPSecrets) Renew() error {
+	if err := hs.Login(); err != nil {
+		atomic.StoreInt32(&hs.ready, 0)
+		return err
+	}
+	return nil
+}
+
+func (hs *AgbotHTTPSecrets) IsReady() bool {
+	return atomic.LoadInt32(&hs.ready) == 1
+}
+
+func (hs *AgbotHTTPSecrets) Close() {
+	glog.V(2).Infof("Closed HTTP secrets implementation")
+}
+
+func (hs *AgbotHTTPSecrets) GetLastVaultStatus() uint64 {
+	return atomic.LoadUint64(&hs.lastInteraction)
+}
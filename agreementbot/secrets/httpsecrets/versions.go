@@ -0,0 +1,57 @@
+package httpsecrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/agreementbot/secrets"
+	"net/http"
+	"sort"
+)
+
+// Return the versions of a secret retained by the secrets service, oldest first.
+func (hs *AgbotHTTPSecrets) ListSecretVersions(user, token, org, secretUser, secretName string) ([]secrets.SecretVersion, error) {
+
+	glog.V(3).Infof(httpPluginLogString(fmt.Sprintf("list versions of secret %s in org %s as user %s", secretName, org, secretUser)))
+
+	if err := checkNames(org, secretName); err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/orgs/%s/versions/%s", hs.cfg.GetSecretsHTTPURL(), org, fullSecretName(secretUser, secretName))
+	respBytes, err := hs.call(user, token, url, http.MethodGet, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	respMsg := ListVersionsResponse{}
+	if err := json.Unmarshal(respBytes, &respMsg); err != nil {
+		return nil, &secrets.InvalidResponse{ParseError: err, Response: respBytes, HttpMethod: http.MethodGet, SecretPath: url}
+	}
+	sort.Slice(respMsg.Versions, func(i, j int) bool { return respMsg.Versions[i].Version < respMsg.Versions[j].Version })
+	return respMsg.Versions, nil
+}
+
+// Read a prior version of a secret and write it back as the newest version.
+func (hs *AgbotHTTPSecrets) RollbackSecret(user, token, org, secretUser, secretName string, version int) error {
+
+	glog.V(3).Infof(httpPluginLogString(fmt.Sprintf("rollback secret %s in org %s as user %s to version %v", secretName, org, secretUser, version)))
+
+	if err := checkNames(org, secretName); err != nil {
+		return err
+	}
+
+	url := hs.secretURL(org, fullSecretName(secretUser, secretName))
+	respBytes, err := hs.call(user, token, fmt.Sprintf("%s?version=%d", url, version), http.MethodGet, nil)
+	if err != nil {
+		return err
+	}
+
+	details := secrets.SecretDetails{}
+	if err := json.Unmarshal(respBytes, &details); err != nil {
+		return &secrets.InvalidResponse{ParseError: err, Response: []byte("********"), HttpMethod: http.MethodGet, SecretPath: url}
+	}
+
+	_, err = hs.call(user, token, url, http.MethodPut, &details)
+	return err
+}
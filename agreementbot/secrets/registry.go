@@ -21,14 +21,23 @@ func Register(name string, as AgbotSecrets) {
 }
 
 // Initialize the underlying Agbot Secrets implementation depending on what is configured. If vault is configured, it is used.
-// If nothing is configured, an error is returned.
+// Otherwise the local encrypted file provider is used if it is configured, and then the generic HTTP provider. If nothing
+// is configured, an error is returned.
 func InitSecrets(cfg *config.HorizonConfig) (AgbotSecrets, error) {
 
 	if cfg.IsVaultConfigured() {
 		secretsObj := SecretsProviders["vault"]
 		return secretsObj, secretsObj.Initialize(cfg)
 
+	} else if cfg.IsSecretsFileConfigured() {
+		secretsObj := SecretsProviders["file"]
+		return secretsObj, secretsObj.Initialize(cfg)
+
+	} else if cfg.IsSecretsHTTPConfigured() {
+		secretsObj := SecretsProviders["http"]
+		return secretsObj, secretsObj.Initialize(cfg)
+
 	}
-	return nil, errors.New(fmt.Sprintf("Vault is not configured correctly."))
+	return nil, errors.New(fmt.Sprintf("none of Vault, the secrets file or the HTTP secrets provider is configured correctly."))
 
 }
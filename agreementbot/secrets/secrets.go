@@ -39,6 +39,15 @@ type AgbotSecrets interface {
 	// "user" argument is the user who is accessing the secret, "secretUser" is the owner of the secret being accessed,
 	// if an org-level secret then this will be empty
 	GetSecretMetadata(secretOrg, secretUser, secretName string) (SecretMetadata, error)
+
+	// This function returns the versions of a secret that are retained by the secret manager, oldest first.
+	// "user" argument is the user who is accessing the secret, "secretUser" is the owner of the secret being accessed,
+	// if an org-level secret then this will be empty
+	ListSecretVersions(user, token, org, secretUser, secretName string) ([]SecretVersion, error)
+
+	// This function makes a prior version of a secret the current version, by writing its details as a new version.
+	// The secret's update time changes, so agreements using the secret receive the restored details.
+	RollbackSecret(user, token, org, secretUser, secretName string, version int) error
 }
 
 type SecretDetails struct {
@@ -59,6 +68,18 @@ func (m SecretMetadata) String() string {
 	return fmt.Sprintf("Created: %v, Updated: %v", m.CreationTime, m.UpdateTime)
 }
 
+type SecretVersion struct {
+	Version      int   `json:"version"`
+	CreationTime int64 `json:"created_time"`
+	DeletionTime int64 `json:"deletion_time,omitempty"` // Non-zero when the version has been deleted.
+	Destroyed    bool  `json:"destroyed"`               // True when the details of the version have been permanently removed.
+	Current      bool  `json:"current"`
+}
+
+func (m SecretVersion) String() string {
+	return fmt.Sprintf("Version: %v, Created: %v, Deleted: %v, Destroyed: %v, Current: %v", m.Version, m.CreationTime, m.DeletionTime, m.Destroyed, m.Current)
+}
+
 type ErrorResponse struct {
 	Msg      string // the error message which shall be logged and added to response body
 	Details  string // optional log message
@@ -82,8 +82,16 @@ type GetSecretResponse struct {
 }
 
 type SecretMetadata struct {
-	CreationTime string `json:"created_time"` // Has format 2018-03-22T02:24:06.945319214Z
-	UpdateTime   string `json:"updated_time"`
+	CreationTime   string                     `json:"created_time"` // Has format 2018-03-22T02:24:06.945319214Z
+	UpdateTime     string                     `json:"updated_time"`
+	CurrentVersion int                        `json:"current_version"`
+	Versions       map[string]VersionMetadata `json:"versions"`
+}
+
+type VersionMetadata struct {
+	CreationTime string `json:"created_time"`
+	DeletionTime string `json:"deletion_time"` // Empty when the version has not been deleted.
+	Destroyed    bool   `json:"destroyed"`
 }
 
 // Create an https connection, using a supplied SSL CA certificate.
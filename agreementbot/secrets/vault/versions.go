@@ -0,0 +1,126 @@
+package vault
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/agreementbot/secrets"
+	"github.com/open-horizon/anax/cutil"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// The openhorizon secrets engine is a KV version 2 engine, so the vault keeps prior versions of each secret. The functions
+// in this file expose that history and allow a prior version to be restored.
+
+// Return the versions of a secret retained by the vault, oldest first.
+func (vs *AgbotVaultSecrets) ListSecretVersions(user, token, org, secretUser, secretName string) ([]secrets.SecretVersion, error) {
+
+	glog.V(3).Infof(vaultPluginLogString(fmt.Sprintf("list versions of secret %s in org %s as user %s", secretName, org, secretUser)))
+
+	url := fmt.Sprintf("%s/v1/openhorizon/metadata/%s/%s", vs.cfg.GetAgbotVaultURL(), org, fullSecretName(secretUser, secretName))
+
+	respBytes, err := vs.readAsUser(user, token, org, url)
+	if err != nil {
+		return nil, err
+	}
+
+	r := ListSecretResponse{}
+	if uerr := json.Unmarshal(respBytes, &r); uerr != nil {
+		return nil, &secrets.InvalidResponse{ParseError: uerr, Response: respBytes, HttpMethod: http.MethodGet, SecretPath: url}
+	}
+
+	versions := make([]secrets.SecretVersion, 0, len(r.Data.Versions))
+	for v, vm := range r.Data.Versions {
+		num, cerr := strconv.Atoi(v)
+		if cerr != nil {
+			return nil, &secrets.InvalidResponse{ParseError: cerr, Response: respBytes, HttpMethod: http.MethodGet, SecretPath: url}
+		}
+		sv := secrets.SecretVersion{
+			Version:      num,
+			CreationTime: cutil.TimeInSeconds(vm.CreationTime, VaultTimeFormat),
+			Destroyed:    vm.Destroyed,
+			Current:      num == r.Data.CurrentVersion,
+		}
+		if vm.DeletionTime != "" {
+			sv.DeletionTime = cutil.TimeInSeconds(vm.DeletionTime, VaultTimeFormat)
+		}
+		versions = append(versions, sv)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Version < versions[j].Version })
+
+	glog.V(3).Infof(vaultPluginLogString(fmt.Sprintf("done listing versions of %s", secretName)))
+	return versions, nil
+}
+
+// Read a prior version of a secret and write it back as the newest version.
+func (vs *AgbotVaultSecrets) RollbackSecret(user, token, org, secretUser, secretName string, version int) error {
+
+	glog.V(3).Infof(vaultPluginLogString(fmt.Sprintf("rollback secret %s in org %s as user %s to version %v", secretName, org, secretUser, version)))
+
+	name := fullSecretName(secretUser, secretName)
+	url := fmt.Sprintf("%s/v1/openhorizon/data/%s/%s", vs.cfg.GetAgbotVaultURL(), org, name)
+
+	respBytes, err := vs.readAsUser(user, token, org, fmt.Sprintf("%s?version=%d", url, version))
+	if err != nil {
+		return err
+	}
+
+	r := GetSecretResponse{}
+	if uerr := json.Unmarshal(respBytes, &r); uerr != nil {
+		return &secrets.InvalidResponse{ParseError: uerr, Response: []byte("********"), HttpMethod: http.MethodGet, SecretPath: url}
+	}
+
+	return vs.createSecret(user, token, org, name, url, r.Data.Data)
+}
+
+// Log in as the user and GET the input URL, converting error responses to the errors defined by the secrets package.
+func (vs *AgbotVaultSecrets) readAsUser(user, token, org, url string) ([]byte, error) {
+
+	userVaultToken, exUser, err := vs.loginUser(user, token, org)
+	if err != nil {
+		return nil, &secrets.Unauthenticated{LoginError: err, ExchangeUser: user}
+	}
+
+	resp, err := vs.invokeVaultWithRetry(userVaultToken, url, http.MethodGet, nil)
+	if resp != nil && resp.Body != nil {
+		defer resp.Body.Close()
+	}
+	if err != nil {
+		return nil, &secrets.SecretsProviderUnavailable{ProviderError: err}
+	}
+
+	respBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &secrets.InvalidResponse{ReadError: err, HttpMethod: http.MethodGet, SecretPath: url}
+	}
+
+	httpCode := resp.StatusCode
+	glog.V(5).Infof(vaultPluginLogString(fmt.Sprintf("HTTP: %v, GET %v", httpCode, url)))
+	if httpCode == http.StatusOK {
+		return respBytes, nil
+	}
+
+	var vaultResponse map[string][]string
+	if perr := json.Unmarshal(respBytes, &vaultResponse); perr != nil {
+		return nil, &secrets.InvalidResponse{ParseError: perr, Response: respBytes, HttpMethod: http.MethodGet, SecretPath: url}
+	}
+
+	if httpCode == http.StatusNotFound {
+		return nil, &secrets.NoSecretFound{Response: vaultResponse, SecretPath: url}
+	} else if httpCode == http.StatusForbidden {
+		return nil, &secrets.PermissionDenied{Response: vaultResponse, HttpMethod: http.MethodGet, SecretPath: url, ExchangeUser: exUser}
+	} else if httpCode == http.StatusBadRequest {
+		return nil, &secrets.BadRequest{ResponseCode: httpCode, Response: vaultResponse, HttpMethod: http.MethodGet, SecretPath: url}
+	}
+	return nil, &secrets.Unknown{Response: vaultResponse, ResponseCode: httpCode, HttpMethod: http.MethodGet, SecretPath: url}
+}
+
+func fullSecretName(secretUser, secretName string) string {
+	if secretUser != "" {
+		return fmt.Sprintf("user/%s/%s", secretUser, secretName)
+	}
+	return secretName
+}
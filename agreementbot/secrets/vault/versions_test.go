@@ -0,0 +1,159 @@
+//go:build unit
+// +build unit
+
+package vault
+
+import (
+	"encoding/json"
+	"github.com/open-horizon/anax/agreementbot/secrets"
+	"github.com/open-horizon/anax/config"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_ListSecretVersions(t *testing.T) {
+
+	vs, _ := newVault(t)
+
+	if versions, err := vs.ListSecretVersions("myorg/user1", "pw", "myorg", "", "sec1"); err != nil {
+		t.Errorf("Error listing versions: %v", err)
+	} else if len(versions) != 3 {
+		t.Errorf("Expected 3 versions but got %v", versions)
+	} else {
+		for ix, v := range versions {
+			if v.Version != ix+1 {
+				t.Errorf("Expected the versions to be sorted but got %v", versions)
+			} else if v.Current != (v.Version == 3) {
+				t.Errorf("Expected only version 3 to be current but got %v", v)
+			} else if v.CreationTime == 0 {
+				t.Errorf("Expected the creation time of version %v to be set", v.Version)
+			} else if (v.DeletionTime != 0) != (v.Version == 1) || v.Destroyed != (v.Version == 1) {
+				t.Errorf("Expected only version 1 to be deleted but got %v", v)
+			}
+		}
+	}
+
+	// User level secrets are read from the user's path in the vault.
+	if versions, err := vs.ListSecretVersions("myorg/user1", "pw", "myorg", "user1", "sec1"); err != nil {
+		t.Errorf("Error listing versions of a user secret: %v", err)
+	} else if len(versions) != 1 || !versions[0].Current {
+		t.Errorf("Expected 1 current version but got %v", versions)
+	}
+
+	if _, err := vs.ListSecretVersions("myorg/user1", "pw", "myorg", "", "missing"); err == nil {
+		t.Errorf("Expected an error listing versions of a missing secret")
+	} else if _, ok := err.(*secrets.NoSecretFound); !ok {
+		t.Errorf("Expected a NoSecretFound error but got %T: %v", err, err)
+	}
+
+	if _, err := vs.ListSecretVersions("myorg/user1", "pw", "myorg", "", "badversion"); err == nil {
+		t.Errorf("Expected an error for a version that is not a number")
+	} else if _, ok := err.(*secrets.InvalidResponse); !ok {
+		t.Errorf("Expected an InvalidResponse error but got %T: %v", err, err)
+	}
+
+	if _, err := vs.ListSecretVersions("myorg/user1", "wrong", "myorg", "", "sec1"); err == nil {
+		t.Errorf("Expected an error listing versions with the wrong credentials")
+	} else if _, ok := err.(*secrets.Unauthenticated); !ok {
+		t.Errorf("Expected an Unauthenticated error but got %T: %v", err, err)
+	}
+}
+
+func Test_RollbackSecret(t *testing.T) {
+
+	vs, written := newVault(t)
+
+	// The details of the prior version are written back as a new version.
+	if err := vs.RollbackSecret("myorg/user1", "pw", "myorg", "", "sec1", 2); err != nil {
+		t.Errorf("Error rolling back secret: %v", err)
+	} else if d, ok := written["myorg/sec1"]; !ok {
+		t.Errorf("Expected the secret to be written but got %v", written)
+	} else if d.Key != "user" || d.Value != "password2" {
+		t.Errorf("Expected the details of version 2 to be written but got %v", d)
+	}
+
+	if err := vs.RollbackSecret("myorg/user1", "pw", "myorg", "", "sec1", 9); err == nil {
+		t.Errorf("Expected an error rolling back to a version that does not exist")
+	} else if _, ok := err.(*secrets.NoSecretFound); !ok {
+		t.Errorf("Expected a NoSecretFound error but got %T: %v", err, err)
+	} else if len(written) != 1 {
+		t.Errorf("Nothing should have been written but got %v", written)
+	}
+
+	if err := vs.RollbackSecret("myorg/user1", "pw", "myorg", "", "denied", 1); err == nil {
+		t.Errorf("Expected an error rolling back a secret the user cannot read")
+	} else if _, ok := err.(*secrets.PermissionDenied); !ok {
+		t.Errorf("Expected a PermissionDenied error but got %T: %v", err, err)
+	}
+}
+
+// Create a vault plugin that uses a mock vault. The mock vault logs in myorg/user1 with the token pw, and keeps 3 versions
+// of the org secret sec1 and 1 version of the user secret user/user1/sec1. The secrets written to the vault are returned
+// in the map, keyed by org and secret name.
+func newVault(t *testing.T) (*AgbotVaultSecrets, map[string]secrets.SecretDetails) {
+
+	written := make(map[string]secrets.SecretDetails)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/auth/openhorizon/login" {
+			var body LoginBody
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Id != "myorg/user1" || body.Token != "pw" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			writeJSON(t, w, http.StatusOK, LoginResponse{Auth: LoginAuthResponse{ClientToken: "usertoken", Metadata: map[string]string{"exchangeUser": body.Id}}})
+			return
+		} else if r.Header.Get("X-Vault-Token") != "usertoken" {
+			t.Errorf("Expected the user's vault token for %v %v", r.Method, r.URL.Path)
+		}
+
+		switch r.Method + " " + r.URL.RequestURI() {
+		case "GET /v1/openhorizon/metadata/myorg/sec1":
+			writeJSON(t, w, http.StatusOK, ListSecretResponse{Data: SecretMetadata{
+				CurrentVersion: 3,
+				Versions: map[string]VersionMetadata{
+					"3": {CreationTime: "2023-03-03T10:00:00.000000000Z"},
+					"1": {CreationTime: "2023-01-01T10:00:00.000000000Z", DeletionTime: "2023-02-01T10:00:00.000000000Z", Destroyed: true},
+					"2": {CreationTime: "2023-02-02T10:00:00.000000000Z"},
+				},
+			}})
+		case "GET /v1/openhorizon/metadata/myorg/user/user1/sec1":
+			writeJSON(t, w, http.StatusOK, ListSecretResponse{Data: SecretMetadata{
+				CurrentVersion: 1,
+				Versions:       map[string]VersionMetadata{"1": {CreationTime: "2023-01-01T10:00:00.000000000Z"}},
+			}})
+		case "GET /v1/openhorizon/metadata/myorg/badversion":
+			writeJSON(t, w, http.StatusOK, ListSecretResponse{Data: SecretMetadata{Versions: map[string]VersionMetadata{"latest": {}}}})
+		case "GET /v1/openhorizon/data/myorg/sec1?version=2":
+			writeJSON(t, w, http.StatusOK, GetSecretResponse{Data: SecretData{Data: secrets.SecretDetails{Key: "user", Value: "password2"}}})
+		case "GET /v1/openhorizon/data/myorg/denied?version=1":
+			writeJSON(t, w, http.StatusForbidden, ErrorResponse{Errors: []string{"permission denied"}})
+		case "POST /v1/openhorizon/data/myorg/sec1":
+			var body SecretCreateRequest
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Errorf("Error decoding the secret: %v", err)
+			}
+			written["myorg/sec1"] = body.Data
+			writeJSON(t, w, http.StatusOK, map[string]string{})
+		default:
+			writeJSON(t, w, http.StatusNotFound, ErrorResponse{Errors: []string{}})
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	vs := new(AgbotVaultSecrets)
+	if err := vs.Initialize(&config.HorizonConfig{AgreementBot: config.AGConfig{Vault: config.VaultConfig{VaultURL: server.URL}}}); err != nil {
+		t.Fatalf("Error initializing the plugin: %v", err)
+	}
+	return vs, written
+}
+
+func writeJSON(t *testing.T, w http.ResponseWriter, code int, body interface{}) {
+	if b, err := json.Marshal(body); err != nil {
+		t.Errorf("Error marshaling the response: %v", err)
+	} else {
+		w.WriteHeader(code)
+		w.Write(b)
+	}
+}
@@ -187,12 +187,16 @@ func (a *SecureAPI) listen() {
 		router.HandleFunc("/deploycheck/policycompatible", a.policy_compatible).Methods("GET", "OPTIONS")
 		router.HandleFunc("/deploycheck/userinputcompatible", a.userinput_compatible).Methods("GET", "OPTIONS")
 		router.HandleFunc("/deploycheck/deploycompatible", a.deploy_compatible).Methods("GET", "OPTIONS")
+		router.HandleFunc("/deploycheck/fleetcompatible", a.fleet_compatible).Methods("GET", "OPTIONS")
 		router.HandleFunc("/deploycheck/secretbindingcompatible", a.secretbinding_compatible).Methods("GET", "OPTIONS")
 		router.HandleFunc("/org/{org}/secrets/user/{user}", a.userSecrets).Methods("LIST", "OPTIONS")
 		router.HandleFunc(`/org/{org}/secrets/user/{user}/{secret:[\w\/\-]+}`, a.userSecret).Methods("GET", "LIST", "PUT", "POST", "DELETE", "OPTIONS")
 		router.HandleFunc("/org/{org}/secrets", a.orgSecrets).Methods("LIST", "OPTIONS")
 		router.HandleFunc(`/org/{org}/secrets/{secret:[\w\/\-]+}`, a.orgSecret).Methods("GET", "LIST", "PUT", "POST", "DELETE", "OPTIONS")
+		router.HandleFunc(`/org/{org}/secretversions/user/{user}/{secret:[\w\/\-]+}`, a.userSecretVersions).Methods("GET", "POST", "OPTIONS")
+		router.HandleFunc(`/org/{org}/secretversions/{secret:[\w\/\-]+}`, a.orgSecretVersions).Methods("GET", "POST", "OPTIONS")
 		router.HandleFunc("/org/{org}/hagroup/{group}/nodemanagement/{node}/{nmpid}", a.haNodeNMPUpdateRequest).Methods("POST", "OPTIONS")
+		router.HandleFunc("/org/{org}/node/{node}/servicelogs", a.nodeServiceLogs).Methods("GET", "OPTIONS")
 
 		apiListen := fmt.Sprintf("%v:%v", apiListenHost, apiListenPort)
 
@@ -788,6 +792,122 @@ func (a *SecureAPI) deploy_compatible(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// This function does the deployment compatibility check of a deployment policy for all the nodes in an organization.
+func (a *SecureAPI) fleet_compatible(w http.ResponseWriter, r *http.Request) {
+
+	switch r.Method {
+	// swagger:operation GET /deploycheck/fleetcompatible fleet_compatible
+	//
+	// Check deployment compatibility for a fleet of nodes.
+	//
+	// This API does the deployment compatibility check of the given deployment policy for each node in the node organization that matches the node filter. It does the policy, user input and secret binding compatibility checks for each node and groups the nodes into compatible and incompatible nodes. For the incompatible nodes, it also counts the number of nodes for each reason.
+	//
+	// ---
+	// consumes:
+	//  - application/json
+	// produces:
+	//  - application/json
+	// parameters:
+	//  - name: checkAll
+	//    in: query
+	//    type: bool
+	//    required: false
+	//    description: "Return the compatibility check result for all the service versions referenced in the deployment policy."
+	//  - name: node_org
+	//    in: body
+	//    type: string
+	//    required: false
+	//    description: "The organization of the nodes. It defaults to the organization of the deployment policy."
+	//  - name: node_filter
+	//    in: body
+	//    required: false
+	//    description: "The filter that selects the nodes to check. The node_id attribute is a shell pattern that is matched against the node id without the organization. The node_type and node_arch attributes must match exactly."
+	//    schema:
+	//     "$ref": "#/definitions/NodeFilter"
+	//  - name: business_policy_id
+	//    in: body
+	//    type: string
+	//    required: false
+	//    description: "The exchange id of the deployment policy. Mutually exclusive with business_policy."
+	//  - name: business_policy
+	//    in: body
+	//    required: false
+	//    description: "The defintion of the deployment policy that will be put in the exchange. Mutually exclusive with business_policy_id."
+	//    schema:
+	//     "$ref": "#/definitions/BusinessPolicy"
+	//  - name: service_policy
+	//    in: body
+	//    required: false
+	//    description: "The service policy that will be put in the exchange. They are for the top level service referenced in the deployment policy. If omitted, the service policy will be retrieved from the exchange."
+	//    schema:
+	//     "$ref": "#/definitions/ExternalPolicy"
+	//  - name: service
+	//    in: body
+	//    required: false
+	//    description: "An array of the top level services that will be put in the exchange. They are refrenced in the deployment policy. If omitted, the services will be retrieved from the exchange."
+	//    schema:
+	//     "$ref": "#/definitions/ServiceFile"
+	// responses:
+	//  '200':
+	//    description: "Success"
+	//    schema:
+	//     type: compcheck.FleetCheckOutput
+	//     "$ref": "#/definitions/FleetCheckOutput"
+	//  '400':
+	//    description: "Failure - No input found"
+	//    schema:
+	//     type: string
+	//  '401':
+	//    description: "Failure - Failed to authenticate"
+	//    schema:
+	//     type: string
+	//  '500':
+	//    description: "Failure - Error"
+	//    schema:
+	//      type: string
+	case "GET":
+		glog.V(5).Infof(APIlogString(fmt.Sprintf("/deploycheck/fleetcompatible called.")))
+
+		if user_ec, exUser, msgPrinter, ok := a.processExchangeCred("/deploycheck/fleetcompatible", UserTypeCred, w, r); ok {
+			body, _ := ioutil.ReadAll(r.Body)
+			if len(body) == 0 {
+				glog.Errorf(APIlogString(fmt.Sprintf("No input found.")))
+				writeResponse(w, msgPrinter.Sprintf("No input found."), http.StatusBadRequest)
+			} else if input, err := a.decodeFleetCheckBody(body, msgPrinter); err != nil {
+				writeResponse(w, err.Error(), http.StatusBadRequest)
+			} else {
+				// if checkAll is set, then check all the services defined in the deployment policy for compatibility.
+				checkAll := r.URL.Query().Get("checkAll")
+
+				// do the bound secret name varification in the secret manager for each node
+				verifySecrets := func(nodeId string, output *compcheck.CompCheckOutput) error {
+					if output.Input == nil || output.Input.NeededSB == nil || len(output.Input.NeededSB) == 0 {
+						return nil
+					}
+					if ok, msg, err := a.verifySecretNames(user_ec, exUser, output.Input.NeededSB, exchange.GetOrg(nodeId), msgPrinter); err != nil {
+						return err
+					} else if !ok {
+						output.Compatible = false
+						output.Reason["general"] = msg
+					}
+					return nil
+				}
+
+				output, err := compcheck.FleetCompatible(user_ec, "", input, (checkAll != ""), verifySecrets, msgPrinter)
+
+				// write the output
+				a.writeCompCheckResponse(w, output, err, msgPrinter)
+			}
+		}
+
+	case "OPTIONS":
+		w.Header().Set("Allow", "GET, OPTIONS")
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
 // This function checks user cred and writes corrsponding response. It also creates a message printer with given language from the http request.
 func (a *SecureAPI) processExchangeCred(resource string, authType string, w http.ResponseWriter, r *http.Request) (exchange.ExchangeContext, string, *message.Printer, bool) {
 	// get message printer with the language passed in from the header
@@ -905,6 +1025,24 @@ func (a *SecureAPI) decodeCompCheckBody(body []byte, msgPrinter *message.Printer
 	}
 }
 
+func (a *SecureAPI) decodeFleetCheckBody(body []byte, msgPrinter *message.Printer) (*compcheck.FleetCheck, error) {
+
+	var js map[string]interface{}
+	if err := json.Unmarshal(body, &js); err != nil {
+		glog.Errorf(APIlogString(fmt.Sprintf("Input body couldn't be deserialized to JSON object. %v", err)))
+		return nil, fmt.Errorf(msgPrinter.Sprintf("Input body couldn't be deserialized to JSON object. %v", err))
+	} else {
+		var input compcheck.FleetCheck
+		if err := json.Unmarshal(body, &input); err != nil {
+			glog.Errorf(APIlogString(fmt.Sprintf("Input body couldn't be deserialized to FleetCheck object. %v", err)))
+			return nil, fmt.Errorf(msgPrinter.Sprintf("Input body couldn't be deserialized to FleetCheck object. %v", err))
+		} else {
+			// verification of the input is done in the compcheck component, no need to validate the policies here.
+			return &input, nil
+		}
+	}
+}
+
 // This function verifies the given exchange user name and password.
 // The user must be in the format of orgId/userId.
 func (a *SecureAPI) authenticateWithExchange(user string, userPasswd string, authType string, msgPrinter *message.Printer) (exchange.ExchangeContext, string, error) {
@@ -1409,6 +1547,138 @@ func (a *SecureAPI) userSecret(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// The body of a request to roll back a secret to a prior version.
+type SecretRollbackRequest struct {
+	Version int `json:"version"`
+}
+
+// handler for /org/<org>/secretversions/<secret> - GET, POST, OPTIONS
+func (a *SecureAPI) orgSecretVersions(w http.ResponseWriter, r *http.Request) {
+	// check the provided secret name, <secret> can sometimes bind to user/<user>
+	pathVars := mux.Vars(r)
+	if strings.HasPrefix(pathVars["secret"], "user/") {
+		writeResponse(w, fmt.Sprintf("Incorrect secret name provided: \"%s\" cannot refer to a secret in the secrets manager.", pathVars["secret"]), http.StatusBadRequest)
+		return
+	}
+
+	if info := a.secretsSetup(w, r); info != nil {
+		a.secretVersions(w, r, info)
+	}
+}
+
+// handler for /org/<org>/secretversions/user/<user>/<secret> - GET, POST, OPTIONS
+func (a *SecureAPI) userSecretVersions(w http.ResponseWriter, r *http.Request) {
+	if info := a.secretsSetup(w, r); info != nil {
+		a.secretVersions(w, r, info)
+	}
+}
+
+func (a *SecureAPI) secretVersions(w http.ResponseWriter, r *http.Request, info *SecretRequestInfo) {
+
+	// handle API options
+	switch r.Method {
+	// swagger:operation GET /org/{org}/secretversions/* secretVersions
+	//
+	// List the versions of a secret retained by the secrets provider, oldest first.
+	//
+	// ---
+	// produces:
+	//   - application/json
+	// responses:
+	//  '200':
+	//    description: "Success."
+	//    type: array
+	//    items: SecretVersion
+	//  '401':
+	//    description: "Unauthenticated user."
+	//    type: string
+	//  '403':
+	//    description: "Secrets permission denied to user."
+	//    type: string
+	//  '404':
+	//    description: "The secret does not exist."
+	//    type: string
+	//  '503':
+	//    description: "Secret provider unavailable"
+	//    type: string
+	case "GET":
+		versions, err := a.secretProvider.ListSecretVersions(info.ec.GetExchangeId(), info.ec.GetExchangeToken(), info.org, info.user, info.vaultSecretName)
+		if serr, errMsg := a.errCheck(err, "read", info); serr == nil {
+			writeResponse(w, versions, http.StatusOK)
+		} else {
+			writeResponse(w, errMsg, serr.ResponseCode)
+		}
+
+	// swagger:operation POST /org/{org}/secretversions/* secretRollback
+	//
+	// Make a prior version of a secret the current version. The request body is {"version": <version>}. Services
+	// that use the secret are updated in the same way as when the secret is updated.
+	//
+	// ---
+	// consumes:
+	//   - application/json
+	// responses:
+	//  '201':
+	//    description: "The secret was rolled back."
+	//    type: string
+	//  '400':
+	//    description: "The request body is not valid."
+	//    type: string
+	//  '403':
+	//    description: "Secrets permission denied to user."
+	//    type: string
+	//  '404':
+	//    description: "The secret or the version does not exist."
+	//    type: string
+	//  '503':
+	//    description: "Secret provider unavailable"
+	//    type: string
+	case "POST":
+		var input SecretRollbackRequest
+		if body, err := ioutil.ReadAll(r.Body); err != nil {
+			writeResponse(w, info.msgPrinter.Sprintf("Unable to read request body, error: %v.", err), http.StatusInternalServerError)
+			return
+		} else if uerr := json.Unmarshal(body, &input); uerr != nil {
+			writeResponse(w, info.msgPrinter.Sprintf("Request body parse error, %v", uerr), http.StatusBadRequest)
+			return
+		} else if input.Version <= 0 {
+			writeResponse(w, info.msgPrinter.Sprintf("The version to roll back to must be greater than 0."), http.StatusBadRequest)
+			return
+		}
+
+		err := a.secretProvider.RollbackSecret(info.ec.GetExchangeId(), info.ec.GetExchangeToken(), info.org, info.user, info.vaultSecretName, input.Version)
+		if serr, errMsg := a.errCheck(err, "update", info); serr != nil {
+			writeResponse(w, errMsg, serr.ResponseCode)
+			return
+		}
+
+		a.propagateSecretUpdate(info)
+		writeResponse(w, info.msgPrinter.Sprintf("Secret rolled back to version %v.", input.Version), http.StatusCreated)
+
+	case "OPTIONS":
+		w.Header().Set("Allow", "GET, POST, OPTIONS")
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// A secret that was changed through this API might be used by agreements. Rather than waiting for the periodic check
+// for secret updates, check this secret now and tell the agbot worker about any policies or patterns that use it.
+func (a *SecureAPI) propagateSecretUpdate(info *SecretRequestInfo) {
+
+	fullSecretName := fmt.Sprintf("%v/%v", info.org, info.vaultSecretName)
+	if info.user != "" {
+		fullSecretName = fmt.Sprintf("%v/user/%v/%v", info.org, info.user, info.vaultSecretName)
+	}
+
+	secretUpdates := events.NewSecretUpdates()
+	checkSecretForUpdate(a.secretProvider, a.db, fullSecretName, secretUpdates)
+	if secretUpdates.Length() != 0 {
+		a.Messages() <- events.NewSecretUpdatesMessage(events.UPDATED_SECRETS, secretUpdates)
+	}
+}
+
 // This function does preprocessing for the secret APIs.
 // It returns error and http response code.
 func (a *SecureAPI) vaultSecretPreCheck(ec exchange.ExchangeContext,
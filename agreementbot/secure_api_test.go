@@ -0,0 +1,202 @@
+//go:build unit
+// +build unit
+
+package agreementbot
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/open-horizon/anax/agreementbot/persistence/sqlite"
+	"github.com/open-horizon/anax/agreementbot/secrets"
+	"github.com/open-horizon/anax/config"
+	"github.com/open-horizon/anax/events"
+	"github.com/open-horizon/anax/exchange"
+	"github.com/open-horizon/anax/i18n"
+	"github.com/open-horizon/anax/worker"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func Test_secretVersions_list(t *testing.T) {
+
+	a, provider := newSecretVersionsAPI(t)
+
+	// The versions are returned as the provider lists them.
+	w := httptest.NewRecorder()
+	a.secretVersions(w, httptest.NewRequest("GET", "/org/myorg/secretversions/sec1", nil), newSecretRequestInfo("", "sec1"))
+	var versions []secrets.SecretVersion
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %v but got %v: %v", http.StatusOK, w.Code, w.Body.String())
+	} else if err := json.Unmarshal(w.Body.Bytes(), &versions); err != nil {
+		t.Errorf("Error parsing the response %v: %v", w.Body.String(), err)
+	} else if len(versions) != 3 || versions[0].Version != 1 || !versions[2].Current {
+		t.Errorf("Expected the 3 versions of sec1 but got %v", versions)
+	}
+
+	// The versions of a user secret are listed for the secret user.
+	w = httptest.NewRecorder()
+	a.secretVersions(w, httptest.NewRequest("GET", "/org/myorg/secretversions/user/user1/sec2", nil), newSecretRequestInfo("user1", "sec2"))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %v but got %v: %v", http.StatusOK, w.Code, w.Body.String())
+	} else if err := json.Unmarshal(w.Body.Bytes(), &versions); err != nil {
+		t.Errorf("Error parsing the response %v: %v", w.Body.String(), err)
+	} else if len(versions) != 1 {
+		t.Errorf("Expected the version of user/user1/sec2 but got %v", versions)
+	}
+
+	// A secret that does not exist is not found.
+	w = httptest.NewRecorder()
+	a.secretVersions(w, httptest.NewRequest("GET", "/org/myorg/secretversions/missing", nil), newSecretRequestInfo("", "missing"))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %v but got %v: %v", http.StatusNotFound, w.Code, w.Body.String())
+	}
+
+	if len(provider.rolledBack) != 0 {
+		t.Errorf("Listing versions should not roll back a secret, got %v", provider.rolledBack)
+	}
+}
+
+func Test_secretVersions_rollback(t *testing.T) {
+
+	a, provider := newSecretVersionsAPI(t)
+
+	// Rolling back a secret tells the agbot worker about the policies that use it.
+	w := httptest.NewRecorder()
+	a.secretVersions(w, httptest.NewRequest("POST", "/org/myorg/secretversions/sec1", strings.NewReader(`{"version": 2}`)), newSecretRequestInfo("", "sec1"))
+	if w.Code != http.StatusCreated {
+		t.Errorf("Expected status %v but got %v: %v", http.StatusCreated, w.Code, w.Body.String())
+	} else if len(provider.rolledBack) != 1 || provider.rolledBack[0] != "myorg/sec1:2" {
+		t.Errorf("Expected sec1 to be rolled back to version 2 but got %v", provider.rolledBack)
+	}
+
+	select {
+	case msg := <-a.Messages():
+		if sm, ok := msg.(*events.SecretUpdatesMessage); !ok {
+			t.Errorf("Expected a secret updates message but got %v", msg)
+		} else if updates := sm.GetSecretUpdates(); updates.Length() != 1 || updates.Updates[0].PolicyNames[0] != "myorg/pol1" {
+			t.Errorf("Expected an update of sec1 for policy myorg/pol1 but got %v", updates)
+		}
+	default:
+		t.Errorf("Expected the secret update to be sent to the agbot worker")
+	}
+
+	// Rolling back to a version that does not exist is not found, and nothing is updated.
+	w = httptest.NewRecorder()
+	a.secretVersions(w, httptest.NewRequest("POST", "/org/myorg/secretversions/sec1", strings.NewReader(`{"version": 9}`)), newSecretRequestInfo("", "sec1"))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %v but got %v: %v", http.StatusNotFound, w.Code, w.Body.String())
+	} else if len(provider.rolledBack) != 1 {
+		t.Errorf("Expected no roll back to version 9 but got %v", provider.rolledBack)
+	} else if len(a.Messages()) != 0 {
+		t.Errorf("Expected no secret update to be sent to the agbot worker")
+	}
+
+	// The version must be a positive number.
+	for _, body := range []string{`{"version": 0}`, `{"version": "2"}`, `{}`} {
+		w = httptest.NewRecorder()
+		a.secretVersions(w, httptest.NewRequest("POST", "/org/myorg/secretversions/sec1", strings.NewReader(body)), newSecretRequestInfo("", "sec1"))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %v for %v but got %v: %v", http.StatusBadRequest, body, w.Code, w.Body.String())
+		}
+	}
+
+	// The org secret handler does not accept user secret names.
+	w = httptest.NewRecorder()
+	r := mux.SetURLVars(httptest.NewRequest("POST", "/org/myorg/secretversions/user/user1", strings.NewReader(`{"version": 1}`)), map[string]string{"org": "myorg", "secret": "user/user1"})
+	a.orgSecretVersions(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %v but got %v: %v", http.StatusBadRequest, w.Code, w.Body.String())
+	} else if len(provider.rolledBack) != 1 {
+		t.Errorf("Expected no roll back of a user secret but got %v", provider.rolledBack)
+	}
+}
+
+// A secrets provider that keeps 3 versions of the org secret sec1 and 1 version of the user secret user/user1/sec2. The
+// rollbacks are recorded as <org>/<secret>:<version>. The other provider functions are not used by these tests.
+type versionsProvider struct {
+	secrets.AgbotSecrets
+	versions   map[string][]secrets.SecretVersion
+	rolledBack []string
+}
+
+func (p *versionsProvider) ListSecretVersions(user, token, org, secretUser, secretName string) ([]secrets.SecretVersion, error) {
+	name := versionsSecretName(org, secretUser, secretName)
+	if versions, ok := p.versions[name]; ok {
+		return versions, nil
+	}
+	return nil, &secrets.NoSecretFound{Response: map[string][]string{}, SecretPath: name}
+}
+
+func (p *versionsProvider) RollbackSecret(user, token, org, secretUser, secretName string, version int) error {
+	name := versionsSecretName(org, secretUser, secretName)
+	for _, v := range p.versions[name] {
+		if v.Version == version {
+			p.rolledBack = append(p.rolledBack, fmt.Sprintf("%v:%v", name, version))
+			return nil
+		}
+	}
+	return &secrets.NoSecretFound{Response: map[string][]string{}, SecretPath: name}
+}
+
+func (p *versionsProvider) GetSecretMetadata(secretOrg, secretUser, secretName string) (secrets.SecretMetadata, error) {
+	return secrets.SecretMetadata{CreationTime: 10, UpdateTime: 20}, nil
+}
+
+func versionsSecretName(org, secretUser, secretName string) string {
+	if secretUser != "" {
+		return fmt.Sprintf("%v/user/%v/%v", org, secretUser, secretName)
+	}
+	return fmt.Sprintf("%v/%v", org, secretName)
+}
+
+// Create a secure API with the versions provider, and a database in which policy myorg/pol1 uses secret sec1.
+func newSecretVersionsAPI(t *testing.T) (*SecureAPI, *versionsProvider) {
+
+	dir, err := ioutil.TempDir("", "utsecureapi-")
+	if err != nil {
+		t.Fatalf("Error creating the database directory: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	cfg := &config.HorizonConfig{AgreementBot: config.AGConfig{Sqlite: config.SqliteConfig{DBPath: dir}}}
+	db := new(sqlite.AgbotSqliteDB)
+	if err := db.Initialize(cfg); err != nil {
+		t.Fatalf("Error initializing the database: %v", err)
+	}
+	t.Cleanup(db.Close)
+
+	if err := db.AddManagedPolicySecret("myorg", "sec1", "myorg", "pol1", 10); err != nil {
+		t.Fatalf("Error adding the policy secret: %v", err)
+	}
+
+	provider := &versionsProvider{
+		versions: map[string][]secrets.SecretVersion{
+			"myorg/sec1":            {{Version: 1, CreationTime: 1}, {Version: 2, CreationTime: 2}, {Version: 3, CreationTime: 3, Current: true}},
+			"myorg/user/user1/sec2": {{Version: 1, CreationTime: 1, Current: true}},
+		},
+	}
+
+	a := &SecureAPI{
+		Manager:        worker.Manager{Config: cfg, Messages: make(chan events.Message, 10)},
+		name:           "SecureAPI",
+		db:             db,
+		secretProvider: provider,
+	}
+	return a, provider
+}
+
+func newSecretRequestInfo(user, secretName string) *SecretRequestInfo {
+	return &SecretRequestInfo{
+		org:             "myorg",
+		ec:              exchange.NewCustomExchangeContext("myorg/admin", "pw", "", "", nil),
+		exUser:          "myorg/admin",
+		user:            user,
+		vaultSecretName: secretName,
+		msgPrinter:      i18n.GetMessagePrinter(),
+	}
+}
@@ -0,0 +1,229 @@
+package agreementbot
+
+import (
+	"encoding/base64"
+	"fmt"
+	"github.com/golang/glog"
+	"github.com/gorilla/mux"
+	"github.com/open-horizon/anax/cutil"
+	"github.com/open-horizon/anax/exchange"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// The default and maximum number of seconds the secure API waits for a node to send back the service log lines.
+const SERVICE_LOG_DEFAULT_TIMEOUT = 60
+const SERVICE_LOG_MAX_TIMEOUT = 300
+
+// A service log request sent to a node, waiting for the response of the node.
+type serviceLogWaiter struct {
+	nodeId   string
+	response chan *exchange.ServiceLogResponse
+}
+
+// The service log requests waiting for a response, by request id.
+var serviceLogWaitersLock sync.Mutex
+var serviceLogWaiters = make(map[string]*serviceLogWaiter)
+
+func addServiceLogWaiter(requestId string, nodeId string) *serviceLogWaiter {
+	serviceLogWaitersLock.Lock()
+	defer serviceLogWaitersLock.Unlock()
+	waiter := &serviceLogWaiter{nodeId: nodeId, response: make(chan *exchange.ServiceLogResponse, 1)}
+	serviceLogWaiters[requestId] = waiter
+	return waiter
+}
+
+func removeServiceLogWaiter(requestId string) {
+	serviceLogWaitersLock.Lock()
+	defer serviceLogWaitersLock.Unlock()
+	delete(serviceLogWaiters, requestId)
+}
+
+// Hand a service log response to the request waiting for it. Returns false if no request is waiting for it, which
+// happens when another agbot instance sharing the message queue sent the request, or when the request timed out.
+func deliverServiceLogResponse(nodeId string, resp *exchange.ServiceLogResponse) bool {
+	serviceLogWaitersLock.Lock()
+	waiter, ok := serviceLogWaiters[resp.RequestId]
+	if ok {
+		delete(serviceLogWaiters, resp.RequestId)
+	}
+	serviceLogWaitersLock.Unlock()
+
+	if !ok {
+		return false
+	} else if waiter.nodeId != nodeId {
+		glog.Warningf(APIlogString(fmt.Sprintf("ignoring service log response %v from %v, the request was sent to %v", resp.RequestId, nodeId, waiter.nodeId)))
+	} else {
+		waiter.response <- resp
+	}
+	return true
+}
+
+// Pull the recent log lines of a service from a node. The request is sent to the node through its exchange message
+// queue and the handler waits for the node to send the lines back. The user has to be able to read the node.
+func (a *SecureAPI) nodeServiceLogs(w http.ResponseWriter, r *http.Request) {
+
+	switch r.Method {
+	// swagger:operation GET /org/{org}/node/{node}/servicelogs nodeServiceLogs
+	//
+	// Get the recent log lines of a service running on a node.
+	//
+	// This API sends an encrypted request to the node through the exchange and waits for the node to send back the most recent output of the service containers. The node must be registered and heartbeating.
+	//
+	// ---
+	// produces:
+	//  - application/json
+	// parameters:
+	//  - name: service
+	//    in: query
+	//    type: string
+	//    required: true
+	//    description: "The url of the service, optionally prefixed with the service organization."
+	//  - name: version
+	//    in: query
+	//    type: string
+	//    required: false
+	//    description: "The version of the service."
+	//  - name: container
+	//    in: query
+	//    type: string
+	//    required: false
+	//    description: "The container of the service. The output of all the containers is returned if omitted."
+	//  - name: tail
+	//    in: query
+	//    type: integer
+	//    required: false
+	//    description: "The number of most recent lines, 100 by default and at most 1000."
+	//  - name: since
+	//    in: query
+	//    type: string
+	//    required: false
+	//    description: "Only the lines after this time, in RFC3339 format, or a duration such as 10m."
+	//  - name: timeout
+	//    in: query
+	//    type: integer
+	//    required: false
+	//    description: "The number of seconds to wait for the node, 60 by default and at most 300."
+	// responses:
+	//  '200':
+	//    description: "Success"
+	//    schema:
+	//     type: exchange.ServiceLogResponse
+	//  '400':
+	//    description: "Failure - Invalid input or the node cannot be read"
+	//    schema:
+	//     type: string
+	//  '401':
+	//    description: "Failure - Failed to authenticate"
+	//    schema:
+	//     type: string
+	//  '404':
+	//    description: "Failure - The service is not running on the node"
+	//    schema:
+	//     type: string
+	//  '504':
+	//    description: "Failure - The node did not respond in time"
+	//    schema:
+	//     type: string
+	case "GET":
+		pathVars := mux.Vars(r)
+		org := pathVars["org"]
+		node := pathVars["node"]
+		nodeId := fmt.Sprintf("%v/%v", org, node)
+
+		resource := fmt.Sprintf("/org/%v/node/%v/servicelogs", org, node)
+		glog.V(5).Infof(APIlogString(fmt.Sprintf("%v called.", resource)))
+
+		user_ec, _, msgPrinter, ok := a.processExchangeCred(resource, UserTypeCred, w, r)
+		if !ok {
+			return
+		}
+
+		query := r.URL.Query()
+		service := query.Get("service")
+		if service == "" {
+			writeResponse(w, msgPrinter.Sprintf("The service parameter is required."), http.StatusBadRequest)
+			return
+		} else if _, err := exchange.ParseServiceLogSince(query.Get("since"), time.Now()); err != nil {
+			writeResponse(w, msgPrinter.Sprintf("The since parameter is not valid: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		tail := exchange.SERVICE_LOG_DEFAULT_TAIL
+		if tailParm := query.Get("tail"); tailParm != "" {
+			if t, err := strconv.Atoi(tailParm); err != nil || t <= 0 || t > exchange.SERVICE_LOG_MAX_TAIL {
+				writeResponse(w, msgPrinter.Sprintf("The tail parameter %v must be a number of lines between 1 and %v.", tailParm, exchange.SERVICE_LOG_MAX_TAIL), http.StatusBadRequest)
+				return
+			} else {
+				tail = t
+			}
+		}
+
+		timeout := SERVICE_LOG_DEFAULT_TIMEOUT
+		if timeoutParm := query.Get("timeout"); timeoutParm != "" {
+			if t, err := strconv.Atoi(timeoutParm); err != nil || t <= 0 || t > SERVICE_LOG_MAX_TIMEOUT {
+				writeResponse(w, msgPrinter.Sprintf("The timeout parameter %v must be a number of seconds between 1 and %v.", timeoutParm, SERVICE_LOG_MAX_TIMEOUT), http.StatusBadRequest)
+				return
+			} else {
+				timeout = t
+			}
+		}
+
+		// Reading the node with the user credentials checks that the user is allowed to see the node.
+		dev, err := exchange.GetExchangeDevice(user_ec.GetHTTPFactory(), nodeId, user_ec.GetExchangeId(), user_ec.GetExchangeToken(), user_ec.GetExchangeURL())
+		if err != nil {
+			writeResponse(w, msgPrinter.Sprintf("Unable to retrieve node %v from the exchange: %v", nodeId, err), http.StatusBadRequest)
+			return
+		} else if dev.PublicKey == "" {
+			writeResponse(w, msgPrinter.Sprintf("Node %v is not registered.", nodeId), http.StatusBadRequest)
+			return
+		}
+
+		nodeKey, err := base64.StdEncoding.DecodeString(dev.PublicKey)
+		if err != nil {
+			writeResponse(w, msgPrinter.Sprintf("Unable to decode the public key of node %v: %v", nodeId, err), http.StatusInternalServerError)
+			return
+		}
+
+		requestId, err := cutil.SecureRandomString()
+		if err != nil {
+			writeResponse(w, msgPrinter.Sprintf("Unable to create a request id: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		waiter := addServiceLogWaiter(requestId, nodeId)
+		defer removeServiceLogWaiter(requestId)
+
+		req := exchange.NewServiceLogRequest(requestId, service, query.Get("version"), query.Get("container"), tail, query.Get("since"))
+		myPubKey, myPrivKey, _ := exchange.GetKeys(a.Config.AgreementBot.MessageKeyPath)
+		targetURL := a.Config.AgreementBot.ExchangeURL + "orgs/" + org + "/nodes/" + node + "/msgs"
+		ttl := a.Config.AgreementBot.GetExchangeMessageTTL(dev.HeartbeatIntv.MaxInterval)
+		if err := exchange.PostEncryptedMessage(newHTTPClientFactory(), targetURL, a.Config.AgreementBot.ExchangeId, a.Config.AgreementBot.ExchangeToken, myPubKey, myPrivKey, nodeKey, req, ttl); err != nil {
+			glog.Errorf(APIlogString(fmt.Sprintf("Unable to send service log request %v to node %v, error %v", req, nodeId, err)))
+			writeResponse(w, msgPrinter.Sprintf("Unable to send the request to node %v: %v", nodeId, err), http.StatusInternalServerError)
+			return
+		}
+
+		glog.V(3).Infof(APIlogString(fmt.Sprintf("Sent service log request %v to node %v", req, nodeId)))
+
+		select {
+		case resp := <-waiter.response:
+			if resp.Error != "" {
+				writeResponse(w, msgPrinter.Sprintf("Node %v: %v", nodeId, resp.Error), http.StatusNotFound)
+			} else {
+				writeResponse(w, resp, http.StatusOK)
+			}
+		case <-time.After(time.Duration(timeout) * time.Second):
+			writeResponse(w, msgPrinter.Sprintf("Node %v did not send the service logs within %v seconds.", nodeId, timeout), http.StatusGatewayTimeout)
+		case <-r.Context().Done():
+		}
+
+	case "OPTIONS":
+		w.Header().Set("Allow", "GET, OPTIONS")
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
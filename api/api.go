@@ -81,11 +81,20 @@ func (a *API) router(includeStaticRedirects bool) *mux.Router {
 	router.HandleFunc("/service/config", a.serviceconfig).Methods("GET", "POST", "OPTIONS")
 	router.HandleFunc("/service/configstate", a.service_configstate).Methods("GET", "POST", "OPTIONS")
 	router.HandleFunc("/service/policy", a.servicepolicy).Methods("GET", "OPTIONS")
+	router.HandleFunc("/service/volumes", a.serviceVolumes).Methods("GET", "DELETE", "OPTIONS")
+	router.HandleFunc("/service/{instance}/logs", a.serviceLogs).Methods("GET", "OPTIONS")
 
 	// Connectivity and blockchain status info
 	router.HandleFunc("/status", a.status).Methods("GET", "OPTIONS")
 	router.HandleFunc("/status/workers", a.workerstatus).Methods("GET", "OPTIONS")
 
+	// Metrics in the Prometheus text exposition format
+	router.HandleFunc("/metrics", a.metrics).Methods("GET", "OPTIONS")
+
+	// Liveness and readiness probes for container orchestrators
+	router.HandleFunc("/health/live", a.healthLive).Methods("GET", "OPTIONS")
+	router.HandleFunc("/health/ready", a.healthReady).Methods("GET", "OPTIONS")
+
 	// Used by the Registration UI to obtain a random token string
 	router.HandleFunc("/token/random", tokenRandom).Methods("GET", "OPTIONS")
 
@@ -94,12 +103,16 @@ func (a *API) router(includeStaticRedirects bool) *mux.Router {
 	router.HandleFunc("/node/configstate", a.nodeconfigstate).Methods("GET", "HEAD", "PUT", "OPTIONS")
 	router.HandleFunc("/node/policy", a.nodepolicy).Methods("GET", "HEAD", "PUT", "POST", "PATCH", "DELETE", "OPTIONS")
 	router.HandleFunc("/node/userinput", a.nodeuserinput).Methods("GET", "HEAD", "PUT", "POST", "PATCH", "DELETE", "OPTIONS")
+	router.HandleFunc("/node/diagnostics", a.nodeDiagnostics).Methods("GET", "OPTIONS")
 
 	// Used to get the event logs on this node.
 	// get the eventlogs for current registration.
 	router.HandleFunc("/eventlog", a.eventlog).Methods("GET", "OPTIONS")
 	// get the eventlogs for all registrations.
 	router.HandleFunc("/eventlog/all", a.eventlog).Methods("GET", "OPTIONS")
+	// follow the eventlogs as they are saved, by server-sent events or long-poll.
+	router.HandleFunc("/eventlog/stream", a.eventlogStream).Methods("GET", "OPTIONS")
+	router.HandleFunc("/eventlog/all/stream", a.eventlogStream).Methods("GET", "OPTIONS")
 	//get the active surface errors for this node
 	router.HandleFunc("/eventlog/surface", a.surface).Methods("GET", "OPTIONS")
 
@@ -1,11 +1,16 @@
 package api
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/golang/glog"
 	"github.com/open-horizon/anax/i18n"
+	"github.com/open-horizon/anax/persistence"
+	"golang.org/x/text/message"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // get the eventlogs for current registration.
@@ -34,9 +39,16 @@ func (a *API) eventlog(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		glog.V(5).Infof(apiLogString(fmt.Sprintf("Handling %v on resource %v with selection %v. Language: %v", r.Method, resource, r.Form, lan)))
+		// The limit, offset and sort parameters are not selectors.
+		page, err := getEventLogPage(r.Form, msgPrinter)
+		if err != nil {
+			errorHandler(err)
+			return
+		}
 
-		if out, err := FindEventLogsForOutput(a.db, all_loags, r.Form, msgPrinter); err != nil {
+		glog.V(5).Infof(apiLogString(fmt.Sprintf("Handling %v on resource %v with selection %v and page %v. Language: %v", r.Method, resource, r.Form, page, lan)))
+
+		if out, err := FindEventLogsPageForOutput(a.db, all_loags, r.Form, page, msgPrinter); err != nil {
 			errorHandler(NewSystemError(msgPrinter.Sprintf("Error getting %v for output, error %v", resource, err)))
 		} else {
 			writeResponse(w, out, http.StatusOK)
@@ -51,6 +63,226 @@ func (a *API) eventlog(w http.ResponseWriter, r *http.Request) {
 
 }
 
+// Get the page of event logs requested by the limit, offset and sort query parameters, and remove them from the
+// form so that the remaining parameters are the selectors.
+func getEventLogPage(form map[string][]string, msgPrinter *message.Printer) (persistence.EventLogPage, error) {
+
+	page := persistence.EventLogPage{}
+
+	getParm := func(name string) string {
+		v := ""
+		if vals, ok := form[name]; ok && len(vals) != 0 {
+			v = vals[0]
+		}
+		delete(form, name)
+		return v
+	}
+
+	if limit := getParm("limit"); limit != "" {
+		if l, err := strconv.Atoi(limit); err != nil || l < 0 {
+			return page, NewAPIUserInputError(msgPrinter.Sprintf("The limit parameter %v must be a positive number.", limit), "limit")
+		} else {
+			page.Limit = l
+		}
+	}
+
+	if offset := getParm("offset"); offset != "" {
+		if o, err := strconv.Atoi(offset); err != nil || o < 0 {
+			return page, NewAPIUserInputError(msgPrinter.Sprintf("The offset parameter %v must be a positive number.", offset), "offset")
+		} else {
+			page.Offset = o
+		}
+	}
+
+	switch order := getParm("sort"); order {
+	case "", "asc":
+	case "desc":
+		page.Descending = true
+	default:
+		return page, NewAPIUserInputError(msgPrinter.Sprintf("The sort parameter %v must be asc or desc.", order), "sort")
+	}
+
+	return page, nil
+}
+
+// The default and maximum number of seconds a long-poll request on /eventlog/stream waits for new event logs.
+const EVENTLOG_STREAM_DEFAULT_TIMEOUT = 30
+const EVENTLOG_STREAM_MAX_TIMEOUT = 300
+
+// How often a comment is sent on an idle server-sent event stream, so that proxies do not close the connection.
+const EVENTLOG_STREAM_KEEPALIVE = 15 * time.Second
+
+// Follow the eventlogs as they are saved. The event logs saved after the record id in the "since" query parameter are
+// returned. If "since" is not specified, only the event logs saved after the request arrives are returned. The other
+// query parameters are selectors, the same as for /eventlog.
+//
+// If the request accepts text/event-stream, the event logs are sent as server-sent events until the client goes
+// away. The id of each event is the record id, so a client that reconnects with the Last-Event-ID header continues
+// where it left off. Otherwise the request is a long-poll. It waits up to "timeout" seconds for at least one event log
+// and returns a JSON array of event logs, which is empty if the timeout expired.
+func (a *API) eventlogStream(w http.ResponseWriter, r *http.Request) {
+
+	resource := "eventlog/stream"
+
+	errorHandler := GetHTTPErrorHandler(w)
+
+	switch r.Method {
+	case "GET":
+		// get message printer with the language passed in from the header
+		lan := r.Header.Get("Accept-Language")
+		if lan == "" {
+			lan = i18n.DEFAULT_LANGUAGE
+		}
+		msgPrinter := i18n.GetMessagePrinterWithLocale(lan)
+
+		all_logs := false
+		if r.URL != nil && strings.Contains(r.URL.Path, "all") {
+			all_logs = true
+		}
+
+		if err := r.ParseForm(); err != nil {
+			errorHandler(NewAPIUserInputError(msgPrinter.Sprintf("Error parsing the selections %v. %v", r.Form, err), "selection"))
+			return
+		}
+
+		// The since and timeout parameters are not selectors.
+		sinceParm := r.Form.Get("since")
+		timeoutParm := r.Form.Get("timeout")
+		r.Form.Del("since")
+		r.Form.Del("timeout")
+
+		if sinceParm == "" {
+			sinceParm = r.Header.Get("Last-Event-ID")
+		}
+
+		var since uint64
+		if sinceParm != "" {
+			if s, err := strconv.ParseUint(sinceParm, 10, 64); err != nil {
+				errorHandler(NewAPIUserInputError(msgPrinter.Sprintf("The since parameter %v must be an event log record id.", sinceParm), "since"))
+				return
+			} else {
+				since = s
+			}
+		} else if last, err := persistence.GetLastEventLogRecordId(a.db); err != nil {
+			errorHandler(NewSystemError(msgPrinter.Sprintf("Error getting the last event log record id, error %v", err)))
+			return
+		} else {
+			since = last
+		}
+
+		timeout := EVENTLOG_STREAM_DEFAULT_TIMEOUT
+		if timeoutParm != "" {
+			if t, err := strconv.Atoi(timeoutParm); err != nil || t < 0 || t > EVENTLOG_STREAM_MAX_TIMEOUT {
+				errorHandler(NewAPIUserInputError(msgPrinter.Sprintf("The timeout parameter %v must be a number of seconds between 0 and %v.", timeoutParm, EVENTLOG_STREAM_MAX_TIMEOUT), "timeout"))
+				return
+			} else {
+				timeout = t
+			}
+		}
+
+		glog.V(5).Infof(apiLogString(fmt.Sprintf("Handling %v on resource %v since record %v with selection %v. Language: %v", r.Method, resource, since, r.Form, lan)))
+
+		if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+			a.eventlogServerSentEvents(w, r, all_logs, since, msgPrinter)
+		} else {
+			a.eventlogLongPoll(w, r, all_logs, since, timeout, msgPrinter)
+		}
+
+	case "OPTIONS":
+		w.Header().Set("Allow", "GET, OPTIONS")
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+
+}
+
+// Wait until there is at least one matching event log after the since record id, or the timeout expires.
+func (a *API) eventlogLongPoll(w http.ResponseWriter, r *http.Request, all_logs bool, since uint64, timeout int, msgPrinter *message.Printer) {
+
+	errorHandler := GetHTTPErrorHandler(w)
+	expired := time.After(time.Duration(timeout) * time.Second)
+
+	for {
+		// Get the notification channel before reading the db so that a record saved while the db is being
+		// read is not missed.
+		saved := persistence.EventLogSaved()
+
+		out, err := FindEventLogsSinceForOutput(a.db, all_logs, since, r.Form, msgPrinter)
+		if err != nil {
+			errorHandler(NewSystemError(msgPrinter.Sprintf("Error getting %v for output, error %v", "eventlog/stream", err)))
+			return
+		} else if len(out) != 0 {
+			writeResponse(w, out, http.StatusOK)
+			return
+		}
+
+		select {
+		case <-saved:
+		case <-expired:
+			writeResponse(w, out, http.StatusOK)
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// Send matching event logs as server-sent events until the client goes away.
+func (a *API) eventlogServerSentEvents(w http.ResponseWriter, r *http.Request, all_logs bool, since uint64, msgPrinter *message.Printer) {
+
+	errorHandler := GetHTTPErrorHandler(w)
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		errorHandler(NewSystemError(msgPrinter.Sprintf("Streaming is not supported by the response writer.")))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepalive := time.NewTicker(EVENTLOG_STREAM_KEEPALIVE)
+	defer keepalive.Stop()
+
+	for {
+		saved := persistence.EventLogSaved()
+
+		out, err := FindEventLogsSinceForOutput(a.db, all_logs, since, r.Form, msgPrinter)
+		if err != nil {
+			glog.Errorf(apiLogString(fmt.Sprintf("Error getting event logs for stream, error %v", err)))
+			return
+		}
+
+		for _, el := range out {
+			serial, err := json.Marshal(el)
+			if err != nil {
+				glog.Errorf(apiLogString(fmt.Sprintf("Error serializing event log %v, error %v", el.Id, err)))
+				continue
+			} else if _, err := fmt.Fprintf(w, "id: %v\nevent: eventlog\ndata: %s\n\n", el.Id, serial); err != nil {
+				return
+			}
+		}
+
+		if len(out) != 0 {
+			since = persistence.EventLogRecordId(out[len(out)-1])
+		}
+		flusher.Flush()
+
+		select {
+		case <-saved:
+		case <-keepalive.C:
+			if _, err := fmt.Fprintf(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
 func (a *API) surface(w http.ResponseWriter, r *http.Request) {
 	resource := "eventlog/surface"
 	errorHandler := GetHTTPErrorHandler(w)
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	dockerclient "github.com/fsouza/go-dockerclient"
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/cutil"
+	"github.com/open-horizon/anax/exchange"
+	"github.com/open-horizon/anax/resource"
+	"github.com/open-horizon/anax/worker"
+	"k8s.io/client-go/kubernetes"
+	"net/http"
+	"time"
+)
+
+// The number of seconds that each readiness check waits for the exchange, docker or kubernetes to respond.
+const HEALTH_CHECK_TIMEOUT_S = 5
+
+// The liveness probe. It fails only when the agent cannot do any work until it is restarted.
+func (a *API) healthLive(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		if err := FindLivenessForOutput(a.db); err != nil {
+			glog.Errorf(apiLogString(fmt.Sprintf("Liveness check failed: %v", err)))
+			writeResponse(w, map[string]interface{}{"live": false, "message": err.Error()}, http.StatusServiceUnavailable)
+		} else {
+			writeResponse(w, map[string]interface{}{"live": true}, http.StatusOK)
+		}
+	case "OPTIONS":
+		w.Header().Set("Allow", "GET, OPTIONS")
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// The readiness probe. It fails when the agent is not able to make or run agreements.
+func (a *API) healthReady(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		readiness := FindReadinessForOutput(a.db, a.readinessProbes(), worker.GetWorkerStatusManager())
+		if readiness.Ready {
+			writeResponse(w, readiness, http.StatusOK)
+		} else {
+			glog.V(3).Infof(apiLogString(fmt.Sprintf("Readiness check failed: %v", readiness.Checks)))
+			writeResponse(w, readiness, http.StatusServiceUnavailable)
+		}
+	case "OPTIONS":
+		w.Header().Set("Allow", "GET, OPTIONS")
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// The readiness probes for the real exchange, container runtime and ESS.
+func (a *API) readinessProbes() ReadinessProbes {
+	probes := ReadinessProbes{
+		Docker: func() error {
+			if client, err := dockerclient.NewClient(a.Config.Edge.DockerEndpoint); err != nil {
+				return errors.New(fmt.Sprintf("unable to create docker client from %v, error %v", a.Config.Edge.DockerEndpoint, err))
+			} else {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Duration(HEALTH_CHECK_TIMEOUT_S)*time.Second)
+				defer cancel()
+				if err := client.PingWithContext(ctx); err != nil {
+					return errors.New(fmt.Sprintf("unable to reach docker at %v, error %v", a.Config.Edge.DockerEndpoint, err))
+				}
+			}
+			return nil
+		},
+		Kube: func() error {
+			kubeConfig, err := cutil.NewKubeConfig()
+			if err != nil {
+				return errors.New(fmt.Sprintf("unable to create kubernetes client, error %v", err))
+			}
+			kubeConfig.Timeout = time.Duration(HEALTH_CHECK_TIMEOUT_S) * time.Second
+			if client, err := kubernetes.NewForConfig(kubeConfig); err != nil {
+				return errors.New(fmt.Sprintf("unable to create kubernetes client, error %v", err))
+			} else if _, err := client.Discovery().ServerVersion(); err != nil {
+				return errors.New(fmt.Sprintf("unable to reach the kubernetes API server, error %v", err))
+			}
+			return nil
+		},
+		ESSStarted: resource.IsFileSyncServiceStarted,
+	}
+
+	// The exchange version is read directly rather than from the exchange cache, so that an unreachable exchange is
+	// noticed. There are no retries, the probe is repeated by the caller.
+	if exchangeURL := a.GetExchangeURL(); exchangeURL != "" {
+		probes.Exchange = func() error {
+			timeout := uint(HEALTH_CHECK_TIMEOUT_S)
+			var resp interface{}
+			resp = ""
+			if err, tpErr := exchange.InvokeExchange(a.GetHTTPFactory().NewHTTPClient(&timeout), "GET", exchangeURL+"admin/version", a.GetExchangeId(), a.GetExchangeToken(), nil, &resp); err != nil {
+				return errors.New(fmt.Sprintf("unable to get the exchange version from %v, error %v", exchangeURL, err))
+			} else if tpErr != nil {
+				return errors.New(fmt.Sprintf("unable to reach the exchange at %v, error %v", exchangeURL, tpErr))
+			}
+			return nil
+		}
+	}
+
+	return probes
+}
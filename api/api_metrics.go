@@ -0,0 +1,30 @@
+package api
+
+import (
+	"fmt"
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/metrics"
+	"net/http"
+)
+
+func (a *API) metrics(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		// The database derived gauges are refreshed on each scrape. If that fails, the metrics maintained by the
+		// workers are still returned.
+		if err := CollectAgentMetrics(a.db, metrics.DefaultRegistry); err != nil {
+			glog.Errorf(apiLogString(fmt.Sprintf("Unable to collect metrics, error: %v", err)))
+		}
+
+		w.Header().Set("Content-Type", metrics.CONTENT_TYPE)
+		w.WriteHeader(http.StatusOK)
+		if err := metrics.DefaultRegistry.Write(w); err != nil {
+			glog.Errorf(apiLogString(fmt.Sprintf("Unable to write metrics, error: %v", err)))
+		}
+	case "OPTIONS":
+		w.Header().Set("Allow", "GET, OPTIONS")
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
@@ -1,13 +1,16 @@
 package api
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/golang/glog"
+	"github.com/open-horizon/anax/diagnostics"
 	"github.com/open-horizon/anax/eventlog"
 	"github.com/open-horizon/anax/events"
 	"github.com/open-horizon/anax/exchange"
@@ -295,9 +298,10 @@ func (a *API) nodepolicy(w http.ResponseWriter, r *http.Request) {
 		}
 		nodeGetPolicyHandler := exchange.GetHTTPNodePolicyHandler(a)
 		nodePutPolicyHandler := exchange.GetHTTPPutNodePolicyHandler(a)
+		propertySchemaHandler := exchange.GetHTTPPropertySchemaHandler(a)
 
 		// Validate and create or update the node policy.
-		errHandled, cfg, msgs := UpdateNodePolicy(&nodePolicy, update_node_policy_error_handler, nodeGetPolicyHandler, nodePutPolicyHandler, a.db)
+		errHandled, cfg, msgs := UpdateNodePolicy(&nodePolicy, update_node_policy_error_handler, nodeGetPolicyHandler, nodePutPolicyHandler, propertySchemaHandler, a.db)
 		if errHandled {
 			return
 		}
@@ -381,9 +385,10 @@ func (a *API) nodepolicy(w http.ResponseWriter, r *http.Request) {
 		}
 		nodeGetPolicyHandler := exchange.GetHTTPNodePolicyHandler(a)
 		nodePatchPolicyHandler := exchange.GetHTTPPutNodePolicyHandler(a)
+		propertySchemaHandler := exchange.GetHTTPPropertySchemaHandler(a)
 
 		//Validate the patch and update the policy
-		errHandled, cfg, msgs := PatchNodePolicy(attribName, patchObject, patch_node_policy_error_handler, nodeGetPolicyHandler, nodePatchPolicyHandler, a.db)
+		errHandled, cfg, msgs := PatchNodePolicy(attribName, patchObject, patch_node_policy_error_handler, nodeGetPolicyHandler, nodePatchPolicyHandler, propertySchemaHandler, a.db)
 
 		if errHandled {
 			return
@@ -567,3 +572,37 @@ func (a *API) nodeuserinput(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 	}
 }
+
+// Return a gzipped tarball with the state of the agent, see diagnostics.CreateBundle. It is built in memory so that an
+// error can still be returned to the client.
+func (a *API) nodeDiagnostics(w http.ResponseWriter, r *http.Request) {
+
+	resource := "node/diagnostics"
+
+	errorHandler := GetHTTPErrorHandler(w)
+
+	switch r.Method {
+	case "GET":
+		glog.V(5).Infof(apiLogString(fmt.Sprintf("Handling %v on resource %v", r.Method, resource)))
+
+		var bundle bytes.Buffer
+		if err := diagnostics.CreateBundle(&bundle, a.db, a.Config, a); err != nil {
+			errorHandler(NewSystemError(fmt.Sprintf("Error creating the diagnostics bundle, error %v", err)))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/gzip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"horizon-diagnostics-%v.tar.gz\"", time.Now().UTC().Format("20060102T150405Z")))
+		w.Header().Set("Content-Length", strconv.Itoa(bundle.Len()))
+		w.WriteHeader(http.StatusOK)
+		if _, err := bundle.WriteTo(w); err != nil {
+			glog.Errorf(apiLogString(fmt.Sprintf("Error writing the diagnostics bundle, error %v", err)))
+		}
+
+	case "OPTIONS":
+		w.Header().Set("Allow", "GET, OPTIONS")
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
@@ -4,14 +4,18 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"github.com/boltdb/bolt"
 	"github.com/golang/glog"
 	"github.com/gorilla/mux"
+	"github.com/open-horizon/anax/container"
 	"github.com/open-horizon/anax/cutil"
 	"github.com/open-horizon/anax/events"
 	"github.com/open-horizon/anax/exchange"
 	"github.com/open-horizon/anax/persistence"
 	"io/ioutil"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 func (a *API) service(w http.ResponseWriter, r *http.Request) {
@@ -239,3 +243,171 @@ func (a *API) servicepolicy(w http.ResponseWriter, r *http.Request) {
 	}
 
 }
+
+// Return the recent output of the containers of a service instance. The instance is the instance id of the service as
+// shown by /service. The "container" query parameter selects one container of the service, "tail" returns only the
+// last number of lines, and "since" only the lines after a time in RFC3339 format or a duration such as 10m. With
+// "follow" set to true, the lines are sent as server-sent events, followed by the new lines until the client goes away.
+func (a *API) serviceLogs(w http.ResponseWriter, r *http.Request) {
+
+	resource := "service/logs"
+	errorhandler := GetHTTPErrorHandler(w)
+
+	switch r.Method {
+	case "GET":
+		instance := mux.Vars(r)["instance"]
+		containerName := r.URL.Query().Get("container")
+
+		glog.V(5).Infof(apiLogString(fmt.Sprintf("Handling %v on resource %v for instance %v", r.Method, resource, instance)))
+
+		tail := 0
+		if tailParm := r.URL.Query().Get("tail"); tailParm != "" {
+			if t, err := strconv.Atoi(tailParm); err != nil || t < 0 {
+				errorhandler(NewAPIUserInputError(fmt.Sprintf("The tail parameter %v must be a positive number of lines.", tailParm), "tail"))
+				return
+			} else {
+				tail = t
+			}
+		}
+
+		since, err := exchange.ParseServiceLogSince(r.URL.Query().Get("since"), time.Now())
+		if err != nil {
+			errorhandler(NewAPIUserInputError(fmt.Sprintf("The since parameter is not valid: %v", err), "since"))
+			return
+		}
+
+		follow := false
+		if followParm := r.URL.Query().Get("follow"); followParm != "" {
+			if f, err := strconv.ParseBool(followParm); err != nil {
+				errorhandler(NewAPIUserInputError(fmt.Sprintf("The follow parameter %v must be true or false.", followParm), "follow"))
+				return
+			} else {
+				follow = f
+			}
+		}
+
+		instance, err = serviceInstanceKey(a.db, instance)
+		if err != nil {
+			errorhandler(NewSystemError(fmt.Sprintf("Error getting service instances, error %v", err)))
+			return
+		}
+
+		cursor := container.NewServiceLogCursor()
+		lines, found := container.FollowServiceLogs(instance, containerName, since, tail, cursor)
+		if !found {
+			errorhandler(NewNotFoundError(fmt.Sprintf("No logs found for service instance %v container %v.", instance, containerName), "instance"))
+		} else if !follow {
+			writeResponse(w, lines, http.StatusOK)
+		} else {
+			a.serviceLogsServerSentEvents(w, r, instance, containerName, lines, since, cursor)
+		}
+
+	case "OPTIONS":
+		w.Header().Set("Allow", "GET, OPTIONS")
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// The containers of a service instance are named after the key of the instance. Return the key of the instance with
+// the input instance id, or the input if there is no such instance.
+func serviceInstanceKey(db *bolt.DB, instance string) (string, error) {
+	msinsts, err := persistence.FindMicroserviceInstances(db, []persistence.MIFilter{persistence.UnarchivedMIFilter()})
+	if err != nil {
+		return "", err
+	}
+	for _, msinst := range msinsts {
+		if msinst.InstanceId == instance {
+			return msinst.GetKey(), nil
+		}
+	}
+	return instance, nil
+}
+
+// Send the log lines as server-sent events, followed by the lines of the service instance added after the cursor, until
+// the client goes away. The lines are numbered by the log buffers, so lines written at the same time are not lost.
+func (a *API) serviceLogsServerSentEvents(w http.ResponseWriter, r *http.Request, instance string, containerName string, lines []exchange.ServiceLogLine, since time.Time, cursor *container.ServiceLogCursor) {
+
+	errorhandler := GetHTTPErrorHandler(w)
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		errorhandler(NewSystemError("Streaming is not supported by the response writer."))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.WriteHeader(http.StatusOK)
+
+	keepalive := time.NewTicker(EVENTLOG_STREAM_KEEPALIVE)
+	defer keepalive.Stop()
+
+	for {
+		// Get the notification channel before reading the log buffers so that a line added meanwhile is not missed.
+		updated := container.ServiceLogsUpdated()
+
+		for _, line := range lines {
+			if serial, err := json.Marshal(line); err != nil {
+				glog.Errorf(apiLogString(fmt.Sprintf("Error serializing service log line %v, error %v", line, err)))
+			} else if _, err := fmt.Fprintf(w, "event: log\ndata: %s\n\n", serial); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+
+		select {
+		case <-updated:
+		case <-keepalive.C:
+			if _, err := fmt.Fprintf(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+
+		var found bool
+		if lines, found = container.FollowServiceLogs(instance, containerName, since, 0, cursor); !found {
+			// The containers of the service instance were removed.
+			return
+		}
+	}
+}
+
+// Return the docker volumes created by the agent for the service containers, or delete the volumes kept after the end
+// of their agreement.
+func (a *API) serviceVolumes(w http.ResponseWriter, r *http.Request) {
+
+	resource := "service/volumes"
+	errorhandler := GetHTTPErrorHandler(w)
+
+	switch r.Method {
+	case "GET":
+		glog.V(5).Infof(apiLogString(fmt.Sprintf("Handling %v on resource %v", r.Method, resource)))
+
+		if volumes, err := container.GetServiceVolumes(a.db, a.Config); err != nil {
+			errorhandler(NewSystemError(fmt.Sprintf("Error getting the service volumes, error %v", err)))
+		} else {
+			writeResponse(w, volumes, http.StatusOK)
+		}
+
+	case "DELETE":
+		glog.V(5).Infof(apiLogString(fmt.Sprintf("Handling %v on resource %v", r.Method, resource)))
+
+		// Delete the volumes kept after the end of their agreement, the volumes in use are not affected. The "name"
+		// query parameters select the volumes to delete.
+		names := r.URL.Query()["name"]
+		if pruned, err := container.PruneServiceVolumes(a.db, a.Config, names); err != nil {
+			errorhandler(NewSystemError(fmt.Sprintf("Error pruning the service volumes, error %v", err)))
+		} else {
+			writeResponse(w, pruned, http.StatusOK)
+		}
+
+	case "OPTIONS":
+		w.Header().Set("Allow", "GET, DELETE, OPTIONS")
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
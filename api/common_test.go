@@ -6,6 +6,7 @@ import (
 	"github.com/open-horizon/anax/config"
 	"github.com/open-horizon/anax/exchange"
 	"github.com/open-horizon/anax/exchangecommon"
+	"github.com/open-horizon/anax/externalpolicy"
 	"github.com/open-horizon/anax/policy"
 	"io/ioutil"
 	"os"
@@ -234,6 +235,12 @@ func getDummyPutNodePolicyHandler() exchange.PutNodePolicyHandler {
 	}
 }
 
+func getDummyPropertySchemaHandler(schema *externalpolicy.PropertySchema) exchange.PropertySchemaHandler {
+	return func(org string) (*externalpolicy.PropertySchema, error) {
+		return schema, nil
+	}
+}
+
 func getDummyNodePolicyHandler(ep *exchangecommon.NodePolicy) exchange.NodePolicyHandler {
 	return func(deviceId string) (*exchange.ExchangeNodePolicy, error) {
 		if ep != nil {
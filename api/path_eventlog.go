@@ -7,13 +7,17 @@ import (
 	"github.com/open-horizon/anax/eventlog"
 	"github.com/open-horizon/anax/persistence"
 	"golang.org/x/text/message"
-	"sort"
 )
 
 // This API returns the event logs saved on the db.
 func FindEventLogsForOutput(db *bolt.DB, all_logs bool, selections map[string][]string, msgPrinter *message.Printer) ([]persistence.EventLog, error) {
+	return FindEventLogsPageForOutput(db, all_logs, selections, persistence.EventLogPage{}, msgPrinter)
+}
+
+// This API returns a page of the event logs saved on the db, in record id order.
+func FindEventLogsPageForOutput(db *bolt.DB, all_logs bool, selections map[string][]string, page persistence.EventLogPage, msgPrinter *message.Printer) ([]persistence.EventLog, error) {
 
-	glog.V(5).Infof(apiLogString(fmt.Sprintf("Getting event logs from the db. The selectors are: %v.", selections)))
+	glog.V(5).Infof(apiLogString(fmt.Sprintf("Getting event logs from the db. The selectors are: %v. The page is: %v.", selections, page)))
 
 	//convert to selectors
 	s, err := persistence.ConvertToSelectors(selections)
@@ -24,12 +28,19 @@ func FindEventLogsForOutput(db *bolt.DB, all_logs bool, selections map[string][]
 	}
 
 	// get the event logs
-	if event_logs, err := eventlog.GetEventLogs(db, all_logs, s, msgPrinter); err != nil {
-		return nil, err
-	} else {
-		sort.Sort(EventLogByRecordId(event_logs))
-		return event_logs, nil
+	return eventlog.GetEventLogsPage(db, all_logs, s, page, msgPrinter)
+}
+
+// This API returns the event logs saved after the since record id which match the selections, in record id order.
+func FindEventLogsSinceForOutput(db *bolt.DB, all_logs bool, since uint64, selections map[string][]string, msgPrinter *message.Printer) ([]persistence.EventLog, error) {
+
+	s := make(map[string][]string, len(selections)+1)
+	for attr, vals := range selections {
+		s[attr] = vals
 	}
+	s["record_id"] = append(append([]string{}, selections["record_id"]...), fmt.Sprintf(">%v", since))
+
+	return FindEventLogsForOutput(db, all_logs, s, msgPrinter)
 }
 
 func FindSurfaceLogsForOutput(db *bolt.DB, msgPrinter *message.Printer) ([]persistence.SurfaceError, error) {
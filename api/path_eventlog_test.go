@@ -115,4 +115,82 @@ func Test_FindEventLogsForOutput(t *testing.T) {
 		assert.Equal(t, 4, len(elogs), "Test FindEventLogsForOutput with selection.")
 	}
 
+	if last, err := persistence.GetLastEventLogRecordId(db); err != nil {
+		t.Errorf("error getting the last event log record id: %v", err)
+	} else {
+		assert.Equal(t, uint64(12), last, "Test GetLastEventLogRecordId.")
+	}
+
+	if elogs, err := FindEventLogsSinceForOutput(db, true, 10, map[string][]string{}, msgPrinter); err != nil {
+		t.Errorf("error getting event logs: %v", err)
+	} else {
+		assert.Equal(t, 2, len(elogs), "Test FindEventLogsSinceForOutput without selection.")
+		assert.Equal(t, "11", elogs[0].Id, "Test FindEventLogsSinceForOutput without selection.")
+	}
+
+	if elogs, err := FindEventLogsSinceForOutput(db, true, 9, map[string][]string{"severity": {"error"}}, msgPrinter); err != nil {
+		t.Errorf("error getting event logs: %v", err)
+	} else {
+		assert.Equal(t, 2, len(elogs), "Test FindEventLogsSinceForOutput with selection.")
+	}
+
+	if elogs, err := FindEventLogsPageForOutput(db, true, map[string][]string{}, persistence.EventLogPage{Offset: 2, Limit: 3}, msgPrinter); err != nil {
+		t.Errorf("error getting event logs: %v", err)
+	} else {
+		assert.Equal(t, 3, len(elogs), "Test FindEventLogsPageForOutput with offset and limit.")
+		assert.Equal(t, "3", elogs[0].Id, "Test FindEventLogsPageForOutput with offset and limit.")
+		assert.Equal(t, "5", elogs[2].Id, "Test FindEventLogsPageForOutput with offset and limit.")
+	}
+
+	if elogs, err := FindEventLogsPageForOutput(db, true, map[string][]string{"agreement_id": {"agreementId1"}}, persistence.EventLogPage{Limit: 2, Descending: true}, msgPrinter); err != nil {
+		t.Errorf("error getting event logs: %v", err)
+	} else {
+		assert.Equal(t, 2, len(elogs), "Test FindEventLogsPageForOutput with selection in descending order.")
+		assert.Equal(t, "12", elogs[0].Id, "Test FindEventLogsPageForOutput with selection in descending order.")
+		assert.Equal(t, "10", elogs[1].Id, "Test FindEventLogsPageForOutput with selection in descending order.")
+	}
+
+	if elogs, err := FindEventLogsPageForOutput(db, true, map[string][]string{}, persistence.EventLogPage{Offset: 20}, msgPrinter); err != nil {
+		t.Errorf("error getting event logs: %v", err)
+	} else {
+		assert.Equal(t, 0, len(elogs), "Test FindEventLogsPageForOutput with offset past the end.")
+	}
+
+	// keep the newest 5 event logs
+	if removed, err := eventlog.CompactEventLogs(db, 0, 5); err != nil {
+		t.Errorf("error compacting event logs: %v", err)
+	} else if elogs, err := FindEventLogsForOutput(db, true, map[string][]string{}, msgPrinter); err != nil {
+		t.Errorf("error getting event logs: %v", err)
+	} else {
+		assert.Equal(t, 7, removed, "Test CompactEventLogs with a maximum number of records.")
+		assert.Equal(t, 5, len(elogs), "Test CompactEventLogs with a maximum number of records.")
+		assert.Equal(t, "8", elogs[0].Id, "Test CompactEventLogs with a maximum number of records.")
+	}
+
+	// all the event logs are newer than the maximum age
+	if removed, err := eventlog.CompactEventLogs(db, 3600, 0); err != nil {
+		t.Errorf("error compacting event logs: %v", err)
+	} else {
+		assert.Equal(t, 0, removed, "Test CompactEventLogs with a maximum age.")
+	}
+
+}
+
+func Test_getEventLogPage(t *testing.T) {
+
+	msgPrinter := i18n.GetMessagePrinterWithLocale("en")
+
+	form := map[string][]string{"limit": {"10"}, "offset": {"5"}, "sort": {"desc"}, "severity": {"error"}}
+	if page, err := getEventLogPage(form, msgPrinter); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	} else {
+		assert.Equal(t, persistence.EventLogPage{Offset: 5, Limit: 10, Descending: true}, page)
+		assert.Equal(t, map[string][]string{"severity": {"error"}}, form, "The paging parameters should be removed from the selections.")
+	}
+
+	for _, f := range []map[string][]string{{"limit": {"-1"}}, {"offset": {"abc"}}, {"sort": {"up"}}} {
+		if _, err := getEventLogPage(f, msgPrinter); err == nil {
+			t.Errorf("expected an error for %v", f)
+		}
+	}
 }
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"github.com/boltdb/bolt"
+	"github.com/open-horizon/anax/persistence"
+	"github.com/open-horizon/anax/worker"
+	"strings"
+)
+
+// The names of the checks that make up the readiness of the agent.
+const HEALTH_CHECK_REGISTRATION = "registration"
+const HEALTH_CHECK_EXCHANGE = "exchange"
+const HEALTH_CHECK_CONTAINER_RUNTIME = "container_runtime"
+const HEALTH_CHECK_ESS = "ess"
+const HEALTH_CHECK_WORKERS = "workers"
+
+// The result of a single readiness check.
+type HealthCheck struct {
+	Name    string `json:"name"`
+	Ready   bool   `json:"ready"`
+	Message string `json:"message,omitempty"`
+}
+
+// The output of the /health/ready API. The agent is ready when all of the checks are ready.
+type Readiness struct {
+	Ready  bool          `json:"ready"`
+	Checks []HealthCheck `json:"checks"`
+}
+
+func (r *Readiness) add(name string, err error) {
+	check := HealthCheck{Name: name, Ready: err == nil}
+	if err != nil {
+		check.Message = err.Error()
+		r.Ready = false
+	}
+	r.Checks = append(r.Checks, check)
+}
+
+// The functions that check the external dependencies of the agent. They are passed in so that the readiness logic
+// can be tested without an exchange, a container runtime or the ESS.
+type ReadinessProbes struct {
+	Exchange   func() error // Returns an error if the exchange cannot be reached.
+	Docker     func() error // Returns an error if the docker daemon cannot be reached.
+	Kube       func() error // Returns an error if the kubernetes API server cannot be reached.
+	ESSStarted func() bool  // Returns true if the embedded ESS is running.
+}
+
+// The agent is live as long as it can answer API requests and read its local database.
+func FindLivenessForOutput(db *bolt.DB) error {
+	if _, err := persistence.FindExchangeDevice(db); err != nil {
+		return errors.New(fmt.Sprintf("unable to read the local database, error %v", err))
+	}
+	return nil
+}
+
+// Check if the agent is ready to do work. The node must be registered, the exchange and the container runtime must be
+// reachable, the ESS must be running on a device and none of the workers can have terminated. Workers terminate
+// when the node is unregistered, but then the registration check is not ready either.
+func FindReadinessForOutput(db *bolt.DB, probes ReadinessProbes, statusManager *worker.WorkerStatusManager) *Readiness {
+
+	readiness := &Readiness{Ready: true, Checks: make([]HealthCheck, 0, 5)}
+
+	pDevice, err := persistence.FindExchangeDevice(db)
+	if err != nil {
+		readiness.add(HEALTH_CHECK_REGISTRATION, errors.New(fmt.Sprintf("unable to read node object, error %v", err)))
+	} else if pDevice == nil {
+		readiness.add(HEALTH_CHECK_REGISTRATION, errors.New("the node is not registered"))
+	} else if pDevice.Config.State != persistence.CONFIGSTATE_CONFIGURED {
+		readiness.add(HEALTH_CHECK_REGISTRATION, errors.New(fmt.Sprintf("the node configuration state is %v", pDevice.Config.State)))
+	} else {
+		readiness.add(HEALTH_CHECK_REGISTRATION, nil)
+	}
+
+	if probes.Exchange != nil {
+		readiness.add(HEALTH_CHECK_EXCHANGE, probes.Exchange())
+	}
+
+	// The container runtime and the ESS depend on the type of node, which is not known until the node is registered.
+	if pDevice != nil {
+		if pDevice.IsEdgeCluster() {
+			if probes.Kube != nil {
+				readiness.add(HEALTH_CHECK_CONTAINER_RUNTIME, probes.Kube())
+			}
+		} else {
+			if probes.Docker != nil {
+				readiness.add(HEALTH_CHECK_CONTAINER_RUNTIME, probes.Docker())
+			}
+			if probes.ESSStarted != nil && pDevice.Config.State == persistence.CONFIGSTATE_CONFIGURED {
+				if probes.ESSStarted() {
+					readiness.add(HEALTH_CHECK_ESS, nil)
+				} else {
+					readiness.add(HEALTH_CHECK_ESS, errors.New("the embedded ESS is not started"))
+				}
+			}
+		}
+	}
+
+	if statusManager != nil {
+		if terminated := statusManager.GetTerminatedWorkers(); len(terminated) != 0 {
+			readiness.add(HEALTH_CHECK_WORKERS, errors.New(fmt.Sprintf("workers terminated: %v", strings.Join(terminated, ", "))))
+		} else {
+			readiness.add(HEALTH_CHECK_WORKERS, nil)
+		}
+	}
+
+	return readiness
+}
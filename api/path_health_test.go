@@ -0,0 +1,74 @@
+//go:build unit
+// +build unit
+
+package api
+
+import (
+	"errors"
+	"github.com/open-horizon/anax/persistence"
+	"github.com/open-horizon/anax/worker"
+	"testing"
+)
+
+func Test_FindReadinessForOutput(t *testing.T) {
+
+	dir, db, err := utsetup()
+	if err != nil {
+		t.Error(err)
+	}
+	defer cleanTestDir(dir)
+
+	essStarted := false
+	probes := ReadinessProbes{
+		Exchange:   func() error { return nil },
+		Docker:     func() error { return nil },
+		Kube:       func() error { return errors.New("kube should not be checked on a device") },
+		ESSStarted: func() bool { return essStarted },
+	}
+	statusManager := worker.NewWorkerStatusManager()
+	statusManager.SetWorkerStatus("Agreement", worker.STATUS_INITIALIZED)
+
+	// The node is not registered, so the container runtime and the ESS are not checked.
+	if readiness := FindReadinessForOutput(db, probes, statusManager); readiness.Ready {
+		t.Errorf("an unregistered node should not be ready: %v", readiness)
+	} else if len(readiness.Checks) != 3 || readiness.Checks[0].Name != HEALTH_CHECK_REGISTRATION || readiness.Checks[0].Ready {
+		t.Errorf("wrong checks for an unregistered node: %v", readiness.Checks)
+	}
+
+	if _, err := persistence.SaveNewExchangeDevice(db, "device1", "token", "device1", persistence.DEVICE_TYPE_DEVICE, "myorg", "", persistence.CONFIGSTATE_CONFIGURED, persistence.SoftwareVersion{persistence.AGENT_VERSION: "1.0.0"}); err != nil {
+		t.Errorf("error saving the device: %v", err)
+	}
+
+	// The ESS is not started yet.
+	if readiness := FindReadinessForOutput(db, probes, statusManager); readiness.Ready {
+		t.Errorf("a node without the ESS should not be ready: %v", readiness)
+	} else if len(readiness.Checks) != 5 || readiness.Checks[3].Name != HEALTH_CHECK_ESS || readiness.Checks[3].Ready {
+		t.Errorf("wrong checks for a node without the ESS: %v", readiness.Checks)
+	}
+
+	essStarted = true
+	if readiness := FindReadinessForOutput(db, probes, statusManager); !readiness.Ready {
+		t.Errorf("the node should be ready: %v", readiness)
+	}
+
+	// The exchange is not reachable.
+	probes.Exchange = func() error { return errors.New("exchange is down") }
+	if readiness := FindReadinessForOutput(db, probes, statusManager); readiness.Ready {
+		t.Errorf("a node that cannot reach the exchange should not be ready: %v", readiness)
+	} else if readiness.Checks[1].Name != HEALTH_CHECK_EXCHANGE || readiness.Checks[1].Message != "exchange is down" {
+		t.Errorf("wrong exchange check: %v", readiness.Checks[1])
+	}
+	probes.Exchange = func() error { return nil }
+
+	// A worker has terminated.
+	statusManager.SetWorkerStatus("Agreement", worker.STATUS_TERMINATED)
+	if readiness := FindReadinessForOutput(db, probes, statusManager); readiness.Ready {
+		t.Errorf("a node with a terminated worker should not be ready: %v", readiness)
+	} else if readiness.Checks[4].Name != HEALTH_CHECK_WORKERS || readiness.Checks[4].Ready {
+		t.Errorf("wrong workers check: %v", readiness.Checks[4])
+	}
+
+	if err := FindLivenessForOutput(db); err != nil {
+		t.Errorf("the node should be live: %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"github.com/boltdb/bolt"
+	"github.com/open-horizon/anax/metrics"
+	"github.com/open-horizon/anax/persistence"
+	"github.com/open-horizon/anax/policy"
+)
+
+// The metrics derived from the local database. They are recomputed each time the /metrics API is called.
+const AGENT_AGREEMENTS = "horizon_agent_agreements"
+const AGENT_SERVICE_INSTANCES = "horizon_agent_service_instances"
+const AGENT_SERVICE_RETRIES = "horizon_agent_service_instance_retries"
+
+func init() {
+	r := metrics.DefaultRegistry
+	r.NewGauge(AGENT_AGREEMENTS, "Number of agreements on this node, by state.")
+	r.NewGauge(AGENT_SERVICE_INSTANCES, "Number of dependent service instances on this node, by state.")
+	r.NewGauge(AGENT_SERVICE_RETRIES, "Number of times a dependent service instance has been restarted within its current retry window.")
+}
+
+// The states that an agreement can be in, as reported by the metrics.
+const AG_STATE_PROPOSED = "proposed"
+const AG_STATE_ACCEPTED = "accepted"
+const AG_STATE_FINALIZED = "finalized"
+const AG_STATE_EXECUTING = "executing"
+const AG_STATE_TERMINATING = "terminating"
+const AG_STATE_ARCHIVED = "archived"
+
+func agreementState(ag *persistence.EstablishedAgreement) string {
+	if ag.Archived {
+		return AG_STATE_ARCHIVED
+	} else if ag.AgreementTerminatedTime != 0 {
+		return AG_STATE_TERMINATING
+	} else if ag.AgreementExecutionStartTime != 0 {
+		return AG_STATE_EXECUTING
+	} else if ag.AgreementFinalizedTime != 0 {
+		return AG_STATE_FINALIZED
+	} else if ag.AgreementAcceptedTime != 0 {
+		return AG_STATE_ACCEPTED
+	}
+	return AG_STATE_PROPOSED
+}
+
+// The states that a dependent service instance can be in, as reported by the metrics.
+const MS_STATE_STARTING = "starting"
+const MS_STATE_RUNNING = "running"
+const MS_STATE_FAILED = "failed"
+const MS_STATE_CLEANUP = "cleanup"
+
+func serviceInstanceState(msi *persistence.MicroserviceInstance) string {
+	if msi.CleanupStartTime != 0 {
+		return MS_STATE_CLEANUP
+	} else if msi.ExecutionFailureCode != 0 {
+		return MS_STATE_FAILED
+	} else if msi.ExecutionStartTime != 0 {
+		return MS_STATE_RUNNING
+	}
+	return MS_STATE_STARTING
+}
+
+// Recompute the gauges that are derived from the local database.
+func CollectAgentMetrics(db *bolt.DB, r *metrics.Registry) error {
+
+	agreements, err := persistence.FindEstablishedAgreementsAllProtocols(db, policy.AllAgreementProtocols(), []persistence.EAFilter{})
+	if err != nil {
+		return errors.New(fmt.Sprintf("unable to read agreement objects, error %v", err))
+	}
+
+	// Always report every state so that a state dropping to zero is visible to the scraper.
+	counts := map[string]int{AG_STATE_PROPOSED: 0, AG_STATE_ACCEPTED: 0, AG_STATE_FINALIZED: 0, AG_STATE_EXECUTING: 0, AG_STATE_TERMINATING: 0, AG_STATE_ARCHIVED: 0}
+	for _, ag := range agreements {
+		counts[agreementState(&ag)] += 1
+	}
+	for state, num := range counts {
+		r.SetGauge(AGENT_AGREEMENTS, metrics.Labels{"state": state}, float64(num))
+	}
+
+	msInstances, err := persistence.FindMicroserviceInstances(db, []persistence.MIFilter{persistence.UnarchivedMIFilter()})
+	if err != nil {
+		return errors.New(fmt.Sprintf("unable to read service instance objects, error %v", err))
+	}
+
+	r.ResetFamily(AGENT_SERVICE_INSTANCES)
+	r.ResetFamily(AGENT_SERVICE_RETRIES)
+	msCounts := map[string]int{MS_STATE_STARTING: 0, MS_STATE_RUNNING: 0, MS_STATE_FAILED: 0, MS_STATE_CLEANUP: 0}
+	for _, msi := range msInstances {
+		msCounts[serviceInstanceState(&msi)] += 1
+
+		// The original execution is counted as the first one in the current retry count.
+		retries := 0
+		if msi.CurrentRetryCount > 1 {
+			retries = int(msi.CurrentRetryCount) - 1
+		}
+		r.AddGauge(AGENT_SERVICE_RETRIES, metrics.Labels{"org": msi.Org, "url": msi.SpecRef, "version": msi.Version}, float64(retries))
+	}
+	for state, num := range msCounts {
+		r.SetGauge(AGENT_SERVICE_INSTANCES, metrics.Labels{"state": state}, float64(num))
+	}
+
+	return nil
+}
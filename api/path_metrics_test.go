@@ -0,0 +1,63 @@
+//go:build unit
+// +build unit
+
+package api
+
+import (
+	"github.com/open-horizon/anax/metrics"
+	"github.com/open-horizon/anax/persistence"
+	"testing"
+)
+
+func Test_CollectAgentMetrics(t *testing.T) {
+
+	dir, db, err := utsetup()
+	if err != nil {
+		t.Error(err)
+	}
+	defer cleanTestDir(dir)
+
+	// Agreement 1 is executing, agreement2 is archived, agreement3 is terminating and agreement4 is just proposed.
+	sp := persistence.ServiceSpec{Url: "http://sensor.org", Org: "myorg"}
+	sps := []persistence.ServiceSpec{sp}
+
+	wi, _ := persistence.NewWorkloadInfo("url", "org", "version", "")
+	for _, id := range []string{"agreementId1", "agreementId2", "agreementId3", "agreementId4"} {
+		if _, err := persistence.NewEstablishedAgreement(db, "name1", id, "consumerId", "{}", "Basic", 1, sps, "signature", "address", "bcType", "bcName", "bcOrg", wi, 180); err != nil {
+			t.Errorf("error writing agreement %v: %v", id, err)
+		}
+	}
+	if _, err := persistence.AgreementStateExecutionStarted(db, "agreementId1", "Basic"); err != nil {
+		t.Errorf("error starting agreement1: %v", err)
+	} else if _, err := persistence.ArchiveEstablishedAgreement(db, "agreementId2", "Basic"); err != nil {
+		t.Errorf("error archiving agreement2: %v", err)
+	} else if _, err := persistence.AgreementStateTerminated(db, "agreementId3", 100, "unit test termination", "Basic"); err != nil {
+		t.Errorf("error terminating agreement3: %v", err)
+	}
+
+	r := metrics.NewRegistry()
+	r.NewGauge(AGENT_AGREEMENTS, "test")
+	r.NewGauge(AGENT_SERVICE_INSTANCES, "test")
+	r.NewGauge(AGENT_SERVICE_RETRIES, "test")
+
+	if err := CollectAgentMetrics(db, r); err != nil {
+		t.Errorf("error collecting metrics: %v", err)
+	}
+
+	expected := map[string]float64{
+		AG_STATE_EXECUTING:   1,
+		AG_STATE_ARCHIVED:    1,
+		AG_STATE_TERMINATING: 1,
+		AG_STATE_PROPOSED:    1,
+		AG_STATE_FINALIZED:   0,
+	}
+	for state, num := range expected {
+		if v, ok := r.Value(AGENT_AGREEMENTS, metrics.Labels{"state": state}); !ok || v != num {
+			t.Errorf("expected %v agreements in state %v, have %v", num, state, v)
+		}
+	}
+
+	if v, ok := r.Value(AGENT_SERVICE_INSTANCES, metrics.Labels{"state": MS_STATE_RUNNING}); !ok || v != 0 {
+		t.Errorf("expected no running service instances, have %v", v)
+	}
+}
@@ -29,6 +29,7 @@ func UpdateNodePolicy(nodePolicy *exchangecommon.NodePolicy,
 	errorhandler DeviceErrorHandler,
 	nodeGetPolicyHandler exchange.NodePolicyHandler,
 	nodePutPolicyHandler exchange.PutNodePolicyHandler,
+	propertySchemaHandler exchange.PropertySchemaHandler,
 	db *bolt.DB) (bool, *exchangecommon.NodePolicy, []*events.NodePolicyMessage) {
 
 	// Check for the device in the local database. If there are errors, they will be written
@@ -40,7 +41,7 @@ func UpdateNodePolicy(nodePolicy *exchangecommon.NodePolicy,
 		return errorhandler(nil, NewNotFoundError("Exchange registration not recorded. Complete account and node registration with an exchange and then record node registration using this API's /node path.", "node")), nil, nil
 	}
 
-	if rc_deploy, rc_management, err := exchangesync.UpdateNodePolicy(pDevice, db, nodePolicy, nodeGetPolicyHandler, nodePutPolicyHandler); err != nil {
+	if rc_deploy, rc_management, err := exchangesync.UpdateNodePolicy(pDevice, db, nodePolicy, nodeGetPolicyHandler, nodePutPolicyHandler, propertySchemaHandler); err != nil {
 		return errorhandler(pDevice, NewSystemError(fmt.Sprintf("Unable to sync the local db with the exchange node policy. %v", err))), nil, nil
 	} else {
 		LogDeviceEvent(db, persistence.SEVERITY_INFO, persistence.NewMessageMeta(EL_API_NEW_NODE_POL, *nodePolicy), persistence.EC_NODE_POLICY_UPDATED, pDevice)
@@ -55,6 +56,7 @@ func PatchNodePolicy(attributeName string, patchObject interface{},
 	errorhandler DeviceErrorHandler,
 	nodeGetPolicyHandler exchange.NodePolicyHandler,
 	nodePatchPolicyHandler exchange.PutNodePolicyHandler,
+	propertySchemaHandler exchange.PropertySchemaHandler,
 	db *bolt.DB) (bool, *exchangecommon.NodePolicy, []*events.NodePolicyMessage) {
 
 	pDevice, err := persistence.FindExchangeDevice(db)
@@ -64,7 +66,7 @@ func PatchNodePolicy(attributeName string, patchObject interface{},
 		return errorhandler(nil, NewNotFoundError("Exchange registration not recorded. Complete account and node registration with an exchange and then record node registration using this API's /node path.", "node")), nil, nil
 	}
 
-	if rc_deploy, rc_management, nodePolicy, err := exchangesync.PatchNodePolicy(pDevice, db, attributeName, patchObject, nodeGetPolicyHandler, nodePatchPolicyHandler); err != nil {
+	if rc_deploy, rc_management, nodePolicy, err := exchangesync.PatchNodePolicy(pDevice, db, attributeName, patchObject, nodeGetPolicyHandler, nodePatchPolicyHandler, propertySchemaHandler); err != nil {
 		return errorhandler(pDevice, NewSystemError(fmt.Sprintf("Unable to sync the local db with the exchange node policy. %v", err))), nil, nil
 	} else {
 		LogDeviceEvent(db, persistence.SEVERITY_INFO, persistence.NewMessageMeta(EL_API_NEW_NODE_POL, patchObject), persistence.EC_NODE_POLICY_UPDATED, pDevice)
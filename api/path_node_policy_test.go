@@ -93,7 +93,7 @@ func Test_SaveNodePolicy1(t *testing.T) {
 
 	ExchangeNodePolicyLastUpdated = ""
 
-	errHandled, np, msgs := UpdateNodePolicy(extNodePolicy, node_policy_error_handler, getDummyNodePolicyHandler(extNodePolicy), getDummyPutNodePolicyHandler(), db)
+	errHandled, np, msgs := UpdateNodePolicy(extNodePolicy, node_policy_error_handler, getDummyNodePolicyHandler(extNodePolicy), getDummyPutNodePolicyHandler(), getDummyPropertySchemaHandler(nil), db)
 
 	if errHandled {
 		t.Errorf("Unexpected error handled: %v", myError)
@@ -159,7 +159,7 @@ func Test_UpdateNodePolicy1(t *testing.T) {
 	}
 	ExchangeNodePolicyLastUpdated = ""
 
-	errHandled, np, msgs := UpdateNodePolicy(extNodePolicy, node_policy_error_handler, getDummyNodePolicyHandler(extNodePolicy), getDummyPutNodePolicyHandler(), db)
+	errHandled, np, msgs := UpdateNodePolicy(extNodePolicy, node_policy_error_handler, getDummyNodePolicyHandler(extNodePolicy), getDummyPutNodePolicyHandler(), getDummyPropertySchemaHandler(nil), db)
 
 	if errHandled {
 		t.Errorf("Unexpected error handled: %v", myError)
@@ -184,7 +184,7 @@ func Test_UpdateNodePolicy1(t *testing.T) {
 
 	extNodePolicy.Properties = *propList
 
-	errHandled, np, msgs = UpdateNodePolicy(extNodePolicy, node_policy_error_handler, getDummyNodePolicyHandler(extNodePolicy), getDummyPutNodePolicyHandler(), db)
+	errHandled, np, msgs = UpdateNodePolicy(extNodePolicy, node_policy_error_handler, getDummyNodePolicyHandler(extNodePolicy), getDummyPutNodePolicyHandler(), getDummyPropertySchemaHandler(nil), db)
 
 	if errHandled {
 		t.Errorf("Unexpected error handled: %v", myError)
@@ -237,7 +237,7 @@ func Test_DeleteNodePolicy1(t *testing.T) {
 
 	ExchangeNodePolicyLastUpdated = ""
 
-	errHandled, np, msgs := UpdateNodePolicy(extNodePolicy, node_policy_error_handler, getDummyNodePolicyHandler(extNodePolicy), getDummyPutNodePolicyHandler(), db)
+	errHandled, np, msgs := UpdateNodePolicy(extNodePolicy, node_policy_error_handler, getDummyNodePolicyHandler(extNodePolicy), getDummyPutNodePolicyHandler(), getDummyPropertySchemaHandler(nil), db)
 
 	if errHandled {
 		t.Errorf("Unexpected error handled: %v", myError)
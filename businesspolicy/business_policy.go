@@ -23,10 +23,12 @@ type BusinessPolicy struct {
 	Constraints   externalpolicy.ConstraintExpression `json:"constraints,omitempty"`
 	UserInput     []policy.UserInput                  `json:"userInput,omitempty"`
 	SecretBinding []exchangecommon.SecretBinding      `json:"secretBinding,omitempty"` // The secret binding from service secret names to secret manager secret names.
+	Placement     *policy.Placement                   `json:"placement,omitempty"`     // The weighted placement of the service on the compatible nodes.
+	Maintenance   *exchangecommon.MaintenanceSchedule `json:"maintenance,omitempty"`   // When the agbot can upgrade the service on the nodes.
 }
 
 func (w BusinessPolicy) String() string {
-	return fmt.Sprintf("Owner: %v, Label: %v, Description: %v, Service: %v, Properties: %v, Constraints: %v, UserInput: %v, SecretBinding: %v",
+	return fmt.Sprintf("Owner: %v, Label: %v, Description: %v, Service: %v, Properties: %v, Constraints: %v, UserInput: %v, SecretBinding: %v, Placement: %v, Maintenance: %v",
 		w.Owner,
 		w.Label,
 		w.Description,
@@ -34,7 +36,9 @@ func (w BusinessPolicy) String() string {
 		w.Properties,
 		w.Constraints,
 		w.UserInput,
-		w.SecretBinding)
+		w.SecretBinding,
+		w.Placement,
+		w.Maintenance)
 }
 
 type ServiceRef struct {
@@ -43,15 +47,17 @@ type ServiceRef struct {
 	Arch            string           `json:"arch,omitempty"`            // the hardware architecture of the service definition
 	ServiceVersions []WorkloadChoice `json:"serviceVersions,omitempty"` // a list of service version for rollback
 	NodeH           NodeHealth       `json:"nodeHealth"`                // policy for determining when a node's health is violating its agreements
+	Rollout         *policy.Rollout  `json:"rollout,omitempty"`         // the staged rollout of a higher priority service version to the nodes
 }
 
 func (w ServiceRef) String() string {
-	return fmt.Sprintf("Name: %v, Org: %v, Arch: %v, ServiceVersions: %v, NodeH: %v",
+	return fmt.Sprintf("Name: %v, Org: %v, Arch: %v, ServiceVersions: %v, NodeH: %v, Rollout: %v",
 		w.Name,
 		w.Org,
 		w.Arch,
 		w.ServiceVersions,
-		w.NodeH)
+		w.NodeH,
+		w.Rollout)
 }
 
 type WorkloadPriority struct {
@@ -140,6 +146,21 @@ func (b *BusinessPolicy) Validate() error {
 		}
 	}
 
+	// Validate the staged rollout.
+	if err := b.Service.Rollout.Validate(); err != nil {
+		return fmt.Errorf(msgPrinter.Sprintf("rollout is not valid: %v", err))
+	}
+
+	// Validate the weighted placement.
+	if err := b.Placement.Validate(); err != nil {
+		return fmt.Errorf(msgPrinter.Sprintf("placement is not valid: %v", err))
+	}
+
+	// Validate the maintenance windows.
+	if err := b.Maintenance.Validate(); err != nil {
+		return fmt.Errorf(msgPrinter.Sprintf("maintenance is not valid: %v", err))
+	}
+
 	// Validate the Constraints expression by invoking the plugins.
 	if b != nil && len(b.Constraints) != 0 {
 		_, err := b.Constraints.Validate()
@@ -166,6 +187,40 @@ func (b *BusinessPolicy) HasNoConstraints() bool {
 	return true
 }
 
+// Return the problems in the policy that do not prevent it from validating. The catalog holds the properties
+// published by the nodes the policy is checked against, it can be nil.
+func (b *BusinessPolicy) Lint(catalog externalpolicy.PropertyCatalog) []externalpolicy.LintIssue {
+	issues := externalpolicy.LintProperties(b.Properties)
+	issues = append(issues, externalpolicy.LintConstraints(&b.Constraints, catalog)...)
+	return append(issues, b.Service.LintServiceVersions()...)
+}
+
+// The service versions are tried in priority order when an agreement fails. A version that is listed more
+// than once, or 2 versions with the same priority, make that order ambiguous.
+func (s ServiceRef) LintServiceVersions() []externalpolicy.LintIssue {
+	msgPrinter := i18n.GetMessagePrinter()
+
+	issues := []externalpolicy.LintIssue{}
+	versions := map[string]bool{}
+	priorities := map[int]string{}
+	for _, wl := range s.ServiceVersions {
+		if versions[wl.Version] {
+			issues = append(issues, externalpolicy.NewLintIssue(externalpolicy.LINT_ERROR, externalpolicy.LINT_SERVICE_PRIORITIES,
+				msgPrinter.Sprintf("The version %v of service %v is listed more than once in serviceVersions.", wl.Version, s.Name)))
+			continue
+		}
+		versions[wl.Version] = true
+
+		if other, ok := priorities[wl.Priority.PriorityValue]; ok {
+			issues = append(issues, externalpolicy.NewLintIssue(externalpolicy.LINT_ERROR, externalpolicy.LINT_SERVICE_PRIORITIES,
+				msgPrinter.Sprintf("The versions %v and %v of service %v have the same priority %v.", other, wl.Version, s.Name, wl.Priority.PriorityValue)))
+		} else {
+			priorities[wl.Priority.PriorityValue] = wl.Version
+		}
+	}
+	return issues
+}
+
 // Convert business policy to a policy object.
 func (b *BusinessPolicy) GenPolicyFromBusinessPolicy(policyName string) (*policy.Policy, error) {
 
@@ -196,6 +251,9 @@ func (b *BusinessPolicy) GenPolicyFromBusinessPolicy(policyName string) (*policy
 	// node health
 	ConvertNodeHealth(service.NodeH, pol)
 
+	// staged rollout
+	pol.Rollout = service.Rollout.DeepCopy()
+
 	pol.MaxAgreements = DEFAULT_MAX_AGREEMENT
 
 	// add default agreement protocol
@@ -207,6 +265,12 @@ func (b *BusinessPolicy) GenPolicyFromBusinessPolicy(policyName string) (*policy
 	pol.UserInput = make([]policy.UserInput, len(b.UserInput))
 	copy(pol.UserInput, b.UserInput)
 
+	// make a copy of the weighted placement
+	pol.Placement = b.Placement.DeepCopy()
+
+	// make a copy of the maintenance windows
+	pol.Maintenance = b.Maintenance.DeepCopy()
+
 	// make a copy of the secretBindings
 	pol.SecretBinding = make([]exchangecommon.SecretBinding, 0)
 	for _, sb := range b.SecretBinding {
@@ -321,3 +321,30 @@ func Test_GenPolicyFromBusinessPolicy_Complicated(t *testing.T) {
 		t.Errorf("Second user input variable value for service cpu should be val2 but got %v.", pPolicy.UserInput[0].Inputs[1].Value)
 	}
 }
+
+func Test_LintServiceVersions(t *testing.T) {
+
+	service := ServiceRef{
+		Name: "cpu",
+		Org:  "mycomp",
+		ServiceVersions: []WorkloadChoice{
+			{Version: "1.0.0", Priority: WorkloadPriority{PriorityValue: 1}},
+			{Version: "2.0.0", Priority: WorkloadPriority{PriorityValue: 2}},
+		},
+	}
+	if issues := service.LintServiceVersions(); len(issues) != 0 {
+		t.Errorf("The service versions should not have issues but got: %v", issues)
+	}
+
+	// same priority for 2 versions
+	service.ServiceVersions = append(service.ServiceVersions, WorkloadChoice{Version: "3.0.0", Priority: WorkloadPriority{PriorityValue: 2}})
+	if issues := service.LintServiceVersions(); len(issues) != 1 || issues[0].Check != externalpolicy.LINT_SERVICE_PRIORITIES {
+		t.Errorf("The service versions should have 1 priority issue but got: %v", issues)
+	}
+
+	// same version listed twice
+	service.ServiceVersions[2] = WorkloadChoice{Version: "1.0.0", Priority: WorkloadPriority{PriorityValue: 3}}
+	if issues := service.LintServiceVersions(); len(issues) != 1 || !strings.Contains(issues[0].Message, "more than once") {
+		t.Errorf("The service versions should have 1 duplicate version issue but got: %v", issues)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/open-horizon/anax/events"
 	"github.com/open-horizon/anax/exchange"
 	"github.com/open-horizon/anax/i18n"
+	"github.com/open-horizon/anax/metrics"
 	"github.com/open-horizon/anax/persistence"
 	"github.com/open-horizon/anax/policy"
 	"github.com/open-horizon/anax/version"
@@ -30,6 +31,17 @@ const (
 	POLL_INTERVAL_ALERT_LEVEL = 2
 )
 
+// Metrics that track the health of the node heartbeat.
+const HEARTBEAT_FAILURES = "horizon_exchange_heartbeat_failures_total"
+const HEARTBEAT_FAILED = "horizon_exchange_heartbeat_failed"
+const HEARTBEAT_LAST_SUCCESS = "horizon_exchange_heartbeat_last_success_timestamp_seconds"
+
+func init() {
+	metrics.DefaultRegistry.NewCounter(HEARTBEAT_FAILURES, "Number of failed node heartbeats to the exchange.")
+	metrics.DefaultRegistry.NewGauge(HEARTBEAT_FAILED, "Set to 1 when the node heartbeat has been failing for longer than the configured grace period.")
+	metrics.DefaultRegistry.NewGauge(HEARTBEAT_LAST_SUCCESS, "Unix time of the last successful node heartbeat.")
+}
+
 type ChangesWorker struct {
 	worker.BaseWorker      // embedded field
 	db                     *bolt.DB
@@ -364,6 +376,7 @@ func (w *ChangesWorker) postProcessChanges(changes *exchange.ExchangeChanges, in
 func (w *ChangesWorker) handleHeartbeatStateAndError(changes *exchange.ExchangeChanges, err error) bool {
 	if err != nil {
 		glog.Errorf(chglog(fmt.Sprintf("heartbeat and change retrieval failed, error %v", err)))
+		metrics.IncCounter(HEARTBEAT_FAILURES, nil)
 
 		if strings.Contains(err.Error(), "status: 401") {
 			// If the heartbeat fails because the node entry is gone then initiate a full node quiesce.
@@ -381,6 +394,7 @@ func (w *ChangesWorker) handleHeartbeatStateAndError(changes *exchange.ExchangeC
 			// that there is a heartbeat problem.
 			if !w.heartBeatFailed && time.Since(time.Unix(w.lastHeartbeat, 0)).Seconds() > float64(w.Config.Edge.ExchangeHeartbeat) {
 				w.heartBeatFailed = true
+				metrics.SetGauge(HEARTBEAT_FAILED, nil, 1)
 
 				eventlog.LogNodeEvent(w.db, persistence.SEVERITY_ERROR,
 					persistence.NewMessageMeta(EL_AG_NODE_HB_FAILED, exchange.GetOrg(w.GetExchangeId()), exchange.GetId(w.GetExchangeId()), err.Error()),
@@ -393,6 +407,7 @@ func (w *ChangesWorker) handleHeartbeatStateAndError(changes *exchange.ExchangeC
 	} else {
 		// Record the last good heartbeat
 		w.lastHeartbeat = time.Now().Unix()
+		metrics.SetGauge(HEARTBEAT_LAST_SUCCESS, nil, float64(w.lastHeartbeat))
 
 		if w.pollHBRestoredInterval != 0 {
 			w.updatePollingInterval(UPDATE_TYPE_HB_RESTORED)
@@ -403,6 +418,7 @@ func (w *ChangesWorker) handleHeartbeatStateAndError(changes *exchange.ExchangeC
 			// Let other workers know that the heartbeat is restored. The message is sent out only when the heartbeat state
 			// changes from failed to successful.
 			w.heartBeatFailed = false
+			metrics.SetGauge(HEARTBEAT_FAILED, nil, 0)
 
 			glog.V(3).Infof(chglog(fmt.Sprintf("node heartbeat restored")))
 			eventlog.LogNodeEvent(w.db, persistence.SEVERITY_INFO,
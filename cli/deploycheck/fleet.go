@@ -0,0 +1,87 @@
+package deploycheck
+
+import (
+	"flag"
+	"fmt"
+	"github.com/open-horizon/anax/cli/cliutils"
+	"github.com/open-horizon/anax/compcheck"
+	"github.com/open-horizon/anax/exchangecommon"
+	"github.com/open-horizon/anax/i18n"
+)
+
+// check the deployment compatibility of a deployment policy against all the nodes in an organization.
+func FleetCompatible(org string, userPw string, nodeOrg string, nodeIdPattern string, nodeType string, nodeArch string,
+	businessPolId string, businessPolFile string, servicePolFile string, svcDefFiles []string, checkAllSvcs bool) {
+
+	msgPrinter := i18n.GetMessagePrinter()
+
+	// make sure the node type has correct value
+	ValidateNodeType(nodeType)
+
+	if businessPolId == "" && businessPolFile == "" {
+		cliutils.Fatal(cliutils.CLI_INPUT_ERROR, msgPrinter.Sprintf("One of these flags must be specified: -b or -B."))
+	} else if businessPolId != "" && businessPolFile != "" {
+		cliutils.Fatal(cliutils.CLI_INPUT_ERROR, msgPrinter.Sprintf("-b and -B are mutually exclusive."))
+	}
+
+	// listing all the nodes in an organization requires a user credential
+	orgToUse := GetOrgFromCred(org, userPw)
+
+	// get the deployment policy from file or the exchange
+	bp := getBusinessPolicy(orgToUse, userPw, businessPolId, businessPolFile)
+
+	// check if the given service files specify correct services.
+	_, serviceDefs := useExchangeForServiceDef(svcDefFiles)
+	checkServiceDefsForBPol(bp, serviceDefs, svcDefFiles)
+
+	fleetCheckInput := compcheck.FleetCheck{}
+	fleetCheckInput.BusinessPolicy = bp
+	if businessPolId != "" {
+		fleetCheckInput.BusinessPolId = cliutils.AddOrg(orgToUse, businessPolId)
+	}
+
+	// the deployment policy only applies to the nodes in its own organization
+	if nodeOrg != "" {
+		fleetCheckInput.NodeOrg = nodeOrg
+	} else if businessPolId == "" {
+		fleetCheckInput.NodeOrg = orgToUse
+	}
+
+	if nodeIdPattern != "" || nodeType != "" || nodeArch != "" {
+		fleetCheckInput.NodeFilter = &compcheck.NodeFilter{NodeId: nodeIdPattern, NodeType: nodeType, NodeArch: nodeArch}
+	}
+
+	// read the service policy from file
+	if servicePolFile != "" {
+		var sp exchangecommon.ServicePolicy
+		readServicePolicyFile(servicePolFile, &sp)
+		fleetCheckInput.ServicePolicy = sp.GetExternalPolicy()
+	}
+
+	// put the given service defs into the fleetCheckInput
+	if len(serviceDefs) != 0 {
+		fleetCheckInput.Service = serviceDefs
+	}
+
+	// get exchange context
+	ec := cliutils.GetUserExchangeContext(orgToUse, userPw)
+	agbotUrl := cliutils.GetAgbotSecureAPIUrlBase()
+
+	// compcheck.FleetCompatible function calls the exchange package that calls glog.
+	// set glog to log to /dev/null so glog errors will not be printed
+	flag.Set("log_dir", "/dev/null")
+
+	cliutils.Verbose(msgPrinter.Sprintf("Using fleet compatibility checking input: %v", fleetCheckInput))
+
+	fleetOutput, err := compcheck.FleetCompatible(ec, agbotUrl, &fleetCheckInput, checkAllSvcs, nil, msgPrinter)
+	if err != nil {
+		cliutils.Fatal(cliutils.CLI_GENERAL_ERROR, err.Error())
+	}
+
+	// display the output
+	output, err := cliutils.DisplayAsJson(fleetOutput)
+	if err != nil {
+		cliutils.Fatal(cliutils.JSON_PARSING_ERROR, msgPrinter.Sprintf("failed to marshal 'hzn deploycheck fleet' output: %v", err))
+	}
+	fmt.Println(output)
+}
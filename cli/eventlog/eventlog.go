@@ -6,7 +6,9 @@ import (
 	"github.com/open-horizon/anax/cli/cliutils"
 	"github.com/open-horizon/anax/i18n"
 	"github.com/open-horizon/anax/persistence"
+	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -23,8 +25,10 @@ type EventLog struct {
 
 // This function takes a list of selection strings. validate them and
 // convert them to the format that the the anax api can take.
+// A selection string is attribute, operator and value. The operators are =, ~, >, <, >=, <=, != and !~.
+// The value can have alternatives separated by '|', the operator applies to each of them.
 func getSelectionString(selections []string) (string, error) {
-	valid_sel := regexp.MustCompile(`^([^~=><]+)([~=><])(.*)$`)
+	valid_sel := regexp.MustCompile(`^([^~=><!]+)(!=|!~|>=|<=|[~=><])(.*)$`)
 
 	sels := []string{}
 	for _, v := range selections {
@@ -38,24 +42,39 @@ func getSelectionString(selections []string) (string, error) {
 				val = match[3]
 			}
 
-			if len(op) > 1 {
-				return "", fmt.Errorf(i18n.GetMessagePrinter().Sprintf("The selection string %v is not valid.", v))
-			}
-
-			real_op := ""
+			// the operator that prefixes each alternative of the value, and whether the value is negated
+			alt_op := ""
+			negate := false
 			switch op {
 			case "=":
-				real_op = "="
-			case ">":
-				real_op = "=>"
-			case "<":
-				real_op = "=<"
-			case "~":
-				real_op = "=~"
+				alt_op = ""
+			case "~", ">", "<", ">=", "<=":
+				alt_op = op
+			case "!=":
+				negate = true
+			case "!~":
+				alt_op = "~"
+				negate = true
 			default:
-				real_op = "="
+				return "", fmt.Errorf(i18n.GetMessagePrinter().Sprintf("The selection string %v is not valid.", v))
 			}
-			sels = append(sels, fmt.Sprintf("%v%v%v", attrib, real_op, val))
+
+			real_val := val
+			if alt_op != "" {
+				alts := splitAlternatives(val)
+				for i, alt := range alts {
+					alts[i] = alt_op + alt
+				}
+				real_val = strings.Join(alts, "|")
+			}
+			if negate {
+				real_val = "!" + real_val
+			} else if alt_op == "" && strings.HasPrefix(real_val, "!") {
+				// a literal '!' at the start of the value
+				real_val = "\\" + real_val
+			}
+
+			sels = append(sels, fmt.Sprintf("%v=%v", url.QueryEscape(attrib), url.QueryEscape(real_val)))
 		} else {
 			return "", fmt.Errorf(i18n.GetMessagePrinter().Sprintf("The selection string %v is not valid.", v))
 		}
@@ -63,22 +82,98 @@ func getSelectionString(selections []string) (string, error) {
 	return strings.Join(sels, "&"), nil
 }
 
-func List(all bool, detail bool, selections []string, tailing bool) {
+// Split the value of a selection string on each '|' that is not escaped. The escapes are kept so that the anax api
+// can tell the alternatives apart.
+func splitAlternatives(val string) []string {
+	alts := []string{}
+	start := 0
+	for i := 0; i < len(val); i++ {
+		if val[i] == '\\' && i+1 < len(val) && val[i+1] == '|' {
+			i++
+		} else if val[i] == '|' {
+			alts = append(alts, val[start:i])
+			start = i + 1
+		}
+	}
+	return append(alts, val[start:])
+}
+
+// Convert the filter flags of the 'hzn eventlog list' command into selection strings.
+func GetFilterSelections(severity string, sourceType string, serviceUrl string, agreementId string, nmpName string, since string) []string {
+	sels := []string{}
+	if severity != "" {
+		sels = append(sels, "severity="+severity)
+	}
+	if sourceType != "" {
+		sels = append(sels, "source_type="+sourceType)
+	}
+	if serviceUrl != "" {
+		sels = append(sels, "service_url="+serviceUrl)
+	}
+	if agreementId != "" {
+		sels = append(sels, "agreement_id="+agreementId)
+	}
+	if nmpName != "" {
+		sels = append(sels, "nmp_name~"+nmpName)
+	}
+	if since != "" {
+		if !regexp.MustCompile(`^[0-9]+(\.[0-9]+)?[smhd]$`).MatchString(since) {
+			cliutils.Fatal(cliutils.CLI_INPUT_ERROR, i18n.GetMessagePrinter().Sprintf("The --since value %v is not valid. It must be a number followed by s, m, h or d, for example 30m or 1d.", since))
+		}
+		sels = append(sels, "timestamp>=now-"+since)
+	}
+	return sels
+}
+
+// The number of seconds each request waits for new records when tailing the event log. This is kept short so that the
+// request does not exceed the HTTP request timeout that can be set with HZN_HTTP_TIMEOUT.
+const TAIL_TIMEOUT = 20
+
+// List the event logs. The limit, offset and sort order only apply to the records that are displayed first, the
+// records displayed afterwards when tailing are always the new records in the order they are saved.
+func List(all bool, detail bool, selections []string, tailing bool, limit int, offset int, sortOrder string) {
 
 	// format the eventlog api string
-	url_s := "eventlog"
+	base_url := "eventlog"
 	if all {
-		url_s = fmt.Sprintf("%v/all", url_s)
+		base_url = fmt.Sprintf("%v/all", base_url)
 	}
 
+	sel_s := ""
 	if len(selections) > 0 {
 		if s, err := getSelectionString(selections); err != nil {
 			cliutils.Fatal(cliutils.CLI_INPUT_ERROR, "%v", err)
 		} else {
-			url_s = fmt.Sprintf("%v?%v", url_s, s)
+			sel_s = s
 		}
 	}
 
+	if limit < 0 || offset < 0 {
+		cliutils.Fatal(cliutils.CLI_INPUT_ERROR, i18n.GetMessagePrinter().Sprintf("The limit and offset must not be negative."))
+	}
+
+	parms := []string{}
+	if sel_s != "" {
+		parms = append(parms, sel_s)
+	}
+	if limit != 0 {
+		parms = append(parms, fmt.Sprintf("limit=%v", limit))
+	}
+	if offset != 0 {
+		parms = append(parms, fmt.Sprintf("offset=%v", offset))
+	}
+	if sortOrder != "" {
+		parms = append(parms, fmt.Sprintf("sort=%v", sortOrder))
+	}
+
+	url_s := base_url
+	if len(parms) != 0 {
+		url_s = fmt.Sprintf("%v?%v", url_s, strings.Join(parms, "&"))
+	}
+
+	// the record id of the most recent record displayed when tailing
+	since := ""
+
 	for {
 		// get the eventlog from anax
 		apiOutput := make([]persistence.EventLogRaw, 0)
@@ -122,34 +217,36 @@ func List(all bool, detail bool, selections []string, tailing bool) {
 		}
 
 		if tailing {
-			// selection contraints for most recent records
-			var newselect []string
-
-			// update with inputted selection contraints
-			if len(selections) > 0 {
-				newselect = make([]string, len(selections))
-				copy(newselect, selections)
-			} else {
-				newselect = []string{}
-			}
-
-			// select for most recent records if any
-			if len(apiOutput) > 0 {
-				newselect = append(newselect, fmt.Sprintf("record_id>%v", apiOutput[len(apiOutput)-1].Id))
-				if s, err := getSelectionString(newselect); err != nil {
-					cliutils.Fatal(cliutils.CLI_INPUT_ERROR, "%v", err)
-				} else {
-					url_s = fmt.Sprintf("eventlog?%v", s)
+			// Wait for the records saved after the most recent record that was displayed. The stream API
+			// holds the request until there is a new record or the timeout expires.
+			for _, v := range apiOutput {
+				if since == "" || recordIdGreater(v.Id, since) {
+					since = v.Id
 				}
 			}
-			time.Sleep(1 * time.Second)
-
+			url_s = fmt.Sprintf("%v/stream?timeout=%v", base_url, TAIL_TIMEOUT)
+			if since != "" {
+				url_s = fmt.Sprintf("%v&since=%v", url_s, since)
+			}
+			if sel_s != "" {
+				url_s = fmt.Sprintf("%v&%v", url_s, sel_s)
+			}
 		} else {
 			break
 		}
 	}
 }
 
+// Returns true if record id a is after record id b. Record ids are decimal sequence numbers.
+func recordIdGreater(a string, b string) bool {
+	ia, errA := strconv.ParseUint(a, 10, 64)
+	ib, errB := strconv.ParseUint(b, 10, 64)
+	if errA != nil || errB != nil {
+		return a > b
+	}
+	return ia > ib
+}
+
 func ListSurfaced(long bool) {
 	apiOutput := make([]persistence.SurfaceError, 0)
 	cliutils.HorizonGet("eventlog/surface", []int{200}, &apiOutput, false)
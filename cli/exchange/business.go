@@ -3,6 +3,7 @@ package exchange
 import (
 	"bytes"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"github.com/open-horizon/anax/businesspolicy"
 	"github.com/open-horizon/anax/cli/cliconfig"
@@ -194,6 +195,24 @@ func BusinessUpdatePolicy(org string, credToUse string, policyName string, fileP
 				break
 			}
 		}
+	} else if _, ok := findPatchType["placement"]; ok {
+		placement := make(map[string]*policy.Placement)
+		err = json.Unmarshal([]byte(attribute), &placement)
+		patch = placement
+		if err == nil {
+			if err1 := placement["placement"].Validate(); err1 != nil {
+				cliutils.Fatal(cliutils.CLI_INPUT_ERROR, msgPrinter.Sprintf("Invalid format for placement: %v", err1))
+			}
+		}
+	} else if _, ok := findPatchType["maintenance"]; ok {
+		maintenance := make(map[string]*exchangecommon.MaintenanceSchedule)
+		err = json.Unmarshal([]byte(attribute), &maintenance)
+		patch = maintenance
+		if err == nil {
+			if err1 := maintenance["maintenance"].Validate(); err1 != nil {
+				cliutils.Fatal(cliutils.CLI_INPUT_ERROR, msgPrinter.Sprintf("Invalid format for maintenance: %v", err1))
+			}
+		}
 	} else {
 		_, ok := findPatchType["label"]
 		_, ok2 := findPatchType["description"]
@@ -201,7 +220,7 @@ func BusinessUpdatePolicy(org string, credToUse string, policyName string, fileP
 			patch = make(map[string]string)
 			err = json.Unmarshal([]byte(attribute), &patch)
 		} else {
-			cliutils.Fatal(cliutils.CLI_INPUT_ERROR, msgPrinter.Sprintf("Deployment policy attribute to be updated is not found in the input file. Supported attributes are: label, description, service, properties, constraints, userInput and secretBinding."))
+			cliutils.Fatal(cliutils.CLI_INPUT_ERROR, msgPrinter.Sprintf("Deployment policy attribute to be updated is not found in the input file. Supported attributes are: label, description, service, properties, constraints, userInput, secretBinding, placement and maintenance."))
 		}
 	}
 
@@ -241,6 +260,63 @@ func BusinessRemovePolicy(org string, credToUse string, policy string, force boo
 	}
 }
 
+// BusinessDiffPolicy compares a deployment policy in the Horizon Exchange with a new version of it in a file, and
+// reports the nodes that would lose or gain the workload if the new version replaced the current one.
+func BusinessDiffPolicy(org string, credToUse string, policyName string, jsonFilePath string, diffOnly bool) {
+	cliutils.SetWhetherUsingApiKey(credToUse)
+	var polOrg string
+	polOrg, policyName = cliutils.TrimOrg(org, policyName)
+
+	// get message printer
+	msgPrinter := i18n.GetMessagePrinter()
+
+	// get the current policy from the exchange
+	var exchangePolicy exchange.GetBusinessPolicyResponse
+	httpCode := cliutils.ExchangeGet("Exchange", cliutils.GetExchangeUrl(), "orgs/"+polOrg+"/business/policies"+cliutils.AddSlash(policyName), cliutils.OrgAndCreds(org, credToUse), []int{200, 404}, &exchangePolicy)
+	if httpCode == 404 || len(exchangePolicy.BusinessPolicy) == 0 {
+		cliutils.Fatal(cliutils.NOT_FOUND, msgPrinter.Sprintf("Policy %s not found in org %s", policyName, polOrg))
+	}
+	var oldPolicy businesspolicy.BusinessPolicy
+	for _, exchPol := range exchangePolicy.BusinessPolicy {
+		oldPolicy = exchPol.GetBusinessPolicy()
+		break
+	}
+
+	// read in the new policy from file
+	newBytes := cliconfig.ReadJsonFileWithLocalConfig(jsonFilePath)
+	var newPolicy businesspolicy.BusinessPolicy
+	if err := json.Unmarshal(newBytes, &newPolicy); err != nil {
+		cliutils.Fatal(cliutils.JSON_PARSING_ERROR, msgPrinter.Sprintf("failed to unmarshal json input file %s: %v", jsonFilePath, err))
+	}
+	if err := newPolicy.Validate(); err != nil {
+		cliutils.Fatal(cliutils.CLI_INPUT_ERROR, msgPrinter.Sprintf("Incorrect deployment policy format in file %s: %v", jsonFilePath, err))
+	}
+
+	var output interface{}
+	if diffOnly {
+		output = compcheck.DiffBusinessPolicies(&oldPolicy, &newPolicy)
+	} else {
+		ec := cliutils.GetUserExchangeContext(org, credToUse)
+		agbotUrl := cliutils.GetAgbotSecureAPIUrlBase()
+
+		// compcheck.PolicyChangeImpact function calls the exchange package that calls glog.
+		// set glog to log to /dev/null so glog errors will not be printed
+		flag.Set("log_dir", "/dev/null")
+
+		impact, err := compcheck.PolicyChangeImpact(ec, agbotUrl, polOrg+"/"+policyName, &oldPolicy, &newPolicy, false, msgPrinter)
+		if err != nil {
+			cliutils.Fatal(cliutils.CLI_GENERAL_ERROR, err.Error())
+		}
+		output = impact
+	}
+
+	jsonOutput, err := cliutils.DisplayAsJson(output)
+	if err != nil {
+		cliutils.Fatal(cliutils.JSON_PARSING_ERROR, msgPrinter.Sprintf("failed to marshal 'hzn exchange deployment diff' output: %v", err))
+	}
+	fmt.Println(jsonOutput)
+}
+
 // Validate and verify the secret binding defined in the given deployment policy.
 // It will output warning messages if the vault secret does not exist or error
 // accessing vault.
@@ -414,7 +490,11 @@ func BusinessNewPolicy() {
 		`        "version": "",`,
 		`        "priority":{}`,
 		`      }`,
-		`    ]`,
+		`    ],`,
+		`    "rollout": {     /* ` + msgPrinter.Sprintf("Optional. Upgrades the nodes to a new highest priority service version in steps.") + ` */`,
+		`      "percentage": 10,            /* ` + msgPrinter.Sprintf("The percentage of the nodes upgraded in each step.") + ` */`,
+		`      "verificationDuration": 600  /* ` + msgPrinter.Sprintf("The number of seconds the upgraded nodes are verified before the next step.") + ` */`,
+		`    }`,
 		`  },`,
 		`  "properties": [   /* ` + msgPrinter.Sprintf("A list of policy properties that describe the service being deployed.") + ` */`,
 		`    {`,
@@ -452,7 +532,31 @@ func BusinessNewPolicy() {
 		`        }`,
 		`      ]`,
 		`    }`,
-		`  ]`,
+		`  ],`,
+		`  "placement": {     /* ` + msgPrinter.Sprintf("Optional. Ranks the compatible nodes and places the service on the highest ranked nodes first.") + ` */`,
+		`    "preferences": [  /* ` + msgPrinter.Sprintf("A list of soft constraints. The weight of each one that a node satisfies is added to its score.") + ` */`,
+		`      {`,
+		`        "constraint": "myproperty == myvalue",`,
+		`        "weight": 1`,
+		`      }`,
+		`    ],`,
+		`    "maxNodes": 0     /* ` + msgPrinter.Sprintf("The maximum number of nodes to place the service on, 0 means no limit.") + ` */`,
+		`  },`,
+		`  "maintenance": {   /* ` + msgPrinter.Sprintf("Optional. Limits when the agbot can change the service version running on the nodes.") + ` */`,
+		`    "windows": [      /* ` + msgPrinter.Sprintf("The service version can only be changed within one of these windows.") + ` */`,
+		`      {`,
+		`        "schedule": "0 22 * * 1-5",   /* ` + msgPrinter.Sprintf("When the window starts, in cron format: minute hour day-of-month month day-of-week.") + ` */`,
+		`        "duration": 14400,            /* ` + msgPrinter.Sprintf("The number of seconds the window lasts.") + ` */`,
+		`        "timezone": "UTC"             /* ` + msgPrinter.Sprintf("The IANA time zone of the schedule.") + ` */`,
+		`      }`,
+		`    ],`,
+		`    "blackouts": [    /* ` + msgPrinter.Sprintf("The service version is never changed within these periods.") + ` */`,
+		`      {`,
+		`        "start": "",  /* ` + msgPrinter.Sprintf("The start of the period in RFC3339 format.") + ` */`,
+		`        "end": ""     /* ` + msgPrinter.Sprintf("The end of the period in RFC3339 format.") + ` */`,
+		`      }`,
+		`    ]`,
+		`  }`,
 		`}`,
 	}
 
@@ -8,16 +8,21 @@ import (
 	"github.com/open-horizon/anax/cli/cliutils"
 	"github.com/open-horizon/anax/common"
 	"github.com/open-horizon/anax/compcheck"
+	"github.com/open-horizon/anax/config"
 	"github.com/open-horizon/anax/exchange"
 	"github.com/open-horizon/anax/exchangecommon"
 	"github.com/open-horizon/anax/externalpolicy"
+	_ "github.com/open-horizon/anax/externalpolicy/cel_language"
 	_ "github.com/open-horizon/anax/externalpolicy/text_language"
 	"github.com/open-horizon/anax/i18n"
 	"github.com/open-horizon/anax/persistence"
 	"github.com/open-horizon/anax/policy"
 	"github.com/open-horizon/anax/semanticversion"
+	"io/ioutil"
 	"net/http"
+	"net/url"
 	"os"
+	"strconv"
 )
 
 type ExchangeNodes struct {
@@ -533,8 +538,21 @@ func NodeUpdatePolicy(org, credToUse, node string, jsonfile string) {
 			cliutils.Fatal(cliutils.CLI_INPUT_ERROR, msgPrinter.Sprintf("Invalid management attribute %s: %v", externPol, err))
 		}
 		newPolicy.Management = externPol
+	} else if _, ok = findAttrType["maintenance"]; ok {
+		attribName = "maintenance"
+		maintenancePatch := make(map[string]*exchangecommon.MaintenanceSchedule)
+		err := json.Unmarshal([]byte(attribute), &maintenancePatch)
+		if err != nil {
+			cliutils.Fatal(cliutils.JSON_PARSING_ERROR, msgPrinter.Sprintf("failed to unmarshal attribute input %s for %s: %v", attribute, attribName, err))
+		}
+		newMaintenance := maintenancePatch["maintenance"]
+		err = newMaintenance.Validate()
+		if err != nil {
+			cliutils.Fatal(cliutils.CLI_INPUT_ERROR, msgPrinter.Sprintf("Invalid maintenance attribute %s: %v", newMaintenance, err))
+		}
+		newPolicy.Maintenance = newMaintenance
 	} else {
-		cliutils.Fatal(cliutils.CLI_INPUT_ERROR, msgPrinter.Sprintf("Failed to find valid attribute to update in input %s. Valid attribute names are properties, constraints, deployment and management.", attribute))
+		cliutils.Fatal(cliutils.CLI_INPUT_ERROR, msgPrinter.Sprintf("Failed to find valid attribute to update in input %s. Valid attribute names are properties, constraints, deployment, management and maintenance.", attribute))
 	}
 
 	msgPrinter.Printf("Updating Node policy %v attribute for node %v in the horizon exchange and re-evaluating all agreements based on this policy. Existing agreements might be cancelled and re-negotiated.", attribName, node)
@@ -656,6 +674,69 @@ func NodeListStatus(org string, credToUse string, node string) {
 
 }
 
+// Pull the recent log lines of a service from a node. The agbot sends the request to the node through the exchange and
+// waits for the node to send the lines back, so the request can take longer than the usual agbot requests.
+func NodeLogs(org, credToUse, node, service, version, containerName string, tail int, since string, timeout int, longDetails bool) {
+	msgPrinter := i18n.GetMessagePrinter()
+
+	cliutils.SetWhetherUsingApiKey(credToUse)
+	var nodeOrg string
+	nodeOrg, node = cliutils.TrimOrg(org, node)
+
+	agbotUrl := cliutils.GetAgbotSecureAPIUrlBase()
+	if agbotUrl == "" {
+		cliutils.Fatal(cliutils.HTTP_ERROR, msgPrinter.Sprintf("HZN_AGBOT_URL is not defined"))
+	}
+
+	params := url.Values{}
+	params.Set("service", service)
+	if version != "" {
+		params.Set("version", version)
+	}
+	if containerName != "" {
+		params.Set("container", containerName)
+	}
+	if since != "" {
+		params.Set("since", since)
+	}
+	params.Set("tail", strconv.Itoa(tail))
+	params.Set("timeout", strconv.Itoa(timeout))
+
+	fullUrl := agbotUrl + "/org/" + nodeOrg + "/node/" + node + "/servicelogs?" + params.Encode()
+	apiMsg := http.MethodGet + " " + fullUrl
+	cliutils.Verbose(apiMsg)
+
+	httpClient := cliutils.GetHTTPClient(timeout + config.HTTPRequestTimeoutS)
+	resp := cliutils.InvokeRestApi(httpClient, http.MethodGet, fullUrl, cliutils.OrgAndCreds(org, credToUse), nil, "Agbot", apiMsg, make(map[string]string), true)
+	if resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	bodyBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		cliutils.Fatal(cliutils.HTTP_ERROR, msgPrinter.Sprintf("failed to read body response from %s: %v", apiMsg, err))
+	}
+	cliutils.Verbose(msgPrinter.Sprintf("HTTP code: %d", resp.StatusCode))
+	if resp.StatusCode == http.StatusNotFound {
+		cliutils.Fatal(cliutils.NOT_FOUND, msgPrinter.Sprintf("%s", string(bodyBytes)))
+	} else if resp.StatusCode != http.StatusOK {
+		cliutils.Fatal(cliutils.HTTP_ERROR, msgPrinter.Sprintf("bad HTTP code %d from %s, output: %s", resp.StatusCode, apiMsg, string(bodyBytes)))
+	}
+
+	var logs exchange.ServiceLogResponse
+	if err := json.Unmarshal(bodyBytes, &logs); err != nil {
+		cliutils.Fatal(cliutils.JSON_PARSING_ERROR, msgPrinter.Sprintf("failed to unmarshal body response from %s: %v", apiMsg, err))
+	}
+
+	if longDetails {
+		fmt.Println(cliutils.MarshalIndent(logs.Lines, "exchange node logs"))
+	} else {
+		for _, line := range logs.Lines {
+			fmt.Println(line.String())
+		}
+	}
+}
+
 func NodeManagementList(org, credToUse, nodeName string, all bool) {
 	msgPrinter := i18n.GetMessagePrinter()
 
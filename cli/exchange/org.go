@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/open-horizon/anax/cli/cliutils"
 	"github.com/open-horizon/anax/exchange"
+	"github.com/open-horizon/anax/externalpolicy"
 	"github.com/open-horizon/anax/i18n"
 	"github.com/open-horizon/edge-sync-service/common"
 	"net/http"
@@ -22,6 +23,11 @@ type PatchOrgTags struct {
 	Tags map[string]string `json:"tags"`
 }
 
+// use this structure to remove a tag, the exchange removes the tags that have a null value
+type PatchOrgRemoveTags struct {
+	Tags map[string]*string `json:"tags"`
+}
+
 func OrgList(org, userPwCreds, theOrg string, long bool) {
 	// get message printer
 	msgPrinter := i18n.GetMessagePrinter()
@@ -148,7 +154,7 @@ func OrgCreate(org, userPwCreds, theOrg string, label string, desc string, tags
 	msgPrinter.Println()
 }
 
-func OrgUpdate(org, userPwCreds, theOrg string, label string, desc string, tags []string, min int, max int, adjust int, maxNodes int) {
+func OrgUpdate(org, userPwCreds, theOrg string, label string, desc string, tags []string, min int, max int, adjust int, maxNodes int, schemaFile string, removeSchema bool) {
 	// get message printer
 	msgPrinter := i18n.GetMessagePrinter()
 
@@ -178,6 +184,17 @@ func OrgUpdate(org, userPwCreds, theOrg string, label string, desc string, tags
 		cliutils.ExchangePutPost("Exchange", http.MethodPatch, cliutils.GetExchangeUrl(), "orgs/"+theOrg, cliutils.OrgAndCreds(org, userPwCreds), []int{201}, newTags, nil)
 	}
 
+	// if --property-schema is specified, save the schema in the org tags
+	if schemaFile != "" && removeSchema {
+		cliutils.Fatal(cliutils.CLI_INPUT_ERROR, msgPrinter.Sprintf("--property-schema and --remove-property-schema cannot be specified together."))
+	} else if schemaFile != "" {
+		newTags := PatchOrgTags{Tags: map[string]string{exchange.ORG_PROPERTY_SCHEMA_TAG: readPropertySchemaFile(schemaFile)}}
+		cliutils.ExchangePutPost("Exchange", http.MethodPatch, cliutils.GetExchangeUrl(), "orgs/"+theOrg, cliutils.OrgAndCreds(org, userPwCreds), []int{201}, newTags, nil)
+	} else if removeSchema {
+		newTags := PatchOrgRemoveTags{Tags: map[string]*string{exchange.ORG_PROPERTY_SCHEMA_TAG: nil}}
+		cliutils.ExchangePutPost("Exchange", http.MethodPatch, cliutils.GetExchangeUrl(), "orgs/"+theOrg, cliutils.OrgAndCreds(org, userPwCreds), []int{201}, newTags, nil)
+	}
+
 	// do nothing if they are -1
 	if min != -1 || max != -1 || adjust != -1 {
 		newMin, newMax, newAdjust := getNewHeartbeatAttributes(min, max, adjust, orgs.Orgs[theOrg].HeartbeatIntv)
@@ -362,6 +379,26 @@ func convertTags(tags []string, update bool) map[string]string {
 	return orgTags
 }
 
+// Read and validate the property schema file, and return the normalized schema as a JSON string for the org tag.
+func readPropertySchemaFile(schemaFile string) string {
+	// get message printer
+	msgPrinter := i18n.GetMessagePrinter()
+
+	content := cliutils.ReadJsonFile(schemaFile)
+	schema := new(externalpolicy.PropertySchema)
+	if err := json.Unmarshal(content, schema); err != nil {
+		cliutils.Fatal(cliutils.JSON_PARSING_ERROR, msgPrinter.Sprintf("failed to unmarshal the property schema file %v: %v", schemaFile, err))
+	} else if err := schema.Validate(); err != nil {
+		cliutils.Fatal(cliutils.CLI_INPUT_ERROR, msgPrinter.Sprintf("Incorrect property schema in file %v: %v", schemaFile, err))
+	}
+
+	jsonBytes, err := json.Marshal(schema)
+	if err != nil {
+		cliutils.Fatal(cliutils.JSON_PARSING_ERROR, msgPrinter.Sprintf("failed to marshal the property schema: %v", err))
+	}
+	return string(jsonBytes)
+}
+
 // This function checks if the given agbot exists or not
 func CheckAgbot(org string, userPw string, agbot string) {
 	// get message printer
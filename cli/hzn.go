@@ -119,6 +119,9 @@ Environment Variables:
   HZN_SDO_SVC_URL:  Override the URL that the 'hzn sdo' sub-commands use
 	  to communicate with SDO owner services. (By default hzn will ask the
 		Horizon Agent for the URL.)
+  HZN_PROPERTY_SCHEMA:  The path of the property schema file of the organization.
+      When it is set, the properties of the node, service and deployment
+      policies are validated against it.
   HZN_FDO_SVC_URL:  Override the URL that the 'hzn fdo' sub-commands use
 	  to communicate with FDO owner services. (By default hzn will ask the
 		Horizon Agent for the URL.)
@@ -202,6 +205,15 @@ Environment Variables:
 	allCompSvcFile := allCompCmd.Flag("service", msgPrinter.Sprintf("(optional) The JSON input file name containing the service definition. If omitted, the service defined in the deployment policy or pattern will be retrieved from the Exchange. This flag can be repeated to specify different versions of the service.")).Strings()
 	allCompPatternId := allCompCmd.Flag("pattern-id", msgPrinter.Sprintf("The Horizon exchange pattern ID. Mutually exclusive with -P, -b, -B --node-pol and --service-pol. If you don't prepend it with the organization id, it will automatically be prepended with the node's organization id.")).Short('p').String()
 	allCompPatternFile := allCompCmd.Flag("pattern", msgPrinter.Sprintf("The JSON input file name containing the pattern. Mutually exclusive with -p, -b and -B, --node-pol and --service-pol.")).Short('P').String()
+	fleetCompCmd := deploycheckCmd.Command("fleet", msgPrinter.Sprintf("Check the deployment compatibility of a deployment policy for all the nodes in an organization."))
+	fleetCompNodeOrg := fleetCompCmd.Flag("node-org", msgPrinter.Sprintf("The organization of the nodes. The default value is the organization of the deployment policy.")).Short('O').String()
+	fleetCompNodeId := fleetCompCmd.Flag("node-id", msgPrinter.Sprintf("Only check the nodes with ids that match this shell pattern, for example 'edge-*'. The pattern is matched against the node id without the organization id.")).Short('n').String()
+	fleetCompNodeType := fleetCompCmd.Flag("node-type", msgPrinter.Sprintf("Only check the nodes of this type. The valid values are 'device' and 'cluster'.")).Short('t').String()
+	fleetCompNodeArch := fleetCompCmd.Flag("arch", msgPrinter.Sprintf("Only check the nodes with this architecture.")).Short('a').String()
+	fleetCompDepPolId := fleetCompCmd.Flag("deployment-pol-id", msgPrinter.Sprintf("The Horizon exchange deployment policy ID. Mutually exclusive with -B. If you don't prepend it with the organization id, it will automatically be prepended with the -o value.")).Short('b').String()
+	fleetCompDepPolFile := fleetCompCmd.Flag("deployment-pol", msgPrinter.Sprintf("The JSON input file name containing the deployment policy. Mutually exclusive with -b.")).Short('B').String()
+	fleetCompSPolFile := fleetCompCmd.Flag("service-pol", msgPrinter.Sprintf("(optional) The JSON input file name containing the service policy. If omitted, the service policy will be retrieved from the Exchange for the service defined in the deployment policy.")).String()
+	fleetCompSvcFile := fleetCompCmd.Flag("service", msgPrinter.Sprintf("(optional) The JSON input file name containing the service definition. If omitted, the service defined in the deployment policy will be retrieved from the Exchange. This flag can be repeated to specify different versions of the service.")).Strings()
 	policyCompCmd := deploycheckCmd.Command("policy | pol", msgPrinter.Sprintf("Check policy compatibility.")).Alias("pol").Alias("policy")
 	policyCompNodeArch := policyCompCmd.Flag("arch", msgPrinter.Sprintf("The architecture of the node. It is required when -n is not specified. If omitted, the service of all the architectures referenced in the deployment policy will be checked for compatibility.")).Short('a').String()
 	policyCompNodeType := policyCompCmd.Flag("node-type", msgPrinter.Sprintf("The node type. The valid values are 'device' and 'cluster'. The default value is the type of the node provided by -n or current registered device, if omitted.")).Short('t').String()
@@ -284,10 +296,19 @@ Environment Variables:
 
 	eventlogCmd := app.Command("eventlog | ev", msgPrinter.Sprintf("List the event logs for the current or all registrations.")).Alias("ev").Alias("eventlog")
 	eventlogListCmd := eventlogCmd.Command("list | ls", msgPrinter.Sprintf("List the event logs for the current or all registrations.")).Alias("ls").Alias("list")
-	listTail := eventlogListCmd.Flag("tail", msgPrinter.Sprintf("Continuously polls the event log to display the most recent records, similar to tail -F behavior.")).Short('f').Bool()
+	listTail := eventlogListCmd.Flag("tail", msgPrinter.Sprintf("Continuously display the most recent records as they are saved, similar to tail -F behavior.")).Short('f').Bool()
 	listAllEventlogs := eventlogListCmd.Flag("all", msgPrinter.Sprintf("List all the event logs including the previous registrations.")).Short('a').Bool()
 	listDetailedEventlogs := eventlogListCmd.Flag("long", msgPrinter.Sprintf("List event logs with details.")).Short('l').Bool()
-	listSelectedEventlogs := eventlogListCmd.Flag("select", msgPrinter.Sprintf("Selection string. This flag can be repeated which means 'AND'. Each flag should be in the format of attribute=value, attribute~value, \"attribute>value\" or \"attribute<value\", where '~' means contains. The common attribute names are timestamp, severity, message, event_code, source_type, agreement_id, service_url etc. Use the '-l' flag to see all the attribute names.")).Short('s').Strings()
+	listSelectedEventlogs := eventlogListCmd.Flag("select", msgPrinter.Sprintf("Selection string. This flag can be repeated which means 'AND'. Each flag should be in the format of attribute=value, attribute~value, \"attribute>value\", \"attribute<value\", \"attribute>=value\", \"attribute<=value\", \"attribute!=value\" or \"attribute!~value\", where '~' means contains and '!' means not. The value can have alternatives separated by '|', which means 'OR', for example \"severity=error|warning\". A value of now-<duration> is a time relative to the current time, for example \"timestamp>=now-1h\". The common attribute names are timestamp, severity, message, event_code, source_type, agreement_id, service_url, nmp_name etc. Use the '-l' flag to see all the attribute names.")).Short('s').Strings()
+	listSeverityEventlogs := eventlogListCmd.Flag("severity", msgPrinter.Sprintf("Only list the event logs with this severity, info, warning or error. Separate more than one severity with '|'.")).String()
+	listSourceTypeEventlogs := eventlogListCmd.Flag("source-type", msgPrinter.Sprintf("Only list the event logs with this source type, for example agreement, service, node or exchange. Separate more than one source type with '|'.")).String()
+	listServiceEventlogs := eventlogListCmd.Flag("service", msgPrinter.Sprintf("Only list the event logs for this service url.")).String()
+	listAgreementEventlogs := eventlogListCmd.Flag("agreement", msgPrinter.Sprintf("Only list the event logs for this agreement id.")).String()
+	listNMPEventlogs := eventlogListCmd.Flag("nmp", msgPrinter.Sprintf("Only list the event logs for the node management policies whose name contains this string.")).String()
+	listSinceEventlogs := eventlogListCmd.Flag("since", msgPrinter.Sprintf("Only list the event logs saved during this period of time before now. The format is a number followed by s, m, h or d, for example 30m or 1d.")).String()
+	listLimitEventlogs := eventlogListCmd.Flag("limit", msgPrinter.Sprintf("The maximum number of event logs to list. The default is to list all of them.")).Int()
+	listOffsetEventlogs := eventlogListCmd.Flag("offset", msgPrinter.Sprintf("The number of matching event logs to skip before listing.")).Int()
+	listSortEventlogs := eventlogListCmd.Flag("sort", msgPrinter.Sprintf("The order of the event logs, asc lists the oldest first and desc lists the newest first. The default is asc.")).Enum("asc", "desc")
 	surfaceErrorsEventlogs := eventlogCmd.Command("surface | sf", msgPrinter.Sprintf("List all the active errors that will be shared with the Exchange if the node is online.")).Alias("sf").Alias("surface")
 	surfaceErrorsEventlogsLong := surfaceErrorsEventlogs.Flag("long", msgPrinter.Sprintf("List the full event logs of the surface errors.")).Short('l').Bool()
 
@@ -337,6 +358,10 @@ Environment Variables:
 	exBusinessAddPolicyPolicy := exBusinessAddPolicyCmd.Arg("policy", msgPrinter.Sprintf("The name of the deployment policy to add or overwrite.")).Required().String()
 	exBusinessAddPolicyJsonFile := exBusinessAddPolicyCmd.Flag("json-file", msgPrinter.Sprintf("The path of a JSON file containing the metadata necessary to create/update the service policy in the Horizon Exchange. Specify -f- to read from stdin.")).Short('f').Required().String()
 	exBusinessAddPolNoConstraint := exBusinessAddPolicyCmd.Flag("no-constraints", msgPrinter.Sprintf("Allow this deployment policy to be published even though it does not have any constraints.")).Bool()
+	exBusinessDiffPolicyCmd := exBusinessCmd.Command("diff", msgPrinter.Sprintf("Compare a deployment policy in the Horizon Exchange with a new version of it, and report the nodes with agreements that would lose the workload and the nodes that would gain it if the new version was published."))
+	exBusinessDiffPolicyPolicy := exBusinessDiffPolicyCmd.Arg("policy", msgPrinter.Sprintf("The name of the deployment policy in the Horizon Exchange.")).Required().String()
+	exBusinessDiffPolicyJsonFile := exBusinessDiffPolicyCmd.Arg("newfile", msgPrinter.Sprintf("The path of a JSON file containing the new version of the deployment policy. Specify - to read from stdin.")).Required().String()
+	exBusinessDiffPolicyDiffOnly := exBusinessDiffPolicyCmd.Flag("diff-only", msgPrinter.Sprintf("Only display the changes between the 2 versions of the policy, do not check the nodes.")).Bool()
 	exBusinessListPolicyCmd := exBusinessCmd.Command("listpolicy | ls", msgPrinter.Sprintf("Display the deployment policies from the Horizon Exchange.")).Alias("ls").Alias("listpolicy")
 	exBusinessListPolicyIdTok := exBusinessListPolicyCmd.Flag("id-token", msgPrinter.Sprintf("The Horizon ID and password of the user.")).Short('n').PlaceHolder("ID:TOK").String()
 	exBusinessListPolicyLong := exBusinessListPolicyCmd.Flag("long", msgPrinter.Sprintf("Display detailed output about the deployment policies.")).Short('l').Bool()
@@ -406,6 +431,15 @@ Environment Variables:
 	exNodeListPolicyIdTok := exNodeListPolicyCmd.Flag("node-id-tok", msgPrinter.Sprintf("The Horizon Exchange node ID and token to be used as credentials to query and modify the node resources if -u flag is not specified. HZN_EXCHANGE_NODE_AUTH will be used as a default for -n. If you don't prepend it with the node's org, it will automatically be prepended with the -o value.")).Short('n').PlaceHolder("ID:TOK").String()
 	exNodeListPolicyNode := exNodeListPolicyCmd.Arg("node", msgPrinter.Sprintf("List policy for this node.")).Required().String()
 
+	exNodeLogsCmd := exNodeCmd.Command("logs", msgPrinter.Sprintf("Display the recent output of a service running on the node. The request is sent to the node through an agbot, HZN_AGBOT_URL must be set."))
+	exNodeLogsNode := exNodeLogsCmd.Arg("node", msgPrinter.Sprintf("The node running the service.")).Required().String()
+	exNodeLogsService := exNodeLogsCmd.Arg("service", msgPrinter.Sprintf("The url of the service. If you don't prepend it with the service's org, the service is looked up in any org.")).Required().String()
+	exNodeLogsVersion := exNodeLogsCmd.Flag("version", msgPrinter.Sprintf("The version of the service, if the node runs more than one version of it.")).Short('V').String()
+	exNodeLogsContainer := exNodeLogsCmd.Flag("container", msgPrinter.Sprintf("Display the output of this container of the service only. The output of all the containers of the service is displayed if omitted.")).Short('c').String()
+	exNodeLogsTail := exNodeLogsCmd.Flag("tail", msgPrinter.Sprintf("The number of most recent lines to display, at most 1000.")).Short('t').Default("100").Int()
+	exNodeLogsSince := exNodeLogsCmd.Flag("since", msgPrinter.Sprintf("Only display the lines written after this time, in RFC3339 format, or within this duration, e.g. 10m.")).Short('s').String()
+	exNodeLogsTimeout := exNodeLogsCmd.Flag("timeout", msgPrinter.Sprintf("The number of seconds to wait for the node to send the lines, at most 300. The node picks up the request at its next heartbeat.")).Default("60").Int()
+	exNodeLogsLong := exNodeLogsCmd.Flag("long", msgPrinter.Sprintf("Display the lines in JSON format.")).Short('l').Bool()
 	exNodeManagementCmd := exNodeCmd.Command("management | mgmt", msgPrinter.Sprintf("List and manage node management resources in the Horizon Exchange")).Alias("mgmt").Alias("management")
 	exNodeManagementListCmd := exNodeManagementCmd.Command("list | ls", msgPrinter.Sprintf("List the compatible node management policies for the node. Only policies that are enabled will be displayed unless the -a flag is specified.")).Alias("ls").Alias("list")
 	exNodeManagementListName := exNodeManagementListCmd.Arg("node", msgPrinter.Sprintf("List node management policies for this node")).Required().String()
@@ -471,6 +505,8 @@ Environment Variables:
 	exOrgUpdateHBMax := exOrgUpdateCmd.Flag("heartbeatmax", msgPrinter.Sprintf("New maximum number of seconds between agent heartbeats to the Exchange. The default negative integer -1 means no change to this attribute.")).Default("-1").Int()
 	exOrgUpdateHBAdjust := exOrgUpdateCmd.Flag("heartbeatadjust", msgPrinter.Sprintf("New value for the number of seconds to increment the agent's heartbeat interval. The default negative integer -1 means no change to this attribute.")).Default("-1").Int()
 	exOrgUpdateMaxNodes := exOrgUpdateCmd.Flag("max-nodes", msgPrinter.Sprintf("The new maximum number of nodes this organization is allowed to have. The value cannot exceed the Exchange global limit. The default negative integer -1 means no change.")).Default("-1").Int()
+	exOrgUpdatePropertySchema := exOrgUpdateCmd.Flag("property-schema", msgPrinter.Sprintf("The JSON file containing the new property schema of the organization. The properties of the node, service and deployment policies in the organization are validated against it.")).String()
+	exOrgUpdateRemovePropertySchema := exOrgUpdateCmd.Flag("remove-property-schema", msgPrinter.Sprintf("Remove the property schema of the organization.")).Bool()
 
 	exPatternCmd := exchangeCmd.Command("pattern | pat", msgPrinter.Sprintf("List and manage patterns in the Horizon Exchange")).Alias("pat").Alias("pattern")
 	exPatternListCmd := exPatternCmd.Command("list | ls", msgPrinter.Sprintf("Display the pattern resources from the Horizon Exchange.")).Alias("ls").Alias("list")
@@ -670,6 +706,8 @@ Environment Variables:
 	mmsStatusCmd := mmsCmd.Command("status", msgPrinter.Sprintf("Display the status of the Horizon Model Management Service."))
 
 	nodeCmd := app.Command("node", msgPrinter.Sprintf("List and manage general information about this Horizon edge node."))
+	nodeDiagCmd := nodeCmd.Command("diag", msgPrinter.Sprintf("Create a diagnostics bundle of this Horizon edge node: a gzipped tarball with the agent status, the worker status, the event log, the surfaced errors, the agreements, the services and their recent output, the agent config with the secrets removed, and the docker or kubernetes version."))
+	nodeDiagFile := nodeDiagCmd.Flag("file", msgPrinter.Sprintf("The file to write the bundle to. Specify -f- to write it to stdout. The default is horizon-diagnostics-<time>.tar.gz in the current directory.")).Short('f').String()
 	nodeListCmd := nodeCmd.Command("list | ls", msgPrinter.Sprintf("Display general information about this Horizon edge node.")).Alias("list").Alias("ls")
 
 	nodeManagementCmd := app.Command("nodemanagement | nm", msgPrinter.Sprintf("List and manage manifests and agent files for node management.")).Alias("nm").Alias("nodemanagement")
@@ -704,6 +742,15 @@ Environment Variables:
 
 	policyCmd := app.Command("policy | pol", msgPrinter.Sprintf("List and manage policy for this Horizon edge node.")).Alias("pol").Alias("policy")
 	policyListCmd := policyCmd.Command("list | ls", msgPrinter.Sprintf("Display this edge node's policy.")).Alias("ls").Alias("list")
+	policySchemaCmd := policyCmd.Command("schema", msgPrinter.Sprintf("Display the property schema of the organization, or the declaration of one property in it. The property schema is stored in the organization in the Exchange, it can be replaced by a local file specified with the HZN_PROPERTY_SCHEMA environment variable. The properties of the node, service and deployment policies are validated against it."))
+	policySchemaProperty := policySchemaCmd.Arg("property", msgPrinter.Sprintf("The name of the property to display.")).HintAction(policy.SchemaPropertyNames).String()
+	policySchemaOrg := policySchemaCmd.Flag("org", msgPrinter.Sprintf("The Horizon exchange organization ID whose property schema is displayed. If not specified, HZN_ORG_ID will be used as a default.")).Short('o').String()
+	policySchemaUserPw := policySchemaCmd.Flag("user-pw", msgPrinter.Sprintf("Horizon Exchange user credentials to get the property schema of the organization. If not specified, HZN_EXCHANGE_USER_AUTH will be used as a default.")).Short('u').PlaceHolder("USER:PW").String()
+	policyLintCmd := policyCmd.Command("lint", msgPrinter.Sprintf("Check a node, service, deployment or node management policy file for constraints that can never be satisfied, type mismatches, properties that shadow built-in properties and colliding service version priorities. If an Exchange user credential is given, the constraints are also checked against the properties published by the nodes in the organization."))
+	policyLintFile := policyLintCmd.Arg("file", msgPrinter.Sprintf("The JSON file containing the policy. Specify - to read from stdin.")).Required().String()
+	policyLintType := policyLintCmd.Flag("type", msgPrinter.Sprintf("The type of the policy: node, service, deployment or nmp. If omitted, the type is detected from the content of the file. A service policy file is detected as a node policy.")).Short('t').String()
+	policyLintOrg := policyLintCmd.Flag("org", msgPrinter.Sprintf("The Horizon exchange organization ID whose nodes the constraints are checked against. If omitted, the organization of the user credential is used.")).Short('o').String()
+	policyLintUserPw := policyLintCmd.Flag("user-pw", msgPrinter.Sprintf("Horizon Exchange user credentials to query the node policies in the organization. If omitted, the constraints are not checked against the node properties.")).Short('u').PlaceHolder("USER:PW").String()
 	policyNewCmd := policyCmd.Command("new", msgPrinter.Sprintf("Display an empty policy template that can be filled in."))
 	policyPatchCmd := policyCmd.Command("patch", msgPrinter.Sprintf("(DEPRECATED) This command is deprecated. Please use 'hzn policy update' to update the node policy. This command is used to update either the node policy properties or the constraints, but not both."))
 	policyPatchInput := policyPatchCmd.Arg("patch", msgPrinter.Sprintf("The new constraints or properties in the format '%s' or '%s'.", "{\"constraints\":[<constraint list>]}", "{\"properties\":[<property list>]}")).Required().String()
@@ -755,6 +802,10 @@ Environment Variables:
 	logTail := serviceLogCmd.Flag("tail", msgPrinter.Sprintf("Continuously polls the service's logs to display the most recent records, similar to tail -F behavior.")).Short('f').Bool()
 	serviceListCmd := serviceCmd.Command("list | ls", msgPrinter.Sprintf("List the services variable configuration that has been done on this Horizon edge node.")).Alias("ls").Alias("list")
 	serviceRegisteredCmd := serviceCmd.Command("registered | reg", msgPrinter.Sprintf("List the services that are currently registered on this Horizon edge node.")).Alias("reg").Alias("registered")
+	serviceVolumeCmd := serviceCmd.Command("volume | vol", msgPrinter.Sprintf("List or prune the docker volumes created for the services on this Horizon edge node.")).Alias("vol").Alias("volume")
+	serviceVolumeListCmd := serviceVolumeCmd.Command("list | ls", msgPrinter.Sprintf("List the docker volumes created for the services on this Horizon edge node, with their size quota, disk usage and retention policy.")).Alias("ls").Alias("list")
+	serviceVolumePruneCmd := serviceVolumeCmd.Command("prune", msgPrinter.Sprintf("Delete the docker volumes that were kept after the agreement of their service ended. The volumes in use are not affected."))
+	forcePruneServiceVolumes := serviceVolumePruneCmd.Flag("force", msgPrinter.Sprintf("Skip the 'are you sure?' prompt.")).Short('f').Bool()
 
 	statusCmd := app.Command("status", msgPrinter.Sprintf("Display the current horizon internal status for the node."))
 	statusLong := statusCmd.Flag("long", msgPrinter.Sprintf("Show detailed status")).Short('l').Bool()
@@ -809,6 +860,11 @@ Environment Variables:
 	smSecretRemoveName := smSecretRemoveCmd.Arg("secretName", msgPrinter.Sprintf("The name of the secret to be removed from the secrets manager.")).Required().String()
 	smSecretReadCmd := smSecretCmd.Command("read", msgPrinter.Sprintf("Read the details of a secret stored in the secrets manager. This consists of the key and value pair provided on secret creation."))
 	smSecretReadName := smSecretReadCmd.Arg("secretName", msgPrinter.Sprintf("The name of the secret to read in the secrets manager.")).Required().String()
+	smSecretHistoryCmd := smSecretCmd.Command("history", msgPrinter.Sprintf("Display the versions of a secret retained by the secrets manager."))
+	smSecretHistoryName := smSecretHistoryCmd.Arg("secretName", msgPrinter.Sprintf("The name of the secret in the secrets manager.")).Required().String()
+	smSecretRollbackCmd := smSecretCmd.Command("rollback", msgPrinter.Sprintf("Make a prior version of a secret the current version. Services that use the secret receive the rolled back secret."))
+	smSecretRollbackName := smSecretRollbackCmd.Arg("secretName", msgPrinter.Sprintf("The name of the secret in the secrets manager.")).Required().String()
+	smSecretRollbackVersion := smSecretRollbackCmd.Flag("version", msgPrinter.Sprintf("The version to roll back to, as displayed by the history command.")).Short('V').Required().Int()
 
 	versionCmd := app.Command("version", msgPrinter.Sprintf("Show the Horizon version.")) // using a cmd for this instead of --version flag, because kingpin takes over the latter and can't get version only when it is needed
 
@@ -909,6 +965,18 @@ Environment Variables:
 	}
 	cliconfig.SetEnvVarsFromProjectConfigFile(project_dir)
 
+	// validate the policy properties against the property schema of the organization in the commands that take policies
+	switch fullCmd {
+	case exNodeAddPolicyCmd.FullCommand(), exNodeUpdatePolicyCmd.FullCommand(), exServicePublishCmd.FullCommand(), exServiceAddPolicyCmd.FullCommand(),
+		exBusinessAddPolicyCmd.FullCommand(), exBusinessUpdatePolicyCmd.FullCommand(), exBusinessDiffPolicyCmd.FullCommand(), registerCmd.FullCommand(),
+		policyLintCmd.FullCommand(), policyUpdateCmd.FullCommand(), policyPatchCmd.FullCommand(), policyCompCmd.FullCommand(), allCompCmd.FullCommand():
+		schemaUserPw := exUserPw
+		if fullCmd == policyLintCmd.FullCommand() {
+			schemaUserPw = policyLintUserPw
+		}
+		policy.LoadPropertySchema(*cliutils.WithDefaultEnvVar(exOrg, "HZN_ORG_ID"), *cliutils.WithDefaultEnvVar(schemaUserPw, "HZN_EXCHANGE_USER_AUTH"))
+	}
+
 	credToUse := ""
 	if strings.HasPrefix(fullCmd, "exchange ") {
 		exOrg = cliutils.WithDefaultEnvVar(exOrg, "HZN_ORG_ID")
@@ -959,6 +1027,8 @@ Environment Variables:
 			credToUse = cliutils.GetExchangeAuth(*exUserPw, *exNodeErrorsListIdTok, false)
 		case "node liststatus | lst":
 			credToUse = cliutils.GetExchangeAuth(*exUserPw, *exNodeStatusIdTok, false)
+		case "node logs":
+			credToUse = cliutils.GetExchangeAuth(*exUserPw, "", true)
 		case "node management | mgmt list | ls":
 			credToUse = cliutils.GetExchangeAuth(*exUserPw, *exNodeManagementListNodeIdTok, false)
 		case "node management | mgmt status":
@@ -1009,6 +1079,8 @@ Environment Variables:
 			credToUse = cliutils.GetExchangeAuth(*exUserPw, *exBusinessAddPolicyIdTok, false)
 		case "deployment | dep removepolicy | rmp":
 			credToUse = cliutils.GetExchangeAuth(*exUserPw, *exBusinessRemovePolicyIdTok, false)
+		case "deployment | dep diff":
+			credToUse = cliutils.GetExchangeAuth(*exUserPw, "", false)
 		case "deployment | dep new":
 			// does not require exchange credentials
 		case "version":
@@ -1170,7 +1242,7 @@ Environment Variables:
 	case exOrgCreateCmd.FullCommand():
 		exchange.OrgCreate(*exOrg, *exUserPw, *exOrgCreateOrg, *exOrgCreateLabel, *exOrgCreateDesc, *exOrgCreateTags, *exOrgCreateHBMin, *exOrgCreateHBMax, *exOrgCreateHBAdjust, *exOrgCreateMaxNodes, *exOrgCreateAddToAgbot)
 	case exOrgUpdateCmd.FullCommand():
-		exchange.OrgUpdate(*exOrg, *exUserPw, *exOrgUpdateOrg, *exOrgUpdateLabel, *exOrgUpdateDesc, *exOrgUpdateTags, *exOrgUpdateHBMin, *exOrgUpdateHBMax, *exOrgUpdateHBAdjust, *exOrgUpdateMaxNodes)
+		exchange.OrgUpdate(*exOrg, *exUserPw, *exOrgUpdateOrg, *exOrgUpdateLabel, *exOrgUpdateDesc, *exOrgUpdateTags, *exOrgUpdateHBMin, *exOrgUpdateHBMax, *exOrgUpdateHBAdjust, *exOrgUpdateMaxNodes, *exOrgUpdatePropertySchema, *exOrgUpdateRemovePropertySchema)
 	case exOrgDelCmd.FullCommand():
 		exchange.OrgDel(*exOrg, *exUserPw, *exOrgDelOrg, *exOrgDelFromAgbot, *exOrgDelForce)
 
@@ -1233,6 +1305,8 @@ Environment Variables:
 		exchange.NodeRemovePolicy(*exOrg, credToUse, *exNodeRemovePolicyNode, *exNodeRemovePolicyForce)
 	case exNodeErrorsList.FullCommand():
 		exchange.NodeListErrors(*exOrg, credToUse, *exNodeErrorsListNode, *exNodeErrorsListLong)
+	case exNodeLogsCmd.FullCommand():
+		exchange.NodeLogs(*exOrg, credToUse, *exNodeLogsNode, *exNodeLogsService, *exNodeLogsVersion, *exNodeLogsContainer, *exNodeLogsTail, *exNodeLogsSince, *exNodeLogsTimeout, *exNodeLogsLong)
 	case exNodeStatusList.FullCommand():
 		exchange.NodeListStatus(*exOrg, credToUse, *exNodeStatusListNode)
 	case exNodeManagementListCmd.FullCommand():
@@ -1305,6 +1379,8 @@ Environment Variables:
 		exchange.ListServiceNodes(*exOrg, *exUserPw, *exServiceListnodeService, *exServiceListnodeNodeOrg)
 	case exBusinessListPolicyCmd.FullCommand():
 		exchange.BusinessListPolicy(*exOrg, credToUse, *exBusinessListPolicyPolicy, !*exBusinessListPolicyLong)
+	case exBusinessDiffPolicyCmd.FullCommand():
+		exchange.BusinessDiffPolicy(*exOrg, credToUse, *exBusinessDiffPolicyPolicy, *exBusinessDiffPolicyJsonFile, *exBusinessDiffPolicyDiffOnly)
 	case exBusinessNewPolicyCmd.FullCommand():
 		exchange.BusinessNewPolicy()
 	case exBusinessAddPolicyCmd.FullCommand():
@@ -1329,10 +1405,16 @@ Environment Variables:
 		key.Import(*keyImportPubKeyFile)
 	case keyDelCmd.FullCommand():
 		key.Remove(*keyDelName)
+	case nodeDiagCmd.FullCommand():
+		node.Diagnostics(*nodeDiagFile)
 	case nodeListCmd.FullCommand():
 		node.List()
 	case policyListCmd.FullCommand():
 		policy.List()
+	case policySchemaCmd.FullCommand():
+		policy.Schema(*cliutils.WithDefaultEnvVar(policySchemaOrg, "HZN_ORG_ID"), *cliutils.WithDefaultEnvVar(policySchemaUserPw, "HZN_EXCHANGE_USER_AUTH"), *policySchemaProperty)
+	case policyLintCmd.FullCommand():
+		policy.Lint(*policyLintFile, *policyLintType, *policyLintOrg, *policyLintUserPw)
 	case policyNewCmd.FullCommand():
 		policy.New()
 	case policyUpdateCmd.FullCommand():
@@ -1349,6 +1431,8 @@ Environment Variables:
 		deploycheck.SecretBindingCompatible(*deploycheckOrg, *deploycheckUserPw, *secretCompNodeId, *secretCompNodeArch, *secretCompNodeType, *secretCompNodeOrg, *secretCompDepPolId, *secretCompDepPolFile, *secretCompPatternId, *secretCompPatternFile, *secretCompSvcFile, *deploycheckCheckAll, *deploycheckLong)
 	case allCompCmd.FullCommand():
 		deploycheck.AllCompatible(*deploycheckOrg, *deploycheckUserPw, *allCompNodeId, *allCompHAGroup, *allCompNodeArch, *allCompNodeType, *allCompNodeOrg, *allCompNodePolFile, *allCompNodeUIFile, *allCompBPolId, *allCompBPolFile, *allCompPatternId, *allCompPatternFile, *allCompSPolFile, *allCompSvcFile, *deploycheckCheckAll, *deploycheckLong)
+	case fleetCompCmd.FullCommand():
+		deploycheck.FleetCompatible(*deploycheckOrg, *deploycheckUserPw, *fleetCompNodeOrg, *fleetCompNodeId, *fleetCompNodeType, *fleetCompNodeArch, *fleetCompDepPolId, *fleetCompDepPolFile, *fleetCompSPolFile, *fleetCompSvcFile, *deploycheckCheckAll)
 	case agreementListCmd.FullCommand():
 		agreement.List(*listArchivedAgreements, *listAgreementId)
 	case agreementCancelCmd.FullCommand():
@@ -1373,6 +1457,10 @@ Environment Variables:
 		service.Log(*logServiceName, *logServiceVersion, *logServiceContainerName, *logTail)
 	case serviceRegisteredCmd.FullCommand():
 		service.Registered()
+	case serviceVolumeListCmd.FullCommand():
+		service.VolumeList()
+	case serviceVolumePruneCmd.FullCommand():
+		service.VolumePrune(*forcePruneServiceVolumes)
 	case serviceConfigStateListCmd.FullCommand():
 		service.ListConfigState()
 	case serviceConfigStateSuspendCmd.FullCommand():
@@ -1384,7 +1472,8 @@ Environment Variables:
 	case statusCmd.FullCommand():
 		status.DisplayStatus(*statusLong, false)
 	case eventlogListCmd.FullCommand():
-		eventlog.List(*listAllEventlogs, *listDetailedEventlogs, *listSelectedEventlogs, *listTail)
+		selections := append(*listSelectedEventlogs, eventlog.GetFilterSelections(*listSeverityEventlogs, *listSourceTypeEventlogs, *listServiceEventlogs, *listAgreementEventlogs, *listNMPEventlogs, *listSinceEventlogs)...)
+		eventlog.List(*listAllEventlogs, *listDetailedEventlogs, selections, *listTail, *listLimitEventlogs, *listOffsetEventlogs, *listSortEventlogs)
 	case surfaceErrorsEventlogs.FullCommand():
 		eventlog.ListSurfaced(*surfaceErrorsEventlogsLong)
 	case devServiceNewCmd.FullCommand():
@@ -1496,5 +1585,9 @@ Environment Variables:
 		secret_manager.SecretRemove(*smOrg, *smUserPw, *smSecretRemoveName, *smSecretRemoveForce)
 	case smSecretReadCmd.FullCommand():
 		secret_manager.SecretRead(*smOrg, *smUserPw, *smSecretReadName)
+	case smSecretHistoryCmd.FullCommand():
+		secret_manager.SecretHistory(*smOrg, *smUserPw, *smSecretHistoryName)
+	case smSecretRollbackCmd.FullCommand():
+		secret_manager.SecretRollback(*smOrg, *smUserPw, *smSecretRollbackName, *smSecretRollbackVersion)
 	}
 }
@@ -174,11 +174,12 @@ func (p *NativeDeploymentConfigPlugin) Validate(dep interface{}, cdep interface{
 }
 
 // This can't be a const because a map literal isn't a const in go
-var VALID_DEPLOYMENT_FIELDS = map[string]int8{"image": 1, "privileged": 1, "cap_add": 1, "environment": 1, "devices": 1, "binds": 1, "specific_ports": 1, "command": 1, "ports": 1, "ephemeral_ports": 1, "tmpfs": 1, "network": 1, "entrypoint": 1, "max_memory_mb": 1, "max_cpus": 1, "log_driver": 1, "secrets": 1, "pid": 1, "user": 1, "sysctls": 1}
+var VALID_DEPLOYMENT_FIELDS = map[string]int8{"image": 1, "privileged": 1, "cap_add": 1, "environment": 1, "devices": 1, "binds": 1, "specific_ports": 1, "command": 1, "ports": 1, "ephemeral_ports": 1, "tmpfs": 1, "network": 1, "entrypoint": 1, "max_memory_mb": 1, "max_cpus": 1, "log_driver": 1, "secrets": 1, "pid": 1, "user": 1, "sysctls": 1, "healthcheck": 1, "restart": 1, "ulimits": 1, "pids_limit": 1, "blkio": 1, "read_only": 1, "no_new_privileges": 1, "cap_drop": 1}
 
 // CheckDeploymentService verifies it has the required 'image' key, and checks for keys we don't recognize.
 // For now it only prints a warning for unrecognized keys, in case we recently added a key to anax and haven't updated hzn yet.
 // It also checks for invalid use of the default anax port, and puts out a warning message.
+// It also verifies the format of the fields that anax validates, such as the health check and the resource limits.
 func CheckDeploymentService(svcName string, depSvc map[string]interface{}) error {
 	// get message printer
 	msgPrinter := i18n.GetMessagePrinter()
@@ -212,6 +213,16 @@ func CheckDeploymentService(svcName string, depSvc map[string]interface{}) error
 			}
 		}
 	}
+
+	// Check the fields that have to be in a specific format, such as the health check and the resource limits.
+	var svc containermessage.Service
+	if bytes, err := json.Marshal(depSvc); err != nil {
+		return errors.New(msgPrinter.Sprintf("service '%s' defined under 'deployment.services' is malformed, error %v", svcName, err))
+	} else if err := json.Unmarshal(bytes, &svc); err != nil {
+		return errors.New(msgPrinter.Sprintf("service '%s' defined under 'deployment.services' is malformed, error %v", svcName, err))
+	} else if err := svc.Validate(); err != nil {
+		return errors.New(msgPrinter.Sprintf("service '%s' defined under 'deployment.services' is not valid: %v", svcName, err))
+	}
 	return nil
 }
 
@@ -9,7 +9,10 @@ import (
 	"github.com/open-horizon/anax/cutil"
 	"github.com/open-horizon/anax/i18n"
 	"github.com/open-horizon/anax/version"
+	"io/ioutil"
+	"os"
 	"strings"
+	"time"
 )
 
 type Configstate struct {
@@ -109,6 +112,31 @@ func Version() {
 	}
 }
 
+// Create a diagnostics bundle of the agent and write it to the output file. The bundle is a gzipped tarball with the
+// status, the event log, the agreements, the services and their recent output, and the config of the agent.
+func Diagnostics(outputFile string) {
+	msgPrinter := i18n.GetMessagePrinter()
+
+	var bundle string
+	cliutils.HorizonGet("node/diagnostics", []int{200}, &bundle, false)
+
+	if outputFile == "-" {
+		if _, err := os.Stdout.Write([]byte(bundle)); err != nil {
+			cliutils.Fatal(cliutils.FILE_IO_ERROR, msgPrinter.Sprintf("failed to write the diagnostics bundle to stdout: %v", err))
+		}
+		return
+	} else if outputFile == "" {
+		outputFile = fmt.Sprintf("horizon-diagnostics-%v.tar.gz", time.Now().UTC().Format("20060102T150405Z"))
+	}
+
+	// The bundle does not contain secrets, but it is still only readable by the user.
+	if err := ioutil.WriteFile(outputFile, []byte(bundle), 0600); err != nil {
+		cliutils.Fatal(cliutils.FILE_IO_ERROR, msgPrinter.Sprintf("failed to write the diagnostics bundle to %v: %v", outputFile, err))
+	}
+	msgPrinter.Printf("Diagnostics bundle written to %v", outputFile)
+	msgPrinter.Println()
+}
+
 func Architecture() {
 	// Show client node architecture
 	fmt.Printf("%s\n", cutil.ArchString())
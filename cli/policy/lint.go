@@ -0,0 +1,151 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/open-horizon/anax/businesspolicy"
+	"github.com/open-horizon/anax/cli/cliconfig"
+	"github.com/open-horizon/anax/cli/cliutils"
+	"github.com/open-horizon/anax/exchange"
+	"github.com/open-horizon/anax/exchangecommon"
+	"github.com/open-horizon/anax/externalpolicy"
+	"github.com/open-horizon/anax/i18n"
+)
+
+// The types of policy that can be linted.
+const (
+	LINT_NODE_POLICY       = "node"
+	LINT_SERVICE_POLICY    = "service"
+	LINT_DEPLOYMENT_POLICY = "deployment"
+	LINT_NMP               = "nmp"
+)
+
+// Check the policy in the file for problems that do not prevent it from validating. When the exchange credential
+// is given, the constraints are also checked against the properties published by the nodes in the organization.
+func Lint(fileName string, policyType string, org string, userPw string) {
+	msgPrinter := i18n.GetMessagePrinter()
+
+	newBytes := cliconfig.ReadJsonFileWithLocalConfig(fileName)
+
+	if policyType == "" {
+		policyType = detectPolicyType(newBytes)
+		cliutils.Verbose(msgPrinter.Sprintf("Linting the file %v as a %v policy.", fileName, policyType))
+	}
+
+	issues := []externalpolicy.LintIssue{}
+	switch policyType {
+	case LINT_NODE_POLICY:
+		var np exchangecommon.NodePolicy
+		unmarshalLintFile(fileName, newBytes, &np)
+		if err := np.ValidateAndNormalize(); err != nil {
+			cliutils.Fatal(cliutils.CLI_INPUT_ERROR, msgPrinter.Sprintf("Incorrect node policy format in file %s: %v", fileName, err))
+		}
+
+		// the node constraints refer to the service and deployment properties, not to the node properties
+		for _, ep := range []*externalpolicy.ExternalPolicy{&np.ExternalPolicy, &np.Deployment, &np.Management} {
+			issues = append(issues, externalpolicy.LintProperties(ep.Properties)...)
+			issues = append(issues, externalpolicy.LintConstraints(&ep.Constraints, nil)...)
+		}
+	case LINT_SERVICE_POLICY:
+		var sp exchangecommon.ServicePolicy
+		unmarshalLintFile(fileName, newBytes, &sp)
+		ep := sp.GetExternalPolicy()
+		issues = append(issues, externalpolicy.LintProperties(ep.Properties)...)
+		issues = append(issues, externalpolicy.LintConstraints(&ep.Constraints, getNodeCatalog(org, userPw, false))...)
+	case LINT_DEPLOYMENT_POLICY:
+		var bp businesspolicy.BusinessPolicy
+		unmarshalLintFile(fileName, newBytes, &bp)
+		issues = bp.Lint(getNodeCatalog(org, userPw, false))
+	case LINT_NMP:
+		var nmp exchangecommon.ExchangeNodeManagementPolicy
+		unmarshalLintFile(fileName, newBytes, &nmp)
+		issues = append(issues, externalpolicy.LintProperties(nmp.Properties)...)
+		issues = append(issues, externalpolicy.LintConstraints(&nmp.Constraints, getNodeCatalog(org, userPw, true))...)
+	default:
+		cliutils.Fatal(cliutils.CLI_INPUT_ERROR, msgPrinter.Sprintf("Invalid policy type %v. The valid policy types are: %v, %v, %v and %v.", policyType, LINT_NODE_POLICY, LINT_SERVICE_POLICY, LINT_DEPLOYMENT_POLICY, LINT_NMP))
+	}
+
+	output, err := cliutils.DisplayAsJson(issues)
+	if err != nil {
+		cliutils.Fatal(cliutils.JSON_PARSING_ERROR, msgPrinter.Sprintf("failed to marshal 'hzn policy lint' output: %v", err))
+	}
+	fmt.Println(output)
+
+	errCount := 0
+	for _, issue := range issues {
+		if issue.Severity == externalpolicy.LINT_ERROR {
+			errCount++
+		}
+	}
+	if errCount != 0 {
+		cliutils.Fatal(cliutils.CLI_INPUT_ERROR, msgPrinter.Sprintf("%v error(s) found in the policy file %v.", errCount, fileName))
+	}
+}
+
+// Guess the type of policy from its top level attributes. A service policy cannot be told apart from a node
+// policy that does not use the deployment and management attributes, it has to be specified with -t.
+func detectPolicyType(content []byte) string {
+	attributes := map[string]json.RawMessage{}
+	if err := json.Unmarshal(content, &attributes); err != nil {
+		cliutils.Fatal(cliutils.JSON_PARSING_ERROR, i18n.GetMessagePrinter().Sprintf("failed to unmarshal json input: %v", err))
+	}
+
+	if _, ok := attributes["service"]; ok {
+		return LINT_DEPLOYMENT_POLICY
+	}
+	for _, attr := range []string{"patterns", "enabled", "agentUpgradePolicy"} {
+		if _, ok := attributes[attr]; ok {
+			return LINT_NMP
+		}
+	}
+	return LINT_NODE_POLICY
+}
+
+func unmarshalLintFile(fileName string, content []byte, inputFileStruct interface{}) {
+	if err := json.Unmarshal(content, inputFileStruct); err != nil {
+		cliutils.Fatal(cliutils.JSON_PARSING_ERROR, i18n.GetMessagePrinter().Sprintf("failed to unmarshal json input file %s: %v", fileName, err))
+	}
+}
+
+// Collect the properties published by the registered nodes in the organization. The deployment properties are
+// collected unless management is true. Nil is returned when no exchange credential is given.
+func getNodeCatalog(org string, userPw string, management bool) externalpolicy.PropertyCatalog {
+	if userPw == "" {
+		return nil
+	}
+
+	msgPrinter := i18n.GetMessagePrinter()
+
+	if org == "" {
+		id, _ := cliutils.SplitIdToken(userPw)
+		org, _ = cliutils.TrimOrg("", id)
+	}
+	if org == "" {
+		cliutils.Fatal(cliutils.CLI_INPUT_ERROR, msgPrinter.Sprintf("Please specify the organization with -o or in the -u credential."))
+	}
+
+	var resp exchange.GetDevicesResponse
+	cliutils.ExchangeGet("Exchange", cliutils.GetExchangeUrl(), "orgs/"+org+"/nodes", cliutils.OrgAndCreds(org, userPw), []int{200, 404}, &resp)
+
+	catalog := externalpolicy.NewPropertyCatalog()
+	for nodeId, node := range resp.Devices {
+		if node.PublicKey == "" {
+			continue
+		}
+
+		var nodePolicy exchange.ExchangeNodePolicy
+		_, nodeName := cliutils.TrimOrg(org, nodeId)
+		if httpCode := cliutils.ExchangeGet("Exchange", cliutils.GetExchangeUrl(), "orgs/"+org+"/nodes"+cliutils.AddSlash(nodeName)+"/policy", cliutils.OrgAndCreds(org, userPw), []int{200, 404}, &nodePolicy); httpCode != 200 {
+			continue
+		}
+
+		if management {
+			catalog.Add(nodePolicy.GetManagementPolicy().Properties)
+		} else {
+			catalog.Add(nodePolicy.GetDeploymentPolicy().Properties)
+		}
+	}
+
+	cliutils.Verbose(msgPrinter.Sprintf("Checking the constraints against the properties of %v nodes in organization %v.", len(resp.Devices), org))
+	return catalog
+}
@@ -137,6 +137,21 @@ func New() {
 		`                       /* ` + msgPrinter.Sprintf("It overwrites all the constraints on the top level if not empty.") + `*/`,
 		`         "" `,
 		`      ] `,
+		`  },`,
+		`  "maintenance": {     /* ` + msgPrinter.Sprintf("Optional. Limits when the agbot can change the service versions running on the node.") + ` */`,
+		`      "windows": [     /* ` + msgPrinter.Sprintf("The service versions can only be changed within one of these windows.") + ` */`,
+		`        {`,
+		`           "schedule": "0 22 * * 1-5",  /* ` + msgPrinter.Sprintf("When the window starts, in cron format: minute hour day-of-month month day-of-week.") + ` */`,
+		`           "duration": 14400,           /* ` + msgPrinter.Sprintf("The number of seconds the window lasts.") + ` */`,
+		`           "timezone": "UTC"            /* ` + msgPrinter.Sprintf("The IANA time zone of the schedule.") + ` */`,
+		`        }`,
+		`      ],`,
+		`      "blackouts": [   /* ` + msgPrinter.Sprintf("The service versions are never changed within these periods.") + ` */`,
+		`        {`,
+		`           "start": "", /* ` + msgPrinter.Sprintf("The start of the period in RFC3339 format.") + ` */`,
+		`           "end": ""    /* ` + msgPrinter.Sprintf("The end of the period in RFC3339 format.") + ` */`,
+		`        }`,
+		`      ]`,
 		`  }`,
 		`}`,
 	}
@@ -0,0 +1,128 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/open-horizon/anax/cli/cliutils"
+	"github.com/open-horizon/anax/exchange"
+	"github.com/open-horizon/anax/externalpolicy"
+	"github.com/open-horizon/anax/i18n"
+	"io/ioutil"
+	"os"
+)
+
+// The environment variable that holds the path of the property schema file of the organization.
+const PROPERTY_SCHEMA_ENV_VAR = "HZN_PROPERTY_SCHEMA"
+
+// Read the property schema of the organization. The file named by HZN_PROPERTY_SCHEMA is used if the variable is set,
+// otherwise the schema stored in the organization in the Exchange is used if an Exchange user credential is given.
+// Nil is returned if there is no property schema.
+func ReadPropertySchema(org string, userPw string) (*externalpolicy.PropertySchema, error) {
+	msgPrinter := i18n.GetMessagePrinter()
+
+	fileName := os.Getenv(PROPERTY_SCHEMA_ENV_VAR)
+	if fileName == "" {
+		if org == "" || userPw == "" {
+			return nil, nil
+		}
+		return readExchangePropertySchema(org, userPw)
+	}
+
+	content, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf(msgPrinter.Sprintf("failed to read the property schema file %v: %v", fileName, err))
+	}
+
+	schema := new(externalpolicy.PropertySchema)
+	if err := json.Unmarshal(content, schema); err != nil {
+		return nil, fmt.Errorf(msgPrinter.Sprintf("failed to unmarshal the property schema file %v: %v", fileName, err))
+	} else if err := schema.Validate(); err != nil {
+		return nil, fmt.Errorf(msgPrinter.Sprintf("Incorrect property schema in file %v: %v", fileName, err))
+	}
+	return schema, nil
+}
+
+// Get the property schema stored in the tags of the organization in the Exchange.
+func readExchangePropertySchema(org string, userPw string) (*externalpolicy.PropertySchema, error) {
+	msgPrinter := i18n.GetMessagePrinter()
+
+	var orgs exchange.GetOrganizationResponse
+	httpCode := cliutils.ExchangeGet("Exchange", cliutils.GetExchangeUrl(), "orgs/"+org, cliutils.OrgAndCreds(org, userPw), []int{200, 401, 403, 404}, &orgs)
+	if httpCode != 200 {
+		return nil, fmt.Errorf(msgPrinter.Sprintf("failed to get organization %v from the Exchange, HTTP code %v", org, httpCode))
+	}
+
+	exOrg, ok := orgs.Orgs[org]
+	if !ok {
+		return nil, fmt.Errorf(msgPrinter.Sprintf("organization %v not found in the Exchange", org))
+	}
+	schema, err := exOrg.GetPropertySchema()
+	if err != nil {
+		return nil, fmt.Errorf(msgPrinter.Sprintf("Incorrect property schema in organization %v: %v", org, err))
+	}
+	return schema, nil
+}
+
+// Validate the properties of the policies against the property schema from now on, if the schema applies
+// to the given organization. The problems are displayed as warnings unless the schema is enforced as errors.
+// A schema that cannot be read is reported as a warning and the properties are not validated.
+func LoadPropertySchema(org string, userPw string) {
+	msgPrinter := i18n.GetMessagePrinter()
+
+	schema, err := ReadPropertySchema(org, userPw)
+	if err != nil {
+		cliutils.Warning(msgPrinter.Sprintf("%v. The policy properties are not validated against the property schema.", err))
+		return
+	} else if schema == nil {
+		return
+	} else if schema.Org != "" && org != "" && schema.Org != org {
+		cliutils.Verbose(msgPrinter.Sprintf("The property schema for organization %v is not used for organization %v.", schema.Org, org))
+		return
+	}
+
+	// the same properties can be validated more than once, display each warning only once
+	displayed := map[string]bool{}
+	externalpolicy.SetPropertySchema(schema, func(msg string) {
+		if !displayed[msg] {
+			displayed[msg] = true
+			cliutils.Warning(msg)
+		}
+	})
+}
+
+// Display the property schema, or the declaration of one property in it.
+func Schema(org string, userPw string, property string) {
+	msgPrinter := i18n.GetMessagePrinter()
+
+	schema, err := ReadPropertySchema(org, userPw)
+	if err != nil {
+		cliutils.Fatal(cliutils.CLI_INPUT_ERROR, err.Error())
+	} else if schema == nil && userPw == "" {
+		cliutils.Fatal(cliutils.CLI_INPUT_ERROR, msgPrinter.Sprintf("No property schema is set. Please set %v to the path of the property schema file, or specify an Exchange user credential to get the property schema of the organization.", PROPERTY_SCHEMA_ENV_VAR))
+	} else if schema == nil {
+		cliutils.Fatal(cliutils.NOT_FOUND, msgPrinter.Sprintf("Organization %v does not have a property schema.", org))
+	}
+
+	var output interface{} = schema
+	if property != "" {
+		def, ok := schema.Properties[property]
+		if !ok {
+			cliutils.Fatal(cliutils.NOT_FOUND, msgPrinter.Sprintf("Property %v is not declared in the property schema.", property))
+		}
+		output = def
+	}
+
+	jsonOutput, err := cliutils.DisplayAsJson(output)
+	if err != nil {
+		cliutils.Fatal(cliutils.JSON_PARSING_ERROR, msgPrinter.Sprintf("failed to marshal 'hzn policy schema' output: %v", err))
+	}
+	fmt.Println(jsonOutput)
+}
+
+// Return the names of the properties declared in the property schema, for shell completion.
+func SchemaPropertyNames() []string {
+	if schema, err := ReadPropertySchema(os.Getenv("HZN_ORG_ID"), os.Getenv("HZN_EXCHANGE_USER_AUTH")); err == nil && schema != nil {
+		return schema.PropertyNames()
+	}
+	return []string{}
+}
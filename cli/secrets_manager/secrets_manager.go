@@ -287,3 +287,63 @@ func SecretRead(org, credToUse, secretName string) {
 	}
 
 }
+
+// Lists the versions of a secret retained by the secrets manager, oldest first. If the secret does not exist, an error (fatal) is raised
+func SecretHistory(org, credToUse, secretName string) {
+	// get rid of trailing / from secret name
+	if strings.HasSuffix(secretName, "/") {
+		secretName = secretName[:len(secretName)-1]
+	}
+
+	// query the agbot secure api
+	var resp []byte
+	historyQuery := func() int {
+		return cliutils.AgbotGet("org"+cliutils.AddSlash(org)+"/secretversions"+cliutils.AddSlash(secretName), cliutils.OrgAndCreds(org, credToUse),
+			[]int{200, 400, 401, 403, 404, 503}, &resp)
+	}
+	retCode := queryWithRetry(historyQuery, 3, 1)
+
+	// parse and print the response
+	if retCode == 400 || retCode == 401 || retCode == 403 || retCode == 404 || retCode == 503 {
+		respString, _ := strconv.Unquote(string(resp))
+		cliutils.Fatal(cliutils.CLI_GENERAL_ERROR, respString)
+	} else {
+		// retCode == 200
+		versions := make([]secrets.SecretVersion, 0)
+		printResponse(resp, &versions)
+	}
+}
+
+// Makes a prior version of a secret the current version. The services that use the secret are updated by the agbot in the
+// same way as when the secret is updated with the add command.
+func SecretRollback(org, credToUse, secretName string, version int) {
+	// get message printer
+	msgPrinter := i18n.GetMessagePrinter()
+
+	// get rid of trailing / from secret name
+	if strings.HasSuffix(secretName, "/") {
+		secretName = secretName[:len(secretName)-1]
+	}
+
+	if version <= 0 {
+		cliutils.Fatal(cliutils.CLI_INPUT_ERROR, msgPrinter.Sprintf("--version must be greater than 0."))
+	}
+
+	// query the agbot secure api
+	var resp []byte
+	body := map[string]int{"version": version}
+	rollbackQuery := func() int {
+		return cliutils.AgbotPutPost(http.MethodPost, "org"+cliutils.AddSlash(org)+"/secretversions"+cliutils.AddSlash(secretName),
+			cliutils.OrgAndCreds(org, credToUse), []int{201, 400, 401, 403, 404, 503}, body, &resp)
+	}
+	retCode := queryWithRetry(rollbackQuery, 3, 1)
+
+	// output success or failure
+	if retCode == 201 {
+		msgPrinter.Printf("Secret \"%s\" successfully rolled back to version %v.", secretName, version)
+		msgPrinter.Println()
+	} else {
+		respString, _ := strconv.Unquote(string(resp))
+		cliutils.Fatal(cliutils.CLI_GENERAL_ERROR, respString)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/open-horizon/anax/api"
 	"github.com/open-horizon/anax/cli/cliutils"
+	"github.com/open-horizon/anax/container"
 	"github.com/open-horizon/anax/containermessage"
 	"github.com/open-horizon/anax/cutil"
 	"github.com/open-horizon/anax/exchange"
@@ -12,6 +13,7 @@ import (
 	"github.com/open-horizon/anax/policy"
 	"github.com/open-horizon/anax/semanticversion"
 	"net/http"
+	"net/url"
 	"runtime"
 	"strings"
 )
@@ -311,3 +313,49 @@ func Resume(applyAll bool, serviceOrg string, serviceUrl string, serviceVer stri
 	}
 	msgPrinter.Println()
 }
+
+func VolumeList() {
+	// get message printer
+	msgPrinter := i18n.GetMessagePrinter()
+
+	apiOutput := make([]container.ServiceVolume, 0)
+	cliutils.HorizonGet("service/volumes", []int{200}, &apiOutput, false)
+
+	// Convert to json and output
+	jsonBytes, err := json.MarshalIndent(apiOutput, "", cliutils.JSON_INDENT)
+	if err != nil {
+		cliutils.Fatal(cliutils.JSON_PARSING_ERROR, msgPrinter.Sprintf("failed to marshal 'hzn service volume list' output: %v", err))
+	}
+	fmt.Printf("%s\n", jsonBytes)
+}
+
+func VolumePrune(forcePrune bool) {
+	// get message printer
+	msgPrinter := i18n.GetMessagePrinter()
+
+	// Only the volumes kept after the end of their agreement are deleted
+	apiOutput := make([]container.ServiceVolume, 0)
+	cliutils.HorizonGet("service/volumes", []int{200}, &apiOutput, false)
+
+	names := make([]string, 0)
+	for _, v := range apiOutput {
+		if !v.InUse {
+			names = append(names, v.Name)
+		}
+	}
+	if len(names) == 0 {
+		msgPrinter.Printf("There are no service volumes to prune.")
+		msgPrinter.Println()
+		return
+	}
+
+	if !forcePrune {
+		cliutils.ConfirmRemove(msgPrinter.Sprintf("Are you sure you want to delete the service volumes %v and their data?", strings.Join(names, ", ")))
+	}
+
+	// Only the volumes that were confirmed are deleted, not the ones released since they were listed
+	query := url.Values{"name": names}
+	cliutils.HorizonDelete("service/volumes?"+query.Encode(), []int{200, 204}, []int{}, false)
+	msgPrinter.Printf("Service volumes pruned.")
+	msgPrinter.Println()
+}
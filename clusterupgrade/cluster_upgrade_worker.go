@@ -237,7 +237,7 @@ func (w *ClusterUpgradeWorker) collectStatus(workingFolderPath string, policyNam
 				if contents.AgentUpgrade.ErrorMessage != "" {
 					status_string += fmt.Sprintf(", ErrorMessage: %v", contents.AgentUpgrade.ErrorMessage)
 				}
-				eventlog.LogNodeEvent(w.db, persistence.SEVERITY_INFO, persistence.NewMessageMeta(nodemanagement.EL_NMP_STATUS_CHANGED, policyName, status_string), persistence.EC_NMP_STATUS_UPDATE_NEW, exchange.GetId(w.GetExchangeId()), exchange.GetOrg(w.GetExchangeId()), pattern, configState)
+				eventlog.LogNodeManagementEvent(w.db, persistence.SEVERITY_INFO, persistence.NewMessageMeta(nodemanagement.EL_NMP_STATUS_CHANGED, policyName, status_string), persistence.EC_NMP_STATUS_UPDATE_NEW, exchange.GetId(w.GetExchangeId()), exchange.GetOrg(w.GetExchangeId()), pattern, configState, policyName)
 			}
 		}
 	}
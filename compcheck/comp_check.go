@@ -176,6 +176,9 @@ type CompCheckResource struct {
 	// in the 'Service' attribute when this attribute is not empty.
 	NeededSB     []exchangecommon.SecretBinding `json:"needed_secret_binding,omitempty"`
 	ExtraneousSB []exchangecommon.SecretBinding `json:"extraneous_secret_binding,omitempty"`
+	// The evaluation of the deployment and node constraints for each service, keyed by service id. It shows
+	// which constraint clauses are not satisfied when the policies are not compatible.
+	ConstraintEvaluation map[string]PolicyEvaluation `json:"constraint_evaluation,omitempty"`
 }
 
 func (p CompCheckResource) String() string {
@@ -536,6 +539,7 @@ func createCompCheckOutput(pcOutput *CompCheckOutput, privOutput *CompCheckOutpu
 	ccInput.BusinessPolicy = lastOutput.Input.BusinessPolicy
 	ccInput.Service = lastOutput.Input.Service
 	ccInput.ServicePolicy = pcOutput.Input.ServicePolicy
+	ccInput.ConstraintEvaluation = pcOutput.Input.ConstraintEvaluation
 	ccOutput.Input = &ccInput
 
 	if sbOutput != nil {
@@ -723,7 +727,7 @@ func ServicesRequirePrivilege(topSvc common.AbstractServiceFile, topSvcId string
 	return reqPriv, nil, privSvcs
 }
 
-// Check if the deployment string given uses the privileged flag or network=host
+// Check if the deployment string given uses the privileged flag or network=host, or raises the scheduling priority ulimits
 func DeploymentRequiresPrivilege(deploymentString string, msgPrinter *message.Printer) (bool, error) {
 	if deploymentString == "" {
 		return false, nil
@@ -738,7 +742,7 @@ func DeploymentRequiresPrivilege(deploymentString string, msgPrinter *message.Pr
 	}
 	for _, topSvc := range deploymentStruct.Services {
 		if topSvc != nil {
-			if topSvc.Privileged || topSvc.Network == "host" {
+			if topSvc.RequiresPrivilege() {
 				return true, nil
 			}
 		}
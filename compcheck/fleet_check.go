@@ -0,0 +1,363 @@
+package compcheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/open-horizon/anax/businesspolicy"
+	"github.com/open-horizon/anax/common"
+	"github.com/open-horizon/anax/exchange"
+	"github.com/open-horizon/anax/externalpolicy"
+	"github.com/open-horizon/anax/i18n"
+	"github.com/open-horizon/anax/policy"
+	"golang.org/x/text/message"
+	"path"
+	"sort"
+)
+
+// The input format for the fleet compatibility check. The deployment policy is checked against
+// every node in the node organization that passes the node filter.
+type FleetCheck struct {
+	NodeOrg        string                         `json:"node_org,omitempty"` // can be omitted if business_policy_id is specified
+	NodeFilter     *NodeFilter                    `json:"node_filter,omitempty"`
+	BusinessPolId  string                         `json:"business_policy_id,omitempty"`
+	BusinessPolicy *businesspolicy.BusinessPolicy `json:"business_policy,omitempty"`
+	ServicePolicy  *externalpolicy.ExternalPolicy `json:"service_policy,omitempty"`
+	Service        []common.AbstractServiceFile   `json:"service,omitempty"`
+}
+
+func (p FleetCheck) String() string {
+	return fmt.Sprintf("NodeOrg: %v, NodeFilter: %v, BusinessPolId: %v, BusinessPolicy: %v, ServicePolicy: %v, Service: %v",
+		p.NodeOrg, p.NodeFilter, p.BusinessPolId, p.BusinessPolicy, p.ServicePolicy, p.Service)
+}
+
+// stucture for the readining of the inital input for the agbot secure API.
+type FleetCheck_NoAbstract struct {
+	NodeOrg        string                         `json:"node_org,omitempty"`
+	NodeFilter     *NodeFilter                    `json:"node_filter,omitempty"`
+	BusinessPolId  string                         `json:"business_policy_id,omitempty"`
+	BusinessPolicy *businesspolicy.BusinessPolicy `json:"business_policy,omitempty"`
+	ServicePolicy  *externalpolicy.ExternalPolicy `json:"service_policy,omitempty"`
+	Service        []common.ServiceFile           `json:"service,omitempty"`
+}
+
+// unmashal handler for FleetCheck object to handle AbstractServiceFile
+func (p *FleetCheck) UnmarshalJSON(b []byte) error {
+
+	var fc FleetCheck_NoAbstract
+	if err := json.Unmarshal(b, &fc); err != nil {
+		return err
+	}
+
+	p.NodeOrg = fc.NodeOrg
+	p.NodeFilter = fc.NodeFilter
+	p.BusinessPolId = fc.BusinessPolId
+	p.BusinessPolicy = fc.BusinessPolicy
+	p.ServicePolicy = fc.ServicePolicy
+
+	if fc.Service != nil && len(fc.Service) != 0 {
+		p.Service = []common.AbstractServiceFile{}
+		for index, _ := range fc.Service {
+			p.Service = append(p.Service, &fc.Service[index])
+		}
+	}
+
+	return nil
+}
+
+// The filter that selects the nodes for the fleet compatibility check. An empty field matches all the nodes.
+type NodeFilter struct {
+	NodeId   string `json:"node_id,omitempty"` // a shell pattern such as "edge-*", matched against the node id without the org
+	NodeType string `json:"node_type,omitempty"`
+	NodeArch string `json:"node_arch,omitempty"`
+}
+
+func (f NodeFilter) String() string {
+	return fmt.Sprintf("NodeId: %v, NodeType: %v, NodeArch: %v", f.NodeId, f.NodeType, f.NodeArch)
+}
+
+// Returns true if the given exchange node passes the filter.
+func (f *NodeFilter) Matches(nodeId string, node *exchange.Device) bool {
+	if f == nil {
+		return true
+	}
+	if f.NodeId != "" {
+		if matched, err := path.Match(f.NodeId, exchange.GetId(nodeId)); err != nil || !matched {
+			return false
+		}
+	}
+	if f.NodeType != "" && f.NodeType != node.GetNodeType() {
+		return false
+	}
+	if f.NodeArch != "" && f.NodeArch != node.Arch {
+		return false
+	}
+	return true
+}
+
+// FleetCheckOutput The output format for the fleet compatibility check
+// swagger:model
+type FleetCheckOutput struct {
+	NumNodes     int                          `json:"num_nodes"`     // the number of nodes that passed the node filter
+	Compatible   []string                     `json:"compatible"`    // the ids of the compatible nodes
+	Incompatible map[string]map[string]string `json:"incompatible"`  // the reasons for each incompatible node, keyed by node id
+	ReasonCounts map[string]int               `json:"reason_counts"` // the number of incompatible nodes for each reason
+}
+
+func (p *FleetCheckOutput) String() string {
+	return fmt.Sprintf("NumNodes: %v, Compatible: %v, Incompatible: %v, ReasonCounts: %v",
+		p.NumNodes, p.Compatible, p.Incompatible, p.ReasonCounts)
+}
+
+// Add the result of the compatibility check of a node to the fleet output.
+func (p *FleetCheckOutput) addNode(nodeId string, compatible bool, reason map[string]string, msgPrinter *message.Printer) {
+	p.NumNodes++
+	if compatible {
+		p.Compatible = append(p.Compatible, nodeId)
+		return
+	}
+
+	p.Incompatible[nodeId] = reason
+
+	// a node is counted once for each distinct reason, the compatible services do not count.
+	msg_compatible := msgPrinter.Sprintf("Compatible")
+	counted := map[string]bool{}
+	for _, rs := range reason {
+		if rs != msg_compatible && !counted[rs] {
+			counted[rs] = true
+			p.ReasonCounts[rs]++
+		}
+	}
+}
+
+// A function that is called with the compatibility check output of each node. It can do additional
+// checks that are outside of the exchange, for example the secret manager, and update the output.
+type FleetNodeCheck func(nodeId string, ccOutput *CompCheckOutput) error
+
+// Check the deployment compatibility of a deployment policy against all the nodes in an organization.
+// The policy, user input and secret binding checks are done for each node by DeployCompatible.
+func FleetCompatible(ec exchange.ExchangeContext, agbotUrl string, fcInput *FleetCheck, checkAllSvcs bool, nodeCheck FleetNodeCheck, msgPrinter *message.Printer) (*FleetCheckOutput, error) {
+
+	getOrgDevices := exchange.GetHTTPOrgDevicesHandler(ec)
+	getBusinessPolicies := exchange.GetHTTPBusinessPoliciesHandler(ec)
+	deployCheck := fleetDeployCheck(ec, agbotUrl, checkAllSvcs, msgPrinter)
+
+	return fleetCompatible(getOrgDevices, getBusinessPolicies, deployCheck, fcInput, nodeCheck, msgPrinter)
+}
+
+// Return the deployment check for the nodes of a fleet. The services of the deployment policy, their dependencies and
+// policies, and the secrets they use are the same for every node, so they are read from the exchange once for the fleet
+// instead of once for each node. Only the node and its policy are read for each node.
+func fleetDeployCheck(ec exchange.ExchangeContext, agbotUrl string, checkAllSvcs bool, msgPrinter *message.Printer) func(ccInput *CompCheck) (*CompCheckOutput, error) {
+
+	getDeviceHandler := exchange.GetHTTPDeviceHandler(ec)
+	nodePolicyHandler := exchange.GetHTTPNodePolicyHandler(ec)
+	getBusinessPolicies := exchange.GetHTTPBusinessPoliciesHandler(ec)
+	getPatterns := exchange.GetHTTPExchangePatternHandler(ec)
+	servicePolicyHandler := cachedServicePolicyHandler(exchange.GetHTTPServicePolicyHandler(ec))
+	getServiceHandler := cachedServiceHandler(exchange.GetHTTPServiceHandler(ec))
+	serviceDefResolverHandler := cachedServiceDefResolverHandler(exchange.GetHTTPServiceDefResolverHandler(ec))
+	getSelectedServices := cachedSelectedServicesHandler(exchange.GetHTTPSelectedServicesHandler(ec))
+	vaultSecretExists := cachedVaultSecretExistsHandler(exchange.GetHTTPVaultSecretExistsHandler(ec))
+
+	return func(ccInput *CompCheck) (*CompCheckOutput, error) {
+		return deployCompatible(getDeviceHandler, nodePolicyHandler, getBusinessPolicies, getPatterns, servicePolicyHandler, getServiceHandler, serviceDefResolverHandler, getSelectedServices, vaultSecretExists, agbotUrl, ccInput, checkAllSvcs, msgPrinter)
+	}
+}
+
+// The handlers below keep the results of the exchange calls for the duration of a fleet check, keyed by the call
+// arguments. Errors are not kept. The handlers are not safe for concurrent use.
+
+func cachedServicePolicyHandler(handler exchange.ServicePolicyHandler) exchange.ServicePolicyHandler {
+	type result struct {
+		pol *exchange.ExchangeServicePolicy
+		id  string
+	}
+	cache := map[string]result{}
+	return func(sUrl string, sOrg string, sVersion string, sArch string) (*exchange.ExchangeServicePolicy, string, error) {
+		key := fmt.Sprintf("%v/%v/%v/%v", sOrg, sUrl, sVersion, sArch)
+		if r, ok := cache[key]; ok {
+			return r.pol, r.id, nil
+		}
+		pol, id, err := handler(sUrl, sOrg, sVersion, sArch)
+		if err == nil {
+			cache[key] = result{pol: pol, id: id}
+		}
+		return pol, id, err
+	}
+}
+
+func cachedServiceHandler(handler exchange.ServiceHandler) exchange.ServiceHandler {
+	type result struct {
+		def *exchange.ServiceDefinition
+		id  string
+	}
+	cache := map[string]result{}
+	return func(wUrl string, wOrg string, wVersion string, wArch string) (*exchange.ServiceDefinition, string, error) {
+		key := fmt.Sprintf("%v/%v/%v/%v", wOrg, wUrl, wVersion, wArch)
+		if r, ok := cache[key]; ok {
+			return r.def, r.id, nil
+		}
+		def, id, err := handler(wUrl, wOrg, wVersion, wArch)
+		if err == nil {
+			cache[key] = result{def: def, id: id}
+		}
+		return def, id, err
+	}
+}
+
+func cachedServiceDefResolverHandler(handler exchange.ServiceDefResolverHandler) exchange.ServiceDefResolverHandler {
+	type result struct {
+		apiSpecs *policy.APISpecList
+		deps     map[string]exchange.ServiceDefinition
+		def      *exchange.ServiceDefinition
+		id       string
+	}
+	cache := map[string]result{}
+	return func(wUrl string, wOrg string, wVersion string, wArch string) (*policy.APISpecList, map[string]exchange.ServiceDefinition, *exchange.ServiceDefinition, string, error) {
+		key := fmt.Sprintf("%v/%v/%v/%v", wOrg, wUrl, wVersion, wArch)
+		if r, ok := cache[key]; ok {
+			return r.apiSpecs, r.deps, r.def, r.id, nil
+		}
+		apiSpecs, deps, def, id, err := handler(wUrl, wOrg, wVersion, wArch)
+		if err == nil {
+			cache[key] = result{apiSpecs: apiSpecs, deps: deps, def: def, id: id}
+		}
+		return apiSpecs, deps, def, id, err
+	}
+}
+
+func cachedSelectedServicesHandler(handler exchange.SelectedServicesHandler) exchange.SelectedServicesHandler {
+	cache := map[string]map[string]exchange.ServiceDefinition{}
+	return func(wUrl string, wOrg string, wVersion string, wArch string) (map[string]exchange.ServiceDefinition, error) {
+		key := fmt.Sprintf("%v/%v/%v/%v", wOrg, wUrl, wVersion, wArch)
+		if svcs, ok := cache[key]; ok {
+			return svcs, nil
+		}
+		svcs, err := handler(wUrl, wOrg, wVersion, wArch)
+		if err == nil {
+			cache[key] = svcs
+		}
+		return svcs, err
+	}
+}
+
+func cachedOrgDevicesHandler(handler exchange.OrgDevicesHandler) exchange.OrgDevicesHandler {
+	cache := map[string]map[string]exchange.Device{}
+	return func(orgId string) (map[string]exchange.Device, error) {
+		if nodes, ok := cache[orgId]; ok {
+			return nodes, nil
+		}
+		nodes, err := handler(orgId)
+		if err == nil {
+			cache[orgId] = nodes
+		}
+		return nodes, err
+	}
+}
+
+func cachedVaultSecretExistsHandler(handler exchange.VaultSecretExistsHandler) exchange.VaultSecretExistsHandler {
+	cache := map[string]bool{}
+	return func(agbotURL string, org string, userName string, secretName string) (bool, error) {
+		key := fmt.Sprintf("%v/%v/%v/%v", agbotURL, org, userName, secretName)
+		if exists, ok := cache[key]; ok {
+			return exists, nil
+		}
+		exists, err := handler(agbotURL, org, userName, secretName)
+		if err == nil {
+			cache[key] = exists
+		}
+		return exists, err
+	}
+}
+
+// Internal function for FleetCompatible
+func fleetCompatible(getOrgDevices exchange.OrgDevicesHandler,
+	getBusinessPolicies exchange.BusinessPoliciesHandler,
+	deployCheck func(ccInput *CompCheck) (*CompCheckOutput, error),
+	fcInput *FleetCheck, nodeCheck FleetNodeCheck, msgPrinter *message.Printer) (*FleetCheckOutput, error) {
+
+	// get default message printer if nil
+	if msgPrinter == nil {
+		msgPrinter = i18n.GetMessagePrinter()
+	}
+
+	if fcInput == nil {
+		return nil, NewCompCheckError(fmt.Errorf(msgPrinter.Sprintf("The fleet check input cannot be null")), COMPCHECK_INPUT_ERROR)
+	}
+
+	// get the deployment policy only once instead of once for each node
+	bPolicy, _, err := processBusinessPolicy(getBusinessPolicies, fcInput.BusinessPolId, fcInput.BusinessPolicy, false, msgPrinter)
+	if err != nil {
+		return nil, err
+	}
+
+	nodeOrg := fcInput.NodeOrg
+	if nodeOrg == "" {
+		nodeOrg = exchange.GetOrg(fcInput.BusinessPolId)
+	}
+	if nodeOrg == "" {
+		return nil, NewCompCheckError(fmt.Errorf(msgPrinter.Sprintf("The node organization is not specified.")), COMPCHECK_INPUT_ERROR)
+	}
+
+	if fcInput.NodeFilter != nil && fcInput.NodeFilter.NodeId != "" {
+		if _, err := path.Match(fcInput.NodeFilter.NodeId, ""); err != nil {
+			return nil, NewCompCheckError(fmt.Errorf(msgPrinter.Sprintf("Invalid node id pattern %v in the node filter: %v", fcInput.NodeFilter.NodeId, err)), COMPCHECK_INPUT_ERROR)
+		}
+	}
+
+	nodes, err := getOrgDevices(nodeOrg)
+	if err != nil {
+		return nil, NewCompCheckError(fmt.Errorf(msgPrinter.Sprintf("Error getting nodes for organization %v from the Exchange. %v", nodeOrg, err)), COMPCHECK_EXCHANGE_ERROR)
+	}
+
+	// check the nodes in a stable order
+	nodeIds := make([]string, 0, len(nodes))
+	for nodeId, _ := range nodes {
+		nodeIds = append(nodeIds, nodeId)
+	}
+	sort.Strings(nodeIds)
+
+	output := FleetCheckOutput{Compatible: []string{}, Incompatible: map[string]map[string]string{}, ReasonCounts: map[string]int{}}
+	for _, nodeId := range nodeIds {
+		node := nodes[nodeId]
+		if !fcInput.NodeFilter.Matches(nodeId, &node) {
+			continue
+		}
+
+		// the agbot does not make agreements with these nodes for a deployment policy
+		if node.Pattern != "" {
+			output.addNode(nodeId, false, map[string]string{"general": msgPrinter.Sprintf("The node is registered with pattern %v.", node.Pattern)}, msgPrinter)
+			continue
+		} else if node.PublicKey == "" {
+			output.addNode(nodeId, false, map[string]string{"general": msgPrinter.Sprintf("The node is not registered.")}, msgPrinter)
+			continue
+		}
+
+		ccInput := CompCheck{
+			NodeId:         nodeId,
+			NodeOrg:        nodeOrg,
+			BusinessPolId:  fcInput.BusinessPolId,
+			BusinessPolicy: bPolicy,
+			ServicePolicy:  fcInput.ServicePolicy,
+			Service:        fcInput.Service,
+		}
+
+		// an error for one node should not stop the check for the rest of the fleet.
+		ccOutput, err := deployCheck(&ccInput)
+		if err != nil {
+			output.addNode(nodeId, false, map[string]string{"general": msgPrinter.Sprintf("Error checking the node: %v", err)}, msgPrinter)
+			continue
+		}
+
+		if nodeCheck != nil {
+			if err := nodeCheck(nodeId, ccOutput); err != nil {
+				output.addNode(nodeId, false, map[string]string{"general": msgPrinter.Sprintf("Error checking the node: %v", err)}, msgPrinter)
+				continue
+			}
+		}
+
+		output.addNode(nodeId, ccOutput.Compatible, ccOutput.Reason, msgPrinter)
+	}
+
+	return &output, nil
+}
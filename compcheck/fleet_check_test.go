@@ -0,0 +1,165 @@
+//go:build unit
+// +build unit
+
+package compcheck
+
+import (
+	"errors"
+	"github.com/open-horizon/anax/businesspolicy"
+	"github.com/open-horizon/anax/exchange"
+	"github.com/open-horizon/anax/i18n"
+	"github.com/open-horizon/anax/policy"
+	"testing"
+)
+
+func Test_fleetCompatible(t *testing.T) {
+
+	msgPrinter := i18n.GetMessagePrinter()
+
+	service := businesspolicy.ServiceRef{
+		Name:            "weather",
+		Org:             "myorg",
+		Arch:            "amd64",
+		ServiceVersions: []businesspolicy.WorkloadChoice{businesspolicy.WorkloadChoice{Version: "1.0.1"}},
+	}
+	getBusinessPolicies := getBusinessPolicyHandler(service, map[string]string{"prop1": "val1"}, []string{"prop3 == val3"})
+
+	getOrgDevices := func(org string) (map[string]exchange.Device, error) {
+		return map[string]exchange.Device{
+			org + "/edge1":   exchange.Device{Arch: "amd64", PublicKey: "key"},
+			org + "/edge2":   exchange.Device{Arch: "amd64", PublicKey: "key"},
+			org + "/edge3":   exchange.Device{Arch: "amd64", PublicKey: "key"},
+			org + "/edge4":   exchange.Device{Arch: "amd64", PublicKey: ""},
+			org + "/edge5":   exchange.Device{Arch: "amd64", PublicKey: "key", Pattern: "myorg/mypattern"},
+			org + "/edge6":   exchange.Device{Arch: "amd64", PublicKey: "key"},
+			org + "/cluster": exchange.Device{Arch: "amd64", PublicKey: "key", NodeType: "cluster"},
+		}, nil
+	}
+
+	msg_incompatible := "Policy Incompatible: node properties do not satisfy the constraints"
+	deployCheck := func(ccInput *CompCheck) (*CompCheckOutput, error) {
+		if ccInput.BusinessPolicy == nil || ccInput.NodeOrg != "myorg" {
+			t.Errorf("wrong deploy check input: %v", ccInput)
+		}
+		switch ccInput.NodeId {
+		case "myorg/edge2", "myorg/edge3":
+			return NewCompCheckOutput(false, map[string]string{"myorg/weather_1.0.1_amd64": msg_incompatible}, nil), nil
+		case "myorg/edge6":
+			return nil, errors.New("exchange error")
+		default:
+			return NewCompCheckOutput(true, map[string]string{"myorg/weather_1.0.1_amd64": "Compatible"}, nil), nil
+		}
+	}
+
+	input := FleetCheck{BusinessPolId: "myorg/mybp", NodeFilter: &NodeFilter{NodeId: "edge*"}}
+	if output, err := fleetCompatible(getOrgDevices, getBusinessPolicies, deployCheck, &input, nil, msgPrinter); err != nil {
+		t.Errorf("fleetCompatible should have returned nil error but got: %v", err)
+	} else if output.NumNodes != 6 {
+		t.Errorf("fleetCompatible should have checked 6 nodes but got: %v", output)
+	} else if len(output.Compatible) != 1 || output.Compatible[0] != "myorg/edge1" {
+		t.Errorf("only myorg/edge1 should be compatible but got: %v", output.Compatible)
+	} else if len(output.Incompatible) != 5 {
+		t.Errorf("fleetCompatible should have returned 5 incompatible nodes but got: %v", output.Incompatible)
+	} else if output.ReasonCounts[msg_incompatible] != 2 {
+		t.Errorf("the policy reason should have been counted twice but got: %v", output.ReasonCounts)
+	} else if len(output.ReasonCounts) != 4 {
+		t.Errorf("fleetCompatible should have returned 4 reasons but got: %v", output.ReasonCounts)
+	}
+
+	// the node check can make a compatible node incompatible
+	input = FleetCheck{BusinessPolId: "myorg/mybp", NodeFilter: &NodeFilter{NodeType: "cluster"}}
+	nodeCheck := func(nodeId string, ccOutput *CompCheckOutput) error {
+		ccOutput.Compatible = false
+		ccOutput.Reason["general"] = "secret not found"
+		return nil
+	}
+	if output, err := fleetCompatible(getOrgDevices, getBusinessPolicies, deployCheck, &input, nodeCheck, msgPrinter); err != nil {
+		t.Errorf("fleetCompatible should have returned nil error but got: %v", err)
+	} else if output.NumNodes != 1 || len(output.Compatible) != 0 || output.ReasonCounts["secret not found"] != 1 {
+		t.Errorf("the cluster node should be incompatible but got: %v", output)
+	}
+
+	// an error from the node check only makes that node incompatible
+	input = FleetCheck{BusinessPolId: "myorg/mybp", NodeFilter: &NodeFilter{NodeId: "edge[123]"}}
+	nodeCheck = func(nodeId string, ccOutput *CompCheckOutput) error {
+		if nodeId == "myorg/edge1" {
+			return errors.New("secret manager error")
+		}
+		return nil
+	}
+	if output, err := fleetCompatible(getOrgDevices, getBusinessPolicies, deployCheck, &input, nodeCheck, msgPrinter); err != nil {
+		t.Errorf("fleetCompatible should have returned nil error but got: %v", err)
+	} else if output.NumNodes != 3 || len(output.Compatible) != 0 || len(output.Incompatible) != 3 {
+		t.Errorf("all 3 nodes should have been checked and be incompatible but got: %v", output)
+	} else if output.Incompatible["myorg/edge1"]["general"] != "Error checking the node: secret manager error" {
+		t.Errorf("the node check error should be the reason for myorg/edge1 but got: %v", output.Incompatible["myorg/edge1"])
+	}
+
+	// the node org cannot be determined from a deployment policy without an id
+	input = FleetCheck{BusinessPolicy: createBusinessPolicy(service, nil, nil)}
+	if _, err := fleetCompatible(getOrgDevices, getBusinessPolicies, deployCheck, &input, nil, msgPrinter); err == nil {
+		t.Errorf("fleetCompatible should have returned an error for a missing node org")
+	}
+
+	// bad node id pattern
+	input = FleetCheck{BusinessPolId: "myorg/mybp", NodeFilter: &NodeFilter{NodeId: "edge["}}
+	if _, err := fleetCompatible(getOrgDevices, getBusinessPolicies, deployCheck, &input, nil, msgPrinter); err == nil {
+		t.Errorf("fleetCompatible should have returned an error for a bad node id pattern")
+	}
+}
+
+func Test_fleet_cached_handlers(t *testing.T) {
+
+	calls := 0
+	getServicePolicy := cachedServicePolicyHandler(func(sUrl string, sOrg string, sVersion string, sArch string) (*exchange.ExchangeServicePolicy, string, error) {
+		calls++
+		if sArch == "arm" {
+			return nil, "", errors.New("exchange error")
+		}
+		return &exchange.ExchangeServicePolicy{}, sOrg + "/" + sUrl + "_" + sVersion + "_" + sArch, nil
+	})
+
+	// the service policy is read once for all the nodes, errors are read again
+	for i := 0; i < 5; i++ {
+		if pol, id, err := getServicePolicy("weather", "myorg", "1.0.1", "amd64"); err != nil || pol == nil || id != "myorg/weather_1.0.1_amd64" {
+			t.Errorf("wrong service policy %v %v, error %v", pol, id, err)
+		} else if _, _, err := getServicePolicy("weather", "myorg", "1.0.1", "arm"); err == nil {
+			t.Errorf("expected an error for arm")
+		}
+	}
+	if calls != 6 {
+		t.Errorf("expected 6 exchange calls but got %v", calls)
+	}
+
+	calls = 0
+	resolveService := cachedServiceDefResolverHandler(func(wUrl string, wOrg string, wVersion string, wArch string) (*policy.APISpecList, map[string]exchange.ServiceDefinition, *exchange.ServiceDefinition, string, error) {
+		calls++
+		return nil, map[string]exchange.ServiceDefinition{"myorg/dep_1.0.0_amd64": {URL: "dep"}}, &exchange.ServiceDefinition{URL: wUrl}, wOrg + "/" + wUrl, nil
+	})
+	for i := 0; i < 5; i++ {
+		for _, url := range []string{"weather", "traffic"} {
+			if _, deps, def, id, err := resolveService(url, "myorg", "[1.0.0,INFINITY)", "amd64"); err != nil || len(deps) != 1 || def.URL != url || id != "myorg/"+url {
+				t.Errorf("wrong service %v %v %v, error %v", deps, def, id, err)
+			}
+		}
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 exchange calls but got %v", calls)
+	}
+
+	calls = 0
+	secretExists := cachedVaultSecretExistsHandler(func(agbotURL string, org string, userName string, secretName string) (bool, error) {
+		calls++
+		return secretName == "sec1", nil
+	})
+	for i := 0; i < 5; i++ {
+		if exists, _ := secretExists("", "myorg", "", "sec1"); !exists {
+			t.Errorf("sec1 should exist")
+		} else if exists, _ := secretExists("", "myorg", "", "sec2"); exists {
+			t.Errorf("sec2 should not exist")
+		}
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 exchange calls but got %v", calls)
+	}
+}
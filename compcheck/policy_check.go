@@ -217,10 +217,12 @@ func policyCompatible(getDeviceHandler exchange.DeviceHandler,
 					}
 					if compatible {
 						// policy compatibility check
-						compatible, reason, _, _, err1 = CheckPolicyCompatiblility(nPolicy, bPolicy, mergedServicePol, resources.NodeArch, msgPrinter)
+						var producerPol, consumerPol *policy.Policy
+						compatible, reason, producerPol, consumerPol, err1 = CheckPolicyCompatiblility(nPolicy, bPolicy, mergedServicePol, resources.NodeArch, msgPrinter)
 						if err1 != nil {
 							return nil, err1
 						}
+						resources.addConstraintEvaluation(sId, producerPol, consumerPol)
 					}
 					if compatible {
 						overall_compatible = true
@@ -262,10 +264,12 @@ func policyCompatible(getDeviceHandler exchange.DeviceHandler,
 							}
 							if compatible {
 								// policy compatibility check
-								compatible, reason, _, _, err = CheckPolicyCompatiblility(nPolicy, bPolicy, mergedServicePol, resources.NodeArch, msgPrinter)
+								var producerPol, consumerPol *policy.Policy
+								compatible, reason, producerPol, consumerPol, err = CheckPolicyCompatiblility(nPolicy, bPolicy, mergedServicePol, resources.NodeArch, msgPrinter)
 								if err != nil {
 									return nil, err
 								}
+								resources.addConstraintEvaluation(sId, producerPol, consumerPol)
 							}
 							if compatible {
 								overall_compatible = true
@@ -314,10 +318,12 @@ func policyCompatible(getDeviceHandler exchange.DeviceHandler,
 				}
 				if compatible {
 					// policy compatibility check
-					compatible, reason, _, _, err1 = CheckPolicyCompatiblility(nPolicy, bPolicy, mergedServicePol, resources.NodeArch, msgPrinter)
+					var producerPol, consumerPol *policy.Policy
+					compatible, reason, producerPol, consumerPol, err1 = CheckPolicyCompatiblility(nPolicy, bPolicy, mergedServicePol, resources.NodeArch, msgPrinter)
 					if err1 != nil {
 						return nil, err1
 					}
+					resources.addConstraintEvaluation(sId, producerPol, consumerPol)
 				}
 			}
 			if compatible {
@@ -396,6 +402,46 @@ func CheckPolicyCompatiblility(nodePolicy *policy.Policy, businessPolicy *policy
 	}
 }
 
+// The evaluation of the constraints of a deployment for one service. The deployment constraints are the constraints of
+// the deployment policy and the service policy, evaluated against the node properties. The node constraints are
+// evaluated against the properties of the deployment policy and the service policy.
+type PolicyEvaluation struct {
+	DeploymentConstraints *externalpolicy.ConstraintEvaluation `json:"deployment_constraints,omitempty"`
+	NodeConstraints       *externalpolicy.ConstraintEvaluation `json:"node_constraints,omitempty"`
+}
+
+func (p PolicyEvaluation) String() string {
+	return fmt.Sprintf("DeploymentConstraints: %v, NodeConstraints: %v", p.DeploymentConstraints, p.NodeConstraints)
+}
+
+// Evaluate the constraints of the merged producer (node) and consumer (deployment) policies returned by
+// CheckPolicyCompatiblility so that the user can see which constraint clauses are not satisfied.
+func EvaluatePolicyConstraints(producerPol *policy.Policy, consumerPol *policy.Policy) (*PolicyEvaluation, error) {
+	if producerPol == nil || consumerPol == nil {
+		return nil, nil
+	}
+
+	var pe PolicyEvaluation
+	var err error
+	if pe.DeploymentConstraints, err = consumerPol.Constraints.Evaluate(producerPol.Properties); err != nil {
+		return nil, err
+	}
+	if pe.NodeConstraints, err = producerPol.Constraints.Evaluate(consumerPol.Properties); err != nil {
+		return nil, err
+	}
+	return &pe, nil
+}
+
+// add the constraint evaluation for a service. The evaluation is only informational, so it is left out on error.
+func (p *CompCheckResource) addConstraintEvaluation(sId string, producerPol *policy.Policy, consumerPol *policy.Policy) {
+	if pe, err := EvaluatePolicyConstraints(producerPol, consumerPol); err == nil && pe != nil {
+		if p.ConstraintEvaluation == nil {
+			p.ConstraintEvaluation = map[string]PolicyEvaluation{}
+		}
+		p.ConstraintEvaluation[sId] = *pe
+	}
+}
+
 // add node arch property to the node policy. node arch can be empty
 func addNodeArchToPolicy(nodePolicy *policy.Policy, nodeArch string, msgPrinter *message.Printer) (*policy.Policy, error) {
 	// get default message printer if nil
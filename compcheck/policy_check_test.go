@@ -861,6 +861,19 @@ func Test_CheckPolicyCompatiblility(t *testing.T) {
 		t.Errorf("The consumerPolicy should not have 2 constraints but got %v", len(consumerPolicy.Constraints))
 	}
 
+	// the evaluation shows the deployment constraint that the node does not satisfy
+	if _, _, producerPolicy, consumerPolicy, _ := CheckPolicyCompatiblility(intNPol1, intBPol, mergedSPol, "", msgPrinter); producerPolicy != nil && consumerPolicy != nil {
+		if pe, err := EvaluatePolicyConstraints(producerPolicy, consumerPolicy); err != nil {
+			t.Errorf("EvaluatePolicyConstraints should have returned nil error but got: %v", err)
+		} else if pe.DeploymentConstraints == nil || pe.DeploymentConstraints.Satisfied || len(pe.DeploymentConstraints.SubExpressions) != 2 {
+			t.Errorf("The deployment constraints should not be satisfied but got: %v", pe.DeploymentConstraints)
+		} else if prop4 := pe.DeploymentConstraints.SubExpressions[1]; prop4.Satisfied || prop4.PropertyValue != "some other value" {
+			t.Errorf("The prop4 constraint should not be satisfied but got: %v", prop4)
+		} else if pe.NodeConstraints == nil || !pe.NodeConstraints.Satisfied {
+			t.Errorf("The node constraints should be satisfied but got: %v", pe.NodeConstraints)
+		}
+	}
+
 	// compatible, node arch is not empty -- this is the case when this function is called from policyCompatible_Pols
 	if compatible, reason, producerPolicy, _, err := CheckPolicyCompatiblility(intNPol, intBPol, mergedSPol, "amd64", msgPrinter); err != nil {
 		t.Errorf("CheckPolicyCompatiblility should have returned nil error but got: %v", err)
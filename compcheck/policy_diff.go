@@ -0,0 +1,349 @@
+package compcheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/open-horizon/anax/businesspolicy"
+	"github.com/open-horizon/anax/cutil"
+	"github.com/open-horizon/anax/exchange"
+	"github.com/open-horizon/anax/i18n"
+	"golang.org/x/text/message"
+	"sort"
+	"strings"
+)
+
+// A value that was added, removed or changed between 2 versions of a deployment policy. Old is nil
+// for an added value and New is nil for a removed value.
+type ValueChange struct {
+	Service string      `json:"service,omitempty"` // the service the user input or secret binding applies to
+	Name    string      `json:"name"`
+	Old     interface{} `json:"old"`
+	New     interface{} `json:"new"`
+}
+
+func (v ValueChange) String() string {
+	return fmt.Sprintf("Service: %v, Name: %v, Old: %v, New: %v", v.Service, v.Name, v.Old, v.New)
+}
+
+// The changes between 2 versions of a deployment policy.
+type BusinessPolicyDiff struct {
+	Service            []ValueChange `json:"service,omitempty"`
+	ConstraintsAdded   []string      `json:"constraints_added,omitempty"`
+	ConstraintsRemoved []string      `json:"constraints_removed,omitempty"`
+	Properties         []ValueChange `json:"properties,omitempty"`
+	UserInput          []ValueChange `json:"userInput,omitempty"`
+	SecretBinding      []ValueChange `json:"secretBinding,omitempty"`
+	Deployment         []ValueChange `json:"deployment,omitempty"` // the placement and the maintenance schedule
+}
+
+func (d BusinessPolicyDiff) String() string {
+	return fmt.Sprintf("Service: %v, ConstraintsAdded: %v, ConstraintsRemoved: %v, Properties: %v, UserInput: %v, SecretBinding: %v, Deployment: %v",
+		d.Service, d.ConstraintsAdded, d.ConstraintsRemoved, d.Properties, d.UserInput, d.SecretBinding, d.Deployment)
+}
+
+func (d *BusinessPolicyDiff) IsEmpty() bool {
+	return len(d.Service) == 0 && len(d.ConstraintsAdded) == 0 && len(d.ConstraintsRemoved) == 0 &&
+		len(d.Properties) == 0 && len(d.UserInput) == 0 && len(d.SecretBinding) == 0 && len(d.Deployment) == 0
+}
+
+// Return true if the service, its architecture or its versions changed. The agreements made for the policy are
+// replaced then, the other changes to the service are applied to the existing agreements.
+func (d *BusinessPolicyDiff) ServiceReplaced() bool {
+	for _, change := range d.Service {
+		switch change.Name {
+		case "name", "org", "arch", "serviceVersions":
+			return true
+		}
+	}
+	return false
+}
+
+// PolicyChangeOutput The output format for the change impact report of a deployment policy
+// swagger:model
+type PolicyChangeOutput struct {
+	Diff           *BusinessPolicyDiff          `json:"diff"`
+	AgreementNodes []string                     `json:"agreement_nodes"` // the nodes that currently have an agreement for the service of the policy
+	LoseWorkload   map[string]map[string]string `json:"lose_workload"`   // the nodes that have an agreement but are not compatible with the new policy, with the reasons
+	GainWorkload   []string                     `json:"gain_workload"`   // the nodes that do not have an agreement but are compatible with the new policy
+}
+
+func (p *PolicyChangeOutput) String() string {
+	return fmt.Sprintf("Diff: %v, AgreementNodes: %v, LoseWorkload: %v, GainWorkload: %v",
+		p.Diff, p.AgreementNodes, p.LoseWorkload, p.GainWorkload)
+}
+
+// Compare 2 versions of a deployment policy. The order of the constraints, properties, user input and
+// secret bindings does not matter.
+func DiffBusinessPolicies(oldPol *businesspolicy.BusinessPolicy, newPol *businesspolicy.BusinessPolicy) *BusinessPolicyDiff {
+	diff := BusinessPolicyDiff{}
+
+	// service
+	diff.Service = diffValues("", serviceValues(oldPol.Service), serviceValues(newPol.Service))
+
+	// placement and maintenance schedule
+	oldDeployment := map[string]interface{}{"placement": jsonValue(oldPol.Placement), "maintenance": jsonValue(oldPol.Maintenance)}
+	newDeployment := map[string]interface{}{"placement": jsonValue(newPol.Placement), "maintenance": jsonValue(newPol.Maintenance)}
+	diff.Deployment = diffValues("", oldDeployment, newDeployment)
+
+	// constraints
+	oldConstraints := map[string]bool{}
+	for _, c := range oldPol.Constraints {
+		oldConstraints[strings.TrimSpace(c)] = true
+	}
+	newConstraints := map[string]bool{}
+	for _, c := range newPol.Constraints {
+		c = strings.TrimSpace(c)
+		newConstraints[c] = true
+		if !oldConstraints[c] && c != "" {
+			diff.ConstraintsAdded = append(diff.ConstraintsAdded, c)
+		}
+	}
+	for _, c := range oldPol.Constraints {
+		c = strings.TrimSpace(c)
+		if !newConstraints[c] && c != "" {
+			diff.ConstraintsRemoved = append(diff.ConstraintsRemoved, c)
+		}
+	}
+
+	// properties
+	oldProps := map[string]interface{}{}
+	for _, p := range oldPol.Properties {
+		oldProps[p.Name] = p.Value
+	}
+	newProps := map[string]interface{}{}
+	for _, p := range newPol.Properties {
+		newProps[p.Name] = p.Value
+	}
+	diff.Properties = diffValues("", oldProps, newProps)
+
+	// user input, keyed by service and then by variable name
+	userInput := func(b *businesspolicy.BusinessPolicy) map[string]map[string]interface{} {
+		values := map[string]map[string]interface{}{}
+		for _, ui := range b.UserInput {
+			svc := serviceKey(ui.ServiceOrgid, ui.ServiceUrl, ui.ServiceArch, ui.ServiceVersionRange)
+			if _, ok := values[svc]; !ok {
+				values[svc] = map[string]interface{}{}
+			}
+			for _, input := range ui.Inputs {
+				values[svc][input.Name] = input.Value
+			}
+		}
+		return values
+	}
+	diff.UserInput = diffServiceValues(userInput(oldPol), userInput(newPol))
+
+	// secret bindings, keyed by service and then by service secret name
+	secretBinding := func(b *businesspolicy.BusinessPolicy) map[string]map[string]interface{} {
+		values := map[string]map[string]interface{}{}
+		for _, sb := range b.SecretBinding {
+			svc := serviceKey(sb.ServiceOrgid, sb.ServiceUrl, sb.ServiceArch, sb.ServiceVersionRange)
+			if _, ok := values[svc]; !ok {
+				values[svc] = map[string]interface{}{}
+			}
+			for _, bs := range sb.Secrets {
+				name, value := bs.GetBinding()
+				values[svc][name] = value
+			}
+		}
+		return values
+	}
+	diff.SecretBinding = diffServiceValues(secretBinding(oldPol), secretBinding(newPol))
+
+	return &diff
+}
+
+// Return the settings of a deployment policy service by name. The priority and upgrade policy of each version are
+// named after the version, e.g. serviceVersions[1.0.1].priority, and are left out when they are not set.
+func serviceValues(svc businesspolicy.ServiceRef) map[string]interface{} {
+	versions := []string{}
+	values := map[string]interface{}{"name": svc.Name, "org": svc.Org, "arch": svc.Arch, "nodeHealth": jsonValue(svc.NodeH), "rollout": jsonValue(svc.Rollout)}
+	for _, wl := range svc.ServiceVersions {
+		versions = append(versions, wl.Version)
+		if priority := jsonValue(wl.Priority); !isEmptyValue(priority) {
+			values[fmt.Sprintf("serviceVersions[%v].priority", wl.Version)] = priority
+		}
+		if upgrade := jsonValue(wl.Upgrade); !isEmptyValue(upgrade) {
+			values[fmt.Sprintf("serviceVersions[%v].upgradePolicy", wl.Version)] = upgrade
+		}
+	}
+	values["serviceVersions"] = versions
+	return values
+}
+
+func isEmptyValue(value interface{}) bool {
+	m, ok := value.(map[string]interface{})
+	return value == nil || (ok && len(m) == 0)
+}
+
+// Return a value as it is written in the JSON form of a policy, so that values are compared by content rather than
+// by pointer. A nil pointer is nil.
+func jsonValue(v interface{}) interface{} {
+	var value interface{}
+	if b, err := json.Marshal(v); err != nil {
+		return fmt.Sprintf("%v", v)
+	} else if err := json.Unmarshal(b, &value); err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return value
+}
+
+// The key that identifies the service a user input or secret binding applies to.
+func serviceKey(org string, url string, arch string, versionRange string) string {
+	key := cutil.FormOrgSpecUrl(url, org)
+	if arch != "" {
+		key += " arch=" + arch
+	}
+	if versionRange != "" {
+		key += " version=" + versionRange
+	}
+	return key
+}
+
+// Return the changes between the old and new values, sorted by name. The values are compared by their
+// string form so that the same number read from different sources is not a change.
+func diffValues(service string, oldValues map[string]interface{}, newValues map[string]interface{}) []ValueChange {
+	names := []string{}
+	for name, _ := range oldValues {
+		names = append(names, name)
+	}
+	for name, _ := range newValues {
+		if _, ok := oldValues[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	changes := []ValueChange{}
+	for _, name := range names {
+		oldValue, oldOk := oldValues[name]
+		newValue, newOk := newValues[name]
+		if oldOk && newOk && fmt.Sprintf("%v", oldValue) == fmt.Sprintf("%v", newValue) {
+			continue
+		}
+		changes = append(changes, ValueChange{Service: service, Name: name, Old: oldValue, New: newValue})
+	}
+	return changes
+}
+
+func diffServiceValues(oldValues map[string]map[string]interface{}, newValues map[string]map[string]interface{}) []ValueChange {
+	services := []string{}
+	for svc, _ := range oldValues {
+		services = append(services, svc)
+	}
+	for svc, _ := range newValues {
+		if _, ok := oldValues[svc]; !ok {
+			services = append(services, svc)
+		}
+	}
+	sort.Strings(services)
+
+	changes := []ValueChange{}
+	for _, svc := range services {
+		changes = append(changes, diffValues(svc, oldValues[svc], newValues[svc])...)
+	}
+	return changes
+}
+
+// Report the changes between the current and new version of a deployment policy, and the nodes that would
+// lose or gain the workload if the new version replaced the current one. The nodes that lose the workload
+// are the nodes that currently have an agreement for the service and are not compatible with the new policy.
+func PolicyChangeImpact(ec exchange.ExchangeContext, agbotUrl string, bpId string, oldPol *businesspolicy.BusinessPolicy, newPol *businesspolicy.BusinessPolicy,
+	checkAllSvcs bool, msgPrinter *message.Printer) (*PolicyChangeOutput, error) {
+
+	// the fleet checks of the current and the new policy share the nodes and the services read from the exchange
+	getOrgDevices := cachedOrgDevicesHandler(exchange.GetHTTPOrgDevicesHandler(ec))
+	getNodeAgreements := exchange.GetHTTPNodeAgreementsHandler(ec)
+	getBusinessPolicies := exchange.GetHTTPBusinessPoliciesHandler(ec)
+	deployCheck := fleetDeployCheck(ec, agbotUrl, checkAllSvcs, msgPrinter)
+	fleetCheck := func(fcInput *FleetCheck) (*FleetCheckOutput, error) {
+		return fleetCompatible(getOrgDevices, getBusinessPolicies, deployCheck, fcInput, nil, msgPrinter)
+	}
+
+	return policyChangeImpact(getNodeAgreements, fleetCheck, bpId, oldPol, newPol, msgPrinter)
+}
+
+// Internal function for PolicyChangeImpact
+func policyChangeImpact(getNodeAgreements exchange.NodeAgreementsHandler,
+	fleetCheck func(fcInput *FleetCheck) (*FleetCheckOutput, error),
+	bpId string, oldPol *businesspolicy.BusinessPolicy, newPol *businesspolicy.BusinessPolicy, msgPrinter *message.Printer) (*PolicyChangeOutput, error) {
+
+	// get default message printer if nil
+	if msgPrinter == nil {
+		msgPrinter = i18n.GetMessagePrinter()
+	}
+
+	if oldPol == nil || newPol == nil {
+		return nil, NewCompCheckError(fmt.Errorf(msgPrinter.Sprintf("The current and the new deployment policy must both be specified.")), COMPCHECK_INPUT_ERROR)
+	}
+
+	nodeOrg := exchange.GetOrg(bpId)
+	if nodeOrg == "" {
+		return nil, NewCompCheckError(fmt.Errorf(msgPrinter.Sprintf("The deployment policy id %v does not contain the organization.", bpId)), COMPCHECK_INPUT_ERROR)
+	}
+
+	output := PolicyChangeOutput{
+		Diff:           DiffBusinessPolicies(oldPol, newPol),
+		AgreementNodes: []string{},
+		LoseWorkload:   map[string]map[string]string{},
+		GainWorkload:   []string{},
+	}
+
+	// Only the nodes that are compatible with the current version of the policy can have an agreement for it, so
+	// only their agreements are read. The node records the service of a policy agreement, but not the deployment
+	// policy, once the agreement is finalized. The agreements still being negotiated are not counted.
+	oldOutput, err := fleetCheck(&FleetCheck{NodeOrg: nodeOrg, BusinessPolId: bpId, BusinessPolicy: oldPol})
+	if err != nil {
+		return nil, err
+	}
+
+	serviceUrl := cutil.FormOrgSpecUrl(oldPol.Service.Name, oldPol.Service.Org)
+	hasAgreement := map[string]bool{}
+	for _, nodeId := range oldOutput.Compatible {
+		agreements, err := getNodeAgreements(nodeId)
+		if err != nil {
+			return nil, NewCompCheckError(fmt.Errorf(msgPrinter.Sprintf("Error getting the agreements of node %v from the Exchange. %v", nodeId, err)), COMPCHECK_EXCHANGE_ERROR)
+		}
+		for _, ag := range agreements {
+			if ag.AgreementService.Pattern == "" && ag.AgreementService.URL == serviceUrl && ag.State != "" {
+				hasAgreement[nodeId] = true
+				output.AgreementNodes = append(output.AgreementNodes, nodeId)
+				break
+			}
+		}
+	}
+	sort.Strings(output.AgreementNodes)
+
+	// check the new policy against the whole fleet
+	fleetOutput, err := fleetCheck(&FleetCheck{NodeOrg: nodeOrg, BusinessPolId: bpId, BusinessPolicy: newPol})
+	if err != nil {
+		return nil, err
+	}
+
+	// the agreements for the current service are cancelled when the policy switches to another service, and
+	// they are replaced when the versions or the architecture of the service change
+	serviceReplaced := serviceUrl != cutil.FormOrgSpecUrl(newPol.Service.Name, newPol.Service.Org)
+	serviceChanged := output.Diff.ServiceReplaced()
+
+	compatible := map[string]bool{}
+	for _, nodeId := range fleetOutput.Compatible {
+		compatible[nodeId] = true
+		if !hasAgreement[nodeId] || serviceChanged {
+			output.GainWorkload = append(output.GainWorkload, nodeId)
+		}
+	}
+	for _, nodeId := range output.AgreementNodes {
+		if serviceReplaced {
+			output.LoseWorkload[nodeId] = map[string]string{"general": msgPrinter.Sprintf("The deployment policy no longer deploys service %v.", serviceUrl)}
+		} else if serviceChanged {
+			output.LoseWorkload[nodeId] = map[string]string{"general": msgPrinter.Sprintf("The deployment policy changes the versions or the architecture of service %v, the agreement is replaced.", serviceUrl)}
+		} else if !compatible[nodeId] {
+			reason, ok := fleetOutput.Incompatible[nodeId]
+			if !ok {
+				reason = map[string]string{}
+			}
+			output.LoseWorkload[nodeId] = reason
+		}
+	}
+
+	return &output, nil
+}
@@ -0,0 +1,195 @@
+//go:build unit
+// +build unit
+
+package compcheck
+
+import (
+	"github.com/open-horizon/anax/businesspolicy"
+	"github.com/open-horizon/anax/exchange"
+	"github.com/open-horizon/anax/exchangecommon"
+	"github.com/open-horizon/anax/i18n"
+	"github.com/open-horizon/anax/policy"
+	"testing"
+)
+
+func Test_DiffBusinessPolicies(t *testing.T) {
+
+	service := businesspolicy.ServiceRef{
+		Name:            "weather",
+		Org:             "myorg",
+		Arch:            "amd64",
+		ServiceVersions: []businesspolicy.WorkloadChoice{businesspolicy.WorkloadChoice{Version: "1.0.1"}},
+	}
+	oldPol := createBusinessPolicy(service, map[string]string{"prop1": "val1", "prop2": "val2"}, []string{"prop3 == val3", "prop4 == val4"})
+	oldPol.UserInput = []policy.UserInput{{ServiceOrgid: "myorg", ServiceUrl: "weather", Inputs: []policy.Input{{Name: "var1", Value: "a"}, {Name: "var2", Value: float64(2)}}}}
+	oldPol.SecretBinding = []exchangecommon.SecretBinding{{ServiceOrgid: "myorg", ServiceUrl: "weather", Secrets: []exchangecommon.BoundSecret{{"secret1": "vault1"}}}}
+
+	// the order does not matter
+	newPol := createBusinessPolicy(service, map[string]string{"prop2": "val2", "prop1": "val1"}, []string{"prop4 == val4", "prop3 == val3"})
+	newPol.UserInput = oldPol.UserInput
+	newPol.SecretBinding = oldPol.SecretBinding
+	if diff := DiffBusinessPolicies(oldPol, newPol); !diff.IsEmpty() {
+		t.Errorf("The policies should be the same but got diff: %v", diff)
+	}
+
+	newPol = createBusinessPolicy(service, map[string]string{"prop1": "val1", "prop2": "new", "prop5": "val5"}, []string{"prop3 == val3", "prop6 == val6"})
+	newPol.Service.ServiceVersions = append(newPol.Service.ServiceVersions, businesspolicy.WorkloadChoice{Version: "1.0.2"})
+	newPol.UserInput = []policy.UserInput{{ServiceOrgid: "myorg", ServiceUrl: "weather", Inputs: []policy.Input{{Name: "var1", Value: "b"}, {Name: "var2", Value: float64(2)}}}}
+	newPol.SecretBinding = []exchangecommon.SecretBinding{{ServiceOrgid: "myorg", ServiceUrl: "weather", ServiceArch: "amd64", Secrets: []exchangecommon.BoundSecret{{"secret1": "vault1"}}}}
+
+	diff := DiffBusinessPolicies(oldPol, newPol)
+	if len(diff.Service) != 1 || diff.Service[0].Name != "serviceVersions" {
+		t.Errorf("The service versions should have changed but got: %v", diff.Service)
+	} else if len(diff.ConstraintsAdded) != 1 || diff.ConstraintsAdded[0] != "prop6 == val6" {
+		t.Errorf("One constraint should have been added but got: %v", diff.ConstraintsAdded)
+	} else if len(diff.ConstraintsRemoved) != 1 || diff.ConstraintsRemoved[0] != "prop4 == val4" {
+		t.Errorf("One constraint should have been removed but got: %v", diff.ConstraintsRemoved)
+	} else if len(diff.Properties) != 2 || diff.Properties[0].Name != "prop2" || diff.Properties[1].Name != "prop5" || diff.Properties[1].Old != nil {
+		t.Errorf("prop2 should have changed and prop5 should have been added but got: %v", diff.Properties)
+	} else if len(diff.UserInput) != 1 || diff.UserInput[0].Name != "var1" || diff.UserInput[0].Service != "myorg/weather" || diff.UserInput[0].New != "b" {
+		t.Errorf("var1 should have changed but got: %v", diff.UserInput)
+	} else if len(diff.SecretBinding) != 2 || diff.SecretBinding[0].New != nil || diff.SecretBinding[1].Service != "myorg/weather arch=amd64" {
+		t.Errorf("The secret binding should have moved to the amd64 service but got: %v", diff.SecretBinding)
+	} else if !diff.ServiceReplaced() {
+		t.Errorf("The agreements should be replaced when a service version is added")
+	}
+
+	// the other settings of the service, the placement and the maintenance schedule are compared too
+	newPol = createBusinessPolicy(service, map[string]string{"prop1": "val1", "prop2": "val2"}, []string{"prop3 == val3", "prop4 == val4"})
+	newPol.UserInput = oldPol.UserInput
+	newPol.SecretBinding = oldPol.SecretBinding
+	newPol.Service.ServiceVersions = []businesspolicy.WorkloadChoice{{Version: "1.0.1", Priority: businesspolicy.WorkloadPriority{PriorityValue: 1, Retries: 2}}}
+	newPol.Service.NodeH = businesspolicy.NodeHealth{MissingHBInterval: 600}
+	newPol.Service.Rollout = policy.Rollout_Factory(20, 3600)
+	newPol.Placement = &policy.Placement{MaxNodes: 10}
+	newPol.Maintenance = &exchangecommon.MaintenanceSchedule{Windows: []exchangecommon.TimeWindow{{Schedule: "0 22 * * *", Duration: 3600}}}
+
+	diff = DiffBusinessPolicies(oldPol, newPol)
+	names := []string{}
+	for _, change := range diff.Service {
+		names = append(names, change.Name)
+	}
+	if len(names) != 3 || names[0] != "nodeHealth" || names[1] != "rollout" || names[2] != "serviceVersions[1.0.1].priority" {
+		t.Errorf("The node health, rollout and priority should have changed but got: %v", diff.Service)
+	} else if diff.ServiceReplaced() {
+		t.Errorf("The agreements should not be replaced when only the settings of the service change")
+	} else if len(diff.Deployment) != 2 || diff.Deployment[0].Name != "maintenance" || diff.Deployment[0].Old != nil || diff.Deployment[1].Name != "placement" {
+		t.Errorf("The maintenance schedule and the placement should have been added but got: %v", diff.Deployment)
+	}
+
+	// the same settings in other objects are not a change
+	oldPol = newPol
+	newPol = createBusinessPolicy(service, map[string]string{"prop1": "val1", "prop2": "val2"}, []string{"prop3 == val3", "prop4 == val4"})
+	newPol.UserInput = oldPol.UserInput
+	newPol.SecretBinding = oldPol.SecretBinding
+	newPol.Service.ServiceVersions = []businesspolicy.WorkloadChoice{{Version: "1.0.1", Priority: businesspolicy.WorkloadPriority{PriorityValue: 1, Retries: 2}}}
+	newPol.Service.NodeH = businesspolicy.NodeHealth{MissingHBInterval: 600}
+	newPol.Service.Rollout = policy.Rollout_Factory(20, 3600)
+	newPol.Placement = &policy.Placement{MaxNodes: 10}
+	newPol.Maintenance = &exchangecommon.MaintenanceSchedule{Windows: []exchangecommon.TimeWindow{{Schedule: "0 22 * * *", Duration: 3600}}}
+	if diff := DiffBusinessPolicies(oldPol, newPol); !diff.IsEmpty() {
+		t.Errorf("The policies should be the same but got diff: %v", diff)
+	}
+}
+
+func Test_policyChangeImpact(t *testing.T) {
+
+	msgPrinter := i18n.GetMessagePrinter()
+
+	service := businesspolicy.ServiceRef{
+		Name:            "weather",
+		Org:             "myorg",
+		Arch:            "amd64",
+		ServiceVersions: []businesspolicy.WorkloadChoice{businesspolicy.WorkloadChoice{Version: "1.0.1"}},
+	}
+	oldPol := createBusinessPolicy(service, nil, []string{"prop3 == val3"})
+	newPol := createBusinessPolicy(service, nil, []string{"prop3 == val4"})
+
+	// edge1 and edge2 run the service, edge4 runs it through a pattern and edge5 for another deployment policy
+	agreementReads := map[string]int{}
+	getNodeAgreements := func(deviceId string) (map[string]exchange.DeviceAgreement, error) {
+		agreementReads[deviceId]++
+		switch deviceId {
+		case "myorg/edge1", "myorg/edge2", "myorg/edge4", "myorg/edge5":
+			return map[string]exchange.DeviceAgreement{"ag1": exchange.DeviceAgreement{State: "Finalized Agreement", AgreementService: exchange.WorkloadAgreement{Org: "myorg", URL: "myorg/weather"}}}, nil
+		default:
+			return map[string]exchange.DeviceAgreement{}, nil
+		}
+	}
+
+	// edge1 and edge2 are compatible with the current policy, edge4 has a pattern and edge5 has another architecture.
+	// edge2 is not compatible with the new policy, edge3 is.
+	msg_incompatible := "Policy Incompatible: node properties do not satisfy the constraints"
+	fleetCheck := func(fcInput *FleetCheck) (*FleetCheckOutput, error) {
+		if fcInput.NodeOrg != "myorg" {
+			t.Errorf("wrong fleet check input: %v", fcInput)
+		} else if fcInput.BusinessPolicy == oldPol {
+			return &FleetCheckOutput{
+				NumNodes:   4,
+				Compatible: []string{"myorg/edge1", "myorg/edge2"},
+				Incompatible: map[string]map[string]string{"myorg/edge3": {"myorg/weather_1.0.1_amd64": msg_incompatible}, "myorg/edge4": {"general": "pattern"},
+					"myorg/edge5": {"myorg/weather_1.0.1_amd64": "Architecture Incompatible"}},
+			}, nil
+		}
+		return &FleetCheckOutput{
+			NumNodes:     4,
+			Compatible:   []string{"myorg/edge1", "myorg/edge3"},
+			Incompatible: map[string]map[string]string{"myorg/edge2": {"myorg/weather_1.0.1_amd64": msg_incompatible}},
+		}, nil
+	}
+
+	if output, err := policyChangeImpact(getNodeAgreements, fleetCheck, "myorg/mybp", oldPol, newPol, msgPrinter); err != nil {
+		t.Errorf("policyChangeImpact should have returned nil error but got: %v", err)
+	} else if len(output.AgreementNodes) != 2 || output.AgreementNodes[0] != "myorg/edge1" || output.AgreementNodes[1] != "myorg/edge2" {
+		t.Errorf("edge1 and edge2 should have agreements but got: %v", output.AgreementNodes)
+	} else if len(output.LoseWorkload) != 1 || output.LoseWorkload["myorg/edge2"]["myorg/weather_1.0.1_amd64"] != msg_incompatible {
+		t.Errorf("edge2 should lose the workload but got: %v", output.LoseWorkload)
+	} else if len(output.GainWorkload) != 1 || output.GainWorkload[0] != "myorg/edge3" {
+		t.Errorf("edge3 should gain the workload but got: %v", output.GainWorkload)
+	} else if len(output.Diff.ConstraintsAdded) != 1 || len(output.Diff.ConstraintsRemoved) != 1 {
+		t.Errorf("The constraint should have changed but got: %v", output.Diff)
+	}
+
+	// only the agreements of the nodes that are compatible with the current policy are read
+	if len(agreementReads) != 2 || agreementReads["myorg/edge1"] != 1 || agreementReads["myorg/edge2"] != 1 {
+		t.Errorf("only the agreements of edge1 and edge2 should have been read but got: %v", agreementReads)
+	}
+
+	// the agreements are kept when only the settings of the service change
+	newPol = createBusinessPolicy(service, nil, []string{"prop3 == val4"})
+	newPol.Service.Rollout = policy.Rollout_Factory(20, 3600)
+	if output, err := policyChangeImpact(getNodeAgreements, fleetCheck, "myorg/mybp", oldPol, newPol, msgPrinter); err != nil {
+		t.Errorf("policyChangeImpact should have returned nil error but got: %v", err)
+	} else if len(output.Diff.Service) != 1 || len(output.LoseWorkload) != 1 || len(output.GainWorkload) != 1 {
+		t.Errorf("only edge2 should lose the workload and only edge3 should gain it but got: %v", output)
+	}
+
+	// all the nodes with agreements lose the workload when the service changes
+	newPol = createBusinessPolicy(service, nil, []string{"prop3 == val3"})
+	newPol.Service.Name = "weather2"
+	if output, err := policyChangeImpact(getNodeAgreements, fleetCheck, "myorg/mybp", oldPol, newPol, msgPrinter); err != nil {
+		t.Errorf("policyChangeImpact should have returned nil error but got: %v", err)
+	} else if len(output.LoseWorkload) != 2 || len(output.GainWorkload) != 2 {
+		t.Errorf("edge1 and edge2 should lose the workload and edge1 and edge3 should gain it but got: %v", output)
+	}
+
+	// the agreements are replaced when the version or the architecture of the service changes
+	for _, change := range []func(p *businesspolicy.BusinessPolicy){
+		func(p *businesspolicy.BusinessPolicy) { p.Service.ServiceVersions[0].Version = "1.0.2" },
+		func(p *businesspolicy.BusinessPolicy) { p.Service.Arch = "*" },
+	} {
+		newPol = createBusinessPolicy(service, nil, []string{"prop3 == val3"})
+		newPol.Service.ServiceVersions = []businesspolicy.WorkloadChoice{businesspolicy.WorkloadChoice{Version: "1.0.1"}}
+		change(newPol)
+		if output, err := policyChangeImpact(getNodeAgreements, fleetCheck, "myorg/mybp", oldPol, newPol, msgPrinter); err != nil {
+			t.Errorf("policyChangeImpact should have returned nil error but got: %v", err)
+		} else if len(output.LoseWorkload) != 2 || len(output.GainWorkload) != 2 {
+			t.Errorf("edge1 and edge2 should lose the workload and edge1 and edge3 should gain it for %v but got: %v", newPol.Service, output)
+		}
+	}
+
+	// the policy id must contain the org
+	if _, err := policyChangeImpact(getNodeAgreements, fleetCheck, "mybp", oldPol, newPol, msgPrinter); err == nil {
+		t.Errorf("policyChangeImpact should have returned an error for a policy id without org")
+	}
+}
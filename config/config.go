@@ -77,6 +77,11 @@ type Config struct {
 	SecretsManagerFilePath           string    // The filepath for the secrets manager to store secrets in the agent filesystem
 	NodeMgmtWorkDirectory            string    // The filepath for the node management policy updates to use
 
+	EventLogForwarder        EventLogForwarderConfig // Forwards event log records to syslog or a file as they are created.
+	EventLogMaxAgeS          uint64                  // Event log records older than this number of seconds are removed. The default is 0, records are not removed because of their age.
+	EventLogMaxRecords       uint64                  // The maximum number of event log records kept, the oldest records are removed first. The default is 0, no limit.
+	EventLogCompactIntervalS int                     // How often, in seconds, old event log records are removed. The default is 3600.
+
 	// these Ids could be provided in config or discovered after startup by the system
 	BlockchainAccountId        string
 	BlockchainDirectoryAddress string
@@ -88,6 +93,7 @@ type AGConfig struct {
 	AgreementWorkers              int
 	DBPath                        string
 	Postgresql                    PostgresqlConfig // The Postgresql config if it is being used
+	Sqlite                        SqliteConfig     // The SQLite config if it is being used
 	PartitionStale                uint64           // Number of seconds to wait before declaring a partition to be stale (i.e. the previous owner has unexpectedly terminated).
 	ProtocolTimeoutS              uint64           // Number of seconds to wait before declaring proposal response is lost
 	AgreementTimeoutS             uint64           // Number of seconds to wait before declaring agreement not finalized in blockchain
@@ -131,6 +137,8 @@ type AGConfig struct {
 	RetryLookBackWindow           uint64           // The time window (in seconds) used by the agbot to look backward in time for node changes when node agreements are retried.
 	PolicySearchOrder             bool             // When true, search policies from most recently changed to least recently changed.
 	Vault                         VaultConfig      // The hashicorp vault config to connect to and fetch secrets from.
+	SecretsFile                   SecretFileConfig // The local encrypted file secrets provider config, used when vault is not configured.
+	SecretsHTTP                   SecretHTTPConfig // The generic HTTP secrets provider config, used when vault and the file provider are not configured.
 	SecretsUpdateCheck            int              // The number of seconds between checks for updated secrets.
 	CSSDestinationBatchSize       int              // The max number of destination updates to send to CSS in a single update.
 }
@@ -165,6 +173,10 @@ func (c *HorizonConfig) IsPostgresqlConfigured() bool {
 	return (c.AgreementBot.Postgresql != (PostgresqlConfig{})) && (c.GetPartitionStale() != 0)
 }
 
+func (c *HorizonConfig) IsSqliteConfigured() bool {
+	return len(c.AgreementBot.Sqlite.DBPath) != 0
+}
+
 func (c *HorizonConfig) GetPartitionStale() uint64 {
 	if c.AgreementBot.PartitionStale == 0 {
 		return 60
@@ -453,6 +465,15 @@ func Read(file string) (*HorizonConfig, error) {
 			config.Edge.InitialPollingBuffer = 120
 		}
 
+		if config.Edge.EventLogCompactIntervalS == 0 {
+			config.Edge.EventLogCompactIntervalS = 3600
+		}
+
+		// make sure the event log forwarder can be started
+		if err := config.Edge.EventLogForwarder.Validate(); err != nil {
+			return nil, fmt.Errorf("Invalid EventLogForwarder configuration: %v", err)
+		}
+
 		// add a slash at the back of the ExchangeUrl
 		if config.Edge.ExchangeURL != "" {
 			config.Edge.ExchangeURL = strings.TrimRight(config.Edge.ExchangeURL, "/") + "/"
@@ -532,6 +553,10 @@ func (con *Config) String() string {
 		", NodeCheckIntervalS: %v"+
 		", FileSyncService: {%v}"+
 		", InitialPollingBuffer: {%v}"+
+		", EventLogForwarder: {%v}"+
+		", EventLogMaxAgeS: %v"+
+		", EventLogMaxRecords: %v"+
+		", EventLogCompactIntervalS: %v"+
 		", BlockchainAccountId: %v"+
 		", BlockchainDirectoryAddress %v",
 		con.ServiceStorage, con.APIListen, con.DBPath, con.DockerEndpoint, con.DockerCredFilePath, con.DefaultCPUSet,
@@ -541,7 +566,8 @@ func (con *Config) String() string {
 		con.ExchangeMessagePollMaxInterval, con.ExchangeMessagePollIncrement, con.UserPublicKeyPath, con.ReportDeviceStatus,
 		con.TrustCertUpdatesFromOrg, con.TrustDockerAuthFromOrg, con.ServiceUpgradeCheckIntervalS, con.MultipleAnaxInstances,
 		con.DefaultServiceRetryCount, con.DefaultServiceRetryDuration, con.NodeCheckIntervalS, con.FileSyncService.String(),
-		con.InitialPollingBuffer, con.BlockchainAccountId, con.BlockchainDirectoryAddress)
+		con.InitialPollingBuffer, con.EventLogForwarder.String(), con.EventLogMaxAgeS, con.EventLogMaxRecords,
+		con.EventLogCompactIntervalS, con.BlockchainAccountId, con.BlockchainDirectoryAddress)
 }
 
 func (agc *AGConfig) String() string {
@@ -550,6 +576,7 @@ func (agc *AGConfig) String() string {
 		", AgreementWorkers: %v"+
 		", DBPath: %v"+
 		", Postgresql: {%v}"+
+		", Sqlite: {%v}"+
 		", PartitionStale: %v"+
 		", ProtocolTimeoutS: %v"+
 		", AgreementTimeoutS: %v"+
@@ -588,8 +615,10 @@ func (agc *AGConfig) String() string {
 		", MaxExchangeChanges: %v"+
 		", RetryLookBackWindow: %v"+
 		", PolicySearchOrder: %v"+
-		", Vault: {%v}",
-		agc.TxLostDelayTolerationSeconds, agc.AgreementWorkers, agc.DBPath, agc.Postgresql.String(),
+		", Vault: {%v}"+
+		", SecretsFile: {%v}"+
+		", SecretsHTTP: {%v}",
+		agc.TxLostDelayTolerationSeconds, agc.AgreementWorkers, agc.DBPath, agc.Postgresql.String(), agc.Sqlite.String(),
 		agc.PartitionStale, agc.ProtocolTimeoutS, agc.AgreementTimeoutS, agc.NoDataIntervalS, agc.ActiveAgreementsURL,
 		agc.ActiveAgreementsUser, mask, agc.PolicyPath, agc.NewContractIntervalS, agc.ProcessGovernanceIntervalS,
 		agc.IgnoreContractWithAttribs, agc.ExchangeURL, agc.ExchangeHeartbeat, agc.ExchangeId,
@@ -597,7 +626,7 @@ func (agc *AGConfig) String() string {
 		agc.SecureAPIListenHost, agc.SecureAPIListenPort, agc.SecureAPIServerCert, agc.SecureAPIServerKey,
 		agc.PurgeArchivedAgreementHours, agc.CheckUpdatedPolicyS, agc.CSSURL, agc.CSSSSLCert, agc.CSSDestinationBatchSize, agc.AgreementBatchSize,
 		agc.AgreementQueueSize, agc.MessageQueueScale, agc.QueueHistorySize, agc.FullRescanS, agc.MaxExchangeChanges,
-		agc.RetryLookBackWindow, agc.PolicySearchOrder, agc.Vault)
+		agc.RetryLookBackWindow, agc.PolicySearchOrder, agc.Vault, agc.SecretsFile, agc.SecretsHTTP)
 }
 
 func (c *VaultConfig) String() string {
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"fmt"
+)
+
+const EVENTLOG_FORWARD_SYSLOG = "syslog"
+const EVENTLOG_FORWARD_FILE = "file"
+
+// Defaults for the event log forwarder, used when the config does not specify a value.
+const EVENTLOG_FORWARD_DEFAULT_SYSLOG_TAG = "anax"
+const EVENTLOG_FORWARD_DEFAULT_MAX_FILE_SIZE_MB = 10
+const EVENTLOG_FORWARD_DEFAULT_MAX_FILES = 5
+
+// Configuration for forwarding event log records, as JSON lines, to syslog or to a file as they are created. Forwarding
+// is used to ship the event log to a log collector on the node, so that events are not lost when the event log is
+// only polled from time to time.
+type EventLogForwarderConfig struct {
+	Type          string // Either "syslog" or "file". If empty, event log records are not forwarded.
+	SyslogNetwork string // The network used to reach syslog, "udp", "tcp" or "unix". If empty, the local syslog daemon is used.
+	SyslogAddress string // The address of syslog, host:port or a unix socket path. If empty, the local syslog daemon is used.
+	SyslogTag     string // The tag on each syslog message. The default is "anax".
+	FilePath      string // The file to which event log records are appended.
+	MaxFileSizeMB int    // The size of the file, in megabytes, at which it is rotated. The default is 10.
+	MaxFiles      int    // The number of rotated files to keep, in addition to the current file. The default is 5.
+}
+
+func (f *EventLogForwarderConfig) String() string {
+	return fmt.Sprintf("Type: %v, SyslogNetwork: %v, SyslogAddress: %v, SyslogTag: %v, FilePath: %v, MaxFileSizeMB: %v, MaxFiles: %v", f.Type, f.SyslogNetwork, f.SyslogAddress, f.SyslogTag, f.FilePath, f.MaxFileSizeMB, f.MaxFiles)
+}
+
+// Returns an error if the forwarder configuration is not usable.
+func (f *EventLogForwarderConfig) Validate() error {
+	switch f.Type {
+	case "":
+		return nil
+	case EVENTLOG_FORWARD_SYSLOG:
+		if (f.SyslogNetwork == "") != (f.SyslogAddress == "") {
+			return fmt.Errorf("SyslogNetwork and SyslogAddress must both be set, or both be empty to use the local syslog daemon")
+		}
+	case EVENTLOG_FORWARD_FILE:
+		if f.FilePath == "" {
+			return fmt.Errorf("FilePath must be set when the event log forwarder type is %v", EVENTLOG_FORWARD_FILE)
+		}
+	default:
+		return fmt.Errorf("unsupported event log forwarder type %v, must be %v or %v", f.Type, EVENTLOG_FORWARD_SYSLOG, EVENTLOG_FORWARD_FILE)
+	}
+	if f.MaxFileSizeMB < 0 || f.MaxFiles < 0 {
+		return fmt.Errorf("MaxFileSizeMB and MaxFiles must not be negative")
+	}
+	return nil
+}
+
+// Returns true if old event log records should be removed.
+func (c *HorizonConfig) IsEventLogRetentionConfigured() bool {
+	return c.Edge.EventLogMaxAgeS != 0 || c.Edge.EventLogMaxRecords != 0
+}
+
+func (c *HorizonConfig) IsEventLogForwarderConfigured() bool {
+	return c.Edge.EventLogForwarder.Type != ""
+}
+
+func (f *EventLogForwarderConfig) GetSyslogTag() string {
+	if f.SyslogTag == "" {
+		return EVENTLOG_FORWARD_DEFAULT_SYSLOG_TAG
+	}
+	return f.SyslogTag
+}
+
+func (f *EventLogForwarderConfig) GetMaxFileSize() int64 {
+	if f.MaxFileSizeMB == 0 {
+		return EVENTLOG_FORWARD_DEFAULT_MAX_FILE_SIZE_MB * 1024 * 1024
+	}
+	return int64(f.MaxFileSizeMB) * 1024 * 1024
+}
+
+func (f *EventLogForwarderConfig) GetMaxFiles() int {
+	if f.MaxFiles == 0 {
+		return EVENTLOG_FORWARD_DEFAULT_MAX_FILES
+	}
+	return f.MaxFiles
+}
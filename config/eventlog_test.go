@@ -0,0 +1,58 @@
+//go:build unit
+// +build unit
+
+package config
+
+import (
+	"testing"
+)
+
+func Test_default_EventLogForwarder(t *testing.T) {
+
+	testCfg := &HorizonConfig{
+		Edge: Config{
+			EventLogForwarder: EventLogForwarderConfig{},
+		},
+	}
+
+	f := testCfg.Edge.EventLogForwarder
+	if testCfg.IsEventLogForwarderConfigured() {
+		t.Errorf("config API should indicate the forwarder is not configured")
+	} else if err := f.Validate(); err != nil {
+		t.Errorf("an empty forwarder config should be valid, error: %v", err)
+	} else if f.GetSyslogTag() != EVENTLOG_FORWARD_DEFAULT_SYSLOG_TAG {
+		t.Errorf("config API should return the default syslog tag, is %v", f.GetSyslogTag())
+	} else if f.GetMaxFileSize() != EVENTLOG_FORWARD_DEFAULT_MAX_FILE_SIZE_MB*1024*1024 {
+		t.Errorf("config API should return the default max file size, is %v", f.GetMaxFileSize())
+	} else if f.GetMaxFiles() != EVENTLOG_FORWARD_DEFAULT_MAX_FILES {
+		t.Errorf("config API should return the default max files, is %v", f.GetMaxFiles())
+	}
+
+}
+
+func Test_validate_EventLogForwarder(t *testing.T) {
+
+	valid := []EventLogForwarderConfig{
+		{Type: EVENTLOG_FORWARD_SYSLOG},
+		{Type: EVENTLOG_FORWARD_SYSLOG, SyslogNetwork: "udp", SyslogAddress: "localhost:514"},
+		{Type: EVENTLOG_FORWARD_FILE, FilePath: "/var/log/anax-events.log", MaxFileSizeMB: 1, MaxFiles: 2},
+	}
+	for _, f := range valid {
+		if err := f.Validate(); err != nil {
+			t.Errorf("config %v should be valid, error: %v", f.String(), err)
+		}
+	}
+
+	invalid := []EventLogForwarderConfig{
+		{Type: "kafka"},
+		{Type: EVENTLOG_FORWARD_SYSLOG, SyslogAddress: "localhost:514"},
+		{Type: EVENTLOG_FORWARD_FILE},
+		{Type: EVENTLOG_FORWARD_FILE, FilePath: "/var/log/anax-events.log", MaxFiles: -1},
+	}
+	for _, f := range invalid {
+		if err := f.Validate(); err == nil {
+			t.Errorf("config %v should not be valid", f.String())
+		}
+	}
+
+}
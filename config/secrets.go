@@ -0,0 +1,56 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// The name of the file created in the configured secrets file directory.
+const SECRETS_FILE_STORE_NAME = "agbot_secrets.enc"
+
+// Contains the configuration of the local encrypted file secrets provider used within AGConfig. This provider is
+// intended for a single agbot, it does not share secrets between agbot instances.
+type SecretFileConfig struct {
+	Path    string // The directory where the encrypted secrets file is kept.
+	KeyPath string // The file containing the base64 encoded 256 bit encryption key, created if necessary. Required, and must not be within Path.
+}
+
+func (s SecretFileConfig) GetStoreFile() string {
+	return path.Join(s.Path, SECRETS_FILE_STORE_NAME)
+}
+
+func (s SecretFileConfig) GetKeyFile() string {
+	return s.KeyPath
+}
+
+func (s SecretFileConfig) String() string {
+	return fmt.Sprintf("Path: %v, KeyPath: %v", s.Path, s.KeyPath)
+}
+
+// Contains the configuration of the generic HTTP secrets provider used within AGConfig. The contract that the remote
+// service has to implement is described in docs/agbot_secrets_providers.md.
+type SecretHTTPConfig struct {
+	URL         string // The base URL of the secrets service.
+	SSLCertPath string // The SSL certificate for the secrets service.
+}
+
+func (s SecretHTTPConfig) String() string {
+	return fmt.Sprintf("URL: %v, SSLCertPath: %v", s.URL, s.SSLCertPath)
+}
+
+func (c *HorizonConfig) IsSecretsFileConfigured() bool {
+	return len(c.AgreementBot.SecretsFile.Path) != 0
+}
+
+func (c *HorizonConfig) IsSecretsHTTPConfigured() bool {
+	return len(c.AgreementBot.SecretsHTTP.URL) != 0
+}
+
+func (c *HorizonConfig) GetSecretsHTTPURL() string {
+	return strings.TrimRight(c.AgreementBot.SecretsHTTP.URL, "/")
+}
+
+func (c *HorizonConfig) GetSecretsHTTPCertPath() string {
+	return strings.TrimRight(c.AgreementBot.SecretsHTTP.SSLCertPath, "/")
+}
@@ -0,0 +1,32 @@
+package config
+
+import (
+	"fmt"
+	"path"
+)
+
+// The name of the database file created in the configured SQLite directory.
+const SQLITE_DATABASE_NAME = "agreementbot.sqlite"
+
+type SqliteConfig struct {
+	DBPath        string // The directory where the SQLite database file is kept.
+	BusyTimeoutMS int    // The number of milliseconds to wait for a lock on the database before returning an error.
+}
+
+// Returns the path of the database file and the data source name used to open it. The database is always opened
+// with foreign keys on, WAL journaling and immediate write transactions so that readers are not blocked while the
+// agbot is updating the database.
+func (s SqliteConfig) MakeConnectionString() (string, string) {
+
+	busyTimeout := 5000
+	if s.BusyTimeoutMS != 0 {
+		busyTimeout = s.BusyTimeoutMS
+	}
+
+	dbFile := path.Join(s.DBPath, SQLITE_DATABASE_NAME)
+	return dbFile, fmt.Sprintf("file:%s?_pragma=busy_timeout(%d)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)&_txlock=immediate", dbFile, busyTimeout)
+}
+
+func (s SqliteConfig) String() string {
+	return fmt.Sprintf("DBPath: %v, BusyTimeoutMS: %v", s.DBPath, s.BusyTimeoutMS)
+}
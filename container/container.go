@@ -44,6 +44,13 @@ const (
 	LOG_DRIVER_JOURNALD    = "journald"
 )
 
+// The health states of a container that has a health check.
+const (
+	HEALTH_STARTING  = "starting"
+	HEALTH_HEALTHY   = "healthy"
+	HEALTH_UNHEALTHY = "unhealthy"
+)
+
 // messages for event logs
 const (
 	EL_CONT_DEPLOYCONF_UNSUPPORT_CAP_FOR_WL   = "Deployment config %v contains unsupported capability for a workload"
@@ -214,6 +221,19 @@ func removeDuplicateVariable(existingArray *[]string, newVar string) {
 
 }
 
+// Return the health state of a container from the status docker reports for it, e.g. "Up 5 minutes (unhealthy)". An
+// empty string is returned if the container does not have a health check.
+func GetContainerHealth(status string) string {
+	if strings.HasSuffix(status, "(health: starting)") {
+		return HEALTH_STARTING
+	} else if strings.HasSuffix(status, "(unhealthy)") {
+		return HEALTH_UNHEALTHY
+	} else if strings.HasSuffix(status, "(healthy)") {
+		return HEALTH_HEALTHY
+	}
+	return ""
+}
+
 func (w *ContainerWorker) finalizeDeployment(agreementId string, deployment *containermessage.DeploymentDescription, environmentAdditions map[string]string, workloadRWStorageDir string, cpuSet string, uds string) (map[string]servicePair, error) {
 
 	// final structure
@@ -344,6 +364,11 @@ func (w *ContainerWorker) finalizeDeployment(agreementId string, deployment *con
 			delete(logConfig.Config, "tag")
 		}
 
+		if err := service.Validate(); err != nil {
+			return nil, fmt.Errorf("Illegal deployment description for service %v: %v", serviceName, err)
+		}
+		restartPolicy, _ := service.GetRestartPolicy()
+
 		serviceConfig := &persistence.ServiceConfig{
 			Config: docker.Config{
 				Image:        service.Image,
@@ -359,10 +384,11 @@ func (w *ContainerWorker) finalizeDeployment(agreementId string, deployment *con
 				Privileged:      service.Privileged,
 				NetworkMode:     service.Network,
 				CapAdd:          service.CapAdd,
+				CapDrop:         service.CapDrop,
 				PublishAllPorts: false,
 				PortBindings:    map[docker.Port][]docker.PortBinding{},
 				Links:           nil, // do not allow any
-				RestartPolicy:   docker.AlwaysRestart(),
+				RestartPolicy:   restartPolicy,
 				Memory:          ramBytes,
 				MemorySwap:      0,
 				Devices:         []docker.Device{},
@@ -370,12 +396,18 @@ func (w *ContainerWorker) finalizeDeployment(agreementId string, deployment *con
 				Binds:           service.Binds,
 				GroupAdd:        groupAdds,
 				Tmpfs:           service.Tmpfs,
-				SecurityOpt:     service.SecurityOpt,
+				SecurityOpt:     service.GetSecurityOpt(),
 				Sysctls:         service.Sysctls,
 				PidMode:         service.PID,
+				ReadonlyRootfs:  service.ReadOnly,
 			},
 		}
 
+		// Let docker check the health of the container if the service config has a health check
+		if service.HealthCheck != nil {
+			serviceConfig.Config.Healthcheck = service.HealthCheck.GetDockerHealthConfig()
+		}
+
 		// Set CPU and memory limits if they are defined in the service config
 		if service.MaxMemoryMb != 0 {
 			serviceConfig.HostConfig.Memory = service.MaxMemoryMb * 1024 * 1024
@@ -384,6 +416,18 @@ func (w *ContainerWorker) finalizeDeployment(agreementId string, deployment *con
 			serviceConfig.HostConfig.NanoCPUs = int64(service.MaxCPUs * 1000000000)
 		}
 
+		// Set the process, file and block I/O limits if they are defined in the service config
+		for _, u := range service.Ulimits {
+			serviceConfig.HostConfig.Ulimits = append(serviceConfig.HostConfig.Ulimits, docker.ULimit{Name: u.Name, Soft: u.Soft, Hard: u.Hard})
+		}
+		if service.PidsLimit != 0 {
+			pidsLimit := service.PidsLimit
+			serviceConfig.HostConfig.PidsLimit = &pidsLimit
+		}
+		if service.Blkio != nil {
+			service.Blkio.SetDockerHostConfig(&serviceConfig.HostConfig)
+		}
+
 		// Mark each container as infrastructure if the deployment description indicates infrastructure
 		if deployment.Infrastructure {
 			serviceConfig.Config.Labels[LABEL_PREFIX+".infrastructure"] = ""
@@ -878,6 +922,12 @@ func serviceStart(client *docker.Client,
 			return fail(container, serviceName, err)
 		}
 	}
+
+	// keep the recent output of the container for the agent API, except for the containers started by hzn dev
+	if serviceConfig.Config.Labels[LABEL_PREFIX+".dev_service"] != "true" {
+		collectServiceLogs(client, container.ID, namePrefix, serviceName, serviceConfig.Config.Tty, serviceConfig.HostConfig.LogConfig.Type)
+	}
+
 	if serviceConfig.HostConfig.NetworkMode != "host" {
 		for _, cfg := range sharedEndpoints {
 			glog.V(5).Infof("Connecting network: %v to container id: %v as endpoint: %v", cfg.NetworkID, container.ID, cfg.Aliases)
@@ -1509,6 +1559,9 @@ func (b *ContainerWorker) ResourcesCreate(agreementId string, agreementProtocol
 
 func (b *ContainerWorker) Initialize() bool {
 	b.syncupResources()
+
+	// Delete the volumes kept after the end of their agreement once their retention time has passed.
+	b.DispatchSubworker(VOLUME_RETENTION_MONITOR, b.deleteExpiredVolumes, VOLUME_RETENTION_CHECK_INTERVAL_S, false)
 	return true
 }
 
@@ -1816,7 +1869,13 @@ func (b *ContainerWorker) CommandHandler(command worker.Command) bool {
 			report := func(container *docker.APIContainers, agreementId string) error {
 
 				for _, name := range serviceNames {
-					if container.Labels[LABEL_PREFIX+".service_name"] == name && container.State == "running" {
+					if container.Labels[LABEL_PREFIX+".service_name"] != name {
+						continue
+					} else if container.State != "running" {
+						glog.Errorf("Service container for agreement %v is not in the running state.", agreementId)
+					} else if GetContainerHealth(container.Status) == HEALTH_UNHEALTHY {
+						glog.Errorf("Service container for agreement %v is unhealthy: %v", agreementId, container.Status)
+					} else {
 						cMatches = append(cMatches, *container)
 						glog.V(4).Infof("Matching container instance for agreement %v: %v", agreementId, container)
 					}
@@ -1829,7 +1888,7 @@ func (b *ContainerWorker) CommandHandler(command worker.Command) bool {
 			if len(serviceNames) == len(cMatches) {
 				glog.V(3).Infof("Found expected count of running containers for agreement %v: %v", cmd.AgreementId, len(cMatches))
 			} else {
-				glog.Errorf("Insufficient running containers found for agreement %v. Found: %v", cmd.AgreementId, cMatches)
+				glog.Errorf("Insufficient running and healthy containers found for agreement %v. Found: %v", cmd.AgreementId, cMatches)
 
 				// ask governer to cancel the agreement
 				b.Messages() <- events.NewWorkloadMessage(events.EXECUTION_FAILED, cmd.AgreementProtocol, cmd.AgreementId, cmd.Deployment)
@@ -1856,6 +1915,7 @@ func (b *ContainerWorker) CommandHandler(command worker.Command) bool {
 		if err := b.ResourcesRemove(agreements); err != nil {
 			glog.Errorf("Error removing resources: %v", err)
 		}
+		b.releaseContainerVolumes(agreements)
 
 		// send the event to let others know that the workload clean up has been processed
 		b.Messages() <- events.NewWorkloadMessage(events.WORKLOAD_DESTROYED, cmd.AgreementProtocol, cmd.CurrentAgreementId, nil)
@@ -1891,6 +1951,8 @@ func (b *ContainerWorker) CommandHandler(command worker.Command) bool {
 					if container.Labels[LABEL_PREFIX+".service_name"] == name {
 						if container.State != "running" {
 							glog.Errorf("Service container for %v is not in the running state.", instance_key)
+						} else if GetContainerHealth(container.Status) == HEALTH_UNHEALTHY {
+							glog.Errorf("Service container for %v is unhealthy: %v", instance_key, container.Status)
 						} else {
 							cMatches = append(cMatches, *container)
 							glog.V(4).Infof("Matching container instance for service instance %v: %v", instance_key, container)
@@ -1905,7 +1967,7 @@ func (b *ContainerWorker) CommandHandler(command worker.Command) bool {
 			if len(serviceNames) == len(cMatches) {
 				glog.V(3).Infof("Found expected count of running containers for service instance %v: %v", cmd.MsInstKey, len(cMatches))
 			} else {
-				glog.Errorf("Insufficient running containers found for service instance %v. Found: %v", cmd.MsInstKey, cMatches)
+				glog.Errorf("Insufficient running and healthy containers found for service instance %v. Found: %v", cmd.MsInstKey, cMatches)
 
 				// ask governer to record it into the db
 				cc := events.NewContainerConfig("", "", "", "", "", "", nil)
@@ -1925,6 +1987,7 @@ func (b *ContainerWorker) CommandHandler(command worker.Command) bool {
 		if err := b.ResourcesRemove(agreements); err != nil {
 			glog.Errorf("Error removing resources: %v", err)
 		}
+		b.releaseContainerVolumes(agreements)
 
 		// send the event to let others know that the microservice clean up has been processed
 		b.Messages() <- events.NewMicroserviceContainersDestroyedMessage(events.CONTAINER_DESTROYED, cmd.MsInstKey)
@@ -2109,6 +2172,9 @@ func (b *ContainerWorker) syncupResources() {
 					leftoverAgreements[container.Labels[LABEL_PREFIX+".agreement_id"]] = true
 				}
 			}
+
+			// Resume collecting the output of the service containers that are still running.
+			b.collectRunningServiceLogs(containers, agMap)
 		}
 
 		// Third, run through each network looking for networks that are leftover from old agreements. Be aware that there
@@ -2170,6 +2236,7 @@ func (b *ContainerWorker) syncupResources() {
 			if err := b.ResourcesRemove(agreementList); err != nil {
 				fail(fmt.Sprintf("ContainerWorker unable to get rid of left over resources, error: %v", err))
 			}
+			b.releaseContainerVolumes(agreementList)
 		}
 
 	}
@@ -2185,6 +2252,41 @@ func (b *ContainerWorker) syncupResources() {
 	b.Messages() <- events.NewDeviceContainersSyncedMessage(events.DEVICE_CONTAINERS_SYNCED, outcome)
 }
 
+// Start collecting the output of the running service containers of the current agreements and of the shared services.
+func (b *ContainerWorker) collectRunningServiceLogs(containers []docker.APIContainers, agMap map[string]bool) {
+	for _, container := range containers {
+		if container.State != "running" || len(container.Names) == 0 {
+			continue
+		} else if _, svcLabel := container.Labels[LABEL_PREFIX+".service_name"]; !svcLabel {
+			continue
+		} else if val, exists := container.Labels[LABEL_PREFIX+".dev_service"]; exists && val == "true" {
+			continue
+		}
+
+		instance := container.Labels[LABEL_PREFIX+".agreement_id"]
+		if shareLabel, sharedThere := container.Labels[LABEL_PREFIX+".service_pattern.shared"]; sharedThere {
+			instance = shareLabel
+		} else if !agMap[instance] {
+			continue
+		}
+
+		// The container name is the instance followed by the name of the service in the deployment.
+		name := strings.TrimPrefix(container.Names[0], "/")
+		if !strings.HasPrefix(name, instance+"-") {
+			continue
+		}
+		if conDetail, err := b.client.InspectContainer(container.ID); err != nil {
+			glog.Errorf("ContainerWorker unable to inspect container %v, error: %v", name, err)
+		} else {
+			logDriver := ""
+			if conDetail.HostConfig != nil {
+				logDriver = conDetail.HostConfig.LogConfig.Type
+			}
+			collectServiceLogs(b.client, container.ID, instance, strings.TrimPrefix(name, instance+"-"), conDetail.Config != nil && conDetail.Config.Tty, logDriver)
+		}
+	}
+}
+
 // Given a list of containers on which a parent service is dependent, we need to get a list of dependency service network ids
 // so that they can be added to all of this (parent) service's containers. The dependency containers can be in more than 1 network so
 // we have to carefully choose the networks that the parent container should connect to. Only choose the network
@@ -2953,11 +3055,18 @@ func (b *ContainerWorker) createDockerVolumesForContainer(serviceName string, ag
 				}
 			}
 
+			// the driver, quota and retention policy of the volume if the service declares it
+			vol := containermessage.Volume{}
+			if servicePair.service != nil {
+				vol = servicePair.service.GetVolume(vol_name)
+			}
+
 			if !bExists {
 				// create the volume if it does not exist
 				vOption := docker.CreateVolumeOptions{
-					Name:   vol_name,
-					Driver: "local",
+					Name:       vol_name,
+					Driver:     vol.GetDriver(),
+					DriverOpts: vol.GetDriverOpts(),
 					Labels: map[string]string{
 						LABEL_PREFIX + ".service_name": serviceName,
 						LABEL_PREFIX + ".agreement_id": agreementId,
@@ -2968,15 +3077,23 @@ func (b *ContainerWorker) createDockerVolumesForContainer(serviceName string, ag
 					return fmt.Errorf("Failed to create the docker volume %v for service %v. %v", vol_name, serviceName, err)
 				} else {
 					glog.V(3).Infof("Volume %v created for service %v.", vol_name, serviceName)
+					if vol.SizeMb != 0 && !vol.QuotaEnforced() {
+						glog.Warningf("The %v MB size quota of volume %v for service %v is not enforced by the %v driver.", vol.SizeMb, vol_name, serviceName, vol.GetDriver())
+					}
 
 					// same the volume in local db so that it can be cleaned up at unregistration time
 					// Ling todo - only save the ones that are specified by the user in binds.
 					if b.db != nil {
-						if persistence.SaveContainerVolumeByName(b.db, vol_name); err != nil {
+						if err := persistence.SaveContainerVolume(b.db, newContainerVolume(vol_name, serviceName, agreementId, vol)); err != nil {
 							return fmt.Errorf("Failed to get save the docker volume name %v into the local db. %v", vol_name, err)
 						}
 					}
 				}
+			} else if b.db != nil {
+				// the volume can be kept from a previous agreement, e.g. for the previous version of the service
+				if err := useContainerVolume(b.db, vol_name, serviceName, agreementId, vol); err != nil {
+					return fmt.Errorf("Failed to save the docker volume %v for service %v into the local db. %v", vol_name, serviceName, err)
+				}
 			}
 		}
 	}
@@ -272,3 +272,18 @@ func Test_CheckPermissions_0(t *testing.T) {
 	}
 
 }
+
+func Test_GetContainerHealth(t *testing.T) {
+	statuses := map[string]string{
+		"Up Less than a second":           "",
+		"Up 2 seconds (health: starting)": HEALTH_STARTING,
+		"Up 5 minutes (healthy)":          HEALTH_HEALTHY,
+		"Up 10 minutes (unhealthy)":       HEALTH_UNHEALTHY,
+		"Exited (1) 3 seconds ago":        "",
+	}
+	for status, expected := range statuses {
+		if health := GetContainerHealth(status); health != expected {
+			t.Errorf("GetContainerHealth for status %v should return %v but got %v.", status, expected, health)
+		}
+	}
+}
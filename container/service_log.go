@@ -0,0 +1,315 @@
+package container
+
+import (
+	"bytes"
+	"fmt"
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/exchange"
+	"sort"
+	"sync"
+	"time"
+)
+
+// The number of lines kept for each service container, older lines are dropped. Longer lines are truncated.
+const SERVICE_LOG_BUFFER_LINES = 1000
+const SERVICE_LOG_MAX_LINE_LENGTH = 16384
+
+// How often the log collector of a stopped container checks whether docker restarted it or it was removed.
+const SERVICE_LOG_RESTART_CHECK_INTERVAL = 10 * time.Second
+
+// The recent output of a service container. The container output is read by attaching to the container, so it is
+// available whatever log driver the service uses.
+type serviceLogBuffer struct {
+	containerId string // the container being collected, protected by serviceLogsLock
+	lock        sync.Mutex
+	lines       []serviceLogEntry // a ring buffer
+	next        int
+	seq         uint64 // the sequence number of the last line added
+}
+
+// A line in a log buffer. Lines are numbered in the order they are added, several lines can have the same time.
+type serviceLogEntry struct {
+	seq  uint64
+	line exchange.ServiceLogLine
+}
+
+func (b *serviceLogBuffer) add(line exchange.ServiceLogLine) {
+	b.lock.Lock()
+	b.seq++
+	entry := serviceLogEntry{seq: b.seq, line: line}
+	if len(b.lines) < SERVICE_LOG_BUFFER_LINES {
+		b.lines = append(b.lines, entry)
+	} else {
+		b.lines[b.next] = entry
+	}
+	b.next = (b.next + 1) % SERVICE_LOG_BUFFER_LINES
+	b.lock.Unlock()
+
+	notifyServiceLogsUpdated()
+}
+
+// Return the lines after the input time, oldest first.
+func (b *serviceLogBuffer) since(t time.Time) []exchange.ServiceLogLine {
+	lines, _ := b.after(t, 0)
+	return lines
+}
+
+// Return the lines after the input time that were added after the line with the input sequence number, oldest first,
+// and the sequence number of the last line added.
+func (b *serviceLogBuffer) after(t time.Time, seq uint64) ([]exchange.ServiceLogLine, uint64) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	res := make([]exchange.ServiceLogLine, 0, len(b.lines))
+	start := 0
+	if len(b.lines) == SERVICE_LOG_BUFFER_LINES {
+		start = b.next
+	}
+	for ix := 0; ix < len(b.lines); ix++ {
+		entry := b.lines[(start+ix)%len(b.lines)]
+		if entry.seq > seq && entry.line.Time.After(t) {
+			res = append(res, entry.line)
+		}
+	}
+	return res, b.seq
+}
+
+// Splits the container output into lines.
+type serviceLogWriter struct {
+	buffer    *serviceLogBuffer
+	container string
+	stream    string
+	partial   []byte
+}
+
+func (w *serviceLogWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) != 0 {
+		ix := bytes.IndexByte(p, '\n')
+		if ix == -1 {
+			w.partial = append(w.partial, p...)
+			break
+		}
+		w.partial = append(w.partial, p[:ix]...)
+		w.flush()
+		p = p[ix+1:]
+	}
+	return n, nil
+}
+
+func (w *serviceLogWriter) flush() {
+	if len(w.partial) == 0 {
+		return
+	}
+	line := bytes.TrimSuffix(w.partial, []byte("\r"))
+	if len(line) > SERVICE_LOG_MAX_LINE_LENGTH {
+		line = line[:SERVICE_LOG_MAX_LINE_LENGTH]
+	}
+	w.buffer.add(exchange.ServiceLogLine{Time: time.Now(), Container: w.container, Stream: w.stream, Line: string(line)})
+	w.partial = w.partial[:0]
+}
+
+// The log buffers of each service instance, by container name. The instance is the prefix of the container names,
+// the agreement id or the service instance id.
+var serviceLogsLock sync.Mutex
+var serviceLogs = make(map[string]map[string]*serviceLogBuffer)
+
+// The channel is closed and replaced each time a line is added to a log buffer, so that API handlers following the
+// logs can wait for new lines instead of polling.
+var serviceLogsUpdatedLock sync.Mutex
+var serviceLogsUpdated = make(chan struct{})
+
+// Returns a channel that is closed when the next line is added to a log buffer.
+func ServiceLogsUpdated() <-chan struct{} {
+	serviceLogsUpdatedLock.Lock()
+	defer serviceLogsUpdatedLock.Unlock()
+	return serviceLogsUpdated
+}
+
+func notifyServiceLogsUpdated() {
+	serviceLogsUpdatedLock.Lock()
+	defer serviceLogsUpdatedLock.Unlock()
+	close(serviceLogsUpdated)
+	serviceLogsUpdated = make(chan struct{})
+}
+
+// The position of a client following the logs of a service instance, the sequence number of the last line returned
+// from each log buffer. The lines that have the same time are each returned once.
+type ServiceLogCursor struct {
+	seqs map[*serviceLogBuffer]uint64
+}
+
+func NewServiceLogCursor() *ServiceLogCursor {
+	return &ServiceLogCursor{seqs: make(map[*serviceLogBuffer]uint64)}
+}
+
+// Return the lines of a service instance written after the since time, at most tail of them if tail is positive. If the
+// container name is empty, the lines of all the containers of the instance are returned. The returned bool is false if
+// there are no log buffers for the instance or the container.
+func GetServiceLogs(instance string, containerName string, since time.Time, tail int) ([]exchange.ServiceLogLine, bool) {
+	return getServiceLogs(instance, containerName, since, tail, nil)
+}
+
+// Return the lines of a service instance like GetServiceLogs, leaving out the lines before the cursor. The cursor is
+// moved after the last line of each log buffer.
+func FollowServiceLogs(instance string, containerName string, since time.Time, tail int, cursor *ServiceLogCursor) ([]exchange.ServiceLogLine, bool) {
+	return getServiceLogs(instance, containerName, since, tail, cursor)
+}
+
+func getServiceLogs(instance string, containerName string, since time.Time, tail int, cursor *ServiceLogCursor) ([]exchange.ServiceLogLine, bool) {
+	serviceLogsLock.Lock()
+	buffers := make([]*serviceLogBuffer, 0, 2)
+	for name, buffer := range serviceLogs[instance] {
+		if containerName == "" || containerName == name {
+			buffers = append(buffers, buffer)
+		}
+	}
+	serviceLogsLock.Unlock()
+
+	if len(buffers) == 0 {
+		return nil, false
+	}
+
+	// The buffers of containers that were removed are dropped from the cursor.
+	seqs := make(map[*serviceLogBuffer]uint64, len(buffers))
+	lines := make([]exchange.ServiceLogLine, 0, 10)
+	for _, buffer := range buffers {
+		seq := uint64(0)
+		if cursor != nil {
+			seq = cursor.seqs[buffer]
+		}
+		bufferLines, last := buffer.after(since, seq)
+		lines = append(lines, bufferLines...)
+		seqs[buffer] = last
+	}
+	if cursor != nil {
+		cursor.seqs = seqs
+	}
+
+	if len(buffers) > 1 {
+		sort.SliceStable(lines, func(i, j int) bool { return lines[i].Time.Before(lines[j].Time) })
+	}
+	if tail > 0 && len(lines) > tail {
+		lines = lines[len(lines)-tail:]
+	}
+	return lines, true
+}
+
+// Return the service instances that have log buffers, sorted.
+func ServiceLogInstances() []string {
+	serviceLogsLock.Lock()
+	defer serviceLogsLock.Unlock()
+
+	instances := make([]string, 0, len(serviceLogs))
+	for instance := range serviceLogs {
+		instances = append(instances, instance)
+	}
+	sort.Strings(instances)
+	return instances
+}
+
+// Docker only returns the output written before an attach when the log driver of the container can be read back. The
+// other drivers, e.g. syslog, fail the attach if the prior output is requested.
+func serviceLogDriverReadable(logDriver string) bool {
+	switch logDriver {
+	case "", "json-file", "local", LOG_DRIVER_JOURNALD:
+		return true
+	}
+	return false
+}
+
+// Start collecting the output of a service container into the log buffer of the container, unless it is already being
+// collected. The lines collected from a previous container with the same name are kept.
+func collectServiceLogs(client *docker.Client, containerId string, instance string, containerName string, tty bool, logDriver string) {
+	serviceLogsLock.Lock()
+	if _, ok := serviceLogs[instance]; !ok {
+		serviceLogs[instance] = make(map[string]*serviceLogBuffer)
+	}
+	buffer, ok := serviceLogs[instance][containerName]
+	if !ok {
+		buffer = new(serviceLogBuffer)
+		serviceLogs[instance][containerName] = buffer
+	} else if buffer.containerId == containerId {
+		serviceLogsLock.Unlock()
+		return
+	}
+	buffer.containerId = containerId
+	serviceLogsLock.Unlock()
+
+	glog.V(3).Infof(serviceLogString(fmt.Sprintf("collecting the output of container %v for service instance %v", containerName, instance)))
+	go buffer.collect(client, containerId, instance, containerName, tty, serviceLogDriverReadable(logDriver))
+}
+
+func (b *serviceLogBuffer) collect(client *docker.Client, containerId string, instance string, containerName string, tty bool, logs bool) {
+
+	// The first attach also returns the output written before the agent attached, e.g. when the agent restarts, if the
+	// log driver can be read.
+	for {
+		stdout := &serviceLogWriter{buffer: b, container: containerName, stream: "stdout"}
+		stderr := &serviceLogWriter{buffer: b, container: containerName, stream: "stderr"}
+		err := client.AttachToContainer(docker.AttachToContainerOptions{
+			Container:    containerId,
+			OutputStream: stdout,
+			ErrorStream:  stderr,
+			Logs:         logs,
+			Stream:       true,
+			Stdout:       true,
+			Stderr:       true,
+			RawTerminal:  tty,
+		})
+		stdout.flush()
+		stderr.flush()
+		if err != nil && logs {
+			// Attach again right away without the prior output, in case docker cannot return it.
+			glog.V(3).Infof(serviceLogString(fmt.Sprintf("unable to attach to container %v for service instance %v with its prior output, error: %v", containerName, instance, err)))
+			logs = false
+			continue
+		} else if err != nil {
+			// The container can still be running when the attach fails, so wait before trying again.
+			glog.V(3).Infof(serviceLogString(fmt.Sprintf("unable to attach to container %v for service instance %v, error: %v", containerName, instance, err)))
+			time.Sleep(SERVICE_LOG_RESTART_CHECK_INTERVAL)
+		}
+		logs = false
+
+		// The attach ends when the container stops. Wait until docker restarts it, or until it is removed.
+		if !b.waitForRestart(client, containerId) {
+			serviceLogsLock.Lock()
+			if buffer, ok := serviceLogs[instance][containerName]; ok && buffer.containerId == containerId {
+				delete(serviceLogs[instance], containerName)
+				if len(serviceLogs[instance]) == 0 {
+					delete(serviceLogs, instance)
+				}
+			}
+			serviceLogsLock.Unlock()
+			glog.V(3).Infof(serviceLogString(fmt.Sprintf("stopped collecting the output of container %v for service instance %v", containerName, instance)))
+			return
+		}
+	}
+}
+
+// Return true when the container is running again, false if it was removed or replaced by another container.
+func (b *serviceLogBuffer) waitForRestart(client *docker.Client, containerId string) bool {
+	for {
+		serviceLogsLock.Lock()
+		replaced := b.containerId != containerId
+		serviceLogsLock.Unlock()
+		if replaced {
+			return false
+		}
+
+		if c, err := client.InspectContainer(containerId); err != nil {
+			if _, ok := err.(*docker.NoSuchContainer); ok {
+				return false
+			}
+			glog.Warningf(serviceLogString(fmt.Sprintf("unable to inspect container %v, error: %v", containerId, err)))
+		} else if c.State.Running {
+			return true
+		}
+		time.Sleep(SERVICE_LOG_RESTART_CHECK_INTERVAL)
+	}
+}
+
+var serviceLogString = func(v interface{}) string {
+	return fmt.Sprintf("ContainerWorker service logs: %v", v)
+}
@@ -0,0 +1,182 @@
+//go:build unit
+// +build unit
+
+package container
+
+import (
+	"fmt"
+	"github.com/open-horizon/anax/exchange"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_serviceLogWriter_lines(t *testing.T) {
+
+	buffer := new(serviceLogBuffer)
+	w := &serviceLogWriter{buffer: buffer, container: "c1", stream: "stdout"}
+
+	w.Write([]byte("first line\nsecond "))
+	w.Write([]byte("line\r\n\nthird"))
+	if lines := buffer.since(time.Time{}); len(lines) != 2 {
+		t.Errorf("expected 2 lines, got %v", lines)
+	}
+
+	w.flush()
+	lines := buffer.since(time.Time{})
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %v", lines)
+	}
+	for ix, expected := range []string{"first line", "second line", "third"} {
+		if lines[ix].Line != expected {
+			t.Errorf("expected line %v to be %v, got %v", ix, expected, lines[ix].Line)
+		} else if lines[ix].Container != "c1" || lines[ix].Stream != "stdout" {
+			t.Errorf("expected line %v to come from stdout of c1, got %v", ix, lines[ix])
+		}
+	}
+
+	w.Write([]byte(strings.Repeat("x", SERVICE_LOG_MAX_LINE_LENGTH+10) + "\n"))
+	lines = buffer.since(time.Time{})
+	if len(lines[3].Line) != SERVICE_LOG_MAX_LINE_LENGTH {
+		t.Errorf("expected the line to be truncated to %v characters, got %v", SERVICE_LOG_MAX_LINE_LENGTH, len(lines[3].Line))
+	}
+}
+
+func Test_serviceLogBuffer_wrap(t *testing.T) {
+
+	buffer := new(serviceLogBuffer)
+	w := &serviceLogWriter{buffer: buffer, container: "c1", stream: "stdout"}
+	for ix := 0; ix < SERVICE_LOG_BUFFER_LINES+5; ix++ {
+		w.Write([]byte(fmt.Sprintf("line %v\n", ix)))
+	}
+
+	lines := buffer.since(time.Time{})
+	if len(lines) != SERVICE_LOG_BUFFER_LINES {
+		t.Fatalf("expected %v lines, got %v", SERVICE_LOG_BUFFER_LINES, len(lines))
+	} else if lines[0].Line != "line 5" {
+		t.Errorf("expected the oldest line to be line 5, got %v", lines[0].Line)
+	} else if last := lines[len(lines)-1].Line; last != fmt.Sprintf("line %v", SERVICE_LOG_BUFFER_LINES+4) {
+		t.Errorf("expected the newest line to be line %v, got %v", SERVICE_LOG_BUFFER_LINES+4, last)
+	}
+
+	since := lines[len(lines)-3].Time
+	if lines := buffer.since(since); len(lines) > 2 {
+		t.Errorf("expected at most 2 lines after %v, got %v", since, len(lines))
+	}
+}
+
+func Test_GetServiceLogs(t *testing.T) {
+
+	b1 := new(serviceLogBuffer)
+	b2 := new(serviceLogBuffer)
+	serviceLogsLock.Lock()
+	serviceLogs["instance1"] = map[string]*serviceLogBuffer{"c1": b1, "c2": b2}
+	serviceLogsLock.Unlock()
+	defer func() {
+		serviceLogsLock.Lock()
+		delete(serviceLogs, "instance1")
+		serviceLogsLock.Unlock()
+	}()
+
+	w1 := &serviceLogWriter{buffer: b1, container: "c1", stream: "stdout"}
+	w2 := &serviceLogWriter{buffer: b2, container: "c2", stream: "stderr"}
+	w1.Write([]byte("one\n"))
+	w2.Write([]byte("two\n"))
+	w1.Write([]byte("three\n"))
+
+	if _, found := GetServiceLogs("instance2", "", time.Time{}, 0); found {
+		t.Errorf("expected no logs for an unknown instance")
+	} else if _, found := GetServiceLogs("instance1", "c3", time.Time{}, 0); found {
+		t.Errorf("expected no logs for an unknown container")
+	}
+
+	if lines, found := GetServiceLogs("instance1", "", time.Time{}, 0); !found || len(lines) != 3 {
+		t.Errorf("expected 3 lines, got %v", lines)
+	} else {
+		for ix := 1; ix < len(lines); ix++ {
+			if lines[ix].Time.Before(lines[ix-1].Time) {
+				t.Errorf("expected the lines to be sorted by time, got %v", lines)
+			}
+		}
+	}
+
+	if lines, found := GetServiceLogs("instance1", "c1", time.Time{}, 0); !found || len(lines) != 2 {
+		t.Errorf("expected 2 lines from c1, got %v", lines)
+	}
+
+	if lines, found := GetServiceLogs("instance1", "", time.Time{}, 1); !found || len(lines) != 1 || lines[0].Line != "three" {
+		t.Errorf("expected the last line only, got %v", lines)
+	}
+}
+
+func Test_ServiceLogsUpdated(t *testing.T) {
+
+	updated := ServiceLogsUpdated()
+	select {
+	case <-updated:
+		t.Errorf("expected the channel to be open before a line is added")
+	default:
+	}
+
+	w := &serviceLogWriter{buffer: new(serviceLogBuffer), container: "c1", stream: "stdout"}
+	w.Write([]byte("line\n"))
+	select {
+	case <-updated:
+	default:
+		t.Errorf("expected the channel to be closed after a line is added")
+	}
+}
+
+func Test_FollowServiceLogs(t *testing.T) {
+
+	b1 := new(serviceLogBuffer)
+	serviceLogsLock.Lock()
+	serviceLogs["instance1"] = map[string]*serviceLogBuffer{"c1": b1}
+	serviceLogsLock.Unlock()
+	defer func() {
+		serviceLogsLock.Lock()
+		delete(serviceLogs, "instance1")
+		serviceLogsLock.Unlock()
+	}()
+
+	// The lines written at the same time are each returned once.
+	now := time.Now()
+	b1.add(exchange.ServiceLogLine{Time: now, Container: "c1", Stream: "stdout", Line: "one"})
+	cursor := NewServiceLogCursor()
+	if lines, found := FollowServiceLogs("instance1", "", time.Time{}, 0, cursor); !found || len(lines) != 1 {
+		t.Errorf("expected 1 line, got %v", lines)
+	}
+
+	b1.add(exchange.ServiceLogLine{Time: now, Container: "c1", Stream: "stdout", Line: "two"})
+	b1.add(exchange.ServiceLogLine{Time: now, Container: "c1", Stream: "stderr", Line: "three"})
+	if lines, found := FollowServiceLogs("instance1", "", time.Time{}, 0, cursor); !found || len(lines) != 2 || lines[0].Line != "two" || lines[1].Line != "three" {
+		t.Errorf("expected lines two and three, got %v", lines)
+	} else if lines, found := FollowServiceLogs("instance1", "", time.Time{}, 0, cursor); !found || len(lines) != 0 {
+		t.Errorf("expected no new lines, got %v", lines)
+	}
+
+	// The lines of a container that is added later are all returned.
+	b2 := new(serviceLogBuffer)
+	serviceLogsLock.Lock()
+	serviceLogs["instance1"]["c2"] = b2
+	serviceLogsLock.Unlock()
+	b2.add(exchange.ServiceLogLine{Time: now, Container: "c2", Stream: "stdout", Line: "four"})
+	if lines, found := FollowServiceLogs("instance1", "", time.Time{}, 0, cursor); !found || len(lines) != 1 || lines[0].Line != "four" {
+		t.Errorf("expected line four, got %v", lines)
+	}
+
+	// The tail and since parameters still apply.
+	if lines, found := FollowServiceLogs("instance1", "c1", time.Time{}, 2, NewServiceLogCursor()); !found || len(lines) != 2 || lines[0].Line != "two" {
+		t.Errorf("expected the last 2 lines of c1, got %v", lines)
+	} else if lines, found := FollowServiceLogs("instance1", "", now, 0, NewServiceLogCursor()); !found || len(lines) != 0 {
+		t.Errorf("expected no lines after %v, got %v", now, lines)
+	}
+}
+
+func Test_serviceLogDriverReadable(t *testing.T) {
+	for driver, readable := range map[string]bool{"": true, "json-file": true, "local": true, LOG_DRIVER_JOURNALD: true, LOG_DRIVER_SYSLOG: false, "fluentd": false} {
+		if serviceLogDriverReadable(driver) != readable {
+			t.Errorf("Expected log driver %v readable to be %v", driver, readable)
+		}
+	}
+}
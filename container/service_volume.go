@@ -0,0 +1,264 @@
+package container
+
+import (
+	"fmt"
+	"github.com/boltdb/bolt"
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/config"
+	"github.com/open-horizon/anax/containermessage"
+	"github.com/open-horizon/anax/persistence"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const VOLUME_RETENTION_MONITOR = "VolumeRetentionMonitor"
+
+// The number of seconds between checks for the kept volumes whose retention time has passed.
+const VOLUME_RETENTION_CHECK_INTERVAL_S = 300
+
+// A docker volume created by the agent for a service container, as returned by the /service/volumes API.
+type ServiceVolume struct {
+	Name          string `json:"name"`
+	ServiceName   string `json:"service_name"`
+	AgreementId   string `json:"agreement_id"`      // the agreement or service instance that uses the volume, or used it last
+	Driver        string `json:"driver"`            // the docker volume driver
+	SizeMb        int64  `json:"size_mb,omitempty"` // the size quota, 0 for none
+	QuotaEnforced bool   `json:"quota_enforced"`    // false if the driver cannot limit the size of the volume
+	UsageBytes    int64  `json:"usage_bytes"`       // the disk space used by the files of a local volume, -1 if it cannot be measured
+	OverQuota     bool   `json:"over_quota"`        // true if the volume uses more than its quota
+	Retention     string `json:"retention"`         // delete, keep or keep:<hours>
+	InUse         bool   `json:"in_use"`            // false once the agreement that used the volume has ended
+	CreationTime  uint64 `json:"creation_time"`     // the time the volume was created
+	ReleaseTime   uint64 `json:"release_time"`      // the time the agreement that used the volume ended, 0 while in use
+	ExpiryTime    uint64 `json:"expiry_time"`       // the time the released volume will be deleted, 0 if it is kept until the node is unregistered
+}
+
+func (s ServiceVolume) String() string {
+	return fmt.Sprintf("Name: %v, ServiceName: %v, AgreementId: %v, Driver: %v, SizeMb: %v, QuotaEnforced: %v, UsageBytes: %v, OverQuota: %v, Retention: %v, InUse: %v, CreationTime: %v, ReleaseTime: %v, ExpiryTime: %v",
+		s.Name, s.ServiceName, s.AgreementId, s.Driver, s.SizeMb, s.QuotaEnforced, s.UsageBytes, s.OverQuota, s.Retention, s.InUse, s.CreationTime, s.ReleaseTime, s.ExpiryTime)
+}
+
+// The volume record saved when the agent creates a volume for a service.
+func newContainerVolume(name string, serviceName string, agreementId string, vol containermessage.Volume) *persistence.ContainerVolume {
+	cv := persistence.NewContainerVolume(name)
+	cv.Driver = vol.GetDriver()
+	cv.SizeMb = vol.SizeMb
+	cv.QuotaEnforced = vol.QuotaEnforced()
+	setContainerVolumeUser(cv, serviceName, agreementId, vol)
+	return cv
+}
+
+func setContainerVolumeUser(cv *persistence.ContainerVolume, serviceName string, agreementId string, vol containermessage.Volume) {
+	cv.ServiceName = serviceName
+	cv.AgreementId = agreementId
+	cv.Retention = vol.Retention
+	cv.ReleaseTime = 0
+	cv.ExpiryTime = 0
+}
+
+// A new agreement uses an existing volume. If the volume was created by the agent and kept after the end of a previous
+// agreement, it is now used by the new agreement and is no longer deleted when its retention time passes. The driver and
+// the quota of an existing volume do not change, the retention policy is the one of the new agreement.
+func useContainerVolume(db *bolt.DB, name string, serviceName string, agreementId string, vol containermessage.Volume) error {
+	cv, err := persistence.FindUndeletedContainerVolumeByName(db, name)
+	if err != nil {
+		return err
+	} else if cv == nil {
+		glog.V(3).Infof("Volume %v for service %v was not created by the agent, it is left alone.", name, serviceName)
+		return nil
+	}
+
+	if cv.IsReleased() {
+		glog.V(3).Infof("Volume %v kept from agreement %v is now used by service %v in agreement %v.", name, cv.AgreementId, serviceName, agreementId)
+	}
+	setContainerVolumeUser(cv, serviceName, agreementId, vol)
+	return persistence.SaveContainerVolume(db, cv)
+}
+
+// Apply the retention policy of the volumes used by the agreements or service instances that have ended. A volume is
+// deleted, or kept for the next agreement until it expires or until the node is unregistered.
+func (b *ContainerWorker) releaseContainerVolumes(agreements []string) {
+	if b.db == nil {
+		return
+	}
+
+	for _, agreementId := range agreements {
+		cvs, err := persistence.FindContainerVolumes(b.db, []persistence.ContainerVolumeFilter{persistence.UnarchivedCVFilter(), persistence.AgreementCVFilter(agreementId)})
+		if err != nil {
+			glog.Errorf("Error retrieving the volumes of agreement %v from the local db. %v", agreementId, err)
+			continue
+		}
+
+		for _, cv := range cvs {
+			policy, hours, err := (&containermessage.Volume{Retention: cv.Retention}).GetRetentionPolicy()
+			if err != nil {
+				glog.Errorf("Volume %v of agreement %v will be kept. %v", cv.Name, agreementId, err)
+			}
+
+			if policy == containermessage.VOLUME_RETENTION_DELETE {
+				if err := deleteContainerVolume(b.client, b.db, &cv); err != nil {
+					glog.Errorf("Failed to delete volume %v of agreement %v. %v", cv.Name, agreementId, err)
+				} else {
+					glog.V(3).Infof("Volume %v of agreement %v is deleted.", cv.Name, agreementId)
+				}
+				continue
+			}
+
+			expiryTime := uint64(0)
+			if hours != 0 {
+				expiryTime = uint64(time.Now().Add(time.Duration(hours) * time.Hour).Unix())
+			}
+			if err := persistence.ReleaseContainerVolume(b.db, &cv, expiryTime); err != nil {
+				glog.Errorf(err.Error())
+			} else {
+				glog.V(3).Infof("Volume %v of agreement %v is kept, expiry time %v.", cv.Name, agreementId, expiryTime)
+			}
+		}
+	}
+}
+
+// This is the function for a subworker that deletes the kept volumes whose retention time has passed.
+func (b *ContainerWorker) deleteExpiredVolumes() int {
+	if b.db == nil || b.client == nil {
+		return 0
+	}
+
+	cvs, err := persistence.FindContainerVolumes(b.db, []persistence.ContainerVolumeFilter{persistence.UnarchivedCVFilter(), persistence.ExpiredCVFilter(uint64(time.Now().Unix()))})
+	if err != nil {
+		glog.Errorf("Error retrieving the expired volumes from the local db. %v", err)
+		return 0
+	}
+
+	for _, cv := range cvs {
+		if err := deleteContainerVolume(b.client, b.db, &cv); err != nil {
+			glog.Errorf("Failed to delete expired volume %v. %v", cv.Name, err)
+		} else {
+			glog.V(3).Infof("Volume %v kept from agreement %v has expired and is deleted.", cv.Name, cv.AgreementId)
+		}
+	}
+	return 0
+}
+
+// Delete the docker volume and archive its record. A volume that no longer exists in docker is archived.
+func deleteContainerVolume(client *docker.Client, db *bolt.DB, cv *persistence.ContainerVolume) error {
+	if err := client.RemoveVolume(cv.Name); err != nil && err != docker.ErrNoSuchVolume {
+		return err
+	}
+	return persistence.ArchiveContainerVolumes(db, cv)
+}
+
+// Return the docker volumes created by the agent for the service containers, with their disk usage.
+func GetServiceVolumes(db *bolt.DB, config *config.HorizonConfig) ([]ServiceVolume, error) {
+	cvs, err := persistence.FindAllUndeletedContainerVolumes(db)
+	if err != nil {
+		return nil, fmt.Errorf("Error retrieving undeleted container volumes from local db. %v", err)
+	}
+
+	var client *docker.Client
+	if len(cvs) != 0 {
+		if client, err = newDockerClient(config); err != nil {
+			return nil, err
+		}
+	}
+
+	volumes := make([]ServiceVolume, 0, len(cvs))
+	for _, cv := range cvs {
+		volumes = append(volumes, newServiceVolume(cv, volumeUsage(client, cv.Name)))
+	}
+	sort.Slice(volumes, func(i, j int) bool { return volumes[i].Name < volumes[j].Name })
+	return volumes, nil
+}
+
+func newServiceVolume(cv persistence.ContainerVolume, usageBytes int64) ServiceVolume {
+	// the volumes created before the drivers and retention policies were recorded use the local driver and are kept
+	vol := containermessage.Volume{Driver: cv.Driver, Retention: cv.Retention}
+	if vol.Retention == "" {
+		vol.Retention = containermessage.VOLUME_RETENTION_KEEP
+	}
+
+	sv := ServiceVolume{
+		Name:          cv.Name,
+		ServiceName:   cv.ServiceName,
+		AgreementId:   cv.AgreementId,
+		Driver:        vol.GetDriver(),
+		SizeMb:        cv.SizeMb,
+		QuotaEnforced: cv.QuotaEnforced,
+		UsageBytes:    usageBytes,
+		Retention:     vol.Retention,
+		InUse:         !cv.IsReleased(),
+		CreationTime:  cv.CreationTime,
+		ReleaseTime:   cv.ReleaseTime,
+		ExpiryTime:    cv.ExpiryTime,
+	}
+	sv.OverQuota = sv.SizeMb != 0 && sv.UsageBytes > sv.SizeMb*1024*1024
+	return sv
+}
+
+// Return the disk space used by the files of a local volume, or -1 if it cannot be measured. The directory of the volume
+// is not accessible when the agent runs in a container.
+func volumeUsage(client *docker.Client, name string) int64 {
+	v, err := client.InspectVolume(name)
+	if err != nil || v.Driver != containermessage.VOLUME_DRIVER_LOCAL || v.Mountpoint == "" {
+		return -1
+	}
+
+	size := int64(0)
+	if err := filepath.Walk(v.Mountpoint, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		} else if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	}); err != nil {
+		glog.V(5).Infof("Unable to get the disk usage of volume %v. %v", name, err)
+		return -1
+	}
+	return size
+}
+
+// Delete the volumes that are no longer used by an agreement, without waiting for their retention time. Only the volumes
+// with the input names are deleted, or all of them if there are no names. Returns the names of the deleted volumes.
+func PruneServiceVolumes(db *bolt.DB, config *config.HorizonConfig, names []string) ([]string, error) {
+	filters := []persistence.ContainerVolumeFilter{persistence.UnarchivedCVFilter(), persistence.ReleasedCVFilter()}
+	if len(names) != 0 {
+		filters = append(filters, persistence.NamesCVFilter(names))
+	}
+
+	cvs, err := persistence.FindContainerVolumes(db, filters)
+	if err != nil {
+		return nil, fmt.Errorf("Error retrieving the released container volumes from local db. %v", err)
+	}
+
+	pruned := make([]string, 0, len(cvs))
+	if len(cvs) == 0 {
+		return pruned, nil
+	}
+
+	client, err := newDockerClient(config)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, cv := range cvs {
+		if err := deleteContainerVolume(client, db, &cv); err != nil {
+			return pruned, fmt.Errorf("Failed to delete docker volume %v. %v", cv.Name, err)
+		}
+		glog.V(3).Infof("Volume %v kept from agreement %v is pruned.", cv.Name, cv.AgreementId)
+		pruned = append(pruned, cv.Name)
+	}
+	return pruned, nil
+}
+
+func newDockerClient(config *config.HorizonConfig) (*docker.Client, error) {
+	if config.Edge.DockerEndpoint == "" {
+		return nil, fmt.Errorf("Docker client cannot be initialized. Please make sure DockerEndpoint is set in the configuration file.")
+	} else if client, err := docker.NewClient(config.Edge.DockerEndpoint); err != nil {
+		return nil, fmt.Errorf("Failed to instantiate docker Client: %v", err)
+	} else {
+		return client, nil
+	}
+}
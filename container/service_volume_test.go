@@ -0,0 +1,39 @@
+//go:build unit
+// +build unit
+
+package container
+
+import (
+	"github.com/open-horizon/anax/containermessage"
+	"github.com/open-horizon/anax/persistence"
+	"testing"
+)
+
+func Test_newServiceVolume(t *testing.T) {
+
+	cv := newContainerVolume("vol1", "svc1", "ag1", containermessage.Volume{SizeMb: 1, Retention: "keep:24"})
+	if cv.Driver != containermessage.VOLUME_DRIVER_LOCAL || cv.QuotaEnforced || cv.AgreementId != "ag1" || cv.Retention != "keep:24" {
+		t.Errorf("wrong volume record %v", cv)
+	}
+
+	sv := newServiceVolume(*cv, 2*1024*1024)
+	if !sv.InUse || !sv.OverQuota || sv.UsageBytes != 2*1024*1024 || sv.Retention != "keep:24" {
+		t.Errorf("wrong service volume %v", sv)
+	}
+
+	// the volume is used by the next version of the service after the end of its agreement
+	cv.ReleaseTime = 100
+	cv.ExpiryTime = 200
+	if sv := newServiceVolume(*cv, -1); sv.InUse || sv.OverQuota || sv.ExpiryTime != 200 {
+		t.Errorf("wrong released service volume %v", sv)
+	}
+	setContainerVolumeUser(cv, "svc1", "ag2", containermessage.Volume{Retention: "delete"})
+	if cv.IsReleased() || cv.ExpiryTime != 0 || cv.AgreementId != "ag2" || cv.Retention != "delete" || cv.SizeMb != 1 {
+		t.Errorf("wrong reused volume record %v", cv)
+	}
+
+	// a volume created before the retention policies is kept
+	if sv := newServiceVolume(*persistence.NewContainerVolume("vol2"), -1); sv.Driver != containermessage.VOLUME_DRIVER_LOCAL || sv.Retention != containermessage.VOLUME_RETENTION_KEEP || sv.OverQuota {
+		t.Errorf("wrong service volume %v", sv)
+	}
+}
@@ -6,7 +6,9 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
+	"time"
 
 	docker "github.com/fsouza/go-dockerclient"
 )
@@ -31,6 +33,12 @@ import (
  *         "/tmp/testdata:/tmp/mydata:ro",
  *         "myvolume1:/tmp/mydata2"
  *       ],
+ *       "volumes": {
+ *         "myvolume1": {
+ *           "size_mb": 100,
+ *           "retention": "keep:24"
+ *         }
+ *       },
  *       "ports": [
  *         {
  *           "HostPort":"5200:6414/tcp",
@@ -172,10 +180,356 @@ type Service struct {
 	MaxCPUs          float32              `json:"max_cpus,omitempty"`
 	LogDriver        string               `json:"log_driver,omitempty"` // Docker's log-driver. Syslog will be used as default driver
 	Secrets          map[string]Secret    `json:"secrets"`
-	SecurityOpt      []string             `json:"security_opt,omitempty"` // Related to SELinux security for podman
-	PID              string               `json:"pid,omitempty"`          // The process id that the container should run in, see docker run --pid
-	User             string               `json:"user,omitempty"`         // The linux user ID (UID format) in which the container should run, see docker run -user
-	Sysctls          map[string]string    `json:"sysctls,omitempty"`      // The namespaced kernel parameters (sysctls) for this container, see docker run --sysctls
+	SecurityOpt      []string             `json:"security_opt,omitempty"`      // Related to SELinux security for podman
+	PID              string               `json:"pid,omitempty"`               // The process id that the container should run in, see docker run --pid
+	User             string               `json:"user,omitempty"`              // The linux user ID (UID format) in which the container should run, see docker run -user
+	Sysctls          map[string]string    `json:"sysctls,omitempty"`           // The namespaced kernel parameters (sysctls) for this container, see docker run --sysctls
+	HealthCheck      *HealthCheck         `json:"healthcheck,omitempty"`       // The command that checks the health of the container, see docker run --health-cmd
+	Restart          string               `json:"restart,omitempty"`           // The restart policy of the container, see docker run --restart. The default is always.
+	Ulimits          []Ulimit             `json:"ulimits,omitempty"`           // The resource limits of the processes in the container, see docker run --ulimit
+	PidsLimit        int64                `json:"pids_limit,omitempty"`        // The maximum number of processes in the container, -1 for no limit, see docker run --pids-limit
+	Blkio            *BlkioConfig         `json:"blkio,omitempty"`             // The block I/O weight and throttles of the container
+	ReadOnly         bool                 `json:"read_only,omitempty"`         // Mount the root filesystem of the container as read only, use tmpfs for writable paths, see docker run --read-only
+	NoNewPrivileges  bool                 `json:"no_new_privileges,omitempty"` // Prevent the processes in the container from gaining new privileges, see docker run --security-opt no-new-privileges
+	CapDrop          []string             `json:"cap_drop,omitempty"`          // The linux capabilities to drop from the container, see docker run --cap-drop
+	Volumes          map[string]*Volume   `json:"volumes,omitempty"`           // The named docker volumes mounted by the binds of the container, with their quota and retention policy
+}
+
+// Verify the fields of the service that have to be in a specific format.
+func (s *Service) Validate() error {
+	if s.HealthCheck != nil {
+		if err := s.HealthCheck.Validate(); err != nil {
+			return errors.New(fmt.Sprintf("healthcheck is not valid: %v", err))
+		}
+	}
+	if _, err := s.GetRestartPolicy(); err != nil {
+		return err
+	}
+	for _, u := range s.Ulimits {
+		if err := u.Validate(); err != nil {
+			return errors.New(fmt.Sprintf("ulimit %v is not valid: %v", u.Name, err))
+		}
+	}
+	if s.PidsLimit < -1 {
+		return errors.New(fmt.Sprintf("pids_limit %v is not valid, it must be -1 for no limit or a positive number", s.PidsLimit))
+	}
+	if s.Blkio != nil {
+		if err := s.Blkio.Validate(); err != nil {
+			return errors.New(fmt.Sprintf("blkio is not valid: %v", err))
+		}
+	}
+	for p, _ := range s.Tmpfs {
+		if !strings.HasPrefix(p, "/") {
+			return errors.New(fmt.Sprintf("tmpfs path %v is not valid, it must be an absolute path", p))
+		}
+	}
+	for _, c := range s.CapDrop {
+		if c == "" {
+			return errors.New("cap_drop must not contain an empty capability")
+		}
+	}
+	for name, v := range s.Volumes {
+		if !s.bindsVolume(name) {
+			return errors.New(fmt.Sprintf("volume %v is not valid, it must be the volume name of one of the binds", name))
+		} else if v == nil {
+			continue
+		} else if err := v.Validate(); err != nil {
+			return errors.New(fmt.Sprintf("volume %v is not valid: %v", name, err))
+		}
+	}
+	return nil
+}
+
+// Return true if the input name is the docker volume mounted by one of the binds of the service. The first field of
+// a bind is the volume name or, for a host directory, an absolute path.
+func (s *Service) bindsVolume(name string) bool {
+	if name == "" || strings.Contains(name, "/") {
+		return false
+	}
+	for _, bind := range s.Binds {
+		if strings.Split(bind, ":")[0] == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Return the declaration of the input volume, or an empty declaration if the service does not declare the volume.
+func (s *Service) GetVolume(name string) Volume {
+	if v, ok := s.Volumes[name]; ok && v != nil {
+		return *v
+	}
+	return Volume{}
+}
+
+// Return true if the service container can affect the host or the other containers beyond its own resources. A service
+// that raises the real-time or nice priority limits can starve the other processes of the host.
+func (s *Service) RequiresPrivilege() bool {
+	if s.Privileged || s.Network == "host" {
+		return true
+	}
+	for _, u := range s.Ulimits {
+		if (u.Name == "rtprio" && u.Hard != 0) || (u.Name == "nice" && u.Hard > 20) {
+			return true
+		}
+	}
+	return false
+}
+
+// Return the docker security options of the container.
+func (s *Service) GetSecurityOpt() []string {
+	opts := append([]string{}, s.SecurityOpt...)
+	if s.NoNewPrivileges {
+		opts = append(opts, "no-new-privileges")
+	}
+	return opts
+}
+
+// The names of the resource limits that can be set for a container, see ulimit -a.
+var validUlimitNames = map[string]bool{"core": true, "cpu": true, "data": true, "fsize": true, "locks": true, "memlock": true, "msgqueue": true,
+	"nice": true, "nofile": true, "nproc": true, "rss": true, "rtprio": true, "rttime": true, "sigpending": true, "stack": true}
+
+// A resource limit of the processes in a service container. A limit of -1 means unlimited.
+type Ulimit struct {
+	Name string `json:"name"`
+	Soft int64  `json:"soft"`
+	Hard int64  `json:"hard"`
+}
+
+func (u Ulimit) String() string {
+	return fmt.Sprintf("%v=%v:%v", u.Name, u.Soft, u.Hard)
+}
+
+func (u *Ulimit) Validate() error {
+	if !validUlimitNames[u.Name] {
+		return errors.New("unknown ulimit name")
+	} else if u.Soft < -1 || u.Hard < -1 {
+		return errors.New("soft and hard limits must be -1 for unlimited or a positive number")
+	} else if u.Hard != -1 && (u.Soft == -1 || u.Soft > u.Hard) {
+		return errors.New("the soft limit must not be greater than the hard limit")
+	}
+	return nil
+}
+
+// The block I/O settings of a service container, see the docker run --blkio-weight and --device-* flags.
+type BlkioConfig struct {
+	Weight          uint16              `json:"weight,omitempty"`            // The relative block I/O weight of the container, between 10 and 1000
+	WeightDevice    []BlkioWeightDevice `json:"weight_device,omitempty"`     // The relative block I/O weight of the container for a device
+	DeviceReadBps   []BlkioDeviceRate   `json:"device_read_bps,omitempty"`   // The maximum number of bytes per second read from a device
+	DeviceWriteBps  []BlkioDeviceRate   `json:"device_write_bps,omitempty"`  // The maximum number of bytes per second written to a device
+	DeviceReadIOps  []BlkioDeviceRate   `json:"device_read_iops,omitempty"`  // The maximum number of read operations per second on a device
+	DeviceWriteIOps []BlkioDeviceRate   `json:"device_write_iops,omitempty"` // The maximum number of write operations per second on a device
+}
+
+type BlkioWeightDevice struct {
+	Path   string `json:"path"`
+	Weight uint16 `json:"weight"`
+}
+
+type BlkioDeviceRate struct {
+	Path string `json:"path"`
+	Rate int64  `json:"rate"`
+}
+
+func (b *BlkioConfig) Validate() error {
+	if b.Weight != 0 && (b.Weight < 10 || b.Weight > 1000) {
+		return errors.New(fmt.Sprintf("weight %v must be between 10 and 1000", b.Weight))
+	}
+	for _, wd := range b.WeightDevice {
+		if !strings.HasPrefix(wd.Path, "/dev/") {
+			return errors.New(fmt.Sprintf("weight_device path %v must be a device under /dev", wd.Path))
+		} else if wd.Weight < 10 || wd.Weight > 1000 {
+			return errors.New(fmt.Sprintf("weight_device weight %v for %v must be between 10 and 1000", wd.Weight, wd.Path))
+		}
+	}
+	for _, rates := range [][]BlkioDeviceRate{b.DeviceReadBps, b.DeviceWriteBps, b.DeviceReadIOps, b.DeviceWriteIOps} {
+		for _, r := range rates {
+			if !strings.HasPrefix(r.Path, "/dev/") {
+				return errors.New(fmt.Sprintf("device path %v must be a device under /dev", r.Path))
+			} else if r.Rate <= 0 {
+				return errors.New(fmt.Sprintf("device rate %v for %v must be a positive number", r.Rate, r.Path))
+			}
+		}
+	}
+	return nil
+}
+
+// Copy the block I/O settings into the docker host configuration.
+func (b *BlkioConfig) SetDockerHostConfig(hc *docker.HostConfig) {
+	hc.BlkioWeight = int64(b.Weight)
+	for _, wd := range b.WeightDevice {
+		hc.BlkioWeightDevice = append(hc.BlkioWeightDevice, docker.BlockWeight{Path: wd.Path, Weight: strconv.Itoa(int(wd.Weight))})
+	}
+	toLimits := func(rates []BlkioDeviceRate) []docker.BlockLimit {
+		limits := make([]docker.BlockLimit, 0, len(rates))
+		for _, r := range rates {
+			limits = append(limits, docker.BlockLimit{Path: r.Path, Rate: r.Rate})
+		}
+		return limits
+	}
+	hc.BlkioDeviceReadBps = toLimits(b.DeviceReadBps)
+	hc.BlkioDeviceWriteBps = toLimits(b.DeviceWriteBps)
+	hc.BlkioDeviceReadIOps = toLimits(b.DeviceReadIOps)
+	hc.BlkioDeviceWriteIOps = toLimits(b.DeviceWriteIOps)
+}
+
+// The retention policies of a volume, what happens to the volume when the agreement or the service instance that uses it
+// ends. With keep, the volume is used again by the next version of the service, it can be kept for a number of hours,
+// e.g. "keep:24", or until the node is unregistered.
+const (
+	VOLUME_RETENTION_DELETE = "delete"
+	VOLUME_RETENTION_KEEP   = "keep"
+)
+
+// The default docker volume driver.
+const VOLUME_DRIVER_LOCAL = "local"
+
+// A docker volume mounted by a bind of a service container, see docker volume create. A volume that is not declared
+// uses the local driver and is kept until the node is unregistered.
+type Volume struct {
+	Driver     string            `json:"driver,omitempty"`      // The volume driver, the default is local
+	DriverOpts map[string]string `json:"driver_opts,omitempty"` // The options of the volume driver, see docker volume create --opt
+	SizeMb     int64             `json:"size_mb,omitempty"`     // The maximum size of the volume, enforced by the drivers that support it
+	Retention  string            `json:"retention,omitempty"`   // delete, keep or keep:<hours>, the default is keep
+}
+
+func (v Volume) String() string {
+	return fmt.Sprintf("Driver: %v, DriverOpts: %v, SizeMb: %v, Retention: %v", v.Driver, v.DriverOpts, v.SizeMb, v.Retention)
+}
+
+func (v *Volume) Validate() error {
+	if v.SizeMb < 0 {
+		return errors.New(fmt.Sprintf("size_mb %v must be a positive number", v.SizeMb))
+	} else if _, _, err := v.GetRetentionPolicy(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Return the retention policy of the volume and, for keep, the number of hours the volume is kept. Zero hours means
+// that the volume is kept until the node is unregistered.
+func (v *Volume) GetRetentionPolicy() (string, int, error) {
+	pieces := strings.SplitN(v.Retention, ":", 2)
+	switch pieces[0] {
+	case VOLUME_RETENTION_DELETE:
+		if len(pieces) == 1 {
+			return VOLUME_RETENTION_DELETE, 0, nil
+		}
+	case "", VOLUME_RETENTION_KEEP:
+		if len(pieces) == 1 {
+			return VOLUME_RETENTION_KEEP, 0, nil
+		} else if hours, err := strconv.Atoi(pieces[1]); err == nil && hours > 0 {
+			return VOLUME_RETENTION_KEEP, hours, nil
+		}
+	}
+	return "", 0, errors.New(fmt.Sprintf("retention %v is not valid, it must be delete, keep or keep:<hours>", v.Retention))
+}
+
+// Return the docker driver of the volume.
+func (v *Volume) GetDriver() string {
+	if v.Driver == "" {
+		return VOLUME_DRIVER_LOCAL
+	}
+	return v.Driver
+}
+
+// Return true if the driver of the volume enforces the size quota. Only the local driver is known to take the size
+// quota as an option, and only for a tmpfs volume. The other drivers name and format their size option differently,
+// their quota is not enforced and the size option has to be given in the driver options.
+func (v *Volume) QuotaEnforced() bool {
+	return v.SizeMb != 0 && v.GetDriver() == VOLUME_DRIVER_LOCAL && v.DriverOpts["type"] == "tmpfs"
+}
+
+// Return the driver options used to create the docker volume, including the size quota when the driver enforces it.
+func (v *Volume) GetDriverOpts() map[string]string {
+	opts := make(map[string]string, len(v.DriverOpts)+1)
+	for k, val := range v.DriverOpts {
+		opts[k] = val
+	}
+
+	if v.QuotaEnforced() {
+		size := fmt.Sprintf("size=%vm", v.SizeMb)
+		if opts["o"] == "" {
+			opts["o"] = size
+		} else {
+			opts["o"] = opts["o"] + "," + size
+		}
+	}
+	return opts
+}
+
+// Return the docker restart policy of the container. The restart field can be "always", "unless-stopped", "no" or
+// "on-failure" with an optional maximum retry count, e.g. "on-failure:5".
+func (s *Service) GetRestartPolicy() (docker.RestartPolicy, error) {
+	pieces := strings.SplitN(s.Restart, ":", 2)
+	switch pieces[0] {
+	case "", "always":
+		if len(pieces) == 1 {
+			return docker.AlwaysRestart(), nil
+		}
+	case "unless-stopped":
+		if len(pieces) == 1 {
+			return docker.RestartUnlessStopped(), nil
+		}
+	case "no":
+		if len(pieces) == 1 {
+			return docker.NeverRestart(), nil
+		}
+	case "on-failure":
+		if len(pieces) == 1 {
+			return docker.RestartOnFailure(0), nil
+		} else if maxRetry, err := strconv.Atoi(pieces[1]); err == nil && maxRetry >= 0 {
+			return docker.RestartOnFailure(maxRetry), nil
+		}
+	}
+	return docker.RestartPolicy{}, errors.New(fmt.Sprintf("restart policy %v is not valid, it must be always, unless-stopped, no or on-failure[:max-retries]", s.Restart))
+}
+
+// The command that docker runs in the container to check that it is still working, see the HEALTHCHECK instruction of a
+// Dockerfile. The durations are in seconds, zero means that the value from the image or the docker default is used.
+type HealthCheck struct {
+	Test        []string `json:"test,omitempty"`         // ["CMD", args...], ["CMD-SHELL", command] or ["NONE"] to disable the health check of the image
+	Interval    int      `json:"interval,omitempty"`     // The time between two checks
+	Timeout     int      `json:"timeout,omitempty"`      // The time after which a check is considered failed
+	Retries     int      `json:"retries,omitempty"`      // The number of consecutive failed checks after which the container is unhealthy
+	StartPeriod int      `json:"start_period,omitempty"` // The time the container has to start up, failed checks do not count during this time
+}
+
+func (h HealthCheck) String() string {
+	return fmt.Sprintf("Test: %v, Interval: %v, Timeout: %v, Retries: %v, StartPeriod: %v", h.Test, h.Interval, h.Timeout, h.Retries, h.StartPeriod)
+}
+
+func (h *HealthCheck) Validate() error {
+	if len(h.Test) != 0 {
+		switch h.Test[0] {
+		case "NONE":
+			if len(h.Test) != 1 {
+				return errors.New("test NONE does not take any arguments")
+			}
+		case "CMD", "CMD-SHELL":
+			if len(h.Test) < 2 {
+				return errors.New(fmt.Sprintf("test %v requires a command", h.Test[0]))
+			}
+		default:
+			return errors.New(fmt.Sprintf("test must start with CMD, CMD-SHELL or NONE, found %v", h.Test[0]))
+		}
+	}
+
+	if h.Interval < 0 || h.Timeout < 0 || h.Retries < 0 || h.StartPeriod < 0 {
+		return errors.New("interval, timeout, retries and start_period must not be negative")
+	}
+	return nil
+}
+
+// Convert the health check to the docker container configuration.
+func (h *HealthCheck) GetDockerHealthConfig() *docker.HealthConfig {
+	return &docker.HealthConfig{
+		Test:        h.Test,
+		Interval:    time.Duration(h.Interval) * time.Second,
+		Timeout:     time.Duration(h.Timeout) * time.Second,
+		StartPeriod: time.Duration(h.StartPeriod) * time.Second,
+		Retries:     h.Retries,
+	}
 }
 
 func (s *Service) AddFilesystemBinding(bind string) {
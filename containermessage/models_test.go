@@ -179,3 +179,209 @@ func Test_AddSpecificPortBinding(t *testing.T) {
 		t.Errorf("Service should have 2 specific port bindings but not.")
 	}
 }
+
+func Test_GetRestartPolicy(t *testing.T) {
+	valid := map[string]docker.RestartPolicy{
+		"":               docker.AlwaysRestart(),
+		"always":         docker.AlwaysRestart(),
+		"unless-stopped": docker.RestartUnlessStopped(),
+		"no":             docker.NeverRestart(),
+		"on-failure":     docker.RestartOnFailure(0),
+		"on-failure:5":   docker.RestartOnFailure(5),
+	}
+	for restart, expected := range valid {
+		serv := Service{Image: "abc", Restart: restart}
+		if policy, err := serv.GetRestartPolicy(); err != nil {
+			t.Errorf("GetRestartPolicy for restart %v should not have returned an error: %v", restart, err)
+		} else if policy != expected {
+			t.Errorf("GetRestartPolicy for restart %v should return %v but got %v.", restart, expected, policy)
+		}
+	}
+
+	for _, restart := range []string{"sometimes", "always:3", "on-failure:x", "on-failure:-1"} {
+		serv := Service{Image: "abc", Restart: restart}
+		if _, err := serv.GetRestartPolicy(); err == nil {
+			t.Errorf("GetRestartPolicy for restart %v should have returned an error.", restart)
+		}
+	}
+}
+
+func Test_HealthCheck_Validate(t *testing.T) {
+	valid := []HealthCheck{
+		{Test: []string{"CMD", "curl", "-f", "http://localhost:8080"}, Interval: 30, Timeout: 5, Retries: 3, StartPeriod: 60},
+		{Test: []string{"CMD-SHELL", "curl -f http://localhost:8080 || exit 1"}},
+		{Test: []string{"NONE"}},
+		{Interval: 10},
+	}
+	for _, hc := range valid {
+		if err := hc.Validate(); err != nil {
+			t.Errorf("Health check %v should be valid but got error: %v", hc, err)
+		}
+	}
+
+	invalid := []HealthCheck{
+		{Test: []string{"CMD"}},
+		{Test: []string{"NONE", "x"}},
+		{Test: []string{"curl", "-f", "http://localhost:8080"}},
+		{Test: []string{"CMD", "true"}, Retries: -1},
+	}
+	for _, hc := range invalid {
+		if err := hc.Validate(); err == nil {
+			t.Errorf("Health check %v should not be valid.", hc)
+		}
+	}
+
+	hc := HealthCheck{Test: []string{"CMD", "true"}, Interval: 30, Timeout: 5, Retries: 3, StartPeriod: 60}
+	dhc := hc.GetDockerHealthConfig()
+	if dhc.Interval.Seconds() != 30 || dhc.Timeout.Seconds() != 5 || dhc.StartPeriod.Seconds() != 60 || dhc.Retries != 3 || len(dhc.Test) != 2 {
+		t.Errorf("GetDockerHealthConfig for %v returned %v.", hc, dhc)
+	}
+}
+
+func Test_Service_Validate_resources(t *testing.T) {
+	serv := Service{
+		Image:           "abc",
+		Ulimits:         []Ulimit{{Name: "nofile", Soft: 1024, Hard: 2048}, {Name: "core", Soft: -1, Hard: -1}},
+		PidsLimit:       100,
+		Blkio:           &BlkioConfig{Weight: 300, DeviceWriteBps: []BlkioDeviceRate{{Path: "/dev/sda", Rate: 1048576}}},
+		ReadOnly:        true,
+		Tmpfs:           map[string]string{"/tmp": "rw,size=64m"},
+		NoNewPrivileges: true,
+		CapDrop:         []string{"NET_RAW"},
+	}
+	if err := serv.Validate(); err != nil {
+		t.Errorf("Service %v should be valid but got error: %v", serv, err)
+	}
+
+	invalid := []func(s *Service){
+		func(s *Service) { s.Ulimits = []Ulimit{{Name: "files", Soft: 1, Hard: 1}} },
+		func(s *Service) { s.Ulimits = []Ulimit{{Name: "nofile", Soft: 2048, Hard: 1024}} },
+		func(s *Service) { s.Ulimits = []Ulimit{{Name: "nofile", Soft: -1, Hard: 1024}} },
+		func(s *Service) { s.PidsLimit = -2 },
+		func(s *Service) { s.Blkio = &BlkioConfig{Weight: 5} },
+		func(s *Service) { s.Blkio = &BlkioConfig{DeviceReadIOps: []BlkioDeviceRate{{Path: "sda", Rate: 100}}} },
+		func(s *Service) {
+			s.Blkio = &BlkioConfig{DeviceReadBps: []BlkioDeviceRate{{Path: "/dev/sda", Rate: 0}}}
+		},
+		func(s *Service) { s.Tmpfs = map[string]string{"tmp": ""} },
+		func(s *Service) { s.CapDrop = []string{""} },
+	}
+	for ix, change := range invalid {
+		s := Service{Image: "abc"}
+		change(&s)
+		if err := s.Validate(); err == nil {
+			t.Errorf("Service %v should not be valid for test %v.", s, ix)
+		}
+	}
+
+	if opts := serv.GetSecurityOpt(); len(opts) != 1 || opts[0] != "no-new-privileges" {
+		t.Errorf("GetSecurityOpt for service should return no-new-privileges but got %v.", opts)
+	}
+}
+
+func Test_Service_RequiresPrivilege(t *testing.T) {
+	for _, serv := range []Service{
+		{Image: "abc", Privileged: true},
+		{Image: "abc", Network: "host"},
+		{Image: "abc", Ulimits: []Ulimit{{Name: "rtprio", Soft: 10, Hard: 10}}},
+		{Image: "abc", Ulimits: []Ulimit{{Name: "nice", Soft: 30, Hard: 30}}},
+	} {
+		if !serv.RequiresPrivilege() {
+			t.Errorf("Service %v should require privilege.", serv)
+		}
+	}
+
+	for _, serv := range []Service{
+		{Image: "abc"},
+		{Image: "abc", Ulimits: []Ulimit{{Name: "nofile", Soft: 65536, Hard: 65536}}, PidsLimit: 100, ReadOnly: true, CapDrop: []string{"ALL"}},
+		{Image: "abc", Ulimits: []Ulimit{{Name: "nice", Soft: 10, Hard: 10}}},
+	} {
+		if serv.RequiresPrivilege() {
+			t.Errorf("Service %v should not require privilege.", serv)
+		}
+	}
+}
+
+func Test_Volume_GetRetentionPolicy(t *testing.T) {
+	for retention, expected := range map[string]struct {
+		policy string
+		hours  int
+	}{
+		"":        {VOLUME_RETENTION_KEEP, 0},
+		"keep":    {VOLUME_RETENTION_KEEP, 0},
+		"keep:24": {VOLUME_RETENTION_KEEP, 24},
+		"delete":  {VOLUME_RETENTION_DELETE, 0},
+	} {
+		v := Volume{Retention: retention}
+		if policy, hours, err := v.GetRetentionPolicy(); err != nil {
+			t.Errorf("Retention %v should be valid but got error: %v", retention, err)
+		} else if policy != expected.policy || hours != expected.hours {
+			t.Errorf("Retention %v should be %v for %v hours but got %v for %v hours.", retention, expected.policy, expected.hours, policy, hours)
+		}
+	}
+
+	for _, retention := range []string{"forever", "keep:", "keep:0", "keep:-1", "keep:abc", "delete:1"} {
+		v := Volume{Retention: retention}
+		if _, _, err := v.GetRetentionPolicy(); err == nil {
+			t.Errorf("Retention %v should not be valid.", retention)
+		}
+	}
+}
+
+func Test_Volume_GetDriverOpts(t *testing.T) {
+	v := Volume{SizeMb: 100}
+	if v.QuotaEnforced() {
+		t.Errorf("The quota of local volume %v should not be enforced.", v)
+	} else if opts := v.GetDriverOpts(); len(opts) != 0 {
+		t.Errorf("Local volume %v should not have driver options but got %v.", v, opts)
+	}
+
+	v = Volume{SizeMb: 100, DriverOpts: map[string]string{"type": "tmpfs", "device": "tmpfs", "o": "uid=1000"}}
+	if !v.QuotaEnforced() {
+		t.Errorf("The quota of tmpfs volume %v should be enforced.", v)
+	} else if opts := v.GetDriverOpts(); opts["o"] != "uid=1000,size=100m" || opts["type"] != "tmpfs" {
+		t.Errorf("Tmpfs volume %v has the wrong driver options %v.", v, opts)
+	} else if v.DriverOpts["o"] != "uid=1000" {
+		t.Errorf("GetDriverOpts should not change the volume %v.", v)
+	}
+
+	// the size quota is not given to a driver that may not accept it
+	v = Volume{Driver: "other", SizeMb: 50, DriverOpts: map[string]string{"type": "tmpfs"}}
+	if v.QuotaEnforced() {
+		t.Errorf("The quota of volume %v should not be enforced.", v)
+	} else if opts := v.GetDriverOpts(); len(opts) != 1 || opts["type"] != "tmpfs" {
+		t.Errorf("Volume %v has the wrong driver options %v.", v, opts)
+	}
+}
+
+func Test_Service_Validate_volumes(t *testing.T) {
+	serv := Service{
+		Image: "abc",
+		Binds: []string{"/tmp/testdata:/tmp/mydata:ro", "myvolume1:/tmp/mydata2", "myvolume2:/tmp/mydata3"},
+		Volumes: map[string]*Volume{
+			"myvolume1": {SizeMb: 100, Retention: "keep:24"},
+			"myvolume2": {Retention: "delete"},
+		},
+	}
+	if err := serv.Validate(); err != nil {
+		t.Errorf("Service %v should be valid but got error: %v", serv, err)
+	} else if v := serv.GetVolume("myvolume1"); v.SizeMb != 100 {
+		t.Errorf("GetVolume for myvolume1 returned the wrong volume %v.", v)
+	} else if v := serv.GetVolume("myvolume3"); v.GetDriver() != VOLUME_DRIVER_LOCAL || v.Retention != "" {
+		t.Errorf("GetVolume for an undeclared volume should return an empty volume but got %v.", v)
+	}
+
+	invalid := []func(s *Service){
+		func(s *Service) { s.Volumes = map[string]*Volume{"myvolume3": {}} },
+		func(s *Service) { s.Volumes = map[string]*Volume{"/tmp/testdata": {}} },
+		func(s *Service) { s.Volumes = map[string]*Volume{"myvolume1": {SizeMb: -1}} },
+		func(s *Service) { s.Volumes = map[string]*Volume{"myvolume1": {Retention: "keep:0"}} },
+	}
+	for ix, change := range invalid {
+		s := Service{Image: "abc", Binds: serv.Binds}
+		change(&s)
+		if err := s.Validate(); err == nil {
+			t.Errorf("Service %v should not be valid for test %v.", s, ix)
+		}
+	}
+}
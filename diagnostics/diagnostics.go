@@ -0,0 +1,243 @@
+package diagnostics
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"github.com/boltdb/bolt"
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/apicommon"
+	"github.com/open-horizon/anax/config"
+	"github.com/open-horizon/anax/container"
+	"github.com/open-horizon/anax/cutil"
+	"github.com/open-horizon/anax/exchange"
+	"github.com/open-horizon/anax/persistence"
+	"github.com/open-horizon/anax/policy"
+	"github.com/open-horizon/anax/resource"
+	"github.com/open-horizon/anax/version"
+	"github.com/open-horizon/anax/worker"
+	"io"
+	"strings"
+	"time"
+)
+
+// The MMS object types used to get a diagnostics bundle from a node without logging in to it. A request is an object
+// of type agent_diagnostics_request sent to the node, the node uploads the bundle to the CSS as an object of type
+// agent_diagnostics in the org of the node, with the id <node id>_<request id>.
+const DIAGNOSTICS_REQUEST_OBJECT_TYPE = "agent_diagnostics_request"
+const DIAGNOSTICS_BUNDLE_OBJECT_TYPE = "agent_diagnostics"
+
+// The maximum number of event log records in a bundle, the most recent ones are kept.
+const DIAGNOSTICS_MAX_EVENT_LOGS = 1000
+
+// Replaces the secrets in the collected records.
+const REDACTED = "<...>"
+
+// Return the id of the bundle object uploaded for a request.
+func BundleObjectId(nodeId string, requestId string) string {
+	return fmt.Sprintf("%v_%v", exchange.GetId(nodeId), requestId)
+}
+
+// Write a gzipped tarball with the state of the agent: the node, the status, the workers, the event log, the surfaced
+// errors, the agreements, the service instances, the recent service container output, the agent and embedded ESS
+// configs, and the docker or kubernetes version. Secrets are removed. A part that cannot be collected is listed
+// in errors.txt and does not prevent collecting the other parts.
+func CreateBundle(w io.Writer, db *bolt.DB, cfg *config.HorizonConfig, ec exchange.ExchangeContext) error {
+
+	gz := gzip.NewWriter(w)
+	b := newBundleWriter(gz, time.Now())
+
+	if err := b.collect(db, cfg, ec); err != nil {
+		return err
+	} else if err := b.close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+type bundleWriter struct {
+	tw     *tar.Writer
+	now    time.Time
+	errors []string
+}
+
+func newBundleWriter(w io.Writer, now time.Time) *bundleWriter {
+	return &bundleWriter{tw: tar.NewWriter(w), now: now, errors: make([]string, 0)}
+}
+
+func (b *bundleWriter) addFile(name string, content []byte) error {
+	header := &tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), ModTime: b.now}
+	if err := b.tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("unable to write %v to the diagnostics bundle, error %v", name, err)
+	} else if _, err := b.tw.Write(content); err != nil {
+		return fmt.Errorf("unable to write %v to the diagnostics bundle, error %v", name, err)
+	}
+	return nil
+}
+
+// Add the json form of an object to the bundle, or record the error that prevented collecting it.
+func (b *bundleWriter) addJSON(name string, obj interface{}, collectErr error) error {
+	if collectErr != nil {
+		b.addError(name, collectErr)
+		return nil
+	}
+
+	content, err := json.MarshalIndent(obj, "", "  ")
+	if err != nil {
+		b.addError(name, err)
+		return nil
+	}
+	return b.addFile(name, content)
+}
+
+func (b *bundleWriter) addError(name string, err error) {
+	glog.Warningf(diagLogString(fmt.Sprintf("unable to collect %v, error %v", name, err)))
+	b.errors = append(b.errors, fmt.Sprintf("%v: %v", name, err))
+}
+
+// Add the errors.txt file if some parts could not be collected, and end the tarball.
+func (b *bundleWriter) close() error {
+	if len(b.errors) != 0 {
+		if err := b.addFile("errors.txt", []byte(strings.Join(b.errors, "\n")+"\n")); err != nil {
+			return err
+		}
+	}
+	return b.tw.Close()
+}
+
+func (b *bundleWriter) collect(db *bolt.DB, cfg *config.HorizonConfig, ec exchange.ExchangeContext) error {
+
+	dev, err := persistence.FindExchangeDevice(db)
+	if err := b.addJSON("node.json", redactedDevice(dev), err); err != nil {
+		return err
+	}
+
+	certVersion, configVersion := "", ""
+	if dev != nil && dev.SoftwareVersions != nil {
+		certVersion = dev.SoftwareVersions[persistence.CERT_VERSION]
+		configVersion = dev.SoftwareVersions[persistence.CONFIG_VERSION]
+	}
+	info := apicommon.NewInfo(ec.GetHTTPFactory(), ec.GetExchangeURL(), ec.GetCSSURL(), ec.GetExchangeId(), ec.GetExchangeToken(), certVersion, configVersion)
+	if err := b.addJSON("status.json", info, nil); err != nil {
+		return err
+	} else if err := b.addJSON("workers.json", worker.GetWorkerStatusManager(), nil); err != nil {
+		return err
+	}
+
+	evlogs, err := persistence.FindEventLogsWithSelectorsPage(db, false, map[string][]persistence.Selector{}, persistence.EventLogPage{Limit: DIAGNOSTICS_MAX_EVENT_LOGS, Descending: true}, nil)
+	if err := b.addJSON("eventlog.json", evlogs, err); err != nil {
+		return err
+	}
+
+	surfaceErrors, err := persistence.FindSurfaceErrors(db)
+	if err := b.addJSON("surface_errors.json", surfaceErrors, err); err != nil {
+		return err
+	}
+
+	agreements, err := persistence.FindEstablishedAgreementsAllProtocols(db, policy.AllAgreementProtocols(), []persistence.EAFilter{})
+	if err := b.addJSON("agreements.json", redactedAgreements(agreements), err); err != nil {
+		return err
+	}
+
+	msinsts, err := persistence.FindMicroserviceInstances(db, []persistence.MIFilter{})
+	if err := b.addJSON("services.json", redactedServiceInstances(msinsts), err); err != nil {
+		return err
+	}
+
+	for _, instance := range container.ServiceLogInstances() {
+		if lines, found := container.GetServiceLogs(instance, "", time.Time{}, 0); found {
+			var sb strings.Builder
+			for _, line := range lines {
+				sb.WriteString(line.String())
+				sb.WriteString("\n")
+			}
+			if err := b.addFile(fmt.Sprintf("logs/%v.log", instance), []byte(sb.String())); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := b.addJSON("config.json", cfg.Edge, nil); err != nil {
+		return err
+	} else if resource.IsFileSyncServiceStarted() {
+		if err := b.addJSON("ess_config.json", resource.CensoredESSConfig(), nil); err != nil {
+			return err
+		}
+	}
+
+	return b.addJSON("versions.json", collectVersions(cfg, dev), nil)
+}
+
+// The node without its exchange token.
+func redactedDevice(dev *persistence.ExchangeDevice) *persistence.ExchangeDevice {
+	if dev == nil {
+		return nil
+	}
+	redacted := *dev
+	if redacted.Token != "" {
+		redacted.Token = REDACTED
+	}
+	return &redacted
+}
+
+// The agreements without the proposal and the deployment, they can contain the credentials of the image registries
+// and the user input values of the services.
+func redactedAgreements(agreements []persistence.EstablishedAgreement) []persistence.EstablishedAgreement {
+	redacted := make([]persistence.EstablishedAgreement, 0, len(agreements))
+	for _, ag := range agreements {
+		if ag.Proposal != "" {
+			ag.Proposal = REDACTED
+		}
+		ag.CurrentDeployment = nil
+		ag.ExtendedDeployment = nil
+		redacted = append(redacted, ag)
+	}
+	return redacted
+}
+
+// The service instances without the values of their environment variables, they can contain user input secrets.
+func redactedServiceInstances(msinsts []persistence.MicroserviceInstance) []persistence.MicroserviceInstance {
+	redacted := make([]persistence.MicroserviceInstance, 0, len(msinsts))
+	for _, msinst := range msinsts {
+		if msinst.EnvVars != nil {
+			envVars := make(map[string]string, len(msinst.EnvVars))
+			for name := range msinst.EnvVars {
+				envVars[name] = REDACTED
+			}
+			msinst.EnvVars = envVars
+		}
+		redacted = append(redacted, msinst)
+	}
+	return redacted
+}
+
+// Return the version of the agent and of the container runtime of the node, docker for a device or kubernetes for a
+// cluster.
+func collectVersions(cfg *config.HorizonConfig, dev *persistence.ExchangeDevice) map[string]interface{} {
+	versions := map[string]interface{}{"anax": version.HORIZON_VERSION}
+
+	if dev != nil && dev.IsEdgeCluster() {
+		if client, err := cutil.NewKubeClient(); err != nil {
+			versions["kubernetes"] = fmt.Sprintf("unable to get the kubernetes client, error %v", err)
+		} else if serverVersion, err := client.Discovery().ServerVersion(); err != nil {
+			versions["kubernetes"] = fmt.Sprintf("unable to get the kubernetes version, error %v", err)
+		} else {
+			versions["kubernetes"] = serverVersion
+		}
+	} else {
+		if client, err := docker.NewClient(cfg.Edge.DockerEndpoint); err != nil {
+			versions["docker"] = fmt.Sprintf("unable to get the docker client, error %v", err)
+		} else if env, err := client.Version(); err != nil {
+			versions["docker"] = fmt.Sprintf("unable to get the docker version, error %v", err)
+		} else {
+			versions["docker"] = env.Map()
+		}
+	}
+	return versions
+}
+
+var diagLogString = func(v interface{}) string {
+	return fmt.Sprintf("Diagnostics: %v", v)
+}
@@ -0,0 +1,72 @@
+//go:build unit
+// +build unit
+
+package diagnostics
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"github.com/open-horizon/anax/persistence"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_bundleWriter(t *testing.T) {
+
+	var buf bytes.Buffer
+	b := newBundleWriter(&buf, time.Now())
+
+	if err := b.addJSON("ok.json", map[string]string{"a": "b"}, nil); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	} else if err := b.addJSON("failed.json", nil, errors.New("db is closed")); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	} else if err := b.addJSON("unsupported.json", func() {}, nil); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	} else if err := b.close(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	files := make(map[string]string)
+	tr := tar.NewReader(&buf)
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			break
+		}
+		content, _ := ioutil.ReadAll(tr)
+		files[header.Name] = string(content)
+	}
+
+	if len(files) != 2 {
+		t.Errorf("expected ok.json and errors.txt in the bundle, got %v", files)
+	} else if !strings.Contains(files["ok.json"], `"a": "b"`) {
+		t.Errorf("unexpected ok.json %v", files["ok.json"])
+	} else if errs := files["errors.txt"]; !strings.Contains(errs, "failed.json: db is closed") || !strings.Contains(errs, "unsupported.json") {
+		t.Errorf("unexpected errors.txt %v", errs)
+	}
+}
+
+func Test_redacted(t *testing.T) {
+
+	dev := &persistence.ExchangeDevice{Id: "node1", Org: "myorg", Token: "secret"}
+	if r := redactedDevice(dev); r.Token != REDACTED || r.Id != "node1" {
+		t.Errorf("expected the token to be redacted, got %v", r)
+	} else if dev.Token != "secret" {
+		t.Errorf("expected the input node not to be changed")
+	} else if redactedDevice(nil) != nil {
+		t.Errorf("expected nil for a nil node")
+	}
+
+	ags := redactedAgreements([]persistence.EstablishedAgreement{{CurrentAgreementId: "ag1", Proposal: "{...}", CurrentDeployment: map[string]persistence.ServiceConfig{"c1": {}}}})
+	if len(ags) != 1 || ags[0].CurrentAgreementId != "ag1" || ags[0].Proposal != REDACTED || ags[0].CurrentDeployment != nil {
+		t.Errorf("expected the proposal and the deployment to be redacted, got %v", ags)
+	}
+
+	msinsts := redactedServiceInstances([]persistence.MicroserviceInstance{{InstanceId: "i1", EnvVars: map[string]string{"PASSWORD": "secret"}}})
+	if len(msinsts) != 1 || msinsts[0].EnvVars["PASSWORD"] != REDACTED {
+		t.Errorf("expected the environment variables to be redacted, got %v", msinsts)
+	}
+}
@@ -1,9 +1,13 @@
 package eventlog
 
 import (
+	"fmt"
 	"github.com/boltdb/bolt"
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/config"
 	"github.com/open-horizon/anax/persistence"
 	"golang.org/x/text/message"
+	"time"
 )
 
 // Save the eventlog into the db
@@ -54,6 +58,13 @@ func LogNodeEvent(db *bolt.DB, severity string, message_meta *persistence.Messag
 	return persistence.SaveEventLog(db, eventlog)
 }
 
+// Save the node management eventlog into the db. It is a node eventlog that records the node management policy.
+func LogNodeManagementEvent(db *bolt.DB, severity string, message_meta *persistence.MessageMeta, event_code, node_id, org, pattern, config_state, nmp_name string) error {
+	source := persistence.NewNodeManagementEventSource(node_id, org, pattern, config_state, nmp_name)
+	eventlog := persistence.NewEventLog(severity, message_meta, event_code, persistence.SRC_TYPE_NODE, source)
+	return persistence.SaveEventLog(db, eventlog)
+}
+
 // Save the database eventlog into the db
 func LogDatabaseEvent(db *bolt.DB, severity string, message_meta *persistence.MessageMeta, event_code string) error {
 	source := persistence.NewDatabaseEventSource()
@@ -96,6 +107,37 @@ func GetEventLogs(db *bolt.DB, all_logs bool, selectors map[string][]persistence
 	return persistence.FindEventLogsWithSelectors(db, all_logs, selectors, msgPrinter)
 }
 
+// Get a page of event logs from the db, in record id order. See GetEventLogs for the selectors.
+func GetEventLogsPage(db *bolt.DB, all_logs bool, selectors map[string][]persistence.Selector, page persistence.EventLogPage, msgPrinter *message.Printer) ([]persistence.EventLog, error) {
+	return persistence.FindEventLogsWithSelectorsPage(db, all_logs, selectors, page, msgPrinter)
+}
+
+// Remove the event logs that are older than maxAgeS seconds and then the oldest event logs beyond maxRecords. A value
+// of 0 disables each of these checks. Returns the number of event logs removed.
+func CompactEventLogs(db *bolt.DB, maxAgeS uint64, maxRecords uint64) (int, error) {
+	maxAgeTime := uint64(0)
+	if now := uint64(time.Now().Unix()); maxAgeS != 0 && now > maxAgeS {
+		maxAgeTime = now - maxAgeS
+	}
+	return persistence.CompactEventLogs(db, maxAgeTime, maxRecords)
+}
+
+// Start the event log compactor, which periodically removes old event logs according to the retention settings in
+// the config. The compactor runs until the anax process terminates.
+func StartCompactor(db *bolt.DB, cfg *config.Config) {
+	interval := time.Duration(cfg.EventLogCompactIntervalS) * time.Second
+	go func() {
+		for {
+			if removed, err := CompactEventLogs(db, cfg.EventLogMaxAgeS, cfg.EventLogMaxRecords); err != nil {
+				glog.Errorf(fmt.Sprintf("Event log compactor: unable to remove old event logs, error: %v", err))
+			} else if removed != 0 {
+				glog.V(3).Infof(fmt.Sprintf("Event log compactor: removed %v event logs", removed))
+			}
+			time.Sleep(interval)
+		}
+	}()
+}
+
 type EventLogByTimestamp []persistence.EventLog
 
 func (s EventLogByTimestamp) Len() int {
@@ -0,0 +1,229 @@
+package eventlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/boltdb/bolt"
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/config"
+	"github.com/open-horizon/anax/persistence"
+	"log/syslog"
+	"os"
+	"sort"
+	"time"
+)
+
+// How long the forwarder waits before trying again when event logs could not be forwarded.
+const FORWARDER_RETRY_INTERVAL = 60 * time.Second
+
+// The forwarder copies event log records, as JSON lines, to syslog or to a file as they are saved in the db. The record
+// id of the last forwarded record is kept in the db so that records saved while the forwarder could not write them,
+// or while anax was down, are forwarded later. Errors are only written to the anax log, never to the event log, so
+// that a broken destination does not generate an endless stream of events.
+type Forwarder struct {
+	db     *bolt.DB
+	writer eventWriter
+}
+
+// A destination for forwarded event log records.
+type eventWriter interface {
+	Write(el *persistence.EventLog, line []byte) error
+	Close() error
+}
+
+func NewForwarder(db *bolt.DB, cfg *config.EventLogForwarderConfig) (*Forwarder, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	var writer eventWriter
+	var err error
+	switch cfg.Type {
+	case config.EVENTLOG_FORWARD_SYSLOG:
+		writer, err = newSyslogWriter(cfg)
+	case config.EVENTLOG_FORWARD_FILE:
+		writer, err = newRotatingFileWriter(cfg)
+	default:
+		return nil, fmt.Errorf("event log forwarder type must be %v or %v", config.EVENTLOG_FORWARD_SYSLOG, config.EVENTLOG_FORWARD_FILE)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Forwarder{db: db, writer: writer}, nil
+}
+
+// Start forwarding event logs. The forwarder runs until the anax process terminates.
+func (f *Forwarder) Start() {
+	go func() {
+		for {
+			// Get the notification channel before reading the db so that a record saved while the db is being
+			// read is not missed.
+			saved := persistence.EventLogSaved()
+			if err := f.forward(); err != nil {
+				glog.Errorf(forwarderLogString(fmt.Sprintf("unable to forward event logs, retrying in %v, error: %v", FORWARDER_RETRY_INTERVAL, err)))
+				time.Sleep(FORWARDER_RETRY_INTERVAL)
+				continue
+			}
+			<-saved
+		}
+	}()
+}
+
+// Forward all the event logs that were saved after the last forwarded record.
+func (f *Forwarder) forward() error {
+
+	last, _, err := persistence.GetLastForwardedEventLog(f.db)
+	if err != nil {
+		return err
+	}
+
+	selectors := map[string][]persistence.Selector{"record_id": {{Op: ">", MatchValue: float64(last)}}}
+	logs, err := persistence.FindEventLogsWithSelectors(f.db, true, selectors, nil)
+	if err != nil {
+		return err
+	} else if len(logs) == 0 {
+		return nil
+	}
+
+	sort.Slice(logs, func(i, j int) bool {
+		return persistence.EventLogRecordId(logs[i]) < persistence.EventLogRecordId(logs[j])
+	})
+
+	forwarded := last
+	var writeErr error
+	for ix := range logs {
+		line, err := json.Marshal(logs[ix])
+		if err != nil {
+			// This record can never be forwarded, skip it rather than blocking all the records after it.
+			glog.Errorf(forwarderLogString(fmt.Sprintf("unable to serialize event log %v, error: %v", logs[ix].Id, err)))
+		} else if writeErr = f.writer.Write(&logs[ix], line); writeErr != nil {
+			break
+		}
+		forwarded = persistence.EventLogRecordId(logs[ix])
+	}
+
+	if forwarded != last {
+		if err := persistence.SaveLastForwardedEventLog(f.db, forwarded); err != nil {
+			return err
+		}
+	}
+
+	glog.V(5).Infof(forwarderLogString(fmt.Sprintf("forwarded event logs up to record %v", forwarded)))
+	return writeErr
+}
+
+// Forward event logs to syslog, using the event log severity as the syslog severity.
+type syslogWriter struct {
+	w *syslog.Writer
+}
+
+func newSyslogWriter(cfg *config.EventLogForwarderConfig) (*syslogWriter, error) {
+	w, err := syslog.Dial(cfg.SyslogNetwork, cfg.SyslogAddress, syslog.LOG_INFO|syslog.LOG_DAEMON, cfg.GetSyslogTag())
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to syslog, error: %v", err)
+	}
+	return &syslogWriter{w: w}, nil
+}
+
+func (s *syslogWriter) Write(el *persistence.EventLog, line []byte) error {
+	switch el.Severity {
+	case persistence.SEVERITY_FATAL:
+		return s.w.Crit(string(line))
+	case persistence.SEVERITY_ERROR:
+		return s.w.Err(string(line))
+	case persistence.SEVERITY_WARN:
+		return s.w.Warning(string(line))
+	default:
+		return s.w.Info(string(line))
+	}
+}
+
+func (s *syslogWriter) Close() error {
+	return s.w.Close()
+}
+
+// Append event logs to a file. When the file reaches its maximum size it is renamed to <file>.1, the previous <file>.1
+// is renamed to <file>.2 and so on. The oldest file is removed.
+type rotatingFileWriter struct {
+	path     string
+	maxSize  int64
+	maxFiles int
+	file     *os.File
+	size     int64
+}
+
+func newRotatingFileWriter(cfg *config.EventLogForwarderConfig) (*rotatingFileWriter, error) {
+	r := &rotatingFileWriter{
+		path:     cfg.FilePath,
+		maxSize:  cfg.GetMaxFileSize(),
+		maxFiles: cfg.GetMaxFiles(),
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotatingFileWriter) open() error {
+	file, err := os.OpenFile(r.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("unable to open event log file %v, error: %v", r.path, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("unable to stat event log file %v, error: %v", r.path, err)
+	}
+	r.file = file
+	r.size = info.Size()
+	return nil
+}
+
+func (r *rotatingFileWriter) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+	r.file = nil
+
+	os.Remove(fmt.Sprintf("%v.%v", r.path, r.maxFiles))
+	for i := r.maxFiles - 1; i >= 1; i-- {
+		if err := os.Rename(fmt.Sprintf("%v.%v", r.path, i), fmt.Sprintf("%v.%v", r.path, i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := os.Rename(r.path, fmt.Sprintf("%v.1", r.path)); err != nil {
+		return err
+	}
+	return r.open()
+}
+
+func (r *rotatingFileWriter) Write(el *persistence.EventLog, line []byte) error {
+	if r.file == nil {
+		// A previous rotation failed part way through, try to open the file again.
+		if err := r.open(); err != nil {
+			return err
+		}
+	}
+
+	if r.size > 0 && r.size+int64(len(line))+1 > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return fmt.Errorf("unable to rotate event log file %v, error: %v", r.path, err)
+		}
+	}
+
+	n, err := r.file.Write(append(line, '\n'))
+	r.size += int64(n)
+	return err
+}
+
+func (r *rotatingFileWriter) Close() error {
+	if r.file == nil {
+		return nil
+	}
+	return r.file.Close()
+}
+
+var forwarderLogString = func(v interface{}) string {
+	return fmt.Sprintf("Event log forwarder: %v", v)
+}
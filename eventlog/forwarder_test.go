@@ -0,0 +1,108 @@
+//go:build unit
+// +build unit
+
+package eventlog
+
+import (
+	"bufio"
+	"encoding/json"
+	"github.com/open-horizon/anax/config"
+	"github.com/open-horizon/anax/persistence"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path"
+	"testing"
+)
+
+func Test_Forwarder_file(t *testing.T) {
+
+	dir, db, err := utsetup()
+	if err != nil {
+		t.Error(err)
+	}
+	defer cleanTestDir(dir)
+
+	for i := 0; i < 12; i++ {
+		if err := LogExchangeEvent(db, persistence.SEVERITY_ERROR, persistence.NewMessageMeta("Error connecting to %v.", "exchange"), "exchange_error", "http://exchange.con/v1"); err != nil {
+			t.Errorf("error saving event log: %v", err)
+		}
+	}
+
+	cfg := &config.EventLogForwarderConfig{Type: config.EVENTLOG_FORWARD_FILE, FilePath: path.Join(dir, "events.log")}
+	f, err := NewForwarder(db, cfg)
+	if err != nil {
+		t.Fatalf("error creating forwarder: %v", err)
+	}
+	defer f.writer.Close()
+
+	if err := f.forward(); err != nil {
+		t.Errorf("error forwarding event logs: %v", err)
+	}
+
+	lines := readLines(t, cfg.FilePath)
+	assert.Equal(t, 12, len(lines), "All the event logs should be forwarded.")
+
+	var el persistence.EventLogRaw
+	if err := json.Unmarshal([]byte(lines[11]), &el); err != nil {
+		t.Errorf("forwarded line is not valid json: %v", err)
+	} else {
+		assert.Equal(t, "12", el.Id, "The event logs should be forwarded in record id order.")
+		assert.Equal(t, "Error connecting to exchange.", el.Message, "The message should be translated.")
+	}
+
+	// Nothing new to forward.
+	if err := f.forward(); err != nil {
+		t.Errorf("error forwarding event logs: %v", err)
+	}
+	assert.Equal(t, 12, len(readLines(t, cfg.FilePath)), "Event logs should only be forwarded once.")
+
+	if last, found, err := persistence.GetLastForwardedEventLog(db); err != nil {
+		t.Errorf("error reading last forwarded event log: %v", err)
+	} else {
+		assert.True(t, found)
+		assert.Equal(t, uint64(12), last)
+	}
+}
+
+func Test_rotatingFileWriter(t *testing.T) {
+
+	dir, err := os.MkdirTemp("", "utforwarder-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanTestDir(dir)
+
+	r := &rotatingFileWriter{path: path.Join(dir, "events.log"), maxSize: 10, maxFiles: 2}
+	if err := r.open(); err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	el := persistence.NewEventLog(persistence.SEVERITY_INFO, nil, "", "", nil)
+	for _, line := range []string{"line1", "line2", "line3", "line4"} {
+		if err := r.Write(el, []byte(line)); err != nil {
+			t.Errorf("error writing %v: %v", line, err)
+		}
+	}
+
+	assert.Equal(t, []string{"line4"}, readLines(t, r.path))
+	assert.Equal(t, []string{"line3"}, readLines(t, r.path+".1"))
+	assert.Equal(t, []string{"line2"}, readLines(t, r.path+".2"))
+	_, err = os.Stat(r.path + ".3")
+	assert.True(t, os.IsNotExist(err), "Only maxFiles rotated files should be kept.")
+}
+
+func readLines(t *testing.T, file string) []string {
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatalf("unable to open %v: %v", file, err)
+	}
+	defer f.Close()
+
+	lines := make([]string, 0)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines
+}
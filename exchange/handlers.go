@@ -3,6 +3,7 @@ package exchange
 import (
 	"github.com/open-horizon/anax/config"
 	"github.com/open-horizon/anax/exchangecommon"
+	"github.com/open-horizon/anax/externalpolicy"
 	"github.com/open-horizon/anax/policy"
 	"github.com/open-horizon/edge-sync-service/common"
 )
@@ -76,6 +77,19 @@ func GetHTTPExchangeOrgHandler(ec ExchangeContext) OrgHandler {
 	}
 }
 
+// A handler for getting the property schema of an organization. Nil is returned if the organization does not have one.
+type PropertySchemaHandler func(org string) (*externalpolicy.PropertySchema, error)
+
+func GetHTTPPropertySchemaHandler(ec ExchangeContext) PropertySchemaHandler {
+	return func(org string) (*externalpolicy.PropertySchema, error) {
+		if exOrg, err := GetOrganization(ec.GetHTTPFactory(), org, ec.GetExchangeURL(), ec.GetExchangeId(), ec.GetExchangeToken()); err != nil {
+			return nil, err
+		} else {
+			return exOrg.GetPropertySchema()
+		}
+	}
+}
+
 // A handler for querying the exchange version. The id and token is used for auth.
 type ExchangeVersionHandler func(id string, token string) (string, error)
 
@@ -134,6 +148,24 @@ func GetHTTPDeviceHandler2(cfg *config.HorizonConfig) DeviceHandler {
 	}
 }
 
+// A handler for getting all the nodes in an organization from the exchange
+type OrgDevicesHandler func(org string) (map[string]Device, error)
+
+func GetHTTPOrgDevicesHandler(ec ExchangeContext) OrgDevicesHandler {
+	return func(org string) (map[string]Device, error) {
+		return GetExchangeOrgDevices(ec, org)
+	}
+}
+
+// A handler for getting the agreements of a node from the exchange
+type NodeAgreementsHandler func(deviceId string) (map[string]DeviceAgreement, error)
+
+func GetHTTPNodeAgreementsHandler(ec ExchangeContext) NodeAgreementsHandler {
+	return func(deviceId string) (map[string]DeviceAgreement, error) {
+		return GetExchangeNodeAgreements(ec, deviceId)
+	}
+}
+
 // A handler for modifying the device information on the exchange
 type PutDeviceHandler func(deviceId string, deviceToken string, pdr *PutDeviceRequest) (*PutDeviceResponse, error)
 
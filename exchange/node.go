@@ -172,6 +172,76 @@ func GetExchangeDevice(httpClientFactory *config.HTTPClientFactory, deviceId str
 	}
 }
 
+// Get all the nodes in the given organization. The nodes are keyed by their full id, org/node.
+func GetExchangeOrgDevices(ec ExchangeContext, org string) (map[string]Device, error) {
+
+	glog.V(3).Infof(rpclogString(fmt.Sprintf("retrieving nodes for org %v from exchange", org)))
+
+	var resp interface{}
+	resp = new(GetDevicesResponse)
+	targetURL := fmt.Sprintf("%vorgs/%v/nodes", ec.GetExchangeURL(), org)
+
+	retryCount := ec.GetHTTPFactory().RetryCount
+	retryInterval := ec.GetHTTPFactory().GetRetryInterval()
+	for {
+		if err, tpErr := InvokeExchange(ec.GetHTTPFactory().NewHTTPClient(nil), "GET", targetURL, ec.GetExchangeId(), ec.GetExchangeToken(), nil, &resp); err != nil {
+			glog.Errorf(rpclogString(fmt.Sprintf(err.Error())))
+			return nil, err
+		} else if tpErr != nil {
+			glog.Warningf(rpclogString(fmt.Sprintf(tpErr.Error())))
+			if ec.GetHTTPFactory().RetryCount == 0 {
+				time.Sleep(time.Duration(retryInterval) * time.Second)
+				continue
+			} else if retryCount == 0 {
+				return nil, fmt.Errorf("Exceeded %v retries for error: %v", ec.GetHTTPFactory().RetryCount, tpErr)
+			} else {
+				retryCount--
+				time.Sleep(time.Duration(retryInterval) * time.Second)
+				continue
+			}
+		} else {
+			devs := resp.(*GetDevicesResponse).Devices
+			glog.V(3).Infof(rpclogString(fmt.Sprintf("found %v nodes for org %v", len(devs), org)))
+			return devs, nil
+		}
+	}
+}
+
+// Get the agreements of a node. The agreements are keyed by agreement id.
+func GetExchangeNodeAgreements(ec ExchangeContext, deviceId string) (map[string]DeviceAgreement, error) {
+
+	glog.V(3).Infof(rpclogString(fmt.Sprintf("retrieving agreements for node %v from exchange", deviceId)))
+
+	var resp interface{}
+	resp = new(AllDeviceAgreementsResponse)
+	targetURL := fmt.Sprintf("%vorgs/%v/nodes/%v/agreements", ec.GetExchangeURL(), GetOrg(deviceId), GetId(deviceId))
+
+	retryCount := ec.GetHTTPFactory().RetryCount
+	retryInterval := ec.GetHTTPFactory().GetRetryInterval()
+	for {
+		if err, tpErr := InvokeExchange(ec.GetHTTPFactory().NewHTTPClient(nil), "GET", targetURL, ec.GetExchangeId(), ec.GetExchangeToken(), nil, &resp); err != nil {
+			glog.Errorf(rpclogString(fmt.Sprintf(err.Error())))
+			return nil, err
+		} else if tpErr != nil {
+			glog.Warningf(rpclogString(fmt.Sprintf(tpErr.Error())))
+			if ec.GetHTTPFactory().RetryCount == 0 {
+				time.Sleep(time.Duration(retryInterval) * time.Second)
+				continue
+			} else if retryCount == 0 {
+				return nil, fmt.Errorf("Exceeded %v retries for error: %v", ec.GetHTTPFactory().RetryCount, tpErr)
+			} else {
+				retryCount--
+				time.Sleep(time.Duration(retryInterval) * time.Second)
+				continue
+			}
+		} else {
+			agreements := resp.(*AllDeviceAgreementsResponse).Agreements
+			glog.V(3).Infof(rpclogString(fmt.Sprintf("found %v agreements for node %v", len(agreements), deviceId)))
+			return agreements, nil
+		}
+	}
+}
+
 type PutDeviceResponse map[string]string
 
 type PostDeviceResponse struct {
@@ -381,14 +451,16 @@ type ContainerStatus struct {
 	Image   string `json:"image"`
 	Created int64  `json:"created"`
 	State   string `json:"state"`
+	Health  string `json:"health,omitempty"` // starting, healthy or unhealthy when the container has a health check
 }
 
 func (w ContainerStatus) String() string {
 	return fmt.Sprintf("Name: %v, "+
 		"Image: %v, "+
 		"Created: %v, "+
-		"State: %v",
-		w.Name, w.Image, w.Created, w.State)
+		"State: %v, "+
+		"Health: %v",
+		w.Name, w.Image, w.Created, w.State, w.Health)
 }
 
 type WorkloadStatus struct {
@@ -1,10 +1,12 @@
 package exchange
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/golang/glog"
 	"github.com/open-horizon/anax/config"
+	"github.com/open-horizon/anax/externalpolicy"
 	"time"
 )
 
@@ -48,6 +50,25 @@ func (o Organization) String() string {
 	return fmt.Sprintf("Label: %v, Description: %v, Tags %v, HeartbeatIntv %v, Limits %v", o.Label, o.Description, o.Tags, o.HeartbeatIntv, o.Limits)
 }
 
+// The organization tag that holds the property schema of the organization as a JSON document.
+const ORG_PROPERTY_SCHEMA_TAG = "openhorizon.propertySchema"
+
+// Return the property schema of the organization from its tags. Nil is returned if the organization does not have one.
+func (o Organization) GetPropertySchema() (*externalpolicy.PropertySchema, error) {
+	content, ok := o.Tags[ORG_PROPERTY_SCHEMA_TAG]
+	if !ok || content == "" {
+		return nil, nil
+	}
+
+	schema := new(externalpolicy.PropertySchema)
+	if err := json.Unmarshal([]byte(content), schema); err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to unmarshal the property schema %v, error %v", content, err))
+	} else if err := schema.Validate(); err != nil {
+		return nil, errors.New(fmt.Sprintf("the property schema is not valid, error %v", err))
+	}
+	return schema, nil
+}
+
 type GetOrganizationResponse struct {
 	Orgs      map[string]Organization `json:"orgs"`
 	LastIndex int                     `json:"lastIndex"`
@@ -0,0 +1,38 @@
+//go:build unit
+// +build unit
+
+package exchange
+
+import (
+	"github.com/open-horizon/anax/externalpolicy"
+	"testing"
+)
+
+func Test_Organization_GetPropertySchema(t *testing.T) {
+
+	// an org without the tag has no property schema
+	org := Organization{Label: "myorg", Tags: map[string]string{"mytag": "myvalue"}}
+	if schema, err := org.GetPropertySchema(); err != nil || schema != nil {
+		t.Errorf("expected no property schema, got %v, error %v", schema, err)
+	}
+
+	org.Tags[ORG_PROPERTY_SCHEMA_TAG] = `{"label":"myorg properties","enforcement":"error","properties":{"location":{"type":"string"},"zones":{"type":"list"}}}`
+	if schema, err := org.GetPropertySchema(); err != nil {
+		t.Errorf("unexpected error %v", err)
+	} else if schema == nil || schema.Enforcement != externalpolicy.SCHEMA_ENFORCE_ERROR || len(schema.Properties) != 2 {
+		t.Errorf("wrong property schema %v", schema)
+	} else if schema.Properties["zones"].Type != externalpolicy.LIST_TYPE {
+		t.Errorf("the short form of the list type should be normalized, got %v", schema.Properties["zones"].Type)
+	}
+
+	// a schema that is not valid is an error
+	org.Tags[ORG_PROPERTY_SCHEMA_TAG] = `{"properties":{"location":{"type":"strng"}}}`
+	if schema, err := org.GetPropertySchema(); err == nil {
+		t.Errorf("expected an error for an invalid property type, got %v", schema)
+	}
+
+	org.Tags[ORG_PROPERTY_SCHEMA_TAG] = `{"properties":`
+	if schema, err := org.GetPropertySchema(); err == nil {
+		t.Errorf("expected an error for a schema that is not JSON, got %v", schema)
+	}
+}
@@ -9,12 +9,14 @@ import (
 	"github.com/golang/glog"
 	"github.com/open-horizon/anax/config"
 	"github.com/open-horizon/anax/exchangecommon"
+	"github.com/open-horizon/anax/metrics"
 	"github.com/open-horizon/anax/semanticversion"
 	"github.com/open-horizon/edge-sync-service/common"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"reflect"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -46,9 +48,63 @@ type PutPostDeleteStandardResponse struct {
 	Msg  string `json:"msg"`
 }
 
+// Metrics recorded for every exchange API invocation.
+const EXCHANGE_REQUEST_DURATION = "horizon_exchange_request_duration_seconds"
+const EXCHANGE_REQUEST_ERRORS = "horizon_exchange_request_errors_total"
+
+func init() {
+	metrics.DefaultRegistry.NewHistogram(EXCHANGE_REQUEST_DURATION, "Latency of exchange API invocations, by HTTP method and exchange resource.", nil)
+	metrics.DefaultRegistry.NewCounter(EXCHANGE_REQUEST_ERRORS, "Number of failed exchange API invocations, by HTTP method, exchange resource and type of error.")
+}
+
 // This function is used to invoke an exchange API
 // For GET, the given resp parameter will be untouched when http returns code 404.
 func InvokeExchange(httpClient *http.Client, method string, urlPath string, user string, pw string, params interface{}, resp *interface{}) (error, error) {
+	start := time.Now()
+	err, tpErr := invokeExchange(httpClient, method, urlPath, user, pw, params, resp)
+
+	labels := metrics.Labels{"method": method, "resource": exchangeResourceFromURL(urlPath)}
+	metrics.ObserveDuration(EXCHANGE_REQUEST_DURATION, labels, start)
+	if err != nil {
+		metrics.IncCounter(EXCHANGE_REQUEST_ERRORS, metrics.Labels{"method": method, "resource": labels["resource"], "type": "api"})
+	} else if tpErr != nil {
+		metrics.IncCounter(EXCHANGE_REQUEST_ERRORS, metrics.Labels{"method": method, "resource": labels["resource"], "type": "transport"})
+	}
+	return err, tpErr
+}
+
+// Reduce an exchange URL to the kind of resource being accessed, e.g. "nodes" or "business", so that the metric labels
+// do not contain org names or object ids. URLs that are not org scoped (e.g. admin/version) return the first path segment
+// after the API version.
+func exchangeResourceFromURL(urlPath string) string {
+	path := urlPath
+	if urlObj, err := url.Parse(urlPath); err == nil {
+		path = urlObj.Path
+	}
+
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for ix, seg := range segments {
+		if seg == "orgs" {
+			if ix+2 < len(segments) {
+				return segments[ix+2]
+			}
+			return "orgs"
+		}
+	}
+	for ix, seg := range segments {
+		if strings.HasPrefix(seg, "v") && ix+1 < len(segments) {
+			if _, err := strconv.Atoi(strings.TrimPrefix(seg, "v")); err == nil {
+				return segments[ix+1]
+			}
+		}
+	}
+	if len(segments) != 0 {
+		return segments[len(segments)-1]
+	}
+	return ""
+}
+
+func invokeExchange(httpClient *http.Client, method string, urlPath string, user string, pw string, params interface{}, resp *interface{}) (error, error) {
 
 	if len(method) == 0 {
 		return errors.New(fmt.Sprintf("Error invoking exchange, method name must be specified")), nil
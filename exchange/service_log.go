@@ -0,0 +1,133 @@
+package exchange
+
+import (
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/open-horizon/anax/config"
+	"time"
+)
+
+// The message types used to pull the recent log lines of a service from a node. The agbot sends a request to the
+// message queue of the node on behalf of a user, the node sends the log lines back to the message queue of the agbot.
+// Both messages are encrypted like the agreement protocol messages.
+const SERVICE_LOG_REQUEST_TYPE = "serviceLogRequest"
+const SERVICE_LOG_RESPONSE_TYPE = "serviceLogResponse"
+
+// The default and the maximum number of log lines returned to a remote request.
+const SERVICE_LOG_DEFAULT_TAIL = 100
+const SERVICE_LOG_MAX_TAIL = 1000
+
+// One line of output written by a service container to stdout or stderr.
+type ServiceLogLine struct {
+	Time      time.Time `json:"time"`
+	Container string    `json:"container"`
+	Stream    string    `json:"stream"` // stdout or stderr
+	Line      string    `json:"line"`
+}
+
+func (l ServiceLogLine) String() string {
+	return fmt.Sprintf("%v %v %v: %v", l.Time.Format(time.RFC3339Nano), l.Container, l.Stream, l.Line)
+}
+
+type ServiceLogRequest struct {
+	MsgType   string `json:"type"`
+	RequestId string `json:"requestId"`
+	Service   string `json:"service"`             // the service url, optionally prefixed with the service org
+	Version   string `json:"version,omitempty"`   // the service version, any version if it is omitted
+	Container string `json:"container,omitempty"` // the container of the service, all the containers if it is omitted
+	Tail      int    `json:"tail,omitempty"`      // the number of most recent lines
+	Since     string `json:"since,omitempty"`     // only the lines after this time, see ParseServiceLogSince
+}
+
+func (r ServiceLogRequest) String() string {
+	return fmt.Sprintf("RequestId: %v, Service: %v, Version: %v, Container: %v, Tail: %v, Since: %v", r.RequestId, r.Service, r.Version, r.Container, r.Tail, r.Since)
+}
+
+func NewServiceLogRequest(requestId string, service string, version string, container string, tail int, since string) *ServiceLogRequest {
+	return &ServiceLogRequest{
+		MsgType:   SERVICE_LOG_REQUEST_TYPE,
+		RequestId: requestId,
+		Service:   service,
+		Version:   version,
+		Container: container,
+		Tail:      tail,
+		Since:     since,
+	}
+}
+
+type ServiceLogResponse struct {
+	MsgType   string           `json:"type"`
+	RequestId string           `json:"requestId"`
+	Instance  string           `json:"instance,omitempty"` // the service instance the lines came from
+	Lines     []ServiceLogLine `json:"lines"`
+	Error     string           `json:"error,omitempty"`
+}
+
+func (r ServiceLogResponse) String() string {
+	return fmt.Sprintf("RequestId: %v, Instance: %v, Lines: %v, Error: %v", r.RequestId, r.Instance, len(r.Lines), r.Error)
+}
+
+func NewServiceLogResponse(requestId string, instance string, lines []ServiceLogLine, err string) *ServiceLogResponse {
+	if lines == nil {
+		lines = []ServiceLogLine{}
+	}
+	return &ServiceLogResponse{
+		MsgType:   SERVICE_LOG_RESPONSE_TYPE,
+		RequestId: requestId,
+		Instance:  instance,
+		Lines:     lines,
+		Error:     err,
+	}
+}
+
+// Parse the since parameter of a service log request. It is either a time in RFC3339 format or a duration before
+// the input time, e.g. 10m. An empty string is the zero time.
+func ParseServiceLogSince(since string, now time.Time) (time.Time, error) {
+	if since == "" {
+		return time.Time{}, nil
+	} else if t, err := time.Parse(time.RFC3339, since); err == nil {
+		return t, nil
+	} else if d, err := time.ParseDuration(since); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, errors.New(fmt.Sprintf("%v is neither a time in RFC3339 format nor a duration", since))
+}
+
+// Return the service log request in the decrypted message, or nil if it is another kind of message.
+func ExtractServiceLogRequest(msg string) *ServiceLogRequest {
+	req := new(ServiceLogRequest)
+	if err := json.Unmarshal([]byte(msg), req); err != nil || req.MsgType != SERVICE_LOG_REQUEST_TYPE {
+		return nil
+	}
+	return req
+}
+
+// Return the service log response in the decrypted message, or nil if it is another kind of message.
+func ExtractServiceLogResponse(msg string) *ServiceLogResponse {
+	resp := new(ServiceLogResponse)
+	if err := json.Unmarshal([]byte(msg), resp); err != nil || resp.MsgType != SERVICE_LOG_RESPONSE_TYPE {
+		return nil
+	}
+	return resp
+}
+
+// Encrypt a message and post it to the message queue of the receiver. The msgsURL is the full url of the message queue,
+// either .../orgs/{org}/nodes/{id}/msgs or .../orgs/{org}/agbots/{id}/msgs.
+func PostEncryptedMessage(httpClientFactory *config.HTTPClientFactory, msgsURL string, senderId string, senderToken string, senderPubKey *rsa.PublicKey, senderPrivKey *rsa.PrivateKey, receiverPubKey []byte, payload interface{}, ttl int) error {
+
+	if pay, err := json.Marshal(payload); err != nil {
+		return errors.New(fmt.Sprintf("unable to marshal message %v, error %v", payload, err))
+	} else if receiverKey, err := DemarshalPublicKey(receiverPubKey); err != nil {
+		return errors.New(fmt.Sprintf("unable to demarshal the public key of the receiver %x, error %v", receiverPubKey, err))
+	} else if encryptedMsg, err := ConstructExchangeMessage(pay, senderPubKey, senderPrivKey, receiverKey); err != nil {
+		return errors.New(fmt.Sprintf("unable to construct encrypted message, error %v for message %s", err, pay))
+	} else if msgBody, err := json.Marshal(encryptedMsg); err != nil {
+		return errors.New(fmt.Sprintf("unable to marshal exchange message, error %v for message %v", err, encryptedMsg))
+	} else {
+		var resp interface{}
+		resp = new(PostDeviceResponse)
+		return InvokeExchangeRetryOnTransportError(httpClientFactory, "POST", msgsURL, senderId, senderToken, CreatePostMessage(msgBody, ttl), &resp)
+	}
+}
@@ -0,0 +1,58 @@
+//go:build unit
+// +build unit
+
+package exchange
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func Test_ParseServiceLogSince(t *testing.T) {
+
+	now := time.Date(2021, 6, 10, 14, 0, 0, 0, time.UTC)
+
+	if since, err := ParseServiceLogSince("", now); err != nil || !since.IsZero() {
+		t.Errorf("expected the zero time, got %v, error %v", since, err)
+	}
+
+	if since, err := ParseServiceLogSince("2021-06-10T12:30:00Z", now); err != nil || !since.Equal(time.Date(2021, 6, 10, 12, 30, 0, 0, time.UTC)) {
+		t.Errorf("expected 12:30, got %v, error %v", since, err)
+	}
+
+	if since, err := ParseServiceLogSince("10m", now); err != nil || !since.Equal(now.Add(-10*time.Minute)) {
+		t.Errorf("expected 10 minutes ago, got %v, error %v", since, err)
+	}
+
+	for _, bad := range []string{"yesterday", "-10m", "2021-06-10"} {
+		if _, err := ParseServiceLogSince(bad, now); err == nil {
+			t.Errorf("expected an error for %v", bad)
+		}
+	}
+}
+
+func Test_ExtractServiceLogMessages(t *testing.T) {
+
+	req := NewServiceLogRequest("id1", "myorg/my.service", "1.0.0", "", 10, "5m")
+	reqBytes, _ := json.Marshal(req)
+
+	resp := NewServiceLogResponse("id1", "instance1", []ServiceLogLine{{Time: time.Now(), Container: "c1", Stream: "stdout", Line: "hello"}}, "")
+	respBytes, _ := json.Marshal(resp)
+
+	if r := ExtractServiceLogRequest(string(reqBytes)); r == nil || r.RequestId != "id1" || r.Service != "myorg/my.service" || r.Tail != 10 {
+		t.Errorf("expected the request to be extracted, got %v", r)
+	} else if r := ExtractServiceLogRequest(string(respBytes)); r != nil {
+		t.Errorf("expected a response not to be extracted as a request, got %v", r)
+	}
+
+	if r := ExtractServiceLogResponse(string(respBytes)); r == nil || r.Instance != "instance1" || len(r.Lines) != 1 || r.Lines[0].Line != "hello" {
+		t.Errorf("expected the response to be extracted, got %v", r)
+	} else if r := ExtractServiceLogResponse(string(reqBytes)); r != nil {
+		t.Errorf("expected a request not to be extracted as a response, got %v", r)
+	}
+
+	if r := ExtractServiceLogRequest(`{"type":"proposal","protocol":"Basic","version":2}`); r != nil {
+		t.Errorf("expected a protocol message not to be extracted, got %v", r)
+	}
+}
@@ -0,0 +1,330 @@
+package exchangecommon
+
+import (
+	"fmt"
+	"github.com/open-horizon/anax/i18n"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The longest recurring maintenance window or blackout period, in seconds.
+const MAX_MAINTENANCE_WINDOW_DURATION = 7 * 24 * 60 * 60
+
+// A period of time. It is either recurring, a schedule in cron format (minute hour day-of-month month day-of-week) with
+// a duration, or a single period between a start and an end time in RFC3339 format.
+type TimeWindow struct {
+	Schedule string `json:"schedule,omitempty"` // when the recurring window starts, e.g. "0 22 * * 1-5" for 10pm on weekdays
+	Duration int    `json:"duration,omitempty"` // the number of seconds the recurring window lasts
+	Timezone string `json:"timezone,omitempty"` // the IANA time zone of the schedule, e.g. "America/New_York". The default is UTC.
+	Start    string `json:"start,omitempty"`    // the start of a single window
+	End      string `json:"end,omitempty"`      // the end of a single window
+}
+
+func (w TimeWindow) String() string {
+	return fmt.Sprintf("Schedule: %v, Duration: %v, Timezone: %v, Start: %v, End: %v", w.Schedule, w.Duration, w.Timezone, w.Start, w.End)
+}
+
+func (w TimeWindow) IsRecurring() bool {
+	return w.Schedule != ""
+}
+
+func (w TimeWindow) Validate() error {
+	// get message printer because this function is called by CLI
+	msgPrinter := i18n.GetMessagePrinter()
+
+	if w.IsRecurring() {
+		if w.Start != "" || w.End != "" {
+			return fmt.Errorf(msgPrinter.Sprintf("A time window cannot have both a schedule and a start or end time."))
+		} else if w.Duration <= 0 || w.Duration > MAX_MAINTENANCE_WINDOW_DURATION {
+			return fmt.Errorf(msgPrinter.Sprintf("The duration of the time window %v must be between 1 and %v seconds.", w.Schedule, MAX_MAINTENANCE_WINDOW_DURATION))
+		} else if _, err := parseCronSchedule(w.Schedule); err != nil {
+			return fmt.Errorf(msgPrinter.Sprintf("The schedule %v is not valid: %v", w.Schedule, err))
+		} else if _, err := time.LoadLocation(w.Timezone); err != nil {
+			return fmt.Errorf(msgPrinter.Sprintf("The timezone %v is not valid: %v", w.Timezone, err))
+		}
+		return nil
+	}
+
+	if w.Duration != 0 || w.Timezone != "" {
+		return fmt.Errorf(msgPrinter.Sprintf("The duration and timezone of a time window are only used with a schedule."))
+	}
+	start, err := time.Parse(time.RFC3339, w.Start)
+	if err != nil {
+		return fmt.Errorf(msgPrinter.Sprintf("The start time of a time window must be in RFC3339 format."))
+	}
+	end, err := time.Parse(time.RFC3339, w.End)
+	if err != nil {
+		return fmt.Errorf(msgPrinter.Sprintf("The end time of a time window must be in RFC3339 format."))
+	} else if !end.After(start) {
+		return fmt.Errorf(msgPrinter.Sprintf("The end time %v of a time window must be after the start time %v.", w.End, w.Start))
+	}
+	return nil
+}
+
+// Return true if the input time is within the window.
+func (w TimeWindow) Contains(t time.Time) (bool, error) {
+	if !w.IsRecurring() {
+		start, err := time.Parse(time.RFC3339, w.Start)
+		if err != nil {
+			return false, err
+		}
+		end, err := time.Parse(time.RFC3339, w.End)
+		if err != nil {
+			return false, err
+		}
+		return !t.Before(start) && t.Before(end), nil
+	}
+
+	parsed, err := w.parse()
+	if err != nil {
+		return false, err
+	}
+
+	// The input time is in the window if the last start of the window is less than a duration before it.
+	t = t.In(parsed.loc)
+	if start, ok := parsed.schedule.previous(t, w.Duration/(24*60*60)+1); ok {
+		return t.Sub(start) < time.Duration(w.Duration)*time.Second, nil
+	}
+	return false, nil
+}
+
+// The parsed schedule and time zone of a recurring window.
+type parsedTimeWindow struct {
+	schedule *cronSchedule
+	loc      *time.Location
+}
+
+// The recurring windows that were parsed, by schedule and time zone, so that checking a window does not parse it again.
+// The cache is cleared when it reaches MAX_PARSED_TIME_WINDOWS entries.
+const MAX_PARSED_TIME_WINDOWS = 1000
+
+var parsedTimeWindowsLock sync.Mutex
+var parsedTimeWindows = make(map[string]*parsedTimeWindow)
+
+func (w TimeWindow) parse() (*parsedTimeWindow, error) {
+	key := w.Schedule + "|" + w.Timezone
+
+	parsedTimeWindowsLock.Lock()
+	defer parsedTimeWindowsLock.Unlock()
+
+	if parsed, ok := parsedTimeWindows[key]; ok {
+		return parsed, nil
+	}
+
+	schedule, err := parseCronSchedule(w.Schedule)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := time.LoadLocation(w.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(parsedTimeWindows) >= MAX_PARSED_TIME_WINDOWS {
+		parsedTimeWindows = make(map[string]*parsedTimeWindow)
+	}
+	parsed := &parsedTimeWindow{schedule: schedule, loc: loc}
+	parsedTimeWindows[key] = parsed
+	return parsed, nil
+}
+
+// When the service versions of a node can be changed. Outside of the maintenance windows, or during a blackout period,
+// the agbot does not cancel the agreements of the node to upgrade the service it runs.
+type MaintenanceSchedule struct {
+	Windows   []TimeWindow `json:"windows,omitempty"`   // the service versions can only be changed within one of these windows, any time if there are none
+	Blackouts []TimeWindow `json:"blackouts,omitempty"` // the service versions are never changed within these windows
+}
+
+func (m MaintenanceSchedule) String() string {
+	return fmt.Sprintf("Windows: %v, Blackouts: %v", m.Windows, m.Blackouts)
+}
+
+func (m *MaintenanceSchedule) DeepCopy() *MaintenanceSchedule {
+	if m == nil {
+		return nil
+	}
+	copyM := MaintenanceSchedule{}
+	if m.Windows != nil {
+		copyM.Windows = make([]TimeWindow, len(m.Windows))
+		copy(copyM.Windows, m.Windows)
+	}
+	if m.Blackouts != nil {
+		copyM.Blackouts = make([]TimeWindow, len(m.Blackouts))
+		copy(copyM.Blackouts, m.Blackouts)
+	}
+	return &copyM
+}
+
+func (m *MaintenanceSchedule) Validate() error {
+	if m == nil {
+		return nil
+	}
+	for _, w := range m.Windows {
+		if err := w.Validate(); err != nil {
+			return err
+		}
+	}
+	for _, w := range m.Blackouts {
+		if err := w.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Return true if the service versions can be changed at the input time. A nil schedule allows it at any time.
+func (m *MaintenanceSchedule) IsAllowed(t time.Time) (bool, error) {
+	if m == nil {
+		return true, nil
+	}
+
+	for _, w := range m.Blackouts {
+		if in, err := w.Contains(t); err != nil || in {
+			return false, err
+		}
+	}
+
+	if len(m.Windows) == 0 {
+		return true, nil
+	}
+	for _, w := range m.Windows {
+		if in, err := w.Contains(t); err != nil || in {
+			return in, err
+		}
+	}
+	return false, nil
+}
+
+// A parsed cron schedule. Each field holds the allowed values of the corresponding time field.
+type cronSchedule struct {
+	minutes    map[int]bool
+	hours      map[int]bool
+	days       map[int]bool
+	months     map[int]bool
+	weekdays   map[int]bool
+	anyDay     bool
+	anyWeekday bool
+}
+
+// Return the last time at or before the input time when the schedule starts a window, in the location of the input time.
+// At most the input number of days before the day of the input time are searched. The returned bool is false if the
+// schedule does not start a window in that time.
+func (c *cronSchedule) previous(t time.Time, days int) (time.Time, bool) {
+	for d := 0; d <= days; d++ {
+		day := time.Date(t.Year(), t.Month(), t.Day()-d, 0, 0, 0, 0, t.Location())
+		if !c.dayMatches(day) {
+			continue
+		}
+
+		// On the day of the input time, only the hours and minutes up to the input time are candidates.
+		maxHour := 23
+		if d == 0 {
+			maxHour = t.Hour()
+		}
+		for hour := maxHour; hour >= 0; hour-- {
+			if !c.hours[hour] {
+				continue
+			}
+			maxMinute := 59
+			if d == 0 && hour == t.Hour() {
+				maxMinute = t.Minute()
+			}
+			for minute := maxMinute; minute >= 0; minute-- {
+				// A start that does not exist on the day, because the clocks were moved forward, is moved after it.
+				if c.minutes[minute] {
+					if start := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, t.Location()); !start.After(t) {
+						return start, true
+					}
+				}
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// Return true if the schedule starts a window on the day of the input time. As in cron, when both the day of the month
+// and the day of the week are restricted, either of them can match.
+func (c *cronSchedule) dayMatches(t time.Time) bool {
+	if !c.months[int(t.Month())] {
+		return false
+	}
+
+	dayMatch := c.days[t.Day()]
+	weekdayMatch := c.weekdays[int(t.Weekday())]
+	if c.anyDay || c.anyWeekday {
+		return dayMatch && weekdayMatch
+	}
+	return dayMatch || weekdayMatch
+}
+
+func parseCronSchedule(schedule string) (*cronSchedule, error) {
+	fields := strings.Fields(schedule)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields (minute hour day-of-month month day-of-week) but found %v", len(fields))
+	}
+
+	c := new(cronSchedule)
+	var err error
+	if c.minutes, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, err
+	} else if c.hours, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, err
+	} else if c.days, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, err
+	} else if c.months, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, err
+	} else if c.weekdays, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, err
+	}
+
+	// Sunday is both 0 and 7.
+	if c.weekdays[7] {
+		c.weekdays[0] = true
+	}
+	c.anyDay = strings.HasPrefix(fields[2], "*")
+	c.anyWeekday = strings.HasPrefix(fields[4], "*")
+	return c, nil
+}
+
+// Parse one cron field: a comma separated list of *, a number or a range, each optionally followed by /step.
+func parseCronField(field string, min int, max int) (map[int]bool, error) {
+	values := make(map[int]bool)
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if ix := strings.Index(part, "/"); ix != -1 {
+			rangePart = part[:ix]
+			s, err := strconv.Atoi(part[ix+1:])
+			if err != nil || s <= 0 {
+				return nil, fmt.Errorf("invalid step in %v", part)
+			}
+			step = s
+		}
+
+		low, high := min, max
+		if rangePart != "*" {
+			bounds := strings.SplitN(rangePart, "-", 2)
+			l, err := strconv.Atoi(bounds[0])
+			if err != nil {
+				return nil, fmt.Errorf("invalid value in %v", part)
+			}
+			low, high = l, l
+			if len(bounds) == 2 {
+				if high, err = strconv.Atoi(bounds[1]); err != nil {
+					return nil, fmt.Errorf("invalid range in %v", part)
+				}
+			} else if step != 1 {
+				high = max
+			}
+		}
+
+		if low < min || high > max || low > high {
+			return nil, fmt.Errorf("%v is out of the range %v-%v", part, min, max)
+		}
+		for v := low; v <= high; v += step {
+			values[v] = true
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,124 @@
+//go:build unit
+// +build unit
+
+package exchangecommon
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_TimeWindow_Validate(t *testing.T) {
+
+	good := []TimeWindow{
+		{Schedule: "0 22 * * 1-5", Duration: 3600},
+		{Schedule: "*/15 0-6 1,15 * 0", Duration: 600, Timezone: "UTC"},
+		{Start: "2026-12-24T00:00:00Z", End: "2026-12-27T00:00:00Z"},
+	}
+	for _, w := range good {
+		if err := w.Validate(); err != nil {
+			t.Errorf("The time window %v should be valid but got: %v", w, err)
+		}
+	}
+
+	bad := []TimeWindow{
+		{Schedule: "0 22 * *", Duration: 3600},
+		{Schedule: "0 24 * * *", Duration: 3600},
+		{Schedule: "0 22 * * 1-5", Duration: 0},
+		{Schedule: "0 22 * * 1-5", Duration: MAX_MAINTENANCE_WINDOW_DURATION + 1},
+		{Schedule: "0 22 * * 1-5", Duration: 3600, Timezone: "Nowhere/Special"},
+		{Schedule: "0 22 * * 1-5", Duration: 3600, Start: "2026-12-24T00:00:00Z"},
+		{Start: "2026-12-24T00:00:00Z", End: "2026-12-23T00:00:00Z"},
+		{Start: "2026-12-24", End: "2026-12-27T00:00:00Z"},
+		{Start: "2026-12-24T00:00:00Z", End: "2026-12-27T00:00:00Z", Duration: 60},
+	}
+	for _, w := range bad {
+		if err := w.Validate(); err == nil {
+			t.Errorf("The time window %v should not be valid", w)
+		}
+	}
+}
+
+func Test_MaintenanceSchedule_IsAllowed(t *testing.T) {
+
+	// 10pm to 6am on weekdays, except during the holidays
+	schedule := &MaintenanceSchedule{
+		Windows:   []TimeWindow{{Schedule: "0 22 * * 1-5", Duration: 8 * 3600}},
+		Blackouts: []TimeWindow{{Start: "2026-12-24T00:00:00Z", End: "2026-12-27T00:00:00Z"}},
+	}
+
+	tests := []struct {
+		time    string
+		allowed bool
+	}{
+		{"2026-10-14T23:30:00Z", true},  // Wednesday night
+		{"2026-10-15T05:59:00Z", true},  // Thursday morning, still in Wednesday's window
+		{"2026-10-15T06:00:00Z", false}, // the window is over
+		{"2026-10-15T14:00:00Z", false}, // business hours
+		{"2026-10-17T23:00:00Z", false}, // Saturday night
+		{"2026-12-24T23:00:00Z", false}, // Thursday night during the blackout
+	}
+	for _, test := range tests {
+		now, _ := time.Parse(time.RFC3339, test.time)
+		if allowed, err := schedule.IsAllowed(now); err != nil {
+			t.Errorf("Unexpected error for %v: %v", test.time, err)
+		} else if allowed != test.allowed {
+			t.Errorf("IsAllowed at %v should have returned %v", test.time, test.allowed)
+		}
+	}
+
+	// no schedule and no windows allow changes at any time
+	now, _ := time.Parse(time.RFC3339, "2026-10-15T14:00:00Z")
+	var none *MaintenanceSchedule
+	if allowed, _ := none.IsAllowed(now); !allowed {
+		t.Errorf("A missing schedule should allow changes")
+	} else if allowed, _ := (&MaintenanceSchedule{}).IsAllowed(now); !allowed {
+		t.Errorf("A schedule without windows should allow changes")
+	}
+}
+
+func Test_TimeWindow_Contains(t *testing.T) {
+
+	windows := []TimeWindow{
+		{Schedule: "0 22 * * 1-5", Duration: 8 * 3600},
+		{Schedule: "30 2 * * 0", Duration: 7 * 24 * 3600, Timezone: "America/New_York"},
+		{Schedule: "*/20 9-17 1,15 * *", Duration: 600, Timezone: "Europe/Paris"},
+		{Schedule: "0 0 13 * 5", Duration: 24 * 3600},
+		{Schedule: "45 23 31 12 *", Duration: 3 * 24 * 3600},
+	}
+
+	// Compare with the start of the window found by checking each minute before the input time.
+	start, _ := time.Parse(time.RFC3339, "2026-10-25T00:07:00Z")
+	for _, w := range windows {
+		schedule, err := parseCronSchedule(w.Schedule)
+		if err != nil {
+			t.Fatalf("Unexpected error parsing %v: %v", w.Schedule, err)
+		}
+		loc, _ := time.LoadLocation(w.Timezone)
+
+		for now := start; now.Before(start.Add(10 * 24 * time.Hour)); now = now.Add(97 * time.Minute) {
+			expected := false
+			local := now.In(loc)
+			for s := local.Truncate(time.Minute); local.Sub(s) < time.Duration(w.Duration)*time.Second; s = s.Add(-time.Minute) {
+				if schedule.dayMatches(s) && schedule.hours[s.Hour()] && schedule.minutes[s.Minute()] {
+					expected = true
+					break
+				}
+			}
+
+			if in, err := w.Contains(now); err != nil {
+				t.Errorf("Unexpected error for %v at %v: %v", w, now, err)
+			} else if in != expected {
+				t.Errorf("Contains for %v at %v should have returned %v", w, now, expected)
+			}
+		}
+	}
+
+	// A window that is not valid is an error.
+	now, _ := time.Parse(time.RFC3339, "2026-10-15T14:00:00Z")
+	if _, err := (TimeWindow{Schedule: "0 22 * *", Duration: 3600}).Contains(now); err == nil {
+		t.Errorf("Expected an error for a schedule with 4 fields")
+	} else if _, err := (TimeWindow{Schedule: "0 22 * * *", Duration: 3600, Timezone: "Nowhere/Nothing"}).Contains(now); err == nil {
+		t.Errorf("Expected an error for an unknown time zone")
+	}
+}
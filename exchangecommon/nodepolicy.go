@@ -18,12 +18,13 @@ type NodePolicy struct {
 	Label                         string                        `json:"label,omitempty"`
 	Description                   string                        `json:"description,omitempty"`
 	externalpolicy.ExternalPolicy                               // top level properties and constraints,
-	Deployment                    externalpolicy.ExternalPolicy `json:"deployment,omitempty"` // properties and constrians for deopoyment
-	Management                    externalpolicy.ExternalPolicy `json:"management,omitempty"` // properties and constrians for node management
+	Deployment                    externalpolicy.ExternalPolicy `json:"deployment,omitempty"`  // properties and constrians for deopoyment
+	Management                    externalpolicy.ExternalPolicy `json:"management,omitempty"`  // properties and constrians for node management
+	Maintenance                   *MaintenanceSchedule          `json:"maintenance,omitempty"` // when the agbot can change the service versions running on the node
 }
 
 func (n NodePolicy) String() string {
-	return fmt.Sprintf("NodePolicy: Label: %v, Description: %v, Properties: %v, Constraints: %v, Deployment: %v, Management: %v", n.Label, n.Description, n.Properties, n.Constraints, n.Deployment, n.Management)
+	return fmt.Sprintf("NodePolicy: Label: %v, Description: %v, Properties: %v, Constraints: %v, Deployment: %v, Management: %v, Maintenance: %v", n.Label, n.Description, n.Properties, n.Constraints, n.Deployment, n.Management, n.Maintenance)
 }
 
 // This function validates the properties and constrains. It also updates the node's
@@ -41,6 +42,9 @@ func (n *NodePolicy) ValidateAndNormalize() error {
 	if err := (&n.Management).ValidateAndNormalize(); err != nil {
 		return err
 	}
+	if err := n.Maintenance.Validate(); err != nil {
+		return err
+	}
 
 	// We only get here if the input object is nil OR all of the top level fields are empty.
 	return nil
@@ -60,6 +64,8 @@ func (n NodePolicy) DeepCopy() *NodePolicy {
 
 	copyN.Management = *(n.Management.DeepCopy())
 
+	copyN.Maintenance = n.Maintenance.DeepCopy()
+
 	return &copyN
 }
 
@@ -390,11 +390,14 @@ func DeleteNodePolicy(pDevice *persistence.ExchangeDevice, db *bolt.DB,
 // (EP_COMPARE_*) for deployment and management. It also returns the latest node policy.
 func UpdateNodePolicy(pDevice *persistence.ExchangeDevice, db *bolt.DB, nodePolicy *exchangecommon.NodePolicy,
 	nodeGetPolicyHandler exchange.NodePolicyHandler,
-	nodePutPolicyHandler exchange.PutNodePolicyHandler) (int, int, error) {
+	nodePutPolicyHandler exchange.PutNodePolicyHandler,
+	propertySchemaHandler exchange.PropertySchemaHandler) (int, int, error) {
 
 	// verify the policy
 	if err := nodePolicy.ValidateAndNormalize(); err != nil {
 		return 0, 0, fmt.Errorf("Node policy does not validate. %v", err)
+	} else if err := checkPropertySchema(pDevice.Org, nodePolicy, propertySchemaHandler); err != nil {
+		return 0, 0, fmt.Errorf("Node policy does not match the property schema of organization %v. %v", pDevice.Org, err)
 	}
 
 	// add node's built-in properties
@@ -443,7 +446,8 @@ func UpdateNodePolicy(pDevice *persistence.ExchangeDevice, db *bolt.DB, nodePoli
 func PatchNodePolicy(pDevice *persistence.ExchangeDevice, db *bolt.DB,
 	attributeName string, patchObject interface{},
 	nodeGetPolicyHandler exchange.NodePolicyHandler,
-	nodePutPolicyHandler exchange.PutNodePolicyHandler) (int, int, *exchangecommon.NodePolicy, error) {
+	nodePutPolicyHandler exchange.PutNodePolicyHandler,
+	propertySchemaHandler exchange.PropertySchemaHandler) (int, int, *exchangecommon.NodePolicy, error) {
 
 	if changed, _, err := ExchangeNodePolicyChanged(pDevice, db, nodeGetPolicyHandler); err != nil {
 		return 0, 0, nil, fmt.Errorf("Failed to check the exchange for the node policy: %v.", err)
@@ -477,6 +481,8 @@ func PatchNodePolicy(pDevice *persistence.ExchangeDevice, db *bolt.DB,
 
 	if err := localNodePolicy.ValidateAndNormalize(); err != nil {
 		return 0, 0, nil, err
+	} else if err := checkPropertySchema(pDevice.Org, localNodePolicy, propertySchemaHandler); err != nil {
+		return 0, 0, nil, fmt.Errorf("Node policy does not match the property schema of organization %v. %v", pDevice.Org, err)
 	}
 
 	// save it into the exchange and sync the local db with it.
@@ -490,3 +496,27 @@ func PatchNodePolicy(pDevice *persistence.ExchangeDevice, db *bolt.DB,
 
 	return rc_deploy, rc_management, localNodePolicy, nil
 }
+
+// Check the properties of the node policy against the property schema of the node's organization. The problems
+// are logged as warnings unless the schema is enforced as errors. If the schema cannot be read from the exchange,
+// the properties are not checked.
+func checkPropertySchema(org string, nodePolicy *exchangecommon.NodePolicy, propertySchemaHandler exchange.PropertySchemaHandler) error {
+	schema, err := propertySchemaHandler(org)
+	if err != nil {
+		glog.Warningf("Unable to get the property schema of organization %v, the node policy properties are not checked against it. %v", org, err)
+		return nil
+	} else if schema == nil {
+		return nil
+	}
+
+	for _, props := range []externalpolicy.PropertyList{nodePolicy.Properties, nodePolicy.Deployment.Properties, nodePolicy.Management.Properties} {
+		warnings, err := schema.CheckProperties(props)
+		if err != nil {
+			return err
+		}
+		for _, warning := range warnings {
+			glog.Warningf("Node policy property schema check for organization %v: %v", org, warning)
+		}
+	}
+	return nil
+}
@@ -51,7 +51,7 @@ func Test_UpdateNodePolicy(t *testing.T) {
 
 	ExchangeNodePolicy = nil
 
-	_, _, err = UpdateNodePolicy(pDevice, db, nodePolicy, getDummyNodePolicyHandler(), getDummyPutNodePolicyHandler())
+	_, _, err = UpdateNodePolicy(pDevice, db, nodePolicy, getDummyNodePolicyHandler(), getDummyPutNodePolicyHandler(), getDummyPropertySchemaHandler(nil))
 
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err)
@@ -65,6 +65,52 @@ func Test_UpdateNodePolicy(t *testing.T) {
 
 }
 
+// Verify that a Node Policy Object is checked against the property schema of the node's organization.
+func Test_UpdateNodePolicy_schema(t *testing.T) {
+
+	dir, db, err := utsetup()
+	if err != nil {
+		t.Error(err)
+	}
+	defer cleanTestDir(dir)
+
+	pDevice, err := persistence.SaveNewExchangeDevice(db, "testid", "testtoken", "testname", "device", "myOrg", "", persistence.CONFIGSTATE_CONFIGURING, persistence.SoftwareVersion{persistence.AGENT_VERSION: "1.0.0"})
+	if err != nil {
+		t.Errorf("failed to create persisted device, error %v", err)
+	}
+
+	schema := &externalpolicy.PropertySchema{
+		Enforcement: externalpolicy.SCHEMA_ENFORCE_ERROR,
+		Properties:  map[string]externalpolicy.PropertyDefinition{"location": {Type: externalpolicy.STRING_TYPE}},
+	}
+	if err := schema.Validate(); err != nil {
+		t.Errorf("Unexpected error validating the property schema: %v", err)
+	}
+
+	ExchangeNodePolicy = nil
+
+	// a misspelled property is rejected when the schema is enforced as errors
+	nodePolicy := &exchangecommon.NodePolicy{ExternalPolicy: externalpolicy.ExternalPolicy{Properties: externalpolicy.PropertyList{*externalpolicy.Property_Factory("locaton", "us")}}}
+	if _, _, err := UpdateNodePolicy(pDevice, db, nodePolicy, getDummyNodePolicyHandler(), getDummyPutNodePolicyHandler(), getDummyPropertySchemaHandler(schema)); err == nil {
+		t.Errorf("Expected an error for property locaton that is not in the property schema")
+	} else if ExchangeNodePolicy != nil {
+		t.Errorf("The rejected node policy should not be saved in the exchange: %v", ExchangeNodePolicy)
+	}
+
+	// the declared property is accepted
+	nodePolicy = &exchangecommon.NodePolicy{ExternalPolicy: externalpolicy.ExternalPolicy{Properties: externalpolicy.PropertyList{*externalpolicy.Property_Factory("location", "us")}}}
+	if _, _, err := UpdateNodePolicy(pDevice, db, nodePolicy, getDummyNodePolicyHandler(), getDummyPutNodePolicyHandler(), getDummyPropertySchemaHandler(schema)); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	// the problems are only warnings when the schema is not enforced as errors
+	schema.Enforcement = externalpolicy.SCHEMA_ENFORCE_WARNING
+	nodePolicy = &exchangecommon.NodePolicy{ExternalPolicy: externalpolicy.ExternalPolicy{Properties: externalpolicy.PropertyList{*externalpolicy.Property_Factory("locaton", "us")}}}
+	if _, _, err := UpdateNodePolicy(pDevice, db, nodePolicy, getDummyNodePolicyHandler(), getDummyPutNodePolicyHandler(), getDummyPropertySchemaHandler(schema)); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
 // Verify that a Node Policy Object can be created and deleted.
 func Test_DeleteNodePolicy(t *testing.T) {
 
@@ -91,7 +137,7 @@ func Test_DeleteNodePolicy(t *testing.T) {
 
 	ExchangeNodePolicy = nil
 
-	_, _, err = UpdateNodePolicy(pDevice, db, nodePolicy, getDummyNodePolicyHandler(), getDummyPutNodePolicyHandler())
+	_, _, err = UpdateNodePolicy(pDevice, db, nodePolicy, getDummyNodePolicyHandler(), getDummyPutNodePolicyHandler(), getDummyPropertySchemaHandler(nil))
 
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err)
@@ -139,7 +185,7 @@ func Test_ExchangeNodePolicyChanged(t *testing.T) {
 
 	ExchangeNodePolicy = nil
 
-	rc_d, rc_m, err := UpdateNodePolicy(pDevice, db, nodePolicy, getDummyNodePolicyHandler(), getDummyPutNodePolicyHandler())
+	rc_d, rc_m, err := UpdateNodePolicy(pDevice, db, nodePolicy, getDummyNodePolicyHandler(), getDummyPutNodePolicyHandler(), getDummyPropertySchemaHandler(nil))
 
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err)
@@ -310,6 +356,12 @@ func getDummyPutNodePolicyHandler() exchange.PutNodePolicyHandler {
 	}
 }
 
+func getDummyPropertySchemaHandler(schema *externalpolicy.PropertySchema) exchange.PropertySchemaHandler {
+	return func(org string) (*externalpolicy.PropertySchema, error) {
+		return schema, nil
+	}
+}
+
 func getDummyNodePolicyHandler() exchange.NodePolicyHandler {
 	return func(deviceId string) (*exchange.ExchangeNodePolicy, error) {
 		if ExchangeNodePolicy != nil {
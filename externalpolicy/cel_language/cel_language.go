@@ -0,0 +1,144 @@
+package cel_language
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+	"github.com/open-horizon/anax/externalpolicy/plugin_registry"
+	"github.com/open-horizon/anax/i18n"
+	"strings"
+	"sync"
+)
+
+// A constraint written in the Common Expression Language starts with this marker, for example:
+//
+//	cel: props["openhorizon.memory"] >= 2048 && props.location.startsWith("us-")
+//
+// The properties are available in the expression as a map named props.
+const CEL_MARKER = "cel:"
+
+// The name of the variable that holds the properties in an expression.
+const CEL_PROPERTIES = "props"
+
+// The compiled programs are cached by expression. The cache is cleared when it reaches MAX_CEL_PROGRAMS entries
+// so that it does not keep growing in a long running agbot.
+const MAX_CEL_PROGRAMS = 1000
+
+func init() {
+	plugin_registry.Register("cel", NewCELConstraintLanguagePlugin())
+}
+
+type CELConstraintLanguagePlugin struct {
+	env      *cel.Env
+	programs map[string]cel.Program // compiled expressions, keyed by the expression
+	lock     sync.Mutex
+}
+
+func NewCELConstraintLanguagePlugin() plugin_registry.ConstraintLanguagePlugin {
+	env, err := cel.NewEnv(
+		cel.Variable(CEL_PROPERTIES, cel.MapType(cel.StringType, cel.DynType)),
+		cel.CrossTypeNumericComparisons(true),
+		ext.Strings(),
+	)
+	if err != nil {
+		panic(fmt.Sprintf("unable to create the cel constraint language environment: %v", err))
+	}
+
+	return &CELConstraintLanguagePlugin{
+		env:      env,
+		programs: make(map[string]cel.Program),
+	}
+}
+
+// The plugin owns the constraints only when every one of them has the cel marker. Each constraint is compiled
+// so that syntax and type errors are reported when the policy is validated.
+func (p *CELConstraintLanguagePlugin) Validate(dconstraints interface{}) (bool, []string, error) {
+
+	// get message printer because this function is called by CLI
+	msgPrinter := i18n.GetMessagePrinter()
+
+	// Validate that the input is a ConstraintExpression type (string[])
+	constraints, ok := dconstraints.([]string)
+	if !ok {
+		return false, []string{}, errors.New(msgPrinter.Sprintf("The constraint expression: %v is type %T, but is expected to be an array of strings", dconstraints, dconstraints))
+	} else if len(constraints) == 0 {
+		return false, nil, nil
+	}
+
+	for _, constraint := range constraints {
+		if !IsCELConstraint(constraint) {
+			return false, nil, nil
+		}
+	}
+
+	for _, constraint := range constraints {
+		if _, err := p.getProgram(constraint); err != nil {
+			return true, nil, errors.New(msgPrinter.Sprintf("The cel constraint expression %v is not valid: %v", constraint, err))
+		}
+	}
+
+	return true, constraints, nil
+}
+
+// A cel expression is evaluated as a whole, it cannot be broken down into property expressions.
+func (p *CELConstraintLanguagePlugin) GetNextExpression(expression string) (string, string, error) {
+	return "", expression, fmt.Errorf("The cel constraint expression %v cannot be broken down into property expressions.", expression)
+}
+
+func (p *CELConstraintLanguagePlugin) GetNextOperator(expression string) (string, string, error) {
+	return "", expression, fmt.Errorf("The cel constraint expression %v cannot be broken down into control operators.", expression)
+}
+
+// Evaluate the constraint with the given property values. A property that is not defined can be checked
+// with the has() macro, e.g. has(props.gpu). Referencing it otherwise is an evaluation error.
+func (p *CELConstraintLanguagePlugin) IsSatisfiedBy(constraint string, properties map[string]interface{}) (bool, error) {
+	prg, err := p.getProgram(constraint)
+	if err != nil {
+		return false, err
+	}
+
+	out, _, err := prg.Eval(map[string]interface{}{CEL_PROPERTIES: properties})
+	if err != nil {
+		return false, err
+	}
+
+	satisfied, ok := out.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("the expression returned %v, not a boolean", out.Value())
+	}
+	return satisfied, nil
+}
+
+// Return the compiled program for the constraint, the constraint is compiled on first use.
+func (p *CELConstraintLanguagePlugin) getProgram(constraint string) (cel.Program, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	expression := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(constraint), CEL_MARKER))
+	if prg, ok := p.programs[expression]; ok {
+		return prg, nil
+	}
+
+	ast, issues := p.env.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, issues.Err()
+	} else if outType := ast.OutputType().String(); outType != cel.BoolType.String() && outType != cel.DynType.String() {
+		return nil, fmt.Errorf("the expression must return a boolean, not %v", outType)
+	}
+
+	prg, err := p.env.Program(ast)
+	if err != nil {
+		return nil, err
+	}
+	if len(p.programs) >= MAX_CEL_PROGRAMS {
+		p.programs = make(map[string]cel.Program)
+	}
+	p.programs[expression] = prg
+	return prg, nil
+}
+
+// Return true if the constraint is written in the cel constraint language.
+func IsCELConstraint(constraint string) bool {
+	return strings.HasPrefix(strings.TrimSpace(constraint), CEL_MARKER)
+}
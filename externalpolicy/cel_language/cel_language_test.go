@@ -0,0 +1,103 @@
+//go:build unit
+// +build unit
+
+package cel_language
+
+import (
+	"fmt"
+	"github.com/google/cel-go/cel"
+	"github.com/open-horizon/anax/externalpolicy/plugin_registry"
+	"testing"
+)
+
+func Test_Validate(t *testing.T) {
+	celPlugin := NewCELConstraintLanguagePlugin()
+
+	// the plugin owns the constraints that have the cel marker
+	constraints := []string{
+		`cel: props["openhorizon.memory"] >= 2048 && props.location.startsWith("us-")`,
+		`cel: props.zones.exists(z, z == "b2") || !has(props.gpu)`,
+	}
+	if owned, _, err := celPlugin.Validate(interface{}(constraints)); !owned {
+		t.Errorf("The plugin should own %v but did not, err: %v", constraints, err)
+	} else if err != nil {
+		t.Errorf("Should validate without err, but returned err: %v", err)
+	}
+
+	// the plugin does not own the constraints written in the text language
+	for _, constraints := range [][]string{{"location == us-east"}, {"cel: has(props.gpu)", "gpu == true"}, {}} {
+		if owned, _, err := celPlugin.Validate(interface{}(constraints)); owned || err != nil {
+			t.Errorf("The plugin should not own %v, err: %v", constraints, err)
+		}
+	}
+
+	// syntax errors and expressions that do not return a boolean
+	for _, constraint := range []string{`cel: props.location +`, `cel: 1 + "a"`, `cel: props.cores * 2`} {
+		if owned, _, err := celPlugin.Validate(interface{}([]string{constraint})); !owned || err == nil {
+			t.Errorf("Validation of %v should fail but did not", constraint)
+		}
+	}
+
+	// the constraint must be an array of strings
+	if owned, _, err := celPlugin.Validate(interface{}("cel: true")); owned || err == nil {
+		t.Errorf("Validation of a string should fail but did not")
+	}
+}
+
+func Test_IsSatisfiedBy(t *testing.T) {
+	celPlugin := NewCELConstraintLanguagePlugin().(plugin_registry.ConstraintEvaluatorPlugin)
+
+	props := map[string]interface{}{
+		"openhorizon.memory": float64(4096),
+		"location":           "us-east-2",
+		"zones":              []string{"a1", "b2"},
+		"cores":              int64(4),
+	}
+
+	satisfied := []string{
+		`cel: props["openhorizon.memory"] >= 2048 && props.location.startsWith("us-")`,
+		`cel: props.zones.exists(z, z == "b2") && props.cores * 2 == 8`,
+		`cel: !has(props.gpu)`,
+		`cel: props.location.matches("^us-(east|west)-[0-9]+$")`,
+	}
+	for _, constraint := range satisfied {
+		if ok, err := celPlugin.IsSatisfiedBy(constraint, props); err != nil || !ok {
+			t.Errorf("The constraint %v should be satisfied but was not, err: %v", constraint, err)
+		}
+	}
+
+	if ok, err := celPlugin.IsSatisfiedBy(`cel: props.cores > 8`, props); err != nil || ok {
+		t.Errorf("The constraint should not be satisfied, err: %v", err)
+	}
+
+	// an undefined property can only be checked with has()
+	if _, err := celPlugin.IsSatisfiedBy(`cel: props.gpu == true`, props); err == nil {
+		t.Errorf("The constraint should have returned an error for the undefined property")
+	}
+
+	// the expression must return a boolean at run time
+	if _, err := celPlugin.IsSatisfiedBy(`cel: props.location`, props); err == nil {
+		t.Errorf("The constraint should have returned an error for a non boolean result")
+	}
+}
+
+func Test_getProgram_cache(t *testing.T) {
+	celPlugin := NewCELConstraintLanguagePlugin().(*CELConstraintLanguagePlugin)
+
+	for i := 0; i < MAX_CEL_PROGRAMS+10; i++ {
+		if _, err := celPlugin.getProgram(fmt.Sprintf(`cel: props.cores == %v`, i)); err != nil {
+			t.Errorf("Unexpected error compiling expression %v: %v", i, err)
+		}
+		if len(celPlugin.programs) > MAX_CEL_PROGRAMS {
+			t.Fatalf("The program cache has %v entries, more than %v", len(celPlugin.programs), MAX_CEL_PROGRAMS)
+		}
+	}
+
+	// the same expression, with or without the marker, is compiled once
+	celPlugin.programs = make(map[string]cel.Program)
+	celPlugin.getProgram(`cel: props.cores == 1`)
+	celPlugin.getProgram(`  props.cores == 1 `)
+	if len(celPlugin.programs) != 1 {
+		t.Errorf("Expected 1 cached program but got %v", len(celPlugin.programs))
+	}
+}
@@ -0,0 +1,176 @@
+package externalpolicy
+
+import (
+	"fmt"
+	"github.com/open-horizon/anax/externalpolicy/plugin_registry"
+	"strings"
+)
+
+// The result of evaluating a constraint expression, or one of its sub-expressions, against a list of properties.
+// A compound expression has an "and" or "or" operator and the results of each of its sub-expressions. A simple
+// expression compares one property with a value, the value of the property is returned with the result so that
+// it is easy to see why the expression failed.
+type ConstraintEvaluation struct {
+	Expression         string                 `json:"expression"`
+	Satisfied          bool                   `json:"satisfied"`
+	Operator           string                 `json:"operator,omitempty"`             // "and" or "or" for a compound expression
+	SubExpressions     []ConstraintEvaluation `json:"sub_expressions,omitempty"`      // set for a compound expression
+	PropertyValue      interface{}            `json:"property_value,omitempty"`       // set for a simple expression when the property is defined
+	PropertyNotDefined bool                   `json:"property_not_defined,omitempty"` // set for a simple expression when the property is not defined
+}
+
+func (e ConstraintEvaluation) String() string {
+	return fmt.Sprintf("Expression: %v, Satisfied: %v, Operator: %v, SubExpressions: %v, PropertyValue: %v, PropertyNotDefined: %v",
+		e.Expression, e.Satisfied, e.Operator, e.SubExpressions, e.PropertyValue, e.PropertyNotDefined)
+}
+
+// Evaluate each constraint in the expression against the given properties and return the evaluation tree. The
+// constraints are ANDed together, just like in IsSatisfiedBy.
+func (self *ConstraintExpression) Evaluate(props []Property) (*ConstraintEvaluation, error) {
+	// If there is no expression at all, then there is nothing to satisify
+	if self == nil || len(*self) == 0 {
+		return &ConstraintEvaluation{Satisfied: true}, nil
+	}
+
+	top := ConstraintEvaluation{Satisfied: true, Operator: OP_AND, SubExpressions: make([]ConstraintEvaluation, 0, len(*self))}
+	for _, constraint := range *self {
+		// A constraint in a language that evaluates its own expressions has no sub-expressions. An evaluation
+		// error, such as a reference to an undefined property, means the constraint is not satisfied.
+		if evaluator := plugin_registry.ConstraintLanguagePlugins.GetEvaluatorByOne(constraint); evaluator != nil {
+			satisfied, _ := evaluator.IsSatisfiedBy(constraint, propertyValues(props))
+			top.Satisfied = top.Satisfied && satisfied
+			top.SubExpressions = append(top.SubExpressions, ConstraintEvaluation{Expression: strings.TrimSpace(constraint), Satisfied: satisfied})
+			continue
+		}
+
+		single := ConstraintExpression([]string{constraint})
+		rp, err := RequiredPropertyFromConstraint(&single)
+		if err != nil {
+			return nil, err
+		}
+
+		eval, err := rp.Evaluate(props)
+		if err != nil {
+			return nil, err
+		}
+
+		// show the constraint the way it was written rather than the way it was parsed.
+		eval.Expression = strings.TrimSpace(strings.Replace(constraint, "\a", " ", -1))
+		top.Satisfied = top.Satisfied && eval.Satisfied
+		top.SubExpressions = append(top.SubExpressions, *eval)
+	}
+
+	if len(top.SubExpressions) == 1 {
+		return &top.SubExpressions[0], nil
+	}
+	top.Expression = displayEvaluations(OP_AND, top.SubExpressions)
+	return &top, nil
+}
+
+// This function is used to evaluate the RequiredProperty expression against the given properties. It reaches the
+// same result as IsSatisfiedBy but it evaluates every sub-expression instead of stopping at the first one that
+// decides the result.
+func (self *RequiredProperty) Evaluate(props []Property) (*ConstraintEvaluation, error) {
+
+	// Make sure the expression is valid
+	if err := self.IsValid(); err != nil {
+		return nil, err
+	}
+
+	// If there is no expression at all, then there is nothing to satisify
+	if len(*self) == 0 {
+		return &ConstraintEvaluation{Satisfied: true}, nil
+	}
+
+	// Make a copy of the object so that we can get it's type correct
+	topMap := make(map[string]interface{})
+	for k := range *self {
+		topMap[k] = (*self)[k]
+	}
+
+	eval := collapseEvaluation(evaluate(&topMap, &props))
+	return &eval, nil
+}
+
+// This function does the real work of evaluating the expression. It is called recursively because
+// control operators can be nested n levels deep. The expression must have been validated.
+func evaluate(cop *map[string]interface{}, props *[]Property) ConstraintEvaluation {
+	controlOp := getControlOperator(cop)
+
+	eval := ConstraintEvaluation{Operator: controlOp, SubExpressions: []ConstraintEvaluation{}}
+	propArray := (*cop)[controlOp].([]interface{})
+	for _, p := range propArray {
+		if prop := isPropertyExpression(p); prop != nil {
+			eval.SubExpressions = append(eval.SubExpressions, evaluateProperty(prop, props))
+		} else if cop1 := isControlOp(p); cop1 != nil {
+			eval.SubExpressions = append(eval.SubExpressions, collapseEvaluation(evaluate(cop1, props)))
+		}
+	}
+
+	// An AND is satisfied when all of the sub-expressions are, an OR when at least one is.
+	eval.Satisfied = (controlOp == OP_AND)
+	for _, sub := range eval.SubExpressions {
+		if controlOp == OP_AND && !sub.Satisfied {
+			eval.Satisfied = false
+		} else if controlOp == OP_OR && sub.Satisfied {
+			eval.Satisfied = true
+		}
+	}
+
+	eval.Expression = displayEvaluations(controlOp, eval.SubExpressions)
+	return eval
+}
+
+// Evaluate a single property expression.
+func evaluateProperty(prop *PropertyExpression, props *[]Property) ConstraintEvaluation {
+	op := prop.Op
+	if op == "" {
+		op = doubleequalto
+	}
+
+	expression := fmt.Sprintf("%v %v %v", prop.Name, op, prop.Value)
+	if _, ok := existenceOperators()[op]; ok {
+		expression = fmt.Sprintf("%v %v", prop.Name, op)
+	}
+
+	eval := ConstraintEvaluation{
+		Expression:         expression,
+		Satisfied:          propertyInArray(prop, props),
+		PropertyNotDefined: true,
+	}
+	for _, p := range *props {
+		if p.Name == prop.Name {
+			eval.PropertyValue = p.Value
+			eval.PropertyNotDefined = false
+			break
+		}
+	}
+	return eval
+}
+
+// The parser wraps every expression in an OR and an AND, a compound expression with only one sub-expression
+// is replaced by the sub-expression to keep the tree readable.
+func collapseEvaluation(eval ConstraintEvaluation) ConstraintEvaluation {
+	for eval.Operator != "" && len(eval.SubExpressions) == 1 {
+		eval = eval.SubExpressions[0]
+	}
+	return eval
+}
+
+// Display the sub-expressions of a compound expression, the compound sub-expressions are put in parentheses.
+func displayEvaluations(controlOp string, subs []ConstraintEvaluation) string {
+	op_display := " && "
+	if controlOp == OP_OR {
+		op_display = " || "
+	}
+
+	display_strings := []string{}
+	for _, sub := range subs {
+		if sub.Operator != "" {
+			display_strings = append(display_strings, fmt.Sprintf("(%v)", sub.Expression))
+		} else {
+			display_strings = append(display_strings, sub.Expression)
+		}
+	}
+	return strings.Join(display_strings, op_display)
+}
@@ -0,0 +1,62 @@
+//go:build unit
+// +build unit
+
+package externalpolicy
+
+import (
+	_ "github.com/open-horizon/anax/externalpolicy/text_language"
+	"testing"
+)
+
+// Verify that the evaluation tree shows which clause of a constraint expression failed.
+func Test_ConstraintExpression_Evaluate(t *testing.T) {
+
+	ce := ConstraintExpression{`(a == 1 || b in "x,y") && openhorizon.memory >= 2048`, "c == true"}
+	props := []Property{
+		*Property_Factory("a", float64(2)),
+		*Property_Factory("b", "z"),
+		*Property_Factory("openhorizon.memory", float64(4096)),
+	}
+
+	eval, err := ce.Evaluate(props)
+	if err != nil {
+		t.Errorf("Error: unable to evaluate %v: %v", ce, err)
+	} else if eval.Satisfied || eval.Operator != OP_AND || len(eval.SubExpressions) != 2 {
+		t.Errorf("Error: wrong top level evaluation: %v", eval)
+	} else if first := eval.SubExpressions[0]; first.Satisfied || first.Expression != ce[0] || len(first.SubExpressions) != 2 {
+		t.Errorf("Error: wrong evaluation for the first constraint: %v", first)
+	} else if or := first.SubExpressions[0]; or.Satisfied || or.Operator != OP_OR || len(or.SubExpressions) != 2 {
+		t.Errorf("Error: wrong evaluation for the OR clause: %v", or)
+	} else if b := or.SubExpressions[1]; b.Satisfied || b.Expression != `b in "x,y"` || b.PropertyValue != "z" {
+		t.Errorf("Error: wrong evaluation for the b clause: %v", b)
+	} else if mem := first.SubExpressions[1]; !mem.Satisfied || mem.PropertyValue != float64(4096) {
+		t.Errorf("Error: wrong evaluation for the memory clause: %v", mem)
+	} else if c := eval.SubExpressions[1]; c.Satisfied || !c.PropertyNotDefined || c.PropertyValue != nil {
+		t.Errorf("Error: wrong evaluation for the undefined property c: %v", c)
+	}
+
+	// the evaluation and IsSatisfiedBy must always agree
+	props = append(props, *Property_Factory("c", true))
+	props[1] = *Property_Factory("b", "y")
+	if eval, err := ce.Evaluate(props); err != nil {
+		t.Errorf("Error: unable to evaluate %v: %v", ce, err)
+	} else if !eval.Satisfied {
+		t.Errorf("Error: the constraints should be satisfied: %v", eval)
+	} else if err := ce.IsSatisfiedBy(props); err != nil {
+		t.Errorf("Error: the constraints should be satisfied: %v", err)
+	}
+
+	// a single simple constraint is not wrapped in a compound expression
+	ce = ConstraintExpression{"prop == value"}
+	if eval, err := ce.Evaluate(nil); err != nil {
+		t.Errorf("Error: unable to evaluate %v: %v", ce, err)
+	} else if eval.Satisfied || eval.Operator != "" || eval.Expression != "prop == value" {
+		t.Errorf("Error: wrong evaluation for a simple constraint: %v", eval)
+	}
+
+	// no constraints are always satisfied
+	ce = ConstraintExpression{}
+	if eval, err := ce.Evaluate(props); err != nil || !eval.Satisfied {
+		t.Errorf("Error: an empty constraint expression should be satisfied: %v %v", eval, err)
+	}
+}
@@ -1,6 +1,7 @@
 package externalpolicy
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/open-horizon/anax/externalpolicy/plugin_registry"
 	"strings"
@@ -9,8 +10,18 @@ import (
 // This type implements all the ConstraintLanguage Plugin methods and delegates to plugin system.
 type ConstraintExpression []string
 
+// Each constraint is validated by the plugin of its own language, so that the constraints written in different
+// languages can be merged into one expression.
 func (c *ConstraintExpression) Validate() ([]string, error) {
-	return plugin_registry.ConstraintLanguagePlugins.ValidatedByOne((*c).GetStrings())
+	validated := make([]string, 0, len(*c))
+	for _, constraint := range *c {
+		if v, err := plugin_registry.ConstraintLanguagePlugins.ValidatedByOne([]string{constraint}); err != nil {
+			return nil, err
+		} else {
+			validated = append(validated, v...)
+		}
+	}
+	return validated, nil
 }
 
 func (c *ConstraintExpression) GetLanguageHandler() (plugin_registry.ConstraintLanguagePlugin, error) {
@@ -96,8 +107,25 @@ func (self *ConstraintExpression) IsSatisfiedBy(props []Property) error {
 		return nil
 	}
 
+	// The constraints in a language that evaluates its own expressions are checked first, the rest
+	// are converted to RequiredProperty.
+	converted := ConstraintExpression{}
+	for _, constraint := range *self {
+		if evaluator := plugin_registry.ConstraintLanguagePlugins.GetEvaluatorByOne(constraint); evaluator == nil {
+			converted = append(converted, constraint)
+		} else if satisfied, err := evaluator.IsSatisfiedBy(constraint, propertyValues(props)); err != nil {
+			return fmt.Errorf("The constraint %v could not be evaluated with the available properties %v: %v", constraint, displayProperties(&props), err)
+		} else if !satisfied {
+			return fmt.Errorf("The constraint %v is not satisfied by the available properties %v", constraint, displayProperties(&props))
+		}
+	}
+
+	if len(converted) == 0 {
+		return nil
+	}
+
 	// convert it to RequiredProperty and then check
-	if rp, err := RequiredPropertyFromConstraint(self); err != nil {
+	if rp, err := RequiredPropertyFromConstraint(&converted); err != nil {
 		return err
 	} else if rp != nil {
 		return rp.IsSatisfiedBy(props)
@@ -133,9 +161,12 @@ func RequiredPropertyFromConstraint(extConstraint *ConstraintExpression) (*Requi
 		remainder := strings.Replace(remainder, "\a", " ", -1)
 
 		// Get a handle to the specific language handler we will be using.
-		handler, err = extConstraint.GetLanguageHandler()
+		single := ConstraintExpression([]string{remainder})
+		handler, err = single.GetLanguageHandler()
 		if err != nil {
 			return nil, fmt.Errorf("unable to obtain policy constraint language handler, error %v", err)
+		} else if _, ok := handler.(plugin_registry.ConstraintEvaluatorPlugin); ok {
+			return nil, fmt.Errorf("the constraint %v cannot be converted to required properties, it is evaluated by its constraint language handler", remainder)
 		}
 
 		// Create a new Required Property structure and initialize it with a top level OR followed by a top level AND. This will allow us
@@ -212,3 +243,40 @@ func parseConstraintExpression(constraint string, handler plugin_registry.Constr
 
 	return nil, constraint, err
 }
+
+// Return the property values by name for the constraint languages that evaluate their own expressions. The values
+// are converted according to the property type: integers to int64, lists of strings to []string.
+func propertyValues(props []Property) map[string]interface{} {
+	values := make(map[string]interface{}, len(props))
+	for _, p := range props {
+		switch v := p.Value.(type) {
+		case json.Number:
+			if i, err := v.Int64(); err == nil && p.Type != FLOAT_TYPE {
+				values[p.Name] = i
+			} else if f, err := v.Float64(); err == nil {
+				values[p.Name] = f
+			} else {
+				values[p.Name] = v.String()
+			}
+		case float64:
+			if p.Type == INTEGER_TYPE {
+				values[p.Name] = int64(v)
+			} else {
+				values[p.Name] = v
+			}
+		case string:
+			if p.Type == LIST_TYPE {
+				list := []string{}
+				for _, s := range strings.Split(v, ",") {
+					list = append(list, removeQuotes(removeSpaces(s)))
+				}
+				values[p.Name] = list
+			} else {
+				values[p.Name] = v
+			}
+		default:
+			values[p.Name] = p.Value
+		}
+	}
+	return values
+}
@@ -4,6 +4,7 @@
 package externalpolicy
 
 import (
+	_ "github.com/open-horizon/anax/externalpolicy/cel_language"
 	_ "github.com/open-horizon/anax/externalpolicy/text_language"
 	"testing"
 )
@@ -337,3 +338,114 @@ func Test_MergeWith(t *testing.T) {
 		t.Errorf("Error: constraints %v should have 4 elements but got %v", ce1, len(*ce1))
 	}
 }
+
+// Verify the regex, prefix, suffix, existence and version range operators.
+func Test_new_operators_IsSatisfiedBy(t *testing.T) {
+	prop_list := `[{"name":"location", "value":"us-east-2"},{"name":"firmware", "value":"1.5.0"},{"name":"zones", "value":"a1,b2", "type":"list of strings"},{"name":"gpu", "value":true}]`
+	props := create_property_list(prop_list, t)
+
+	satisfied := []string{
+		"location matches \"^us-(east|west)-[0-9]+$\"",
+		"location startsWith us- && location endsWith \"-2\"",
+		"zones startsWith b",
+		"gpu exists AND legacy !exists",
+		"firmware in [1.2.0,2.0.0)",
+		"(location matches eu OR gpu exists) && firmware in [1.5.0,INFINITY)",
+	}
+	for _, c := range satisfied {
+		ce := ConstraintExpression{c}
+		if err := ce.IsSatisfiedBy(*props); err != nil {
+			t.Errorf("Error: constraint %v should be satisfied by %v: %v", c, props, err)
+		}
+	}
+
+	not_satisfied := []string{
+		"location matches \"^eu-.*\"",
+		"location startsWith east",
+		"location endsWith us",
+		"zones endsWith c",
+		"gpu startsWith t",
+		"gpu !exists",
+		"legacy exists",
+		"firmware in [2.0.0,3.0.0)",
+		"firmware in (1.2.0,1.5.0)",
+	}
+	for _, c := range not_satisfied {
+		ce := ConstraintExpression{c}
+		if err := ce.IsSatisfiedBy(*props); err == nil {
+			t.Errorf("Error: constraint %v should not be satisfied by %v", c, props)
+		}
+	}
+
+	// the existence operators are converted to property expressions without a value
+	ce := ConstraintExpression{"gpu exists"}
+	if rp, err := RequiredPropertyFromConstraint(&ce); err != nil {
+		t.Errorf("Error: unable to convert %v: %v", ce, err)
+	} else if display := displayRequiredProperty((*map[string]interface{})(rp)); display != "(gpu exists)" {
+		t.Errorf("Error: wrong display of %v: %v", ce, display)
+	}
+
+	// the spaces inside a pattern and a property value are significant
+	spaced := create_property_list(`[{"name":"city", "value":"new york"},{"name":"range", "value":"x{1, 3}"}]`, t)
+	for _, rpString := range []string{
+		`{"and":[{"name":"city", "value":"^new york$", "op":"matches"}]}`,
+		`{"and":[{"name":"city", "value":"\"new \"", "op":"startsWith"}]}`,
+		`{"and":[{"name":"city", "value":"\" york\"", "op":"endsWith"}]}`,
+		`{"and":[{"name":"range", "value":"^x\\{1, 3\\}$", "op":"matches"}]}`,
+	} {
+		if rp := create_RP(rpString, t); rp.IsSatisfiedBy(*spaced) != nil {
+			t.Errorf("Error: %v should be satisfied by %v", rp, spaced)
+		}
+	}
+	for _, rpString := range []string{
+		`{"and":[{"name":"city", "value":"^newyork$", "op":"matches"}]}`,
+		`{"and":[{"name":"city", "value":"\"york \"", "op":"endsWith"}]}`,
+	} {
+		if rp := create_RP(rpString, t); rp.IsSatisfiedBy(*spaced) == nil {
+			t.Errorf("Error: %v should not be satisfied by %v", rp, spaced)
+		}
+	}
+
+	// an invalid regular expression in a required property is not valid
+	rp := create_RP(`{"and":[{"name":"location", "value":"^us-(east", "op":"matches"}]}`, t)
+	if err := rp.IsValid(); err == nil {
+		t.Errorf("Error: %v should not be valid", rp)
+	}
+}
+
+// Verify that the constraints written in the cel language can be mixed with the text language constraints.
+func Test_cel_IsSatisfiedBy(t *testing.T) {
+	prop_list := `[{"name":"openhorizon.memory", "value":4096},{"name":"location", "value":"us-east-2"},{"name":"zones", "value":"a1,b2", "type":"list of strings"},{"name":"cores", "value":4, "type":"int"}]`
+	props := create_property_list(prop_list, t)
+
+	ce := ConstraintExpression{
+		`cel: props["openhorizon.memory"] >= 2048 && props.zones.exists(z, z == "b2")`,
+		"location startsWith us-",
+		`cel: props.cores * 2 == 8 && !has(props.gpu)`,
+	}
+	if _, err := ce.Validate(); err != nil {
+		t.Errorf("Error: %v should be valid: %v", ce, err)
+	} else if err := ce.IsSatisfiedBy(*props); err != nil {
+		t.Errorf("Error: constraints %v should be satisfied by %v: %v", ce, props, err)
+	} else if eval, err := ce.Evaluate(*props); err != nil || !eval.Satisfied || len(eval.SubExpressions) != 3 {
+		t.Errorf("Error: wrong evaluation of %v: %v %v", ce, eval, err)
+	}
+
+	ce = ConstraintExpression{"location startsWith us-", `cel: props.location.endsWith("-1")`}
+	if err := ce.IsSatisfiedBy(*props); err == nil {
+		t.Errorf("Error: constraints %v should not be satisfied by %v", ce, props)
+	} else if eval, err := ce.Evaluate(*props); err != nil || eval.Satisfied || eval.SubExpressions[1].Satisfied {
+		t.Errorf("Error: wrong evaluation of %v: %v %v", ce, eval, err)
+	}
+
+	// a cel constraint cannot be converted to a RequiredProperty
+	if _, err := RequiredPropertyFromConstraint(&ConstraintExpression{"cel: has(props.gpu)"}); err == nil {
+		t.Errorf("Error: a cel constraint should not be converted to a RequiredProperty")
+	}
+
+	// the cel syntax errors are reported by validation
+	ce = ConstraintExpression{"location == us-east-2", "cel: props.location +"}
+	if _, err := ce.Validate(); err == nil {
+		t.Errorf("Error: %v should not be valid", ce)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/open-horizon/anax/semanticversion"
+	"regexp"
 	"strconv"
 	"strings"
 )
@@ -23,8 +24,10 @@ import (
 // _control_operator_    = {"and", "or", "not"}
 // _expression_          = _control_operator_: [_expression_] || property
 // _property_            = "name": _property_name_, "value": _property_value, "op": _comparison_operator_
-// _comparison_operator_ = {"<", "=", ">", "<=", ">=", "!="}
+// _comparison_operator_ = {"<", "=", ">", "<=", ">=", "!=", "in", "matches", "startsWith", "endsWith", "exists", "!exists"}
 // The "=" and "!=" comparison operators can be applied to strings and integers.
+// The "matches", "startsWith" and "endsWith" operators only apply to strings, the value of "matches" is a RE2 regular expression.
+// The "exists" and "!exists" operators ignore the value, they check if the property is defined or not.
 // If the "op" key is missing, then equal is assumed.
 //
 // See the unit tests for examples of valid and invalid syntax
@@ -65,12 +68,18 @@ const lessthaneq = "<="
 const greaterthaneq = ">="
 const notequalto = "!="
 const isin = "in"
+const matches = "matches"
+const startswith = "startsWith"
+const endswith = "endsWith"
+const exists = "exists"
+const notexists = "!exists"
 
 // This struct represents property value expressions to be satisfied
 type PropertyExpression struct {
-	Name  string      `json:"name"`  // The Property name
-	Value interface{} `json:"value"` // The Property value
-	Op    string      `json:"op"`    // The operator to apply to the property value
+	Name  string         `json:"name"`  // The Property name
+	Value interface{}    `json:"value"` // The Property value
+	Op    string         `json:"op"`    // The operator to apply to the property value
+	re    *regexp.Regexp // The compiled regular expression of the matches operator
 }
 
 func (p PropertyExpression) String() string {
@@ -83,6 +92,11 @@ func PropertyExpression_Factory(name string, value interface{}, op string) *Prop
 	pe.Value = value
 	pe.Op = op
 
+	// Compile the regular expression once, the copies of the expression share it. An invalid one is reported by IsValid.
+	if op == matches {
+		pe.re, _ = regexp.Compile(removeQuotes(fmt.Sprintf("%v", value)))
+	}
+
 	return pe
 }
 
@@ -218,6 +232,11 @@ func (self *RequiredProperty) verify(cop *map[string]interface{}) error {
 	propArray := (*cop)[controlOp].([]interface{})
 	for _, p := range propArray {
 		if prop := isPropertyExpression(p); prop != nil {
+			if prop.Op == matches {
+				if _, err := regexp.Compile(removeQuotes(fmt.Sprintf("%v", prop.Value))); err != nil {
+					return errors.New(fmt.Sprintf("RequiredProperty Object not valid, the value of property %v is not a valid regular expression: %v", prop.Name, err))
+				}
+			}
 			continue
 		} else if cop := isControlOp(p); cop != nil {
 			if err := self.verify(cop); err != nil {
@@ -282,7 +301,8 @@ func controlOperators() map[string]int {
 // of the supported comparison operators.
 func comparisonOperators() map[string]int {
 	// return map[string]int {and:0, or:0, not:0}
-	return map[string]int{lessthan: 0, greaterthan: 0, doubleequalto: 0, equalto: 0, lessthaneq: 0, greaterthaneq: 0, notequalto: 0, isin: 0}
+	return map[string]int{lessthan: 0, greaterthan: 0, doubleequalto: 0, equalto: 0, lessthaneq: 0, greaterthaneq: 0, notequalto: 0, isin: 0,
+		matches: 0, startswith: 0, endswith: 0, exists: 0, notexists: 0}
 }
 
 // Return a map of comparison operators that only work on strings
@@ -290,6 +310,16 @@ func stringOperators() map[string]int {
 	return map[string]int{doubleequalto: 0, equalto: 0, notequalto: 0, isin: 0}
 }
 
+// Return a map of comparison operators that match a pattern in a string
+func patternOperators() map[string]int {
+	return map[string]int{matches: 0, startswith: 0, endswith: 0}
+}
+
+// Return a map of comparison operators that do not use the property value
+func existenceOperators() map[string]int {
+	return map[string]int{exists: 0, notexists: 0}
+}
+
 // This function checks the type of the input interface object to see if it's a map of string to
 // interface. Control operators and Properties are both of this type when deserialized by the
 // JSON library.
@@ -412,12 +442,38 @@ func getKeys(m map[string]interface{}) []string {
 // This function compares a Property object with an array of Property objects to see if it's
 // in the array with an appropriate value.
 func propertyInArray(propexp *PropertyExpression, props *[]Property) bool {
+	// The existence operators only need the property name
+	if _, ok := existenceOperators()[propexp.Op]; ok {
+		found := false
+		for _, p := range *props {
+			if p.Name == propexp.Name {
+				found = true
+				break
+			}
+		}
+		return found == (propexp.Op == exists)
+	}
+
 	for _, p := range *props {
 		if p.Name != propexp.Name {
 			// These are not the droids we're looking for
 			continue
 		} else {
-			if isFloat64(p.Value) {
+			if _, ok := patternOperators()[propexp.Op]; ok {
+				if !isString(p.Value) {
+					return false
+				}
+				// The spaces around the elements of a list are separators, any other space is part of the value.
+				if p.Type == LIST_TYPE {
+					for _, pValue := range strings.Split(p.Value.(string), ",") {
+						if propexp.matchesPattern(removeQuotes(removeSpaces(pValue))) {
+							return true
+						}
+					}
+					return false
+				}
+				return propexp.matchesPattern(removeQuotes(p.Value.(string)))
+			} else if isFloat64(p.Value) {
 				var propexpFloat float64
 				if isFloat64(propexp.Value) {
 					propexpFloat = propexp.Value.(float64)
@@ -500,6 +556,41 @@ func removeQuotes(value string) string {
 	return value
 }
 
+// This function checks if the string value matches the pattern of the expression's pattern operator. Only the quotes
+// are removed from the pattern, the spaces are part of it. The regular expression of the matches operator is compiled
+// by the factory or the first time it is needed, and reused after that.
+func (p *PropertyExpression) matchesPattern(value string) bool {
+	pattern := removeQuotes(fmt.Sprintf("%v", p.Value))
+	if p.Op != matches {
+		return stringMatchesPattern(value, p.Op, pattern)
+	}
+	if p.re == nil {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return false
+		}
+		p.re = re
+	}
+	return p.re.MatchString(value)
+}
+
+// This function checks if the string value matches the pattern of one of the pattern operators.
+func stringMatchesPattern(value string, op string, pattern string) bool {
+	switch op {
+	case matches:
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return false
+		}
+		return re.MatchString(value)
+	case startswith:
+		return strings.HasPrefix(value, pattern)
+	case endswith:
+		return strings.HasSuffix(value, pattern)
+	}
+	return false
+}
+
 func containsVersion(versRange string, version string) bool {
 	vers, err := semanticversion.Version_Expression_Factory(version)
 	if err != nil {
@@ -549,11 +640,7 @@ func displayRequiredProperty(cop *map[string]interface{}) string {
 	display_strings := []string{}
 	for _, p := range propArray {
 		if prop := isPropertyExpression(p); prop != nil {
-			if prop.Op == "" {
-				prop.Op = doubleequalto
-			}
-			s := fmt.Sprintf("%v%v%v", prop.Name, prop.Op, prop.Value)
-			display_strings = append(display_strings, s)
+			display_strings = append(display_strings, displayPropertyExpression(prop))
 		} else if cop1 := isControlOp(p); cop1 != nil {
 			s := displayRequiredProperty(cop1)
 			if controlOp == OP_OR {
@@ -570,6 +657,19 @@ func displayRequiredProperty(cop *map[string]interface{}) string {
 	return strings.Join(display_strings, op_display)
 }
 
+// This function displays a property expression, the operators that are words are separated by spaces.
+func displayPropertyExpression(prop *PropertyExpression) string {
+	if prop.Op == "" {
+		prop.Op = doubleequalto
+	}
+	if _, ok := existenceOperators()[prop.Op]; ok {
+		return fmt.Sprintf("%v %v", prop.Name, prop.Op)
+	} else if _, ok := patternOperators()[prop.Op]; ok {
+		return fmt.Sprintf("%v %v %v", prop.Name, prop.Op, prop.Value)
+	}
+	return fmt.Sprintf("%v%v%v", prop.Name, prop.Op, prop.Value)
+}
+
 // This fuction displays the a property list to "key1=value1, key1=value2..." format.
 func displayProperties(props *[]Property) string {
 	if props != nil && len(*props) > 0 {
@@ -12,6 +12,13 @@ type ConstraintLanguagePlugin interface {
 	GetNextOperator(expression string) (string, string, error)
 }
 
+// A constraint language plugin whose expressions cannot be broken down into property expressions also implements
+// this interface, so that it can evaluate its own constraints. The properties map holds the property values by name.
+type ConstraintEvaluatorPlugin interface {
+	ConstraintLanguagePlugin
+	IsSatisfiedBy(constraint string, properties map[string]interface{}) (bool, error)
+}
+
 // Global constraint language registry.
 type ConstraintLanguageRegistry map[string]ConstraintLanguagePlugin
 
@@ -51,6 +58,17 @@ func (d ConstraintLanguageRegistry) GetLanguageHandlerByOne(constraints interfac
 	return nil, errors.New(fmt.Sprintf("constraint language %v is not supported", constraints))
 }
 
+// Return the plugin that owns the input constraint if it evaluates its own constraints. Nil is returned
+// when the constraint is owned by a plugin that breaks it down into property expressions.
+func (d ConstraintLanguageRegistry) GetEvaluatorByOne(constraint string) ConstraintEvaluatorPlugin {
+	if p, err := d.GetLanguageHandlerByOne([]string{constraint}); err == nil {
+		if evaluator, ok := p.(ConstraintEvaluatorPlugin); ok {
+			return evaluator
+		}
+	}
+	return nil
+}
+
 // Utility methods that can be used by other parts of the system to ask the global registry about plugins.
 func (d ConstraintLanguageRegistry) HasPlugin(name string) bool {
 	if _, ok := d[name]; ok {
@@ -0,0 +1,535 @@
+package externalpolicy
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/open-horizon/anax/externalpolicy/plugin_registry"
+	"github.com/open-horizon/anax/i18n"
+	"github.com/open-horizon/anax/semanticversion"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The purpose of this file is to find the semantic problems in the properties and constraints of a policy,
+// the problems that Validate() does not catch because the policy is syntactically correct.
+
+// The severity of a lint issue. An error means that the policy cannot work as intended, a warning means
+// that it might not.
+const (
+	LINT_ERROR   = "error"
+	LINT_WARNING = "warning"
+)
+
+// The checks done by the policy linter.
+const (
+	LINT_UNSATISFIABLE      = "unsatisfiable_constraint"
+	LINT_TYPE_MISMATCH      = "type_mismatch"
+	LINT_UNKNOWN_PROPERTY   = "unknown_property"
+	LINT_BUILTIN_PROPERTY   = "builtin_property"
+	LINT_SERVICE_PRIORITIES = "service_version_priority"
+)
+
+// The maximum number of conjunctions a constraint expression is expanded into when looking for
+// unsatisfiable constraints. Larger expressions are not checked.
+const MAX_LINT_CONJUNCTIONS = 1024
+
+// A problem found in a policy.
+type LintIssue struct {
+	Severity string `json:"severity"`
+	Check    string `json:"check"`
+	Message  string `json:"message"`
+}
+
+func (l LintIssue) String() string {
+	return fmt.Sprintf("Severity: %v, Check: %v, Message: %v", l.Severity, l.Check, l.Message)
+}
+
+func NewLintIssue(severity string, check string, message string) LintIssue {
+	return LintIssue{Severity: severity, Check: check, Message: message}
+}
+
+// The types of the values that have been published for each property, keyed by property name. The numeric types
+// are all recorded as FLOAT_TYPE because the constraints compare them the same way.
+type PropertyCatalog map[string]map[string]bool
+
+// Create a catalog that contains the node built-in properties.
+func NewPropertyCatalog() PropertyCatalog {
+	c := PropertyCatalog{}
+	for _, name := range []string{PROP_NODE_CPU, PROP_NODE_MEMORY} {
+		c.addType(name, FLOAT_TYPE)
+	}
+	for _, name := range []string{PROP_NODE_ARCH, PROP_NODE_HARDWAREID, PROP_NODE_OS, PROP_NODE_K8S_VERSION} {
+		c.addType(name, STRING_TYPE)
+	}
+	for _, name := range []string{PROP_NODE_PRIVILEGED, PROP_NODE_CONTAINERIZED} {
+		c.addType(name, BOOLEAN_TYPE)
+	}
+	return c
+}
+
+// Add the given properties to the catalog. A string with commas is also recorded as a list because the
+// == operator matches it when it contains the value.
+func (c PropertyCatalog) Add(props PropertyList) {
+	for _, p := range props {
+		c.addType(p.Name, propertyValueType(p))
+		if s, ok := p.Value.(string); ok && strings.Contains(s, ",") {
+			c.addType(p.Name, LIST_TYPE)
+		}
+	}
+}
+
+// Return true unless the catalog proves that the property is never published as a list.
+func (c PropertyCatalog) mayBeList(name string) bool {
+	if c == nil {
+		return true
+	}
+	types, ok := c[name]
+	return !ok || types[LIST_TYPE]
+}
+
+func (c PropertyCatalog) addType(name string, valueType string) {
+	if _, ok := c[name]; !ok {
+		c[name] = map[string]bool{}
+	}
+	c[name][valueType] = true
+}
+
+// Return the type of the property value, the declared type is used when there is one.
+func propertyValueType(p Property) string {
+	switch p.Type {
+	case INTEGER_TYPE, FLOAT_TYPE:
+		return FLOAT_TYPE
+	case UNDECLARED_TYPE:
+		switch p.Value.(type) {
+		case float64, json.Number:
+			return FLOAT_TYPE
+		case bool:
+			return BOOLEAN_TYPE
+		default:
+			return STRING_TYPE
+		}
+	default:
+		return p.Type
+	}
+}
+
+// Check the properties of a policy. The read-only built-in properties are set by the agent, a policy that
+// sets them shadows the real value.
+func LintProperties(props PropertyList) []LintIssue {
+	msgPrinter := i18n.GetMessagePrinter()
+
+	issues := []LintIssue{}
+	for _, builtIn := range ListReadOnlyProperties() {
+		if props.HasProperty(builtIn) {
+			issues = append(issues, NewLintIssue(LINT_WARNING, LINT_BUILTIN_PROPERTY,
+				msgPrinter.Sprintf("The property %v shadows the built-in property of the same name, the value set by the agent will be used.", builtIn)))
+		}
+	}
+	return issues
+}
+
+// Check the constraints of a policy. The catalog holds the properties published by the policies the
+// constraints are evaluated against. When it is nil, the constraints are not checked against the published
+// properties. The constraints written in a language that evaluates its own expressions are not checked.
+func LintConstraints(ce *ConstraintExpression, catalog PropertyCatalog) []LintIssue {
+	msgPrinter := i18n.GetMessagePrinter()
+
+	issues := []LintIssue{}
+	if ce == nil || len(*ce) == 0 {
+		return issues
+	}
+
+	// the constraints that can be broken down into property expressions
+	textConstraints := ConstraintExpression{}
+	for _, constraint := range *ce {
+		if plugin_registry.ConstraintLanguagePlugins.GetEvaluatorByOne(constraint) == nil {
+			textConstraints = append(textConstraints, constraint)
+		}
+	}
+
+	if len(textConstraints) == 0 {
+		return issues
+	}
+
+	rp, err := RequiredPropertyFromConstraint(&textConstraints)
+	if err != nil {
+		return append(issues, NewLintIssue(LINT_ERROR, LINT_UNSATISFIABLE, msgPrinter.Sprintf("Unable to parse the constraints %v: %v", textConstraints, err)))
+	}
+
+	topMap := map[string]interface{}(*rp)
+
+	// the constraints are unsatisfiable when every conjunction of their disjunctive normal form is.
+	if conjunctions := disjunctiveNormalForm(&topMap); conjunctions != nil {
+		reasons := []string{}
+		allCertain := true
+		for _, conjunction := range conjunctions {
+			if reason, certain := conjunctionConflict(conjunction, catalog); reason == "" {
+				reasons = nil
+				break
+			} else {
+				reasons = append(reasons, reason)
+				allCertain = allCertain && certain
+			}
+		}
+		if len(reasons) != 0 && allCertain {
+			issues = append(issues, NewLintIssue(LINT_ERROR, LINT_UNSATISFIABLE,
+				msgPrinter.Sprintf("The constraints %v can never be satisfied: %v", textConstraints, strings.Join(reasons, "; "))))
+		} else if len(reasons) != 0 {
+			issues = append(issues, NewLintIssue(LINT_WARNING, LINT_UNSATISFIABLE,
+				msgPrinter.Sprintf("The constraints %v can only be satisfied by list properties: %v", textConstraints, strings.Join(reasons, "; "))))
+		}
+	}
+
+	// check each property expression against the published properties
+	if catalog != nil {
+		reported := map[string]bool{}
+		for _, prop := range propertyExpressions(&topMap) {
+			types, ok := catalog[prop.Name]
+			if !ok {
+				if _, isExistence := existenceOperators()[prop.Op]; !isExistence && !reported[prop.Name] {
+					issues = append(issues, NewLintIssue(LINT_WARNING, LINT_UNKNOWN_PROPERTY,
+						msgPrinter.Sprintf("The property %v is not published by any policy the constraints are checked against.", prop.Name)))
+					reported[prop.Name] = true
+				}
+			} else if mismatch := typeMismatch(prop, types); mismatch != "" {
+				issues = append(issues, NewLintIssue(LINT_ERROR, LINT_TYPE_MISMATCH, mismatch))
+			}
+		}
+	}
+
+	return issues
+}
+
+// Return the conjunctions of property expressions of the disjunctive normal form of the expression. Nil is
+// returned when there are more than MAX_LINT_CONJUNCTIONS conjunctions.
+func disjunctiveNormalForm(cop *map[string]interface{}) [][]PropertyExpression {
+	controlOp := getControlOperator(cop)
+	propArray := (*cop)[controlOp].([]interface{})
+
+	result := [][]PropertyExpression{}
+	if controlOp == OP_AND {
+		result = [][]PropertyExpression{{}}
+	}
+
+	for _, p := range propArray {
+		var sub [][]PropertyExpression
+		if prop := isPropertyExpression(p); prop != nil {
+			sub = [][]PropertyExpression{{*prop}}
+		} else if cop1 := isControlOp(p); cop1 != nil {
+			if sub = disjunctiveNormalForm(cop1); sub == nil {
+				return nil
+			}
+		}
+
+		if controlOp == OP_OR {
+			result = append(result, sub...)
+		} else {
+			product := [][]PropertyExpression{}
+			for _, left := range result {
+				for _, right := range sub {
+					conjunction := make([]PropertyExpression, 0, len(left)+len(right))
+					conjunction = append(append(conjunction, left...), right...)
+					product = append(product, conjunction)
+				}
+			}
+			result = product
+		}
+
+		if len(result) > MAX_LINT_CONJUNCTIONS {
+			return nil
+		}
+	}
+	return result
+}
+
+// Return all the property expressions in the expression.
+func propertyExpressions(cop *map[string]interface{}) []PropertyExpression {
+	props := []PropertyExpression{}
+	controlOp := getControlOperator(cop)
+	for _, p := range (*cop)[controlOp].([]interface{}) {
+		if prop := isPropertyExpression(p); prop != nil {
+			props = append(props, *prop)
+		} else if cop1 := isControlOp(p); cop1 != nil {
+			props = append(props, propertyExpressions(cop1)...)
+		}
+	}
+	return props
+}
+
+// Return the reason why the property expressions that are ANDed together can never be satisfied, or an
+// empty string if they can be. The reason is not certain when a list property could satisfy the expressions.
+func conjunctionConflict(conjunction []PropertyExpression, catalog PropertyCatalog) (string, bool) {
+	byName := map[string][]PropertyExpression{}
+	names := []string{}
+	for _, prop := range conjunction {
+		if prop.Op == "" || prop.Op == equalto {
+			prop.Op = doubleequalto
+		}
+		if _, ok := byName[prop.Name]; !ok {
+			names = append(names, prop.Name)
+		}
+		byName[prop.Name] = append(byName[prop.Name], prop)
+	}
+	sort.Strings(names)
+
+	possible := ""
+	for _, name := range names {
+		if reason, certain := propertyConflict(byName[name], catalog.mayBeList(name)); certain {
+			return reason, true
+		} else if possible == "" {
+			possible = reason
+		}
+	}
+	return possible, false
+}
+
+// Return the reason why the property expressions on the same property can never be satisfied together. The
+// reason is not certain when the property may be a list that satisfies the expressions.
+func propertyConflict(props []PropertyExpression, mayBeList bool) (string, bool) {
+	display := func(p PropertyExpression) string {
+		if _, ok := existenceOperators()[p.Op]; ok {
+			return fmt.Sprintf("%v %v", p.Name, p.Op)
+		}
+		return fmt.Sprintf("%v %v %v", p.Name, p.Op, p.Value)
+	}
+
+	// a property that must not exist cannot satisfy any other expression
+	for _, p1 := range props {
+		if p1.Op != notexists {
+			continue
+		}
+		for _, p2 := range props {
+			if p2.Op != notexists {
+				return fmt.Sprintf("%v conflicts with %v", display(p1), display(p2)), true
+			}
+		}
+	}
+
+	possible := ""
+	for i, p1 := range props {
+		for _, p2 := range props[i+1:] {
+			if !conflictingExpressions(p1, p2) && !conflictingExpressions(p2, p1) {
+				continue
+			}
+			reason := fmt.Sprintf("%v conflicts with %v", display(p1), display(p2))
+			if !mayBeList || !(listSatisfiable(p1, p2) || listSatisfiable(p2, p1)) {
+				return reason, true
+			} else if possible == "" {
+				possible = reason
+			}
+		}
+	}
+
+	// the numeric bounds must leave some room for a value
+	var lower, upper *PropertyExpression
+	for i, p := range props {
+		if _, ok := lintNumber(p.Value); !ok {
+			continue
+		}
+		switch p.Op {
+		case greaterthan, greaterthaneq, doubleequalto:
+			if lower == nil || boundIsTighter(p, *lower, true) {
+				lower = &props[i]
+			}
+		}
+		switch p.Op {
+		case lessthan, lessthaneq, doubleequalto:
+			if upper == nil || boundIsTighter(p, *upper, false) {
+				upper = &props[i]
+			}
+		}
+	}
+	if lower != nil && upper != nil {
+		l, _ := lintNumber(lower.Value)
+		u, _ := lintNumber(upper.Value)
+		if l > u || (l == u && (lower.Op == greaterthan || upper.Op == lessthan)) {
+			return fmt.Sprintf("%v conflicts with %v", display(*lower), display(*upper)), true
+		}
+	}
+
+	// the version ranges must intersect
+	var versRange *semanticversion.Version_Expression
+	var first *PropertyExpression
+	for i, p := range props {
+		value := removeQuotes(removeSpaces(fmt.Sprintf("%v", p.Value)))
+		if p.Op != isin || !semanticversion.IsVersionExpression(value) {
+			continue
+		}
+		vr, err := semanticversion.Version_Expression_Factory(value)
+		if err != nil {
+			continue
+		}
+		if versRange == nil {
+			versRange = vr
+			first = &props[i]
+		} else if err := versRange.IntersectsWith(vr); err != nil {
+			return fmt.Sprintf("%v conflicts with %v", display(*first), display(p)), true
+		} else if expr := versRange.Get_expression(); versRange.Get_start_version() == versRange.Get_end_version() && !(strings.HasPrefix(expr, "[") && strings.HasSuffix(expr, "]")) {
+			// IntersectsWith lets through a single version range that excludes the version, e.g. [2.0.0,2.0.0)
+			return fmt.Sprintf("%v conflicts with %v", display(*first), display(p)), true
+		}
+	}
+
+	return possible, false
+}
+
+// Return true if a value that satisfies the equality expression p1 can never satisfy p2.
+func conflictingExpressions(p1 PropertyExpression, p2 PropertyExpression) bool {
+	if p1.Op != doubleequalto {
+		return false
+	}
+
+	v1 := removeQuotes(removeSpaces(fmt.Sprintf("%v", p1.Value)))
+	v2 := removeQuotes(removeSpaces(fmt.Sprintf("%v", p2.Value)))
+	switch p2.Op {
+	case doubleequalto:
+		return !sameLintValue(v1, v2)
+	case notequalto:
+		return sameLintValue(v1, v2)
+	case isin:
+		if semanticversion.IsVersionExpression(v2) {
+			if !semanticversion.IsVersionString(v1) {
+				return false
+			}
+			vr, err := semanticversion.Version_Expression_Factory(v2)
+			if err != nil {
+				return false
+			}
+			within, err := vr.Is_within_range(v1)
+			return err == nil && !within
+		} else if semanticversion.IsVersionString(v2) {
+			return false
+		}
+		return !stringListContains(v1, v2)
+	case matches, startswith, endswith:
+		if _, ok := lintNumber(v1); ok {
+			return true
+		} else if _, err := strconv.ParseBool(v1); err == nil {
+			return true
+		}
+		return !stringMatchesPattern(v1, p2.Op, removeQuotes(fmt.Sprintf("%v", p2.Value)))
+	}
+	return false
+}
+
+// Return true if a list property can satisfy the conflicting expressions p1 and p2, because the == operator
+// matches a list that contains the value. A list holds strings, so it never equals a number or a boolean.
+func listSatisfiable(p1 PropertyExpression, p2 PropertyExpression) bool {
+	if p1.Op != doubleequalto {
+		return false
+	}
+
+	v1 := removeQuotes(removeSpaces(fmt.Sprintf("%v", p1.Value)))
+	v2 := removeQuotes(removeSpaces(fmt.Sprintf("%v", p2.Value)))
+	if !isLintString(v1) {
+		return false
+	}
+	switch p2.Op {
+	case doubleequalto:
+		return isLintString(v2)
+	case isin:
+		return !semanticversion.IsVersionExpression(v2)
+	case matches, startswith, endswith:
+		return true
+	}
+	return false
+}
+
+// Return true if the constraint value is neither a number nor a boolean.
+func isLintString(value string) bool {
+	if _, ok := lintNumber(value); ok {
+		return false
+	} else if _, err := strconv.ParseBool(value); err == nil {
+		return false
+	}
+	return true
+}
+
+// Return true if the 2 values are the same number, the same boolean or the same string.
+func sameLintValue(v1 string, v2 string) bool {
+	if n1, ok := lintNumber(v1); ok {
+		n2, ok := lintNumber(v2)
+		return ok && n1 == n2
+	} else if b1, err := strconv.ParseBool(v1); err == nil {
+		b2, err := strconv.ParseBool(v2)
+		return err == nil && b1 == b2
+	}
+	return v1 == v2
+}
+
+// Return true if the bound p1 is tighter than the bound p2. A lower bound is tighter when it is larger.
+func boundIsTighter(p1 PropertyExpression, p2 PropertyExpression, lowerBound bool) bool {
+	v1, _ := lintNumber(p1.Value)
+	v2, _ := lintNumber(p2.Value)
+	if v1 == v2 {
+		return p1.Op == greaterthan || p1.Op == lessthan
+	} else if lowerBound {
+		return v1 > v2
+	}
+	return v1 < v2
+}
+
+// Return the numeric value of a constraint value.
+func lintNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case string:
+		if f, err := strconv.ParseFloat(removeQuotes(removeSpaces(v)), 64); err == nil {
+			return f, true
+		}
+	}
+	return 0, false
+}
+
+// Return the reason why the property expression does not work with any of the types the property has been
+// published with, or an empty string if it works with at least one of them.
+func typeMismatch(prop PropertyExpression, types map[string]bool) string {
+	msgPrinter := i18n.GetMessagePrinter()
+
+	op := prop.Op
+	if op == "" || op == equalto {
+		op = doubleequalto
+	}
+	value := removeQuotes(removeSpaces(fmt.Sprintf("%v", prop.Value)))
+
+	if _, ok := existenceOperators()[op]; ok {
+		return ""
+	}
+
+	published := []string{}
+	for t := range types {
+		if typeAccepts(t, op, value) {
+			return ""
+		}
+		published = append(published, t)
+	}
+	sort.Strings(published)
+
+	return msgPrinter.Sprintf("The constraint '%v %v %v' cannot be satisfied by the property %v, it has been published with the type %v.", prop.Name, op, prop.Value, prop.Name, strings.Join(published, ", "))
+}
+
+// Return true if the operator and constraint value can be satisfied by a property of the given type.
+func typeAccepts(propType string, op string, value string) bool {
+	_, isNumber := lintNumber(value)
+	_, boolErr := strconv.ParseBool(value)
+
+	switch op {
+	case lessthan, greaterthan, lessthaneq, greaterthaneq:
+		return propType == FLOAT_TYPE
+	case matches, startswith, endswith:
+		return propType == STRING_TYPE || propType == VERSION_TYPE || propType == LIST_TYPE
+	case isin:
+		return propType == STRING_TYPE || propType == VERSION_TYPE || propType == LIST_TYPE
+	}
+
+	// == and !=
+	switch propType {
+	case FLOAT_TYPE:
+		return isNumber
+	case BOOLEAN_TYPE:
+		return boolErr == nil
+	}
+	return true
+}
@@ -0,0 +1,155 @@
+//go:build unit
+// +build unit
+
+package externalpolicy
+
+import (
+	_ "github.com/open-horizon/anax/externalpolicy/cel_language"
+	_ "github.com/open-horizon/anax/externalpolicy/text_language"
+	"testing"
+)
+
+func lintChecks(issues []LintIssue) map[string]int {
+	checks := map[string]int{}
+	for _, issue := range issues {
+		checks[issue.Check]++
+	}
+	return checks
+}
+
+func Test_LintConstraints_unsatisfiable(t *testing.T) {
+
+	unsatisfiable := []string{
+		"a == 1 && a == 2",
+		"a == 1 && a != 1",
+		"cores > 4 && cores < 2",
+		"cores >= 4 && cores < 4",
+		"location == \"us-east\" && location in \"eu-west,eu-north\"",
+		"location == us-east && location startsWith eu-",
+		"gpu !exists && gpu == true",
+		"version in [1.0.0,2.0.0) && version in [2.0.0,3.0.0)",
+		"version == 3.0.0 && version in [1.0.0,2.0.0)",
+		"(a == 1 || a == 2) && a == 3",
+	}
+	for _, c := range unsatisfiable {
+		ce := ConstraintExpression{c}
+		if checks := lintChecks(LintConstraints(&ce, nil)); checks[LINT_UNSATISFIABLE] != 1 {
+			t.Errorf("The constraint %v should be unsatisfiable, checks: %v", c, checks)
+		}
+	}
+
+	// each constraint is ANDed with the others
+	ce := ConstraintExpression{"a == 1", "a == 2"}
+	if checks := lintChecks(LintConstraints(&ce, nil)); checks[LINT_UNSATISFIABLE] != 1 {
+		t.Errorf("The constraints %v should be unsatisfiable, checks: %v", ce, checks)
+	}
+
+	satisfiable := []string{
+		"a == 1 || a == 2",
+		"(a == 1 || a == 2) && a != 1",
+		"cores >= 4 && cores <= 4",
+		"location == us-east && location in \"us-east,eu-west\"",
+		"location == us-east && location matches \"^us-\"",
+		"gpu exists && gpu == true",
+		"version in [1.0.0,2.0.0) && version in [1.5.0,3.0.0)",
+		"version == 1.2.0 && version in [1.0.0,2.0.0)",
+		"a == 1 && b == 2",
+		"cel: props.a == 1 && props.a == 2",
+	}
+	for _, c := range satisfiable {
+		ce := ConstraintExpression{c}
+		if issues := LintConstraints(&ce, nil); len(issues) != 0 {
+			t.Errorf("The constraint %v should not have issues, but has: %v", c, issues)
+		}
+	}
+}
+
+func Test_LintConstraints_catalog(t *testing.T) {
+
+	catalog := NewPropertyCatalog()
+	catalog.Add(PropertyList{
+		*Property_Factory("location", "us-east"),
+		*Property_Factory("cores", float64(4)),
+		*Property_Factory("gpu", true),
+	})
+
+	// the built-in properties are in the catalog
+	ce := ConstraintExpression{"openhorizon.memory >= 1024 && openhorizon.arch == amd64 && openhorizon.containerized == false"}
+	if issues := LintConstraints(&ce, catalog); len(issues) != 0 {
+		t.Errorf("The constraint %v should not have issues, but has: %v", ce, issues)
+	}
+
+	// properties that are not published by any node
+	ce = ConstraintExpression{"zone == b2 || zone == c3", "gpu exists", "missing !exists"}
+	if checks := lintChecks(LintConstraints(&ce, catalog)); len(checks) != 1 || checks[LINT_UNKNOWN_PROPERTY] != 1 {
+		t.Errorf("The constraints %v should have 1 unknown property, checks: %v", ce, checks)
+	}
+
+	// type mismatches
+	for _, c := range []string{"location > 4", "cores matches \"^4\"", "gpu == yes", "cores == four", "gpu in [1.0.0,2.0.0)"} {
+		ce := ConstraintExpression{c}
+		if checks := lintChecks(LintConstraints(&ce, catalog)); len(checks) != 1 || checks[LINT_TYPE_MISMATCH] != 1 {
+			t.Errorf("The constraint %v should have a type mismatch, checks: %v", c, checks)
+		}
+	}
+
+	// a property published with several types accepts the operators of any of them
+	catalog.Add(PropertyList{*Property_Factory("location", float64(3))})
+	ce = ConstraintExpression{"location > 2"}
+	if issues := LintConstraints(&ce, catalog); len(issues) != 0 {
+		t.Errorf("The constraint %v should not have issues, but has: %v", ce, issues)
+	}
+}
+
+func Test_LintConstraints_lists(t *testing.T) {
+
+	// == matches a list property that contains the value, so the conflicts between strings are only certain
+	// when the catalog proves the property is not a list
+	listConflicts := []string{
+		"zones == a1 && zones == b2",
+		"zones == a1 && zones in \"b2,c3\"",
+		"zones == a1 && zones startsWith b",
+	}
+	for _, c := range listConflicts {
+		ce := ConstraintExpression{c}
+		if issues := LintConstraints(&ce, nil); len(issues) != 1 || issues[0].Check != LINT_UNSATISFIABLE || issues[0].Severity != LINT_WARNING {
+			t.Errorf("The constraint %v should have an unsatisfiable warning, but has: %v", c, issues)
+		}
+	}
+
+	catalog := NewPropertyCatalog()
+	catalog.Add(PropertyList{*Property_Factory("zones", "a1")})
+	for _, c := range listConflicts {
+		ce := ConstraintExpression{c}
+		if issues := LintConstraints(&ce, catalog); len(issues) != 1 || issues[0].Check != LINT_UNSATISFIABLE || issues[0].Severity != LINT_ERROR {
+			t.Errorf("The constraint %v should have an unsatisfiable error, but has: %v", c, issues)
+		}
+	}
+
+	// a string with commas is a list for the == operator
+	catalog.Add(PropertyList{*Property_Factory("zones", "a1,b2")})
+	ce := ConstraintExpression{"zones == a1 && zones == b2"}
+	if issues := LintConstraints(&ce, catalog); len(issues) != 1 || issues[0].Severity != LINT_WARNING {
+		t.Errorf("The constraint %v should have an unsatisfiable warning, but has: %v", ce, issues)
+	}
+
+	// the conflicts that no list can satisfy are still errors
+	for _, c := range []string{"zones == a1 && zones != a1", "a == 1 && a == 2", "zones == a1 && zones == b2 && zones !exists"} {
+		ce := ConstraintExpression{c}
+		if issues := LintConstraints(&ce, nil); len(issues) != 1 || issues[0].Check != LINT_UNSATISFIABLE || issues[0].Severity != LINT_ERROR {
+			t.Errorf("The constraint %v should have an unsatisfiable error, but has: %v", c, issues)
+		}
+	}
+}
+
+func Test_LintProperties(t *testing.T) {
+
+	props := PropertyList{
+		*Property_Factory(PROP_NODE_ARCH, "amd64"),
+		*Property_Factory(PROP_NODE_PRIVILEGED, true),
+		*Property_Factory("location", "us-east"),
+	}
+	if checks := lintChecks(LintProperties(props)); len(checks) != 1 || checks[LINT_BUILTIN_PROPERTY] != 1 {
+		t.Errorf("The properties %v should shadow 1 built-in property, checks: %v", props, checks)
+	}
+}
@@ -203,7 +203,8 @@ func (self PropertyList) GetProperty(name string) (Property, error) {
 	return Property{}, fmt.Errorf("Error: property %s not found in list %v.", name, self)
 }
 
-// Validate will return an error if any property in the list has an invalid format or a value that does not match a declared type
+// Validate will return an error if any property in the list has an invalid format or a value that does not match a declared type.
+// When a property schema is set, the properties are also checked against it.
 func (self *PropertyList) Validate() error {
 	// get message printer because this function is called by CLI
 	msgPrinter := i18n.GetMessagePrinter()
@@ -253,7 +254,9 @@ func (self *PropertyList) Validate() error {
 			return fmt.Errorf(msgPrinter.Sprintf("Property %s has invalid value type %T", property.Name, actualType))
 		}
 	}
-	return nil
+
+	// check the properties against the property schema of the organization, if one is set
+	return checkPropertySchema(*self)
 }
 
 // IsVersionString will return true if the input version string is a valid version according to the version string schema outlined in anax/policy/version.go.
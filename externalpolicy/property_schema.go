@@ -0,0 +1,264 @@
+package externalpolicy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/open-horizon/anax/i18n"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// The purpose of this file is to describe the properties an organization uses in its policies, so that a
+// misspelled property name or a value of the wrong type is caught when the policy is validated instead of
+// silently breaking the matching of policies.
+
+// How the property schema is enforced.
+const (
+	SCHEMA_ENFORCE_WARNING = "warning"
+	SCHEMA_ENFORCE_ERROR   = "error"
+)
+
+// The short form of the list of strings type that can be used in a property schema.
+const SCHEMA_LIST_TYPE = "list"
+
+// The declaration of a property in a property schema.
+type PropertyDefinition struct {
+	Type          string        `json:"type"`                    // one of the property types, "list" can be used for "list of strings"
+	AllowedValues []interface{} `json:"allowedValues,omitempty"` // the values the property can have, any value of the type if empty
+	Description   string        `json:"description,omitempty"`
+}
+
+func (p PropertyDefinition) String() string {
+	return fmt.Sprintf("Type: %v, AllowedValues: %v, Description: %v", p.Type, p.AllowedValues, p.Description)
+}
+
+// The properties an organization uses in its node, service and deployment policies. The built-in properties
+// do not need to be declared.
+type PropertySchema struct {
+	Owner       string                        `json:"owner,omitempty"`
+	Label       string                        `json:"label"`
+	Description string                        `json:"description"`
+	Org         string                        `json:"org,omitempty"`         // the organization the schema applies to
+	Enforcement string                        `json:"enforcement,omitempty"` // warning (default) or error
+	Properties  map[string]PropertyDefinition `json:"properties"`
+	LastUpdated string                        `json:"lastUpdated,omitempty"`
+}
+
+func (s PropertySchema) String() string {
+	return fmt.Sprintf("Owner: %v, Label: %v, Description: %v, Org: %v, Enforcement: %v, Properties: %v, LastUpdated: %v",
+		s.Owner, s.Label, s.Description, s.Org, s.Enforcement, s.Properties, s.LastUpdated)
+}
+
+// Validate the schema and normalize the short form of the types.
+func (s *PropertySchema) Validate() error {
+	// get message printer because this function is called by CLI
+	msgPrinter := i18n.GetMessagePrinter()
+
+	if s.Enforcement == "" {
+		s.Enforcement = SCHEMA_ENFORCE_WARNING
+	} else if s.Enforcement != SCHEMA_ENFORCE_WARNING && s.Enforcement != SCHEMA_ENFORCE_ERROR {
+		return fmt.Errorf(msgPrinter.Sprintf("Invalid enforcement %v in the property schema. The valid values are %v and %v.", s.Enforcement, SCHEMA_ENFORCE_WARNING, SCHEMA_ENFORCE_ERROR))
+	}
+
+	for name, def := range s.Properties {
+		if def.Type == SCHEMA_LIST_TYPE {
+			def.Type = LIST_TYPE
+		}
+		if def.Type == UNDECLARED_TYPE || !isValidPropertyType(def.Type) {
+			return fmt.Errorf(msgPrinter.Sprintf("Property %v has invalid type %v in the property schema. Allowed property types are: version, string, int, boolean, float, and list.", name, def.Type))
+		}
+		for _, v := range def.AllowedValues {
+			if !valueHasType(v, def.Type) {
+				return fmt.Errorf(msgPrinter.Sprintf("The allowed value %v of property %v is not of type %v.", v, name, def.Type))
+			}
+		}
+		s.Properties[name] = def
+	}
+	return nil
+}
+
+// Return the names of the declared properties in alphabetical order.
+func (s *PropertySchema) PropertyNames() []string {
+	names := make([]string, 0, len(s.Properties))
+	for name, _ := range s.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Return the problems with the properties in the list. A property must be declared in the schema, have the
+// declared type and, if the schema restricts them, one of the allowed values.
+func (s *PropertySchema) Check(props PropertyList) []string {
+	// get message printer because this function is called by CLI
+	msgPrinter := i18n.GetMessagePrinter()
+
+	problems := []string{}
+	for _, prop := range props {
+		if strings.HasPrefix(prop.Name, "openhorizon.") {
+			continue
+		}
+
+		def, ok := s.Properties[prop.Name]
+		if !ok {
+			if similar := s.similarName(prop.Name); similar != "" {
+				problems = append(problems, msgPrinter.Sprintf("Property %v is not declared in the property schema, did you mean %v?", prop.Name, similar))
+			} else {
+				problems = append(problems, msgPrinter.Sprintf("Property %v is not declared in the property schema.", prop.Name))
+			}
+			continue
+		}
+
+		if (prop.Type != UNDECLARED_TYPE && prop.Type != def.Type) || !valueHasType(prop.Value, def.Type) {
+			problems = append(problems, msgPrinter.Sprintf("Property %v with value %v is not of type %v declared in the property schema.", prop.Name, prop.Value, def.Type))
+			continue
+		}
+
+		if len(def.AllowedValues) != 0 {
+			values := []string{fmt.Sprintf("%v", prop.Value)}
+			if def.Type == LIST_TYPE {
+				values = strings.Split(fmt.Sprintf("%v", prop.Value), ",")
+			}
+			for _, v := range values {
+				if !def.allows(strings.TrimSpace(v)) {
+					problems = append(problems, msgPrinter.Sprintf("Property %v has value %v, the allowed values are %v.", prop.Name, strings.TrimSpace(v), def.AllowedValues))
+				}
+			}
+		}
+	}
+	return problems
+}
+
+// Check the properties against the schema. The problems are returned as an error when the schema is enforced
+// as errors, otherwise they are returned as warnings.
+func (s *PropertySchema) CheckProperties(props PropertyList) ([]string, error) {
+	problems := s.Check(props)
+	if len(problems) == 0 {
+		return nil, nil
+	} else if s.Enforcement == SCHEMA_ENFORCE_ERROR {
+		return nil, errors.New(strings.Join(problems, " "))
+	}
+	return problems, nil
+}
+
+// Return the declared property name that is at most 2 edits away from the given name.
+func (s *PropertySchema) similarName(name string) string {
+	best := ""
+	bestDistance := 3
+	for _, declared := range s.PropertyNames() {
+		if d := editDistance(strings.ToLower(name), strings.ToLower(declared)); d < bestDistance {
+			best = declared
+			bestDistance = d
+		}
+	}
+	return best
+}
+
+func (p PropertyDefinition) allows(value string) bool {
+	for _, allowed := range p.AllowedValues {
+		if fmt.Sprintf("%v", allowed) == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Return true if the property value can have the given type.
+func valueHasType(value interface{}, propType string) bool {
+	switch v := value.(type) {
+	case bool:
+		return propType == BOOLEAN_TYPE
+	case float64:
+		return propType == FLOAT_TYPE || (propType == INTEGER_TYPE && float64(int64(v)) == v)
+	case json.Number:
+		if propType == INTEGER_TYPE {
+			_, err := v.Int64()
+			return err == nil
+		}
+		return propType == FLOAT_TYPE
+	case string:
+		if propType == VERSION_TYPE {
+			return IsVersionString(v)
+		}
+		return propType == STRING_TYPE || propType == LIST_TYPE
+	}
+	return false
+}
+
+// The number of single character insertions, deletions or substitutions to change a into b.
+func editDistance(a string, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(minInt(prev[j]+1, cur[j-1]+1), prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// The property schema that PropertyList.Validate checks the properties against. It is set by the processes
+// that validate the policies of a single organization, like the CLI. The agent and the agbot serve more than
+// one organization, they check the properties with CheckProperties against the schema of the policy's organization.
+var propertySchema *PropertySchema
+var schemaWarningHandler func(msg string)
+var schemaLock sync.Mutex
+
+// Set the property schema that the properties are validated against. The warning handler is called with
+// the problems found when the schema is not enforced as errors. A nil schema turns the check off.
+func SetPropertySchema(schema *PropertySchema, warningHandler func(msg string)) {
+	schemaLock.Lock()
+	defer schemaLock.Unlock()
+
+	propertySchema = schema
+	schemaWarningHandler = warningHandler
+}
+
+func GetPropertySchema() *PropertySchema {
+	schemaLock.Lock()
+	defer schemaLock.Unlock()
+
+	return propertySchema
+}
+
+// Check the properties against the property schema if there is one.
+func checkPropertySchema(props PropertyList) error {
+	schemaLock.Lock()
+	schema := propertySchema
+	warningHandler := schemaWarningHandler
+	schemaLock.Unlock()
+
+	if schema == nil {
+		return nil
+	}
+
+	warnings, err := schema.CheckProperties(props)
+	if err != nil {
+		return err
+	}
+
+	if warningHandler != nil {
+		for _, warning := range warnings {
+			warningHandler(warning)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+//go:build unit
+// +build unit
+
+package externalpolicy
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func getTestPropertySchema(t *testing.T, enforcement string) *PropertySchema {
+	schemaJson := `{
+		"label": "myorg properties",
+		"description": "",
+		"org": "myorg",
+		"enforcement": "` + enforcement + `",
+		"properties": {
+			"location": {"type": "string", "allowedValues": ["us-east", "eu-west"]},
+			"cores": {"type": "int"},
+			"firmware": {"type": "version"},
+			"zones": {"type": "list", "allowedValues": ["a1", "b2", "c3"]},
+			"gpu": {"type": "boolean"}
+		}
+	}`
+
+	schema := new(PropertySchema)
+	if err := json.Unmarshal([]byte(schemaJson), schema); err != nil {
+		t.Fatalf("Error unmarshalling the property schema: %v", err)
+	} else if err := schema.Validate(); err != nil {
+		t.Fatalf("The property schema should be valid but got: %v", err)
+	}
+	return schema
+}
+
+func Test_PropertySchema_Validate(t *testing.T) {
+
+	schema := getTestPropertySchema(t, "")
+	if schema.Enforcement != SCHEMA_ENFORCE_WARNING {
+		t.Errorf("The default enforcement should be %v but got %v", SCHEMA_ENFORCE_WARNING, schema.Enforcement)
+	} else if schema.Properties["zones"].Type != LIST_TYPE {
+		t.Errorf("The list type should have been normalized but got %v", schema.Properties["zones"].Type)
+	} else if names := schema.PropertyNames(); len(names) != 5 || names[0] != "cores" {
+		t.Errorf("The property names should be sorted but got %v", names)
+	}
+
+	badSchemas := []PropertySchema{
+		{Enforcement: "never"},
+		{Properties: map[string]PropertyDefinition{"location": {Type: "text"}}},
+		{Properties: map[string]PropertyDefinition{"location": {Type: ""}}},
+		{Properties: map[string]PropertyDefinition{"cores": {Type: INTEGER_TYPE, AllowedValues: []interface{}{"four"}}}},
+	}
+	for _, s := range badSchemas {
+		if err := s.Validate(); err == nil {
+			t.Errorf("The property schema %v should not be valid", s)
+		}
+	}
+}
+
+func Test_PropertySchema_Check(t *testing.T) {
+
+	schema := getTestPropertySchema(t, SCHEMA_ENFORCE_ERROR)
+
+	good := PropertyList{
+		*Property_Factory("location", "us-east"),
+		*Property_Factory("cores", float64(4)),
+		*Property_Factory("firmware", "1.2.0"),
+		*Property_Factory("zones", "a1, c3"),
+		*Property_Factory("gpu", true),
+		*Property_Factory(PROP_NODE_ARCH, "amd64"),
+	}
+	if problems := schema.Check(good); len(problems) != 0 {
+		t.Errorf("The properties should match the schema but got: %v", problems)
+	}
+
+	bad := []Property{
+		*Property_Factory("locaton", "us-east"),
+		*Property_Factory("location", "ap-south"),
+		*Property_Factory("cores", float64(4.5)),
+		*Property_Factory("firmware", "latest"),
+		*Property_Factory("zones", "a1,d4"),
+		*Property_Factory("gpu", "yes"),
+		{Name: "cores", Value: "4", Type: STRING_TYPE},
+	}
+	for _, p := range bad {
+		if problems := schema.Check(PropertyList{p}); len(problems) != 1 {
+			t.Errorf("The property %v should have 1 problem but got: %v", p, problems)
+		}
+	}
+
+	// a misspelled name suggests the declared one
+	if problems := schema.Check(PropertyList{*Property_Factory("locaton", "us-east")}); len(problems) != 1 || !strings.Contains(problems[0], "did you mean location") {
+		t.Errorf("The problem should suggest the property location but got: %v", problems)
+	}
+}
+
+func Test_PropertyList_Validate_schema(t *testing.T) {
+
+	defer SetPropertySchema(nil, nil)
+
+	props := PropertyList{*Property_Factory("locaton", "us-east")}
+
+	// the properties are only checked when there is a schema
+	if err := props.Validate(); err != nil {
+		t.Errorf("The properties should be valid without a schema but got: %v", err)
+	}
+
+	// warnings
+	warnings := []string{}
+	SetPropertySchema(getTestPropertySchema(t, SCHEMA_ENFORCE_WARNING), func(msg string) { warnings = append(warnings, msg) })
+	if err := props.Validate(); err != nil {
+		t.Errorf("The schema should only warn but got: %v", err)
+	} else if len(warnings) != 1 {
+		t.Errorf("There should be 1 warning but got: %v", warnings)
+	}
+
+	// errors
+	SetPropertySchema(getTestPropertySchema(t, SCHEMA_ENFORCE_ERROR), nil)
+	if err := props.Validate(); err == nil {
+		t.Errorf("The schema should have returned an error")
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/open-horizon/anax/externalpolicy/plugin_registry"
 	"github.com/open-horizon/anax/i18n"
 	"github.com/open-horizon/anax/semanticversion"
+	"regexp"
 	"strconv"
 	"strings"
 )
@@ -114,7 +115,7 @@ func (p *TextConstraintLanguagePlugin) GetNextExpression(expression string) (str
 		}
 
 		nextRune = nextToken.Type
-		if nextRune != def["OpEq"] && nextRune != def["OpComp"] && nextRune != def["OpIn"] {
+		if nextRune != def["OpEq"] && nextRune != def["OpComp"] && nextRune != def["OpIn"] && nextRune != def["OpMatch"] && nextRune != def["OpExists"] {
 			if len(name) > 3 && name[len(name)-2:] == "in" {
 				op = "in"
 				opType = def["in"]
@@ -128,6 +129,12 @@ func (p *TextConstraintLanguagePlugin) GetNextExpression(expression string) (str
 		if op == "" {
 			op = nextToken.Value
 			opType = nextRune
+
+			// the existence operators do not take a value.
+			if opType == def["OpExists"] {
+				return fmt.Sprintf("%v\a%v\a", name, strings.TrimSpace(op)), strings.Replace(expression, fmt.Sprintf("%v%v", name, op), "", 1), nil
+			}
+
 			nextToken, err = lex.Next()
 			if err != nil {
 				return "", expression, fmt.Errorf("Unrecognized token found: %v", err)
@@ -135,7 +142,7 @@ func (p *TextConstraintLanguagePlugin) GetNextExpression(expression string) (str
 			nextRune = nextToken.Type
 		}
 
-		if nextRune != def["Str"] && nextRune != def["InStr"] && nextRune != def["QuoteStr"] && nextRune != def["ListStr"] && nextRune != def["Vers"] && nextRune != def["VersRange"] && nextRune != def["Num"] {
+		if nextRune != def["Str"] && nextRune != def["InStr"] && nextRune != def["QuoteStr"] && nextRune != def["ListStr"] && nextRune != def["Vers"] && nextRune != def["VersRange"] && nextRune != def["Num"] && nextRune != def["PatternStr"] {
 			return "", expression, fmt.Errorf("Invalid property value. %v%v%v", name, op, nextToken.Value)
 		}
 		if val == "" {
@@ -193,6 +200,8 @@ func (p *TextConstraintLanguagePlugin) GetNextOperator(expression string) (strin
 // 4. for string types, a quoted string, inside which is a list of comma separated strings provide acceptable values
 // 5. string values that contain spaces must be quoted
 // 6. for the version type, supported values are a single version or a range of versions in the semantic version format (the same as used for service verions). The == operator implies that the value is a single version. The 'in' operator treats the value as a version range. As with service versions, the version 1.0.0 when treated as a version range is equivalent to the explicit range [1.0.0,INFINITY).
+// 7. for string types, the 'matches' operator takes a RE2 regular expression, 'startsWith' and 'endsWith' take a prefix and a suffix. A quoted value that contains regular expression characters can only be used with these operators.
+// 8. the 'exists' and '!exists' operators do not take a value, they check if the property is defined or not.
 
 // This function checks that the operator is valid for the specified value and validates version ranges with the semanticversion Factory function
 // Returns a property expression struct with numerical values as float64
@@ -225,6 +234,22 @@ func validOpValuePair(name string, op string, opType rune, val interface{}, valT
 			}
 		}
 	}
+	if lexMap["OpMatch"] == opType {
+		if lexMap["VersRange"] == valType {
+			return fmt.Errorf("Version range can only use operator 'in'.")
+		}
+		if strings.TrimSpace(op) == "matches" {
+			pattern := strings.TrimSpace(val.(string))
+			if len(pattern) > 1 && strings.HasPrefix(pattern, "\"") && strings.HasSuffix(pattern, "\"") {
+				pattern = pattern[1 : len(pattern)-1]
+			}
+			if _, err := regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("The value %v of the 'matches' operator is not a valid regular expression: %v", val, err)
+			}
+		}
+	} else if lexMap["PatternStr"] == valType {
+		return fmt.Errorf("The value %v can only be used with the operators 'matches', 'startsWith' and 'endsWith'.", val)
+	}
 
 	return nil
 }
@@ -247,6 +272,9 @@ func getLexer() lexer.Definition {
 	  AndOp = whitespace {whitespace} ("AND" | "&&") whitespace {whitespace} .
 	  OrOp = whitespace {whitespace} ("OR" | "||") whitespace {whitespace} .
 
+	  OpMatch = whitespace {whitespace} ("matches" | "startsWith" | "endsWith") whitespace {whitespace} .
+	  OpExists = whitespace {whitespace} ["!"] "exists" .
+
 		OpComp =  {whitespace} ( ["="] (">" | "<") ["="] ) {whitespace} .
 		OpIn =  {whitespace} "in" {whitespace} .
 	  OpEq =  {whitespace}  ( "!=" | "="["="] )  {whitespace} .
@@ -262,6 +290,7 @@ func getLexer() lexer.Definition {
 	  Str =  {whitespace} (alphanumeric | "_" | "-" | "/" | "!" | "?" | "+" | "~" | "'" | ".") {alphanumeric | "_" | "-" | "/" | "!" | "?" | "+" | "~" | "'" | "."} .
 	  QuoteStr = {whitespace} "\x22" (alphanumeric  | "_" | "-" |  "/" | "!" | "?" | "+" | "~" | "." | "'" | " " | "\t") {alphanumeric | "_" | "-" |  "/" | "!" | "?" | "+" | "~" | "." | "'" | " " | "\t" } "\x22" .
 		ListStr = {whitespace} "\x22" (alphanumeric  | "_" | "-" |  "/" | "!" | "?" | "+" | "~" | "." | "'" | "," | " " | "\t") {alphanumeric | "_" | "-" |  "/" | "!" | "?" | "+" | "~" | "." | "'" | "," | " " | "\t" } "\x22" .
+	  PatternStr = {whitespace} "\x22" (alpha | "\x20"…"\x21" | "\x23"…"\x7E" | "\t") {alpha | "\x20"…"\x21" | "\x23"…"\x7E" | "\t"} "\x22" .
 
 
 	  Unused = digit .`))
@@ -365,3 +365,45 @@ func Test_GetNextExpression_Succeed(t *testing.T) {
 	}
 
 }
+
+func Test_Validate_Succeed_New_Operators(t *testing.T) {
+
+	// regex, prefix, suffix, existence and version range operators
+	textConstraintLanguagePlugin := NewTextConstraintLanguagePlugin()
+	constraintStrings := []string{
+		"location matches \"^us-(east|west)-[0-9]+$\" && firmware in [1.2.0,2.0.0)",
+		"location startsWith us-east OR location endsWith \"-1\"",
+		"gpu exists AND (legacy !exists || location matches eu)",
+		"(gpu exists)",
+	}
+
+	validated, _, err := textConstraintLanguagePlugin.Validate(interface{}(constraintStrings))
+	if validated == false {
+		t.Errorf("Should validate successfully but not, err: %v", err)
+	} else if err != nil {
+		t.Errorf("Should validate without err, but returned err: %v", err)
+	}
+
+	// the existence operators do not take a value
+	if exp, rem, err := textConstraintLanguagePlugin.GetNextExpression("gpu exists AND legacy !exists"); err != nil {
+		t.Errorf("Error parsing constraint expression with GetNextExpression: %v", err)
+	} else if exp != "gpu\aexists\a" || rem != " AND legacy !exists" {
+		t.Errorf("Wrong expression %q or remainder %q", exp, rem)
+	}
+}
+
+func Test_Validate_Failed_New_Operators(t *testing.T) {
+
+	textConstraintLanguagePlugin := NewTextConstraintLanguagePlugin()
+	for _, constraint := range []string{
+		"location matches \"^us-(east\"",    // invalid regular expression
+		"location == \"^us-.*\"",            // regular expression characters can only be used with the pattern operators
+		"firmware startsWith [1.2.0,2.0.0)", // version range can only be used with 'in'
+		"location matches",                  // the pattern operators need a value
+		"gpu exists true",                   // the existence operator does not take a value
+	} {
+		if validated, _, err := textConstraintLanguagePlugin.Validate(interface{}([]string{constraint})); validated == true || err == nil {
+			t.Errorf("Validation of %v should fail but did not", constraint)
+		}
+	}
+}
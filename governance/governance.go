@@ -843,6 +843,12 @@ func (w *GovernanceWorker) CommandHandler(command worker.Command) bool {
 		deleteMessage := true
 		protocolMsg := cmd.Msg.ProtocolMessage()
 
+		// Service log requests are not agreement protocol messages.
+		if req := exchange.ExtractServiceLogRequest(protocolMsg); req != nil {
+			w.handleServiceLogRequest(exchangeMsg, req)
+			return true
+		}
+
 		// Pull the agreement protocol out of the message
 		if msgProtocol, err := abstractprotocol.ExtractProtocol(protocolMsg); err != nil {
 			glog.Errorf(logString(fmt.Sprintf("unable to extract agreement protocol name from message %v", protocolMsg)))
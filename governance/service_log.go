@@ -0,0 +1,67 @@
+package governance
+
+import (
+	"fmt"
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/container"
+	"github.com/open-horizon/anax/cutil"
+	"github.com/open-horizon/anax/exchange"
+	"github.com/open-horizon/anax/persistence"
+	"time"
+)
+
+// Handle a request from an agbot for the recent log lines of a service. The lines are sent back to the agbot that asked
+// for them, encrypted with its public key, and the request is deleted.
+func (w *GovernanceWorker) handleServiceLogRequest(msg *exchange.DeviceMessage, req *exchange.ServiceLogRequest) {
+
+	glog.V(3).Infof(logString(fmt.Sprintf("received service log request %v from %v", req, msg.AgbotId)))
+
+	resp := w.getServiceLogs(req)
+
+	myPubKey, myPrivKey, _ := exchange.GetKeys("")
+	targetURL := w.GetExchangeURL() + "orgs/" + exchange.GetOrg(msg.AgbotId) + "/agbots/" + exchange.GetId(msg.AgbotId) + "/msgs"
+	if err := exchange.PostEncryptedMessage(w.limitedRetryEC.GetHTTPFactory(), targetURL, w.GetExchangeId(), w.GetExchangeToken(), myPubKey, myPrivKey, msg.AgbotPubKey, resp, w.Config.Edge.ExchangeMessageTTL); err != nil {
+		glog.Errorf(logString(fmt.Sprintf("unable to send service log response %v to %v, error %v", resp, msg.AgbotId, err)))
+	} else {
+		glog.V(3).Infof(logString(fmt.Sprintf("sent service log response %v to %v", resp, msg.AgbotId)))
+	}
+
+	if err := w.deleteMessage(msg); err != nil {
+		glog.Errorf(logString(fmt.Sprintf("error deleting exchange message %v, error %v", msg.MsgId, err)))
+	}
+}
+
+// Find the running instance of the requested service and return its recent log lines.
+func (w *GovernanceWorker) getServiceLogs(req *exchange.ServiceLogRequest) *exchange.ServiceLogResponse {
+
+	since, err := exchange.ParseServiceLogSince(req.Since, time.Now())
+	if err != nil {
+		return exchange.NewServiceLogResponse(req.RequestId, "", nil, err.Error())
+	}
+
+	tail := req.Tail
+	if tail <= 0 {
+		tail = exchange.SERVICE_LOG_DEFAULT_TAIL
+	} else if tail > exchange.SERVICE_LOG_MAX_TAIL {
+		tail = exchange.SERVICE_LOG_MAX_TAIL
+	}
+
+	msinsts, err := persistence.FindMicroserviceInstances(w.db, []persistence.MIFilter{persistence.UnarchivedMIFilter()})
+	if err != nil {
+		return exchange.NewServiceLogResponse(req.RequestId, "", nil, fmt.Sprintf("unable to read service instances, error %v", err))
+	}
+
+	org, url := cutil.SplitOrgSpecUrl(req.Service)
+	for _, msinst := range msinsts {
+		if msinst.SpecRef != url || (org != "" && msinst.Org != org) || (req.Version != "" && msinst.Version != req.Version) {
+			continue
+		} else if lines, found := container.GetServiceLogs(msinst.GetKey(), req.Container, since, tail); found {
+			return exchange.NewServiceLogResponse(req.RequestId, msinst.GetKey(), lines, "")
+		}
+	}
+
+	if req.Container != "" {
+		return exchange.NewServiceLogResponse(req.RequestId, "", nil, fmt.Sprintf("container %v of service %v is not running on the node", req.Container, req.Service))
+	}
+	return exchange.NewServiceLogResponse(req.RequestId, "", nil, fmt.Sprintf("service %v is not running on the node", req.Service))
+}
@@ -250,14 +250,15 @@ func GetContainerStatus(deployment string, key string, infrastructure bool, cont
 			container_status.Name = serviceName
 			container_status.Image = s_details.Image
 			container_status.State = "not started"
-			for _, container := range containers {
-				if _, ok := container.Labels[label]; ok {
-					cname := container.Names[0]
+			for _, c := range containers {
+				if _, ok := c.Labels[label]; ok {
+					cname := c.Names[0]
 					if cname == "/"+key+"-"+serviceName {
-						container_status.Name = container.Names[0]
-						container_status.Image = container.Image
-						container_status.Created = container.Created
-						container_status.State = container.State
+						container_status.Name = c.Names[0]
+						container_status.Image = c.Image
+						container_status.Created = c.Created
+						container_status.State = c.State
+						container_status.Health = container.GetContainerHealth(c.Status)
 						break
 					}
 				}
@@ -439,7 +440,7 @@ func changeInContainerStatuses(newContainers []persistence.ContainerStatus, oldC
 	for _, oldContainer := range oldContainers {
 		for _, newContainer := range newContainers {
 			if oldContainer.Name == newContainer.Name && oldContainer.Image == newContainer.Image && oldContainer.Created == newContainer.Created {
-				if oldContainer.State == newContainer.State {
+				if oldContainer.State == newContainer.State && oldContainer.Health == newContainer.Health {
 					matches++
 				} else {
 					return true
@@ -468,7 +469,7 @@ func convertToPersistenceType(workload []exchange.WorkloadStatus) []persistence.
 func converContainerStatusToPersistenceType(containers []exchange.ContainerStatus) []persistence.ContainerStatus {
 	persistentCStatuses := []persistence.ContainerStatus{}
 	for _, cStatus := range containers {
-		persistentCStatuses = append(persistentCStatuses, persistence.ContainerStatus{Name: cStatus.Name, Image: cStatus.Image, Created: cStatus.Created, State: cStatus.State})
+		persistentCStatuses = append(persistentCStatuses, persistence.ContainerStatus{Name: cStatus.Name, Image: cStatus.Image, Created: cStatus.Created, State: cStatus.State, Health: cStatus.Health})
 	}
 	return persistentCStatuses
 }
@@ -111,6 +111,19 @@ func Test_GetContainerStatus(t *testing.T) {
 
 	assert.Nil(t, err)
 	assert.True(t, statusArrayIsSame(exp_status, status), "The elements should be the same.")
+
+	// test workload containers with health checks
+	c1.Status = "Up 2 seconds (health: starting)"
+	c2.Status = "Up 10 minutes (unhealthy)"
+	containers = []docker.APIContainers{c1, c2, c3, c4}
+	deployment = "{\"services\":{\"netspeed5\":{\"image\":\"mycompany/x86/netspeed5:v2.5\"}, \"test\":{\"image\":\"mycompany/x86/test:v1.0\"}}}"
+	exp_status = []exchange.ContainerStatus{exchange.ContainerStatus{Name: "/aaaa-netspeed5", Image: "mycompany/x86/netspeed5:v2.5", Created: 1507728202, State: "running", Health: "starting"},
+		{Name: "/aaaa-test", Image: "mycompany/x86/test:v1.0", Created: 1507728356, State: "running", Health: "unhealthy"}}
+
+	status, err = GetContainerStatus(deployment, agreementId, false, containers)
+
+	assert.Nil(t, err)
+	assert.True(t, statusArrayIsSame(exp_status, status), "The elements should be the same.")
 }
 
 // Compare 2 ContainerStatus array contents without considering the order
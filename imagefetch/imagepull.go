@@ -8,6 +8,7 @@ import (
 	"github.com/open-horizon/anax/config"
 	"github.com/open-horizon/anax/containermessage"
 	"github.com/open-horizon/anax/cutil"
+	"github.com/open-horizon/anax/metrics"
 	"os"
 	"strings"
 	"time"
@@ -19,6 +20,12 @@ const (
 	maxPullAttempts = 3
 )
 
+const IMAGE_PULL_DURATION = "horizon_image_pull_duration_seconds"
+
+func init() {
+	metrics.DefaultRegistry.NewHistogram(IMAGE_PULL_DURATION, "Time taken to pull a service container image, by registry and result.", []float64{1, 5, 10, 30, 60, 120, 300, 600, 1200})
+}
+
 // read the given docker file and get the auths
 func dockerCredsFromConfigFile(configFilePath string) (*docker.AuthConfigurations, error) {
 
@@ -133,6 +140,7 @@ func pullImageFromRepos(config config.Config, authConfigs map[string][]docker.Au
 		}
 
 		// try auths one at a time
+		start := time.Now()
 		var err error
 		for i, auth := range auth_array {
 			err = pullSingleImageFromRepo(client, opts, auth)
@@ -150,9 +158,11 @@ func pullImageFromRepos(config config.Config, authConfigs map[string][]docker.Au
 		}
 
 		if err != nil {
+			metrics.ObserveDuration(IMAGE_PULL_DURATION, metrics.Labels{"registry": domain, "result": "failure"}, start)
 			glog.Errorf("Docker image pull(s) failed for docker image %v. Error: %v.", service.Image, err)
 			return err
 		} else {
+			metrics.ObserveDuration(IMAGE_PULL_DURATION, metrics.Labels{"registry": domain, "result": "success"}, start)
 			glog.V(3).Infof("Succeeded fetching image %v for service %v", service.Image, name)
 		}
 	}
@@ -9,8 +9,12 @@ import (
 	"github.com/open-horizon/anax/agreementbot"
 	agbotPersistence "github.com/open-horizon/anax/agreementbot/persistence"
 	_ "github.com/open-horizon/anax/agreementbot/persistence/bolt"
+	"github.com/open-horizon/anax/agreementbot/persistence/migrate"
 	_ "github.com/open-horizon/anax/agreementbot/persistence/postgresql"
+	_ "github.com/open-horizon/anax/agreementbot/persistence/sqlite"
 	agbotSecretsImpl "github.com/open-horizon/anax/agreementbot/secrets"
+	_ "github.com/open-horizon/anax/agreementbot/secrets/file"
+	_ "github.com/open-horizon/anax/agreementbot/secrets/httpsecrets"
 	_ "github.com/open-horizon/anax/agreementbot/secrets/vault"
 	"github.com/open-horizon/anax/api"
 	"github.com/open-horizon/anax/changes"
@@ -18,7 +22,9 @@ import (
 	"github.com/open-horizon/anax/config"
 	"github.com/open-horizon/anax/container"
 	"github.com/open-horizon/anax/download"
+	"github.com/open-horizon/anax/eventlog"
 	"github.com/open-horizon/anax/exchange"
+	_ "github.com/open-horizon/anax/externalpolicy/cel_language"
 	_ "github.com/open-horizon/anax/externalpolicy/text_language"
 	"github.com/open-horizon/anax/governance"
 	"github.com/open-horizon/anax/i18n"
@@ -46,6 +52,9 @@ import (
 func main() {
 	configFile := flag.String("config", "/etc/colonus/anax.config", "Config file location")
 	cpuprofile := flag.String("cpuprofile", "", "write cpu profile to file")
+	dbMigrate := flag.Bool("agbot-db-migrate", false, "Copy the agbot bolt database (AgreementBot.DBPath) into the configured Postgresql (or else Sqlite) database and exit. The agbot using the bolt database must be stopped first.")
+	migratePartition := flag.String("migrate-partition", "", "The Postgresql partition to migrate into. If omitted, a new or stale partition is used.")
+	migrateDryRun := flag.Bool("migrate-dry-run", false, "Read and count the records in the agbot bolt database without writing anything.")
 
 	flag.Parse()
 
@@ -72,6 +81,27 @@ func main() {
 	// eventlog messages.
 	i18n.InitMessagePrinter(true)
 
+	// Migrate the agbot database and exit, if requested.
+	if *dbMigrate {
+		var report *migrate.Report
+		if cfg.IsPostgresqlConfigured() || !cfg.IsSqliteConfigured() {
+			report, err = migrate.BoltToPostgresql(cfg, *migratePartition, *migrateDryRun)
+		} else {
+			report, err = migrate.BoltToSqlite(cfg, *migrateDryRun)
+		}
+		if report != nil {
+			fmt.Printf("%v\n", report)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Agbot database migration failed: %v\n", err)
+			os.Exit(1)
+		} else if !report.Verified() {
+			fmt.Fprintf(os.Stderr, "Agbot database migration verification failed.\n")
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
 	// open edge DB if necessary
 	var db *bolt.DB
 	if len(cfg.Edge.DBPath) != 0 {
@@ -163,6 +193,20 @@ func main() {
 	// Initialize the secrets manager to store secrets in the local db and in agent file system.
 	secretm := resource.NewSecretsManager(cfg.GetSecretsManagerFilePath(), db)
 
+	// Start removing old event logs, if a retention limit is configured.
+	if db != nil && cfg.IsEventLogRetentionConfigured() {
+		eventlog.StartCompactor(db, &cfg.Edge)
+	}
+
+	// Start forwarding event logs to syslog or a file, if configured.
+	if db != nil && cfg.IsEventLogForwarderConfigured() {
+		if fwd, err := eventlog.NewForwarder(db, &cfg.Edge.EventLogForwarder); err != nil {
+			glog.Errorf("Unable to start the event log forwarder, event logs will not be forwarded: %v", err)
+		} else {
+			fwd.Start()
+		}
+	}
+
 	// start workers
 	workers := worker.NewMessageHandlerRegistry()
 
@@ -0,0 +1,310 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// This package holds a small in-memory metrics registry that can be rendered in the Prometheus text exposition
+// format (which is also accepted by OpenMetrics scrapers). It is shared by the agent and the agbot so that both
+// can expose a /metrics endpoint without pulling a metrics client library into the runtime. Metric families are
+// declared once (usually in a package init()) and then updated from anywhere in the process.
+
+const CONTENT_TYPE = "text/plain; version=0.0.4; charset=utf-8"
+
+const COUNTER = "counter"
+const GAUGE = "gauge"
+const HISTOGRAM = "histogram"
+
+// Default histogram buckets, in seconds, suitable for timing network calls and handler invocations.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// The set of labels attached to a single time series. The ordering of the labels in the output is alphabetical.
+type Labels map[string]string
+
+func (l Labels) key() string {
+	names := make([]string, 0, len(l))
+	for n := range l {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for ix, n := range names {
+		if ix != 0 {
+			b.WriteString(",")
+		}
+		b.WriteString(n)
+		b.WriteString("=\"")
+		b.WriteString(escapeLabelValue(l[n]))
+		b.WriteString("\"")
+	}
+	return b.String()
+}
+
+// A single time series within a metric family.
+type series struct {
+	labels       string   // The pre-rendered label set, used as the key within the family.
+	value        float64  // The value of a counter or gauge.
+	bucketCounts []uint64 // Histograms only, the count of observations per bucket.
+	sum          float64  // Histograms only, the sum of all observations.
+	count        uint64   // Histograms only, the number of observations.
+}
+
+// A named metric and all of its time series.
+type family struct {
+	name    string
+	help    string
+	kind    string
+	buckets []float64
+	series  map[string]*series
+}
+
+type Registry struct {
+	lock     sync.Mutex
+	families map[string]*family
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		families: make(map[string]*family),
+	}
+}
+
+// The registry used by the runtime. Metric families are declared on this registry by the packages that own them.
+var DefaultRegistry = NewRegistry()
+
+func (r *Registry) NewCounter(name string, help string) {
+	r.declare(name, help, COUNTER, nil)
+}
+
+func (r *Registry) NewGauge(name string, help string) {
+	r.declare(name, help, GAUGE, nil)
+}
+
+func (r *Registry) NewHistogram(name string, help string, buckets []float64) {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	b := make([]float64, len(buckets))
+	copy(b, buckets)
+	sort.Float64s(b)
+	r.declare(name, help, HISTOGRAM, b)
+}
+
+func (r *Registry) declare(name string, help string, kind string, buckets []float64) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if _, ok := r.families[name]; !ok {
+		r.families[name] = &family{
+			name:    name,
+			help:    help,
+			kind:    kind,
+			buckets: buckets,
+			series:  make(map[string]*series),
+		}
+	}
+}
+
+// Return the series for the input labels, creating it if necessary. The caller must hold the registry lock.
+// A nil return means the metric family was never declared or was declared with a different kind.
+func (r *Registry) getSeries(name string, kind string, labels Labels) *series {
+	f, ok := r.families[name]
+	if !ok || f.kind != kind {
+		return nil
+	}
+	key := labels.key()
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labels: key}
+		if kind == HISTOGRAM {
+			s.bucketCounts = make([]uint64, len(f.buckets))
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+// Add the input value to a counter. Negative values are ignored because counters only go up.
+func (r *Registry) AddCounter(name string, labels Labels, v float64) {
+	if v < 0 {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if s := r.getSeries(name, COUNTER, labels); s != nil {
+		s.value += v
+	}
+}
+
+func (r *Registry) IncCounter(name string, labels Labels) {
+	r.AddCounter(name, labels, 1)
+}
+
+func (r *Registry) SetGauge(name string, labels Labels, v float64) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if s := r.getSeries(name, GAUGE, labels); s != nil {
+		s.value = v
+	}
+}
+
+func (r *Registry) AddGauge(name string, labels Labels, v float64) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if s := r.getSeries(name, GAUGE, labels); s != nil {
+		s.value += v
+	}
+}
+
+// Remove all the time series of a metric family. This is used for gauges that are recomputed from scratch each
+// time they are collected, so that series for objects which no longer exist are not reported forever.
+func (r *Registry) ResetFamily(name string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if f, ok := r.families[name]; ok {
+		f.series = make(map[string]*series)
+	}
+}
+
+func (r *Registry) Observe(name string, labels Labels, v float64) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	f, ok := r.families[name]
+	if !ok {
+		return
+	}
+	if s := r.getSeries(name, HISTOGRAM, labels); s != nil {
+		for ix, b := range f.buckets {
+			if v <= b {
+				s.bucketCounts[ix] += 1
+			}
+		}
+		s.sum += v
+		s.count += 1
+	}
+}
+
+// Record the elapsed time since start, in seconds, in a histogram.
+func (r *Registry) ObserveDuration(name string, labels Labels, start time.Time) {
+	r.Observe(name, labels, time.Since(start).Seconds())
+}
+
+// Return the current value of a counter or gauge, mostly useful for tests and status output.
+func (r *Registry) Value(name string, labels Labels) (float64, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if f, ok := r.families[name]; ok && f.kind != HISTOGRAM {
+		if s, ok := f.series[labels.key()]; ok {
+			return s.value, true
+		}
+	}
+	return 0, false
+}
+
+// Render all metric families in the text exposition format. Families and series are written in sorted order
+// so that the output is stable from one scrape to the next.
+func (r *Registry) Write(w io.Writer) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	names := make([]string, 0, len(r.families))
+	for n := range r.families {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	bw := bufio.NewWriter(w)
+	for _, n := range names {
+		f := r.families[n]
+		if len(f.series) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(bw, "# HELP %v %v\n", f.name, escapeHelp(f.help))
+		fmt.Fprintf(bw, "# TYPE %v %v\n", f.name, f.kind)
+
+		keys := make([]string, 0, len(f.series))
+		for k := range f.series {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			s := f.series[k]
+			if f.kind == HISTOGRAM {
+				for ix, b := range f.buckets {
+					fmt.Fprintf(bw, "%v_bucket{%v} %v\n", f.name, joinLabels(s.labels, "le=\""+formatFloat(b)+"\""), s.bucketCounts[ix])
+				}
+				fmt.Fprintf(bw, "%v_bucket{%v} %v\n", f.name, joinLabels(s.labels, "le=\"+Inf\""), s.count)
+				fmt.Fprintf(bw, "%v_sum%v %v\n", f.name, braces(s.labels), formatFloat(s.sum))
+				fmt.Fprintf(bw, "%v_count%v %v\n", f.name, braces(s.labels), s.count)
+			} else {
+				fmt.Fprintf(bw, "%v%v %v\n", f.name, braces(s.labels), formatFloat(s.value))
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+func joinLabels(labels string, extra string) string {
+	if labels == "" {
+		return extra
+	}
+	return labels + "," + extra
+}
+
+func braces(labels string) string {
+	if labels == "" {
+		return ""
+	}
+	return "{" + labels + "}"
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func escapeLabelValue(v string) string {
+	return strings.NewReplacer("\\", "\\\\", "\"", "\\\"", "\n", "\\n").Replace(v)
+}
+
+func escapeHelp(v string) string {
+	return strings.NewReplacer("\\", "\\\\", "\n", "\\n").Replace(v)
+}
+
+// Convenience wrappers that operate on the default registry.
+func IncCounter(name string, labels Labels) {
+	DefaultRegistry.IncCounter(name, labels)
+}
+
+func AddCounter(name string, labels Labels, v float64) {
+	DefaultRegistry.AddCounter(name, labels, v)
+}
+
+func SetGauge(name string, labels Labels, v float64) {
+	DefaultRegistry.SetGauge(name, labels, v)
+}
+
+func Observe(name string, labels Labels, v float64) {
+	DefaultRegistry.Observe(name, labels, v)
+}
+
+func ObserveDuration(name string, labels Labels, start time.Time) {
+	DefaultRegistry.ObserveDuration(name, labels, start)
+}
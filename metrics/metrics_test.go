@@ -0,0 +1,76 @@
+//go:build unit
+// +build unit
+
+package metrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func Test_Counter_Gauge(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounter("test_requests_total", "Number of requests.")
+	r.NewGauge("test_queue_depth", "Depth of the queue.")
+
+	r.IncCounter("test_requests_total", Labels{"method": "GET"})
+	r.AddCounter("test_requests_total", Labels{"method": "GET"}, 2)
+	r.AddCounter("test_requests_total", Labels{"method": "GET"}, -5)
+	r.SetGauge("test_queue_depth", Labels{"priority": "high"}, 7)
+	r.SetGauge("test_queue_depth", Labels{"priority": "high"}, 4)
+
+	// Undeclared metrics and kind mismatches are ignored.
+	r.IncCounter("test_unknown_total", nil)
+	r.SetGauge("test_requests_total", Labels{"method": "GET"}, 100)
+
+	if v, ok := r.Value("test_requests_total", Labels{"method": "GET"}); !ok || v != 3 {
+		t.Errorf("expected counter value 3, got %v %v", v, ok)
+	} else if v, ok := r.Value("test_queue_depth", Labels{"priority": "high"}); !ok || v != 4 {
+		t.Errorf("expected gauge value 4, got %v %v", v, ok)
+	} else if _, ok := r.Value("test_unknown_total", nil); ok {
+		t.Errorf("undeclared metric should not have a value")
+	}
+
+	r.ResetFamily("test_queue_depth")
+	if _, ok := r.Value("test_queue_depth", Labels{"priority": "high"}); ok {
+		t.Errorf("gauge should have been reset")
+	}
+}
+
+func Test_Write(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounter("test_requests_total", "Number of requests.")
+	r.NewGauge("test_empty", "Never set.")
+	r.NewHistogram("test_duration_seconds", "Request duration.", []float64{1, 0.1})
+
+	r.IncCounter("test_requests_total", Labels{"path": "a\"b", "method": "GET"})
+	r.Observe("test_duration_seconds", nil, 0.05)
+	r.Observe("test_duration_seconds", nil, 0.5)
+	r.Observe("test_duration_seconds", nil, 5)
+
+	buf := new(bytes.Buffer)
+	if err := r.Write(buf); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	out := buf.String()
+
+	expected := []string{
+		"# TYPE test_requests_total counter\n",
+		"test_requests_total{method=\"GET\",path=\"a\\\"b\"} 1\n",
+		"# TYPE test_duration_seconds histogram\n",
+		"test_duration_seconds_bucket{le=\"0.1\"} 1\n",
+		"test_duration_seconds_bucket{le=\"1\"} 2\n",
+		"test_duration_seconds_bucket{le=\"+Inf\"} 3\n",
+		"test_duration_seconds_sum 5.55\n",
+		"test_duration_seconds_count 3\n",
+	}
+	for _, e := range expected {
+		if !strings.Contains(out, e) {
+			t.Errorf("output is missing %v, output was:\n%v", e, out)
+		}
+	}
+	if strings.Contains(out, "test_empty") {
+		t.Errorf("families without series should not be written, output was:\n%v", out)
+	}
+}
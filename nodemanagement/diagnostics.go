@@ -0,0 +1,81 @@
+package nodemanagement
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/golang/glog"
+	"github.com/open-horizon/anax/cutil"
+	"github.com/open-horizon/anax/diagnostics"
+	"github.com/open-horizon/anax/eventlog"
+	"github.com/open-horizon/anax/persistence"
+	"github.com/open-horizon/anax/resource"
+)
+
+const DIAGNOSTICS_MONITOR = "DiagnosticsMonitor"
+
+// The number of seconds between checks for diagnostics requests received by the embedded ESS.
+const DIAGNOSTICS_CHECK_INTERVAL_S = 60
+
+// This is the function for a subworker that handles the diagnostics requests sent to the node. Like a node management
+// policy, a request is created in the management hub and picked up by the node: it is an MMS object of type
+// agent_diagnostics_request sent to the node, or to all the nodes of a destination type. The node creates a diagnostics
+// bundle for each new request and uploads it to the CSS through the embedded ESS, then marks the request consumed so
+// that the object status in the CSS shows which nodes have uploaded their bundle.
+func (w *NodeManagementWorker) checkDiagnosticsRequests() int {
+	if w.GetExchangeId() == "" || !resource.IsFileSyncServiceStarted() {
+		return 0
+	}
+
+	org, _ := cutil.SplitOrgSpecUrl(w.GetExchangeId())
+	requestIds, err := resource.GetReceivedObjectIds(org, diagnostics.DIAGNOSTICS_REQUEST_OBJECT_TYPE)
+	if err != nil {
+		glog.Errorf(nmwlog(fmt.Sprintf("Error checking for diagnostics requests: %v", err)))
+		return 0
+	}
+
+	for _, requestId := range requestIds {
+		w.handleDiagnosticsRequest(org, requestId)
+	}
+	return 0
+}
+
+func (w *NodeManagementWorker) handleDiagnosticsRequest(org string, requestId string) {
+	glog.Infof(nmwlog(fmt.Sprintf("Creating the diagnostics bundle for request %v", requestId)))
+
+	bundleId := diagnostics.BundleObjectId(w.GetExchangeId(), requestId)
+	description := fmt.Sprintf("Diagnostics bundle of node %v", w.GetExchangeId())
+
+	var bundle bytes.Buffer
+	if err := diagnostics.CreateBundle(&bundle, w.db, w.Config, w); err != nil {
+		w.logDiagnosticsEvent(persistence.SEVERITY_ERROR, persistence.NewMessageMeta(EL_DIAGNOSTICS_FAILED, requestId, err.Error()), persistence.EC_ERROR_NODE_DIAGNOSTICS)
+	} else if err := resource.PublishObject(org, diagnostics.DIAGNOSTICS_BUNDLE_OBJECT_TYPE, bundleId, description, bundle.Bytes()); err != nil {
+		w.logDiagnosticsEvent(persistence.SEVERITY_ERROR, persistence.NewMessageMeta(EL_DIAGNOSTICS_FAILED, requestId, err.Error()), persistence.EC_ERROR_NODE_DIAGNOSTICS)
+	} else {
+		w.logDiagnosticsEvent(persistence.SEVERITY_INFO, persistence.NewMessageMeta(EL_DIAGNOSTICS_UPLOADED, bundleId, requestId), persistence.EC_NODE_DIAGNOSTICS_UPLOADED)
+	}
+
+	// A request is handled once, even if the bundle could not be uploaded. The error is in the event log of the node,
+	// and a new request can be sent.
+	if err := resource.MarkObjectConsumed(org, diagnostics.DIAGNOSTICS_REQUEST_OBJECT_TYPE, requestId); err != nil {
+		glog.Errorf(nmwlog(fmt.Sprintf("Error marking diagnostics request %v consumed: %v", requestId, err)))
+	}
+}
+
+func (w *NodeManagementWorker) logDiagnosticsEvent(severity string, messageMeta *persistence.MessageMeta, eventCode string) {
+	org, nodeId := cutil.SplitOrgSpecUrl(w.GetExchangeId())
+	pattern := ""
+	configState := ""
+	if exchDev, err := persistence.FindExchangeDevice(w.db); err != nil {
+		glog.Errorf(nmwlog(fmt.Sprintf("Error getting device from database: %v", err)))
+	} else if exchDev != nil {
+		pattern = exchDev.Pattern
+		configState = exchDev.Config.State
+	}
+
+	if severity == persistence.SEVERITY_ERROR {
+		glog.Errorf(nmwlog(fmt.Sprintf(messageMeta.MessageKey, messageMeta.MessageArgs...)))
+	} else {
+		glog.Infof(nmwlog(fmt.Sprintf(messageMeta.MessageKey, messageMeta.MessageArgs...)))
+	}
+	eventlog.LogNodeEvent(w.db, severity, messageMeta, eventCode, nodeId, org, pattern, configState)
+}
@@ -8,6 +8,8 @@ const (
 	EL_NMP_STATUS_CREATED            = "New node management policy status created for policy %v."
 	EL_NMP_STATUS_CHANGED            = "Node management status for %v changed to %v."
 	EL_NMP_STATUS_CHANGED_WITH_ERROR = "Node management status for %v changed to %v. Error message: %v"
+	EL_DIAGNOSTICS_UPLOADED          = "Diagnostics bundle %v uploaded for request %v."
+	EL_DIAGNOSTICS_FAILED            = "Failed to upload the diagnostics bundle for request %v. Error message: %v"
 )
 
 // This is does nothing useful at run time.
@@ -20,4 +22,6 @@ func MarkI18nMessages() {
 
 	msgPrinter.Sprintf(EL_NMP_STATUS_CREATED)
 	msgPrinter.Sprintf(EL_NMP_STATUS_CHANGED)
+	msgPrinter.Sprintf(EL_DIAGNOSTICS_UPLOADED)
+	msgPrinter.Sprintf(EL_DIAGNOSTICS_FAILED)
 }
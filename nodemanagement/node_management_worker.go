@@ -49,6 +49,7 @@ func NewNodeManagementWorker(name string, config *config.HorizonConfig, db *bolt
 
 func (w *NodeManagementWorker) Initialize() bool {
 	w.DispatchSubworker(NMP_MONITOR, w.checkNMPTimeToRun, 60, false)
+	w.DispatchSubworker(DIAGNOSTICS_MONITOR, w.checkDiagnosticsRequests, DIAGNOSTICS_CHECK_INTERVAL_S, false)
 
 	if dev, _ := persistence.FindExchangeDevice(w.db); dev != nil && dev.Config.State == persistence.CONFIGSTATE_CONFIGURED {
 		// Node is registered. Check nmp's in exchange, statuses in db
@@ -611,7 +612,7 @@ func (n *NodeManagementWorker) CollectStatus(workingFolderPath string, policyNam
 				if contents.AgentUpgrade.ErrorMessage != "" {
 					status_string += fmt.Sprintf(", ErrorMessage: %v", contents.AgentUpgrade.ErrorMessage)
 				}
-				eventlog.LogNodeEvent(n.db, persistence.SEVERITY_INFO, persistence.NewMessageMeta(EL_NMP_STATUS_CHANGED, policyName, status_string), persistence.EC_NMP_STATUS_CHANGED, exchange.GetId(n.GetExchangeId()), exchange.GetOrg(n.GetExchangeId()), pattern, configState)
+				eventlog.LogNodeManagementEvent(n.db, persistence.SEVERITY_INFO, persistence.NewMessageMeta(EL_NMP_STATUS_CHANGED, policyName, status_string), persistence.EC_NMP_STATUS_CHANGED, exchange.GetId(n.GetExchangeId()), exchange.GetOrg(n.GetExchangeId()), pattern, configState, policyName)
 			}
 		}
 	}
@@ -630,7 +631,7 @@ func (n *NodeManagementWorker) UpdateStatus(policyName string, status *exchangec
 		pattern = exchDev.Pattern
 		configState = exchDev.Config.State
 	}
-	eventlog.LogNodeEvent(n.db, persistence.SEVERITY_INFO, eventLogMessageMeta, eventCode, nodeId, org, pattern, configState)
+	eventlog.LogNodeManagementEvent(n.db, persistence.SEVERITY_INFO, eventLogMessageMeta, eventCode, nodeId, org, pattern, configState, policyName)
 	if err := persistence.SaveOrUpdateNMPStatus(n.db, policyName, *status); err != nil {
 		return err
 	}
@@ -13,10 +13,18 @@ import (
 const CONTAINER_VOLUMES = "container_volumes"
 
 type ContainerVolume struct {
-	RecordId     string `json:"record_id"` // unique primary key for records
-	Name         string `json:"name"`
-	CreationTime uint64 `json:"creation_time"`
-	ArchiveTime  uint64 `json:"archive_time"`
+	RecordId      string `json:"record_id"` // unique primary key for records
+	Name          string `json:"name"`
+	CreationTime  uint64 `json:"creation_time"`
+	ArchiveTime   uint64 `json:"archive_time"`
+	ServiceName   string `json:"service_name,omitempty"`   // the container that mounts the volume
+	AgreementId   string `json:"agreement_id,omitempty"`   // the agreement or service instance that last used the volume
+	Driver        string `json:"driver,omitempty"`         // the docker volume driver
+	SizeMb        int64  `json:"size_mb,omitempty"`        // the size quota from the deployment string, 0 for none
+	QuotaEnforced bool   `json:"quota_enforced,omitempty"` // true if the driver limits the size of the volume to the quota
+	Retention     string `json:"retention,omitempty"`      // the retention policy from the deployment string, empty if the volume is not declared
+	ReleaseTime   uint64 `json:"release_time,omitempty"`   // the time the agreement using the volume ended, 0 while the volume is in use
+	ExpiryTime    uint64 `json:"expiry_time,omitempty"`    // the time after which a released volume is deleted, 0 for no expiry
 }
 
 func NewContainerVolume(name string) *ContainerVolume {
@@ -31,8 +39,21 @@ func (w ContainerVolume) String() string {
 	return fmt.Sprintf("RecordId: %v, "+
 		"Name: %v, "+
 		"CreationTime: %v, "+
-		"ArchiveTime: %v",
-		w.RecordId, w.Name, w.CreationTime, w.ArchiveTime)
+		"ArchiveTime: %v, "+
+		"ServiceName: %v, "+
+		"AgreementId: %v, "+
+		"Driver: %v, "+
+		"SizeMb: %v, "+
+		"QuotaEnforced: %v, "+
+		"Retention: %v, "+
+		"ReleaseTime: %v, "+
+		"ExpiryTime: %v",
+		w.RecordId, w.Name, w.CreationTime, w.ArchiveTime, w.ServiceName, w.AgreementId, w.Driver, w.SizeMb, w.QuotaEnforced, w.Retention, w.ReleaseTime, w.ExpiryTime)
+}
+
+// Return true if the agreement or service instance that used the volume has ended.
+func (w ContainerVolume) IsReleased() bool {
+	return w.ReleaseTime != 0
 }
 
 func (w ContainerVolume) ShortString() string {
@@ -73,6 +94,27 @@ func SaveContainerVolumeByName(db *bolt.DB, name string) error {
 	return SaveContainerVolume(db, pcv)
 }
 
+// Find the container volume with the input name that is not deleted yet, nil if there is none.
+func FindUndeletedContainerVolumeByName(db *bolt.DB, name string) (*ContainerVolume, error) {
+	if cvs, err := FindContainerVolumes(db, []ContainerVolumeFilter{UnarchivedCVFilter(), NameCVFilter(name)}); err != nil {
+		return nil, err
+	} else if len(cvs) == 0 {
+		return nil, nil
+	} else {
+		return &cvs[0], nil
+	}
+}
+
+// Mark the given volume as released by its agreement, a released volume is deleted after the expiry time if there is one.
+func ReleaseContainerVolume(db *bolt.DB, cv *ContainerVolume, expiryTime uint64) error {
+	cv.ReleaseTime = uint64(time.Now().Unix())
+	cv.ExpiryTime = expiryTime
+	if err := SaveContainerVolume(db, cv); err != nil {
+		return fmt.Errorf("Failed to release the container volume %v. %v", cv.Name, err)
+	}
+	return nil
+}
+
 // Find the container volumes that are not deleted yet
 func FindAllUndeletedContainerVolumes(db *bolt.DB) ([]ContainerVolume, error) {
 	return FindContainerVolumes(db, []ContainerVolumeFilter{UnarchivedCVFilter()})
@@ -103,6 +145,33 @@ func NameCVFilter(name string) ContainerVolumeFilter {
 	return func(c ContainerVolume) bool { return c.Name == name }
 }
 
+// filter on a list of names
+func NamesCVFilter(names []string) ContainerVolumeFilter {
+	return func(c ContainerVolume) bool {
+		for _, name := range names {
+			if c.Name == name {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// filter on the agreement or service instance that uses the volume
+func AgreementCVFilter(agreementId string) ContainerVolumeFilter {
+	return func(c ContainerVolume) bool { return c.AgreementId == agreementId && c.ReleaseTime == 0 }
+}
+
+// filter on the volumes whose agreement has ended
+func ReleasedCVFilter() ContainerVolumeFilter {
+	return func(c ContainerVolume) bool { return c.ReleaseTime != 0 }
+}
+
+// filter on the released volumes whose expiry time has passed
+func ExpiredCVFilter(now uint64) ContainerVolumeFilter {
+	return func(c ContainerVolume) bool { return c.ReleaseTime != 0 && c.ExpiryTime != 0 && c.ExpiryTime <= now }
+}
+
 // find container volumes from the db for the given filters
 func FindContainerVolumes(db *bolt.DB, filters []ContainerVolumeFilter) ([]ContainerVolume, error) {
 	cvs := make([]ContainerVolume, 0)
@@ -0,0 +1,83 @@
+//go:build unit
+// +build unit
+
+package persistence
+
+import (
+	"testing"
+)
+
+func Test_ContainerVolume_release(t *testing.T) {
+
+	// Setup the DB for the UT environment
+	dir, db, err := utsetup()
+	if err != nil {
+		t.Errorf("Error setting up UT DB: %v", err)
+	}
+
+	defer cleanTestDir(dir)
+
+	for _, name := range []string{"vol1", "vol2", "vol3"} {
+		cv := NewContainerVolume(name)
+		cv.AgreementId = "ag1"
+		if err := SaveContainerVolume(db, cv); err != nil {
+			t.Errorf("Error saving volume %v: %v", name, err)
+		}
+	}
+
+	if cvs, err := FindContainerVolumes(db, []ContainerVolumeFilter{UnarchivedCVFilter(), AgreementCVFilter("ag1")}); err != nil {
+		t.Errorf("Error finding volumes: %v", err)
+	} else if len(cvs) != 3 {
+		t.Errorf("Expected 3 volumes used by ag1 but got %v", cvs)
+	}
+
+	// vol1 is kept for an hour, vol2 is kept until the node is unregistered
+	if cv, err := FindUndeletedContainerVolumeByName(db, "vol1"); err != nil || cv == nil {
+		t.Errorf("Error finding volume vol1: %v %v", cv, err)
+	} else if err := ReleaseContainerVolume(db, cv, 1000); err != nil {
+		t.Errorf("Error releasing volume vol1: %v", err)
+	}
+	if cv, err := FindUndeletedContainerVolumeByName(db, "vol2"); err != nil || cv == nil {
+		t.Errorf("Error finding volume vol2: %v %v", cv, err)
+	} else if err := ReleaseContainerVolume(db, cv, 0); err != nil {
+		t.Errorf("Error releasing volume vol2: %v", err)
+	}
+
+	if cvs, err := FindContainerVolumes(db, []ContainerVolumeFilter{UnarchivedCVFilter(), AgreementCVFilter("ag1")}); err != nil {
+		t.Errorf("Error finding volumes: %v", err)
+	} else if len(cvs) != 1 || cvs[0].Name != "vol3" {
+		t.Errorf("Expected vol3 used by ag1 but got %v", cvs)
+	}
+
+	if cvs, err := FindContainerVolumes(db, []ContainerVolumeFilter{UnarchivedCVFilter(), ReleasedCVFilter()}); err != nil {
+		t.Errorf("Error finding volumes: %v", err)
+	} else if len(cvs) != 2 || !cvs[0].IsReleased() || !cvs[1].IsReleased() {
+		t.Errorf("Expected 2 released volumes but got %v", cvs)
+	}
+
+	if cvs, err := FindContainerVolumes(db, []ContainerVolumeFilter{UnarchivedCVFilter(), ReleasedCVFilter(), NamesCVFilter([]string{"vol2", "vol3"})}); err != nil {
+		t.Errorf("Error finding volumes: %v", err)
+	} else if len(cvs) != 1 || cvs[0].Name != "vol2" {
+		t.Errorf("Expected the released volume vol2 but got %v", cvs)
+	}
+
+	if cvs, err := FindContainerVolumes(db, []ContainerVolumeFilter{UnarchivedCVFilter(), ExpiredCVFilter(999)}); err != nil {
+		t.Errorf("Error finding volumes: %v", err)
+	} else if len(cvs) != 0 {
+		t.Errorf("Expected no expired volumes but got %v", cvs)
+	}
+
+	if cvs, err := FindContainerVolumes(db, []ContainerVolumeFilter{UnarchivedCVFilter(), ExpiredCVFilter(1000)}); err != nil {
+		t.Errorf("Error finding volumes: %v", err)
+	} else if len(cvs) != 1 || cvs[0].Name != "vol1" {
+		t.Errorf("Expected vol1 to be expired but got %v", cvs)
+	} else if err := ArchiveContainerVolumes(db, &cvs[0]); err != nil {
+		t.Errorf("Error archiving volume vol1: %v", err)
+	}
+
+	if cv, err := FindUndeletedContainerVolumeByName(db, "vol1"); err != nil {
+		t.Errorf("Error finding volume vol1: %v", err)
+	} else if cv != nil {
+		t.Errorf("Volume vol1 should be archived but got %v", cv)
+	}
+}
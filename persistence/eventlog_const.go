@@ -49,6 +49,8 @@ const (
 	EC_NMP_STATUS_DOWNLOAD_FAILED     = "node_management_status_download_failed"
 	EC_NMP_STATUS_UPDATE_COMPLETE     = "node_management_status_update_complete"
 	EC_NMP_STATUS_CHANGED             = "node_management_status_changed"
+	EC_NODE_DIAGNOSTICS_UPLOADED      = "node_diagnostics_uploaded"
+	EC_ERROR_NODE_DIAGNOSTICS         = "error_node_diagnostics"
 
 	// node pattern
 	EC_NODE_PATTERN_CHANGED            = "node_pattern_changed"
@@ -0,0 +1,202 @@
+package persistence
+
+import (
+	"container/heap"
+	"encoding/json"
+	"fmt"
+	"github.com/boltdb/bolt"
+	"github.com/golang/glog"
+	"sort"
+	"strconv"
+)
+
+// The number of event logs deleted in each db transaction when the event log is compacted, so that the db is not
+// locked for a long time.
+const EVENT_LOG_COMPACT_BATCH = 500
+
+// A page of event logs, in record id order.
+type EventLogPage struct {
+	Offset     int  // The number of matching event logs to skip.
+	Limit      int  // The maximum number of event logs to return. 0 means no limit.
+	Descending bool // Return the newest event logs first.
+}
+
+func (p EventLogPage) String() string {
+	return fmt.Sprintf("Offset: %v, Limit: %v, Descending: %v", p.Offset, p.Limit, p.Descending)
+}
+
+type pagedEventLog struct {
+	id uint64
+	el EventLog
+}
+
+// A heap of event logs whose root is the event log that would be dropped first if the page is full.
+type eventLogHeap struct {
+	items      []pagedEventLog
+	descending bool
+}
+
+func (h eventLogHeap) Len() int {
+	return len(h.items)
+}
+
+func (h eventLogHeap) Less(i, j int) bool {
+	if h.descending {
+		return h.items[i].id < h.items[j].id
+	}
+	return h.items[i].id > h.items[j].id
+}
+
+func (h eventLogHeap) Swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+}
+
+func (h *eventLogHeap) Push(x interface{}) {
+	h.items = append(h.items, x.(pagedEventLog))
+}
+
+func (h *eventLogHeap) Pop() interface{} {
+	last := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return last
+}
+
+// Collects the event logs for a page. When the page has a limit, at most offset+limit event logs are held.
+type eventLogCollector struct {
+	page EventLogPage
+	keep int
+	logs eventLogHeap
+}
+
+func newEventLogCollector(page EventLogPage) *eventLogCollector {
+	keep := 0
+	if page.Limit > 0 {
+		keep = page.Offset + page.Limit
+	}
+	return &eventLogCollector{
+		page: page,
+		keep: keep,
+		logs: eventLogHeap{items: make([]pagedEventLog, 0), descending: page.Descending},
+	}
+}
+
+func (c *eventLogCollector) add(el EventLog) {
+	item := pagedEventLog{id: EventLogRecordId(el), el: el}
+	if c.keep == 0 {
+		c.logs.items = append(c.logs.items, item)
+	} else if c.logs.Len() < c.keep {
+		heap.Push(&c.logs, item)
+	} else if root := c.logs.items[0]; (c.page.Descending && item.id > root.id) || (!c.page.Descending && item.id < root.id) {
+		// The page is full and the new event log belongs in the page before the root, so it replaces the root.
+		c.logs.items[0] = item
+		heap.Fix(&c.logs, 0)
+	}
+}
+
+func (c *eventLogCollector) result() []EventLog {
+	items := c.logs.items
+	sort.Slice(items, func(i, j int) bool {
+		if c.page.Descending {
+			return items[i].id > items[j].id
+		}
+		return items[i].id < items[j].id
+	})
+
+	if c.page.Offset >= len(items) {
+		return make([]EventLog, 0)
+	}
+	items = items[c.page.Offset:]
+	if c.page.Limit > 0 && len(items) > c.page.Limit {
+		items = items[:c.page.Limit]
+	}
+
+	evlogs := make([]EventLog, 0, len(items))
+	for _, item := range items {
+		evlogs = append(evlogs, item.el)
+	}
+	return evlogs
+}
+
+// Remove old event logs from the db. Event logs with a timestamp before maxAgeTime are removed. Then, if there are more
+// than maxRecords event logs, the oldest are removed. A value of 0 disables each of these checks. Event logs that are
+// referenced by a surfaced error are never removed. Returns the number of event logs removed.
+func CompactEventLogs(db *bolt.DB, maxAgeTime uint64, maxRecords uint64) (int, error) {
+
+	// Only the record id and timestamp of each event log are held in memory.
+	type eventLogKey struct {
+		Id        string `json:"record_id"`
+		Timestamp uint64 `json:"timestamp"`
+	}
+
+	protected := make(map[string]bool)
+	if surfaceErrors, err := FindSurfaceErrors(db); err != nil {
+		return 0, err
+	} else {
+		for _, se := range surfaceErrors {
+			protected[se.Record_id] = true
+		}
+	}
+
+	type candidate struct {
+		key       string
+		id        uint64
+		timestamp uint64
+	}
+	candidates := make([]candidate, 0)
+	total := uint64(0)
+
+	readErr := db.View(func(tx *bolt.Tx) error {
+		if b := tx.Bucket([]byte(EVENT_LOGS)); b != nil {
+			return b.ForEach(func(k, v []byte) error {
+				total += 1
+				if protected[string(k)] {
+					return nil
+				}
+
+				var el eventLogKey
+				if err := json.Unmarshal(v, &el); err != nil {
+					glog.Errorf("Unable to deserialize event log db record %v. Error: %v", string(k), err)
+				}
+				id, _ := strconv.ParseUint(string(k), 10, 64)
+				candidates = append(candidates, candidate{key: string(k), id: id, timestamp: el.Timestamp})
+				return nil
+			})
+		}
+		return nil // end the transaction
+	})
+	if readErr != nil {
+		return 0, readErr
+	}
+
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].id < candidates[j].id })
+
+	remove := make([]string, 0)
+	for _, c := range candidates {
+		if (maxAgeTime != 0 && c.timestamp < maxAgeTime) || (maxRecords != 0 && total > maxRecords) {
+			remove = append(remove, c.key)
+			total -= 1
+		}
+	}
+
+	for start := 0; start < len(remove); start += EVENT_LOG_COMPACT_BATCH {
+		end := start + EVENT_LOG_COMPACT_BATCH
+		if end > len(remove) {
+			end = len(remove)
+		}
+		writeErr := db.Update(func(tx *bolt.Tx) error {
+			if b := tx.Bucket([]byte(EVENT_LOGS)); b != nil {
+				for _, key := range remove[start:end] {
+					if err := b.Delete([]byte(key)); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		})
+		if writeErr != nil {
+			return start, fmt.Errorf("Unable to delete event logs, error: %v", writeErr)
+		}
+	}
+
+	return len(remove), nil
+}
@@ -0,0 +1,91 @@
+package persistence
+
+import (
+	"fmt"
+	"github.com/boltdb/bolt"
+	"strconv"
+	"sync"
+)
+
+// table stores the record id of the last event log forwarded to syslog or a file
+const EVENT_LOG_FORWARDER = "event_log_forwarder"
+
+// The channel is closed and replaced each time an event log is saved, so that API handlers streaming the event log
+// and the event log forwarder can wait for new records instead of polling the db.
+var eventLogSavedLock sync.Mutex
+var eventLogSaved = make(chan struct{})
+
+// Returns a channel that is closed when the next event log is saved.
+func EventLogSaved() <-chan struct{} {
+	eventLogSavedLock.Lock()
+	defer eventLogSavedLock.Unlock()
+	return eventLogSaved
+}
+
+func notifyEventLogSaved() {
+	eventLogSavedLock.Lock()
+	defer eventLogSavedLock.Unlock()
+	close(eventLogSaved)
+	eventLogSaved = make(chan struct{})
+}
+
+// Event log record ids are sequence numbers saved as strings. Records that were saved before the record id was
+// assigned, or that have a malformed id, are treated as record 0.
+func EventLogRecordId(el EventLog) uint64 {
+	if id, err := strconv.ParseUint(el.Id, 10, 64); err == nil {
+		return id
+	}
+	return 0
+}
+
+// save the record id of the last event log that was forwarded.
+func SaveLastForwardedEventLog(db *bolt.DB, record_id uint64) error {
+	writeErr := db.Update(func(tx *bolt.Tx) error {
+		if bucket, err := tx.CreateBucketIfNotExists([]byte(EVENT_LOG_FORWARDER)); err != nil {
+			return err
+		} else {
+			return bucket.Put([]byte("lastforwarded"), []byte(strconv.FormatUint(record_id, 10)))
+		}
+	})
+
+	return writeErr
+}
+
+// Find the record id of the last event log that was forwarded. The second return value is false if no event log has
+// ever been forwarded.
+func GetLastForwardedEventLog(db *bolt.DB) (uint64, bool, error) {
+	var last uint64
+	found := false
+
+	readErr := db.View(func(tx *bolt.Tx) error {
+
+		if b := tx.Bucket([]byte(EVENT_LOG_FORWARDER)); b != nil {
+			if v := b.Get([]byte("lastforwarded")); v != nil {
+				if s, err := strconv.ParseUint(string(v[:]), 10, 64); err != nil {
+					return fmt.Errorf("Failed to convert the last forwarded event log record id %v into uint64, error: %v", v, err)
+				} else {
+					last = s
+					found = true
+				}
+			}
+		}
+
+		return nil // end the transaction
+	})
+
+	return last, found, readErr
+}
+
+// Returns the record id of the most recently saved event log, 0 if no event log has been saved.
+func GetLastEventLogRecordId(db *bolt.DB) (uint64, error) {
+	var last uint64
+
+	readErr := db.View(func(tx *bolt.Tx) error {
+		if b := tx.Bucket([]byte(EVENT_LOGS)); b != nil {
+			last = b.Sequence()
+		}
+		return nil // end the transaction
+	})
+
+	return last, readErr
+}
@@ -70,48 +70,40 @@ func (w AgreementEventSource) Matches(selectors map[string][]Selector) bool {
 		case "consumer_id":
 			attr = w.ConsumerId
 		case "dependent_services":
-			matches := false
+			attrs := make([]interface{}, 0, len(w.DependentServices))
 			for _, sp := range w.DependentServices {
-				if m, _, _ := MatchAttributeValue(cutil.FormOrgSpecUrl(sp.Url, sp.Org), s_vals); m {
-					matches = true
-					break
-				}
+				attrs = append(attrs, cutil.FormOrgSpecUrl(sp.Url, sp.Org))
 			}
-			if !matches {
+			if !MatchAttributeList(attrs, s_vals) {
 				return false
 			}
 			handle = false
 		case "dependent_services.url":
-			matches := false
+			attrs := make([]interface{}, 0, len(w.DependentServices))
 			for _, sp := range w.DependentServices {
-				if m, _, _ := MatchAttributeValue(sp.Url, s_vals); m {
-					matches = true
-					break
-				}
+				attrs = append(attrs, sp.Url)
 			}
-			if !matches {
+			if !MatchAttributeList(attrs, s_vals) {
 				return false
 			}
 			handle = false
 		case "service_url":
 			// service_url is no longer an attribute for AgreementEventSource,
 			// but we keep it here so that the selection can be made for both AgreementEventSource and ServiceEventSource in the eventlogs
+			attrs := []interface{}{w.RunningWorkload.URL}
 			for _, sp := range w.DependentServices {
-				if m, _, _ := MatchAttributeValue(sp.Url, s_vals); m {
-					handle = false
-					break
-				}
+				attrs = append(attrs, sp.Url)
 			}
-			attr = w.RunningWorkload.URL
+			if !MatchAttributeList(attrs, s_vals) {
+				return false
+			}
+			handle = false
 		case "dependent_services.organization":
-			matches := false
+			attrs := make([]interface{}, 0, len(w.DependentServices))
 			for _, sp := range w.DependentServices {
-				if m, _, _ := MatchAttributeValue(sp.Org, s_vals); m {
-					matches = true
-					break
-				}
+				attrs = append(attrs, sp.Org)
 			}
-			if !matches {
+			if !MatchAttributeList(attrs, s_vals) {
 				return false
 			}
 			handle = false
@@ -209,14 +201,11 @@ func (w ServiceEventSource) Matches(selectors map[string][]Selector) bool {
 		case "arch":
 			attr = w.Arch
 		case "agreement_id":
-			matches := false
+			attrs := make([]interface{}, 0, len(w.AssociatedAgreements))
 			for _, id1 := range w.AssociatedAgreements {
-				if m, _, _ := MatchAttributeValue(id1, s_vals); m {
-					matches = true
-					break
-				}
+				attrs = append(attrs, id1)
 			}
-			if !matches {
+			if !MatchAttributeList(attrs, s_vals) {
 				return false
 			}
 			handle = false
@@ -239,14 +228,16 @@ type NodeEventSource struct {
 	Org         string `json:"node_org"`
 	Pattern     string `json:"pattern"` // fprmat: pattern_org/pattern
 	ConfigState string `json:"config_state"`
+	NMPName     string `json:"nmp_name,omitempty"` // the node management policy, for node management events
 }
 
 func (w NodeEventSource) String() string {
 	return fmt.Sprintf("Id: %v, "+
 		"Org: %v, "+
 		"Pattern: %v, "+
-		"ConfigState: %v",
-		w.Id, w.Org, w.Pattern, w.ConfigState)
+		"ConfigState: %v, "+
+		"NMPName: %v",
+		w.Id, w.Org, w.Pattern, w.ConfigState, w.NMPName)
 }
 
 func (w NodeEventSource) ShortString() string {
@@ -263,6 +254,12 @@ func NewNodeEventSource(id string, org string, pattern string, state string) *No
 	return &source
 }
 
+func NewNodeManagementEventSource(id string, org string, pattern string, state string, nmp_name string) *NodeEventSource {
+	source := NewNodeEventSource(id, org, pattern, state)
+	source.NMPName = nmp_name
+	return source
+}
+
 func (w NodeEventSource) Matches(selectors map[string][]Selector) bool {
 	for s_attr, s_vals := range selectors {
 		handle := true
@@ -276,6 +273,8 @@ func (w NodeEventSource) Matches(selectors map[string][]Selector) bool {
 			attr = w.Pattern
 		case "config_state":
 			attr = w.ConfigState
+		case "nmp_name":
+			attr = w.NMPName
 		default:
 			return false // not tolerate wrong attribute name in the selector
 		}
@@ -111,6 +111,30 @@ func Test_ServiceEventSource_Matches(t *testing.T) {
 	assert.True(t, source4.Matches(sel5), "Test source4")
 }
 
+func Test_AgreementEventSource_Matches_negation(t *testing.T) {
+
+	sp11 := ServiceSpec{Url: "http://mycom.com", Org: "mycom"}
+	sp12 := ServiceSpec{Url: "http://service12.com", Org: "service12"}
+	sp21 := ServiceSpec{Url: "http://mycom21.com", Org: "mycom21"}
+
+	source1 := NewAgreementEventSource("agreement id 1", WorkloadInfo{"http://top1.com", "mycomp", "1.0.0", "amd64"}, []ServiceSpec{sp11, sp12}, "agbot1", "basic")
+	source2 := NewAgreementEventSource("agreement id 2", WorkloadInfo{"http://top2.com", "mycomp", "1.0.0", "amd64"}, []ServiceSpec{sp21}, "agbot2", "cs")
+
+	// none of the services is service12
+	sel1 := map[string][]Selector{"service_url": {{"!~", "service12"}}}
+	assert.False(t, source1.Matches(sel1), "Test source1")
+	assert.True(t, source2.Matches(sel1), "Test source2")
+
+	// the top level service is not a dependent service
+	sel2 := map[string][]Selector{"dependent_services.url": {{"!~", "top"}}}
+	assert.True(t, source1.Matches(sel2), "Test source1")
+	assert.True(t, source2.Matches(sel2), "Test source2")
+
+	sel3 := map[string][]Selector{"consumer_id": {{"|", []Selector{{"=", "agbot1"}, {"=", "agbot3"}}}}}
+	assert.True(t, source1.Matches(sel3), "Test source1")
+	assert.False(t, source2.Matches(sel3), "Test source2")
+}
+
 func Test_NodeEventSource_Matches(t *testing.T) {
 
 	source1 := NewNodeEventSource("node1", "mycomp1", "e2edev/pattern1", "unconfigured")
@@ -148,4 +172,11 @@ func Test_NodeEventSource_Matches(t *testing.T) {
 	assert.False(t, source2.Matches(sel5), "Test source2")
 	assert.True(t, source3.Matches(sel5), "Test source3")
 
+	source4 := NewNodeManagementEventSource("node4", "mycomp4", "", "configured", "mycomp4/upgrade-nmp")
+
+	sel6 := make(map[string][]Selector)
+	sel6["nmp_name"] = []Selector{{"~", "upgrade"}}
+	assert.False(t, source1.Matches(sel6), "Test source1")
+	assert.True(t, source4.Matches(sel6), "Test source4")
+
 }
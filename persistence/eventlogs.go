@@ -252,6 +252,10 @@ func SaveEventLog(db *bolt.DB, event_log *EventLog) error {
 		}
 	})
 
+	if writeErr == nil {
+		notifyEventLogSaved()
+	}
+
 	NewErrorLog(db, *event_log)
 	return writeErr
 }
@@ -412,10 +416,18 @@ func FindEventLogs(db *bolt.DB, filters []EventLogFilter) ([]EventLog, error) {
 // find event logs from the db for the given given selectors.
 // If all_logs is false, only the event logs for the current registration is returned.
 func FindEventLogsWithSelectors(db *bolt.DB, all_logs bool, selectors map[string][]Selector, msgPrinter *message.Printer) ([]EventLog, error) {
+	return FindEventLogsWithSelectorsPage(db, all_logs, selectors, EventLogPage{}, msgPrinter)
+}
+
+// find a page of event logs from the db for the given given selectors. The event logs are returned in record id order.
+// If all_logs is false, only the event logs for the current registration is returned.
+// Only the event logs in the page are kept in memory while the db is read, so a page with a limit can be read from
+// a large event log without reading the whole event log into memory.
+func FindEventLogsWithSelectorsPage(db *bolt.DB, all_logs bool, selectors map[string][]Selector, page EventLogPage, msgPrinter *message.Printer) ([]EventLog, error) {
 	// separate base selectors from the source selectors
 	base_selectors, source_selectors := GroupSelectors(selectors)
 
-	evlogs := make([]EventLog, 0)
+	evlogs := newEventLogCollector(page)
 
 	last_unreg := uint64(0)
 	if !all_logs {
@@ -455,7 +467,7 @@ func FindEventLogsWithSelectors(db *bolt.DB, all_logs bool, selectors map[string
 							pel := newEventLog1(el.Severity, el.Message, el.MessageMeta, el.EventCode, el.SourceType, *esrc)
 							pel.Id = el.Id
 							pel.Timestamp = el.Timestamp
-							evlogs = append(evlogs, *pel)
+							evlogs.add(*pel)
 						}
 					}
 				}
@@ -469,7 +481,7 @@ func FindEventLogsWithSelectors(db *bolt.DB, all_logs bool, selectors map[string
 	if readErr != nil {
 		return nil, readErr
 	} else {
-		return evlogs, nil
+		return evlogs.result(), nil
 	}
 }
 
@@ -512,13 +524,78 @@ func FindAllEventLogs(db *bolt.DB) ([]EventLog, error) {
 	}
 }
 
+// A selector is an operator and the value that an attribute is matched against. The operators are:
+//
+//	"=", "~" (contains), ">", "<", ">=" and "<="
+//	"|" the MatchValue is a []Selector, the attribute matches if it matches any of them
+//
+// Each operator can be prefixed with "!" to negate it, for example "!~" means does not contain.
 type Selector struct {
 	Op         string
 	MatchValue interface{}
 }
 
-// convert the given string to a selector
+// convert the given string to a selector. The string is an optional "!" which negates the selector, followed by one
+// or more alternatives separated by "|". Each alternative is an optional operator (~, >, <, >= or <=) and a value.
+// A value of the form now, now-<duration> or now+<duration> is a unix time, where the duration is a number followed
+// by s, m, h or d, for example now-1h. A literal "|" in a value, or a leading "!", is escaped with a backslash.
 func ConvertToSelectorType(s string) (*Selector, error) {
+
+	negate := false
+	if len(s) > 1 && s[0] == '!' {
+		negate = true
+		s = s[1:]
+	} else if strings.HasPrefix(s, "\\!") {
+		s = s[1:]
+	}
+
+	alts := splitSelectorAlternatives(s)
+
+	var sel *Selector
+	if len(alts) == 1 {
+		if a, err := convertToSingleSelector(alts[0]); err != nil {
+			return nil, err
+		} else {
+			sel = a
+		}
+	} else {
+		any := make([]Selector, 0, len(alts))
+		for _, alt := range alts {
+			if a, err := convertToSingleSelector(alt); err != nil {
+				return nil, err
+			} else {
+				any = append(any, *a)
+			}
+		}
+		sel = &Selector{Op: "|", MatchValue: any}
+	}
+
+	if negate {
+		sel.Op = "!" + sel.Op
+	}
+	return sel, nil
+}
+
+// Split a selector string into its alternatives, on each "|" that is not escaped.
+func splitSelectorAlternatives(s string) []string {
+	alts := make([]string, 0, 1)
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && s[i+1] == '|' {
+			b.WriteByte('|')
+			i++
+		} else if s[i] == '|' {
+			alts = append(alts, b.String())
+			b.Reset()
+		} else {
+			b.WriteByte(s[i])
+		}
+	}
+	return append(alts, b.String())
+}
+
+// convert a single alternative of a selector string to a selector.
+func convertToSingleSelector(s string) (*Selector, error) {
 	var a interface{}
 	op := "="
 	a = s
@@ -531,14 +608,24 @@ func ConvertToSelectorType(s string) (*Selector, error) {
 			a = s[1:]
 			handled = true
 		case '>', '<':
+			op = string(s[0])
+			v := s[1:]
+			if len(s) > 2 && s[1] == '=' {
+				op = s[:2]
+				v = s[2:]
+			}
+
 			// parse to float64 because it can represent any other number types such as int, uint, int64 etc.
-			if num, err := strconv.ParseFloat(s[1:], 64); err == nil {
-				op = string(s[0])
+			if num, err := strconv.ParseFloat(v, 64); err == nil {
 				a = num
+			} else if t, isTime, err := parseRelativeTime(v); isTime {
+				if err != nil {
+					return nil, err
+				}
+				a = t
 			} else {
 				// '>' and '<' will be used for string comparison.
-				op = string(s[0])
-				a = s[1:]
+				a = v
 			}
 			handled = true
 		}
@@ -551,12 +638,43 @@ func ConvertToSelectorType(s string) (*Selector, error) {
 			a = false
 		} else if num, err := strconv.ParseFloat(s, 64); err == nil { // a number
 			a = num
+		} else if t, isTime, err := parseRelativeTime(s); isTime && err == nil { // a time relative to now
+			a = t
 		}
 	}
 
 	return &Selector{Op: op, MatchValue: a}, nil
 }
 
+// Parse a time relative to the current time, such as now, now-1h or now+30m, into a unix time. The second return
+// value is false if the string is not a relative time at all.
+func parseRelativeTime(s string) (float64, bool, error) {
+	if s == "now" {
+		return float64(time.Now().Unix()), true, nil
+	} else if !strings.HasPrefix(s, "now-") && !strings.HasPrefix(s, "now+") {
+		return 0, false, nil
+	}
+
+	var offset time.Duration
+	d := s[4:]
+	if strings.HasSuffix(d, "d") {
+		if days, err := strconv.ParseFloat(strings.TrimSuffix(d, "d"), 64); err != nil {
+			return 0, true, fmt.Errorf("The duration %v in %v is not valid.", d, s)
+		} else {
+			offset = time.Duration(days * float64(24*time.Hour))
+		}
+	} else if dur, err := time.ParseDuration(d); err != nil {
+		return 0, true, fmt.Errorf("The duration %v in %v is not valid.", d, s)
+	} else {
+		offset = dur
+	}
+
+	if s[3] == '-' {
+		offset = -offset
+	}
+	return float64(time.Now().Add(offset).Unix()), true, nil
+}
+
 // convert the given http.Request.Form into map of Selectors
 func ConvertToSelectors(selections map[string][]string) (map[string][]Selector, error) {
 	s_map := make(map[string][]Selector)
@@ -612,99 +730,187 @@ func GroupSelectors(selectors map[string][]Selector) (map[string][]Selector, map
 // This function returns (match_or_not, handled_or_not, error)
 func MatchAttributeValue(attr interface{}, selectors []Selector) (bool, bool, error) {
 	for _, s := range selectors {
-		switch s.MatchValue.(type) {
-		case int, uint, int32, int64, uint64, float32, float64:
+		if m, handled, err := matchSelector(attr, s); !m || !handled || err != nil {
+			return false, handled, err
+		}
+	}
 
-			// convert the two parties into float64 because it can represent all types of numbers
-			var a_data, s_data float64
-			var err error
+	return true, true, nil
+}
 
-			if reflect.TypeOf(attr).Kind() != reflect.Float64 {
-				if a_data, err = strconv.ParseFloat(fmt.Sprintf("%v", attr), 64); err != nil {
-					return false, true, fmt.Errorf("Error converting %v to float64: %v", attr, err)
-				}
-			} else {
-				a_data = attr.(float64)
+// Check if the attribute matches a single selector. Returns (match_or_not, handled_or_not, error).
+func matchSelector(attr interface{}, s Selector) (bool, bool, error) {
+
+	// negation
+	if len(s.Op) > 1 && s.Op[0] == '!' {
+		m, handled, err := matchSelector(attr, Selector{Op: s.Op[1:], MatchValue: s.MatchValue})
+		if !handled || err != nil {
+			return false, handled, err
+		}
+		return !m, true, nil
+	}
+
+	// alternatives
+	if s.Op == "|" {
+		alts, ok := s.MatchValue.([]Selector)
+		if !ok {
+			return false, true, fmt.Errorf("Selector %v type miss match.", s)
+		}
+		for _, alt := range alts {
+			if m, handled, err := matchSelector(attr, alt); err != nil {
+				return false, handled, err
+			} else if m {
+				return true, true, nil
 			}
+		}
+		return false, true, nil
+	}
 
-			if reflect.TypeOf(s.MatchValue).Kind() != reflect.Float64 {
-				if s_data, err = strconv.ParseFloat(fmt.Sprintf("%v", s.MatchValue), 64); err != nil {
-					return false, true, fmt.Errorf("Error converting %v to float64: %v", s.MatchValue, err)
-				}
-			} else {
-				s_data = s.MatchValue.(float64)
+	switch s.MatchValue.(type) {
+	case int, uint, int32, int64, uint64, float32, float64:
+
+		// convert the two parties into float64 because it can represent all types of numbers
+		var a_data, s_data float64
+		var err error
+
+		if reflect.TypeOf(attr).Kind() != reflect.Float64 {
+			if a_data, err = strconv.ParseFloat(fmt.Sprintf("%v", attr), 64); err != nil {
+				return false, true, fmt.Errorf("Error converting %v to float64: %v", attr, err)
 			}
+		} else {
+			a_data = attr.(float64)
+		}
 
-			switch s.Op {
-			case "=":
-				if a_data != s_data {
-					return false, true, nil
-				}
-			case ">":
-				if a_data <= s_data {
-					return false, true, nil
-				}
-			case "<":
-				if a_data >= s_data {
-					return false, true, nil
-				}
-			default:
-				return false, true, fmt.Errorf("%v does not support operation: %v", reflect.TypeOf(attr).Kind(), s.Op)
+		if reflect.TypeOf(s.MatchValue).Kind() != reflect.Float64 {
+			if s_data, err = strconv.ParseFloat(fmt.Sprintf("%v", s.MatchValue), 64); err != nil {
+				return false, true, fmt.Errorf("Error converting %v to float64: %v", s.MatchValue, err)
 			}
+		} else {
+			s_data = s.MatchValue.(float64)
+		}
 
-		case string:
-			var a_data string
-			if reflect.TypeOf(attr).Kind() != reflect.String {
-				a_data = fmt.Sprintf("%v", attr)
-			} else {
-				a_data = attr.(string)
+		switch s.Op {
+		case "=":
+			if a_data != s_data {
+				return false, true, nil
+			}
+		case ">":
+			if a_data <= s_data {
+				return false, true, nil
 			}
+		case "<":
+			if a_data >= s_data {
+				return false, true, nil
+			}
+		case ">=":
+			if a_data < s_data {
+				return false, true, nil
+			}
+		case "<=":
+			if a_data > s_data {
+				return false, true, nil
+			}
+		default:
+			return false, true, fmt.Errorf("%v does not support operation: %v", reflect.TypeOf(attr).Kind(), s.Op)
+		}
 
-			switch s.Op {
-			case "=":
-				if a_data != s.MatchValue.(string) {
-					return false, true, nil
-				}
-			case "~":
-				if !strings.Contains(a_data, s.MatchValue.(string)) {
-					return false, true, nil
-				}
-			case ">":
-				if !(a_data > s.MatchValue.(string)) {
-					return false, true, nil
-				}
-			case "<":
-				if !(a_data < s.MatchValue.(string)) {
-					return false, true, nil
-				}
-			default:
-				return false, true, fmt.Errorf("%v does not support operation: %v", reflect.TypeOf(attr).Kind(), s.Op)
+	case string:
+		var a_data string
+		if reflect.TypeOf(attr).Kind() != reflect.String {
+			a_data = fmt.Sprintf("%v", attr)
+		} else {
+			a_data = attr.(string)
+		}
+
+		switch s.Op {
+		case "=":
+			if a_data != s.MatchValue.(string) {
+				return false, true, nil
+			}
+		case "~":
+			if !strings.Contains(a_data, s.MatchValue.(string)) {
+				return false, true, nil
 			}
+		case ">":
+			if !(a_data > s.MatchValue.(string)) {
+				return false, true, nil
+			}
+		case "<":
+			if !(a_data < s.MatchValue.(string)) {
+				return false, true, nil
+			}
+		case ">=":
+			if !(a_data >= s.MatchValue.(string)) {
+				return false, true, nil
+			}
+		case "<=":
+			if !(a_data <= s.MatchValue.(string)) {
+				return false, true, nil
+			}
+		default:
+			return false, true, fmt.Errorf("%v does not support operation: %v", reflect.TypeOf(attr).Kind(), s.Op)
+		}
 
+	case bool:
+		switch attr.(type) {
 		case bool:
-			switch attr.(type) {
-			case bool:
-				switch s.Op {
-				case "=":
-					if attr.(bool) != s.MatchValue.(bool) {
-						return false, true, nil
-					}
-				default:
-					return false, true, fmt.Errorf("Boolean does not support operation: %v", s.Op)
+			switch s.Op {
+			case "=":
+				if attr.(bool) != s.MatchValue.(bool) {
+					return false, true, nil
 				}
-
 			default:
-				return false, true, fmt.Errorf("Selector %v type miss match.", s)
+				return false, true, fmt.Errorf("Boolean does not support operation: %v", s.Op)
 			}
 
 		default:
-			return false, false, nil
+			return false, true, fmt.Errorf("Selector %v type miss match.", s)
 		}
+
+	default:
+		return false, false, nil
 	}
 
 	return true, true, nil
 }
 
+// Check if a list attribute, such as the dependent services of an agreement, matches the selectors. The attribute
+// matches if one of the items matches all the selectors that are not negated, and none of the items matches a
+// negated selector.
+func MatchAttributeList(attrs []interface{}, selectors []Selector) bool {
+	positive := make([]Selector, 0, len(selectors))
+	negative := make([]Selector, 0)
+	for _, s := range selectors {
+		if len(s.Op) > 1 && s.Op[0] == '!' {
+			negative = append(negative, Selector{Op: s.Op[1:], MatchValue: s.MatchValue})
+		} else {
+			positive = append(positive, s)
+		}
+	}
+
+	if len(positive) != 0 {
+		matches := false
+		for _, attr := range attrs {
+			if m, _, _ := MatchAttributeValue(attr, positive); m {
+				matches = true
+				break
+			}
+		}
+		if !matches {
+			return false
+		}
+	}
+
+	for _, s := range negative {
+		for _, attr := range attrs {
+			if m, _, _ := MatchAttributeValue(attr, []Selector{s}); m {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 // GetEventLogObject returns the full eventlog object associated with a given record id
 func GetEventLogObject(db *bolt.DB, msgPrinter *message.Printer, recordID string) EventLog {
 	if msgPrinter == nil {
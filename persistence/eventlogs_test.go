@@ -367,6 +367,80 @@ func Test_ConvertToSelectors(t *testing.T) {
 
 }
 
+func Test_ConvertToSelectors_extended(t *testing.T) {
+	selections := make(map[string][]string)
+	selections["severity"] = []string{"error|warning", "!info|~warn"}
+	selections["message"] = []string{"!~test", "a\\|b", "\\!x"}
+	selections["timestamp"] = []string{">=now-1h", "<=now", "<=5"}
+
+	before := time.Now().Unix()
+	selectors, err := ConvertToSelectors(selections)
+	assert.Nil(t, err, "Error should be nil")
+
+	assert.Equal(t, Selector{"|", []Selector{{"=", "error"}, {"=", "warning"}}}, selectors["severity"][0], "Check alternatives.")
+	assert.Equal(t, Selector{"!|", []Selector{{"=", "info"}, {"~", "warn"}}}, selectors["severity"][1], "Check negated alternatives.")
+
+	assert.Equal(t, Selector{"!~", "test"}, selectors["message"][0], "Check negation.")
+	assert.Equal(t, Selector{"=", "a|b"}, selectors["message"][1], "Check escaped alternative.")
+	assert.Equal(t, Selector{"=", "!x"}, selectors["message"][2], "Check escaped negation.")
+
+	assert.Equal(t, ">=", selectors["timestamp"][0].Op, "Check operator")
+	assert.InDelta(t, float64(before-3600), selectors["timestamp"][0].MatchValue, 2, "Check relative time.")
+	assert.Equal(t, "<=", selectors["timestamp"][1].Op, "Check operator")
+	assert.InDelta(t, float64(before), selectors["timestamp"][1].MatchValue, 2, "Check relative time.")
+	assert.Equal(t, Selector{"<=", float64(5)}, selectors["timestamp"][2], "Check number.")
+
+	if sel, err := ConvertToSelectorType(">now-1d"); err != nil {
+		t.Errorf("Error should be nil, was %v", err)
+	} else {
+		assert.InDelta(t, float64(before-86400), sel.MatchValue, 2, "Check relative time in days.")
+	}
+
+	_, err = ConvertToSelectorType(">=now-1x")
+	assert.NotNil(t, err, "Error should not be nil for an invalid duration.")
+
+	// a string that only looks like a relative time is a string when it is not compared
+	if sel, err := ConvertToSelectorType("now-playing"); err != nil {
+		t.Errorf("Error should be nil, was %v", err)
+	} else {
+		assert.Equal(t, Selector{"=", "now-playing"}, *sel, "Check literal string.")
+	}
+}
+
+func Test_MatchAttributeValue_extended(t *testing.T) {
+
+	matched, handled, err := MatchAttributeValue("error", []Selector{{"|", []Selector{{"=", "error"}, {"=", "warning"}}}})
+	assert.True(t, matched, "Should match")
+	assert.True(t, handled, "Should be handled")
+	assert.Nil(t, err, "Error should be nil")
+
+	matched, _, _ = MatchAttributeValue("info", []Selector{{"|", []Selector{{"=", "error"}, {"=", "warning"}}}})
+	assert.False(t, matched, "Should not match")
+
+	matched, _, _ = MatchAttributeValue("info", []Selector{{"!|", []Selector{{"=", "error"}, {"=", "warning"}}}})
+	assert.True(t, matched, "Should match")
+
+	matched, _, _ = MatchAttributeValue("This is a test.", []Selector{{"!~", "test"}})
+	assert.False(t, matched, "Should not match")
+
+	matched, _, _ = MatchAttributeValue("This is a test.", []Selector{{"!=", "test"}, {"~", "This"}})
+	assert.True(t, matched, "Should match")
+
+	matched, _, _ = MatchAttributeValue(uint64(100), []Selector{{">=", 100}, {"<=", float64(100)}})
+	assert.True(t, matched, "Should match")
+
+	matched, _, _ = MatchAttributeValue(uint64(99), []Selector{{">=", 100}})
+	assert.False(t, matched, "Should not match")
+
+	matched, _, _ = MatchAttributeValue("bbb", []Selector{{">=", "bbb"}, {"<=", "bbc"}})
+	assert.True(t, matched, "Should match")
+
+	assert.True(t, MatchAttributeList([]interface{}{"a1", "b1"}, []Selector{{"~", "a"}, {"!=", "c1"}}), "Should match")
+	assert.False(t, MatchAttributeList([]interface{}{"a1", "b1"}, []Selector{{"!=", "b1"}}), "Should not match")
+	assert.True(t, MatchAttributeList([]interface{}{}, []Selector{{"!=", "b1"}}), "Should match")
+	assert.False(t, MatchAttributeList([]interface{}{}, []Selector{{"=", "b1"}}), "Should not match")
+}
+
 func Test_Save_and_Get_LastUnregistrationTime(t *testing.T) {
 
 	dir, db, err := utsetup()
@@ -24,6 +24,7 @@ type ContainerStatus struct {
 	Image   string `json:"image"`
 	Created int64  `json:"created"`
 	State   string `json:"state"`
+	Health  string `json:"health,omitempty"`
 }
 
 // FindNodeStatus returns the node status currently in the local db
@@ -0,0 +1,95 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"github.com/open-horizon/anax/externalpolicy"
+	"github.com/open-horizon/anax/i18n"
+)
+
+// The weighted placement of a workload. Without it, the workload is placed on every node that is compatible with
+// the policy. With it, the compatible nodes are ranked by their score, which is the sum of the weights of the
+// preferences that the node properties satisfy, and the workload is placed on the nodes with the highest scores first.
+// When MaxNodes is set, the workload is placed on at most that many nodes.
+type Placement struct {
+	Preferences []Preference `json:"preferences,omitempty"` // soft constraints, a node that does not satisfy them can still get the workload
+	MaxNodes    int          `json:"maxNodes,omitempty"`    // the maximum number of nodes to place the workload on, 0 means no limit
+}
+
+func (p Placement) String() string {
+	return fmt.Sprintf("Preferences: %v, MaxNodes: %v", p.Preferences, p.MaxNodes)
+}
+
+type Preference struct {
+	Constraint string `json:"constraint"`       // a constraint expression on the node properties
+	Weight     int    `json:"weight,omitempty"` // added to the score of the nodes that satisfy the constraint, defaults to 1. A negative weight disfavors the nodes.
+}
+
+func (p Preference) String() string {
+	return fmt.Sprintf("Constraint: %v, Weight: %v", p.Constraint, p.Weight)
+}
+
+// Return the weight of the preference, taking the default into account.
+func (p Preference) GetWeight() int {
+	if p.Weight == 0 {
+		return 1
+	}
+	return p.Weight
+}
+
+func Placement_Factory(maxNodes int, prefs []Preference) *Placement {
+	p := new(Placement)
+	p.MaxNodes = maxNodes
+	p.Preferences = make([]Preference, len(prefs))
+	copy(p.Preferences, prefs)
+	return p
+}
+
+func (p *Placement) DeepCopy() *Placement {
+	if p == nil {
+		return nil
+	}
+	return Placement_Factory(p.MaxNodes, p.Preferences)
+}
+
+// Return true if the placement does not change how the workload is placed.
+func (p *Placement) IsEmpty() bool {
+	return p == nil || (len(p.Preferences) == 0 && p.MaxNodes == 0)
+}
+
+func (p *Placement) Validate() error {
+	// get message printer because this function is called by CLI
+	msgPrinter := i18n.GetMessagePrinter()
+
+	if p == nil {
+		return nil
+	} else if p.MaxNodes < 0 {
+		return errors.New(msgPrinter.Sprintf("maxNodes must not be negative."))
+	}
+
+	for _, pref := range p.Preferences {
+		ce := externalpolicy.ConstraintExpression{pref.Constraint}
+		if pref.Constraint == "" {
+			return errors.New(msgPrinter.Sprintf("The constraint of a placement preference is empty."))
+		} else if _, err := ce.Validate(); err != nil {
+			return errors.New(msgPrinter.Sprintf("The constraint %v of a placement preference is not valid: %v", pref.Constraint, err))
+		}
+	}
+	return nil
+}
+
+// Return the score of a node with the given properties.
+func (p *Placement) Score(props externalpolicy.PropertyList) int {
+	if p == nil {
+		return 0
+	}
+
+	score := 0
+	for _, pref := range p.Preferences {
+		ce := externalpolicy.ConstraintExpression{pref.Constraint}
+		if err := ce.IsSatisfiedBy(props); err == nil {
+			score += pref.GetWeight()
+		}
+	}
+	return score
+}
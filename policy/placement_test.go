@@ -0,0 +1,63 @@
+//go:build unit
+// +build unit
+
+package policy
+
+import (
+	"github.com/open-horizon/anax/externalpolicy"
+	"testing"
+)
+
+func Test_Placement_Validate(t *testing.T) {
+
+	var nilPlacement *Placement
+	if err := nilPlacement.Validate(); err != nil {
+		t.Errorf("A nil placement should be valid but got: %v", err)
+	} else if !nilPlacement.IsEmpty() {
+		t.Errorf("A nil placement should be empty")
+	}
+
+	good := Placement_Factory(3, []Preference{{Constraint: "gpu == true", Weight: 10}, {Constraint: "memory >= 2048"}})
+	if err := good.Validate(); err != nil {
+		t.Errorf("The placement %v should be valid but got: %v", good, err)
+	} else if good.IsEmpty() {
+		t.Errorf("The placement %v should not be empty", good)
+	}
+
+	bad := []*Placement{
+		Placement_Factory(-1, nil),
+		Placement_Factory(0, []Preference{{Constraint: ""}}),
+		Placement_Factory(0, []Preference{{Constraint: "gpu =="}}),
+	}
+	for _, p := range bad {
+		if err := p.Validate(); err == nil {
+			t.Errorf("The placement %v should not be valid", p)
+		}
+	}
+}
+
+func Test_Placement_Score(t *testing.T) {
+
+	p := Placement_Factory(0, []Preference{
+		{Constraint: "gpu == true", Weight: 10},
+		{Constraint: "memory >= 2048"},
+		{Constraint: "location == factory", Weight: -5},
+	})
+
+	props := externalpolicy.PropertyList{
+		*externalpolicy.Property_Factory("gpu", true),
+		*externalpolicy.Property_Factory("memory", float64(4096)),
+		*externalpolicy.Property_Factory("location", "factory"),
+	}
+	if score := p.Score(props); score != 6 {
+		t.Errorf("The score should be 6 but got %v", score)
+	}
+
+	if score := p.Score(externalpolicy.PropertyList{}); score != 0 {
+		t.Errorf("A node without properties should have score 0 but got %v", score)
+	}
+
+	if copied := p.DeepCopy(); copied == p || len(copied.Preferences) != 3 || copied.Preferences[0] != p.Preferences[0] {
+		t.Errorf("The copy %v should be the same as %v", copied, p)
+	}
+}
@@ -71,6 +71,9 @@ type Policy struct {
 	UserInput          []UserInput                         `json:"userInput,omitempty"`
 	SecretBinding      []exchangecommon.SecretBinding      `json:"secretBinding,omitempty"` // This structure has the servive secret name to secret provider name mappings
 	SecretDetails      []exchangecommon.SecretBinding      `json:"secretDetails,omitempty"` // This structure has the service secret name to secret details mappings
+	Placement          *Placement                          `json:"placement,omitempty"`     // The weighted placement of the workload on the compatible nodes
+	Rollout            *Rollout                            `json:"rollout,omitempty"`       // The staged rollout of new service versions
+	Maintenance        *exchangecommon.MaintenanceSchedule `json:"maintenance,omitempty"`   // When the service versions on the nodes can be changed
 }
 
 // These functions are used to create Policy objects. You can create the base object
@@ -130,6 +133,10 @@ func (self *Policy) DeepCopy() *Policy {
 		newPolicy.SecretDetails = append(newPolicy.SecretDetails, newSD)
 	}
 
+	newPolicy.Placement = self.Placement.DeepCopy()
+	newPolicy.Rollout = self.Rollout.DeepCopy()
+	newPolicy.Maintenance = self.Maintenance.DeepCopy()
+
 	return newPolicy
 }
 
@@ -505,6 +512,15 @@ func (self *Policy) String() string {
 	res += fmt.Sprintf("Data Verification: %v\n", self.DataVerify)
 	res += fmt.Sprintf("Node Health: %v\n", self.NodeH)
 	res += fmt.Sprintf("SecretBinding: %v\n", self.SecretBinding)
+	if self.Placement != nil {
+		res += fmt.Sprintf("Placement: %v\n", self.Placement)
+	}
+	if self.Rollout != nil {
+		res += fmt.Sprintf("Rollout: %v\n", self.Rollout)
+	}
+	if self.Maintenance != nil {
+		res += fmt.Sprintf("Maintenance: %v\n", self.Maintenance)
+	}
 
 	return res
 }
@@ -0,0 +1,59 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"github.com/open-horizon/anax/i18n"
+)
+
+// The default number of seconds the upgraded nodes are verified after each step of a rollout.
+const DEFAULT_ROLLOUT_VERIFICATION_S = 600
+
+// The staged rollout of a new service version. When a higher priority service version is added to a deployment policy,
+// the nodes that have an agreement with the policy are upgraded in steps of a percentage of the nodes. After each step,
+// the upgraded nodes are verified for a period of time. If they stay healthy and the service does not fail on them, the
+// next step starts, otherwise the upgraded nodes are rolled back to the previous version.
+type Rollout struct {
+	Percentage    int `json:"percentage"`                     // the percentage of the nodes upgraded in each step
+	VerificationS int `json:"verificationDuration,omitempty"` // the number of seconds the upgraded nodes are verified after each step
+}
+
+func (r Rollout) String() string {
+	return fmt.Sprintf("Percentage: %v, VerificationS: %v", r.Percentage, r.VerificationS)
+}
+
+func Rollout_Factory(percentage int, verificationS int) *Rollout {
+	r := new(Rollout)
+	r.Percentage = percentage
+	r.VerificationS = verificationS
+	return r
+}
+
+func (r *Rollout) DeepCopy() *Rollout {
+	if r == nil {
+		return nil
+	}
+	return Rollout_Factory(r.Percentage, r.VerificationS)
+}
+
+// Return the verification period of the rollout, taking the default into account.
+func (r *Rollout) GetVerificationS() int {
+	if r.VerificationS == 0 {
+		return DEFAULT_ROLLOUT_VERIFICATION_S
+	}
+	return r.VerificationS
+}
+
+func (r *Rollout) Validate() error {
+	// get message printer because this function is called by CLI
+	msgPrinter := i18n.GetMessagePrinter()
+
+	if r == nil {
+		return nil
+	} else if r.Percentage < 1 || r.Percentage > 100 {
+		return errors.New(msgPrinter.Sprintf("The rollout percentage must be between 1 and 100."))
+	} else if r.VerificationS < 0 {
+		return errors.New(msgPrinter.Sprintf("The rollout verificationDuration must not be negative."))
+	}
+	return nil
+}
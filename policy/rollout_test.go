@@ -0,0 +1,29 @@
+//go:build unit
+// +build unit
+
+package policy
+
+import (
+	"testing"
+)
+
+func Test_Rollout_Validate(t *testing.T) {
+
+	var nilRollout *Rollout
+	if err := nilRollout.Validate(); err != nil {
+		t.Errorf("A missing rollout should be valid but got: %v", err)
+	}
+
+	r := Rollout_Factory(10, 0)
+	if err := r.Validate(); err != nil {
+		t.Errorf("The rollout %v should be valid but got: %v", r, err)
+	} else if r.GetVerificationS() != DEFAULT_ROLLOUT_VERIFICATION_S {
+		t.Errorf("The default verification duration should be %v but got %v", DEFAULT_ROLLOUT_VERIFICATION_S, r.GetVerificationS())
+	}
+
+	for _, bad := range []*Rollout{Rollout_Factory(0, 60), Rollout_Factory(101, 60), Rollout_Factory(50, -1)} {
+		if err := bad.Validate(); err == nil {
+			t.Errorf("The rollout %v should not be valid", bad)
+		}
+	}
+}
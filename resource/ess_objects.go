@@ -0,0 +1,67 @@
+package resource
+
+import (
+	"errors"
+	"fmt"
+	"github.com/golang/glog"
+	"github.com/open-horizon/edge-sync-service/common"
+	"github.com/open-horizon/edge-sync-service/core/base"
+)
+
+// These functions give the agent itself access to the MMS objects of the embedded ESS, through the ESS API module
+// instead of the ESS REST API used by the services. They can only be used while the embedded ESS is started.
+
+// Return the ids of the objects of the input type that the embedded ESS has completely received from the CSS and that
+// have not been consumed yet.
+func GetReceivedObjectIds(org string, objectType string) ([]string, error) {
+	if !IsFileSyncServiceStarted() {
+		return nil, errors.New("the embedded ESS is not started")
+	}
+
+	metas, err := base.ListUpdatedObjects(org, objectType, false)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("unable to list the received objects of type %v, error %v", objectType, err))
+	}
+
+	ids := make([]string, 0, len(metas))
+	for _, meta := range metas {
+		if !meta.Deleted {
+			ids = append(ids, meta.ObjectID)
+		}
+	}
+	return ids, nil
+}
+
+// Tell the CSS that the agent is done with an object received by the embedded ESS, so that it is not returned again.
+func MarkObjectConsumed(org string, objectType string, objectId string) error {
+	if !IsFileSyncServiceStarted() {
+		return errors.New("the embedded ESS is not started")
+	}
+
+	if err := base.ObjectConsumed(org, objectType, objectId); err != nil {
+		return errors.New(fmt.Sprintf("unable to mark object %v/%v/%v consumed, error %v", org, objectType, objectId, err))
+	}
+	return nil
+}
+
+// Create an object in the embedded ESS. An object without a destination is sent to the CSS, where it can be downloaded
+// by the users of the org.
+func PublishObject(org string, objectType string, objectId string, description string, data []byte) error {
+	if !IsFileSyncServiceStarted() {
+		return errors.New("the embedded ESS is not started")
+	}
+
+	meta := common.MetaData{
+		ObjectID:    objectId,
+		ObjectType:  objectType,
+		DestOrgID:   org,
+		Description: description,
+	}
+
+	glog.V(3).Infof(rmLogString(fmt.Sprintf("publishing object %v/%v/%v, %v bytes", org, objectType, objectId, len(data))))
+
+	if err := base.UpdateObject(org, objectType, objectId, meta, data); err != nil {
+		return errors.New(fmt.Sprintf("unable to publish object %v/%v/%v, error %v", org, objectType, objectId, err))
+	}
+	return nil
+}
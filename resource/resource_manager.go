@@ -16,9 +16,19 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"sync/atomic"
 	"time"
 )
 
+// Set to 1 while the embedded ESS is running. This is kept outside of the ResourceManager because the ResourceManager
+// methods that start and stop the ESS do not have a pointer receiver.
+var fileSyncServiceStarted int32
+
+// Returns true if the embedded ESS has been started and has not been stopped.
+func IsFileSyncServiceStarted() bool {
+	return atomic.LoadInt32(&fileSyncServiceStarted) == 1
+}
+
 type ResourceManager struct {
 	config  *config.HorizonConfig
 	org     string
@@ -182,31 +192,32 @@ func (r ResourceManager) StartFileSyncServiceAndSecretsAPI(am *AuthenticationMan
 		os.Exit(98)
 	}
 
+	atomic.StoreInt32(&fileSyncServiceStarted, 1)
 	glog.V(3).Infof(rmLogString(fmt.Sprintf("ESS and Secrets API Started")))
 	return nil
 
 }
 
 func censorAndDumpConfig() {
-	toBeCensored := []*string{&common.Configuration.ServerCertificate, &common.Configuration.ServerKey,
-		&common.Configuration.HTTPCSSCACertificate,
-		&common.Configuration.MQTTUserName, &common.Configuration.MQTTPassword,
-		&common.Configuration.MQTTCACertificate, &common.Configuration.MQTTSSLCert, &common.Configuration.MQTTSSLKey,
-		&common.Configuration.MongoUsername, &common.Configuration.MongoPassword, &common.Configuration.MongoCACertificate}
-	backups := make([]string, len(toBeCensored))
-
-	for index, fieldPointer := range toBeCensored {
-		backups[index] = *fieldPointer
+	trace.Dump("Loaded configuration:", CensoredESSConfig())
+}
+
+// Return a copy of the embedded ESS config without the certificates, keys and credentials, so that it can be logged or
+// collected for diagnostics.
+func CensoredESSConfig() common.Config {
+	censored := common.Configuration
+	toBeCensored := []*string{&censored.ServerCertificate, &censored.ServerKey,
+		&censored.HTTPCSSCACertificate,
+		&censored.MQTTUserName, &censored.MQTTPassword,
+		&censored.MQTTCACertificate, &censored.MQTTSSLCert, &censored.MQTTSSLKey,
+		&censored.MongoUsername, &censored.MongoPassword, &censored.MongoCACertificate}
+
+	for _, fieldPointer := range toBeCensored {
 		if len(*fieldPointer) != 0 {
 			*fieldPointer = "<...>"
 		}
 	}
-
-	trace.Dump("Loaded configuration:", common.Configuration)
-
-	for index, fieldPointer := range toBeCensored {
-		*fieldPointer = backups[index]
-	}
+	return censored
 }
 
 func (r ResourceManager) StopFileSyncService() {
@@ -238,6 +249,8 @@ func (r ResourceManager) StopFileSyncService() {
 			}
 		}
 
+		atomic.StoreInt32(&fileSyncServiceStarted, 0)
+
 		// Complete the final steps of cleanup.
 		r.RemovePersistencePath()
 		glog.Infof(rmLogString(fmt.Sprintf("ESS Stopped")))
@@ -0,0 +1,19 @@
+package worker
+
+import (
+	"github.com/open-horizon/anax/metrics"
+)
+
+// Metrics recorded by the worker framework on behalf of every worker, and by the event dispatcher.
+const WORKER_COMMANDS = "horizon_worker_commands_total"
+const WORKER_COMMAND_DURATION = "horizon_worker_command_duration_seconds"
+const EVENT_MESSAGES = "horizon_event_messages_total"
+const EVENT_QUEUE_DEPTH = "horizon_event_queue_depth"
+
+func init() {
+	r := metrics.DefaultRegistry
+	r.NewCounter(WORKER_COMMANDS, "Number of commands handled by each worker, by worker and command type.")
+	r.NewHistogram(WORKER_COMMAND_DURATION, "Time spent in each worker's command handler.", nil)
+	r.NewCounter(EVENT_MESSAGES, "Number of event messages dispatched to the workers, by message type.")
+	r.NewGauge(EVENT_QUEUE_DEPTH, "Number of event messages waiting to be dispatched to the workers.")
+}
@@ -5,7 +5,9 @@ import (
 	"github.com/golang/glog"
 	"github.com/open-horizon/anax/config"
 	"github.com/open-horizon/anax/events"
+	"github.com/open-horizon/anax/metrics"
 	"runtime"
+	"strings"
 	"time"
 )
 
@@ -319,14 +321,26 @@ func (w *BaseWorker) internalCommandhandler(worker Worker, command Command) bool
 	}
 
 	// Handle domain specific commands
+	start := time.Now()
 	if handled := worker.CommandHandler(command); !handled {
 		glog.Errorf(cdLogString(fmt.Sprintf("%v received unknown command (%T): %v", w.GetName(), command, command)))
 	} else {
 		glog.V(2).Infof(cdLogString(fmt.Sprintf("%v handled command (%T)", w.GetName(), command)))
+		metrics.IncCounter(WORKER_COMMANDS, metrics.Labels{"worker": w.GetName(), "command": typeName(command)})
+		metrics.ObserveDuration(WORKER_COMMAND_DURATION, metrics.Labels{"worker": w.GetName()}, start)
 	}
 	return false
 }
 
+// Return the unqualified type name of a command or message, for use as a metric label.
+func typeName(v interface{}) string {
+	n := fmt.Sprintf("%T", v)
+	if ix := strings.LastIndex(n, "."); ix >= 0 {
+		n = n[ix+1:]
+	}
+	return n
+}
+
 // This function kicks off the go routine that the worker's logic runs in.
 func (w *BaseWorker) Start(worker Worker, noWorkInterval int) {
 
@@ -547,6 +561,8 @@ func eventHandler(incoming events.Message, workers *MessageHandlerRegistry) (str
 		return successMsg, nil
 	}
 
+	metrics.IncCounter(EVENT_MESSAGES, metrics.Labels{"type": typeName(incoming)})
+
 	// Dispatch the message to all workers
 	for name, worker := range workers.Handlers {
 		glog.V(5).Infof(mdLogString(fmt.Sprintf("Delivering message to %v", name)))
@@ -589,6 +605,7 @@ func (workers *MessageHandlerRegistry) ProcessEventMessages() {
 
 		// Grab messages that are outbound from the workers.
 		messageStream = mux(workers, messageStream)
+		metrics.SetGauge(EVENT_QUEUE_DEPTH, nil, float64(len(messageStream)))
 
 		// Process any new messages on the combined worker message queue.
 		done := false
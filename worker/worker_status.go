@@ -2,6 +2,7 @@ package worker
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 )
@@ -126,3 +127,18 @@ func (w *WorkerStatusManager) GetAllSubworkerStatus(name string) map[string]stri
 
 	return nil
 }
+
+// Get the names of the workers that have terminated or failed to initialize, in alphabetical order.
+func (w *WorkerStatusManager) GetTerminatedWorkers() []string {
+	w.ManagerLock.Lock()
+	defer w.ManagerLock.Unlock()
+
+	names := make([]string, 0)
+	for name, ws := range w.Workers {
+		if ws.Status == STATUS_TERMINATED || ws.Status == STATUS_INIT_FAILED {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
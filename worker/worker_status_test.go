@@ -22,6 +22,10 @@ func Test_WorkerStatus(t *testing.T) {
 	assert.Equal(t, STATUS_INITIALIZED, workerStatusManager.GetWorkerStatus("worker1"), "The status for worker1 should be "+STATUS_INITIALIZED)
 	assert.Equal(t, STATUS_INIT_FAILED, workerStatusManager.GetWorkerStatus("worker2"), "The status for worker2 should be "+STATUS_INIT_FAILED)
 	assert.Equal(t, "", workerStatusManager.GetWorkerStatus("worker3"), "The status for worker3 should be an empty string")
+	assert.Equal(t, []string{"worker2"}, workerStatusManager.GetTerminatedWorkers(), "Only worker2 should be terminated")
+
+	workerStatusManager.SetWorkerStatus("worker1", STATUS_TERMINATED)
+	assert.Equal(t, []string{"worker1", "worker2"}, workerStatusManager.GetTerminatedWorkers(), "Both workers should be terminated")
 }
 
 func Test_SubworkerStatus(t *testing.T) {